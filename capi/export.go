@@ -0,0 +1,159 @@
+// Package main provides a C API for merging JW Library backups, so
+// go-jwlm can be embedded into applications written in other languages.
+// Build the shared library and its header using
+// `go build -buildmode=c-shared -o libjwlm.so ./capi`.
+//
+// A merge is represented by a handle returned by jwlm_open. Functions
+// returning an int use -1 to indicate an error, which can be retrieved
+// with jwlm_last_error. Strings returned by the API must be freed
+// with jwlm_free.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"sync"
+	"unsafe"
+)
+
+var (
+	lastErrorMu sync.Mutex
+	lastError   error
+)
+
+func main() {}
+
+// jwlm_open imports the left and right backup and returns
+// a handle for merging them.
+//
+//export jwlm_open
+func jwlm_open(left *C.char, right *C.char) C.int {
+	handle, err := openSession(C.GoString(left), C.GoString(right))
+	if err != nil {
+		return fail(err)
+	}
+	return C.int(handle)
+}
+
+// jwlm_merge merges the backups. conflictSolver may be NULL or the
+// name of a solver for automatic conflict resolution. Returns 0 if the
+// merge has been finished and 1 if there are conflicts that need to
+// be resolved before calling jwlm_merge again.
+//
+//export jwlm_merge
+func jwlm_merge(handle C.int, conflictSolver *C.char) C.int {
+	s, err := getSession(int(handle))
+	if err != nil {
+		return fail(err)
+	}
+
+	solver := ""
+	if conflictSolver != nil {
+		solver = C.GoString(conflictSolver)
+	}
+	conflicts, err := s.merge(solver)
+	if err != nil {
+		return fail(err)
+	}
+	if conflicts {
+		return 1
+	}
+	return 0
+}
+
+// jwlm_conflicts returns the unresolved conflicts as a JSON array,
+// or NULL on error. catalogPath may point to a catalog.db that is used
+// for looking up the titles of publications.
+//
+//export jwlm_conflicts
+func jwlm_conflicts(handle C.int, catalogPath *C.char) *C.char {
+	s, err := getSession(int(handle))
+	if err != nil {
+		fail(err)
+		return nil
+	}
+
+	catalog := ""
+	if catalogPath != nil {
+		catalog = C.GoString(catalogPath)
+	}
+	jsn, err := s.mcw.PendingConflictsJSON(catalog)
+	if err != nil {
+		fail(err)
+		return nil
+	}
+	return C.CString(jsn)
+}
+
+// jwlm_resolve resolves the conflict with the given key by choosing
+// side, which is either "leftSide" or "rightSide".
+//
+//export jwlm_resolve
+func jwlm_resolve(handle C.int, key *C.char, side *C.char) C.int {
+	s, err := getSession(int(handle))
+	if err != nil {
+		return fail(err)
+	}
+	if err := s.mcw.SolveConflict(C.GoString(key), C.GoString(side)); err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+// jwlm_export exports the merged backup to filename.
+//
+//export jwlm_export
+func jwlm_export(handle C.int, filename *C.char) C.int {
+	s, err := getSession(int(handle))
+	if err != nil {
+		return fail(err)
+	}
+	if !s.finished() {
+		return fail(errors.New("Merge has not been finished yet"))
+	}
+	if err := s.dbw.ExportMerged(C.GoString(filename)); err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+// jwlm_close releases the resources of the given handle.
+//
+//export jwlm_close
+func jwlm_close(handle C.int) {
+	closeSession(int(handle))
+}
+
+// jwlm_last_error returns the message of the last error,
+// or NULL if there has been none.
+//
+//export jwlm_last_error
+func jwlm_last_error() *C.char {
+	lastErrorMu.Lock()
+	defer lastErrorMu.Unlock()
+
+	if lastError == nil {
+		return nil
+	}
+	return C.CString(lastError.Error())
+}
+
+// jwlm_free frees a string returned by the API.
+//
+//export jwlm_free
+func jwlm_free(str *C.char) {
+	C.free(unsafe.Pointer(str))
+}
+
+// fail stores err so it can be retrieved with jwlm_last_error
+func fail(err error) C.int {
+	lastErrorMu.Lock()
+	defer lastErrorMu.Unlock()
+
+	lastError = err
+	return -1
+}
@@ -0,0 +1,106 @@
+//go:build cgo
+// +build cgo
+
+package main
+
+import (
+	"sync"
+
+	"github.com/AndreasSko/go-jwlm/gomobile"
+	"github.com/pkg/errors"
+)
+
+// session holds the state of a merge of two backups between
+// calls of the C API
+type session struct {
+	dbw *gomobile.DatabaseWrapper
+	mcw *gomobile.MergeConflictsWrapper
+	// stage is the index of the next stage of the merge to execute
+	stage int
+}
+
+var (
+	sessionsMu  sync.Mutex
+	sessions    = map[int]*session{}
+	nextSession = 1
+)
+
+// openSession imports the given backups and registers a new session.
+// It returns the handle of the session.
+func openSession(left string, right string) (int, error) {
+	dbw := &gomobile.DatabaseWrapper{}
+	if err := dbw.ImportJWLBackup(left, "leftSide"); err != nil {
+		return 0, errors.Wrap(err, "Error while importing left backup")
+	}
+	if err := dbw.ImportJWLBackup(right, "rightSide"); err != nil {
+		return 0, errors.Wrap(err, "Error while importing right backup")
+	}
+	dbw.Init()
+
+	mcw := &gomobile.MergeConflictsWrapper{}
+	mcw.InitDBWrapper(dbw)
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	handle := nextSession
+	nextSession++
+	sessions[handle] = &session{dbw: dbw, mcw: mcw}
+
+	return handle, nil
+}
+
+// getSession returns the session with the given handle
+func getSession(handle int) (*session, error) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	s, ok := sessions[handle]
+	if !ok {
+		return nil, errors.Errorf("Session %d does not exist", handle)
+	}
+	return s, nil
+}
+
+// closeSession removes the session with the given handle
+func closeSession(handle int) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	delete(sessions, handle)
+}
+
+// merge executes the remaining stages of the merge. If a stage runs into
+// conflicts, it stops and returns true. After the conflicts have been
+// resolved, merge can be called again to continue.
+func (s *session) merge(conflictSolver string) (bool, error) {
+	stages := s.stages(conflictSolver)
+	for ; s.stage < len(stages); s.stage++ {
+		err := stages[s.stage]()
+		if err == nil {
+			continue
+		}
+		if _, ok := err.(gomobile.MergeConflictError); ok {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return false, nil
+}
+
+// finished indicates if all stages of the merge have been executed
+func (s *session) finished() bool {
+	return s.stage >= len(s.stages(""))
+}
+
+// stages returns the stages of a merge in the order they are executed
+func (s *session) stages(conflictSolver string) []func() error {
+	return []func() error{
+		s.dbw.MergeLocations,
+		func() error { return s.dbw.MergeBookmarks(conflictSolver, s.mcw) },
+		s.dbw.MergeTags,
+		func() error { return s.dbw.MergeUserMarkAndBlockRange(conflictSolver, s.mcw) },
+		func() error { return s.dbw.MergeNotes(conflictSolver, s.mcw) },
+		s.dbw.MergeTagMaps,
+	}
+}
@@ -0,0 +1,86 @@
+//go:build !windows && cgo
+// +build !windows,cgo
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var backupFile = filepath.Join("..", "model", "testdata", "backup.jwlibrary")
+
+func Test_session(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	_, err = openSession("wrongFile", backupFile)
+	assert.Error(t, err)
+
+	handle, err := openSession(backupFile, backupFile)
+	assert.NoError(t, err)
+	s, err := getSession(handle)
+	assert.NoError(t, err)
+	assert.False(t, s.finished())
+
+	conflicts, err := s.merge("")
+	assert.NoError(t, err)
+	assert.False(t, conflicts)
+	assert.True(t, s.finished())
+
+	merged := filepath.Join(tmp, "merged.jwlibrary")
+	assert.NoError(t, s.dbw.ExportMerged(merged))
+	expected := &model.Database{}
+	assert.NoError(t, expected.ImportJWLBackup(backupFile))
+	actual := &model.Database{}
+	assert.NoError(t, actual.ImportJWLBackup(merged))
+	assert.True(t, expected.Equals(actual))
+
+	closeSession(handle)
+	_, err = getSession(handle)
+	assert.Error(t, err)
+}
+
+func Test_session_conflicts(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := &model.Database{}
+	assert.NoError(t, db.ImportJWLBackup(backupFile))
+	db.Bookmark[1].Title = "Changed"
+	right := filepath.Join(tmp, "right.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(right))
+
+	handle, err := openSession(backupFile, right)
+	assert.NoError(t, err)
+	defer closeSession(handle)
+	s, err := getSession(handle)
+	assert.NoError(t, err)
+
+	conflicts, err := s.merge("")
+	assert.NoError(t, err)
+	assert.True(t, conflicts)
+	assert.False(t, s.finished())
+
+	conflict, err := s.mcw.NextConflict()
+	assert.NoError(t, err)
+	assert.NoError(t, s.mcw.SolveConflict(conflict.Key, "rightSide"))
+
+	conflicts, err = s.merge("")
+	assert.NoError(t, err)
+	assert.False(t, conflicts)
+	assert.True(t, s.finished())
+
+	merged := filepath.Join(tmp, "merged.jwlibrary")
+	assert.NoError(t, s.dbw.ExportMerged(merged))
+	actual := &model.Database{}
+	assert.NoError(t, actual.ImportJWLBackup(merged))
+	assert.True(t, db.Equals(actual))
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/export"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var analyticsCmd = &cobra.Command{
+	Use:   "analytics <backup>",
+	Short: "Compute statistics about the notes of a JW Library backup",
+	Long: `analytics imports the given .jwlibrary backup file and computes statistics
+about its notes, so you can keep track of your study habits:
+  - notes: the number of words of every note
+  - months: the number of notes per month they have been last modified in
+  - publications: the number of notes per publication and their
+    average number of words
+  - tags: the number of notes per tag and month
+As JSON, all tables are written at once. As CSV, the table to write
+is selected with --table.`,
+	Example: `go-jwlm analytics backup.jwlibrary > analytics.json
+go-jwlm analytics backup.jwlibrary --format csv --table months > months.csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		return analytics(filename, AnalyticsFormat, AnalyticsTable, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+// AnalyticsFormat represents the format the analytics should be written in
+var AnalyticsFormat string
+
+// AnalyticsTable represents the table of the analytics that should
+// be written if the format is CSV
+var AnalyticsTable string
+
+func analytics(filename string, format string, table string, stdio terminal.Stdio) error {
+	if format != "json" && format != "csv" {
+		return errors.Errorf("Analytics format %s is not supported", format)
+	}
+
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db := &model.Database{}
+	err = importDatabase(db, filename)
+	if err != nil {
+		return err
+	}
+
+	a := export.NoteAnalytics(db)
+	switch format {
+	case "json":
+		err = a.JSON(stdio.Out)
+	case "csv":
+		err = a.CSV(stdio.Out, table)
+	}
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(analyticsCmd)
+	analyticsCmd.Flags().StringVar(&AnalyticsFormat, "format", "json", "Format of the analytics (can be 'json' or 'csv')")
+	analyticsCmd.Flags().StringVar(&AnalyticsTable, "table", "notes", "Table to write as CSV (can be '"+strings.Join(export.AnalyticsTables, "', '")+"')")
+	analyticsCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
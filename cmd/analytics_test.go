@@ -0,0 +1,53 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_analytics(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "left.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(filename))
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString(`"notesPerMonth": [`)
+			assert.NoError(t, err)
+			_, err = c.ExpectString(`"tagsPerMonth": [`)
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, analytics(filename, "json", "notes", terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("title,notes,averageWords")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, analytics(filename, "csv", "publications", terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/export"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog <older-backup> <newer-backup>",
+	Short: "List the notes and highlights that changed between two backups",
+	Long: `changelog imports an older and a newer .jwlibrary backup file and lists
+the notes and highlights that have been added, changed, or removed since
+the older one, grouped by publication and Bible book. This shows what
+changed on your device since the last backup. The changelog can be rendered
+as Markdown or JSON.`,
+	Example: `go-jwlm changelog last-month.jwlibrary today.jwlibrary
+go-jwlm changelog last-month.jwlibrary today.jwlibrary --format json > changelog.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changelog(args[0], args[1], ChangelogFormat, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+// ChangelogFormat represents the format the changelog should be rendered in
+var ChangelogFormat string
+
+func changelog(olderFilename string, newerFilename string, format string, stdio terminal.Stdio) error {
+	if format != "markdown" && format != "json" {
+		return errors.Errorf("Changelog format %s is not supported", format)
+	}
+
+	olderFilename, cleanupOlder, err := localBackup(olderFilename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanupOlder()
+	newerFilename, cleanupNewer, err := localBackup(newerFilename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanupNewer()
+
+	older := &model.Database{}
+	if err := importDatabase(older, olderFilename); err != nil {
+		return err
+	}
+	newer := &model.Database{}
+	if err := importDatabase(newer, newerFilename); err != nil {
+		return err
+	}
+
+	c := export.NewChangelog(older, newer)
+	switch format {
+	case "markdown":
+		err = c.Markdown(stdio.Out)
+	case "json":
+		err = c.JSON(stdio.Out)
+	}
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+	changelogCmd.Flags().StringVar(&ChangelogFormat, "format", "markdown", "Format of the changelog (can be 'markdown' or 'json')")
+	changelogCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
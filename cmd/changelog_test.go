@@ -0,0 +1,55 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_changelog(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	emptyFilename := filepath.Join(tmp, "empty.jwlibrary")
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	assert.NoError(t, emptyDB.ExportJWLBackup(emptyFilename))
+	assert.NoError(t, leftDB.ExportJWLBackup(leftFilename))
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("# Changelog")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("- Added note")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, changelog(emptyFilename, leftFilename, "markdown", terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString(`"action": "removed"`)
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, changelog(leftFilename, emptyFilename, "json", terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+}
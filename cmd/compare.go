@@ -5,8 +5,8 @@ import (
 	"os"
 
 	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
 	"github.com/AndreasSko/go-jwlm/model"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -14,37 +14,51 @@ var compareCmd = &cobra.Command{
 	Use:     "compare <left-backup> <right-backup>",
 	Short:   "Compare two JW Library backup files to see if they are equal",
 	Example: `go-jwlm compare left.jwlibrary right.jwlibrary`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		leftFilename := args[0]
 		rightFilename := args[1]
-		compare(leftFilename, rightFilename, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+		return compare(leftFilename, rightFilename, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
 	},
 	Args: cobra.ExactArgs(2),
 }
 
-func compare(leftFilename string, rightFilename string, stdio terminal.Stdio) {
-	fmt.Fprintln(stdio.Out, "Importing left backup")
+func compare(leftFilename string, rightFilename string, stdio terminal.Stdio) error {
+	leftFilename, cleanupLeft, err := localBackup(leftFilename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanupLeft()
+	rightFilename, cleanupRight, err := localBackup(rightFilename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanupRight()
+
+	fmt.Fprintln(stdio.Out, i18n.T("Importing left backup"))
 	left := &model.Database{}
-	err := left.ImportJWLBackup(leftFilename)
+	err = importDatabase(left, leftFilename)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	fmt.Fprintln(stdio.Out, "Importing right backup")
+	fmt.Fprintln(stdio.Out, i18n.T("Importing right backup"))
 	right := &model.Database{}
-	err = right.ImportJWLBackup(rightFilename)
+	err = importDatabase(right, rightFilename)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	equal := left.Equals(right)
 	if equal {
-		fmt.Fprintln(stdio.Out, "✅ Backups are equal")
+		fmt.Fprintln(stdio.Out, "✅ "+i18n.T("Backups are equal"))
 	} else {
-		fmt.Fprintln(stdio.Out, "❌ Backups are NOT equal")
+		fmt.Fprintln(stdio.Out, "❌ "+i18n.T("Backups are NOT equal"))
 	}
+
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(compareCmd)
+	compareCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
 }
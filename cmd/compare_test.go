@@ -1,3 +1,4 @@
+//go:build !windows
 // +build !windows
 
 package cmd
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
 	expect "github.com/Netflix/go-expect"
 	"github.com/tj/assert"
 )
@@ -36,7 +38,7 @@ func Test_compare(t *testing.T) {
 			assert.NoError(t, err)
 		},
 		func(t *testing.T, c *expect.Console) {
-			compare(leftFilename, emptyFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()})
+			assert.NoError(t, compare(leftFilename, emptyFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
 			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
 		})
 
@@ -48,7 +50,7 @@ func Test_compare(t *testing.T) {
 			assert.NoError(t, err)
 		},
 		func(t *testing.T, c *expect.Console) {
-			compare(leftFilename, leftFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()})
+			assert.NoError(t, compare(leftFilename, leftFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
 			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
 		})
 
@@ -60,7 +62,33 @@ func Test_compare(t *testing.T) {
 			assert.NoError(t, err)
 		},
 		func(t *testing.T, c *expect.Console) {
-			compare(rightFilename, rightFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()})
+			assert.NoError(t, compare(rightFilename, rightFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+}
+
+func Test_compareLocalized(t *testing.T) {
+	assert.NoError(t, i18n.SetLanguage("de"))
+	defer i18n.SetLanguage("en")
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(leftFilename))
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Linkes Backup wird importiert")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("✅ Backups sind gleich")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, compare(leftFilename, leftFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
 			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
 		})
 }
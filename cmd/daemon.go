@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/schedule"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run merges on a schedule",
+	Long: `daemon runs the merge jobs of the given YAML configuration on a schedule,
+which is given as cron expression (like "0 3 * * *" for every night at 3am).
+Conflicts are solved using resolvers, which default to chooseLeft for
+bookmarks and markings and to chooseNewest for notes. Every run stores the
+merged backup together with a merge report and the output of the merge in
+a folder named after the job within the target folder:
+
+  target: /srv/jwlm
+  jobs:
+    - name: family
+      schedule: "0 3 * * *"
+      left: /srv/backups/phone.jwlibrary
+      right: /srv/backups/tablet.jwlibrary
+      notes: chooseRight
+      args: ["--history"]
+
+Backups can also be given as URLs or on remote storages (see merge).
+Additional flags of the merge command can be set using args.`,
+	Example: `go-jwlm daemon --config sched.yaml
+go-jwlm daemon --config sched.yaml --once`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return daemon(DaemonConfigFile, DaemonOnce, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.NoArgs,
+}
+
+// DaemonConfigFile is the YAML file containing the jobs of the daemon
+var DaemonConfigFile string
+
+// DaemonOnce runs every job once instead of following their schedule
+var DaemonOnce bool
+
+// daemonConfig is the configuration of the daemon command
+type daemonConfig struct {
+	Target string      `yaml:"target"`
+	Jobs   []daemonJob `yaml:"jobs"`
+}
+
+// daemonJob is a merge the daemon runs on a schedule
+type daemonJob struct {
+	Name      string   `yaml:"name"`
+	Schedule  string   `yaml:"schedule"`
+	Left      string   `yaml:"left"`
+	Right     string   `yaml:"right"`
+	Bookmarks string   `yaml:"bookmarks"`
+	Markings  string   `yaml:"markings"`
+	Notes     string   `yaml:"notes"`
+	Args      []string `yaml:"args"`
+
+	schedule *schedule.Schedule
+}
+
+// daemonJobName restricts job names, as they are used as folder names
+var daemonJobName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// runMergeCommand runs go-jwlm with the given arguments in a separate
+// process, so a failing merge does not stop the daemon
+var runMergeCommand = func(args []string, output io.Writer) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	return cmd.Run()
+}
+
+func daemon(configFile string, once bool, stdio terminal.Stdio) error {
+	config, err := readDaemonConfig(configFile)
+	if err != nil {
+		return err
+	}
+
+	if once {
+		for _, job := range config.Jobs {
+			runDaemonJob(job, config.Target, time.Now(), stdio.Out)
+		}
+		return nil
+	}
+
+	for {
+		jobs, next := nextDaemonJobs(config.Jobs, time.Now())
+		if next.IsZero() {
+			return errors.New("None of the jobs is scheduled to run again")
+		}
+		for _, job := range jobs {
+			fmt.Fprintln(stdio.Out, i18n.T("Next run of %s at %s", job.Name, next.Format("2006-01-02 15:04")))
+		}
+		time.Sleep(time.Until(next))
+
+		for _, job := range jobs {
+			runDaemonJob(job, config.Target, next, stdio.Out)
+		}
+	}
+}
+
+// readDaemonConfig reads and validates the configuration of the daemon.
+// Resolvers that are not set are filled in with their defaults.
+func readDaemonConfig(filename string) (*daemonConfig, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while reading daemon configuration")
+	}
+	config := &daemonConfig{}
+	if err := yaml.UnmarshalStrict(content, config); err != nil {
+		return nil, errors.Wrapf(err, "Error while parsing daemon configuration %s", filename)
+	}
+
+	if config.Target == "" {
+		return nil, errors.New("The daemon configuration needs a target folder")
+	}
+	if len(config.Jobs) == 0 {
+		return nil, errors.New("The daemon configuration does not contain any jobs")
+	}
+	names := map[string]bool{}
+	for i := range config.Jobs {
+		job := &config.Jobs[i]
+		if !daemonJobName.MatchString(job.Name) {
+			return nil, errors.Errorf("Job name %q should only contain letters, digits, - and _", job.Name)
+		}
+		if names[job.Name] {
+			return nil, errors.Errorf("Job %s is defined twice", job.Name)
+		}
+		names[job.Name] = true
+
+		if job.Left == "" || job.Right == "" {
+			return nil, errors.Errorf("Job %s needs a left and a right backup", job.Name)
+		}
+		job.schedule, err = schedule.Parse(job.Schedule)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error while parsing schedule of job %s", job.Name)
+		}
+
+		if job.Bookmarks == "" {
+			job.Bookmarks = "chooseLeft"
+		}
+		if job.Markings == "" {
+			job.Markings = "chooseLeft"
+		}
+		if job.Notes == "" {
+			job.Notes = "chooseNewest"
+		}
+		for _, resolver := range []string{job.Bookmarks, job.Markings, job.Notes} {
+			if _, err := merger.AutoResolveConflicts(nil, resolver); err != nil {
+				return nil, errors.Wrapf(err, "Invalid resolver in job %s", job.Name)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// nextDaemonJobs returns the jobs that run next after now,
+// together with the time they are scheduled for
+func nextDaemonJobs(jobs []daemonJob, now time.Time) ([]daemonJob, time.Time) {
+	var next time.Time
+	var result []daemonJob
+	for _, job := range jobs {
+		t := job.schedule.Next(now)
+		switch {
+		case t.IsZero():
+		case next.IsZero() || t.Before(next):
+			next = t
+			result = []daemonJob{job}
+		case t.Equal(next):
+			result = append(result, job)
+		}
+	}
+	return result, next
+}
+
+// runDaemonJob merges the backups of the job into a timestamped backup
+// within the folder of the job, next to a merge report and a log of the
+// merge. Failures are reported, but don't stop the daemon.
+func runDaemonJob(job daemonJob, target string, now time.Time, w io.Writer) {
+	fmt.Fprintln(w, i18n.T("Running job %s", job.Name))
+
+	dir := filepath.Join(target, job.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintln(w, "❌ "+i18n.T("Job %s failed: %v", job.Name, err))
+		return
+	}
+	base := filepath.Join(dir, job.Name+"_"+now.Format(safetyBackupTimeFormat))
+
+	logFile, err := os.Create(base + ".log")
+	if err != nil {
+		fmt.Fprintln(w, "❌ "+i18n.T("Job %s failed: %v", job.Name, err))
+		return
+	}
+	defer logFile.Close()
+
+	args := []string{"merge", job.Left, job.Right, base + ".jwlibrary",
+		"--bookmarks", job.Bookmarks,
+		"--markings", job.Markings,
+		"--notes", job.Notes,
+		"--report", base + ".md",
+		"--no-backup",
+	}
+	args = append(args, job.Args...)
+	if err := runMergeCommand(args, logFile); err != nil {
+		fmt.Fprintln(w, "❌ "+i18n.T("Job %s failed: %v", job.Name, err)+" ("+logFile.Name()+")")
+		return
+	}
+
+	fmt.Fprintln(w, "🎉 "+i18n.T("Job %s stored the merged backup at %s", job.Name, base+".jwlibrary"))
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().StringVar(&DaemonConfigFile, "config", "", "YAML file containing the jobs to run")
+	daemonCmd.Flags().BoolVar(&DaemonOnce, "once", false, "Run every job once right away instead of following their schedule")
+	daemonCmd.MarkFlagRequired("config")
+}
@@ -0,0 +1,140 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/schedule"
+	"github.com/tj/assert"
+)
+
+func Test_readDaemonConfig(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "sched.yaml")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`target: /srv/jwlm
+jobs:
+  - name: family
+    schedule: "0 3 * * *"
+    left: left.jwlibrary
+    right: right.jwlibrary
+    notes: chooseRight
+    args: ["--history"]
+`), 0644))
+	config, err := readDaemonConfig(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "/srv/jwlm", config.Target)
+	assert.Len(t, config.Jobs, 1)
+	job := config.Jobs[0]
+	assert.Equal(t, "family", job.Name)
+	assert.Equal(t, "chooseLeft", job.Bookmarks)
+	assert.Equal(t, "chooseLeft", job.Markings)
+	assert.Equal(t, "chooseRight", job.Notes)
+	assert.Equal(t, []string{"--history"}, job.Args)
+	assert.NotNil(t, job.schedule)
+
+	invalid := []string{
+		"jobs: []",
+		"target: /srv\njobs: []",
+		"target: /srv\nunknown: true",
+		"target: /srv\njobs:\n  - {name: a b, schedule: '@daily', left: l, right: r}",
+		"target: /srv\njobs:\n  - {name: a, schedule: '@daily', left: l}",
+		"target: /srv\njobs:\n  - {name: a, schedule: '61 * * * *', left: l, right: r}",
+		"target: /srv\njobs:\n  - {name: a, schedule: '@daily', left: l, right: r, notes: chooseWisely}",
+		"target: /srv\njobs:\n  - {name: a, schedule: '@daily', left: l, right: r}\n  - {name: a, schedule: '@daily', left: l, right: r}",
+	}
+	for _, content := range invalid {
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+		_, err := readDaemonConfig(filename)
+		assert.Error(t, err, content)
+	}
+
+	_, err = readDaemonConfig(filepath.Join(tmp, "nonexistent.yaml"))
+	assert.Error(t, err)
+}
+
+func Test_nextDaemonJobs(t *testing.T) {
+	t.Parallel()
+
+	parse := func(expr string) *schedule.Schedule {
+		s, err := schedule.Parse(expr)
+		assert.NoError(t, err)
+		return s
+	}
+	jobs := []daemonJob{
+		{Name: "daily", schedule: parse("0 3 * * *")},
+		{Name: "hourly", schedule: parse("0 * * * *")},
+		{Name: "alsoHourly", schedule: parse("@hourly")},
+		{Name: "never", schedule: parse("0 0 30 2 *")},
+	}
+
+	now := time.Date(2021, 5, 7, 10, 30, 0, 0, time.UTC)
+	next, at := nextDaemonJobs(jobs, now)
+	assert.Equal(t, time.Date(2021, 5, 7, 11, 0, 0, 0, time.UTC), at)
+	assert.Equal(t, []string{"hourly", "alsoHourly"}, []string{next[0].Name, next[1].Name})
+
+	next, at = nextDaemonJobs(jobs[3:], now)
+	assert.Empty(t, next)
+	assert.True(t, at.IsZero())
+}
+
+// Test_runDaemonJob replaces runMergeCommand, so it must not run in parallel
+func Test_runDaemonJob(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	var calls [][]string
+	fail := false
+	defer func(orig func([]string, io.Writer) error) { runMergeCommand = orig }(runMergeCommand)
+	runMergeCommand = func(args []string, output io.Writer) error {
+		calls = append(calls, args)
+		fmt.Fprintln(output, "merge output")
+		if fail {
+			return errors.New("exit status 1")
+		}
+		return nil
+	}
+
+	job := daemonJob{
+		Name:      "family",
+		Left:      "left.jwlibrary",
+		Right:     "right.jwlibrary",
+		Bookmarks: "chooseLeft",
+		Markings:  "chooseRight",
+		Notes:     "chooseNewest",
+		Args:      []string{"--history"},
+	}
+	now := time.Date(2021, 5, 7, 3, 0, 0, 0, time.UTC)
+	base := filepath.Join(tmp, "family", "family_20210507-030000")
+
+	var out bytes.Buffer
+	runDaemonJob(job, tmp, now, &out)
+	assert.Equal(t, [][]string{{"merge", "left.jwlibrary", "right.jwlibrary", base + ".jwlibrary",
+		"--bookmarks", "chooseLeft", "--markings", "chooseRight", "--notes", "chooseNewest",
+		"--report", base + ".md", "--no-backup", "--history"}}, calls)
+	assert.Contains(t, out.String(), "Running job family")
+	assert.Contains(t, out.String(), "🎉 Job family stored the merged backup at "+base+".jwlibrary")
+	log, err := ioutil.ReadFile(base + ".log")
+	assert.NoError(t, err)
+	assert.Equal(t, "merge output\n", string(log))
+
+	out.Reset()
+	fail = true
+	runDaemonJob(job, tmp, now.Add(time.Hour), &out)
+	assert.Contains(t, out.String(), "❌ Job family failed: exit status 1")
+}
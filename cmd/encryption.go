@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/encryption"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/pkg/errors"
+)
+
+// passwordEnv is the environment variable that can contain the password
+// for encrypting and decrypting backups, so it doesn't have to be entered.
+const passwordEnv = "JWLM_PASSWORD"
+
+// EncryptPassword indicates if the merged backup should be encrypted with a password
+var EncryptPassword bool
+
+// EncryptRecipients contains the age public keys the merged backup should be encrypted for
+var EncryptRecipients []string
+
+// IdentityFile is the age identity file used for decrypting encrypted backups
+var IdentityFile string
+
+// localBackup returns the path of a local, decrypted copy of the given
+// backup (see stdinBackup, downloadRemoteBackup, and decryptBackup)
+// together with a function to remove the temporary files.
+func localBackup(filename string, stdio terminal.Stdio) (string, func(), error) {
+	filename, cleanupStdin, err := stdinBackup(filename, stdio.In)
+	if err != nil {
+		return "", nil, err
+	}
+	filename, cleanupDownload, err := downloadRemoteBackup(filename)
+	if err != nil {
+		cleanupStdin()
+		return "", nil, err
+	}
+	filename, cleanupDecrypted, err := decryptBackup(filename, stdio)
+	if err != nil {
+		cleanupDownload()
+		cleanupStdin()
+		return "", nil, err
+	}
+	return filename, func() {
+		cleanupDecrypted()
+		cleanupDownload()
+		cleanupStdin()
+	}, nil
+}
+
+// decryptBackup decrypts the given backup into a temporary directory if it
+// has been encrypted with age. Backups are decrypted using the keys in
+// IdentityFile or, if it is not set, using a password. The password is read
+// from JWLM_PASSWORD or asked for. It returns the path of the decrypted
+// backup together with a function to remove the temporary directory.
+func decryptBackup(filename string, stdio terminal.Stdio) (string, func(), error) {
+	encrypted, err := encryption.IsEncrypted(filename)
+	if err != nil {
+		return "", nil, ioError(err)
+	}
+	if !encrypted {
+		return filename, func() {}, nil
+	}
+
+	var identities []encryption.Identity
+	if IdentityFile != "" {
+		f, err := os.Open(IdentityFile)
+		if err != nil {
+			return "", nil, ioError(err)
+		}
+		identities, err = encryption.ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			return "", nil, err
+		}
+	} else {
+		password, err := askPassword(i18n.T("Password for %s:", filepath.Base(filename)), stdio)
+		if err != nil {
+			return "", nil, err
+		}
+		identities = append(identities, encryption.NewPasswordIdentity(password))
+	}
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return "", nil, ioError(err)
+	}
+	decrypted := filepath.Join(tmp, strings.TrimSuffix(filepath.Base(filename), ".age"))
+	if err := encryption.DecryptFile(filename, decrypted, identities...); err != nil {
+		os.RemoveAll(tmp)
+		return "", nil, invalidBackupError(errors.Wrapf(err, "Error while decrypting %s", filename))
+	}
+	return decrypted, func() { os.RemoveAll(tmp) }, nil
+}
+
+// encryptedDestination returns a local path that should be written to
+// instead of the given destination if the backup should be encrypted
+// (see EncryptPassword and EncryptRecipients). After writing, the returned
+// function encrypts the file to the destination and removes the
+// unencrypted one. Otherwise, the path is returned unchanged.
+func encryptedDestination(dest string, stdio terminal.Stdio) (string, func() error, error) {
+	if !EncryptPassword && len(EncryptRecipients) == 0 {
+		return dest, func() error { return nil }, nil
+	}
+	if EncryptPassword && len(EncryptRecipients) > 0 {
+		return "", nil, errors.New("A password can not be combined with age recipients")
+	}
+
+	var recipients []encryption.Recipient
+	for _, r := range EncryptRecipients {
+		recipient, err := encryption.ParseRecipient(r)
+		if err != nil {
+			return "", nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	if EncryptPassword {
+		password, err := askPassword(i18n.T("Password for encrypting the merged backup:"), stdio)
+		if err != nil {
+			return "", nil, err
+		}
+		recipient, err := encryption.NewPasswordRecipient(password)
+		if err != nil {
+			return "", nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return "", nil, ioError(err)
+	}
+	local := filepath.Join(tmp, filepath.Base(dest))
+	return local, func() error {
+		err := encryption.EncryptFile(local, dest, recipients...)
+		os.RemoveAll(tmp)
+		return ioError(err)
+	}, nil
+}
+
+// askPassword returns the password set in JWLM_PASSWORD
+// or asks the user for it otherwise.
+func askPassword(message string, stdio terminal.Stdio) (string, error) {
+	if password := os.Getenv(passwordEnv); password != "" {
+		return password, nil
+	}
+
+	if Plain {
+		return askPlainPassword(message, stdio)
+	}
+
+	var password string
+	err := survey.AskOne(&survey.Password{Message: message}, &password,
+		survey.WithStdio(stdio.In, stdio.Out, stdio.Err), survey.WithValidator(survey.Required))
+	if err == terminal.InterruptErr {
+		return "", errors.New(i18n.T("interrupted"))
+	} else if err != nil {
+		return "", errors.Wrap(err, "Error while asking for the password")
+	}
+	return password, nil
+}
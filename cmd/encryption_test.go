@@ -0,0 +1,157 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/encryption"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_mergeEncrypted(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	emptyFilename := filepath.Join(tmp, "empty.jwlibrary")
+	mergedFilename := filepath.Join(tmp, "merged.jwlibrary.age")
+	assert.NoError(t, leftDB.ExportJWLBackup(leftFilename))
+	assert.NoError(t, emptyDB.ExportJWLBackup(emptyFilename))
+
+	EncryptPassword = true
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Password for encrypting the merged backup:")
+			assert.NoError(t, err)
+			c.SendLine("secret")
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, merge(leftFilename, emptyFilename, mergedFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+	EncryptPassword = false
+
+	encrypted, err := encryption.IsEncrypted(mergedFilename)
+	assert.NoError(t, err)
+	assert.True(t, encrypted)
+	decryptedFilename := filepath.Join(tmp, "decrypted.jwlibrary")
+	assert.NoError(t, encryption.DecryptFile(mergedFilename, decryptedFilename, encryption.NewPasswordIdentity("secret")))
+	mergedDB := &model.Database{}
+	assert.NoError(t, mergedDB.ImportJWLBackup(decryptedFilename))
+	assert.True(t, leftDB.Equals(mergedDB))
+
+	// Encrypted inputs are decrypted transparently
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Password for merged.jwlibrary.age:")
+			assert.NoError(t, err)
+			c.SendLine("secret")
+			_, err = c.ExpectString("✅ Backups are equal")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, compare(mergedFilename, leftFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+}
+
+func Test_mergeEncryptedRecipient(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	emptyFilename := filepath.Join(tmp, "empty.jwlibrary")
+	mergedFilename := filepath.Join(tmp, "merged.jwlibrary.age")
+	assert.NoError(t, leftDB.ExportJWLBackup(leftFilename))
+	assert.NoError(t, emptyDB.ExportJWLBackup(emptyFilename))
+
+	identity, err := encryption.GenerateX25519Identity()
+	assert.NoError(t, err)
+	identityFilename := filepath.Join(tmp, "key.txt")
+	assert.NoError(t, ioutil.WriteFile(identityFilename, []byte("# public key: "+identity.Recipient().String()+"\n"+identity.String()+"\n"), 0600))
+
+	EncryptRecipients = []string{identity.Recipient().String()}
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Finished merging!")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, merge(leftFilename, emptyFilename, mergedFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+	EncryptRecipients = nil
+
+	IdentityFile = identityFilename
+	defer func() { IdentityFile = "" }()
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("✅ Backups are equal")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, compare(mergedFilename, leftFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+}
+
+func Test_localBackup(t *testing.T) {
+	os.Setenv(passwordEnv, "secret")
+	defer os.Unsetenv(passwordEnv)
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	plain := filepath.Join(tmp, "backup.jwlibrary")
+	assert.NoError(t, ioutil.WriteFile(plain, []byte("backup"), 0644))
+	filename, cleanup, err := localBackup(plain, terminal.Stdio{})
+	assert.NoError(t, err)
+	assert.Equal(t, plain, filename)
+	cleanup()
+
+	recipient, err := encryption.NewPasswordRecipient("secret")
+	assert.NoError(t, err)
+	encrypted := filepath.Join(tmp, "backup.jwlibrary.age")
+	assert.NoError(t, encryption.EncryptFile(plain, encrypted, recipient))
+
+	filename, cleanup, err = localBackup(encrypted, terminal.Stdio{})
+	assert.NoError(t, err)
+	assert.Equal(t, "backup.jwlibrary", filepath.Base(filename))
+	content, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "backup", string(content))
+	cleanup()
+	_, err = os.Stat(filename)
+	assert.True(t, os.IsNotExist(err))
+
+	dest, encrypt, err := encryptedDestination("merged.jwlibrary", terminal.Stdio{})
+	assert.NoError(t, err)
+	assert.Equal(t, "merged.jwlibrary", dest)
+	assert.NoError(t, encrypt())
+
+	_, _, err = localBackup(filepath.Join(tmp, "missing.jwlibrary.age"), terminal.Stdio{})
+	assert.Equal(t, ExitIO, ExitCode(err))
+	assert.NoError(t, ioutil.WriteFile(encrypted, []byte("age-encryption.org/v1\ndamaged"), 0644))
+	_, _, err = localBackup(encrypted, terminal.Stdio{})
+	assert.Equal(t, ExitInvalidBackup, ExitCode(err))
+}
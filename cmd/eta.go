@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/pkg/errors"
+)
+
+// minRemaining is the shortest remaining time of a merge that is
+// printed, so small libraries are not cluttered with estimates
+const minRemaining = 10 * time.Second
+
+// throughputFile stores the throughput of the merge stages measured by
+// previous runs (see merger.Throughput), so the remaining time of a merge
+// can be estimated more accurately. It is empty if there is no cache
+// directory.
+var throughputFile = defaultThroughputFile()
+
+// defaultThroughputFile returns the path of the throughput
+// file within the cache directory of the user.
+func defaultThroughputFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-jwlm", "throughput.json")
+}
+
+// loadThroughput restores the throughput of the merge stages from the
+// given file. A missing file is not an error, as it is created with the
+// first merge.
+func loadThroughput(filename string) error {
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error while reading throughput")
+	}
+
+	throughput := map[string]float64{}
+	if err := json.Unmarshal(content, &throughput); err != nil {
+		return errors.Wrapf(err, "Error while parsing %s", filename)
+	}
+	merger.SetThroughput(throughput)
+	return nil
+}
+
+// saveThroughput stores the current throughput of the
+// merge stages in the given file.
+func saveThroughput(filename string) error {
+	content, err := json.MarshalIndent(merger.Throughput(), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Error while encoding throughput")
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return errors.Wrap(err, "Error while creating cache directory")
+	}
+	if err := ioutil.WriteFile(filename, content, 0644); err != nil {
+		return errors.Wrap(err, "Error while writing throughput")
+	}
+	return nil
+}
+
+// formatRemaining describes the estimated remaining time of a merge,
+// like "About 40 seconds remaining". It returns an empty string if the
+// merge is expected to finish within minRemaining.
+func formatRemaining(remaining time.Duration) string {
+	switch {
+	case remaining < minRemaining:
+		return ""
+	case remaining < 2*time.Minute:
+		return i18n.T("About %d seconds remaining", int(remaining.Round(time.Second)/time.Second))
+	default:
+		return i18n.T("About %d minutes remaining", int(remaining.Round(time.Minute)/time.Minute))
+	}
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/tj/assert"
+)
+
+func Test_loadSaveThroughput(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	orig := merger.Throughput()
+	defer merger.SetThroughput(orig)
+
+	filename := filepath.Join(tmp, "cache", "throughput.json")
+	assert.NoError(t, loadThroughput(filename))
+	assert.Equal(t, orig, merger.Throughput())
+
+	merger.SetThroughput(map[string]float64{merger.StageNotes: 42})
+	assert.NoError(t, saveThroughput(filename))
+	merger.SetThroughput(orig)
+	assert.NoError(t, loadThroughput(filename))
+	assert.Equal(t, 42.0, merger.Throughput()[merger.StageNotes])
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("no json"), 0644))
+	assert.Error(t, loadThroughput(filename))
+}
+
+func Test_formatRemaining(t *testing.T) {
+	assert.Equal(t, "", formatRemaining(9*time.Second))
+	assert.Equal(t, "About 40 seconds remaining", formatRemaining(39600*time.Millisecond))
+	assert.Equal(t, "About 3 minutes remaining", formatRemaining(170*time.Second))
+}
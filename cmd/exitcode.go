@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// Exit codes of go-jwlm, so scripts can react to the cause of an error
+const (
+	// ExitFailure is used for all errors without a more specific exit
+	// code, like invalid flags
+	ExitFailure = 1
+	// ExitInvalidBackup is used if a backup is damaged or not supported
+	ExitInvalidBackup = 2
+	// ExitUnresolvedConflicts is used if a merge stopped because its
+	// conflicts could not be solved, e.g. because the prompt was interrupted
+	ExitUnresolvedConflicts = 3
+	// ExitIO is used if reading or writing a file failed
+	ExitIO = 4
+)
+
+// exitError is an error with the exit code go-jwlm should exit with
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e exitError) Unwrap() error {
+	return e.err
+}
+
+// invalidBackupError marks err as caused by an invalid backup. If the
+// backup could not be read at all, it is marked as IO error instead.
+func invalidBackupError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if isIOError(err) {
+		return exitError{code: ExitIO, err: err}
+	}
+	return exitError{code: ExitInvalidBackup, err: err}
+}
+
+// conflictError marks err as caused by conflicts that could not be solved
+func conflictError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return exitError{code: ExitUnresolvedConflicts, err: err}
+}
+
+// ioError marks err as caused by reading or writing a file
+func ioError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return exitError{code: ExitIO, err: err}
+}
+
+// ExitCode returns the exit code go-jwlm exits with because of err.
+// Errors that have not been marked by the commands are categorized
+// by their cause, falling back to ExitFailure.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr exitError
+	var conflictErr merger.MergeConflictError
+	switch {
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	case errors.Is(err, model.ErrManifestOutdated),
+		errors.Is(err, model.ErrSchemaUnsupported),
+		errors.Is(err, model.ErrHashMismatch),
+		errors.Is(err, model.ErrBackupDamaged):
+		return ExitInvalidBackup
+	case errors.As(err, &conflictErr):
+		return ExitUnresolvedConflicts
+	case isIOError(err):
+		return ExitIO
+	}
+	return ExitFailure
+}
+
+// isIOError checks if err has been caused by a file operation
+func isIOError(err error) bool {
+	var pathErr *os.PathError
+	var linkErr *os.LinkError
+	var syscallErr *os.SyscallError
+	return errors.As(err, &pathErr) || errors.As(err, &linkErr) || errors.As(err, &syscallErr)
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/tj/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	_, pathErr := os.Open("missing.jwlibrary")
+
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, 0},
+		{errors.New("Export format pdf is not supported"), ExitFailure},
+		{invalidBackupError(errors.New("zip: not a valid zip file")), ExitInvalidBackup},
+		{invalidBackupError(pathErr), ExitIO},
+		{errors.Wrap(model.ErrSchemaUnsupported, "Error while importing"), ExitInvalidBackup},
+		{model.HashMismatchError{Name: "backup", Expected: "a", Actual: "b"}, ExitInvalidBackup},
+		{fmt.Errorf("%w: zip: not a valid zip file", model.ErrBackupDamaged), ExitInvalidBackup},
+		{conflictError(errors.New("interrupted")), ExitUnresolvedConflicts},
+		{fmt.Errorf("Error while merging notes: %w", merger.MergeConflictError{Err: "Conflicts"}), ExitUnresolvedConflicts},
+		{ioError(errors.New("Error while uploading")), ExitIO},
+		{errors.Wrap(pathErr, "Error while opening mapping"), ExitIO},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.code, ExitCode(test.err), fmt.Sprint(test.err))
+	}
+
+	assert.Nil(t, invalidBackupError(nil))
+	assert.Nil(t, conflictError(nil))
+	assert.Nil(t, ioError(nil))
+	assert.Equal(t, "interrupted", conflictError(errors.New("interrupted")).Error())
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/export"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <backup> <dest>",
+	Short: "Export notes and highlights of a JW Library backup",
+	Long: `export imports the given .jwlibrary backup file and exports its notes
+and highlights, grouped by publication, into a format that can be read
+outside of JW Library. The following formats are supported:
+  - html: a self-contained HTML file that can be opened in any browser
+  - obsidian: a directory containing an Obsidian vault with one Markdown
+    file per note and index files for every publication and Bible book
+  - heatmap-csv, heatmap-json: the number of notes and highlights per
+    Bible book and chapter, to be visualized with other tools
+  - opml: an outline of all tags containing their notes, to be opened
+    with outliners like OmniOutliner or Dynalist`,
+	Example: `go-jwlm export backup.jwlibrary notes.html
+go-jwlm export backup.jwlibrary vault --format obsidian
+go-jwlm export backup.jwlibrary heatmap.csv --format heatmap-csv
+go-jwlm export backup.jwlibrary tags.opml --format opml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		dest := args[1]
+		return exportBackup(filename, dest, ExportFormat, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+// ExportFormat represents the format the export command should produce
+var ExportFormat string
+
+// exporters contains the supported export formats
+var exporters = map[string]func(db *model.Database, dest string) error{
+	"html": func(db *model.Database, dest string) error {
+		return exportToFile(dest, func(w io.Writer) error {
+			return export.HTML(db, w)
+		})
+	},
+	"obsidian": export.Obsidian,
+	"heatmap-csv": func(db *model.Database, dest string) error {
+		return exportToFile(dest, func(w io.Writer) error {
+			return export.HeatmapCSV(db, w)
+		})
+	},
+	"heatmap-json": func(db *model.Database, dest string) error {
+		return exportToFile(dest, func(w io.Writer) error {
+			return export.HeatmapJSON(db, w)
+		})
+	},
+	"opml": func(db *model.Database, dest string) error {
+		return exportToFile(dest, func(w io.Writer) error {
+			return export.OPML(db, w)
+		})
+	},
+}
+
+func exportBackup(filename string, dest string, format string, stdio terminal.Stdio) error {
+	exporter, ok := exporters[format]
+	if !ok {
+		return errors.Errorf("Export format %s is not supported", format)
+	}
+
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	fmt.Fprintln(stdio.Out, i18n.T("Importing backup"))
+	db := &model.Database{}
+	err = importDatabase(db, filename)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdio.Out, i18n.T("Exporting notes and highlights"))
+	if err := exporter(db, dest); err != nil {
+		return ioError(err)
+	}
+
+	fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Finished exporting!"))
+	return nil
+}
+
+// exportToFile creates the file with the given filename and
+// passes it to write, closing it afterwards.
+func exportToFile(filename string, write func(w io.Writer) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVar(&ExportFormat, "format", "html", "Format of the export (can be 'html', 'obsidian', 'heatmap-csv', 'heatmap-json', or 'opml')")
+	exportCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
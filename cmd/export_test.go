@@ -0,0 +1,76 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_exportBackup(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "left.jwlibrary")
+	destFilename := filepath.Join(tmp, "notes.html")
+	assert.NoError(t, leftDB.ExportJWLBackup(filename))
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Finished exporting!")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, exportBackup(filename, destFilename, "html", terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	html, err := ioutil.ReadFile(destFilename)
+	assert.NoError(t, err)
+	assert.Contains(t, string(html), "<!DOCTYPE html>")
+	assert.Contains(t, string(html), `<section class="publication">`)
+
+	vault := filepath.Join(tmp, "vault")
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Finished exporting!")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, exportBackup(filename, vault, "obsidian", terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+	assert.FileExists(t, filepath.Join(vault, "Index.md"))
+	assert.DirExists(t, filepath.Join(vault, "Notes"))
+
+	heatmap := filepath.Join(tmp, "heatmap.csv")
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Finished exporting!")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, exportBackup(filename, heatmap, "heatmap-csv", terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+	csv, err := ioutil.ReadFile(heatmap)
+	assert.NoError(t, err)
+	assert.Contains(t, string(csv), "book,bookName,chapter,notes,highlights\n")
+}
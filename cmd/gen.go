@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/spf13/cobra"
+)
+
+var genCmd = &cobra.Command{
+	Use:   "gen <dest>",
+	Short: "Generate a backup with fabricated notes, highlights, and bookmarks",
+	Long: `gen creates a .jwlibrary backup filled with fabricated notes, highlights,
+bookmarks, and tags, spread across the Bible and other publications. Use it
+to reproduce slow merges or problems with big libraries without sharing
+your personal backups: the same flags (including --seed) always generate
+the same backup, so it is enough to share the command. Merging two
+backups generated with different seeds brings up conflicts like merging
+backups of different devices would.
+
+If dest is -, the backup is written to stdout and all messages to stderr.`,
+	Example: `go-jwlm gen big.jwlibrary --notes 50000 --highlights 100000 --publications 10
+go-jwlm gen left.jwlibrary --notes 1000 --seed 1
+go-jwlm gen right.jwlibrary --notes 1000 --seed 2`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return generate(args[0], GenerateSpec, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+// GenerateSpec describes the backup the gen command creates
+var GenerateSpec model.TestDatabaseSpec
+
+func generate(dest string, spec model.TestDatabaseSpec, stdio terminal.Stdio) error {
+	// Keep stdout free for the generated backup
+	var out io.Writer = stdio.Out
+	if dest == pipeFilename {
+		out = stdio.Err
+	}
+
+	fmt.Fprintln(out, i18n.T("Generating backup"))
+	db, err := model.GenerateTestDatabase(spec)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, i18n.T("Exporting backup"))
+	localFilename, write, err := stdoutDestination(dest, stdio.Out)
+	if err != nil {
+		return err
+	}
+	if err := db.ExportJWLBackup(localFilename); err != nil {
+		return ioError(err)
+	}
+	if err := write(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "🎉 "+i18n.T("Generated %d notes, %d highlights, and %d bookmarks", spec.Notes, spec.Highlights, spec.Bookmarks))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(genCmd)
+	genCmd.Flags().IntVar(&GenerateSpec.Notes, "notes", 100, "Number of notes")
+	genCmd.Flags().IntVar(&GenerateSpec.Highlights, "highlights", 100, "Number of highlights")
+	genCmd.Flags().IntVar(&GenerateSpec.Bookmarks, "bookmarks", 10, "Number of bookmarks (at most 10 per publication)")
+	genCmd.Flags().IntVar(&GenerateSpec.Publications, "publications", 3, "Number of publications the entries are spread across, starting with the Bible")
+	genCmd.Flags().IntVar(&GenerateSpec.Tags, "tags", 5, "Number of tags the notes are tagged with")
+	genCmd.Flags().Int64Var(&GenerateSpec.Seed, "seed", 0, "Seed for the content of the backup")
+}
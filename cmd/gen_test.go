@@ -0,0 +1,63 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_generate(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	spec := model.TestDatabaseSpec{Notes: 20, Highlights: 30, Bookmarks: 5, Publications: 2, Tags: 3, Seed: 42}
+	expected, err := model.GenerateTestDatabase(spec)
+	assert.NoError(t, err)
+
+	dest := filepath.Join(tmp, "generated.jwlibrary")
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Generated 20 notes, 30 highlights, and 5 bookmarks")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, generate(dest, spec, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+	generated := &model.Database{}
+	assert.NoError(t, generated.ImportJWLBackup(dest))
+	assert.True(t, expected.Equals(generated))
+
+	// The backup can be written to stdout
+	out, err := os.Create(filepath.Join(tmp, "stdout"))
+	assert.NoError(t, err)
+	defer out.Close()
+	errOut, err := os.Create(filepath.Join(tmp, "stderr"))
+	assert.NoError(t, err)
+	defer errOut.Close()
+	assert.NoError(t, generate("-", spec, terminal.Stdio{Out: out, Err: errOut}))
+	generated = &model.Database{}
+	assert.NoError(t, generated.ImportJWLBackup(out.Name()))
+	assert.True(t, expected.Equals(generated))
+	messages, err := ioutil.ReadFile(errOut.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(messages), "Generated 20 notes")
+
+	spec.Bookmarks = 100
+	assert.Error(t, generate(dest, spec, terminal.Stdio{Out: out, Err: errOut}))
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/export"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/lint"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// PreMergeHooks are commands that are run before merging,
+// which can veto the merge by exiting with a non-zero status
+var PreMergeHooks []string
+
+// PreMergeChecks are the built-in validators (see parsePreMergeCheck)
+// both backups have to pass before they are merged
+var PreMergeChecks []string
+
+// PostMergeHooks are commands that are run after a successful merge
+var PostMergeHooks []string
+
+// preMergeCheck validates a backup before it is merged
+// and returns an error if it should not be merged
+type preMergeCheck func(filename string, db *model.Database, now time.Time) error
+
+// parsePreMergeCheck returns the built-in validator of the given name:
+// 'integrity' refuses backups for which lint finds errors, while
+// 'max-age=<age>' refuses backups that have been modified longer than
+// the given age ago (like 72h or 30d).
+func parsePreMergeCheck(check string) (preMergeCheck, error) {
+	name, arg := check, ""
+	if i := strings.Index(check, "="); i >= 0 {
+		name, arg = check[:i], check[i+1:]
+	}
+
+	switch name {
+	case "integrity":
+		return checkIntegrity, nil
+	case "max-age":
+		maxAge, err := parseAge(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid pre-merge check %s", check)
+		}
+		return func(filename string, db *model.Database, now time.Time) error {
+			return checkMaxAge(filename, maxAge, now)
+		}, nil
+	}
+	return nil, errors.Errorf("Pre-merge check %s is not supported", check)
+}
+
+// parseAge parses a duration like time.ParseDuration,
+// but also supports days (like 30d)
+func parseAge(age string) (time.Duration, error) {
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil || days < 1 {
+			return 0, errors.Errorf("%s is not a valid number of days", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(age)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, errors.Errorf("%s is not a valid age", age)
+	}
+	return d, nil
+}
+
+// checkIntegrity refuses databases for which lint finds errors
+func checkIntegrity(filename string, db *model.Database, now time.Time) error {
+	var errs []lint.Finding
+	for _, finding := range lint.Lint(db) {
+		if finding.Severity == lint.Error {
+			errs = append(errs, finding)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("%s failed the integrity check with %d errors (see lint), like %s", filename, len(errs), errs[0])
+	}
+	return nil
+}
+
+// checkMaxAge refuses backups whose last modification is longer than
+// maxAge ago. Bare databases don't have a manifest, so the modification
+// time of the file is used for them.
+func checkMaxAge(filename string, maxAge time.Duration, now time.Time) error {
+	isDB, err := model.IsUserDB(filename)
+	if err != nil {
+		return err
+	}
+
+	var lastModified time.Time
+	if isDB {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return errors.Wrap(err, "Error while checking age of backup")
+		}
+		lastModified = info.ModTime()
+	} else {
+		mfst, err := model.ReadManifest(filename)
+		if err != nil {
+			return err
+		}
+		lastModified = mfst.LastModified
+	}
+
+	if lastModified.IsZero() {
+		return errors.Errorf("The age of %s is unknown", filename)
+	}
+	if now.Sub(lastModified) > maxAge {
+		return errors.Errorf("%s has last been modified on %s, which is longer than %s ago",
+			filename, lastModified.Format("2006-01-02 15:04"), maxAge)
+	}
+	return nil
+}
+
+// runPreMergeChecks runs the given checks on both backups
+// and returns the first error, which vetoes the merge
+func runPreMergeChecks(checks []preMergeCheck, leftFilename string, left *model.Database,
+	rightFilename string, right *model.Database, now time.Time) error {
+	for _, check := range checks {
+		if err := check(leftFilename, left, now); err != nil {
+			return err
+		}
+		if err := check(rightFilename, right, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPreMergeHooks runs the given commands one after another, passing the
+// names of the left and the right backup as arguments. If a hook exits
+// with a non-zero status, the merge is vetoed and an error is returned.
+// The output of the hooks is written to w.
+func runPreMergeHooks(hooks []string, leftFilename string, rightFilename string, w io.Writer) error {
+	for _, hook := range hooks {
+		fmt.Fprintln(w, i18n.T("Running pre-merge hook %s", hook))
+		cmd := exec.Command(hook, leftFilename, rightFilename)
+		cmd.Stdout = w
+		cmd.Stderr = w
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "Pre-merge hook %s vetoed the merge", hook)
+		}
+	}
+	return nil
+}
+
+// runPostMergeHooks runs the given commands one after another, passing the
+// name of the merged backup and the merge report as JSON as arguments, so
+// they can e.g. upload the backup or send a notification. The output of the
+// hooks is written to w. If a hook fails, the remaining ones are not run.
+func runPostMergeHooks(hooks []string, mergedFilename string, report *export.MergeReport, w io.Writer) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	var summary bytes.Buffer
+	if err := report.JSON(&summary); err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		fmt.Fprintln(w, i18n.T("Running post-merge hook %s", hook))
+		cmd := exec.Command(hook, mergedFilename, summary.String())
+		cmd.Stdout = w
+		cmd.Stderr = w
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "Error while running post-merge hook %s", hook)
+		}
+	}
+	return nil
+}
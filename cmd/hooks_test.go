@@ -0,0 +1,175 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/export"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+func Test_parsePreMergeCheck(t *testing.T) {
+	for _, check := range []string{"integrity", "max-age=30d", "max-age=12h"} {
+		fn, err := parsePreMergeCheck(check)
+		assert.NoError(t, err, check)
+		assert.NotNil(t, fn, check)
+	}
+
+	for _, check := range []string{"", "unknown", "max-age", "max-age=", "max-age=-1h", "max-age=0d", "max-age=xd"} {
+		_, err := parsePreMergeCheck(check)
+		assert.Error(t, err, check)
+	}
+}
+
+func Test_parseAge(t *testing.T) {
+	age, err := parseAge("30d")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, age)
+
+	age, err = parseAge("90m")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, age)
+
+	_, err = parseAge("0s")
+	assert.Error(t, err)
+}
+
+func Test_checkIntegrity(t *testing.T) {
+	db := &model.Database{
+		Location: []*model.Location{nil, {LocationID: 1}},
+		Note: []*model.Note{
+			nil,
+			{NoteID: 1, GUID: "6F3E5B1B-50D2-4C6C-8E2C-4C1D2C0A1B01", LocationID: sql.NullInt32{Int32: 1, Valid: true}},
+		},
+	}
+	assert.NoError(t, checkIntegrity("left.jwlibrary", db, time.Now()))
+
+	db.Note = append(db.Note, &model.Note{NoteID: 2, GUID: "6F3E5B1B-50D2-4C6C-8E2C-4C1D2C0A1B02", LocationID: sql.NullInt32{Int32: 5, Valid: true}})
+	err := checkIntegrity("left.jwlibrary", db, time.Now())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "left.jwlibrary failed the integrity check with 1 errors (see lint), like error: Note 2:")
+}
+
+func Test_checkMaxAge(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	backup := filepath.Join(tmp, "backup.jwlibrary")
+	assert.NoError(t, emptyDB.ExportJWLBackup(backup))
+	mfst, err := model.ReadManifest(backup)
+	assert.NoError(t, err)
+
+	assert.NoError(t, checkMaxAge(backup, time.Hour, mfst.LastModified.Add(time.Minute)))
+	err = checkMaxAge(backup, time.Hour, mfst.LastModified.Add(2*time.Hour))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "which is longer than 1h0m0s ago")
+
+	// Bare databases are checked using the modification time of the file
+	userDB := filepath.Join(tmp, "user_data.db")
+	assert.NoError(t, emptyDB.ExportUserDB(userDB))
+	modified := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(userDB, modified, modified))
+	assert.NoError(t, checkMaxAge(userDB, 24*time.Hour, modified.Add(time.Hour)))
+	assert.Error(t, checkMaxAge(userDB, 24*time.Hour, modified.Add(48*time.Hour)))
+
+	assert.Error(t, checkMaxAge(filepath.Join(tmp, "missing.jwlibrary"), time.Hour, time.Now()))
+}
+
+func Test_runPreMergeChecks(t *testing.T) {
+	var checked []string
+	check := func(filename string, db *model.Database, now time.Time) error {
+		checked = append(checked, filename)
+		if filename == "right.jwlibrary" {
+			return os.ErrInvalid
+		}
+		return nil
+	}
+
+	assert.NoError(t, runPreMergeChecks(nil, "left.jwlibrary", nil, "right.jwlibrary", nil, time.Now()))
+	err := runPreMergeChecks([]preMergeCheck{check, check}, "left.jwlibrary", nil, "right.jwlibrary", nil, time.Now())
+	assert.Equal(t, os.ErrInvalid, err)
+	assert.Equal(t, []string{"left.jwlibrary", "right.jwlibrary"}, checked)
+}
+
+func Test_runPreMergeHooks(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	hook := filepath.Join(tmp, "hook.sh")
+	assert.NoError(t, ioutil.WriteFile(hook, []byte("#!/bin/sh\necho \"checking $1 and $2\"\n"), 0755))
+	vetoHook := filepath.Join(tmp, "veto.sh")
+	assert.NoError(t, ioutil.WriteFile(vetoHook, []byte("#!/bin/sh\necho \"$2 is too old\"\nexit 1\n"), 0755))
+
+	var out bytes.Buffer
+	assert.NoError(t, runPreMergeHooks([]string{hook}, "left.jwlibrary", "right.jwlibrary", &out))
+	assert.Equal(t, "Running pre-merge hook "+hook+"\nchecking left.jwlibrary and right.jwlibrary\n", out.String())
+
+	out.Reset()
+	err = runPreMergeHooks([]string{vetoHook, hook}, "left.jwlibrary", "right.jwlibrary", &out)
+	assert.Error(t, err)
+	assert.Equal(t, "Pre-merge hook "+vetoHook+" vetoed the merge: exit status 1", err.Error())
+	assert.Equal(t, "Running pre-merge hook "+vetoHook+"\nright.jwlibrary is too old\n", out.String())
+}
+
+func Test_runPostMergeHooks(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	argsFile := filepath.Join(tmp, "args")
+	hook := filepath.Join(tmp, "hook.sh")
+	assert.NoError(t, ioutil.WriteFile(hook,
+		[]byte("#!/bin/sh\necho uploading\nprintf '%s\\n%s' \"$1\" \"$2\" > "+argsFile+"\n"), 0755))
+	failingHook := filepath.Join(tmp, "failing.sh")
+	assert.NoError(t, ioutil.WriteFile(failingHook, []byte("#!/bin/sh\nexit 3\n"), 0755))
+
+	report := &export.MergeReport{
+		Left:   "left.jwlibrary",
+		Right:  "right.jwlibrary",
+		Merged: "merged.jwlibrary",
+		Tables: []export.TableCount{{Table: "Note", Left: 1, Right: 2, Merged: 2}},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, runPostMergeHooks(nil, "merged.jwlibrary", report, &out))
+	assert.Equal(t, "", out.String())
+
+	assert.NoError(t, runPostMergeHooks([]string{hook}, "merged.jwlibrary", report, &out))
+	assert.Equal(t, "Running post-merge hook "+hook+"\nuploading\n", out.String())
+	args, err := ioutil.ReadFile(argsFile)
+	assert.NoError(t, err)
+	parts := strings.SplitN(string(args), "\n", 2)
+	assert.Equal(t, "merged.jwlibrary", parts[0])
+	summary := export.MergeReport{}
+	assert.NoError(t, json.Unmarshal([]byte(parts[1]), &summary))
+	assert.Equal(t, *report, summary)
+
+	// Hooks after a failing one are not run
+	assert.NoError(t, os.Remove(argsFile))
+	out.Reset()
+	err = runPostMergeHooks([]string{failingHook, hook}, "merged.jwlibrary", report, &out)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Error while running post-merge hook "+failingHook+": exit status 3")
+	assert.NoFileExists(t, argsFile)
+
+	assert.Error(t, runPostMergeHooks([]string{filepath.Join(tmp, "missing")}, "merged.jwlibrary", report, &out))
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/importer"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <notes-file> <dest-backup>",
+	Short: "Import notes from other tools into a new JW Library backup",
+	Long: `import reads notes from an Evernote export (.enex) or a CSV file (.csv)
+and creates a new .jwlibrary backup containing them. This backup can then be
+merged into your existing one using the merge command.
+
+The CSV file needs a header row, naming the columns. Understood columns are
+title, content, tags (separated by semicolons), publication, book, chapter,
+verse, document, paragraph, and modified.
+
+To attach notes to a publication, provide a mapping file (--mapping) that maps
+the publication names used in the CSV file (or tags used in Evernote) to the
+publications of JW Library:
+
+  {"Study Bible": {"keySymbol": "nwtsty", "mepsLanguage": 0}}`,
+	Example: `go-jwlm import notes.enex imported.jwlibrary
+go-jwlm import notes.csv imported.jwlibrary --mapping mapping.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		destFilename := args[1]
+		return importNotes(filename, destFilename, MappingFilename, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+// MappingFilename is the path to a file mapping publication
+// names of other tools to JW Library publications
+var MappingFilename string
+
+func importNotes(filename string, destFilename string, mappingFilename string, stdio terminal.Stdio) error {
+	mapping := importer.Mapping{}
+	if mappingFilename != "" {
+		f, err := os.Open(mappingFilename)
+		if err != nil {
+			return err
+		}
+		mapping, err = importer.ReadMapping(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(stdio.Out, i18n.T("Reading notes"))
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var notes []importer.ExternalNote
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".enex":
+		notes, err = importer.ReadENEX(f)
+	case ".csv":
+		notes, err = importer.ReadCSV(f)
+	default:
+		return errors.Errorf("File %s is neither an Evernote export (.enex) nor a CSV file (.csv)", filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	db, err := importer.Build(notes, mapping)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdio.Out, i18n.T("Exporting backup"))
+	if err := db.ExportJWLBackup(destFilename); err != nil {
+		return ioError(err)
+	}
+
+	fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Imported %d notes!", len(notes)))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVar(&MappingFilename, "mapping", "", "JSON file mapping publication names to JW Library publications")
+}
@@ -0,0 +1,53 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_importNotes(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	csvFilename := filepath.Join(tmp, "notes.csv")
+	mappingFilename := filepath.Join(tmp, "mapping.json")
+	destFilename := filepath.Join(tmp, "imported.jwlibrary")
+	assert.NoError(t, ioutil.WriteFile(csvFilename, []byte(`title,content,tags,publication,book,chapter,verse
+First,Content,Faith;Hope,Study Bible,1,3,15
+Second,,Faith,,,,
+`), 0644))
+	assert.NoError(t, ioutil.WriteFile(mappingFilename, []byte(`{"Study Bible": {"keySymbol": "nwtsty", "mepsLanguage": 2}}`), 0644))
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Imported 2 notes!")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, importNotes(csvFilename, destFilename, mappingFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	imported := &model.Database{}
+	assert.NoError(t, imported.ImportJWLBackup(destFilename))
+	assert.Len(t, imported.Note, 3)
+	assert.Len(t, imported.Location, 2)
+	assert.Len(t, imported.Tag, 3)
+	assert.Equal(t, "nwtsty", imported.Location[1].KeySymbol.String)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/lint"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint <backup>",
+	Short: "Check a JW Library backup for anomalies",
+	Long: `lint imports the given .jwlibrary backup file and checks it for anomalies,
+like notes or highlights referring to entries that don't exist, duplicate
+or redundant bookmarks, or empty GUIDs. Each finding has a severity (info, warning, or
+error) and a hint on how to fix it.`,
+	Example: `go-jwlm lint backup.jwlibrary`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		return lintBackup(filename, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func lintBackup(filename string, stdio terminal.Stdio) error {
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db := &model.Database{}
+	err = importDatabase(db, filename)
+	if err != nil {
+		return err
+	}
+
+	findings := lint.Lint(db)
+	for _, finding := range findings {
+		fmt.Fprintf(stdio.Out, "%-8s %s %d: %s (%s)\n", finding.Severity, finding.Table, finding.ID, finding.Message, finding.Check)
+		fmt.Fprintf(stdio.Out, "%-8s 💡 %s\n", "", finding.Fix)
+	}
+
+	if len(findings) == 0 {
+		fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("No anomalies found"))
+		return nil
+	}
+	fmt.Fprintln(stdio.Out, i18n.T("Found %d anomalies", len(findings)))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
@@ -0,0 +1,62 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_lintBackup(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "left.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(filename))
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("info     Note 2: Is not attached to any publication (note-without-location)")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("💡 Nothing to do if this is a personal note")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Found 1 anomalies")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, lintBackup(filename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	db := model.MakeDatabaseCopy(leftDB)
+	db.Note = db.Note[:2]
+	db.TagMap = db.TagMap[:2]
+	filename = filepath.Join(tmp, "clean.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(filename))
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("No anomalies found")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, lintBackup(filename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+}
@@ -1,16 +1,20 @@
+//go:build !windows
 // +build !windows
 
 package cmd
 
 import (
+	"archive/zip"
 	"bytes"
 	"database/sql"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/merger"
 	"github.com/AndreasSko/go-jwlm/model"
 	expect "github.com/Netflix/go-expect"
 	"github.com/hinshun/vt10x"
@@ -46,13 +50,156 @@ func Test_merge(t *testing.T) {
 			assert.NoError(t, err)
 		},
 		func(t *testing.T, c *expect.Console) {
-			merge(leftFilename, emptyFilename, mergedFilename,
-				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()})
+			assert.NoError(t, merge(leftFilename, emptyFilename, mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
 			merged := &model.Database{}
 			merged.ImportJWLBackup(mergedFilename)
 			assert.True(t, leftDB.Equals(merged))
 		})
 
+	// Merge into an existing backup, which is replaced
+	masterFilename := filepath.Join(tmp, "master.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(masterFilename))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Finished merging!")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Saved existing " + masterFilename + " as " + masterFilename + ".bak-")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			MergeInto = masterFilename
+			BookmarkResolver, MarkingResolver, NoteResolver = "chooseRight", "chooseRight", "chooseRight"
+			defer func() {
+				MergeInto = ""
+				BookmarkResolver, MarkingResolver, NoteResolver = "", "", ""
+			}()
+			assert.NoError(t, merge(MergeInto, rightFilename, MergeInto,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			merged := &model.Database{}
+			assert.NoError(t, merged.ImportJWLBackup(masterFilename))
+			assert.True(t, mergedAllRightDB.Equals(merged))
+
+			backups, err := filepath.Glob(masterFilename + ".bak-*")
+			assert.NoError(t, err)
+			assert.Len(t, backups, 1)
+			previous := &model.Database{}
+			assert.NoError(t, previous.ImportJWLBackup(backups[0]))
+			assert.True(t, leftDB.Equals(previous))
+
+			leftovers, err := filepath.Glob(filepath.Join(tmp, ".go-jwlm*"))
+			assert.NoError(t, err)
+			assert.Empty(t, leftovers)
+		})
+
+	// Merge without discarding or changing anything of the left side. The
+	// right side contains a newer version of a left note at a different
+	// location and tags that would be interleaved with the left ones.
+	appendLeft := model.MakeDatabaseCopy(leftDB)
+	appendLeft.TagMap = append(appendLeft.TagMap, &model.TagMap{
+		TagMapID: 3,
+		NoteID:   sql.NullInt32{Int32: 1, Valid: true},
+		TagID:    3,
+		Position: 1,
+	})
+	appendRight := model.MakeDatabaseCopy(rightDB)
+	appendRight.Note[2].Content = appendLeft.Note[2].Content
+	appendRight.Note[2].LocationID = sql.NullInt32{Int32: 3, Valid: true}
+	appendRight.TagMap[2].Position = 1
+	appendRight.TagMap = append(appendRight.TagMap, &model.TagMap{
+		TagMapID: 3,
+		NoteID:   sql.NullInt32{Int32: 1, Valid: true},
+		TagID:    3,
+		Position: 0,
+	})
+	appendLeftFilename := filepath.Join(tmp, "appendLeft.jwlibrary")
+	appendRightFilename := filepath.Join(tmp, "appendRight.jwlibrary")
+	assert.NoError(t, appendLeft.ExportJWLBackup(appendLeftFilename))
+	assert.NoError(t, appendRight.ExportJWLBackup(appendRightFilename))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Moved 1 bookmarks of the right backup to free slots")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("🎉 Finished merging!")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			AppendOnly = true
+			defer func() { AppendOnly = false }()
+			assert.NoError(t, merge(appendLeftFilename, appendRightFilename, mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			merged := &model.Database{}
+			assert.NoError(t, merged.ImportJWLBackup(mergedFilename))
+
+			bookmarks := map[string]int{}
+			for _, bm := range merged.Bookmark[1:] {
+				bookmarks[bm.Title] = bm.Slot
+			}
+			assert.Equal(t, map[string]int{"1. Mose 1:1": 0, "1. Mose 2:1": 1}, bookmarks)
+
+			// locationTitle returns the title of the Location of a note
+			locationTitle := func(db *model.Database, note *model.Note) string {
+				if location, ok := db.FetchFromTable("Location", int(note.LocationID.Int32)).(*model.Location); ok {
+					return location.Title.String
+				}
+				return ""
+			}
+			notes := map[string]*model.Note{}
+			for _, note := range merged.Note[1:] {
+				notes[note.GUID] = note
+			}
+			for _, note := range appendLeft.Note[1:] {
+				assert.Equal(t, note.Content.String, notes[note.GUID].Content.String)
+				assert.Equal(t, note.LastModified, notes[note.GUID].LastModified)
+				assert.Equal(t, locationTitle(appendLeft, note), locationTitle(merged, notes[note.GUID]))
+			}
+
+			// tagMapPositions returns the positions of the tagged
+			// notes of db by the names of their Tag and their GUID
+			tagMapPositions := func(db *model.Database) map[string]int {
+				positions := map[string]int{}
+				for _, tm := range db.TagMap[1:] {
+					tag := db.FetchFromTable("Tag", tm.TagID).(*model.Tag)
+					note := db.FetchFromTable("Note", int(tm.NoteID.Int32)).(*model.Note)
+					positions[tag.Name+"/"+note.GUID] = tm.Position
+				}
+				return positions
+			}
+			mergedPositions := tagMapPositions(merged)
+			for key, position := range tagMapPositions(appendLeft) {
+				assert.Equal(t, position, mergedPositions[key], key)
+			}
+
+			colors := map[string]int{}
+			for _, um := range merged.UserMark[1:] {
+				colors[um.UserMarkGUID] = um.ColorIndex
+			}
+			for _, um := range appendLeft.UserMark[1:] {
+				assert.Equal(t, um.ColorIndex, colors[um.UserMarkGUID])
+			}
+		})
+
+	// Merge a bare database with a backup into a bare database
+	leftDBFilename := filepath.Join(tmp, "left.db")
+	mergedDBFilename := filepath.Join(tmp, "merged.db")
+	assert.NoError(t, leftDB.ExportUserDB(leftDBFilename))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Finished merging!")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, merge(leftDBFilename, emptyFilename, mergedDBFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			merged := &model.Database{}
+			assert.NoError(t, merged.ImportUserDB(mergedDBFilename))
+			assert.True(t, leftDB.Equals(merged))
+		})
+
 	// Merge while selecting all right
 	RunCmdTest(t,
 		func(t *testing.T, c *expect.Console) {
@@ -68,8 +215,8 @@ func Test_merge(t *testing.T) {
 			c.ExpectEOF()
 		},
 		func(t *testing.T, c *expect.Console) {
-			merge(leftFilename, rightFilename, mergedFilename,
-				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()})
+			assert.NoError(t, merge(leftFilename, rightFilename, mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
 			merged := &model.Database{}
 			merged.ImportJWLBackup(mergedFilename)
 			assert.True(t, mergedAllRightDB.Equals(merged))
@@ -90,8 +237,8 @@ func Test_merge(t *testing.T) {
 			c.ExpectEOF()
 		},
 		func(t *testing.T, c *expect.Console) {
-			merge(leftFilename, rightFilename, mergedFilename,
-				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()})
+			assert.NoError(t, merge(leftFilename, rightFilename, mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
 			merged := &model.Database{}
 			merged.ImportJWLBackup(mergedFilename)
 			assert.True(t, mergedAllLeftDB.Equals(merged))
@@ -108,8 +255,8 @@ func Test_merge(t *testing.T) {
 			BookmarkResolver = "chooseRight"
 			MarkingResolver = "chooseRight"
 			NoteResolver = "chooseNewest"
-			merge(leftFilename, rightFilename, mergedFilename,
-				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()})
+			assert.NoError(t, merge(leftFilename, rightFilename, mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
 			merged := &model.Database{}
 			merged.ImportJWLBackup(mergedFilename)
 			assert.True(t, mergedAllRightDB.Equals(merged))
@@ -124,14 +271,319 @@ func Test_merge(t *testing.T) {
 		},
 		func(t *testing.T, c *expect.Console) {
 			MarkingResolver = "chooseRight"
-			merge(leftMultiCollisionFilename,
+			assert.NoError(t, merge(leftMultiCollisionFilename,
 				rightMultiCollisionFilename,
 				mergedFilename,
-				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()})
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
 			merged := &model.Database{}
 			merged.ImportJWLBackup(mergedFilename)
 			assert.True(t, rightMultiCollision.Equals(merged))
 		})
+
+	// Merge with history
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Finished merging!")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			RecordHistory = true
+			defer func() { RecordHistory = false }()
+			assert.NoError(t, merge(leftMultiCollisionFilename,
+				rightMultiCollisionFilename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			history, err := model.ReadMergeHistory(mergedFilename)
+			assert.NoError(t, err)
+			assert.Equal(t, "dev", history.ToolVersion)
+			assert.Len(t, history.Sources, 2)
+			assert.Equal(t, "leftMultiCollision.jwlibrary", history.Sources[0].Name)
+			assert.Equal(t, "rightMultiCollision.jwlibrary", history.Sources[1].Name)
+		})
+
+	// Merge with report
+	reportFilename := filepath.Join(tmp, "report.md")
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Wrote merge report to")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			MergeReportFile = reportFilename
+			defer func() { MergeReportFile = "" }()
+			assert.NoError(t, merge(leftMultiCollisionFilename,
+				rightMultiCollisionFilename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			report, err := ioutil.ReadFile(reportFilename)
+			assert.NoError(t, err)
+			assert.Contains(t, string(report), "# Merge report")
+			assert.Contains(t, string(report), "| UserMark |")
+			assert.Contains(t, string(report), "Markings: kept right")
+			assert.Contains(t, string(report), "Resolved automatically using chooseRight.")
+		})
+
+	// Merge with a post-merge hook
+	summaryFilename := filepath.Join(tmp, "summary.json")
+	hook := filepath.Join(tmp, "hook.sh")
+	assert.NoError(t, ioutil.WriteFile(hook, []byte("#!/bin/sh\nprintf '%s' \"$2\" > "+summaryFilename+"\n"), 0755))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Running post-merge hook")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			PostMergeHooks = []string{hook}
+			defer func() { PostMergeHooks = nil }()
+			assert.NoError(t, merge(leftMultiCollisionFilename,
+				rightMultiCollisionFilename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			summary, err := ioutil.ReadFile(summaryFilename)
+			assert.NoError(t, err)
+			assert.Contains(t, string(summary), `"merged":"`+mergedFilename+`"`)
+			assert.Contains(t, string(summary), `{"table":"UserMark",`)
+			assert.Contains(t, string(summary), `"resolver":"chooseRight"`)
+		})
+
+	// Merge with a policy
+	policyFilename := filepath.Join(tmp, "policy.yaml")
+	assert.NoError(t, ioutil.WriteFile(policyFilename, []byte("bookmarks: right\nmarkings: [ignore-color-differences, right]\nnotes: right\n"), 0644))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Finished merging!")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			PolicyFile = policyFilename
+			defer func() { PolicyFile = "" }()
+			assert.NoError(t, merge(leftMultiCollisionFilename,
+				rightMultiCollisionFilename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			merged := model.Database{}
+			assert.NoError(t, merged.ImportJWLBackup(mergedFilename))
+			assert.True(t, rightMultiCollision.Equals(&merged))
+		})
+
+	// Merge with rules
+	rulesFilename := filepath.Join(tmp, "rules.txt")
+	assert.NoError(t, ioutil.WriteFile(rulesFilename, []byte("# Always keep the right side\ntrue then right\n"), 0644))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Finished merging!")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			RulesFile = rulesFilename
+			defer func() { RulesFile = "" }()
+			assert.NoError(t, merge(leftMultiCollisionFilename,
+				rightMultiCollisionFilename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			merged := model.Database{}
+			assert.NoError(t, merged.ImportJWLBackup(mergedFilename))
+			assert.True(t, rightMultiCollision.Equals(&merged))
+		})
+
+	// Merge with pre-merge checks and hooks
+	preHook := filepath.Join(tmp, "pre.sh")
+	assert.NoError(t, ioutil.WriteFile(preHook, []byte("#!/bin/sh\necho \"validated $(basename $1)\"\n"), 0755))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("validated leftMultiCollision.jwlibrary")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Finished merging!")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			PreMergeHooks = []string{preHook}
+			PreMergeChecks = []string{"integrity"}
+			defer func() { PreMergeHooks, PreMergeChecks = nil, nil }()
+			assert.NoError(t, merge(leftMultiCollisionFilename,
+				rightMultiCollisionFilename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+		})
+
+	// Merge with self-check and verification of the export
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Verified merged database")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			SelfCheck = true
+			VerifyExport = true
+			defer func() { SelfCheck, VerifyExport = false, false }()
+			assert.NoError(t, merge(leftFilename,
+				rightFilename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			merged := &model.Database{}
+			merged.ImportJWLBackup(mergedFilename)
+			assert.True(t, mergedAllRightDB.Equals(merged))
+		})
+
+	// Merge a backup using another Bible edition with one using the Study Edition
+	bi12DB := model.MakeDatabaseCopy(leftDB)
+	for _, location := range bi12DB.Location[1:] {
+		location.KeySymbol.String = "bi12"
+	}
+	bi12Filename := filepath.Join(tmp, "bi12.jwlibrary")
+	assert.NoError(t, bi12DB.ExportJWLBackup(bi12Filename))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Verified merged database")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			SelfCheck = true
+			EquivalentEditions = map[string]string{"bi12": "nwtsty"}
+			defer func() { SelfCheck, EquivalentEditions = false, nil }()
+			assert.NoError(t, merge(leftFilename,
+				bi12Filename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			merged := &model.Database{}
+			merged.ImportJWLBackup(mergedFilename)
+			assert.True(t, leftDB.Equals(merged))
+		})
+
+	// Merge keeping the thumbnail of the right backup
+	rightThumbnailFilename := filepath.Join(tmp, "rightThumbnail.jwlibrary")
+	assert.NoError(t, emptyDB.ExportJWLBackupWithOptions(rightThumbnailFilename, model.ExportOptions{Thumbnail: []byte("right")}))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Finished merging!")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			ThumbnailSide = "right"
+			defer func() { ThumbnailSide = "left" }()
+			assert.NoError(t, merge(leftFilename,
+				rightThumbnailFilename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			thumbnail, err := model.ReadThumbnail(mergedFilename)
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("right"), thumbnail)
+		})
+
+	// Media files of both backups are kept
+	leftMediaFilename := filepath.Join(tmp, "leftMedia.jwlibrary")
+	rightMediaFilename := filepath.Join(tmp, "rightMedia.jwlibrary")
+	assert.NoError(t, emptyDB.ExportJWLBackupWithOptions(leftMediaFilename, model.ExportOptions{
+		Media: map[string][]byte{"picture.jpg": []byte("left"), "audio.mp3": []byte("audio")},
+	}))
+	assert.NoError(t, emptyDB.ExportJWLBackupWithOptions(rightMediaFilename, model.ExportOptions{
+		Media: map[string][]byte{"picture.jpg": []byte("right"), "audio.mp3": []byte("audio")},
+	}))
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Media file picture.jpg of the right backup is stored as picture 2.jpg")
+			assert.NoError(t, err)
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, merge(leftMediaFilename,
+				rightMediaFilename,
+				mergedFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			media, err := model.ReadMedia(mergedFilename)
+			assert.NoError(t, err)
+			assert.Equal(t, map[string][]byte{
+				"picture.jpg":   []byte("left"),
+				"picture 2.jpg": []byte("right"),
+				"audio.mp3":     []byte("audio"),
+			}, media)
+		})
+}
+
+func Test_checkCompatibility(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(leftFilename))
+	assert.NoError(t, rightDB.ExportJWLBackup(rightFilename))
+
+	var buf bytes.Buffer
+	assert.NoError(t, checkCompatibility(leftFilename, rightFilename, &buf))
+	assert.Empty(t, buf.String())
+
+	assert.NoError(t, checkCompatibility(leftFilename, filepath.Join("..", "model", "testdata", "backup.jwlibrary"), &buf))
+	assert.Contains(t, buf.String(), "⚠️  Only the")
+
+	_, err = os.Create(filepath.Join(tmp, "broken.jwlibrary"))
+	assert.NoError(t, err)
+	assert.Error(t, checkCompatibility(leftFilename, filepath.Join(tmp, "broken.jwlibrary"), &buf))
+}
+
+func Test_sideLabel(t *testing.T) {
+	assert.Equal(t, "Left", sideLabel("Left", ""))
+	assert.Equal(t, "Right (Living-room iPad)", sideLabel("Right", "Living-room iPad"))
+}
+
+func Test_prettyPrintContext(t *testing.T) {
+	note := &model.Note{BlockType: 2, BlockIdentifier: sql.NullInt32{Int32: 3, Valid: true}}
+	genesis := &model.Location{
+		BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+		ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+		KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+	}
+
+	assert.Equal(t, "", prettyPrintContext(nil, note))
+	assert.Equal(t, "\n\n\nNo tags", prettyPrintContext(&merger.ConflictContext{}, note))
+	assert.Equal(t, "\n\n\nTags: Faith, Love\nLocation: Genesis 1:3 (New World Translation of the Holy Scriptures (Study Edition))",
+		prettyPrintContext(&merger.ConflictContext{Tags: []string{"Faith", "Love"}, Location: genesis}, note))
+}
+
+func Test_prettyPrintColor(t *testing.T) {
+	marking := &model.UserMarkBlockRange{UserMark: &model.UserMark{ColorIndex: 1}}
+	assert.Equal(t, "\nColor: \x1b[48;2;255;241;118m   \x1b[0m yellow", prettyPrintColor(marking))
+
+	marking.UserMark.ColorIndex = 42
+	assert.Equal(t, "\nColor: \x1b[48;2;214;214;214m   \x1b[0m grey", prettyPrintColor(marking))
+
+	assert.Equal(t, "", prettyPrintColor(&model.Note{}))
+	assert.Equal(t, "", prettyPrintColor(&model.UserMarkBlockRange{}))
+}
+
+func Test_warnAboutConflicts(t *testing.T) {
+	left := &model.Database{Note: []*model.Note{nil}}
+	right := &model.Database{Note: []*model.Note{nil}}
+	for i := 1; i <= conflictWarningThreshold; i++ {
+		guid := fmt.Sprintf("GUID-%d", i)
+		left.Note = append(left.Note, &model.Note{NoteID: i, GUID: guid, Content: sql.NullString{String: "Left", Valid: true}})
+		right.Note = append(right.Note, &model.Note{NoteID: i, GUID: guid, Content: sql.NullString{String: "Right", Valid: true}})
+	}
+
+	var buf bytes.Buffer
+	warnAboutConflicts(left, right, &buf)
+	assert.Equal(t, "⚠️  About 10 conflicts are expected. Consider solving them automatically using --notes.\n", buf.String())
+
+	NoteResolver = "chooseLeft"
+	defer func() { NoteResolver = "" }()
+	buf.Reset()
+	warnAboutConflicts(left, right, &buf)
+	assert.Empty(t, buf.String())
+
+	NoteResolver = ""
+	buf.Reset()
+	warnAboutConflicts(leftDB, rightDB, &buf)
+	assert.Empty(t, buf.String())
 }
 
 // https://github.com/AlecAivazis/survey/blob/master/survey_posix_test.go
@@ -910,3 +1362,39 @@ var mergedAllRightDB = model.Database{
 		},
 	},
 }
+
+func Test_discoverBackups(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	writeBackup := func(name string, device string, lastModified string) string {
+		filename := filepath.Join(tmp, name)
+		f, err := os.Create(filename)
+		assert.NoError(t, err)
+		defer f.Close()
+		w := zip.NewWriter(f)
+		entry, err := w.Create("manifest.json")
+		assert.NoError(t, err)
+		_, err = fmt.Fprintf(entry, `{"userDataBackup":{"lastModifiedDate":"%s","databaseName":"user_data.db","schemaVersion":8,"deviceName":"%s"},"version":1}`,
+			lastModified, device)
+		assert.NoError(t, err)
+		assert.NoError(t, w.Close())
+		return filename
+	}
+	writeBackup("old.jwlibrary", "iPhone", "2021-04-01T10:00:00+02:00")
+	phone := writeBackup("phone.jwlibrary", "iPhone", "2021-05-01T10:00:00+02:00")
+	tablet := writeBackup("tablet.jwlibrary", "iPad", "2021-05-03T10:00:00+02:00")
+	mergedFilename := filepath.Join(tmp, "merged.jwlibrary")
+	assert.NoError(t, emptyDB.ExportJWLBackup(mergedFilename))
+
+	var buf bytes.Buffer
+	left, right, merged, err := discoverBackups([]string{tmp}, mergedFilename, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, tablet, left)
+	assert.Equal(t, phone, right)
+	assert.Equal(t, mergedFilename, merged)
+	assert.Equal(t, "Found backup of iPad: "+tablet+"\nFound backup of iPhone: "+phone+"\n", buf.String())
+}
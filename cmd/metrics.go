@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// mergeMetrics implements merger.Metrics and collects the measurements
+// of all merges, so they can be scraped by Prometheus
+type mergeMetrics struct {
+	mutex  sync.Mutex
+	stages map[string]*stageMetrics
+}
+
+// stageMetrics are the measurements of a single stage
+type stageMetrics struct {
+	runs      int
+	errors    int
+	conflicts int
+	duration  time.Duration
+}
+
+func newMergeMetrics() *mergeMetrics {
+	return &mergeMetrics{stages: map[string]*stageMetrics{}}
+}
+
+func (m *mergeMetrics) ObserveStage(stage string, duration time.Duration, conflicts int, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s, ok := m.stages[stage]
+	if !ok {
+		s = &stageMetrics{}
+		m.stages[stage] = s
+	}
+	s.runs++
+	s.conflicts += conflicts
+	s.duration += duration
+	if err != nil {
+		s.errors++
+	}
+}
+
+// ServeHTTP writes the metrics in the text format of Prometheus
+func (m *mergeMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.writeTo(w)
+}
+
+// writeTo writes the metrics in the text format of Prometheus to w
+func (m *mergeMetrics) writeTo(w io.Writer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stages := make([]string, 0, len(m.stages))
+	for stage := range m.stages {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+
+	fmt.Fprintln(w, "# HELP jwlm_merge_stage_duration_seconds Time spent merging a stage.")
+	fmt.Fprintln(w, "# TYPE jwlm_merge_stage_duration_seconds summary")
+	for _, stage := range stages {
+		s := m.stages[stage]
+		fmt.Fprintf(w, "jwlm_merge_stage_duration_seconds_sum{stage=%q} %g\n", stage, s.duration.Seconds())
+		fmt.Fprintf(w, "jwlm_merge_stage_duration_seconds_count{stage=%q} %d\n", stage, s.runs)
+	}
+	fmt.Fprintln(w, "# HELP jwlm_merge_stage_conflicts_total Conflicts of a stage that could not be solved automatically.")
+	fmt.Fprintln(w, "# TYPE jwlm_merge_stage_conflicts_total counter")
+	for _, stage := range stages {
+		fmt.Fprintf(w, "jwlm_merge_stage_conflicts_total{stage=%q} %d\n", stage, m.stages[stage].conflicts)
+	}
+	fmt.Fprintln(w, "# HELP jwlm_merge_stage_errors_total Runs of a stage that failed, including runs with conflicts.")
+	fmt.Fprintln(w, "# TYPE jwlm_merge_stage_errors_total counter")
+	for _, stage := range stages {
+		fmt.Fprintf(w, "jwlm_merge_stage_errors_total{stage=%q} %d\n", stage, m.stages[stage].errors)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/tj/assert"
+)
+
+func Test_serveMetrics(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	metrics := serveMetrics(l)
+
+	metrics.ObserveStage(merger.StageTags, 2*time.Second, 0, nil)
+	metrics.ObserveStage(merger.StageNotes, time.Second, 3, errors.New("conflict"))
+	metrics.ObserveStage(merger.StageNotes, 500*time.Millisecond, 0, nil)
+
+	resp, err := http.Get("http://" + l.Addr().String() + "/metrics")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `# HELP jwlm_merge_stage_duration_seconds Time spent merging a stage.
+# TYPE jwlm_merge_stage_duration_seconds summary
+jwlm_merge_stage_duration_seconds_sum{stage="Notes"} 1.5
+jwlm_merge_stage_duration_seconds_count{stage="Notes"} 2
+jwlm_merge_stage_duration_seconds_sum{stage="Tags"} 2
+jwlm_merge_stage_duration_seconds_count{stage="Tags"} 1
+# HELP jwlm_merge_stage_conflicts_total Conflicts of a stage that could not be solved automatically.
+# TYPE jwlm_merge_stage_conflicts_total counter
+jwlm_merge_stage_conflicts_total{stage="Notes"} 3
+jwlm_merge_stage_conflicts_total{stage="Tags"} 0
+# HELP jwlm_merge_stage_errors_total Runs of a stage that failed, including runs with conflicts.
+# TYPE jwlm_merge_stage_errors_total counter
+jwlm_merge_stage_errors_total{stage="Notes"} 1
+jwlm_merge_stage_errors_total{stage="Tags"} 0
+`, string(body))
+}
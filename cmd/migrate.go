@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var migrateEditionCmd = &cobra.Command{
+	Use:   "migrate-edition <backup> <dest-backup>",
+	Short: "Move notes and highlights to another Bible edition",
+	Long: `migrate-edition imports the given .jwlibrary backup file, moves the notes,
+highlights, bookmarks, and tags of one Bible edition to another one, and
+stores the result as a new backup. By default, it migrates from the
+standard edition (nwt) to the Study Edition (nwtsty), so your verse notes
+are still available after switching to the Study Edition. Other editions
+can be mapped with --edition. Only Bible chapters are migrated, as their
+verses are the same in both editions.`,
+	Example: `go-jwlm migrate-edition backup.jwlibrary migrated.jwlibrary
+go-jwlm migrate-edition backup.jwlibrary migrated.jwlibrary --edition bi12=nwtsty`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		destFilename := args[1]
+		return migrateEdition(filename, destFilename, BibleEditions, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+// BibleEditions maps the KeySymbol of a Bible edition to the
+// one of the edition the migrate-edition command migrates it to
+var BibleEditions map[string]string
+
+func migrateEdition(filename string, destFilename string, editions map[string]string, stdio terminal.Stdio) error {
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db := &model.Database{}
+	err = importDatabase(db, filename)
+	if err != nil {
+		return err
+	}
+
+	count, err := db.MigrateBibleEdition(editions)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdio.Out, i18n.T("Exporting backup"))
+	if err := db.ExportJWLBackup(destFilename); err != nil {
+		return ioError(err)
+	}
+
+	fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Migrated %d locations", count))
+	return nil
+}
+
+var migrateDocumentsCmd = &cobra.Command{
+	Use:   "migrate-documents <backup> <dest-backup>",
+	Short: "Move notes and highlights to the documents of a new publication edition",
+	Long: `migrate-documents imports the given .jwlibrary backup file, moves the notes,
+highlights, bookmarks, and tags of the documents of a superseded publication
+(like an old edition of a songbook or a book) to the documents of its
+replacement, and stores the result as a new backup. The mapping file given
+with --mapping lists one document per line, with its old and its new
+DocumentID, optionally followed by a number that is added to its paragraphs:
+
+  # old DocumentID, new DocumentID, paragraph offset
+  1102016801 1102022801
+  1102016802 1102022802 -1
+
+If the new edition uses another symbol, map it with --key-symbol.`,
+	Example: `go-jwlm migrate-documents backup.jwlibrary migrated.jwlibrary --mapping songbook.txt --key-symbol sjj=sjjm`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		destFilename := args[1]
+		return migrateDocuments(filename, destFilename, DocumentMappingFile, DocumentKeySymbols, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+// DocumentMappingFile is the file with the document mapping
+// of the migrate-documents command (see model.ParseDocumentMapping)
+var DocumentMappingFile string
+
+// DocumentKeySymbols maps the KeySymbol of a superseded
+// publication to the one of its replacement
+var DocumentKeySymbols map[string]string
+
+func migrateDocuments(filename string, destFilename string, mappingFilename string, keySymbols map[string]string, stdio terminal.Stdio) error {
+	f, err := os.Open(mappingFilename)
+	if err != nil {
+		return errors.Wrap(err, "Error while opening document mapping")
+	}
+	mapping, err := model.ParseDocumentMapping(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	mapping.KeySymbols = keySymbols
+
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db := &model.Database{}
+	if err := importDatabase(db, filename); err != nil {
+		return err
+	}
+
+	count, err := db.MigrateDocuments(mapping)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdio.Out, i18n.T("Exporting backup"))
+	if err := db.ExportJWLBackup(destFilename); err != nil {
+		return ioError(err)
+	}
+
+	fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Migrated %d locations", count))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(migrateDocumentsCmd)
+	migrateDocumentsCmd.Flags().StringVar(&DocumentMappingFile, "mapping", "", "file mapping the old DocumentIDs to the new ones")
+	migrateDocumentsCmd.Flags().StringToStringVar(&DocumentKeySymbols, "key-symbol", nil, "symbol of the old publication and the one of the new publication, like sjj=sjjm")
+	migrateDocumentsCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+	migrateDocumentsCmd.MarkFlagRequired("mapping")
+
+	rootCmd.AddCommand(migrateEditionCmd)
+	migrateEditionCmd.Flags().StringToStringVar(&BibleEditions, "edition", map[string]string{"nwt": "nwtsty"}, "Bible edition to migrate and the edition to migrate it to")
+	migrateEditionCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
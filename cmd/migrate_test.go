@@ -0,0 +1,106 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_migrateEdition(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "left.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(filename))
+	destFilename := filepath.Join(tmp, "migrated.jwlibrary")
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Exporting backup")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Migrated 2 locations")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, migrateEdition(filename, destFilename, map[string]string{"nwtsty": "nwt"},
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	migrated := &model.Database{}
+	assert.NoError(t, migrated.ImportJWLBackup(destFilename))
+	assert.Equal(t, "nwt", migrated.Location[1].KeySymbol.String)
+	assert.Equal(t, "nwt", migrated.Location[2].KeySymbol.String)
+	assert.Equal(t, leftDB.Note[1], migrated.Note[1])
+	assert.Equal(t, leftDB.UserMark[1], migrated.UserMark[1])
+}
+
+func Test_migrateDocuments(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := &model.Database{
+		Location: []*model.Location{
+			nil,
+			{
+				LocationID:   1,
+				DocumentID:   sql.NullInt32{Int32: 1102016801, Valid: true},
+				KeySymbol:    sql.NullString{String: "sjj", Valid: true},
+				MepsLanguage: 0,
+			},
+		},
+		UserMark: []*model.UserMark{
+			nil,
+			{UserMarkID: 1, ColorIndex: 1, LocationID: 1, StyleIndex: 0, UserMarkGUID: "5E5A2A6B-4B1D-4E36-9B8C-0A4E5A5B5C5D", Version: 1},
+		},
+		BlockRange: []*model.BlockRange{
+			nil,
+			{BlockRangeID: 1, BlockType: 1, Identifier: 3, StartToken: sql.NullInt32{Int32: 0, Valid: true}, EndToken: sql.NullInt32{Int32: 4, Valid: true}, UserMarkID: 1},
+		},
+	}
+	filename := filepath.Join(tmp, "songbook.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(filename))
+	mappingFilename := filepath.Join(tmp, "mapping.txt")
+	assert.NoError(t, ioutil.WriteFile(mappingFilename, []byte("# Songbook\n1102016801 1102022801 -1\n"), 0644))
+	destFilename := filepath.Join(tmp, "migrated.jwlibrary")
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Exporting backup")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Migrated 1 locations")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, migrateDocuments(filename, destFilename, mappingFilename, map[string]string{"sjj": "sjjm"},
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	migrated := &model.Database{}
+	assert.NoError(t, migrated.ImportJWLBackup(destFilename))
+	assert.Equal(t, int32(1102022801), migrated.Location[1].DocumentID.Int32)
+	assert.Equal(t, "sjjm", migrated.Location[1].KeySymbol.String)
+	assert.Equal(t, 2, migrated.BlockRange[1].Identifier)
+}
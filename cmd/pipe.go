@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// pipeFilename is the filename that stands for stdin when reading
+// a backup and for stdout when writing one
+const pipeFilename = "-"
+
+// stdinBackup stores the backup read from stdin in a temporary file if
+// filename is pipeFilename, so it can be used like any other backup. It
+// returns the path of the backup together with a function to remove the
+// temporary file.
+func stdinBackup(filename string, stdin io.Reader) (string, func(), error) {
+	if filename != pipeFilename {
+		return filename, func() {}, nil
+	}
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return "", nil, ioError(err)
+	}
+	cleanup := func() { os.RemoveAll(tmp) }
+	local := filepath.Join(tmp, "stdin")
+	f, err := os.Create(local)
+	if err != nil {
+		cleanup()
+		return "", nil, ioError(err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, stdin); err != nil {
+		cleanup()
+		return "", nil, ioError(errors.Wrap(err, "Error while reading backup from stdin"))
+	}
+	return local, cleanup, nil
+}
+
+// stdoutDestination returns a temporary file the backup should be written
+// to if dest is pipeFilename, together with a function that writes it to
+// stdout afterwards. Otherwise, dest is returned as it is.
+func stdoutDestination(dest string, stdout io.Writer) (string, func() error, error) {
+	if dest != pipeFilename {
+		return dest, func() error { return nil }, nil
+	}
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return "", nil, ioError(err)
+	}
+	local := filepath.Join(tmp, "merged.jwlibrary")
+	return local, func() error {
+		defer os.RemoveAll(tmp)
+		f, err := os.Open(local)
+		if err != nil {
+			return ioError(err)
+		}
+		defer f.Close()
+		if _, err := io.Copy(stdout, f); err != nil {
+			return ioError(errors.Wrap(err, "Error while writing backup to stdout"))
+		}
+		return nil
+	}, nil
+}
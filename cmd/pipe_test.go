@@ -0,0 +1,131 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_mergePipe(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	assert.NoError(t, leftMultiCollision.ExportJWLBackup(leftFilename))
+	assert.NoError(t, rightMultiCollision.ExportJWLBackup(rightFilename))
+
+	in, err := os.Open(rightFilename)
+	assert.NoError(t, err)
+	defer in.Close()
+	out, err := os.Create(filepath.Join(tmp, "stdout"))
+	assert.NoError(t, err)
+	defer out.Close()
+	errOut, err := os.Create(filepath.Join(tmp, "stderr"))
+	assert.NoError(t, err)
+	defer errOut.Close()
+
+	BookmarkResolver, MarkingResolver, NoteResolver = "chooseRight", "chooseRight", "chooseRight"
+	defer func() { BookmarkResolver, MarkingResolver, NoteResolver = "", "", "" }()
+	assert.NoError(t, merge(leftFilename, "-", "-", terminal.Stdio{In: in, Out: out, Err: errOut}))
+
+	merged := &model.Database{}
+	assert.NoError(t, merged.ImportJWLBackup(out.Name()))
+	assert.True(t, rightMultiCollision.Equals(merged))
+	messages, err := ioutil.ReadFile(errOut.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(messages), "Finished merging!")
+
+	ConflictProtocol = "jsonl"
+	defer func() { ConflictProtocol = "" }()
+	assert.Error(t, merge(leftFilename, rightFilename, "-", terminal.Stdio{In: in, Out: out, Err: errOut}))
+}
+
+func Test_mergePipe_interactive(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(leftFilename))
+	assert.NoError(t, rightDB.ExportJWLBackup(rightFilename))
+	out, err := os.Create(filepath.Join(tmp, "stdout"))
+	assert.NoError(t, err)
+	defer out.Close()
+
+	// Conflicts are shown on the terminal, so only
+	// the merged backup is written to stdout
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			c.ExpectString("📑 Merging Bookmarks")
+			c.ExpectString("1. Mose 1:1")
+			c.SendLine("")
+
+			c.ExpectString("🖍  Merging Markings")
+			c.SendLine("")
+
+			c.ExpectString("📝 Merging Notes")
+			c.SendLine("")
+
+			c.ExpectEOF()
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, merge(leftFilename, rightFilename, "-",
+				terminal.Stdio{In: c.Tty(), Out: out, Err: c.Tty()}))
+		})
+
+	stdout, err := ioutil.ReadFile(out.Name())
+	assert.NoError(t, err)
+	_, err = zip.NewReader(bytes.NewReader(stdout), int64(len(stdout)))
+	assert.NoError(t, err)
+	merged := &model.Database{}
+	assert.NoError(t, merged.ImportJWLBackupFromBytes(stdout))
+	assert.True(t, mergedAllLeftDB.Equals(merged))
+}
+
+func Test_stdinBackup(t *testing.T) {
+	filename, cleanup, err := stdinBackup("backup.jwlibrary", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "backup.jwlibrary", filename)
+	cleanup()
+
+	filename, cleanup, err = stdinBackup("-", bytes.NewBufferString("backup"))
+	assert.NoError(t, err)
+	content, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "backup", string(content))
+	cleanup()
+	assert.NoFileExists(t, filename)
+}
+
+func Test_stdoutDestination(t *testing.T) {
+	filename, write, err := stdoutDestination("merged.jwlibrary", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "merged.jwlibrary", filename)
+	assert.NoError(t, write())
+
+	out := &bytes.Buffer{}
+	filename, write, err = stdoutDestination("-", out)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("merged"), 0644))
+	assert.NoError(t, write())
+	assert.Equal(t, "merged", out.String())
+	assert.NoFileExists(t, filename)
+
+	_, write, err = stdoutDestination("-", out)
+	assert.NoError(t, err)
+	assert.Equal(t, ExitIO, ExitCode(write()))
+}
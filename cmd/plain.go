@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"golang.org/x/term"
+)
+
+// Plain disables tables, colors, and interactive prompts in favor of plain
+// text and numbered questions, so screen readers and dumb terminals can be used
+var Plain bool
+
+// readLine reads a single line from in. It reads byte by byte,
+// so nothing after the line is consumed for later questions.
+func readLine(in io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := in.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				break
+			}
+			return "", err
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
+}
+
+// askPlain asks to choose one of the numbered options and returns
+// the index of the chosen one. Entering ? shows the help, if there is one.
+func askPlain(message string, options []string, help string, in io.Reader, out io.Writer) (int, error) {
+	fmt.Fprintln(out, message)
+	for i, option := range options {
+		fmt.Fprintf(out, "%d. %s\n", i+1, option)
+	}
+
+	for {
+		if help != "" {
+			fmt.Fprint(out, i18n.T("Enter a number between 1 and %d (? for help): ", len(options)))
+		} else {
+			fmt.Fprint(out, i18n.T("Enter a number between 1 and %d: ", len(options)))
+		}
+		answer, err := readLine(in)
+		if err != nil {
+			return 0, errors.Wrap(err, "Error while reading answer")
+		}
+		answer = strings.TrimSpace(answer)
+
+		if answer == "?" && help != "" {
+			fmt.Fprintln(out, help)
+			continue
+		}
+		if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(options) {
+			return i - 1, nil
+		}
+		fmt.Fprintln(out, i18n.T("%s is not a valid choice", answer))
+	}
+}
+
+// askPlainPassword asks for a password without using an interactive
+// prompt. If in is a terminal, the password is not echoed.
+func askPlainPassword(message string, stdio terminal.Stdio) (string, error) {
+	fmt.Fprint(stdio.Out, message+" ")
+	if f, ok := stdio.In.(terminal.FileReader); ok && term.IsTerminal(int(f.Fd())) {
+		password, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(stdio.Out)
+		if err != nil {
+			return "", errors.Wrap(err, "Error while reading password")
+		}
+		return string(password), nil
+	}
+
+	password, err := readLine(stdio.In)
+	if err != nil {
+		return "", errors.Wrap(err, "Error while reading password")
+	}
+	return password, nil
+}
+
+// handlePlainMergeConflict asks which side of each conflict should be chosen
+// like handleMergeConflict, but using plain text instead of tables and
+// a numbered question instead of an interactive prompt.
+func handlePlainMergeConflict(conflicts map[string]merger.MergeConflict, mergedDB *model.Database, leftDevice string, rightDevice string, solved solvedFunc, stdio terminal.Stdio) (map[string]merger.MergeSolution, error) {
+	leftLabel := sideLabel(i18n.T("Left"), leftDevice)
+	rightLabel := sideLabel(i18n.T("Right"), rightDevice)
+
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]merger.MergeSolution, len(conflicts))
+	for i, key := range keys {
+		conflict := conflicts[key]
+		helpText := mergeConflictHelp(reflect.TypeOf(conflict.Left).String())
+
+		fmt.Fprintln(stdio.Out, i18n.T("Conflict %d of %d", i+1, len(keys)))
+		fmt.Fprintf(stdio.Out, "\n%s:\n%s\n", leftLabel,
+			strings.TrimSpace(conflict.Left.PrettyPrint(mergedDB)+prettyPrintColor(conflict.Left)+prettyPrintContext(conflict.LeftContext, conflict.Left)))
+		fmt.Fprintf(stdio.Out, "\n%s:\n%s\n\n", rightLabel,
+			strings.TrimSpace(conflict.Right.PrettyPrint(mergedDB)+prettyPrintColor(conflict.Right)+prettyPrintContext(conflict.RightContext, conflict.Right)))
+
+		selected, err := askPlain(i18n.T("Select which side should be chosen:"), []string{leftLabel, rightLabel}, helpText, stdio.In, stdio.Out)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(stdio.Out)
+
+		if selected == 0 {
+			result[key] = merger.MergeSolution{
+				Side:      merger.LeftSide,
+				Solution:  conflict.Left,
+				Discarded: conflict.Right,
+			}
+		} else {
+			result[key] = merger.MergeSolution{
+				Side:      merger.RightSide,
+				Solution:  conflict.Right,
+				Discarded: conflict.Left,
+			}
+		}
+		if solved != nil {
+			if err := solved(key, result[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
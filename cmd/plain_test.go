@@ -0,0 +1,121 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+func Test_readLine(t *testing.T) {
+	in := strings.NewReader("first\r\nsecond\nthird")
+	line, err := readLine(in)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", line)
+
+	line, err = readLine(in)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", line)
+
+	line, err = readLine(in)
+	assert.NoError(t, err)
+	assert.Equal(t, "third", line)
+
+	_, err = readLine(in)
+	assert.Error(t, err)
+}
+
+func Test_askPlain(t *testing.T) {
+	var out bytes.Buffer
+	selected, err := askPlain("Which one?", []string{"Left", "Right"}, "Some help", strings.NewReader("?\n3\nfoo\n 2 \n"), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, selected)
+	assert.Equal(t, "Which one?\n1. Left\n2. Right\n"+
+		"Enter a number between 1 and 2 (? for help): Some help\n"+
+		"Enter a number between 1 and 2 (? for help): 3 is not a valid choice\n"+
+		"Enter a number between 1 and 2 (? for help): foo is not a valid choice\n"+
+		"Enter a number between 1 and 2 (? for help): ", out.String())
+
+	out.Reset()
+	selected, err = askPlain("Which one?", []string{"Left", "Right"}, "", strings.NewReader("?\n1\n"), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, selected)
+	assert.Equal(t, "Which one?\n1. Left\n2. Right\n"+
+		"Enter a number between 1 and 2: ? is not a valid choice\n"+
+		"Enter a number between 1 and 2: ", out.String())
+
+	_, err = askPlain("Which one?", []string{"Left", "Right"}, "", strings.NewReader(""), &out)
+	assert.Error(t, err)
+}
+
+func Test_askPlainPassword(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	inFilename := filepath.Join(tmp, "in")
+	assert.NoError(t, ioutil.WriteFile(inFilename, []byte("secret\n"), 0644))
+	in, err := os.Open(inFilename)
+	assert.NoError(t, err)
+	defer in.Close()
+	out, err := os.Create(filepath.Join(tmp, "out"))
+	assert.NoError(t, err)
+	defer out.Close()
+
+	password, err := askPlainPassword("Password:", terminal.Stdio{In: in, Out: out, Err: out})
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", password)
+
+	_, err = askPlainPassword("Password:", terminal.Stdio{In: in, Out: out, Err: out})
+	assert.Error(t, err)
+
+	messages, err := ioutil.ReadFile(out.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, "Password: Password: ", string(messages))
+}
+
+func Test_mergePlain(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	mergedFilename := filepath.Join(tmp, "merged.jwlibrary")
+	assert.NoError(t, leftMultiCollision.ExportJWLBackup(leftFilename))
+	assert.NoError(t, rightMultiCollision.ExportJWLBackup(rightFilename))
+
+	inFilename := filepath.Join(tmp, "in")
+	assert.NoError(t, ioutil.WriteFile(inFilename, []byte("x\n"+strings.Repeat("2\n", 50)), 0644))
+	in, err := os.Open(inFilename)
+	assert.NoError(t, err)
+	defer in.Close()
+	out, err := os.Create(filepath.Join(tmp, "out"))
+	assert.NoError(t, err)
+	defer out.Close()
+
+	Plain = true
+	defer func() { Plain = false }()
+	assert.NoError(t, merge(leftFilename, rightFilename, mergedFilename, terminal.Stdio{In: in, Out: out, Err: out}))
+
+	messages, err := ioutil.ReadFile(out.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(messages), "Conflict 1 of ")
+	assert.Contains(t, string(messages), "x is not a valid choice")
+	assert.Contains(t, string(messages), "Finished merging!")
+	assert.NotContains(t, string(messages), "╭")
+	assert.NotContains(t, string(messages), "\x1b[")
+
+	merged := &model.Database{}
+	assert.NoError(t, merged.ImportJWLBackup(mergedFilename))
+	assert.True(t, rightMultiCollision.Equals(merged))
+}
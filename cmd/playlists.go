@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/export"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var playlistsCmd = &cobra.Command{
+	Use:   "playlists <backup> <dest>",
+	Short: "Export the playlists of a JW Library backup",
+	Long: `playlists reads the playlists of the given .jwlibrary backup file and
+exports them, so they can be inspected or rebuilt outside of JW Library.
+The following formats are supported:
+  - json: a single JSON file containing all playlists with their items,
+    including trimming, markers, and the publication of their media
+  - m3u: a directory containing an extended M3U playlist per playlist.
+    Pictures and videos added by yourself are referenced by their filename
+    within the backup, media of publications by a jwlm:publication URI`,
+	Example: `go-jwlm playlists backup.jwlibrary playlists.json
+go-jwlm playlists backup.jwlibrary playlists --format m3u`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		dest := args[1]
+		return exportPlaylists(filename, dest, PlaylistFormat, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+// PlaylistFormat represents the format the playlists command should produce
+var PlaylistFormat string
+
+// playlistExporters contains the supported playlist formats
+var playlistExporters = map[string]func(playlists []model.Playlist, dest string) error{
+	"json": func(playlists []model.Playlist, dest string) error {
+		return exportToFile(dest, func(w io.Writer) error {
+			return export.PlaylistsJSON(playlists, w)
+		})
+	},
+	"m3u": export.PlaylistsM3U,
+}
+
+func exportPlaylists(filename string, dest string, format string, stdio terminal.Stdio) error {
+	exporter, ok := playlistExporters[format]
+	if !ok {
+		return errors.Errorf("Playlist format %s is not supported", format)
+	}
+
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	playlists, err := model.ReadPlaylists(filename)
+	if err != nil {
+		return invalidBackupError(err)
+	}
+
+	if err := exporter(playlists, dest); err != nil {
+		return ioError(err)
+	}
+
+	fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Exported %d playlists", len(playlists)))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(playlistsCmd)
+	playlistsCmd.Flags().StringVar(&PlaylistFormat, "format", "json", "Format of the export (can be 'json' or 'm3u')")
+	playlistsCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
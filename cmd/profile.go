@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+
+	"github.com/pkg/errors"
+)
+
+// CPUProfile is the file the CPU profile of the command is written to
+var CPUProfile string
+
+// MemProfile is the file the heap profile is written to
+// after the command has finished
+var MemProfile string
+
+// TraceFile is the file the execution trace of the command is written to
+var TraceFile string
+
+// stopProfiling stops the profiles started by startProfiling
+// and writes them to their files. It is nil if none are running.
+var stopProfiling func() error
+
+// startProfiling starts writing a CPU profile and an execution trace to
+// the given files (if set), so users can send them along with reports
+// about slow merges. The returned function stops them and writes the
+// heap profile to memFile.
+func startProfiling(cpuFile string, memFile string, traceFile string) (func() error, error) {
+	var stops []func() error
+	stop := func() error {
+		var firstErr error
+		for _, s := range stops {
+			if err := s(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	if cpuFile != "" {
+		f, err := os.Create(cpuFile)
+		if err != nil {
+			return nil, ioError(errors.Wrap(err, "Error while creating CPU profile"))
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, errors.Wrap(err, "Error while starting CPU profile")
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return ioError(f.Close())
+		})
+	}
+
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err != nil {
+			stop()
+			return nil, ioError(errors.Wrap(err, "Error while creating trace"))
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			stop()
+			return nil, errors.Wrap(err, "Error while starting trace")
+		}
+		stops = append(stops, func() error {
+			trace.Stop()
+			return ioError(f.Close())
+		})
+	}
+
+	if memFile != "" {
+		stops = append(stops, func() error {
+			return writeHeapProfile(memFile)
+		})
+	}
+
+	return stop, nil
+}
+
+// writeHeapProfile writes the current heap profile to filename
+func writeHeapProfile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return ioError(errors.Wrap(err, "Error while creating memory profile"))
+	}
+
+	// Get up-to-date statistics of the allocations
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return ioError(errors.Wrap(err, "Error while writing memory profile"))
+	}
+	return ioError(f.Close())
+}
@@ -0,0 +1,48 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func Test_startProfiling(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	cpuFile := filepath.Join(tmp, "cpu.pprof")
+	memFile := filepath.Join(tmp, "mem.pprof")
+	traceFile := filepath.Join(tmp, "trace.out")
+	stop, err := startProfiling(cpuFile, memFile, traceFile)
+	assert.NoError(t, err)
+	assert.NoError(t, leftMultiCollision.ExportJWLBackup(filepath.Join(tmp, "backup.jwlibrary")))
+	assert.NoError(t, stop())
+	for _, filename := range []string{cpuFile, memFile, traceFile} {
+		info, err := os.Stat(filename)
+		assert.NoError(t, err)
+		assert.NotZero(t, info.Size(), filename)
+	}
+
+	// Nothing is written if no file is given
+	stop, err = startProfiling("", "", "")
+	assert.NoError(t, err)
+	assert.NoError(t, stop())
+
+	// Profiles that have already been started are stopped on errors
+	_, err = startProfiling(cpuFile, "", filepath.Join(tmp, "missing", "trace.out"))
+	assert.Equal(t, ExitIO, ExitCode(err))
+	stop, err = startProfiling(cpuFile, "", "")
+	assert.NoError(t, err)
+	assert.NoError(t, stop())
+
+	stop, err = startProfiling("", filepath.Join(tmp, "missing", "mem.pprof"), "")
+	assert.NoError(t, err)
+	assert.Equal(t, ExitIO, ExitCode(stop()))
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// ConflictProtocol is the machine-readable protocol used for solving
+// conflicts instead of asking the user. Currently, only 'jsonl' is supported.
+var ConflictProtocol string
+
+// protocolMessage is a message sent by go-jwlm as one line of JSON.
+// Messages of go-jwlm serve carry the ID of the request they belong to.
+type protocolMessage struct {
+	Type        string        `json:"type"`
+	ID          string        `json:"id,omitempty"`
+	Stage       string        `json:"stage,omitempty"`
+	Key         string        `json:"key,omitempty"`
+	Left        *protocolSide `json:"left,omitempty"`
+	Right       *protocolSide `json:"right,omitempty"`
+	Side        string        `json:"side,omitempty"`
+	Resolver    string        `json:"resolver,omitempty"`
+	Destination string        `json:"destination,omitempty"`
+	Version     string        `json:"version,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Code        int           `json:"code,omitempty"`
+}
+
+// protocolSide is one side of a conflict together with its related
+// entries and the name of the device the backup has been created on
+type protocolSide struct {
+	Model   model.Model   `json:"model"`
+	Related model.Related `json:"related"`
+	Device  string        `json:"device,omitempty"`
+	// Context contains the tags and the location of notes
+	Context *merger.ConflictContext `json:"context,omitempty"`
+}
+
+// protocolResolution is the answer to a conflict, where
+// Side is either 'leftSide' or 'rightSide'
+type protocolResolution struct {
+	Key  string `json:"key"`
+	Side string `json:"side"`
+}
+
+// jsonlProtocol solves conflicts by writing each of them as a line of
+// JSON to out and reading the resolution as a line of JSON from in, so
+// other programs (like GUIs) can drive an interactive merge.
+type jsonlProtocol struct {
+	in  *bufio.Reader
+	out *json.Encoder
+
+	// Names of the devices the left and right backup have been created on
+	leftDevice  string
+	rightDevice string
+}
+
+func newJSONLProtocol(in io.Reader, out io.Writer) *jsonlProtocol {
+	return &jsonlProtocol{
+		in:  bufio.NewReader(in),
+		out: json.NewEncoder(out),
+	}
+}
+
+// resolve sends the given conflicts sorted by their key and waits
+// for the resolution of each conflict before sending the next one.
+// If solved is not nil, it is called with each resolution.
+func (p *jsonlProtocol) resolve(conflicts map[string]merger.MergeConflict, mergedDB *model.Database, solved solvedFunc) (map[string]merger.MergeSolution, error) {
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]merger.MergeSolution, len(conflicts))
+	for _, key := range keys {
+		conflict := conflicts[key]
+		err := p.out.Encode(conflictMessage(key, conflict, mergedDB, p.leftDevice, p.rightDevice))
+		if err != nil {
+			return nil, errors.Wrap(err, "Error while sending conflict")
+		}
+
+		line, err := p.in.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			return nil, errors.Wrapf(err, "Error while reading resolution of conflict %s", key)
+		}
+		var res protocolResolution
+		if err := json.Unmarshal(line, &res); err != nil {
+			return nil, errors.Wrapf(err, "Error while parsing resolution of conflict %s", key)
+		}
+		if res.Key != key {
+			return nil, errors.Errorf("Expected resolution of conflict %s, got %s", key, res.Key)
+		}
+
+		result[key], err = protocolSolution(conflict, res.Side)
+		if err != nil {
+			return nil, err
+		}
+		if solved != nil {
+			if err := solved(key, result[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// conflictMessage creates the message of a conflict
+// together with the related entries of both sides
+func conflictMessage(key string, conflict merger.MergeConflict, mergedDB *model.Database, leftDevice string, rightDevice string) protocolMessage {
+	return protocolMessage{
+		Type:  "conflict",
+		Key:   key,
+		Left:  &protocolSide{Model: conflict.Left, Related: conflict.Left.RelatedEntries(mergedDB), Device: leftDevice, Context: conflict.LeftContext},
+		Right: &protocolSide{Model: conflict.Right, Related: conflict.Right.RelatedEntries(mergedDB), Device: rightDevice, Context: conflict.RightContext},
+	}
+}
+
+// protocolSolution returns the solution of the conflict
+// for a side given as 'leftSide' or 'rightSide'
+func protocolSolution(conflict merger.MergeConflict, side string) (merger.MergeSolution, error) {
+	switch side {
+	case "leftSide":
+		return merger.MergeSolution{
+			Side:      merger.LeftSide,
+			Solution:  conflict.Left,
+			Discarded: conflict.Right,
+		}, nil
+	case "rightSide":
+		return merger.MergeSolution{
+			Side:      merger.RightSide,
+			Solution:  conflict.Right,
+			Discarded: conflict.Left,
+		}, nil
+	}
+	return merger.MergeSolution{}, errors.Errorf("Side %s is not valid", side)
+}
+
+// finish signals that the merged backup has been written to destination
+func (p *jsonlProtocol) finish(destination string) error {
+	return p.out.Encode(protocolMessage{Type: "finished", Destination: destination})
+}
@@ -0,0 +1,123 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+func Test_mergeJSONLProtocol(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	mergedFilename := filepath.Join(tmp, "merged.jwlibrary")
+	assert.NoError(t, leftMultiCollision.ExportJWLBackup(leftFilename))
+	assert.NoError(t, rightMultiCollision.ExportJWLBackup(rightFilename))
+
+	inR, inW, err := os.Pipe()
+	assert.NoError(t, err)
+	outR, outW, err := os.Pipe()
+	assert.NoError(t, err)
+	errOut, err := os.Create(filepath.Join(tmp, "stderr"))
+	assert.NoError(t, err)
+	defer errOut.Close()
+
+	ConflictProtocol = "jsonl"
+	defer func() { ConflictProtocol = "" }()
+	go func() {
+		assert.NoError(t, merge(leftFilename, rightFilename, mergedFilename, terminal.Stdio{In: inR, Out: outW, Err: errOut}))
+		outW.Close()
+	}()
+
+	conflicts := 0
+	var finished protocolMessage
+	scanner := bufio.NewScanner(outR)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var msg map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		switch msg["type"] {
+		case "conflict":
+			conflicts++
+			assert.Contains(t, msg["left"], "model")
+			assert.Contains(t, msg["right"], "related")
+			fmt.Fprintf(inW, "{\"key\": %q, \"side\": \"rightSide\"}\n", msg["key"])
+		case "finished":
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &finished))
+		default:
+			t.Errorf("Unexpected message %s", scanner.Text())
+		}
+	}
+	assert.NoError(t, scanner.Err())
+	assert.Greater(t, conflicts, 0)
+	assert.Equal(t, mergedFilename, finished.Destination)
+
+	// Human-readable messages are written to stderr instead
+	messages, err := ioutil.ReadFile(errOut.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(messages), "Finished merging!")
+
+	merged := &model.Database{}
+	assert.NoError(t, merged.ImportJWLBackup(mergedFilename))
+	assert.True(t, rightMultiCollision.Equals(merged))
+}
+
+func Test_jsonlProtocol_resolve(t *testing.T) {
+	conflicts := map[string]merger.MergeConflict{
+		"b": {Left: leftDB.Note[1], Right: rightDB.Note[1]},
+		"a": {Left: leftDB.Note[1], Right: rightDB.Note[1]},
+	}
+
+	out := &bytes.Buffer{}
+	p := newJSONLProtocol(strings.NewReader("{\"key\": \"a\", \"side\": \"leftSide\"}\n{\"key\": \"b\", \"side\": \"rightSide\"}"), out)
+	solutions, err := p.resolve(conflicts, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, merger.LeftSide, solutions["a"].Side)
+	assert.Equal(t, leftDB.Note[1], solutions["a"].Solution)
+	assert.Equal(t, merger.RightSide, solutions["b"].Side)
+	assert.Equal(t, rightDB.Note[1], solutions["b"].Solution)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], `{"type":"conflict","key":"a",`))
+	assert.True(t, strings.HasPrefix(lines[1], `{"type":"conflict","key":"b",`))
+
+	// Names of the devices are included if they are known
+	assert.NotContains(t, lines[0], `"device"`)
+	out.Reset()
+	p = newJSONLProtocol(strings.NewReader("{\"key\": \"a\", \"side\": \"leftSide\"}\n{\"key\": \"b\", \"side\": \"rightSide\"}"), out)
+	p.leftDevice, p.rightDevice = "iPhone", "iPad"
+	_, err = p.resolve(conflicts, nil, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), `"device":"iPhone"`)
+	assert.Contains(t, out.String(), `"device":"iPad"`)
+
+	p = newJSONLProtocol(strings.NewReader("{\"key\": \"b\", \"side\": \"leftSide\"}\n"), &bytes.Buffer{})
+	_, err = p.resolve(conflicts, nil, nil)
+	assert.EqualError(t, err, "Expected resolution of conflict a, got b")
+
+	p = newJSONLProtocol(strings.NewReader("{\"key\": \"a\", \"side\": \"middle\"}\n"), &bytes.Buffer{})
+	_, err = p.resolve(conflicts, nil, nil)
+	assert.EqualError(t, err, "Side middle is not valid")
+
+	p = newJSONLProtocol(strings.NewReader(""), &bytes.Buffer{})
+	_, err = p.resolve(conflicts, nil, nil)
+	assert.EqualError(t, err, "Error while reading resolution of conflict a: EOF")
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/spf13/cobra"
+)
+
+var remapLanguageCmd = &cobra.Command{
+	Use:   "remap-language <backup> <dest-backup>",
+	Short: "Move highlights, notes, and bookmarks to another language edition",
+	Long: `remap-language imports the given .jwlibrary backup file, moves the locations
+of one language to another one, and stores the result as a new backup.
+This is useful if you switched your study language and want to see your
+Bible highlights, notes, and bookmarks in the new language edition.
+Languages are given by their MEPS language ID (like 0 for English).
+
+By default, only Bibles are remapped, as their verses are the same in
+every language. With --documents, other publications are remapped as
+well, which only makes sense if both editions share the same paragraphs.
+If a publication uses a different symbol in the new language, map it with
+--key-symbol. As bookmarks might end up in the same slot, consider running
+the repair command afterwards.`,
+	Example: `go-jwlm remap-language backup.jwlibrary remapped.jwlibrary --from 2 --to 0
+go-jwlm remap-language backup.jwlibrary remapped.jwlibrary --from 2 --to 0 --key-symbol nwt=nwtsty`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		destFilename := args[1]
+		return remapLanguage(filename, destFilename, RemapLanguage, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+// RemapLanguage describes how the remap-language command moves locations
+var RemapLanguage model.LanguageRemap
+
+func remapLanguage(filename string, destFilename string, remap model.LanguageRemap, stdio terminal.Stdio) error {
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db := &model.Database{}
+	err = importDatabase(db, filename)
+	if err != nil {
+		return err
+	}
+
+	count, err := db.RemapLanguage(remap)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdio.Out, i18n.T("Exporting backup"))
+	if err := db.ExportJWLBackup(destFilename); err != nil {
+		return ioError(err)
+	}
+
+	fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Remapped %d locations", count))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(remapLanguageCmd)
+	remapLanguageCmd.Flags().IntVar(&RemapLanguage.From, "from", 0, "MEPS language ID of the locations to remap")
+	remapLanguageCmd.Flags().IntVar(&RemapLanguage.To, "to", 0, "MEPS language ID the locations should be remapped to")
+	remapLanguageCmd.Flags().StringToStringVar(&RemapLanguage.KeySymbols, "key-symbol", nil, "publication symbol that differs in the new language, like nwt=nwtsty")
+	remapLanguageCmd.Flags().BoolVar(&RemapLanguage.Documents, "documents", false, "remap other publications than Bibles as well")
+	remapLanguageCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+	remapLanguageCmd.MarkFlagRequired("from")
+	remapLanguageCmd.MarkFlagRequired("to")
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_remapLanguage(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "left.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(filename))
+	destFilename := filepath.Join(tmp, "remapped.jwlibrary")
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Exporting backup")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Remapped 2 locations")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, remapLanguage(filename, destFilename, model.LanguageRemap{From: 2, To: 0},
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	remapped := &model.Database{}
+	assert.NoError(t, remapped.ImportJWLBackup(destFilename))
+	assert.Equal(t, 0, remapped.Location[1].MepsLanguage)
+	assert.Equal(t, 0, remapped.Location[2].MepsLanguage)
+	assert.Equal(t, leftDB.Note[1], remapped.Note[1])
+	assert.Equal(t, leftDB.Bookmark[1], remapped.Bookmark[1])
+}
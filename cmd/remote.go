@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/AndreasSko/go-jwlm/storage"
+)
+
+// downloadRemoteBackup downloads the given backup into a temporary
+// directory if it is an https:// URL or points to a remote storage
+// (like dav:// or s3://), so it only has to be downloaded once if it is
+// used several times. It returns the path of the local backup together
+// with a function to remove the temporary directory.
+func downloadRemoteBackup(filename string) (string, func(), error) {
+	if !model.IsRemoteBackup(filename) && !storage.IsRemote(filename) {
+		return filename, func() {}, nil
+	}
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return "", nil, ioError(err)
+	}
+	var local string
+	if storage.IsRemote(filename) {
+		local, err = storage.Download(filename, tmp)
+	} else {
+		local, err = model.DownloadBackup(filename, tmp)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		return "", nil, ioError(err)
+	}
+	return local, func() { os.RemoveAll(tmp) }, nil
+}
+
+// remoteDestination returns a local path that should be written to
+// instead of the given destination if it points to a remote storage.
+// After writing, the returned function uploads the file to the remote
+// storage and removes the local one. For local destinations, the path
+// is returned unchanged.
+func remoteDestination(dest string) (string, func() error, error) {
+	if !storage.IsRemote(dest) {
+		return dest, func() error { return nil }, nil
+	}
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return "", nil, ioError(err)
+	}
+	local := filepath.Join(tmp, path.Base(dest))
+	return local, func() error {
+		err := storage.Upload(local, dest)
+		os.RemoveAll(tmp)
+		return ioError(err)
+	}, nil
+}
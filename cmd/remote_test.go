@@ -0,0 +1,101 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_mergeRemote(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	emptyFilename := filepath.Join(tmp, "empty.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(leftFilename))
+	assert.NoError(t, emptyDB.ExportJWLBackup(emptyFilename))
+	left, err := ioutil.ReadFile(leftFilename)
+	assert.NoError(t, err)
+	empty, err := ioutil.ReadFile(emptyFilename)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	files := map[string][]byte{
+		"/dav/left.jwlibrary":  left,
+		"/dav/empty.jwlibrary": empty,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			content, ok := files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(content)
+		case http.MethodPut:
+			files[r.URL.Path], _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+	remote := "dav://" + strings.TrimPrefix(server.URL, "http://") + "/dav/"
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Finished merging!")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, merge(remote+"left.jwlibrary", remote+"empty.jwlibrary", remote+"merged.jwlibrary",
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+		})
+
+	mu.Lock()
+	merged, ok := files["/dav/merged.jwlibrary"]
+	mu.Unlock()
+	assert.True(t, ok)
+	mergedFilename := filepath.Join(tmp, "merged.jwlibrary")
+	assert.NoError(t, ioutil.WriteFile(mergedFilename, merged, 0644))
+	mergedDB := &model.Database{}
+	assert.NoError(t, mergedDB.ImportJWLBackup(mergedFilename))
+	assert.True(t, leftDB.Equals(mergedDB))
+}
+
+func Test_downloadRemoteBackup(t *testing.T) {
+	filename, cleanup, err := downloadRemoteBackup("backup.jwlibrary")
+	assert.NoError(t, err)
+	assert.Equal(t, "backup.jwlibrary", filename)
+	cleanup()
+
+	dest, upload, err := remoteDestination("merged.jwlibrary")
+	assert.NoError(t, err)
+	assert.Equal(t, "merged.jwlibrary", dest)
+	assert.NoError(t, upload())
+
+	dest, _, err = remoteDestination("s3://bucket/merged.jwlibrary")
+	assert.NoError(t, err)
+	assert.Equal(t, "merged.jwlibrary", filepath.Base(dest))
+	assert.NotEqual(t, "merged.jwlibrary", dest)
+	os.RemoveAll(filepath.Dir(dest))
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/spf13/cobra"
+)
+
+var repackageCmd = &cobra.Command{
+	Use:   "repackage <backup> <user_data.db> <dest-backup>",
+	Short: "Replace the database of a backup with a modified one",
+	Long: `repackage creates a new backup out of the given .jwlibrary backup file,
+replacing its database with the given SQLite file. This is useful if you
+fixed the database of a backup by hand or with other tools, as JW Library
+only restores backups whose manifest matches the database. The manifest is
+updated accordingly, while the thumbnail and media files of the backup
+are kept.`,
+	Example: `go-jwlm repackage backup.jwlibrary user_data.db fixed.jwlibrary`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return repackage(args[0], args[1], args[2], terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(3),
+}
+
+func repackage(filename string, dbFile string, destFilename string, stdio terminal.Stdio) error {
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	fmt.Fprintln(stdio.Out, i18n.T("Exporting backup"))
+	if err := model.RepackageBackup(filename, dbFile, destFilename); err != nil {
+		return err
+	}
+
+	hash, err := model.ComputeBackupHash(dbFile)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Repackaged database with hash %s", hash))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(repackageCmd)
+	repackageCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
@@ -0,0 +1,67 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_repackage(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "left.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(filename))
+
+	// Take the database of another backup as the modified one
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	assert.NoError(t, rightDB.ExportJWLBackup(rightFilename))
+	r, err := zip.OpenReader(rightFilename)
+	assert.NoError(t, err)
+	defer r.Close()
+	dbFile := filepath.Join(tmp, "modified.db")
+	for _, file := range r.File {
+		if file.Name != "user_data.db" {
+			continue
+		}
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(reader)
+		assert.NoError(t, err)
+		reader.Close()
+		assert.NoError(t, ioutil.WriteFile(dbFile, content, 0644))
+	}
+	hash, err := model.ComputeBackupHash(dbFile)
+	assert.NoError(t, err)
+
+	destFilename := filepath.Join(tmp, "repackaged.jwlibrary")
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🎉 Repackaged database with hash " + hash)
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, repackage(filename, dbFile, destFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	repackaged := &model.Database{}
+	assert.NoError(t, repackaged.ImportJWLBackup(destFilename))
+	assert.True(t, rightDB.Equals(repackaged))
+}
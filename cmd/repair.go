@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/lint"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/spf13/cobra"
+)
+
+var repairCmd = &cobra.Command{
+	Use:   "repair <backup> <dest-backup>",
+	Short: "Repair orphaned and inconsistent entries of a JW Library backup",
+	Long: `repair imports the given .jwlibrary backup file, applies safe fixes for the
+anomalies found by the lint command, and stores the result as a new backup.
+For example, it removes tags of entries that don't exist, reattaches notes
+and highlights to the location of each other, moves duplicate bookmarks to
+free slots, removes bookmarks pointing at the same place as another one in
+a different slot, and generates new GUIDs for empty or duplicate ones. Every
+change is printed, so you can review it before restoring the new backup.
+
+With --compact-bookmarks, the bookmark slots of every publication are
+renumbered afterwards to be contiguous starting at the first slot, so slots
+freed by removed bookmarks can be used again.`,
+	Example: `go-jwlm repair backup.jwlibrary repaired.jwlibrary
+go-jwlm repair backup.jwlibrary repaired.jwlibrary --compact-bookmarks`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		destFilename := args[1]
+		return repair(filename, destFilename, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(2),
+}
+
+// CompactBookmarks renumbers the bookmark slots of every publication
+// to be contiguous after repairing a backup
+var CompactBookmarks bool
+
+func repair(filename string, destFilename string, stdio terminal.Stdio) error {
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db := &model.Database{}
+	err = importDatabase(db, filename)
+	if err != nil {
+		return err
+	}
+
+	changes, err := lint.Repair(db)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		fmt.Fprintln(stdio.Out, "🔧 "+change.String())
+	}
+	made := len(changes)
+	if CompactBookmarks {
+		moved := db.CompactBookmarkSlots()
+		if len(moved) > 0 {
+			fmt.Fprintln(stdio.Out, "🔧 "+i18n.T("Compacted the bookmark slots, moving %d bookmarks", len(moved)))
+		}
+		made += len(moved)
+	}
+
+	fmt.Fprintln(stdio.Out, i18n.T("Exporting backup"))
+	if err := db.ExportJWLBackup(destFilename); err != nil {
+		return ioError(err)
+	}
+
+	if made == 0 {
+		fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Nothing to repair"))
+		return nil
+	}
+	fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Made %d changes", made))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(repairCmd)
+	repairCmd.Flags().BoolVar(&CompactBookmarks, "compact-bookmarks", false, "Renumber the bookmark slots of every publication to be contiguous")
+	repairCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
@@ -0,0 +1,97 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_repair(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := model.MakeDatabaseCopy(leftDB)
+	db.Note[1].GUID = ""
+	filename := filepath.Join(tmp, "broken.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(filename))
+	destFilename := filepath.Join(tmp, "repaired.jwlibrary")
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🔧 Note 1: Generated the new GUID")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Exporting backup")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Made 1 changes")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, repair(filename, destFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	repaired := &model.Database{}
+	assert.NoError(t, repaired.ImportJWLBackup(destFilename))
+	assert.Len(t, repaired.Note[1].GUID, 36)
+	assert.Equal(t, db.Note[2], repaired.Note[2])
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Nothing to repair")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, repair(destFilename, filepath.Join(tmp, "again.jwlibrary"), terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+}
+
+func Test_repairCompactBookmarks(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := model.MakeDatabaseCopy(leftDB)
+	db.Bookmark[1].Slot = 4
+	filename := filepath.Join(tmp, "gaps.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(filename))
+	destFilename := filepath.Join(tmp, "compacted.jwlibrary")
+
+	CompactBookmarks = true
+	defer func() { CompactBookmarks = false }()
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("🔧 Compacted the bookmark slots, moving 1 bookmarks")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Made 1 changes")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, repair(filename, destFilename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	compacted := &model.Database{}
+	assert.NoError(t, compacted.ImportJWLBackup(destFilename))
+	assert.Equal(t, 0, compacted.Bookmark[1].Slot)
+}
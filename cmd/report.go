@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/export"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report <backup>",
+	Short: "Summarize the highlights of a JW Library backup",
+	Long: `report imports the given .jwlibrary backup file and prints a summary of
+its highlights per publication and per color, together with the most
+highlighted Bible chapters. The report can be rendered as Markdown or JSON.`,
+	Example: `go-jwlm report backup.jwlibrary
+go-jwlm report backup.jwlibrary --format json > report.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		return report(filename, ReportFormat, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+// ReportFormat represents the format the report should be rendered in
+var ReportFormat string
+
+func report(filename string, format string, stdio terminal.Stdio) error {
+	if format != "markdown" && format != "json" {
+		return errors.Errorf("Report format %s is not supported", format)
+	}
+
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db := &model.Database{}
+	err = importDatabase(db, filename)
+	if err != nil {
+		return err
+	}
+
+	r := export.HighlightReport(db)
+	switch format {
+	case "markdown":
+		err = r.Markdown(stdio.Out)
+	case "json":
+		err = r.JSON(stdio.Out)
+	}
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVar(&ReportFormat, "format", "markdown", "Format of the report (can be 'markdown' or 'json')")
+	reportCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
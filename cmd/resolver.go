@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"sort"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// ResolverCommand is an external command that resolves conflicts
+// (see resolverCommand)
+var ResolverCommand string
+
+// resolverRequest is written as JSON to the stdin of the resolver command
+type resolverRequest struct {
+	Stage     string            `json:"stage"`
+	Conflicts []protocolMessage `json:"conflicts"`
+}
+
+// resolverResponse is read as JSON from the stdout of the resolver command
+type resolverResponse struct {
+	Resolutions []protocolResolution `json:"resolutions"`
+}
+
+// resolverCommand solves conflicts using an external command, so conflict
+// resolution can be written in any language. For every merge stage with
+// conflicts, the command is started and gets all of them at once as JSON
+// on stdin, using the messages of the jsonl protocol. It answers with the
+// resolutions as JSON on stdout. Conflicts it doesn't answer are left
+// unresolved, so they can be solved otherwise.
+type resolverCommand struct {
+	command string
+	// stderr receives the stderr of the command
+	stderr io.Writer
+
+	// Names of the devices the left and right backup have been created on
+	leftDevice  string
+	rightDevice string
+}
+
+// resolve runs the command for the conflicts of the given stage
+// and returns the solutions of the conflicts it resolved
+func (r *resolverCommand) resolve(stage string, conflicts map[string]merger.MergeConflict, mergedDB *model.Database) (map[string]merger.MergeSolution, error) {
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	req := resolverRequest{Stage: stage, Conflicts: make([]protocolMessage, 0, len(keys))}
+	for _, key := range keys {
+		conflict := conflicts[key]
+		req.Conflicts = append(req.Conflicts, protocolMessage{
+			Type:  "conflict",
+			Key:   key,
+			Left:  &protocolSide{Model: conflict.Left, Related: conflict.Left.RelatedEntries(mergedDB), Device: r.leftDevice, Context: conflict.LeftContext},
+			Right: &protocolSide{Model: conflict.Right, Related: conflict.Right.RelatedEntries(mergedDB), Device: r.rightDevice, Context: conflict.RightContext},
+		})
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while marshalling conflicts")
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(r.command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &output
+	cmd.Stderr = r.stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "Error while running resolver command %s", r.command)
+	}
+
+	var res resolverResponse
+	if err := json.Unmarshal(output.Bytes(), &res); err != nil {
+		return nil, errors.Wrapf(err, "Error while parsing resolutions of resolver command %s", r.command)
+	}
+
+	result := make(map[string]merger.MergeSolution, len(res.Resolutions))
+	for _, resolution := range res.Resolutions {
+		conflict, ok := conflicts[resolution.Key]
+		if !ok {
+			return nil, errors.Errorf("Resolver command %s resolved the unknown conflict %s", r.command, resolution.Key)
+		}
+		result[resolution.Key], err = protocolSolution(conflict, resolution.Side)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// unresolvedConflicts returns the conflicts that don't have a solution
+func unresolvedConflicts(conflicts map[string]merger.MergeConflict, solutions map[string]merger.MergeSolution) map[string]merger.MergeConflict {
+	unresolved := map[string]merger.MergeConflict{}
+	for key, conflict := range conflicts {
+		if _, ok := solutions[key]; !ok {
+			unresolved[key] = conflict
+		}
+	}
+	return unresolved
+}
@@ -0,0 +1,126 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+// resolveAllScript answers every conflict it gets with the given side
+const resolveAllScript = `#!/bin/sh
+keys=$(grep -o '"key":"[^"]*"' | sed 's/"key"://')
+printf '{"resolutions":['
+sep=""
+for key in $keys; do
+	printf '%s{"key":%s,"side":"%s"}' "$sep" "$key" "$1"
+	sep=","
+done
+printf ']}'
+`
+
+func Test_resolverCommand_resolve(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	inputFilename := filepath.Join(tmp, "input.json")
+	writeResolver := func(name string, script string) string {
+		filename := filepath.Join(tmp, name)
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(script), 0755))
+		return filename
+	}
+
+	conflicts := map[string]merger.MergeConflict{
+		"a": {Left: &model.Note{NoteID: 1, GUID: "a"}, Right: &model.Note{NoteID: 2, GUID: "a"}},
+		"b": {Left: &model.Note{NoteID: 3, GUID: "b"}, Right: &model.Note{NoteID: 4, GUID: "b"}},
+	}
+
+	var stderr bytes.Buffer
+	r := &resolverCommand{
+		command: writeResolver("partial.sh", "#!/bin/sh\ncat > "+inputFilename+"\necho resolving >&2\n"+
+			`echo '{"resolutions": [{"key": "b", "side": "rightSide"}]}'`+"\n"),
+		stderr:      &stderr,
+		leftDevice:  "iPhone",
+		rightDevice: "iPad",
+	}
+	solutions, err := r.resolve(merger.StageNotes, conflicts, &model.Database{})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]merger.MergeSolution{
+		"b": {Side: merger.RightSide, Solution: conflicts["b"].Right, Discarded: conflicts["b"].Left},
+	}, solutions)
+	assert.Equal(t, "resolving\n", stderr.String())
+	assert.Equal(t, map[string]merger.MergeConflict{"a": conflicts["a"]}, unresolvedConflicts(conflicts, solutions))
+
+	input, err := ioutil.ReadFile(inputFilename)
+	assert.NoError(t, err)
+	var req map[string]interface{}
+	assert.NoError(t, json.Unmarshal(input, &req))
+	assert.Equal(t, "Notes", req["stage"])
+	assert.Len(t, req["conflicts"], 2)
+	first := req["conflicts"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "conflict", first["type"])
+	assert.Equal(t, "a", first["key"])
+	assert.Equal(t, "iPhone", first["left"].(map[string]interface{})["device"])
+	assert.Equal(t, "iPad", first["right"].(map[string]interface{})["device"])
+	assert.Contains(t, first["left"], "related")
+
+	r.command = writeResolver("unknown.sh", "#!/bin/sh\n"+`echo '{"resolutions": [{"key": "c", "side": "rightSide"}]}'`+"\n")
+	_, err = r.resolve(merger.StageNotes, conflicts, nil)
+	assert.EqualError(t, err, "Resolver command "+r.command+" resolved the unknown conflict c")
+
+	r.command = writeResolver("side.sh", "#!/bin/sh\n"+`echo '{"resolutions": [{"key": "a", "side": "middle"}]}'`+"\n")
+	_, err = r.resolve(merger.StageNotes, conflicts, nil)
+	assert.EqualError(t, err, "Side middle is not valid")
+
+	r.command = writeResolver("invalid.sh", "#!/bin/sh\necho nope\n")
+	_, err = r.resolve(merger.StageNotes, conflicts, nil)
+	assert.Error(t, err)
+
+	r.command = writeResolver("failing.sh", "#!/bin/sh\nexit 2\n")
+	_, err = r.resolve(merger.StageNotes, conflicts, nil)
+	assert.Error(t, err)
+}
+
+func Test_mergeResolverCommand(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	mergedFilename := filepath.Join(tmp, "merged.jwlibrary")
+	assert.NoError(t, leftMultiCollision.ExportJWLBackup(leftFilename))
+	assert.NoError(t, rightMultiCollision.ExportJWLBackup(rightFilename))
+
+	resolver := filepath.Join(tmp, "resolve.sh")
+	assert.NoError(t, ioutil.WriteFile(resolver, []byte(resolveAllScript), 0755))
+	wrapper := filepath.Join(tmp, "resolveRight.sh")
+	assert.NoError(t, ioutil.WriteFile(wrapper, []byte("#!/bin/sh\nexec "+resolver+" rightSide\n"), 0755))
+
+	ResolverCommand = wrapper
+	defer func() { ResolverCommand = "" }()
+	out, err := os.Create(filepath.Join(tmp, "out"))
+	assert.NoError(t, err)
+	defer out.Close()
+	assert.NoError(t, merge(leftFilename, rightFilename, mergedFilename, terminal.Stdio{In: os.Stdin, Out: out, Err: out}))
+	messages, err := ioutil.ReadFile(out.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(messages), "Finished merging!")
+
+	merged := &model.Database{}
+	assert.NoError(t, merged.ImportJWLBackup(mergedFilename))
+	assert.True(t, rightMultiCollision.Equals(merged))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/AndreasSko/go-jwlm/i18n"
 	"github.com/spf13/cobra"
 
 	homedir "github.com/mitchellh/go-homedir"
@@ -12,29 +13,103 @@ import (
 
 var cfgFile string
 
+// Lang is the language messages and prompts are shown in
+var Lang string
+
+// TranslationsFile is a JSON file with additional translations
+var TranslationsFile string
+
+// Version is the version of go-jwlm. It is set at build time.
+var Version = "dev"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "go-jwlm",
 	Short: "A utility to merge multiple JW Library backup files",
+	Long: `A utility to merge multiple JW Library backup files.
+
+If a command fails, go-jwlm exits with one of the following codes:
+  1: any error without a more specific exit code, like invalid flags
+  2: a backup is damaged or not supported
+  3: conflicts could not be solved, e.g. because the prompt was interrupted
+  4: reading or writing a file failed`,
+	// Errors are printed by Execute
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// The arguments are fine at this point, so
+		// errors of the command should not show its usage
+		cmd.SilenceUsage = true
+
+		if CPUProfile == "" && MemProfile == "" && TraceFile == "" {
+			return nil
+		}
+		stop, err := startProfiling(CPUProfile, MemProfile, TraceFile)
+		if err != nil {
+			return err
+		}
+		stopProfiling = stop
+		return nil
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// If a command fails, go-jwlm exits with the code returned by ExitCode.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	rootCmd.Version = Version
+	err := rootCmd.Execute()
+	// Profiles are also written if the command failed, as
+	// they might show why it took so long to do so
+	if stopProfiling != nil {
+		if stopErr := stopProfiling(); err == nil {
+			err = stopErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(ExitCode(err))
 	}
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, initLanguage)
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.go-jwlm.yaml)")
+	rootCmd.PersistentFlags().StringVar(&Lang, "lang", "", "Language of messages and prompts, like 'de', 'es', or 'fr' (default is taken from JWLM_LANG or LANG)")
+	rootCmd.PersistentFlags().BoolVar(&Plain, "plain", false, "Use plain text and numbered questions instead of tables, colors, and interactive prompts (like for screen readers)")
+	rootCmd.PersistentFlags().StringVar(&CPUProfile, "cpuprofile", "", "Write a CPU profile to this file (for reporting performance problems)")
+	rootCmd.PersistentFlags().StringVar(&MemProfile, "memprofile", "", "Write a memory profile to this file when finished (for reporting performance problems)")
+	rootCmd.PersistentFlags().StringVar(&TraceFile, "trace", "", "Write an execution trace to this file (for reporting performance problems)")
+	rootCmd.PersistentFlags().StringVar(&TranslationsFile, "translations", "", "JSON file with additional translations, named after its language (like pt.json)")
+}
+
+// initLanguage sets the language of messages and prompts
+func initLanguage() {
+	if TranslationsFile != "" {
+		if err := i18n.LoadFile(TranslationsFile); err != nil {
+			fmt.Println(err)
+			os.Exit(ExitFailure)
+		}
+	}
+
+	lang := Lang
+	if lang == "" {
+		lang = i18n.DetectLanguage()
+	}
+	if lang == "" {
+		return
+	}
+	if err := i18n.SetLanguage(lang); err != nil {
+		// Unknown locales in the environment should not prevent using go-jwlm
+		if Lang != "" {
+			fmt.Println(err)
+			os.Exit(ExitFailure)
+		}
+	}
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -47,7 +122,7 @@ func initConfig() {
 		home, err := homedir.Dir()
 		if err != nil {
 			fmt.Println(err)
-			os.Exit(1)
+			os.Exit(ExitFailure)
 		}
 
 		// Search config in home directory with name ".go-jwlm" (without extension).
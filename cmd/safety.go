@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// safetyBackupTimeFormat is appended to the name of safety backups
+const safetyBackupTimeFormat = "20060102-150405"
+
+// NoSafetyBackup disables the copy of an existing destination
+// that is created before it is overwritten
+var NoSafetyBackup bool
+
+// createSafetyBackup copies the given file to a timestamped file next to
+// it (like merged.jwlibrary.bak-20210101-120000), so it isn't lost when
+// the file is overwritten. If the file does not exist, nothing is done.
+// It returns the name of the safety backup, or an empty string if none
+// has been created.
+func createSafetyBackup(filename string, now time.Time) (string, error) {
+	src, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "Error while opening existing destination")
+	}
+	defer src.Close()
+	stat, err := src.Stat()
+	if err != nil {
+		return "", errors.Wrap(err, "Error while opening existing destination")
+	}
+	if stat.IsDir() {
+		return "", errors.Errorf("Destination %s is a directory", filename)
+	}
+
+	// Never overwrite an older safety backup, even if
+	// the destination is overwritten several times a second
+	backup := filename + ".bak-" + now.Format(safetyBackupTimeFormat)
+	dst, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, stat.Mode())
+	for i := 1; os.IsExist(err); i++ {
+		backup = fmt.Sprintf("%s.bak-%s-%d", filename, now.Format(safetyBackupTimeFormat), i)
+		dst, err = os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, stat.Mode())
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "Error while creating safety backup")
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(backup)
+		return "", errors.Wrap(err, "Error while creating safety backup")
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(backup)
+		return "", errors.Wrap(err, "Error while creating safety backup")
+	}
+	return backup, nil
+}
+
+// atomicDestination returns a path next to the given destination that
+// should be written to instead of it. After writing, the returned function
+// replaces the destination with the written file in one step, so the
+// destination is never left half-written if the merge fails.
+func atomicDestination(dest string) (string, func() error, error) {
+	tmp, err := ioutil.TempDir(filepath.Dir(dest), ".go-jwlm")
+	if err != nil {
+		return "", nil, ioError(err)
+	}
+	local := filepath.Join(tmp, filepath.Base(dest))
+	return local, func() error {
+		err := os.Rename(local, dest)
+		os.RemoveAll(tmp)
+		if err != nil {
+			return ioError(errors.Wrap(err, "Error while replacing destination"))
+		}
+		return nil
+	}, nil
+}
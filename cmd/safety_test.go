@@ -0,0 +1,78 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func Test_createSafetyBackup(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	now := time.Date(2021, 1, 2, 15, 4, 5, 0, time.UTC)
+	filename := filepath.Join(tmp, "merged.jwlibrary")
+
+	backup, err := createSafetyBackup(filename, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "", backup)
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("first"), 0644))
+	backup, err = createSafetyBackup(filename, now)
+	assert.NoError(t, err)
+	assert.Equal(t, filename+".bak-20210102-150405", backup)
+	content, err := ioutil.ReadFile(backup)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(content))
+
+	// Existing safety backups are not overwritten
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("second"), 0644))
+	backup, err = createSafetyBackup(filename, now)
+	assert.NoError(t, err)
+	assert.Equal(t, filename+".bak-20210102-150405-1", backup)
+	content, err = ioutil.ReadFile(backup)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+	content, err = ioutil.ReadFile(filename + ".bak-20210102-150405")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(content))
+
+	_, err = createSafetyBackup(tmp, now)
+	assert.Error(t, err)
+}
+
+func Test_atomicDestination(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	dest := filepath.Join(tmp, "master.jwlibrary")
+	assert.NoError(t, ioutil.WriteFile(dest, []byte("old"), 0644))
+
+	local, replace, err := atomicDestination(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, tmp, filepath.Dir(filepath.Dir(local)))
+	assert.Equal(t, "master.jwlibrary", filepath.Base(local))
+	assert.NoError(t, ioutil.WriteFile(local, []byte("new"), 0644))
+
+	content, err := ioutil.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+
+	assert.NoError(t, replace())
+	content, err = ioutil.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+
+	files, err := ioutil.ReadDir(tmp)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Control merges from other programs using a unix socket",
+	Long: `serve listens on a unix socket, so GUIs can control merges without embedding
+Go or parsing the output of go-jwlm. Unix sockets are also available on
+Windows 10 and later.
+
+Programs send requests as lines of JSON and get messages in the format of
+the jsonl protocol (see merge --protocol), which carry the id of their
+request:
+
+  > {"id":"1","method":"merge","left":"left.jwlibrary","right":"right.jwlibrary","merged":"merged.jwlibrary","bookmarks":"chooseLeft"}
+  < {"type":"stage","id":"1","stage":"Bookmarks"}
+  < {"type":"conflict","id":"1","stage":"Notes","key":"...","left":{...},"right":{...}}
+  > {"id":"1","method":"resolve","key":"...","side":"rightSide"}
+  < {"type":"finished","id":"1","destination":"merged.jwlibrary"}
+
+A merge can be stopped at any time with the method cancel. If a request
+fails, an error message with the exit code of the error is sent instead:
+
+  < {"type":"error","id":"1","error":"interrupted","code":3}
+
+The method version returns the version of go-jwlm. Each connection can
+run one merge at a time.
+
+With --metrics, the duration and the conflicts of every merge stage are
+served in the format of Prometheus on http://<address>/metrics.`,
+	Example: `go-jwlm serve --socket /tmp/go-jwlm.sock
+go-jwlm serve --socket /tmp/go-jwlm.sock --metrics localhost:9100`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l, err := listenSocket(ServeSocket)
+		if err != nil {
+			return err
+		}
+		var metrics merger.Metrics
+		if ServeMetrics != "" {
+			ml, err := net.Listen("tcp", ServeMetrics)
+			if err != nil {
+				l.Close()
+				return ioError(errors.Wrap(err, "Error while listening for metrics"))
+			}
+			defer ml.Close()
+			metrics = serveMetrics(ml)
+		}
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-interrupt
+			l.Close()
+		}()
+		return serve(l, metrics)
+	},
+	Args: cobra.NoArgs,
+}
+
+// ServeSocket is the unix socket go-jwlm serve listens on
+var ServeSocket string
+
+// ServeMetrics is the address the metrics of go-jwlm serve are served on
+var ServeMetrics string
+
+// socketRequest is a request sent to go-jwlm serve as one line of JSON
+type socketRequest struct {
+	ID     string `json:"id"`
+	Method string `json:"method"`
+
+	// Backups and resolvers of a merge
+	Left      string `json:"left"`
+	Right     string `json:"right"`
+	Merged    string `json:"merged"`
+	Bookmarks string `json:"bookmarks"`
+	Markings  string `json:"markings"`
+	Notes     string `json:"notes"`
+
+	// Resolution of a conflict, where Side is either 'leftSide' or 'rightSide'
+	Key  string `json:"key"`
+	Side string `json:"side"`
+}
+
+// listenSocket listens on the unix socket with the given filename.
+// A socket left behind by a previous run is removed first.
+func listenSocket(filename string) (net.Listener, error) {
+	if info, err := os.Lstat(filename); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", filename); err == nil {
+			conn.Close()
+			return nil, errors.Errorf("%s is already used by a different process", filename)
+		}
+		if err := os.Remove(filename); err != nil {
+			return nil, ioError(errors.Wrap(err, "Error while removing old socket"))
+		}
+	}
+
+	l, err := net.Listen("unix", filename)
+	if err != nil {
+		return nil, ioError(errors.Wrap(err, "Error while listening on socket"))
+	}
+	return l, nil
+}
+
+// serveMetrics serves the metrics of all merges on /metrics
+// of the listener until it is closed and returns them
+func serveMetrics(l net.Listener) *mergeMetrics {
+	metrics := newMergeMetrics()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	go http.Serve(l, mux)
+	return metrics
+}
+
+// serve handles the connections of the listener until it is closed.
+// The stages of all merges are measured by metrics, if it is not nil.
+func serve(l net.Listener, metrics merger.Metrics) error {
+	var wg sync.WaitGroup
+	var connsMutex sync.Mutex
+	conns := map[net.Conn]struct{}{}
+	// Clients might stay connected, so their connections are closed,
+	// which cancels running merges, before waiting for the sessions
+	defer func() {
+		connsMutex.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		connsMutex.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return ioError(errors.Wrap(err, "Error while accepting connection"))
+		}
+		connsMutex.Lock()
+		conns[conn] = struct{}{}
+		connsMutex.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				connsMutex.Lock()
+				delete(conns, conn)
+				connsMutex.Unlock()
+				conn.Close()
+			}()
+			newSocketSession(conn, conn, metrics).run()
+		}()
+	}
+}
+
+// socketSession handles the requests of a single connection
+type socketSession struct {
+	in       io.Reader
+	out      *json.Encoder
+	outMutex sync.Mutex
+	// requests are the requests read from in, except for cancel,
+	// which is handled right away
+	requests chan socketRequest
+
+	// cancel stops the running merge
+	cancel      context.CancelFunc
+	cancelMutex sync.Mutex
+
+	metrics merger.Metrics
+}
+
+func newSocketSession(in io.Reader, out io.Writer, metrics merger.Metrics) *socketSession {
+	return &socketSession{
+		in:       in,
+		out:      json.NewEncoder(out),
+		requests: make(chan socketRequest, 16),
+		metrics:  metrics,
+	}
+}
+
+// run handles the requests until the connection is closed
+func (s *socketSession) run() {
+	go s.read()
+	for req := range s.requests {
+		var err error
+		switch req.Method {
+		case "version":
+			err = s.send(protocolMessage{Type: "version", ID: req.ID, Version: Version})
+		case "merge":
+			err = s.merge(req)
+		case "resolve":
+			err = errors.New("No conflict is waiting for a resolution")
+		default:
+			err = errors.Errorf("Method %s is not supported", req.Method)
+		}
+		if err != nil {
+			s.sendError(req.ID, err)
+		}
+	}
+}
+
+// read reads the requests of the connection. As merges wait for
+// resolutions, cancel is handled here instead of in run.
+func (s *socketSession) read() {
+	defer close(s.requests)
+	// Stop a running merge if the connection is closed
+	defer s.cancelMerge()
+
+	scanner := bufio.NewScanner(s.in)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var req socketRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			s.sendError("", errors.Wrap(err, "Error while parsing request"))
+			continue
+		}
+		if req.Method == "cancel" {
+			s.cancelMerge()
+			continue
+		}
+		s.requests <- req
+	}
+}
+
+// merge merges the backups of the request, asking for
+// the resolution of conflicts that have no resolver
+func (s *socketSession) merge(req socketRequest) error {
+	if req.Left == "" || req.Right == "" || req.Merged == "" {
+		return errors.New("A merge needs a left, right, and merged backup")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.cancelMutex.Lock()
+	s.cancel = cancel
+	s.cancelMutex.Unlock()
+
+	left, right := &model.Database{}, &model.Database{}
+	if err := importDatabase(left, req.Left); err != nil {
+		return err
+	}
+	if err := importDatabase(right, req.Right); err != nil {
+		return err
+	}
+	leftDevice, rightDevice := left.DeviceName(), right.DeviceName()
+	media, err := socketMedia(req.Left, req.Right)
+	if err != nil {
+		return err
+	}
+
+	resolvers := map[string]string{
+		merger.StageBookmarks: req.Bookmarks,
+		merger.StageMarkings:  req.Markings,
+		merger.StageNotes:     req.Notes,
+	}
+	result, err := merger.MergeBackups(left, right, merger.MergeOptions{
+		Metrics: s.metrics,
+		Progress: func(stage string, finished bool) error {
+			if ctx.Err() != nil {
+				return conflictError(errors.New(i18n.T("interrupted")))
+			}
+			if finished {
+				return nil
+			}
+			return s.send(protocolMessage{Type: "stage", ID: req.ID, Stage: stage})
+		},
+		Solve: func(stage string, conflicts map[string]merger.MergeConflict, merged *model.Database) (map[string]merger.MergeSolution, error) {
+			if resolvers[stage] != "" {
+				return merger.AutoResolveConflicts(conflicts, resolvers[stage])
+			}
+			solutions, err := s.ask(ctx, req.ID, stage, conflicts, merged, leftDevice, rightDevice)
+			return solutions, conflictError(err)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if filepath.Ext(req.Merged) == ".db" {
+		err = result.Merged.ExportUserDB(req.Merged)
+	} else {
+		err = result.Merged.ExportJWLBackupWithOptions(req.Merged, model.ExportOptions{Media: media})
+	}
+	if err != nil {
+		return ioError(err)
+	}
+	return s.send(protocolMessage{Type: "finished", ID: req.ID, Destination: req.Merged})
+}
+
+// ask sends the given conflicts sorted by their key and waits for
+// the resolution of each conflict before sending the next one
+func (s *socketSession) ask(ctx context.Context, id string, stage string, conflicts map[string]merger.MergeConflict,
+	mergedDB *model.Database, leftDevice string, rightDevice string) (map[string]merger.MergeSolution, error) {
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]merger.MergeSolution, len(conflicts))
+	for _, key := range keys {
+		msg := conflictMessage(key, conflicts[key], mergedDB, leftDevice, rightDevice)
+		msg.ID, msg.Stage = id, stage
+		if err := s.send(msg); err != nil {
+			return nil, err
+		}
+
+		for {
+			var req socketRequest
+			var ok bool
+			select {
+			case req, ok = <-s.requests:
+			case <-ctx.Done():
+				return nil, errors.New(i18n.T("interrupted"))
+			}
+			if !ok {
+				return nil, errors.New("Connection has been closed")
+			}
+			if req.Method != "resolve" || req.ID != id {
+				s.sendError(req.ID, errors.Errorf("Expected resolution of conflict %s", key))
+				continue
+			}
+			if req.Key != key {
+				s.sendError(req.ID, errors.Errorf("Expected resolution of conflict %s, got %s", key, req.Key))
+				continue
+			}
+			solution, err := protocolSolution(conflicts[key], req.Side)
+			if err != nil {
+				s.sendError(req.ID, err)
+				continue
+			}
+			result[key] = solution
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// cancelMerge stops the running merge, if any
+func (s *socketSession) cancelMerge() {
+	s.cancelMutex.Lock()
+	defer s.cancelMutex.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// send writes the message as one line of JSON
+func (s *socketSession) send(msg protocolMessage) error {
+	s.outMutex.Lock()
+	defer s.outMutex.Unlock()
+	if err := s.out.Encode(msg); err != nil {
+		return errors.Wrap(err, "Error while sending message")
+	}
+	return nil
+}
+
+// sendError sends err together with its exit code
+func (s *socketSession) sendError(id string, err error) {
+	s.send(protocolMessage{Type: "error", ID: id, Error: err.Error(), Code: ExitCode(err)})
+}
+
+// socketMedia merges the media files of both backups
+func socketMedia(leftFilename string, rightFilename string) (map[string][]byte, error) {
+	leftMedia, err := readMedia(leftFilename)
+	if err != nil {
+		return nil, err
+	}
+	rightMedia, err := readMedia(rightFilename)
+	if err != nil {
+		return nil, err
+	}
+	media, _ := merger.MergeMedia(leftMedia, rightMedia)
+	return media, nil
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&ServeSocket, "socket", "", "Unix socket to listen on")
+	serveCmd.MarkFlagRequired("socket")
+	serveCmd.Flags().StringVar(&ServeMetrics, "metrics", "", "Address to serve metrics for Prometheus on, like localhost:9100")
+}
@@ -0,0 +1,163 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+func Test_serve(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	mergedFilename := filepath.Join(tmp, "merged.jwlibrary")
+	assert.NoError(t, leftMultiCollision.ExportJWLBackup(leftFilename))
+	assert.NoError(t, rightMultiCollision.ExportJWLBackup(rightFilename))
+
+	socket := filepath.Join(tmp, "go-jwlm.sock")
+	l, err := listenSocket(socket)
+	assert.NoError(t, err)
+	served := make(chan error)
+	metrics := newMergeMetrics()
+	go func() { served <- serve(l, metrics) }()
+
+	// The socket can't be used twice
+	_, err = listenSocket(socket)
+	assert.Error(t, err)
+
+	conn, err := net.Dial("unix", socket)
+	assert.NoError(t, err)
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, 1024*1024)
+	receive := func() map[string]interface{} {
+		assert.True(t, scanner.Scan(), "Expected message")
+		var msg map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		return msg
+	}
+
+	fmt.Fprintln(conn, `{"id":"1","method":"version"}`)
+	assert.Equal(t, map[string]interface{}{"type": "version", "id": "1", "version": Version}, receive())
+
+	fmt.Fprintln(conn, `{"id":"2","method":"unknown"}`)
+	assert.Equal(t, map[string]interface{}{
+		"type": "error", "id": "2", "error": "Method unknown is not supported", "code": float64(ExitFailure),
+	}, receive())
+
+	fmt.Fprintf(conn, "{\"id\":\"3\",\"method\":\"merge\",\"left\":%q,\"right\":%q,\"merged\":%q}\n",
+		leftFilename, rightFilename, mergedFilename)
+	conflicts := 0
+	stages := 0
+	for msg := receive(); msg["type"] != "finished"; msg = receive() {
+		assert.Equal(t, "3", msg["id"])
+		switch msg["type"] {
+		case "stage":
+			stages++
+		case "conflict":
+			conflicts++
+			assert.Contains(t, msg["left"], "model")
+			assert.Contains(t, msg["right"], "related")
+			fmt.Fprintf(conn, "{\"id\":\"3\",\"method\":\"resolve\",\"key\":%q,\"side\":\"rightSide\"}\n", msg["key"])
+		default:
+			t.Fatalf("Unexpected message %s", scanner.Text())
+		}
+	}
+	assert.Greater(t, conflicts, 0)
+	assert.Equal(t, 6, stages)
+	metrics.mutex.Lock()
+	assert.Len(t, metrics.stages, 6)
+	assert.Equal(t, conflicts, metrics.stages[merger.StageBookmarks].conflicts+
+		metrics.stages[merger.StageMarkings].conflicts+metrics.stages[merger.StageNotes].conflicts)
+	metrics.mutex.Unlock()
+	merged := &model.Database{}
+	assert.NoError(t, merged.ImportJWLBackup(mergedFilename))
+	assert.True(t, rightMultiCollision.Equals(merged))
+
+	// Cancel a merge while it waits for a resolution
+	fmt.Fprintf(conn, "{\"id\":\"4\",\"method\":\"merge\",\"left\":%q,\"right\":%q,\"merged\":%q}\n",
+		leftFilename, rightFilename, mergedFilename)
+	msg := receive()
+	for ; msg["type"] == "stage"; msg = receive() {
+	}
+	assert.Equal(t, "conflict", msg["type"])
+	fmt.Fprintln(conn, `{"id":"4","method":"resolve","key":"wrong","side":"leftSide"}`)
+	assert.Equal(t, "error", receive()["type"])
+	fmt.Fprintln(conn, `{"id":"4","method":"cancel"}`)
+	assert.Equal(t, map[string]interface{}{
+		"type": "error", "id": "4", "error": "interrupted", "code": float64(ExitUnresolvedConflicts),
+	}, receive())
+
+	fmt.Fprintln(conn, `{"id":"5","method":"merge","left":"missing.jwlibrary","right":"missing.jwlibrary","merged":"merged.jwlibrary"}`)
+	assert.Equal(t, float64(ExitIO), receive()["code"])
+
+	conn.Close()
+	l.Close()
+	assert.NoError(t, <-served)
+}
+
+func Test_serve_shutdown(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	assert.NoError(t, leftMultiCollision.ExportJWLBackup(leftFilename))
+	assert.NoError(t, rightMultiCollision.ExportJWLBackup(rightFilename))
+
+	socket := filepath.Join(tmp, "go-jwlm.sock")
+	l, err := listenSocket(socket)
+	assert.NoError(t, err)
+	served := make(chan error)
+	go func() { served <- serve(l, nil) }()
+
+	// One client is idle and one waits for the resolution of a conflict
+	idle, err := net.Dial("unix", socket)
+	assert.NoError(t, err)
+	defer idle.Close()
+	conn, err := net.Dial("unix", socket)
+	assert.NoError(t, err)
+	defer conn.Close()
+	fmt.Fprintf(conn, "{\"id\":\"1\",\"method\":\"merge\",\"left\":%q,\"right\":%q,\"merged\":%q}\n",
+		leftFilename, rightFilename, filepath.Join(tmp, "merged.jwlibrary"))
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var msg map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		if msg["type"] == "conflict" {
+			break
+		}
+	}
+
+	// Closing the listener closes the connections of both clients
+	l.Close()
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("serve did not return while clients were connected")
+	}
+	for scanner.Scan() {
+	}
+	_, err = idle.Read(make([]byte, 1))
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// ConflictStream is the format the conflicts of a merge and their
+// resolutions are streamed in. Currently, only 'jsonl' is supported.
+var ConflictStream string
+
+// ConflictStreamFile is the file the conflict stream is written to.
+// If it is empty, the stream is written to stderr.
+var ConflictStreamFile string
+
+// solvedFunc is called whenever a single conflict has been solved
+// by the user, so the solution can be streamed right away
+type solvedFunc func(key string, solution merger.MergeSolution) error
+
+// conflictStream writes the conflicts of a merge and their resolutions as
+// lines of JSON while they happen, so other programs (like GUIs or
+// monitoring tools) can follow a merge live. The messages have the same
+// format as the ones of the jsonl protocol. All methods can be called on
+// a nil conflictStream, in which case nothing is written.
+type conflictStream struct {
+	out *json.Encoder
+
+	// Names of the devices the left and right backup have been created on
+	leftDevice  string
+	rightDevice string
+}
+
+// openConflictStream opens the conflict stream in the given format, writing
+// to filename or to stderr if it is empty. It returns a nil conflictStream
+// if no format is given, together with a function to close the file.
+func openConflictStream(format string, filename string, stderr io.Writer) (*conflictStream, func(), error) {
+	switch format {
+	case "":
+		return nil, func() {}, nil
+	case "jsonl":
+	default:
+		return nil, nil, errors.Errorf("Conflict stream %s is not supported", format)
+	}
+
+	if filename == "" {
+		return &conflictStream{out: json.NewEncoder(stderr)}, func() {}, nil
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, nil, ioError(errors.Wrap(err, "Error while creating conflict stream"))
+	}
+	return &conflictStream{out: json.NewEncoder(f)}, func() { f.Close() }, nil
+}
+
+// setDevices sets the names of the devices the backups have been created on
+func (s *conflictStream) setDevices(leftDevice string, rightDevice string) {
+	if s == nil {
+		return
+	}
+	s.leftDevice, s.rightDevice = leftDevice, rightDevice
+}
+
+// conflicts writes the conflicts of the given stage sorted by their key
+func (s *conflictStream) conflicts(stage string, conflicts map[string]merger.MergeConflict, mergedDB *model.Database) error {
+	if s == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		msg := conflictMessage(key, conflicts[key], mergedDB, s.leftDevice, s.rightDevice)
+		msg.Stage = stage
+		if err := s.out.Encode(msg); err != nil {
+			return errors.Wrap(err, "Error while streaming conflict")
+		}
+	}
+	return nil
+}
+
+// resolutions writes the solutions of the given stage sorted by their key.
+// The resolver is empty if the conflicts have been solved by the user.
+func (s *conflictStream) resolutions(stage string, resolver string, solutions map[string]merger.MergeSolution) error {
+	if s == nil {
+		return nil
+	}
+
+	keys := make([]string, 0, len(solutions))
+	for key := range solutions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err := s.out.Encode(protocolMessage{
+			Type:     "resolution",
+			Stage:    stage,
+			Key:      key,
+			Side:     string(solutions[key].Side),
+			Resolver: resolver,
+		})
+		if err != nil {
+			return errors.Wrap(err, "Error while streaming resolution")
+		}
+	}
+	return nil
+}
+
+// finish signals that the merged backup has been written to destination
+func (s *conflictStream) finish(destination string) error {
+	if s == nil {
+		return nil
+	}
+	return s.out.Encode(protocolMessage{Type: "finished", Destination: destination})
+}
@@ -0,0 +1,138 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/tj/assert"
+)
+
+// streamMessage is a message of the conflict stream with the
+// sides of a conflict left as raw JSON
+type streamMessage struct {
+	Type        string          `json:"type"`
+	Stage       string          `json:"stage"`
+	Key         string          `json:"key"`
+	Left        json.RawMessage `json:"left"`
+	Right       json.RawMessage `json:"right"`
+	Side        string          `json:"side"`
+	Resolver    string          `json:"resolver"`
+	Destination string          `json:"destination"`
+}
+
+func Test_mergeConflictStream(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	leftFilename := filepath.Join(tmp, "left.jwlibrary")
+	rightFilename := filepath.Join(tmp, "right.jwlibrary")
+	mergedFilename := filepath.Join(tmp, "merged.jwlibrary")
+	streamFilename := filepath.Join(tmp, "stream.jsonl")
+	assert.NoError(t, leftMultiCollision.ExportJWLBackup(leftFilename))
+	assert.NoError(t, rightMultiCollision.ExportJWLBackup(rightFilename))
+
+	ConflictStream, ConflictStreamFile = "jsonl", streamFilename
+	BookmarkResolver, MarkingResolver, NoteResolver = "chooseLeft", "chooseLeft", "chooseLeft"
+	defer func() {
+		ConflictStream, ConflictStreamFile = "", ""
+		BookmarkResolver, MarkingResolver, NoteResolver = "", "", ""
+	}()
+	out, err := os.Create(filepath.Join(tmp, "out"))
+	assert.NoError(t, err)
+	defer out.Close()
+	assert.NoError(t, merge(leftFilename, rightFilename, mergedFilename, terminal.Stdio{In: os.Stdin, Out: out, Err: out}))
+
+	content, err := ioutil.ReadFile(streamFilename)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+
+	conflicts := map[string]bool{}
+	resolutions := map[string]bool{}
+	var last streamMessage
+	for _, line := range lines {
+		var msg streamMessage
+		assert.NoError(t, json.Unmarshal([]byte(line), &msg))
+		switch msg.Type {
+		case "conflict":
+			assert.NotEmpty(t, msg.Stage)
+			assert.Contains(t, string(msg.Left), "model")
+			assert.Contains(t, string(msg.Right), "related")
+			conflicts[msg.Stage+"/"+msg.Key] = true
+		case "resolution":
+			// Every resolution follows its conflict
+			assert.True(t, conflicts[msg.Stage+"/"+msg.Key], line)
+			assert.Equal(t, "leftSide", msg.Side)
+			assert.Equal(t, "chooseLeft", msg.Resolver)
+			resolutions[msg.Stage+"/"+msg.Key] = true
+		}
+		last = msg
+	}
+	assert.Greater(t, len(conflicts), 0)
+	assert.Equal(t, conflicts, resolutions)
+	assert.Equal(t, "finished", last.Type)
+	assert.Equal(t, mergedFilename, last.Destination)
+
+	// Answers of the user are streamed without a resolver
+	BookmarkResolver, MarkingResolver, NoteResolver = "", "", ""
+	Plain = true
+	defer func() { Plain = false }()
+	inFilename := filepath.Join(tmp, "in")
+	assert.NoError(t, ioutil.WriteFile(inFilename, []byte(strings.Repeat("2\n", 50)), 0644))
+	in, err := os.Open(inFilename)
+	assert.NoError(t, err)
+	defer in.Close()
+	assert.NoError(t, merge(leftFilename, rightFilename, mergedFilename, terminal.Stdio{In: in, Out: out, Err: out}))
+
+	content, err = ioutil.ReadFile(streamFilename)
+	assert.NoError(t, err)
+	asked, answered := 0, 0
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var msg streamMessage
+		assert.NoError(t, json.Unmarshal([]byte(line), &msg))
+		switch msg.Type {
+		case "conflict":
+			asked++
+		case "resolution":
+			answered++
+			assert.Equal(t, "rightSide", msg.Side)
+			assert.Empty(t, msg.Resolver)
+		}
+	}
+	assert.Greater(t, asked, 0)
+	assert.Equal(t, asked, answered)
+}
+
+func Test_openConflictStream(t *testing.T) {
+	stream, closeStream, err := openConflictStream("", "", os.Stderr)
+	assert.NoError(t, err)
+	assert.Nil(t, stream)
+	closeStream()
+	// A nil stream does not write anything
+	assert.NoError(t, stream.conflicts("notes", nil, nil))
+	assert.NoError(t, stream.resolutions("notes", "", nil))
+	assert.NoError(t, stream.finish("merged.jwlibrary"))
+
+	_, _, err = openConflictStream("xml", "", os.Stderr)
+	assert.EqualError(t, err, "Conflict stream xml is not supported")
+
+	_, _, err = openConflictStream("jsonl", filepath.Join("does", "not", "exist"), os.Stderr)
+	assert.Error(t, err)
+	assert.Equal(t, ExitIO, ExitCode(err))
+
+	out := &bytes.Buffer{}
+	stream, closeStream, err = openConflictStream("jsonl", "", out)
+	assert.NoError(t, err)
+	defer closeStream()
+	assert.NoError(t, stream.finish("merged.jwlibrary"))
+	assert.Equal(t, "{\"type\":\"finished\",\"destination\":\"merged.jwlibrary\"}\n", out.String())
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/i18n"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/spf13/cobra"
+)
+
+var subtractCmd = &cobra.Command{
+	Use:   "subtract <backup> <reference-backup> <dest-backup>",
+	Short: "Remove every entry of a backup that is also part of another one",
+	Long: `subtract imports the given .jwlibrary backup file, removes every bookmark,
+highlight, note, and tag assignment that is also part of the reference
+backup, and stores the result as a new backup. Entries are compared by
+their content, so this works for backups of different devices as well.
+This is useful to extract the notes that have been added since the last
+merge (by subtracting the backup of that merge), or to remove content that
+has been imported twice. Highlights that still have a note attached are
+kept, as are tags that are still used.`,
+	Example: `go-jwlm subtract current.jwlibrary last-merge.jwlibrary new-since-last-merge.jwlibrary`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return subtract(args[0], args[1], args[2], terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(3),
+}
+
+func subtract(filename string, referenceFilename string, destFilename string, stdio terminal.Stdio) error {
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	referenceFilename, cleanupReference, err := localBackup(referenceFilename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanupReference()
+
+	fmt.Fprintln(stdio.Out, i18n.T("Importing backup"))
+	db := &model.Database{}
+	if err := importDatabase(db, filename); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdio.Out, i18n.T("Importing reference backup"))
+	reference := &model.Database{}
+	if err := importDatabase(reference, referenceFilename); err != nil {
+		return err
+	}
+
+	removed := db.Subtract(reference)
+	tables := make([]string, 0, len(removed))
+	total := 0
+	for table, count := range removed {
+		tables = append(tables, table)
+		total += count
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		fmt.Fprintf(stdio.Out, "%s: %d\n", table, removed[table])
+	}
+
+	fmt.Fprintln(stdio.Out, i18n.T("Exporting backup"))
+	if err := db.ExportJWLBackup(destFilename); err != nil {
+		return ioError(err)
+	}
+
+	fmt.Fprintln(stdio.Out, "🎉 "+i18n.T("Removed %d entries", total))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(subtractCmd)
+	subtractCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
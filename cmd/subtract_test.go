@@ -0,0 +1,61 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_subtract(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "merged.jwlibrary")
+	referenceFilename := filepath.Join(tmp, "left.jwlibrary")
+	destFilename := filepath.Join(tmp, "subtracted.jwlibrary")
+	assert.NoError(t, leftDB.ExportJWLBackup(filename))
+	assert.NoError(t, leftDB.ExportJWLBackup(referenceFilename))
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Importing reference backup")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Note: ")
+			assert.NoError(t, err)
+			_, err = c.ExpectString("Removed ")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, subtract(filename, referenceFilename, destFilename,
+				terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+
+	subtracted := &model.Database{}
+	assert.NoError(t, subtracted.ImportJWLBackup(destFilename))
+	for _, note := range subtracted.Note {
+		assert.Nil(t, note)
+	}
+	for _, bm := range subtracted.Bookmark {
+		assert.Nil(t, bm)
+	}
+	for _, um := range subtracted.UserMark {
+		assert.Nil(t, um)
+	}
+	assert.Equal(t, len(leftDB.Location), len(subtracted.Location))
+}
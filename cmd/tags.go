@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/spf13/cobra"
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Work with the tags of a JW Library backup",
+	Long: `tags groups commands for working with the tags of a JW Library backup.
+Tag names like "Talks/2024/Dedication" are treated as a hierarchy.`,
+}
+
+var tagsTreeCmd = &cobra.Command{
+	Use:   "tree <backup>",
+	Short: "Print the tags of a JW Library backup as a tree",
+	Long: `tree imports the given .jwlibrary backup file and prints its tags as a tree.
+Tag names are split into levels by a slash, so "Talks/2024/Dedication"
+is shown below "Talks" and "2024". Each tag shows the number of its
+notes; branches additionally show the number of notes in total.`,
+	Example: `go-jwlm tags tree backup.jwlibrary`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		return tagsTree(filename, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+func tagsTree(filename string, stdio terminal.Stdio) error {
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db := &model.Database{}
+	err = importDatabase(db, filename)
+	if err != nil {
+		return err
+	}
+
+	db.TagTree().Walk(func(node *model.TagNode, depth int) {
+		indent := strings.Repeat("  ", depth)
+		if len(node.Children) == 0 {
+			fmt.Fprintf(stdio.Out, "%s%s (%d)\n", indent, node.Name, node.Notes)
+			return
+		}
+		fmt.Fprintf(stdio.Out, "%s%s (%d, %d in total)\n", indent, node.Name, node.Notes, node.TotalNotes)
+	})
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+	tagsCmd.AddCommand(tagsTreeCmd)
+	tagsTreeCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/model"
+	expect "github.com/Netflix/go-expect"
+	"github.com/tj/assert"
+)
+
+func Test_tagsTree(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := model.MakeDatabaseCopy(leftDB)
+	db.Tag[2].Name = "Talks/2024/Dedication"
+	db.Tag[3].Name = "Talks/2023"
+	filename := filepath.Join(tmp, "left.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(filename))
+
+	RunCmdTest(t,
+		func(t *testing.T, c *expect.Console) {
+			_, err := c.ExpectString("Favorite (0)\r\nTalks (0, 2 in total)\r\n  2023 (1)\r\n  2024 (0, 1 in total)\r\n    Dedication (1)\r\n")
+			assert.NoError(t, err)
+			_, err = c.ExpectEOF()
+			assert.NoError(t, err)
+		},
+		func(t *testing.T, c *expect.Console) {
+			assert.NoError(t, tagsTree(filename, terminal.Stdio{In: c.Tty(), Out: c.Tty(), Err: c.Tty()}))
+			time.Sleep(time.Millisecond * 150) // So it does not finish before go-expect finished
+		})
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/AndreasSko/go-jwlm/export"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var timelineCmd = &cobra.Command{
+	Use:   "timeline <backup>",
+	Short: "Bucket the notes and highlights of a JW Library backup by week or month",
+	Long: `timeline imports the given .jwlibrary backup file and counts its notes and
+highlights per week or month, broken down by publication, so you can chart
+the activity of your personal study. Notes are dated by when they have been
+last modified. As JW Library doesn't store when a highlight has been made,
+highlights are dated by the note attached to them; all other highlights are
+counted as undated. The timeline can be written as JSON or CSV.`,
+	Example: `go-jwlm timeline backup.jwlibrary > timeline.json
+go-jwlm timeline backup.jwlibrary --interval week --format csv > timeline.csv`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filename := args[0]
+		return timeline(filename, TimelineInterval, TimelineFormat, terminal.Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+// TimelineInterval represents the interval the timeline is bucketed by
+var TimelineInterval string
+
+// TimelineFormat represents the format the timeline should be written in
+var TimelineFormat string
+
+func timeline(filename string, interval string, format string, stdio terminal.Stdio) error {
+	if format != "json" && format != "csv" {
+		return errors.Errorf("Timeline format %s is not supported", format)
+	}
+
+	filename, cleanup, err := localBackup(filename, stdio)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	db := &model.Database{}
+	err = importDatabase(db, filename)
+	if err != nil {
+		return err
+	}
+
+	tl, err := export.NoteTimeline(db, interval)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "json":
+		err = tl.JSON(stdio.Out)
+	case "csv":
+		err = tl.CSV(stdio.Out)
+	}
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(timelineCmd)
+	timelineCmd.Flags().StringVar(&TimelineInterval, "interval", "month", "Interval to bucket notes and highlights by (can be 'week' or 'month')")
+	timelineCmd.Flags().StringVar(&TimelineFormat, "format", "json", "Format of the timeline (can be 'json' or 'csv')")
+	timelineCmd.Flags().StringVar(&IdentityFile, "identity", "", "age identity file for decrypting encrypted backups (instead of a password)")
+}
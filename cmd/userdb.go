@@ -0,0 +1,25 @@
+package cmd
+
+import "github.com/AndreasSko/go-jwlm/model"
+
+// importDatabase imports the given .jwlibrary backup into db. Bare SQLite
+// databases, like a user_data.db extracted from a backup, are imported
+// as well, so all commands can work on them.
+// Errors are marked as caused by an invalid backup (see invalidBackupError).
+func importDatabase(db *model.Database, filename string) error {
+	isDB, err := isUserDB(filename)
+	if err != nil {
+		return err
+	}
+	if isDB {
+		return invalidBackupError(db.ImportUserDB(filename))
+	}
+	return invalidBackupError(db.ImportJWLBackup(filename))
+}
+
+// isUserDB checks if the given file is a bare SQLite database
+// instead of a .jwlibrary backup
+func isUserDB(filename string) (bool, error) {
+	isDB, err := model.IsUserDB(filename)
+	return isDB, ioError(err)
+}
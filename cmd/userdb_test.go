@@ -0,0 +1,47 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+func Test_importDatabase(t *testing.T) {
+	t.Parallel()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	backupFilename := filepath.Join(tmp, "left.jwlibrary")
+	dbFilename := filepath.Join(tmp, "user_data.db")
+	assert.NoError(t, leftDB.ExportJWLBackup(backupFilename))
+	assert.NoError(t, leftDB.ExportUserDB(dbFilename))
+
+	for _, filename := range []string{backupFilename, dbFilename} {
+		db := &model.Database{}
+		assert.NoError(t, importDatabase(db, filename))
+		assert.True(t, leftDB.Equals(db))
+	}
+
+	isDB, err := isUserDB(backupFilename)
+	assert.NoError(t, err)
+	assert.False(t, isDB)
+	isDB, err = isUserDB(dbFilename)
+	assert.NoError(t, err)
+	assert.True(t, isDB)
+
+	err = importDatabase(&model.Database{}, filepath.Join(tmp, "missing.jwlibrary"))
+	assert.Equal(t, ExitIO, ExitCode(err))
+	damaged := filepath.Join(tmp, "damaged.jwlibrary")
+	assert.NoError(t, ioutil.WriteFile(damaged, []byte("damaged"), 0644))
+	err = importDatabase(&model.Database{}, damaged)
+	assert.Equal(t, ExitInvalidBackup, ExitCode(err))
+}
@@ -0,0 +1,137 @@
+// Package encryption allows to encrypt backups in the age format
+// (https://age-encryption.org/v1), either using a password or using
+// age X25519 keys. It is a thin layer on top of filippo.io/age, so
+// encrypted backups can be decrypted with the age CLI and vice versa.
+package encryption
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"filippo.io/age"
+	"github.com/pkg/errors"
+)
+
+// intro is the first line of every file encrypted with age
+const intro = "age-encryption.org/v1\n"
+
+// ErrNoIdentityMatched is returned if none of the given identities
+// is able to decrypt a file, e.g. because the password is wrong.
+var ErrNoIdentityMatched = errors.New("No identity matched: the password or key is wrong")
+
+// Recipient is someone who is able to decrypt a file,
+// either by knowing a password or by holding a key.
+type Recipient = age.Recipient
+
+// Identity is able to decrypt files that have been
+// encrypted for the corresponding Recipient.
+type Identity = age.Identity
+
+// IsEncrypted checks if the given file has been encrypted with age
+func IsEncrypted(filename string) (bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return false, errors.Wrap(err, "Error while opening file")
+	}
+	defer f.Close()
+
+	header := make([]byte, len(intro))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false, nil
+	}
+	return string(header) == intro, nil
+}
+
+// EncryptFile encrypts the file src for the given recipients and writes
+// the result to dst.
+func EncryptFile(src string, dst string, recipients ...Recipient) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "Error while opening file to encrypt")
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return errors.Wrap(err, "Error while creating encrypted file")
+	}
+	defer out.Close()
+
+	if err := Encrypt(out, in, recipients...); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// DecryptFile decrypts the file src using the given identities and writes
+// the result to dst.
+func DecryptFile(src string, dst string, identities ...Identity) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "Error while opening encrypted file")
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return errors.Wrap(err, "Error while creating decrypted file")
+	}
+	defer out.Close()
+
+	if err := Decrypt(out, in, identities...); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// Encrypt reads the plaintext from src and writes it
+// encrypted for the given recipients to dst.
+func Encrypt(dst io.Writer, src io.Reader, recipients ...Recipient) error {
+	if len(recipients) == 0 {
+		return errors.New("At least one recipient is needed for encryption")
+	}
+
+	w, err := age.Encrypt(dst, recipients...)
+	if err != nil {
+		return errors.Wrap(err, "Error while writing header")
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return errors.Wrap(err, "Error while encrypting")
+	}
+	return errors.Wrap(w.Close(), "Error while encrypting")
+}
+
+// Decrypt reads the encrypted file from src, decrypts it
+// using the given identities and writes the result to dst.
+func Decrypt(dst io.Writer, src io.Reader, identities ...Identity) error {
+	in := bufio.NewReader(src)
+	if header, err := in.Peek(len(intro)); err != nil || string(header) != intro {
+		return errors.New("Error while reading header: File is not encrypted with age")
+	}
+
+	r, err := age.Decrypt(in, identities...)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return ErrNoIdentityMatched
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error while reading header")
+	}
+
+	// Errors while reading come from authenticating the payload, so they
+	// are told apart from errors while writing the plaintext.
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return errors.Wrap(err, "Error while writing decrypted file")
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.New("Encrypted file is corrupted or has been modified")
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	// Keep tests fast
+	scryptWorkFactor = 10
+}
+
+func TestEncrypt_Decrypt(t *testing.T) {
+	// The payload is encrypted in chunks of 64 KiB
+	chunkSize := 64 * 1024
+	sizes := []int{0, 1, 1000, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 17}
+
+	for _, size := range sizes {
+		plaintext := make([]byte, size)
+		_, err := rand.Read(plaintext)
+		assert.NoError(t, err)
+
+		pw, err := NewPasswordRecipient("secret")
+		assert.NoError(t, err)
+		encrypted := &bytes.Buffer{}
+		assert.NoError(t, Encrypt(encrypted, bytes.NewReader(plaintext), pw))
+		assert.True(t, bytes.HasPrefix(encrypted.Bytes(), []byte(intro)))
+
+		decrypted := &bytes.Buffer{}
+		assert.NoError(t, Decrypt(decrypted, bytes.NewReader(encrypted.Bytes()), NewPasswordIdentity("secret")))
+		assert.Equal(t, string(plaintext), decrypted.String(), "size %d", size)
+
+		err = Decrypt(&bytes.Buffer{}, bytes.NewReader(encrypted.Bytes()), NewPasswordIdentity("wrong"))
+		assert.Equal(t, ErrNoIdentityMatched, err)
+	}
+}
+
+func TestEncrypt_Decrypt_X25519(t *testing.T) {
+	alice, err := GenerateX25519Identity()
+	assert.NoError(t, err)
+	bob, err := GenerateX25519Identity()
+	assert.NoError(t, err)
+	eve, err := GenerateX25519Identity()
+	assert.NoError(t, err)
+
+	encrypted := &bytes.Buffer{}
+	assert.NoError(t, Encrypt(encrypted, bytes.NewReader([]byte("notes")), alice.Recipient(), bob.Recipient()))
+
+	for _, id := range []Identity{alice, bob} {
+		decrypted := &bytes.Buffer{}
+		assert.NoError(t, Decrypt(decrypted, bytes.NewReader(encrypted.Bytes()), eve, id))
+		assert.Equal(t, "notes", decrypted.String())
+	}
+
+	err = Decrypt(&bytes.Buffer{}, bytes.NewReader(encrypted.Bytes()), eve, NewPasswordIdentity("secret"))
+	assert.Equal(t, ErrNoIdentityMatched, err)
+}
+
+func TestEncrypt_errors(t *testing.T) {
+	assert.Error(t, Encrypt(&bytes.Buffer{}, bytes.NewReader(nil)))
+
+	pw, err := NewPasswordRecipient("secret")
+	assert.NoError(t, err)
+	id, err := GenerateX25519Identity()
+	assert.NoError(t, err)
+	assert.Error(t, Encrypt(&bytes.Buffer{}, bytes.NewReader(nil), pw, id.Recipient()))
+}
+
+func TestDecrypt_modified(t *testing.T) {
+	pw, err := NewPasswordRecipient("secret")
+	assert.NoError(t, err)
+	encrypted := &bytes.Buffer{}
+	assert.NoError(t, Encrypt(encrypted, bytes.NewReader(bytes.Repeat([]byte("a"), 100)), pw))
+	id := NewPasswordIdentity("secret")
+
+	// Modified payload
+	payload := append([]byte{}, encrypted.Bytes()...)
+	payload[len(payload)-1] ^= 1
+	assert.EqualError(t, Decrypt(&bytes.Buffer{}, bytes.NewReader(payload), id),
+		"Encrypted file is corrupted or has been modified")
+
+	// Truncated payload
+	truncated := encrypted.Bytes()[:encrypted.Len()-20]
+	assert.Error(t, Decrypt(&bytes.Buffer{}, bytes.NewReader(truncated), id))
+
+	// Modified header
+	header := bytes.Replace(encrypted.Bytes(), []byte(" 10\n"), []byte(" 10 \n"), 1)
+	assert.Error(t, Decrypt(&bytes.Buffer{}, bytes.NewReader(header), id))
+
+	// Not encrypted at all
+	assert.EqualError(t, Decrypt(&bytes.Buffer{}, bytes.NewReader([]byte("PK\x03\x04")), id),
+		"Error while reading header: File is not encrypted with age")
+}
+
+func TestEncryptFile_DecryptFile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	plain := filepath.Join(tmp, "backup.jwlibrary")
+	encrypted := filepath.Join(tmp, "backup.jwlibrary.age")
+	decrypted := filepath.Join(tmp, "decrypted.jwlibrary")
+	assert.NoError(t, ioutil.WriteFile(plain, []byte("PK\x03\x04 backup"), 0644))
+
+	isEncrypted, err := IsEncrypted(plain)
+	assert.NoError(t, err)
+	assert.False(t, isEncrypted)
+
+	pw, err := NewPasswordRecipient("secret")
+	assert.NoError(t, err)
+	assert.NoError(t, EncryptFile(plain, encrypted, pw))
+	isEncrypted, err = IsEncrypted(encrypted)
+	assert.NoError(t, err)
+	assert.True(t, isEncrypted)
+
+	assert.NoError(t, DecryptFile(encrypted, decrypted, NewPasswordIdentity("secret")))
+	content, err := ioutil.ReadFile(decrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "PK\x03\x04 backup", string(content))
+
+	_, err = IsEncrypted(filepath.Join(tmp, "nonexistent"))
+	assert.Error(t, err)
+}
+
+func TestDecrypt_ageCLI(t *testing.T) {
+	// Both files have been encrypted with the age CLI (v1.0.0)
+	id, err := ParseIdentity(testIdentity)
+	assert.NoError(t, err)
+	tests := map[string]Identity{
+		"x25519.age":   id,
+		"password.age": NewPasswordIdentity("secret"),
+	}
+
+	for file, id := range tests {
+		encrypted, err := ioutil.ReadFile(filepath.Join("testdata", file))
+		assert.NoError(t, err)
+		decrypted := &bytes.Buffer{}
+		assert.NoError(t, Decrypt(decrypted, bytes.NewReader(encrypted), id), file)
+		assert.Equal(t, "encrypted with the age CLI\n", decrypted.String(), file)
+	}
+}
+
+func TestDecrypt_workFactor(t *testing.T) {
+	pw, err := NewPasswordRecipient("secret")
+	assert.NoError(t, err)
+	encrypted := &bytes.Buffer{}
+	assert.NoError(t, Encrypt(encrypted, bytes.NewReader([]byte("notes")), pw))
+
+	tooLarge := bytes.Replace(encrypted.Bytes(), []byte(" 10\n"), []byte(" 23\n"), 1)
+	err = Decrypt(&bytes.Buffer{}, bytes.NewReader(tooLarge), NewPasswordIdentity("secret"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "scrypt work factor too large: 23")
+}
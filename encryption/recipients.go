@@ -0,0 +1,94 @@
+package encryption
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/pkg/errors"
+)
+
+// scryptMaxWorkFactor limits the work factor accepted when decrypting,
+// so malicious files can't make us compute forever.
+const scryptMaxWorkFactor = 22
+
+// scryptWorkFactor is the logarithm of the scrypt cost parameter N
+// used when encrypting with a password.
+var scryptWorkFactor = 18
+
+// NewPasswordRecipient returns a Recipient for the given password
+func NewPasswordRecipient(password string) (*age.ScryptRecipient, error) {
+	if password == "" {
+		return nil, errors.New("Password must not be empty")
+	}
+	r, err := age.NewScryptRecipient(password)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while creating recipient")
+	}
+	r.SetWorkFactor(scryptWorkFactor)
+	return r, nil
+}
+
+// PasswordIdentity decrypts files that have been encrypted with a password
+type PasswordIdentity struct {
+	password string
+}
+
+// NewPasswordIdentity returns an Identity for the given password
+func NewPasswordIdentity(password string) *PasswordIdentity {
+	return &PasswordIdentity{password: password}
+}
+
+// Unwrap implements age.Identity. An empty password never matches.
+func (i *PasswordIdentity) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	id, err := age.NewScryptIdentity(i.password)
+	if err != nil {
+		return nil, age.ErrIncorrectIdentity
+	}
+	id.SetMaxWorkFactor(scryptMaxWorkFactor)
+	return id.Unwrap(stanzas)
+}
+
+// ParseRecipient parses an age public key, like age1...
+func ParseRecipient(s string) (*age.X25519Recipient, error) {
+	r, err := age.ParseX25519Recipient(strings.TrimSpace(s))
+	return r, errors.Wrapf(err, "Error while parsing recipient %s", s)
+}
+
+// GenerateX25519Identity generates a new random age key
+func GenerateX25519Identity() (*age.X25519Identity, error) {
+	id, err := age.GenerateX25519Identity()
+	return id, errors.Wrap(err, "Error while generating key")
+}
+
+// ParseIdentity parses an age secret key, like AGE-SECRET-KEY-1...
+func ParseIdentity(s string) (*age.X25519Identity, error) {
+	id, err := age.ParseX25519Identity(strings.TrimSpace(s))
+	return id, errors.Wrap(err, "Error while parsing identity")
+}
+
+// ParseIdentities reads all age secret keys from an identity file,
+// ignoring empty lines and comments starting with #.
+func ParseIdentities(r io.Reader) ([]Identity, error) {
+	var identities []Identity
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, err := ParseIdentity(line)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error while reading identities")
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("No identities found")
+	}
+	return identities, nil
+}
@@ -0,0 +1,74 @@
+package encryption
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testIdentity  = "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX"
+	testRecipient = "age1zvkyg2lqzraa2lnjvqej32nkuu0ues2s82hzrye869xeexvn73equnujwj"
+)
+
+func TestParseIdentity(t *testing.T) {
+	id, err := ParseIdentity(testIdentity)
+	assert.NoError(t, err)
+	assert.Equal(t, testIdentity, id.String())
+	assert.Equal(t, testRecipient, id.Recipient().String())
+
+	_, err = ParseIdentity(testRecipient)
+	assert.Error(t, err)
+	_, err = ParseIdentity(strings.Replace(testIdentity, "GFPQ", "GFPR", 1))
+	assert.Error(t, err)
+}
+
+func TestParseRecipient(t *testing.T) {
+	r, err := ParseRecipient(testRecipient)
+	assert.NoError(t, err)
+	assert.Equal(t, testRecipient, r.String())
+
+	_, err = ParseRecipient(testIdentity)
+	assert.Error(t, err)
+	_, err = ParseRecipient("age1")
+	assert.Error(t, err)
+}
+
+func TestParseIdentities(t *testing.T) {
+	ids, err := ParseIdentities(strings.NewReader("# created: today\n# public key: " + testRecipient + "\n" + testIdentity + "\n\n"))
+	assert.NoError(t, err)
+	assert.Len(t, ids, 1)
+
+	_, err = ParseIdentities(strings.NewReader("# nothing here\n"))
+	assert.EqualError(t, err, "No identities found")
+
+	_, err = ParseIdentities(strings.NewReader("garbage\n"))
+	assert.Error(t, err)
+}
+
+func TestGenerateX25519Identity(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	assert.NoError(t, err)
+	parsed, err := ParseIdentity(id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, id.Recipient().String(), parsed.Recipient().String())
+}
+
+func TestNewPasswordRecipient(t *testing.T) {
+	_, err := NewPasswordRecipient("")
+	assert.Error(t, err)
+}
+
+func TestPasswordIdentity_Unwrap(t *testing.T) {
+	id, err := GenerateX25519Identity()
+	assert.NoError(t, err)
+	encrypted := &bytes.Buffer{}
+	assert.NoError(t, Encrypt(encrypted, bytes.NewReader([]byte("notes")), id.Recipient()))
+
+	for _, password := range []string{"secret", ""} {
+		err := Decrypt(&bytes.Buffer{}, bytes.NewReader(encrypted.Bytes()), NewPasswordIdentity(password))
+		assert.Equal(t, ErrNoIdentityMatched, err)
+	}
+}
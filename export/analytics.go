@@ -0,0 +1,197 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// AnalyticsTables contains the names of the tables of the Analytics,
+// as they can be written as CSV.
+var AnalyticsTables = []string{"notes", "months", "publications", "tags"}
+
+// Analytics contains statistics about the notes of a backup,
+// so study habits can be tracked over time.
+type Analytics struct {
+	Notes         []NoteStats            `json:"notes"`
+	NotesPerMonth []MonthCount           `json:"notesPerMonth"`
+	Publications  []PublicationNoteStats `json:"publications"`
+	TagsPerMonth  []TagMonthCount        `json:"tagsPerMonth"`
+}
+
+// NoteStats contains the number of words of a note
+type NoteStats struct {
+	GUID         string `json:"guid"`
+	Title        string `json:"title"`
+	Publication  string `json:"publication"`
+	LastModified string `json:"lastModified"`
+	Words        int    `json:"words"`
+}
+
+// MonthCount contains the number of notes last modified within a month
+type MonthCount struct {
+	Month string `json:"month"`
+	Notes int    `json:"notes"`
+}
+
+// PublicationNoteStats contains the number of notes of a publication
+// and their average number of words.
+type PublicationNoteStats struct {
+	Title        string  `json:"title"`
+	Notes        int     `json:"notes"`
+	AverageWords float64 `json:"averageWords"`
+}
+
+// TagMonthCount contains the number of notes with a tag
+// that have been last modified within a month.
+type TagMonthCount struct {
+	Month string `json:"month"`
+	Tag   string `json:"tag"`
+	Notes int    `json:"notes"`
+}
+
+// NoteAnalytics computes the Analytics of the notes of the given Database.
+// Words are counted in the content of a note, ignoring HTML tags. Notes are
+// assigned to the month they have been last modified in; notes without a
+// valid LastModified are left out of the monthly statistics. Months are
+// sorted chronologically, publications by their title.
+func NoteAnalytics(db *model.Database) *Analytics {
+	a := &Analytics{
+		Notes:         []NoteStats{},
+		NotesPerMonth: []MonthCount{},
+		Publications:  []PublicationNoteStats{},
+		TagsPerMonth:  []TagMonthCount{},
+	}
+	months := map[string]int{}
+	tagMonths := map[[2]string]int{}
+
+	for _, publ := range Collect(db) {
+		if len(publ.Notes) == 0 {
+			continue
+		}
+		words := 0
+		for _, note := range publ.Notes {
+			stats := NoteStats{
+				GUID:         note.GUID,
+				Title:        note.Title,
+				Publication:  publ.Title,
+				LastModified: note.LastModified,
+				Words:        countWords(note.Content),
+			}
+			a.Notes = append(a.Notes, stats)
+			words += stats.Words
+
+			month, ok := noteMonth(note.LastModified)
+			if !ok {
+				continue
+			}
+			months[month]++
+			for _, tag := range note.Tags {
+				tagMonths[[2]string{month, tag}]++
+			}
+		}
+		a.Publications = append(a.Publications, PublicationNoteStats{
+			Title:        publ.Title,
+			Notes:        len(publ.Notes),
+			AverageWords: float64(words) / float64(len(publ.Notes)),
+		})
+	}
+
+	for month, count := range months {
+		a.NotesPerMonth = append(a.NotesPerMonth, MonthCount{Month: month, Notes: count})
+	}
+	sort.Slice(a.NotesPerMonth, func(i, j int) bool {
+		return a.NotesPerMonth[i].Month < a.NotesPerMonth[j].Month
+	})
+	for key, count := range tagMonths {
+		a.TagsPerMonth = append(a.TagsPerMonth, TagMonthCount{Month: key[0], Tag: key[1], Notes: count})
+	}
+	sort.Slice(a.TagsPerMonth, func(i, j int) bool {
+		if a.TagsPerMonth[i].Month != a.TagsPerMonth[j].Month {
+			return a.TagsPerMonth[i].Month < a.TagsPerMonth[j].Month
+		}
+		return a.TagsPerMonth[i].Tag < a.TagsPerMonth[j].Tag
+	})
+
+	return a
+}
+
+// JSON writes the Analytics as JSON to w
+func (a *Analytics) JSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(a); err != nil {
+		return errors.Wrap(err, "Error while writing analytics")
+	}
+	return nil
+}
+
+// CSV writes the given table of the Analytics as CSV to w.
+// See AnalyticsTables for the supported tables.
+func (a *Analytics) CSV(w io.Writer, table string) error {
+	var records [][]string
+	switch table {
+	case "notes":
+		records = [][]string{{"guid", "title", "publication", "lastModified", "words"}}
+		for _, n := range a.Notes {
+			records = append(records, []string{n.GUID, n.Title, n.Publication, n.LastModified, strconv.Itoa(n.Words)})
+		}
+	case "months":
+		records = [][]string{{"month", "notes"}}
+		for _, m := range a.NotesPerMonth {
+			records = append(records, []string{m.Month, strconv.Itoa(m.Notes)})
+		}
+	case "publications":
+		records = [][]string{{"title", "notes", "averageWords"}}
+		for _, p := range a.Publications {
+			records = append(records, []string{p.Title, strconv.Itoa(p.Notes), strconv.FormatFloat(p.AverageWords, 'f', 1, 64)})
+		}
+	case "tags":
+		records = [][]string{{"month", "tag", "notes"}}
+		for _, t := range a.TagsPerMonth {
+			records = append(records, []string{t.Month, t.Tag, strconv.Itoa(t.Notes)})
+		}
+	default:
+		return errors.Errorf("Analytics table %s does not exist", table)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(records); err != nil {
+		return errors.Wrap(err, "Error while writing analytics")
+	}
+	return nil
+}
+
+// countWords counts the words of the given note content, ignoring HTML tags
+func countWords(content string) int {
+	var text strings.Builder
+	inTag := false
+	for _, r := range content {
+		switch {
+		case r == '<':
+			inTag = true
+			text.WriteRune(' ')
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			text.WriteRune(r)
+		}
+	}
+	return len(strings.Fields(text.String()))
+}
+
+// noteMonth returns the month (like 2020-04) of the given LastModified
+func noteMonth(lastModified string) (string, bool) {
+	t, err := time.Parse(time.RFC3339, lastModified)
+	if err != nil {
+		return "", false
+	}
+	return t.Format("2006-01"), true
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoteAnalytics(t *testing.T) {
+	db := model.MakeDatabaseCopy(studyDB)
+	db.Note[3].Content.String = "<p>Some words</p><p>about faith</p>"
+	db.Note[3].Content.Valid = true
+	db.Note[2].LastModified = "2021-01-02T08:00:00+00:00"
+
+	a := NoteAnalytics(db)
+	nwt := "New World Translation of the Holy Scriptures (Study Edition)"
+	assert.Equal(t, []NoteStats{
+		{GUID: "2", Title: "Earlier note", Publication: nwt, LastModified: "2021-01-02T08:00:00+00:00", Words: 0},
+		{GUID: "1", Title: "Later note", Publication: nwt, LastModified: "2020-04-14T18:42:58+00:00", Words: 2},
+		{GUID: "3", Title: "Loose note", Publication: "Other notes", LastModified: "2020-04-14T18:42:58+00:00", Words: 4},
+	}, a.Notes)
+	assert.Equal(t, []MonthCount{
+		{Month: "2020-04", Notes: 2},
+		{Month: "2021-01", Notes: 1},
+	}, a.NotesPerMonth)
+	assert.Equal(t, []PublicationNoteStats{
+		{Title: nwt, Notes: 2, AverageWords: 1},
+		{Title: "Other notes", Notes: 1, AverageWords: 4},
+	}, a.Publications)
+	assert.Equal(t, []TagMonthCount{
+		{Month: "2020-04", Tag: "Creation", Notes: 1},
+		{Month: "2020-04", Tag: "Faith", Notes: 2},
+	}, a.TagsPerMonth)
+
+	db.Note[2].LastModified = "yesterday"
+	assert.Equal(t, []MonthCount{{Month: "2020-04", Notes: 2}}, NoteAnalytics(db).NotesPerMonth)
+
+	empty := NoteAnalytics(&model.Database{})
+	assert.Empty(t, empty.Notes)
+	assert.Empty(t, empty.Publications)
+}
+
+func TestAnalytics_CSV(t *testing.T) {
+	a := NoteAnalytics(studyDB)
+
+	var buf bytes.Buffer
+	assert.NoError(t, a.CSV(&buf, "months"))
+	assert.Equal(t, "month,notes\n2020-04,3\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, a.CSV(&buf, "publications"))
+	assert.Equal(t, `title,notes,averageWords
+New World Translation of the Holy Scriptures (Study Edition),2,1.0
+Other notes,1,0.0
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, a.CSV(&buf, "tags"))
+	assert.Equal(t, "month,tag,notes\n2020-04,Creation,1\n2020-04,Faith,2\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, a.CSV(&buf, "notes"))
+	assert.Contains(t, buf.String(), "guid,title,publication,lastModified,words\n")
+	assert.Contains(t, buf.String(), "3,Loose note,Other notes,2020-04-14T18:42:58+00:00,0\n")
+
+	assert.EqualError(t, a.CSV(&buf, "colors"), "Analytics table colors does not exist")
+}
+
+func TestAnalytics_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, NoteAnalytics(studyDB).JSON(&buf))
+	assert.Contains(t, buf.String(), `"notesPerMonth": [`)
+	assert.Contains(t, buf.String(), `"averageWords": 1`)
+
+	buf.Reset()
+	assert.NoError(t, NoteAnalytics(&model.Database{}).JSON(&buf))
+	assert.JSONEq(t, `{"notes": [], "notesPerMonth": [], "publications": [], "tagsPerMonth": []}`, buf.String())
+}
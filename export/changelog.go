@@ -0,0 +1,237 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// Actions of a Change
+const (
+	ChangeAdded   = "added"
+	ChangeChanged = "changed"
+	ChangeRemoved = "removed"
+)
+
+// Changelog lists the notes and highlights that have been added, changed,
+// or removed between an older and a newer backup
+type Changelog struct {
+	Added        int                    `json:"added"`
+	Changed      int                    `json:"changed"`
+	Removed      int                    `json:"removed"`
+	Publications []ChangelogPublication `json:"publications"`
+}
+
+// ChangelogPublication groups the changes within a publication by Bible book
+type ChangelogPublication struct {
+	Title string          `json:"title"`
+	Books []ChangelogBook `json:"books"`
+}
+
+// ChangelogBook contains the changes within a Bible book. Changes of
+// publications other than the Bible are grouped without a book.
+type ChangelogBook struct {
+	BibleBook string   `json:"bibleBook,omitempty"`
+	Changes   []Change `json:"changes"`
+}
+
+// Change is a note or highlight that has been added, changed, or removed.
+// For removed entries, it describes the entry of the older backup.
+type Change struct {
+	Action   string `json:"action"`
+	Type     string `json:"type"`
+	Location string `json:"location,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Content  string `json:"content,omitempty"`
+	Color    string `json:"color,omitempty"`
+	Date     string `json:"date,omitempty"`
+	book     string
+	position position
+}
+
+// changelogEntry is a note or highlight together with its publication
+type changelogEntry struct {
+	publication string
+	change      Change
+	// compare contains everything that makes up a change of the entry
+	compare string
+}
+
+// NewChangelog compares the notes and highlights of the older and the newer
+// Database. Entries are identified by their GUID, so an entry is changed if
+// its content, tags, color, or location differ. Publications are sorted by
+// their title, while changes keep the order in which they appear.
+func NewChangelog(older *model.Database, newer *model.Database) *Changelog {
+	olderEntries := changelogEntries(older)
+	newerEntries := changelogEntries(newer)
+
+	changelog := &Changelog{Publications: []ChangelogPublication{}}
+	var changes []changelogEntry
+	for key, entry := range newerEntries {
+		old, ok := olderEntries[key]
+		switch {
+		case !ok:
+			entry.change.Action = ChangeAdded
+			changelog.Added++
+		case old.compare != entry.compare:
+			entry.change.Action = ChangeChanged
+			changelog.Changed++
+		default:
+			continue
+		}
+		changes = append(changes, entry)
+	}
+	for key, entry := range olderEntries {
+		if _, ok := newerEntries[key]; ok {
+			continue
+		}
+		entry.change.Action = ChangeRemoved
+		changelog.Removed++
+		changes = append(changes, entry)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.publication != b.publication {
+			return a.publication < b.publication
+		}
+		if a.change.position != b.change.position {
+			return a.change.position.less(b.change.position)
+		}
+		if a.change.Type != b.change.Type {
+			return a.change.Type > b.change.Type
+		}
+		if a.change.Title != b.change.Title {
+			return a.change.Title < b.change.Title
+		}
+		return a.compare < b.compare
+	})
+
+	for _, entry := range changes {
+		pubs := changelog.Publications
+		if len(pubs) == 0 || pubs[len(pubs)-1].Title != entry.publication {
+			changelog.Publications = append(changelog.Publications, ChangelogPublication{Title: entry.publication})
+			pubs = changelog.Publications
+		}
+		publ := &pubs[len(pubs)-1]
+		if len(publ.Books) == 0 || publ.Books[len(publ.Books)-1].BibleBook != entry.change.book {
+			publ.Books = append(publ.Books, ChangelogBook{BibleBook: entry.change.book})
+		}
+		book := &publ.Books[len(publ.Books)-1]
+		book.Changes = append(book.Changes, entry.change)
+	}
+
+	return changelog
+}
+
+// changelogEntries returns the notes and highlights of the given
+// Database, indexed by their GUID
+func changelogEntries(db *model.Database) map[string]changelogEntry {
+	entries := map[string]changelogEntry{}
+	for _, publ := range Collect(db) {
+		for _, note := range publ.Notes {
+			key := "note_" + note.GUID
+			if note.GUID == "" {
+				key = "note__" + note.Location + "_" + note.Title
+			}
+			entries[key] = changelogEntry{
+				publication: publ.Title,
+				change: Change{
+					Type:     "note",
+					Location: note.Location,
+					Title:    note.Title,
+					Content:  note.Content,
+					Date:     note.LastModified,
+					book:     note.BibleBook,
+					position: note.position,
+				},
+				compare: strings.Join([]string{publ.Title, note.Location, note.Title, note.Content,
+					strings.Join(note.Tags, ","), ColorName(note.ColorIndex)}, "\x00"),
+			}
+		}
+		for _, hl := range publ.Highlights {
+			key := "highlight_" + hl.GUID
+			if hl.GUID == "" {
+				key = "highlight__" + hl.Location + "_" + ColorName(hl.ColorIndex)
+			}
+			entries[key] = changelogEntry{
+				publication: publ.Title,
+				change: Change{
+					Type:     "highlight",
+					Location: hl.Location,
+					Color:    ColorName(hl.ColorIndex),
+					book:     hl.BibleBook,
+					position: hl.position,
+				},
+				compare: strings.Join([]string{publ.Title, hl.Location, ColorName(hl.ColorIndex)}, "\x00"),
+			}
+		}
+	}
+	return entries
+}
+
+// JSON writes the changelog as indented JSON to w
+func (c *Changelog) JSON(w io.Writer) error {
+	jsn, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Error while marshalling changelog")
+	}
+	if _, err := fmt.Fprintln(w, string(jsn)); err != nil {
+		return errors.Wrap(err, "Error while writing changelog")
+	}
+	return nil
+}
+
+// Markdown writes the changelog as Markdown to w
+func (c *Changelog) Markdown(w io.Writer) error {
+	var md strings.Builder
+	md.WriteString("# Changelog\n\n")
+	fmt.Fprintf(&md, "%d added, %d changed, and %d removed notes and highlights.\n", c.Added, c.Changed, c.Removed)
+
+	for _, publ := range c.Publications {
+		fmt.Fprintf(&md, "\n## %s\n", publ.Title)
+		for _, book := range publ.Books {
+			if book.BibleBook != "" {
+				fmt.Fprintf(&md, "\n### %s\n", book.BibleBook)
+			}
+			md.WriteString("\n")
+			for _, change := range book.Changes {
+				md.WriteString(change.markdown() + "\n")
+			}
+		}
+	}
+
+	if _, err := io.WriteString(w, md.String()); err != nil {
+		return errors.Wrap(err, "Error while writing changelog")
+	}
+	return nil
+}
+
+// markdown returns the change as Markdown list entry,
+// like `- Added note "Title" at Genesis 1:3 (2020-04-14)`
+func (c Change) markdown() string {
+	line := fmt.Sprintf("- %s %s", strings.ToUpper(c.Action[:1])+c.Action[1:], c.Type)
+	if c.Title != "" {
+		line += fmt.Sprintf(" \"%s\"", strings.Join(strings.Fields(c.Title), " "))
+	}
+	if c.Location != "" {
+		line += " at " + c.Location
+	}
+
+	var details []string
+	if c.Color != "" {
+		details = append(details, c.Color)
+	}
+	if len(c.Date) >= len("2006-01-02") {
+		details = append(details, c.Date[:len("2006-01-02")])
+	}
+	if len(details) > 0 {
+		line += " (" + strings.Join(details, ", ") + ")"
+	}
+	return line
+}
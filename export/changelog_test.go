@@ -0,0 +1,161 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewChangelog(t *testing.T) {
+	older := model.MakeDatabaseCopy(studyDB)
+	older.UserMark[1].UserMarkGUID = "UM1"
+	older.UserMark[2].UserMarkGUID = "UM2"
+
+	newer := model.MakeDatabaseCopy(older)
+	newer.Note[1].Content = sql.NullString{String: "New content", Valid: true}
+	newer.Note[1].LastModified = "2021-05-03T10:00:00+00:00"
+	newer.Note[3] = nil
+	newer.Note = append(newer.Note, &model.Note{
+		NoteID:          4,
+		GUID:            "4",
+		LocationID:      sql.NullInt32{Int32: 2, Valid: true},
+		Title:           sql.NullString{String: "New note", Valid: true},
+		LastModified:    "2021-05-02T10:00:00+00:00",
+		BlockType:       2,
+		BlockIdentifier: sql.NullInt32{Int32: 5, Valid: true},
+	})
+	newer.UserMark[2].ColorIndex = 1
+
+	changelog := NewChangelog(older, newer)
+	assert.Equal(t, &Changelog{
+		Added:   1,
+		Changed: 2,
+		Removed: 1,
+		Publications: []ChangelogPublication{
+			{
+				Title: "New World Translation of the Holy Scriptures (Study Edition)",
+				Books: []ChangelogBook{
+					{
+						BibleBook: "Genesis",
+						Changes: []Change{
+							{
+								Action:   ChangeAdded,
+								Type:     "note",
+								Location: "Genesis 2:5",
+								Title:    "New note",
+								Date:     "2021-05-02T10:00:00+00:00",
+								book:     "Genesis",
+								position: position{1, 2, 5},
+							},
+							{
+								Action:   ChangeChanged,
+								Type:     "note",
+								Location: "Genesis 10:3",
+								Title:    "Later note",
+								Content:  "New content",
+								Date:     "2021-05-03T10:00:00+00:00",
+								book:     "Genesis",
+								position: position{1, 10, 3},
+							},
+						},
+					},
+				},
+			},
+			{
+				Title: "Other notes",
+				Books: []ChangelogBook{
+					{
+						Changes: []Change{
+							{
+								Action: ChangeRemoved,
+								Type:   "note",
+								Title:  "Loose note",
+								Date:   "2020-04-14T18:42:58+00:00",
+							},
+						},
+					},
+				},
+			},
+			{
+				Title: "The Watchtower, January 2020",
+				Books: []ChangelogBook{
+					{
+						Changes: []Change{
+							{
+								Action:   ChangeChanged,
+								Type:     "highlight",
+								Location: "Study Article 1 ¶7",
+								Color:    "yellow",
+								position: position{0, 2020123, 7},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, changelog)
+
+	unchanged := NewChangelog(older, older)
+	assert.Equal(t, &Changelog{Publications: []ChangelogPublication{}}, unchanged)
+}
+
+func TestChangelog_Markdown(t *testing.T) {
+	older := model.MakeDatabaseCopy(studyDB)
+	newer := model.MakeDatabaseCopy(studyDB)
+	newer.Note[1].Title = sql.NullString{String: "Changed\ntitle", Valid: true}
+	newer.UserMark[2] = nil
+
+	var buf bytes.Buffer
+	assert.NoError(t, NewChangelog(older, newer).Markdown(&buf))
+	assert.Equal(t, `# Changelog
+
+0 added, 1 changed, and 1 removed notes and highlights.
+
+## New World Translation of the Holy Scriptures (Study Edition)
+
+### Genesis
+
+- Changed note "Changed title" at Genesis 10:3 (2020-04-14)
+
+## The Watchtower, January 2020
+
+- Removed highlight at Study Article 1 ¶7 (pink)
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, NewChangelog(older, older).Markdown(&buf))
+	assert.Equal(t, "# Changelog\n\n0 added, 0 changed, and 0 removed notes and highlights.\n", buf.String())
+}
+
+func TestChangelog_JSON(t *testing.T) {
+	newer := model.MakeDatabaseCopy(studyDB)
+	newer.Note[3] = nil
+
+	var buf bytes.Buffer
+	assert.NoError(t, NewChangelog(studyDB, newer).JSON(&buf))
+	assert.JSONEq(t, `{
+		"added": 0,
+		"changed": 0,
+		"removed": 1,
+		"publications": [
+			{
+				"title": "Other notes",
+				"books": [
+					{
+						"changes": [
+							{
+								"action": "removed",
+								"type": "note",
+								"title": "Loose note",
+								"date": "2020-04-14T18:42:58+00:00"
+							}
+						]
+					}
+				]
+			}
+		]
+	}`, buf.String())
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// HeatmapEntry contains the number of notes and highlights
+// within a chapter of the Bible.
+type HeatmapEntry struct {
+	Book       int    `json:"book"`
+	BookName   string `json:"bookName"`
+	Chapter    int    `json:"chapter"`
+	Notes      int    `json:"notes"`
+	Highlights int    `json:"highlights"`
+}
+
+// Heatmap counts the notes and highlights of the given Database per
+// Bible book and chapter, so the coverage of the personal study can be
+// visualized. All Bible editions are counted together. Chapters without
+// any notes or highlights are left out. The entries are sorted in the
+// order of the Bible.
+func Heatmap(db *model.Database) []HeatmapEntry {
+	chapters := map[position]*HeatmapEntry{}
+	entry := func(book string, pos position) *HeatmapEntry {
+		key := position{pos[0], pos[1]}
+		if _, ok := chapters[key]; !ok {
+			chapters[key] = &HeatmapEntry{Book: pos[0], BookName: book, Chapter: pos[1]}
+		}
+		return chapters[key]
+	}
+
+	for _, publ := range Collect(db) {
+		for _, note := range publ.Notes {
+			if note.BibleBook != "" {
+				entry(note.BibleBook, note.position).Notes++
+			}
+		}
+		for _, hl := range publ.Highlights {
+			if hl.BibleBook != "" {
+				entry(hl.BibleBook, hl.position).Highlights++
+			}
+		}
+	}
+
+	result := make([]HeatmapEntry, 0, len(chapters))
+	for _, e := range chapters {
+		result = append(result, *e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Book != result[j].Book {
+			return result[i].Book < result[j].Book
+		}
+		return result[i].Chapter < result[j].Chapter
+	})
+	return result
+}
+
+// HeatmapJSON writes the Heatmap of the given Database as JSON to w
+func HeatmapJSON(db *model.Database, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Heatmap(db)); err != nil {
+		return errors.Wrap(err, "Error while writing heatmap")
+	}
+	return nil
+}
+
+// HeatmapCSV writes the Heatmap of the given Database as CSV to w
+func HeatmapCSV(db *model.Database, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{{"book", "bookName", "chapter", "notes", "highlights"}}
+	for _, e := range Heatmap(db) {
+		records = append(records, []string{
+			strconv.Itoa(e.Book),
+			e.BookName,
+			strconv.Itoa(e.Chapter),
+			strconv.Itoa(e.Notes),
+			strconv.Itoa(e.Highlights),
+		})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return errors.Wrap(err, "Error while writing heatmap")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeatmap(t *testing.T) {
+	db := model.MakeDatabaseCopy(studyDB)
+	db.UserMark = append(db.UserMark,
+		&model.UserMark{UserMarkID: 3, ColorIndex: 1, LocationID: 2},
+		&model.UserMark{UserMarkID: 4, ColorIndex: 2, LocationID: 2})
+
+	assert.Equal(t, []HeatmapEntry{
+		{Book: 1, BookName: "Genesis", Chapter: 2, Notes: 1, Highlights: 2},
+		{Book: 1, BookName: "Genesis", Chapter: 10, Notes: 1, Highlights: 1},
+	}, Heatmap(db))
+
+	assert.Empty(t, Heatmap(&model.Database{}))
+}
+
+func TestHeatmapCSV(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, HeatmapCSV(studyDB, &buf))
+	assert.Equal(t, `book,bookName,chapter,notes,highlights
+1,Genesis,2,1,0
+1,Genesis,10,1,1
+`, buf.String())
+}
+
+func TestHeatmapJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, HeatmapJSON(studyDB, &buf))
+	assert.JSONEq(t, `[
+		{"book": 1, "bookName": "Genesis", "chapter": 2, "notes": 1, "highlights": 0},
+		{"book": 1, "bookName": "Genesis", "chapter": 10, "notes": 1, "highlights": 1}
+	]`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, HeatmapJSON(&model.Database{}, &buf))
+	assert.Equal(t, "[]\n", buf.String())
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	_ "embed" // Needed for embedding the HTML template
+	"encoding/json"
+	"html/template"
+	"io"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// htmlTemplate is a self-contained page (including styles and scripts)
+// that lists notes and highlights grouped by publication.
+//
+//go:embed html.tmpl
+var htmlTemplate string
+
+var htmlTmpl = template.Must(template.New("html").Funcs(template.FuncMap{
+	"colorName": ColorName,
+	"colorRGB": func(colorIndex int) template.CSS {
+		return template.CSS(ColorRGB(colorIndex))
+	},
+	"json": func(v interface{}) (string, error) {
+		jsn, err := json.Marshal(v)
+		return string(jsn), err
+	},
+}).Parse(htmlTemplate))
+
+type htmlData struct {
+	Publications []*Publication
+	Tags         []string
+}
+
+// HTML writes the notes and highlights of the given Database as a single,
+// self-contained HTML file to w. Notes are grouped by publication and can be
+// filtered by their tags, so they can be browsed or printed outside the app.
+func HTML(db *model.Database, w io.Writer) error {
+	publications := Collect(db)
+	data := htmlData{
+		Publications: publications,
+		Tags:         Tags(publications),
+	}
+
+	if err := htmlTmpl.Execute(w, data); err != nil {
+		return errors.Wrap(err, "Error while rendering HTML export")
+	}
+	return nil
+}
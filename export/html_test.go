@@ -0,0 +1,28 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, HTML(studyDB, &buf))
+	html := buf.String()
+
+	assert.Contains(t, html, "<h2>New World Translation of the Holy Scriptures (Study Edition)</h2>")
+	assert.Contains(t, html, "<h2>The Watchtower, January 2020</h2>")
+	assert.Contains(t, html, `<input type="checkbox" value="Creation"> Creation`)
+	assert.Contains(t, html, `style="border-left-color: #aed581" data-tags="[&#34;Creation&#34;,&#34;Faith&#34;]"`)
+	assert.Contains(t, html, `<li style="background: #f48fb1" title="pink">Study Article 1 ¶7</li>`)
+	assert.Contains(t, html, "Content &lt;b&gt;1&lt;/b&gt;")
+	assert.NotContains(t, html, "<b>1</b>")
+
+	buf.Reset()
+	assert.NoError(t, HTML(&model.Database{}, &buf))
+	assert.NotContains(t, buf.String(), `<nav id="tags">`)
+	assert.NotContains(t, buf.String(), "<section")
+}
@@ -0,0 +1,185 @@
+package export
+
+import (
+	_ "embed" // Needed for embedding the HTML template
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// mergeTemplate is a self-contained page listing the
+// entry counts and the resolved conflicts of a merge.
+//
+//go:embed merge.tmpl
+var mergeTemplate string
+
+var mergeTmpl = template.Must(template.New("merge").Parse(mergeTemplate))
+
+// MergeReport records what happened during a merge: the number of entries
+// per table and every conflict together with its resolution, so users can
+// double-check the merged backup later.
+type MergeReport struct {
+	Left        string       `json:"left"`
+	Right       string       `json:"right"`
+	Merged      string       `json:"merged"`
+	Created     time.Time    `json:"created"`
+	Tables      []TableCount `json:"tables"`
+	Resolutions []Resolution `json:"resolutions"`
+}
+
+// TableCount is the number of entries of a table in the left,
+// right, and merged backup
+type TableCount struct {
+	Table  string `json:"table"`
+	Left   int    `json:"left"`
+	Right  int    `json:"right"`
+	Merged int    `json:"merged"`
+}
+
+// Resolution is a conflict of a merge stage and how it has been resolved
+type Resolution struct {
+	Stage string `json:"stage"`
+	// Resolver is the automatic resolver that has been used
+	// (like chooseNewest), or empty if the user decided.
+	Resolver  string           `json:"resolver,omitempty"`
+	Side      merger.MergeSide `json:"side"`
+	Kept      string           `json:"kept"`
+	Discarded string           `json:"discarded"`
+}
+
+// AddResolutions adds the given solutions of a merge stage to the report.
+// The entries are pretty-printed using the (partly) merged Database, so
+// they include information about related entries.
+func (r *MergeReport) AddResolutions(stage string, resolver string, solutions map[string]merger.MergeSolution, mergedDB *model.Database) {
+	keys := make([]string, 0, len(solutions))
+	for key := range solutions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sol := solutions[key]
+		res := Resolution{
+			Stage:    stage,
+			Resolver: resolver,
+			Side:     sol.Side,
+		}
+		if sol.Solution != nil {
+			res.Kept = sol.Solution.PrettyPrint(mergedDB)
+		}
+		if sol.Discarded != nil {
+			res.Discarded = sol.Discarded.PrettyPrint(mergedDB)
+		}
+		r.Resolutions = append(r.Resolutions, res)
+	}
+}
+
+// SetCounts sets the number of entries per table of the given Databases
+func (r *MergeReport) SetCounts(left *model.Database, right *model.Database, merged *model.Database) {
+	r.Tables = nil
+	tp := reflect.TypeOf(model.Database{})
+	for i := 0; i < tp.NumField(); i++ {
+		if tp.Field(i).PkgPath != "" {
+			continue
+		}
+		name := tp.Field(i).Name
+		r.Tables = append(r.Tables, TableCount{
+			Table:  name,
+			Left:   countEntries(left, name),
+			Right:  countEntries(right, name),
+			Merged: countEntries(merged, name),
+		})
+	}
+}
+
+// countEntries returns the number of non-nil entries of the given table
+func countEntries(db *model.Database, table string) int {
+	if db == nil {
+		return 0
+	}
+	slice := reflect.ValueOf(db).Elem().FieldByName(table)
+	count := 0
+	for i := 0; i < slice.Len(); i++ {
+		if !slice.Index(i).IsNil() {
+			count++
+		}
+	}
+	return count
+}
+
+// Markdown writes the report as Markdown to w
+func (r *MergeReport) Markdown(w io.Writer) error {
+	var md strings.Builder
+	md.WriteString("# Merge report\n\n")
+	fmt.Fprintf(&md, "Merged %s and %s into %s on %s.\n",
+		r.Left, r.Right, r.Merged, r.Created.Format("2006-01-02 15:04:05"))
+
+	if len(r.Tables) > 0 {
+		md.WriteString("\n## Entries\n\n")
+		md.WriteString("| Table | Left | Right | Merged |\n|---|---:|---:|---:|\n")
+		for _, t := range r.Tables {
+			fmt.Fprintf(&md, "| %s | %d | %d | %d |\n", t.Table, t.Left, t.Right, t.Merged)
+		}
+	}
+
+	md.WriteString("\n## Conflicts\n\n")
+	if len(r.Resolutions) == 0 {
+		md.WriteString("There were no conflicts.\n")
+	}
+	for i, res := range r.Resolutions {
+		fmt.Fprintf(&md, "### %d. %s: kept %s\n\n", i+1, res.Stage, res.SideName())
+		fmt.Fprintf(&md, "Resolved %s.\n\n", res.ResolvedBy())
+		fmt.Fprintf(&md, "Kept:\n\n```\n%s\n```\n\n", res.Kept)
+		fmt.Fprintf(&md, "Discarded:\n\n```\n%s\n```\n\n", res.Discarded)
+	}
+
+	if _, err := io.WriteString(w, md.String()); err != nil {
+		return errors.Wrap(err, "Error while writing merge report")
+	}
+	return nil
+}
+
+// JSON writes the report as JSON to w
+func (r *MergeReport) JSON(w io.Writer) error {
+	jsn, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "Error while marshalling merge report")
+	}
+	if _, err := w.Write(jsn); err != nil {
+		return errors.Wrap(err, "Error while writing merge report")
+	}
+	return nil
+}
+
+// HTML writes the report as a self-contained HTML file to w
+func (r *MergeReport) HTML(w io.Writer) error {
+	if err := mergeTmpl.Execute(w, r); err != nil {
+		return errors.Wrap(err, "Error while rendering merge report")
+	}
+	return nil
+}
+
+// SideName returns the side that has been kept in a human readable form
+func (res Resolution) SideName() string {
+	if res.Side == merger.LeftSide {
+		return "left"
+	}
+	return "right"
+}
+
+// ResolvedBy describes who resolved the conflict
+func (res Resolution) ResolvedBy() string {
+	if res.Resolver == "" {
+		return "by you"
+	}
+	return "automatically using " + res.Resolver
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeReport_SetCounts(t *testing.T) {
+	left := &model.Database{
+		Tag: []*model.Tag{nil, {TagID: 1, Name: "A"}, {TagID: 2, Name: "B"}},
+	}
+	right := &model.Database{
+		Tag: []*model.Tag{nil, {TagID: 1, Name: "A"}},
+	}
+	merged := &model.Database{
+		Tag: []*model.Tag{nil, {TagID: 1, Name: "A"}, {TagID: 2, Name: "B"}},
+	}
+
+	report := &MergeReport{}
+	report.SetCounts(left, right, merged)
+	assert.Contains(t, report.Tables, TableCount{Table: "Tag", Left: 2, Right: 1, Merged: 2})
+	assert.Contains(t, report.Tables, TableCount{Table: "Note", Left: 0, Right: 0, Merged: 0})
+
+	report.SetCounts(nil, nil, nil)
+	assert.Contains(t, report.Tables, TableCount{Table: "Tag"})
+}
+
+func TestMergeReport_AddResolutions(t *testing.T) {
+	leftNote := &model.Note{NoteID: 1, GUID: "B", Title: sql.NullString{String: "Left note", Valid: true}, Content: sql.NullString{String: "Left content", Valid: true}}
+	rightNote := &model.Note{NoteID: 1, GUID: "A", Title: sql.NullString{String: "Right note", Valid: true}, Content: sql.NullString{String: "Right content", Valid: true}}
+
+	report := &MergeReport{}
+	report.AddResolutions(merger.StageNotes, "chooseRight", map[string]merger.MergeSolution{
+		"B": {Side: merger.RightSide, Solution: rightNote, Discarded: leftNote},
+		"A": {Side: merger.LeftSide, Solution: leftNote, Discarded: rightNote},
+	}, &model.Database{})
+
+	assert.Len(t, report.Resolutions, 2)
+	// Ordered by their key
+	assert.Equal(t, merger.LeftSide, report.Resolutions[0].Side)
+	assert.Equal(t, merger.RightSide, report.Resolutions[1].Side)
+	assert.Equal(t, "chooseRight", report.Resolutions[1].Resolver)
+	assert.Contains(t, report.Resolutions[1].Kept, "Right content")
+	assert.Contains(t, report.Resolutions[1].Discarded, "Left content")
+}
+
+func TestMergeReport_Markdown(t *testing.T) {
+	report := &MergeReport{
+		Left:    "left.jwlibrary",
+		Right:   "right.jwlibrary",
+		Merged:  "merged.jwlibrary",
+		Created: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Tables:  []TableCount{{Table: "Note", Left: 1, Right: 2, Merged: 2}},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.Markdown(&buf))
+	assert.Equal(t, `# Merge report
+
+Merged left.jwlibrary and right.jwlibrary into merged.jwlibrary on 2021-03-04 05:06:07.
+
+## Entries
+
+| Table | Left | Right | Merged |
+|---|---:|---:|---:|
+| Note | 1 | 2 | 2 |
+
+## Conflicts
+
+There were no conflicts.
+`, buf.String())
+
+	report.Resolutions = []Resolution{
+		{Stage: merger.StageNotes, Side: merger.LeftSide, Kept: "Left", Discarded: "Right"},
+		{Stage: merger.StageBookmarks, Resolver: "chooseRight", Side: merger.RightSide, Kept: "Right", Discarded: "Left"},
+	}
+	buf.Reset()
+	assert.NoError(t, report.Markdown(&buf))
+	assert.Contains(t, buf.String(), "### 1. Notes: kept left\n\nResolved by you.\n\nKept:\n\n```\nLeft\n```\n\nDiscarded:\n\n```\nRight\n```\n")
+	assert.Contains(t, buf.String(), "### 2. Bookmarks: kept right\n\nResolved automatically using chooseRight.")
+	assert.NotContains(t, buf.String(), "There were no conflicts.")
+}
+
+func TestMergeReport_JSON(t *testing.T) {
+	report := &MergeReport{
+		Left:    "left.jwlibrary",
+		Right:   "right.jwlibrary",
+		Merged:  "merged.jwlibrary",
+		Created: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Tables:  []TableCount{{Table: "Note", Left: 1, Right: 2, Merged: 2}},
+		Resolutions: []Resolution{
+			{Stage: merger.StageNotes, Resolver: "chooseNewest", Side: merger.RightSide, Kept: "Right", Discarded: "Left"},
+		},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.JSON(&buf))
+	assert.JSONEq(t, `{
+		"left": "left.jwlibrary",
+		"right": "right.jwlibrary",
+		"merged": "merged.jwlibrary",
+		"created": "2021-03-04T05:06:07Z",
+		"tables": [{"table": "Note", "left": 1, "right": 2, "merged": 2}],
+		"resolutions": [{"stage": "Notes", "resolver": "chooseNewest", "side": "rightSide", "kept": "Right", "discarded": "Left"}]
+	}`, buf.String())
+}
+
+func TestMergeReport_HTML(t *testing.T) {
+	report := &MergeReport{
+		Left:    "left.jwlibrary",
+		Right:   "right.jwlibrary",
+		Merged:  "merged.jwlibrary",
+		Created: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Tables:  []TableCount{{Table: "Note", Left: 1, Right: 2, Merged: 2}},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.HTML(&buf))
+	assert.Contains(t, buf.String(), "<title>Merge report</title>")
+	assert.Contains(t, buf.String(), "on 2021-03-04 05:06:07.")
+	assert.Contains(t, buf.String(), `<tr><td>Note</td><td class="count">1</td><td class="count">2</td><td class="count">2</td></tr>`)
+	assert.Contains(t, buf.String(), "There were no conflicts.")
+
+	report.Resolutions = []Resolution{
+		{Stage: merger.StageNotes, Side: merger.RightSide, Kept: "<b>Right</b>", Discarded: "Left"},
+	}
+	buf.Reset()
+	assert.NoError(t, report.HTML(&buf))
+	assert.Contains(t, buf.String(), "<h3>Notes: kept right</h3>")
+	assert.Contains(t, buf.String(), "Resolved by you.")
+	assert.Contains(t, buf.String(), "<pre>&lt;b&gt;Right&lt;/b&gt;</pre>")
+	assert.NotContains(t, buf.String(), "There were no conflicts.")
+}
@@ -0,0 +1,245 @@
+package export
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	vaultNotesDir        = "Notes"
+	vaultPublicationsDir = "Publications"
+	vaultBibleDir        = "Bible"
+	vaultIndex           = "Index"
+	maxVaultFilename     = 100
+)
+
+// vault keeps track of the files written to an Obsidian vault,
+// so every file gets a unique name that can be linked to.
+type vault struct {
+	dir   string
+	names map[string]bool
+}
+
+// Obsidian writes the notes and highlights of the given Database as an
+// Obsidian vault into dir. Every note becomes a Markdown file with YAML
+// front-matter (tags, location, color), linked from index files for every
+// publication and Bible book. As it only consists of Markdown files, the
+// vault can also be imported into Joplin and similar tools.
+func Obsidian(db *model.Database, dir string) error {
+	v := &vault{dir: dir, names: map[string]bool{}}
+	for _, sub := range []string{vaultNotesDir, vaultPublicationsDir, vaultBibleDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return errors.Wrap(err, "Error while creating vault directory")
+		}
+	}
+
+	publications := Collect(db)
+	publLinks := make([]string, 0, len(publications))
+	bookLinks := map[string][]string{}
+	bookNumbers := map[string]int{}
+	books := []string{}
+	for _, publ := range publications {
+		publName := v.uniqueName(vaultPublicationsDir, publ.Title)
+		publLink := wikiLink(vaultPublicationsDir, publName, publ.Title)
+		publLinks = append(publLinks, publLink)
+
+		var index strings.Builder
+		fmt.Fprintf(&index, "# %s\n", publ.Title)
+		if len(publ.Notes) > 0 {
+			index.WriteString("\n## Notes\n\n")
+		}
+		for _, note := range publ.Notes {
+			title := noteTitle(note)
+			name := v.uniqueName(vaultNotesDir, title)
+			link := wikiLink(vaultNotesDir, name, title)
+
+			bookLink := ""
+			if note.BibleBook != "" {
+				bookLink = wikiLink(vaultBibleDir, sanitizeFilename(note.BibleBook), note.BibleBook)
+				if _, ok := bookLinks[note.BibleBook]; !ok {
+					books = append(books, note.BibleBook)
+					bookNumbers[note.BibleBook] = note.position[0]
+				}
+				bookLinks[note.BibleBook] = append(bookLinks[note.BibleBook], listEntry(link, note.Location))
+			}
+			if err := v.write(vaultNotesDir, name, noteMarkdown(note, title, publ.Title, publLink, bookLink)); err != nil {
+				return err
+			}
+			index.WriteString(listEntry(link, note.Location))
+		}
+		if len(publ.Highlights) > 0 {
+			index.WriteString("\n## Highlights\n\n")
+		}
+		for _, hl := range publ.Highlights {
+			fmt.Fprintf(&index, "- %s (%s)\n", hl.Location, ColorName(hl.ColorIndex))
+		}
+		if err := v.write(vaultPublicationsDir, publName, index.String()); err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(books, func(i, j int) bool {
+		return bookNumbers[books[i]] < bookNumbers[books[j]]
+	})
+	bookIndexLinks := make([]string, 0, len(books))
+	for _, book := range books {
+		name := sanitizeFilename(book)
+		bookIndexLinks = append(bookIndexLinks, wikiLink(vaultBibleDir, name, book))
+		content := fmt.Sprintf("# %s\n\n%s", book, strings.Join(bookLinks[book], ""))
+		if err := v.write(vaultBibleDir, name, content); err != nil {
+			return err
+		}
+	}
+
+	var index strings.Builder
+	index.WriteString("# Index\n")
+	if len(publLinks) > 0 {
+		index.WriteString("\n## Publications\n\n")
+	}
+	for _, link := range publLinks {
+		index.WriteString(listEntry(link, ""))
+	}
+	if len(bookIndexLinks) > 0 {
+		index.WriteString("\n## Bible\n\n")
+	}
+	for _, link := range bookIndexLinks {
+		index.WriteString(listEntry(link, ""))
+	}
+	return v.write("", vaultIndex, index.String())
+}
+
+// uniqueName returns a filename (without extension) based on the given
+// title that has not been used within the given subdirectory yet. Names
+// are compared case-insensitively, as not every filesystem differentiates.
+func (v *vault) uniqueName(sub string, title string) string {
+	base := sanitizeFilename(title)
+	name := base
+	for i := 2; v.names[strings.ToLower(filepath.Join(sub, name))]; i++ {
+		name = base + " " + strconv.Itoa(i)
+	}
+	v.names[strings.ToLower(filepath.Join(sub, name))] = true
+	return name
+}
+
+// write stores the given content as a Markdown file
+func (v *vault) write(sub string, name string, content string) error {
+	path := filepath.Join(v.dir, sub, name+".md")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		return errors.Wrapf(err, "Error while writing %s", path)
+	}
+	return nil
+}
+
+// noteMarkdown returns the content of the Markdown file for the given note
+func noteMarkdown(note Note, title string, publTitle string, publLink string, bookLink string) string {
+	var md strings.Builder
+	md.WriteString("---\n")
+	fmt.Fprintf(&md, "title: %s\n", strconv.Quote(title))
+	if len(note.Tags) > 0 {
+		md.WriteString("tags:\n")
+		for _, tag := range note.Tags {
+			fmt.Fprintf(&md, "  - %s\n", strconv.Quote(vaultTag(tag)))
+		}
+	}
+	fmt.Fprintf(&md, "publication: %s\n", strconv.Quote(publTitle))
+	if note.Location != "" {
+		fmt.Fprintf(&md, "location: %s\n", strconv.Quote(note.Location))
+	}
+	if note.ColorIndex != 0 {
+		fmt.Fprintf(&md, "color: %s\n", ColorName(note.ColorIndex))
+	}
+	fmt.Fprintf(&md, "modified: %s\n", strconv.Quote(note.LastModified))
+	fmt.Fprintf(&md, "guid: %s\n", strconv.Quote(note.GUID))
+	md.WriteString("---\n\n")
+
+	fmt.Fprintf(&md, "# %s\n\n", title)
+	if note.Content != "" {
+		md.WriteString(note.Content)
+		md.WriteString("\n\n")
+	}
+	md.WriteString("Publication: " + publLink)
+	if note.Location != "" {
+		md.WriteString(", " + note.Location)
+	}
+	md.WriteString("\n")
+	if bookLink != "" {
+		md.WriteString("Bible book: " + bookLink + "\n")
+	}
+	return md.String()
+}
+
+// noteTitle returns the title of a note, falling back to its
+// location if it does not have one.
+func noteTitle(note Note) string {
+	switch {
+	case strings.TrimSpace(note.Title) != "":
+		return note.Title
+	case note.Location != "":
+		return note.Location
+	default:
+		return "Untitled"
+	}
+}
+
+// wikiLink returns an Obsidian link to the given file, shown as alias
+func wikiLink(sub string, name string, alias string) string {
+	target := name
+	if sub != "" {
+		target = sub + "/" + name
+	}
+	alias = strings.NewReplacer("[", "(", "]", ")", "|", "-").Replace(alias)
+	if alias == name {
+		return "[[" + target + "]]"
+	}
+	return "[[" + target + "|" + alias + "]]"
+}
+
+// listEntry returns a Markdown list entry for link with an optional suffix
+func listEntry(link string, suffix string) string {
+	if suffix == "" {
+		return "- " + link + "\n"
+	}
+	return "- " + link + " (" + suffix + ")\n"
+}
+
+// sanitizeFilename removes characters from title that are not
+// allowed in filenames or Obsidian links.
+func sanitizeFilename(title string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r == ':':
+			return '.'
+		case strings.ContainsRune(`\/*?"<>|#^[]`, r):
+			return -1
+		case unicode.IsSpace(r):
+			return ' '
+		case !unicode.IsPrint(r):
+			return -1
+		}
+		return r
+	}, title)
+	name = strings.Join(strings.Fields(name), " ")
+	name = strings.TrimLeft(name, ".")
+	if runes := []rune(name); len(runes) > maxVaultFilename {
+		name = strings.TrimSpace(string(runes[:maxVaultFilename]))
+	}
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+// vaultTag converts a tag name into an Obsidian tag,
+// which must not contain any whitespace.
+func vaultTag(tag string) string {
+	return strings.Join(strings.Fields(tag), "-")
+}
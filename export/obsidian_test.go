@@ -0,0 +1,108 @@
+package export
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObsidian(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := model.MakeDatabaseCopy(studyDB)
+	db.Note = append(db.Note, &model.Note{
+		NoteID:       4,
+		GUID:         "4",
+		Title:        sql.NullString{String: "loose note", Valid: true},
+		LastModified: "2020-04-14T18:42:58+00:00",
+	})
+	assert.NoError(t, Obsidian(db, tmp))
+
+	read := func(path ...string) string {
+		content, err := ioutil.ReadFile(filepath.Join(append([]string{tmp}, path...)...))
+		assert.NoError(t, err)
+		return string(content)
+	}
+
+	assert.Equal(t, `---
+title: "Later note"
+tags:
+  - "Creation"
+  - "Faith"
+publication: "New World Translation of the Holy Scriptures (Study Edition)"
+location: "Genesis 10:3"
+color: green
+modified: "2020-04-14T18:42:58+00:00"
+guid: "1"
+---
+
+# Later note
+
+Content <b>1</b>
+
+Publication: [[Publications/New World Translation of the Holy Scriptures (Study Edition)]], Genesis 10:3
+Bible book: [[Bible/Genesis]]
+`, read("Notes", "Later note.md"))
+
+	assert.Equal(t, `# New World Translation of the Holy Scriptures (Study Edition)
+
+## Notes
+
+- [[Notes/Earlier note]] (Genesis 2:1)
+- [[Notes/Later note]] (Genesis 10:3)
+
+## Highlights
+
+- Genesis 10:3-4 (green)
+`, read("Publications", "New World Translation of the Holy Scriptures (Study Edition).md"))
+
+	assert.Equal(t, `# Genesis
+
+- [[Notes/Earlier note]] (Genesis 2:1)
+- [[Notes/Later note]] (Genesis 10:3)
+`, read("Bible", "Genesis.md"))
+
+	assert.Equal(t, `# Other notes
+
+## Notes
+
+- [[Notes/Loose note]]
+- [[Notes/loose note 2|loose note]]
+`, read("Publications", "Other notes.md"))
+
+	assert.Equal(t, `# Index
+
+## Publications
+
+- [[Publications/New World Translation of the Holy Scriptures (Study Edition)]]
+- [[Publications/Other notes]]
+- [[Publications/The Watchtower, January 2020]]
+
+## Bible
+
+- [[Bible/Genesis]]
+`, read("Index.md"))
+}
+
+func Test_sanitizeFilename(t *testing.T) {
+	assert.Equal(t, "Genesis 1.3", sanitizeFilename("Genesis 1:3"))
+	assert.Equal(t, "A B", sanitizeFilename(" A/\\*?\"<>|#^[]\n\tB "))
+	assert.Equal(t, "hidden", sanitizeFilename("..hidden"))
+	assert.Equal(t, "Untitled", sanitizeFilename("???"))
+	long := ""
+	for i := 0; i < 30; i++ {
+		long += "äöüß "
+	}
+	assert.Len(t, []rune(sanitizeFilename(long)), maxVaultFilename-1)
+}
+
+func Test_vaultTag(t *testing.T) {
+	assert.Equal(t, "Bible-Study", vaultTag("Bible  Study "))
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// untaggedOutline is the outline containing notes without any tag
+const untaggedOutline = "Untagged"
+
+type opml struct {
+	XMLName  xml.Name      `xml:"opml"`
+	Version  string        `xml:"version,attr"`
+	Title    string        `xml:"head>title"`
+	Outlines []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Note     string        `xml:"_note,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// OPML writes the tags of the given Database as an OPML outline to w.
+// Every tag becomes an outline containing its notes as children, while
+// notes without any tag are collected in an additional outline. The
+// content of a note is stored in the _note attribute, which is understood
+// by outliners like OmniOutliner or Dynalist.
+func OPML(db *model.Database, w io.Writer) error {
+	publications := Collect(db)
+	tags := Tags(publications)
+
+	outlines := make(map[string]*opmlOutline, len(tags)+1)
+	doc := opml{Version: "2.0", Title: "Notes by tag"}
+	for _, tag := range append(tags, untaggedOutline) {
+		doc.Outlines = append(doc.Outlines, opmlOutline{Text: tag})
+	}
+	for i := range doc.Outlines {
+		outlines[doc.Outlines[i].Text] = &doc.Outlines[i]
+	}
+
+	for _, publ := range publications {
+		for _, note := range publ.Notes {
+			child := opmlOutline{Text: noteTitle(note), Note: note.Content}
+			if note.Location != "" && note.Location != child.Text {
+				child.Text += " (" + note.Location + ")"
+			}
+
+			noteTags := note.Tags
+			if len(noteTags) == 0 {
+				noteTags = []string{untaggedOutline}
+			}
+			for _, tag := range noteTags {
+				outlines[tag].Outlines = append(outlines[tag].Outlines, child)
+			}
+		}
+	}
+	if last := doc.Outlines[len(doc.Outlines)-1]; len(last.Outlines) == 0 {
+		doc.Outlines = doc.Outlines[:len(doc.Outlines)-1]
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.Wrap(err, "Error while writing OPML")
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return errors.Wrap(err, "Error while writing OPML")
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return errors.Wrap(err, "Error while writing OPML")
+	}
+	return nil
+}
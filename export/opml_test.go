@@ -0,0 +1,44 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOPML(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, OPML(studyDB, &buf))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head>
+    <title>Notes by tag</title>
+  </head>
+  <body>
+    <outline text="Creation">
+      <outline text="Later note (Genesis 10:3)" _note="Content &lt;b&gt;1&lt;/b&gt;"></outline>
+    </outline>
+    <outline text="Faith">
+      <outline text="Later note (Genesis 10:3)" _note="Content &lt;b&gt;1&lt;/b&gt;"></outline>
+      <outline text="Loose note"></outline>
+    </outline>
+    <outline text="Untagged">
+      <outline text="Earlier note (Genesis 2:1)"></outline>
+    </outline>
+  </body>
+</opml>
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, OPML(&model.Database{}, &buf))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head>
+    <title>Notes by tag</title>
+  </head>
+  <body></body>
+</opml>
+`, buf.String())
+}
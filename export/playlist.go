@@ -0,0 +1,192 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+type jsonPlaylist struct {
+	Name  string             `json:"name"`
+	Items []jsonPlaylistItem `json:"items"`
+}
+
+type jsonPlaylistItem struct {
+	Label             string                `json:"label"`
+	MediaType         int                   `json:"mediaType"`
+	Filename          string                `json:"filename,omitempty"`
+	Publication       *jsonPublicationMedia `json:"publication,omitempty"`
+	Duration          float64               `json:"durationSeconds,omitempty"`
+	StartTime         float64               `json:"startSeconds,omitempty"`
+	EndTime           float64               `json:"endSeconds,omitempty"`
+	EndAction         int                   `json:"endAction"`
+	ThumbnailFilename string                `json:"thumbnailFilename,omitempty"`
+	Markers           []jsonPlaylistMarker  `json:"markers,omitempty"`
+}
+
+type jsonPublicationMedia struct {
+	KeySymbol      string `json:"keySymbol"`
+	MepsLanguage   int    `json:"mepsLanguage"`
+	IssueTagNumber int    `json:"issueTagNumber,omitempty"`
+	DocumentID     int    `json:"documentId,omitempty"`
+	Track          int    `json:"track,omitempty"`
+	BookNumber     int    `json:"bookNumber,omitempty"`
+	ChapterNumber  int    `json:"chapterNumber,omitempty"`
+}
+
+type jsonPlaylistMarker struct {
+	Label      string  `json:"label"`
+	StartTime  float64 `json:"startSeconds"`
+	Transition float64 `json:"transitionSeconds,omitempty"`
+}
+
+// PlaylistsJSON writes the given playlists as JSON to w. Durations are given
+// in seconds. Media added by the user is referenced by its filename within
+// the backup, while media of publications is referenced by the publication.
+func PlaylistsJSON(playlists []model.Playlist, w io.Writer) error {
+	result := make([]jsonPlaylist, len(playlists))
+	for i, playlist := range playlists {
+		result[i] = jsonPlaylist{Name: playlist.Name, Items: []jsonPlaylistItem{}}
+		for _, item := range playlist.Items {
+			jsonItem := jsonPlaylistItem{
+				Label:             item.Label,
+				MediaType:         item.MediaType,
+				Filename:          item.Filename,
+				Duration:          item.Duration.Seconds(),
+				StartTime:         item.StartTime.Seconds(),
+				EndTime:           item.EndTime.Seconds(),
+				EndAction:         item.EndAction,
+				ThumbnailFilename: item.ThumbnailFilename,
+			}
+			if loc := item.Location; loc != nil {
+				jsonItem.Publication = &jsonPublicationMedia{
+					KeySymbol:      loc.KeySymbol.String,
+					MepsLanguage:   loc.MepsLanguage,
+					IssueTagNumber: loc.IssueTagNumber,
+					DocumentID:     int(loc.DocumentID.Int32),
+					Track:          int(loc.Track.Int32),
+					BookNumber:     int(loc.BookNumber.Int32),
+					ChapterNumber:  int(loc.ChapterNumber.Int32),
+				}
+			}
+			for _, marker := range item.Markers {
+				jsonItem.Markers = append(jsonItem.Markers, jsonPlaylistMarker{
+					Label:      marker.Label,
+					StartTime:  marker.StartTime.Seconds(),
+					Transition: marker.Transition.Seconds(),
+				})
+			}
+			result[i].Items = append(result[i].Items, jsonItem)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return errors.Wrap(err, "Error while writing playlists")
+	}
+	return nil
+}
+
+// PlaylistM3U writes the given playlist as extended M3U to w. Media added
+// by the user is referenced by its filename within the backup, so the
+// playlist can be played after extracting the backup. Media of publications
+// is referenced by a jwlm:publication URI describing the publication. Trimmed
+// items are marked using VLC options.
+func PlaylistM3U(playlist model.Playlist, w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("#EXTM3U\n")
+	sb.WriteString("#PLAYLIST:" + m3uText(playlist.Name) + "\n")
+	for _, item := range playlist.Items {
+		duration := -1
+		if item.Duration > 0 {
+			duration = int((item.Duration + time.Second/2) / time.Second)
+		}
+		fmt.Fprintf(&sb, "#EXTINF:%d,%s\n", duration, m3uText(item.Label))
+		if item.StartTime > 0 {
+			fmt.Fprintf(&sb, "#EXTVLCOPT:start-time=%s\n", strconv.FormatFloat(item.StartTime.Seconds(), 'f', -1, 64))
+		}
+		if item.EndTime > 0 {
+			fmt.Fprintf(&sb, "#EXTVLCOPT:stop-time=%s\n", strconv.FormatFloat(item.EndTime.Seconds(), 'f', -1, 64))
+		}
+		sb.WriteString(mediaURI(item) + "\n")
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return errors.Wrap(err, "Error while writing playlist")
+	}
+	return nil
+}
+
+// PlaylistsM3U writes every playlist as an extended M3U file (see
+// PlaylistM3U) named after the playlist into the directory dir,
+// which is created if it doesn't exist.
+func PlaylistsM3U(playlists []model.Playlist, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "Error while creating %s", dir)
+	}
+
+	names := map[string]bool{}
+	for _, playlist := range playlists {
+		base := sanitizeFilename(playlist.Name)
+		name := base
+		for i := 2; names[strings.ToLower(name)]; i++ {
+			name = base + " " + strconv.Itoa(i)
+		}
+		names[strings.ToLower(name)] = true
+
+		path := filepath.Join(dir, name+".m3u8")
+		f, err := os.Create(path)
+		if err != nil {
+			return errors.Wrapf(err, "Error while writing %s", path)
+		}
+		if err := PlaylistM3U(playlist, f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return errors.Wrapf(err, "Error while writing %s", path)
+		}
+	}
+	return nil
+}
+
+// mediaURI returns the path or URI of the media of the item
+func mediaURI(item model.PlaylistItem) string {
+	if item.Filename != "" || item.Location == nil {
+		return item.Filename
+	}
+
+	loc := item.Location
+	query := url.Values{}
+	query.Set("keySymbol", loc.KeySymbol.String)
+	query.Set("mepsLanguage", strconv.Itoa(loc.MepsLanguage))
+	if loc.IssueTagNumber != 0 {
+		query.Set("issueTagNumber", strconv.Itoa(loc.IssueTagNumber))
+	}
+	if loc.DocumentID.Valid {
+		query.Set("documentId", strconv.Itoa(int(loc.DocumentID.Int32)))
+	}
+	if loc.Track.Valid {
+		query.Set("track", strconv.Itoa(int(loc.Track.Int32)))
+	}
+	if loc.BookNumber.Valid {
+		query.Set("bookNumber", strconv.Itoa(int(loc.BookNumber.Int32)))
+		query.Set("chapterNumber", strconv.Itoa(int(loc.ChapterNumber.Int32)))
+	}
+	return "jwlm:publication?" + query.Encode()
+}
+
+// m3uText removes line breaks, which would end an M3U directive
+func m3uText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,111 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPlaylists = []model.Playlist{
+	{
+		Name: "Meeting",
+		Items: []model.PlaylistItem{
+			{
+				Label:     "Video",
+				MediaType: 3,
+				Location: &model.Location{
+					DocumentID:   sql.NullInt32{Int32: 502013365, Valid: true},
+					Track:        sql.NullInt32{Int32: 1, Valid: true},
+					KeySymbol:    sql.NullString{String: "w", Valid: true},
+					MepsLanguage: 2,
+				},
+				Duration:  time.Minute,
+				StartTime: 1500 * time.Millisecond,
+				EndTime:   5 * time.Second,
+				EndAction: 1,
+				Markers: []model.PlaylistMarker{
+					{Label: "Chapter 1", StartTime: 10 * time.Second},
+				},
+			},
+			{
+				Label:             "Introduction\nslide",
+				MediaType:         1,
+				Filename:          "picture.jpg",
+				ThumbnailFilename: "thumb.jpg",
+			},
+		},
+	},
+	{Name: "Empty"},
+}
+
+func TestPlaylistsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, PlaylistsJSON(testPlaylists, &buf))
+	assert.JSONEq(t, `[
+		{
+			"name": "Meeting",
+			"items": [
+				{
+					"label": "Video",
+					"mediaType": 3,
+					"publication": {"keySymbol": "w", "mepsLanguage": 2, "documentId": 502013365, "track": 1},
+					"durationSeconds": 60,
+					"startSeconds": 1.5,
+					"endSeconds": 5,
+					"endAction": 1,
+					"markers": [{"label": "Chapter 1", "startSeconds": 10}]
+				},
+				{
+					"label": "Introduction\nslide",
+					"mediaType": 1,
+					"filename": "picture.jpg",
+					"endAction": 0,
+					"thumbnailFilename": "thumb.jpg"
+				}
+			]
+		},
+		{"name": "Empty", "items": []}
+	]`, buf.String())
+}
+
+func TestPlaylistM3U(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, PlaylistM3U(testPlaylists[0], &buf))
+	assert.Equal(t, `#EXTM3U
+#PLAYLIST:Meeting
+#EXTINF:60,Video
+#EXTVLCOPT:start-time=1.5
+#EXTVLCOPT:stop-time=5
+jwlm:publication?documentId=502013365&keySymbol=w&mepsLanguage=2&track=1
+#EXTINF:-1,Introduction slide
+picture.jpg
+`, buf.String())
+}
+
+func TestPlaylistsM3U(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "playlists")
+	assert.NoError(t, PlaylistsM3U(append(testPlaylists, model.Playlist{Name: "meeting"}), dir))
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.Equal(t, []string{"Empty.m3u8", "Meeting.m3u8", "meeting 2.m3u8"}, names)
+
+	empty, err := ioutil.ReadFile(filepath.Join(dir, "Empty.m3u8"))
+	assert.NoError(t, err)
+	assert.Equal(t, "#EXTM3U\n#PLAYLIST:Empty\n", string(empty))
+}
@@ -0,0 +1,179 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// maxReportChapters is the number of chapters listed as most highlighted
+const maxReportChapters = 10
+
+// Report summarizes the highlights and notes of a Database
+type Report struct {
+	Highlights   int                 `json:"highlights"`
+	Notes        int                 `json:"notes"`
+	Colors       []ColorCount        `json:"colors"`
+	Publications []PublicationReport `json:"publications"`
+	TopChapters  []ChapterCount      `json:"topChapters"`
+}
+
+// PublicationReport summarizes the highlights and notes of a publication
+type PublicationReport struct {
+	Title      string       `json:"title"`
+	Highlights int          `json:"highlights"`
+	Notes      int          `json:"notes"`
+	Colors     []ColorCount `json:"colors"`
+}
+
+// ColorCount is the number of highlights with a given color
+type ColorCount struct {
+	Color string `json:"color"`
+	Count int    `json:"count"`
+}
+
+// ChapterCount is the number of highlights within a Bible chapter
+type ChapterCount struct {
+	Chapter    string `json:"chapter"`
+	Highlights int    `json:"highlights"`
+}
+
+// HighlightReport summarizes the highlights of the given Database per
+// publication and per color, and lists the most highlighted Bible chapters.
+// Publications are sorted by their number of highlights.
+func HighlightReport(db *model.Database) *Report {
+	report := &Report{
+		Colors:       []ColorCount{},
+		Publications: []PublicationReport{},
+		TopChapters:  []ChapterCount{},
+	}
+
+	colors := map[int]int{}
+	chapters := map[position]*ChapterCount{}
+	for _, publ := range Collect(db) {
+		publColors := map[int]int{}
+		for _, hl := range publ.Highlights {
+			colors[hl.ColorIndex]++
+			publColors[hl.ColorIndex]++
+			if hl.BibleBook == "" {
+				continue
+			}
+			chapter := position{hl.position[0], hl.position[1]}
+			if _, ok := chapters[chapter]; !ok {
+				chapters[chapter] = &ChapterCount{Chapter: fmt.Sprintf("%s %d", hl.BibleBook, chapter[1])}
+			}
+			chapters[chapter].Highlights++
+		}
+
+		report.Highlights += len(publ.Highlights)
+		report.Notes += len(publ.Notes)
+		report.Publications = append(report.Publications, PublicationReport{
+			Title:      publ.Title,
+			Highlights: len(publ.Highlights),
+			Notes:      len(publ.Notes),
+			Colors:     colorCounts(publColors),
+		})
+	}
+	report.Colors = colorCounts(colors)
+
+	sort.SliceStable(report.Publications, func(i, j int) bool {
+		return report.Publications[i].Highlights > report.Publications[j].Highlights
+	})
+
+	keys := make([]position, 0, len(chapters))
+	for key := range chapters {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if chapters[keys[i]].Highlights != chapters[keys[j]].Highlights {
+			return chapters[keys[i]].Highlights > chapters[keys[j]].Highlights
+		}
+		return keys[i].less(keys[j])
+	})
+	for i, key := range keys {
+		if i == maxReportChapters {
+			break
+		}
+		report.TopChapters = append(report.TopChapters, *chapters[key])
+	}
+
+	return report
+}
+
+// colorCounts converts the given counts (indexed by ColorIndex)
+// into a slice sorted by ColorIndex.
+func colorCounts(counts map[int]int) []ColorCount {
+	indexes := make([]int, 0, len(counts))
+	for index := range counts {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	result := make([]ColorCount, 0, len(indexes))
+	for _, index := range indexes {
+		result = append(result, ColorCount{Color: ColorName(index), Count: counts[index]})
+	}
+	return result
+}
+
+// JSON writes the report as indented JSON to w
+func (r *Report) JSON(w io.Writer) error {
+	jsn, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Error while marshalling report")
+	}
+	if _, err := fmt.Fprintln(w, string(jsn)); err != nil {
+		return errors.Wrap(err, "Error while writing report")
+	}
+	return nil
+}
+
+// Markdown writes the report as Markdown to w
+func (r *Report) Markdown(w io.Writer) error {
+	var md strings.Builder
+	md.WriteString("# Highlight report\n\n")
+	fmt.Fprintf(&md, "%d highlights and %d notes in %d publications.\n", r.Highlights, r.Notes, len(r.Publications))
+
+	if len(r.Colors) > 0 {
+		md.WriteString("\n## Colors\n\n")
+		md.WriteString("| Color | Highlights |\n|---|---:|\n")
+		for _, c := range r.Colors {
+			fmt.Fprintf(&md, "| %s | %d |\n", c.Color, c.Count)
+		}
+	}
+
+	if len(r.Publications) > 0 {
+		md.WriteString("\n## Publications\n\n")
+		md.WriteString("| Publication | Highlights | Notes | Colors |\n|---|---:|---:|---|\n")
+		for _, publ := range r.Publications {
+			colors := make([]string, 0, len(publ.Colors))
+			for _, c := range publ.Colors {
+				colors = append(colors, fmt.Sprintf("%s: %d", c.Color, c.Count))
+			}
+			fmt.Fprintf(&md, "| %s | %d | %d | %s |\n",
+				markdownCell(publ.Title), publ.Highlights, publ.Notes, strings.Join(colors, ", "))
+		}
+	}
+
+	if len(r.TopChapters) > 0 {
+		md.WriteString("\n## Most highlighted chapters\n\n")
+		for i, chapter := range r.TopChapters {
+			fmt.Fprintf(&md, "%d. %s (%d)\n", i+1, chapter.Chapter, chapter.Highlights)
+		}
+	}
+
+	if _, err := io.WriteString(w, md.String()); err != nil {
+		return errors.Wrap(err, "Error while writing report")
+	}
+	return nil
+}
+
+// markdownCell escapes characters that would break a Markdown table
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
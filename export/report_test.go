@@ -0,0 +1,106 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightReport(t *testing.T) {
+	db := model.MakeDatabaseCopy(studyDB)
+	db.UserMark = append(db.UserMark,
+		&model.UserMark{UserMarkID: 3, ColorIndex: 1, LocationID: 2},
+		&model.UserMark{UserMarkID: 4, ColorIndex: 2, LocationID: 2})
+
+	report := HighlightReport(db)
+	assert.Equal(t, &Report{
+		Highlights: 4,
+		Notes:      3,
+		Colors: []ColorCount{
+			{Color: "yellow", Count: 1},
+			{Color: "green", Count: 2},
+			{Color: "pink", Count: 1},
+		},
+		Publications: []PublicationReport{
+			{
+				Title:      "New World Translation of the Holy Scriptures (Study Edition)",
+				Highlights: 3,
+				Notes:      2,
+				Colors: []ColorCount{
+					{Color: "yellow", Count: 1},
+					{Color: "green", Count: 2},
+				},
+			},
+			{
+				Title:      "The Watchtower, January 2020",
+				Highlights: 1,
+				Colors:     []ColorCount{{Color: "pink", Count: 1}},
+			},
+			{
+				Title:  "Other notes",
+				Notes:  1,
+				Colors: []ColorCount{},
+			},
+		},
+		TopChapters: []ChapterCount{
+			{Chapter: "Genesis 2", Highlights: 2},
+			{Chapter: "Genesis 10", Highlights: 1},
+		},
+	}, report)
+
+	empty := HighlightReport(&model.Database{})
+	assert.Equal(t, &Report{
+		Colors:       []ColorCount{},
+		Publications: []PublicationReport{},
+		TopChapters:  []ChapterCount{},
+	}, empty)
+}
+
+func TestReport_Markdown(t *testing.T) {
+	var buf bytes.Buffer
+	report := HighlightReport(studyDB)
+	report.Publications[0].Title = "A | B"
+	assert.NoError(t, report.Markdown(&buf))
+	assert.Equal(t, `# Highlight report
+
+2 highlights and 3 notes in 3 publications.
+
+## Colors
+
+| Color | Highlights |
+|---|---:|
+| green | 1 |
+| pink | 1 |
+
+## Publications
+
+| Publication | Highlights | Notes | Colors |
+|---|---:|---:|---|
+| A \| B | 1 | 2 | green: 1 |
+| The Watchtower, January 2020 | 1 | 0 | pink: 1 |
+| Other notes | 0 | 1 |  |
+
+## Most highlighted chapters
+
+1. Genesis 10 (1)
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, HighlightReport(&model.Database{}).Markdown(&buf))
+	assert.Equal(t, "# Highlight report\n\n0 highlights and 0 notes in 0 publications.\n", buf.String())
+}
+
+func TestReport_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, HighlightReport(&model.Database{}).JSON(&buf))
+	assert.Equal(t, `{
+  "highlights": 0,
+  "notes": 0,
+  "colors": [],
+  "publications": [],
+  "topChapters": []
+}
+`, buf.String())
+}
@@ -0,0 +1,362 @@
+// Package export converts the notes and highlights of a JW Library backup
+// into formats that can be read outside of the app.
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/AndreasSko/go-jwlm/publication"
+)
+
+// Publication groups the notes and highlights that belong to the same
+// publication (or issue of a periodical).
+type Publication struct {
+	Title      string
+	KeySymbol  string
+	Notes      []Note
+	Highlights []Highlight
+}
+
+// Note is a human-readable representation of a model.Note
+type Note struct {
+	GUID         string
+	Title        string
+	Content      string
+	Location     string
+	BibleBook    string
+	LastModified string
+	Tags         []string
+	ColorIndex   int
+	position     position
+}
+
+// Highlight is a human-readable representation of a model.UserMark
+// together with the blocks it covers.
+type Highlight struct {
+	GUID       string
+	Location   string
+	BibleBook  string
+	ColorIndex int
+	position   position
+}
+
+// position is used to sort notes and highlights in the order
+// they appear within a publication.
+type position [3]int
+
+func (p position) less(o position) bool {
+	for i := range p {
+		if p[i] != o[i] {
+			return p[i] < o[i]
+		}
+	}
+	return false
+}
+
+// highlightColors contains the names and RGB values of the
+// colors JW Library uses for highlights, indexed by ColorIndex.
+var highlightColors = []struct {
+	Name string
+	RGB  string
+}{
+	{"grey", "#d6d6d6"},
+	{"yellow", "#fff176"},
+	{"green", "#aed581"},
+	{"blue", "#81d4fa"},
+	{"pink", "#f48fb1"},
+	{"orange", "#ffb74d"},
+	{"purple", "#ce93d8"},
+}
+
+// ColorName returns the name of the highlight color with the given
+// ColorIndex. Unknown indexes fall back to grey.
+func ColorName(colorIndex int) string {
+	if colorIndex < 0 || colorIndex >= len(highlightColors) {
+		return highlightColors[0].Name
+	}
+	return highlightColors[colorIndex].Name
+}
+
+// ColorRGB returns the RGB value (in hex notation) of the highlight color
+// with the given ColorIndex. Unknown indexes fall back to grey.
+func ColorRGB(colorIndex int) string {
+	if colorIndex < 0 || colorIndex >= len(highlightColors) {
+		return highlightColors[0].RGB
+	}
+	return highlightColors[colorIndex].RGB
+}
+
+// Collect groups the notes and highlights of the given Database by
+// publication. Publications are sorted by their title, while notes and
+// highlights keep the order in which they appear within the publication.
+func Collect(db *model.Database) []*Publication {
+	publications := map[string]*Publication{}
+	getPublication := func(location *model.Location) *Publication {
+		key := publicationKey(location)
+		if publ, ok := publications[key]; ok {
+			return publ
+		}
+		publ := &Publication{Title: publicationName(location)}
+		if location != nil {
+			publ.KeySymbol = location.KeySymbol.String
+		}
+		publications[key] = publ
+		return publ
+	}
+
+	tags := noteTags(db)
+	for _, note := range db.Note {
+		if note == nil {
+			continue
+		}
+		var location *model.Location
+		colorIndex := 0
+		if note.UserMarkID.Valid {
+			if um, ok := db.FetchFromTable("UserMark", int(note.UserMarkID.Int32)).(*model.UserMark); ok {
+				colorIndex = um.ColorIndex
+				location, _ = db.FetchFromTable("Location", um.LocationID).(*model.Location)
+			}
+		}
+		if note.LocationID.Valid {
+			location, _ = db.FetchFromTable("Location", int(note.LocationID.Int32)).(*model.Location)
+		}
+
+		block := 0
+		if note.BlockIdentifier.Valid {
+			block = int(note.BlockIdentifier.Int32)
+		}
+		publ := getPublication(location)
+		publ.Notes = append(publ.Notes, Note{
+			GUID:         note.GUID,
+			Title:        note.Title.String,
+			Content:      note.Content.String,
+			Location:     locationName(location, note.BlockType, block),
+			BibleBook:    bibleBookName(location),
+			LastModified: note.LastModified,
+			Tags:         tags[note.NoteID],
+			ColorIndex:   colorIndex,
+			position:     locationPosition(location, block),
+		})
+	}
+
+	blockRanges := map[int][]*model.BlockRange{}
+	for _, br := range db.BlockRange {
+		if br == nil {
+			continue
+		}
+		blockRanges[br.UserMarkID] = append(blockRanges[br.UserMarkID], br)
+	}
+	for _, um := range db.UserMark {
+		if um == nil {
+			continue
+		}
+		location, _ := db.FetchFromTable("Location", um.LocationID).(*model.Location)
+		publ := getPublication(location)
+
+		brs := blockRanges[um.UserMarkID]
+		sort.SliceStable(brs, func(i, j int) bool {
+			return brs[i].Identifier < brs[j].Identifier
+		})
+		block, blockType := 0, 0
+		if len(brs) > 0 {
+			block, blockType = brs[0].Identifier, brs[0].BlockType
+		}
+		name := locationName(location, blockType, block)
+		if len(brs) > 1 && brs[len(brs)-1].Identifier != block {
+			name += "-" + strconv.Itoa(brs[len(brs)-1].Identifier)
+		}
+
+		publ.Highlights = append(publ.Highlights, Highlight{
+			GUID:       um.UserMarkGUID,
+			Location:   name,
+			BibleBook:  bibleBookName(location),
+			ColorIndex: um.ColorIndex,
+			position:   locationPosition(location, block),
+		})
+	}
+
+	result := make([]*Publication, 0, len(publications))
+	for _, publ := range publications {
+		sort.SliceStable(publ.Notes, func(i, j int) bool {
+			if publ.Notes[i].position != publ.Notes[j].position {
+				return publ.Notes[i].position.less(publ.Notes[j].position)
+			}
+			return publ.Notes[i].Title < publ.Notes[j].Title
+		})
+		sort.SliceStable(publ.Highlights, func(i, j int) bool {
+			return publ.Highlights[i].position.less(publ.Highlights[j].position)
+		})
+		result = append(result, publ)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Title != result[j].Title {
+			return result[i].Title < result[j].Title
+		}
+		return result[i].KeySymbol < result[j].KeySymbol
+	})
+
+	return result
+}
+
+// Tags returns the sorted names of all tags used by the given publications
+func Tags(publications []*Publication) []string {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, publ := range publications {
+		for _, note := range publ.Notes {
+			for _, tag := range note.Tags {
+				if !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// noteTags returns the sorted tag names of every note, indexed by NoteID
+func noteTags(db *model.Database) map[int][]string {
+	tags := map[int][]string{}
+	for _, tm := range db.TagMap {
+		if tm == nil || !tm.NoteID.Valid {
+			continue
+		}
+		tag, ok := db.FetchFromTable("Tag", tm.TagID).(*model.Tag)
+		if !ok {
+			continue
+		}
+		noteID := int(tm.NoteID.Int32)
+		tags[noteID] = append(tags[noteID], tag.Name)
+	}
+	for _, t := range tags {
+		sort.Strings(t)
+	}
+	return tags
+}
+
+// publicationKey returns a key that is the same for all
+// locations belonging to the same publication.
+func publicationKey(location *model.Location) string {
+	if location == nil {
+		return ""
+	}
+	if !location.KeySymbol.Valid {
+		return fmt.Sprintf("doc_%d_%d", location.DocumentID.Int32, location.MepsLanguage)
+	}
+	return fmt.Sprintf("%s_%d_%d", location.KeySymbol.String, location.IssueTagNumber, location.MepsLanguage)
+}
+
+// publicationName returns a human-readable name of the
+// publication the given location belongs to.
+func publicationName(location *model.Location) string {
+	if location == nil {
+		return "Other notes"
+	}
+
+	name, ok := publication.OfflinePublicationTitle(location.KeySymbol.String)
+	if !ok {
+		switch {
+		case location.KeySymbol.Valid:
+			name = location.KeySymbol.String
+		case location.Title.Valid:
+			name = location.Title.String
+		default:
+			name = fmt.Sprintf("Document %d", location.DocumentID.Int32)
+		}
+	}
+	if issue := issueName(location.IssueTagNumber); issue != "" {
+		name += ", " + issue
+	}
+	return name
+}
+
+// issueName converts an IssueTagNumber (like 20200100) into
+// a human-readable issue (like January 2020).
+func issueName(issueTagNumber int) string {
+	if issueTagNumber == 0 {
+		return ""
+	}
+	year, month := issueTagNumber/10000, issueTagNumber/100%100
+	if month < 1 || month > 12 {
+		return strconv.Itoa(issueTagNumber)
+	}
+	return fmt.Sprintf("%s %d", time.Month(month), year)
+}
+
+// DescribeLocation returns a human-readable description of the given
+// location together with its publication, like "Genesis 1:3 (New World
+// Translation of the Holy Scriptures (Study Edition))". Blocks are only
+// included for the given blockType (1 = paragraph, 2 = verse).
+func DescribeLocation(location *model.Location, blockType int, block int) string {
+	if location == nil {
+		return ""
+	}
+	name := locationName(location, blockType, block)
+	publ := publicationName(location)
+	if name == "" || name == publ {
+		return publ
+	}
+	return name + " (" + publ + ")"
+}
+
+// locationName returns a human-readable name of the given location,
+// like "Genesis 1:3" for Bible verses or "Title ¶3" for paragraphs.
+func locationName(location *model.Location, blockType int, block int) string {
+	if location == nil {
+		return ""
+	}
+
+	name := ""
+	if location.BookNumber.Valid {
+		name = bibleBookName(location)
+		if location.ChapterNumber.Valid {
+			name += " " + strconv.Itoa(int(location.ChapterNumber.Int32))
+			if blockType == 2 && block > 0 {
+				name += ":" + strconv.Itoa(block)
+			}
+		}
+		return name
+	}
+
+	switch {
+	case location.Title.Valid && location.Title.String != "":
+		name = location.Title.String
+	case location.DocumentID.Valid:
+		name = fmt.Sprintf("Document %d", location.DocumentID.Int32)
+	}
+	if blockType == 1 && block > 0 {
+		name += " ¶" + strconv.Itoa(block)
+	}
+	return name
+}
+
+// bibleBookName returns the name of the Bible book the given location
+// belongs to. If it is not a location within the Bible, it returns an
+// empty string.
+func bibleBookName(location *model.Location) string {
+	if location == nil || !location.BookNumber.Valid {
+		return ""
+	}
+	book, ok := publication.BibleBookName(int(location.BookNumber.Int32))
+	if !ok {
+		book = fmt.Sprintf("Book %d", location.BookNumber.Int32)
+	}
+	return book
+}
+
+// locationPosition returns the position of a block within its publication
+func locationPosition(location *model.Location, block int) position {
+	if location == nil {
+		return position{}
+	}
+	if location.BookNumber.Valid {
+		return position{int(location.BookNumber.Int32), int(location.ChapterNumber.Int32), block}
+	}
+	return position{0, int(location.DocumentID.Int32), block}
+}
@@ -0,0 +1,229 @@
+package export
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var studyDB = &model.Database{
+	BlockRange: []*model.BlockRange{
+		nil,
+		{
+			BlockRangeID: 1,
+			BlockType:    2,
+			Identifier:   3,
+			UserMarkID:   1,
+		},
+		{
+			BlockRangeID: 2,
+			BlockType:    2,
+			Identifier:   4,
+			UserMarkID:   1,
+		},
+		{
+			BlockRangeID: 3,
+			BlockType:    1,
+			Identifier:   7,
+			UserMarkID:   2,
+		},
+	},
+	Location: []*model.Location{
+		nil,
+		{
+			LocationID:    1,
+			BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: 10, Valid: true},
+			KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			MepsLanguage:  2,
+		},
+		{
+			LocationID:    2,
+			BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: 2, Valid: true},
+			KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			MepsLanguage:  2,
+		},
+		{
+			LocationID:     3,
+			DocumentID:     sql.NullInt32{Int32: 2020123, Valid: true},
+			IssueTagNumber: 20200100,
+			KeySymbol:      sql.NullString{String: "w", Valid: true},
+			MepsLanguage:   2,
+			Title:          sql.NullString{String: "Study Article 1", Valid: true},
+		},
+	},
+	Note: []*model.Note{
+		nil,
+		{
+			NoteID:          1,
+			GUID:            "1",
+			UserMarkID:      sql.NullInt32{Int32: 1, Valid: true},
+			LocationID:      sql.NullInt32{Int32: 1, Valid: true},
+			Title:           sql.NullString{String: "Later note", Valid: true},
+			Content:         sql.NullString{String: "Content <b>1</b>", Valid: true},
+			LastModified:    "2020-04-14T18:42:58+00:00",
+			BlockType:       2,
+			BlockIdentifier: sql.NullInt32{Int32: 3, Valid: true},
+		},
+		{
+			NoteID:          2,
+			GUID:            "2",
+			LocationID:      sql.NullInt32{Int32: 2, Valid: true},
+			Title:           sql.NullString{String: "Earlier note", Valid: true},
+			LastModified:    "2020-04-14T18:42:58+00:00",
+			BlockType:       2,
+			BlockIdentifier: sql.NullInt32{Int32: 1, Valid: true},
+		},
+		{
+			NoteID:       3,
+			GUID:         "3",
+			Title:        sql.NullString{String: "Loose note", Valid: true},
+			LastModified: "2020-04-14T18:42:58+00:00",
+		},
+	},
+	Tag: []*model.Tag{
+		nil,
+		{
+			TagID: 1,
+			Name:  "Faith",
+		},
+		{
+			TagID: 2,
+			Name:  "Creation",
+		},
+	},
+	TagMap: []*model.TagMap{
+		nil,
+		{
+			TagMapID: 1,
+			NoteID:   sql.NullInt32{Int32: 1, Valid: true},
+			TagID:    1,
+		},
+		{
+			TagMapID: 2,
+			NoteID:   sql.NullInt32{Int32: 1, Valid: true},
+			TagID:    2,
+			Position: 1,
+		},
+		{
+			TagMapID: 3,
+			NoteID:   sql.NullInt32{Int32: 3, Valid: true},
+			TagID:    1,
+		},
+	},
+	UserMark: []*model.UserMark{
+		nil,
+		{
+			UserMarkID: 1,
+			ColorIndex: 2,
+			LocationID: 1,
+		},
+		{
+			UserMarkID: 2,
+			ColorIndex: 4,
+			LocationID: 3,
+		},
+	},
+}
+
+func TestCollect(t *testing.T) {
+	publications := Collect(studyDB)
+
+	expected := []*Publication{
+		{
+			Title:     "New World Translation of the Holy Scriptures (Study Edition)",
+			KeySymbol: "nwtsty",
+			Notes: []Note{
+				{
+					GUID:         "2",
+					Title:        "Earlier note",
+					Location:     "Genesis 2:1",
+					BibleBook:    "Genesis",
+					LastModified: "2020-04-14T18:42:58+00:00",
+					position:     position{1, 2, 1},
+				},
+				{
+					GUID:         "1",
+					Title:        "Later note",
+					Content:      "Content <b>1</b>",
+					Location:     "Genesis 10:3",
+					BibleBook:    "Genesis",
+					LastModified: "2020-04-14T18:42:58+00:00",
+					Tags:         []string{"Creation", "Faith"},
+					ColorIndex:   2,
+					position:     position{1, 10, 3},
+				},
+			},
+			Highlights: []Highlight{
+				{
+					Location:   "Genesis 10:3-4",
+					BibleBook:  "Genesis",
+					ColorIndex: 2,
+					position:   position{1, 10, 3},
+				},
+			},
+		},
+		{
+			Title: "Other notes",
+			Notes: []Note{
+				{
+					GUID:         "3",
+					Title:        "Loose note",
+					LastModified: "2020-04-14T18:42:58+00:00",
+					Tags:         []string{"Faith"},
+				},
+			},
+		},
+		{
+			Title:     "The Watchtower, January 2020",
+			KeySymbol: "w",
+			Highlights: []Highlight{
+				{
+					Location:   "Study Article 1 ¶7",
+					ColorIndex: 4,
+					position:   position{0, 2020123, 7},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, publications)
+	assert.Equal(t, []string{"Creation", "Faith"}, Tags(publications))
+
+	assert.Empty(t, Collect(&model.Database{}))
+}
+
+func TestColorName(t *testing.T) {
+	assert.Equal(t, "yellow", ColorName(1))
+	assert.Equal(t, "purple", ColorName(6))
+	assert.Equal(t, "grey", ColorName(0))
+	assert.Equal(t, "grey", ColorName(-1))
+	assert.Equal(t, "grey", ColorName(100))
+	assert.Equal(t, "#fff176", ColorRGB(1))
+	assert.Equal(t, "#d6d6d6", ColorRGB(100))
+}
+
+func Test_issueName(t *testing.T) {
+	assert.Equal(t, "", issueName(0))
+	assert.Equal(t, "January 2020", issueName(20200100))
+	assert.Equal(t, "December 2019", issueName(20191215))
+	assert.Equal(t, "12345", issueName(12345))
+}
+
+func Test_locationName(t *testing.T) {
+	assert.Equal(t, "", locationName(nil, 0, 0))
+	assert.Equal(t, "Genesis 10", locationName(studyDB.Location[1], 0, 0))
+	assert.Equal(t, "Genesis 10:5", locationName(studyDB.Location[1], 2, 5))
+	assert.Equal(t, "Study Article 1", locationName(studyDB.Location[3], 0, 0))
+	assert.Equal(t, "Study Article 1 ¶2", locationName(studyDB.Location[3], 1, 2))
+	assert.Equal(t, "Document 5", locationName(&model.Location{DocumentID: sql.NullInt32{Int32: 5, Valid: true}}, 0, 0))
+}
+
+func TestDescribeLocation(t *testing.T) {
+	assert.Equal(t, "", DescribeLocation(nil, 0, 0))
+	assert.Equal(t, "Genesis 10:5 ("+publicationName(studyDB.Location[1])+")", DescribeLocation(studyDB.Location[1], 2, 5))
+	assert.Equal(t, "Study Article 1 ¶2 ("+publicationName(studyDB.Location[3])+")", DescribeLocation(studyDB.Location[3], 1, 2))
+	assert.Equal(t, "Document 5", DescribeLocation(&model.Location{DocumentID: sql.NullInt32{Int32: 5, Valid: true}}, 0, 0))
+}
@@ -0,0 +1,197 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// Timeline contains the notes and highlights of a backup bucketed by
+// week or month, so the activity of the personal study can be charted.
+type Timeline struct {
+	Interval string           `json:"interval"`
+	Buckets  []TimelineBucket `json:"buckets"`
+	// UndatedNotes and UndatedHighlights count the entries that could
+	// not be assigned to a bucket, as they don't have a date
+	UndatedNotes      int `json:"undatedNotes"`
+	UndatedHighlights int `json:"undatedHighlights"`
+}
+
+// TimelineBucket contains the number of notes and highlights within
+// a week or month, together with a breakdown per publication.
+type TimelineBucket struct {
+	Period       string                `json:"period"`
+	Start        string                `json:"start"`
+	Notes        int                   `json:"notes"`
+	Highlights   int                   `json:"highlights"`
+	Publications []TimelinePublication `json:"publications"`
+}
+
+// TimelinePublication contains the number of notes and highlights of
+// a publication within a TimelineBucket.
+type TimelinePublication struct {
+	Title      string `json:"title"`
+	Notes      int    `json:"notes"`
+	Highlights int    `json:"highlights"`
+}
+
+// NoteTimeline buckets the notes and highlights of the given Database by
+// the week (ISO 8601) or month they have been last modified in, depending
+// on interval. As JW Library doesn't store when a highlight has been made,
+// highlights are dated by the note attached to them and counted as undated
+// otherwise. Buckets are sorted chronologically and include all weeks or
+// months between the first and the last one, even if they are empty.
+func NoteTimeline(db *model.Database, interval string) (*Timeline, error) {
+	if interval != "week" && interval != "month" {
+		return nil, errors.Errorf("Timeline interval %s is not supported", interval)
+	}
+
+	// Highlights are dated by their notes
+	highlightDates := map[string]string{}
+	for _, note := range db.Note {
+		if note == nil || !note.UserMarkID.Valid {
+			continue
+		}
+		if um, ok := db.FetchFromTable("UserMark", int(note.UserMarkID.Int32)).(*model.UserMark); ok && um.UserMarkGUID != "" {
+			if note.LastModified > highlightDates[um.UserMarkGUID] {
+				highlightDates[um.UserMarkGUID] = note.LastModified
+			}
+		}
+	}
+
+	tl := &Timeline{Interval: interval, Buckets: []TimelineBucket{}}
+	buckets := map[time.Time]map[string]*TimelinePublication{}
+	bucket := func(date string, publication string) *TimelinePublication {
+		t, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			return nil
+		}
+		start := periodStart(t, interval)
+		if _, ok := buckets[start]; !ok {
+			buckets[start] = map[string]*TimelinePublication{}
+		}
+		if _, ok := buckets[start][publication]; !ok {
+			buckets[start][publication] = &TimelinePublication{Title: publication}
+		}
+		return buckets[start][publication]
+	}
+
+	for _, publ := range Collect(db) {
+		for _, note := range publ.Notes {
+			if b := bucket(note.LastModified, publ.Title); b != nil {
+				b.Notes++
+			} else {
+				tl.UndatedNotes++
+			}
+		}
+		for _, hl := range publ.Highlights {
+			if b := bucket(highlightDates[hl.GUID], publ.Title); b != nil {
+				b.Highlights++
+			} else {
+				tl.UndatedHighlights++
+			}
+		}
+	}
+	if len(buckets) == 0 {
+		return tl, nil
+	}
+
+	var first, last time.Time
+	for start := range buckets {
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+	for start := first; !start.After(last); start = nextPeriod(start, interval) {
+		b := TimelineBucket{
+			Period:       periodName(start, interval),
+			Start:        start.Format("2006-01-02"),
+			Publications: []TimelinePublication{},
+		}
+		for _, publ := range buckets[start] {
+			b.Notes += publ.Notes
+			b.Highlights += publ.Highlights
+			b.Publications = append(b.Publications, *publ)
+		}
+		sort.Slice(b.Publications, func(i, j int) bool {
+			return b.Publications[i].Title < b.Publications[j].Title
+		})
+		tl.Buckets = append(tl.Buckets, b)
+	}
+
+	return tl, nil
+}
+
+// JSON writes the Timeline as JSON to w
+func (tl *Timeline) JSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(tl); err != nil {
+		return errors.Wrap(err, "Error while writing timeline")
+	}
+	return nil
+}
+
+// CSV writes the Timeline as CSV to w, with one row per bucket and
+// publication. Empty buckets are written with an empty publication,
+// so they still show up in charts.
+func (tl *Timeline) CSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{{"period", "start", "publication", "notes", "highlights"}}
+	for _, b := range tl.Buckets {
+		if len(b.Publications) == 0 {
+			records = append(records, []string{b.Period, b.Start, "", "0", "0"})
+		}
+		for _, publ := range b.Publications {
+			records = append(records, []string{
+				b.Period,
+				b.Start,
+				publ.Title,
+				strconv.Itoa(publ.Notes),
+				strconv.Itoa(publ.Highlights),
+			})
+		}
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return errors.Wrap(err, "Error while writing timeline")
+	}
+	return nil
+}
+
+// periodStart returns the first day of the week (Monday) or
+// month the given time is in
+func periodStart(t time.Time, interval string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if interval == "month" {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// nextPeriod returns the start of the week or month following start
+func nextPeriod(start time.Time, interval string) time.Time {
+	if interval == "month" {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 7)
+}
+
+// periodName returns the name of the week (like 2020-W16)
+// or month (like 2020-04) starting at start
+func periodName(start time.Time, interval string) string {
+	if interval == "month" {
+		return start.Format("2006-01")
+	}
+	year, week := start.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
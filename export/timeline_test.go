@@ -0,0 +1,87 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoteTimeline(t *testing.T) {
+	db := model.MakeDatabaseCopy(studyDB)
+	db.UserMark[1].UserMarkGUID = "HL1"
+	db.UserMark[2].UserMarkGUID = "HL2"
+	db.Note[2].LastModified = "2020-05-02T10:00:00+00:00"
+
+	nwt := "New World Translation of the Holy Scriptures (Study Edition)"
+	tl, err := NoteTimeline(db, "week")
+	assert.NoError(t, err)
+	assert.Equal(t, &Timeline{
+		Interval: "week",
+		Buckets: []TimelineBucket{
+			{
+				Period: "2020-W16", Start: "2020-04-13", Notes: 2, Highlights: 1,
+				Publications: []TimelinePublication{
+					{Title: nwt, Notes: 1, Highlights: 1},
+					{Title: "Other notes", Notes: 1},
+				},
+			},
+			{Period: "2020-W17", Start: "2020-04-20", Publications: []TimelinePublication{}},
+			{
+				Period: "2020-W18", Start: "2020-04-27", Notes: 1,
+				Publications: []TimelinePublication{{Title: nwt, Notes: 1}},
+			},
+		},
+		UndatedHighlights: 1,
+	}, tl)
+
+	tl, err = NoteTimeline(db, "month")
+	assert.NoError(t, err)
+	assert.Len(t, tl.Buckets, 2)
+	assert.Equal(t, "2020-04", tl.Buckets[0].Period)
+	assert.Equal(t, "2020-04-01", tl.Buckets[0].Start)
+	assert.Equal(t, 2, tl.Buckets[0].Notes)
+	assert.Equal(t, "2020-05", tl.Buckets[1].Period)
+	assert.Equal(t, 1, tl.Buckets[1].Notes)
+
+	// The week of the new year belongs to the ISO year it starts in
+	db.Note[2].LastModified = "2021-01-01T10:00:00+00:00"
+	db.Note[2].UserMarkID.Valid = false
+	tl, err = NoteTimeline(&model.Database{Note: []*model.Note{nil, db.Note[2]}}, "week")
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-W53", tl.Buckets[0].Period)
+	assert.Equal(t, "2020-12-28", tl.Buckets[0].Start)
+
+	tl, err = NoteTimeline(&model.Database{}, "month")
+	assert.NoError(t, err)
+	assert.Empty(t, tl.Buckets)
+
+	_, err = NoteTimeline(db, "day")
+	assert.EqualError(t, err, "Timeline interval day is not supported")
+}
+
+func TestTimeline_CSV(t *testing.T) {
+	db := model.MakeDatabaseCopy(studyDB)
+	db.Note[2].LastModified = "2020-06-02T10:00:00+00:00"
+	tl, err := NoteTimeline(db, "month")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tl.CSV(&buf))
+	assert.Equal(t, `period,start,publication,notes,highlights
+2020-04,2020-04-01,New World Translation of the Holy Scriptures (Study Edition),1,0
+2020-04,2020-04-01,Other notes,1,0
+2020-05,2020-05-01,,0,0
+2020-06,2020-06-01,New World Translation of the Holy Scriptures (Study Edition),1,0
+`, buf.String())
+}
+
+func TestTimeline_JSON(t *testing.T) {
+	tl, err := NoteTimeline(&model.Database{}, "week")
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tl.JSON(&buf))
+	assert.JSONEq(t, `{"interval": "week", "buckets": [], "undatedNotes": 0, "undatedHighlights": 0}`, buf.String())
+}
@@ -0,0 +1,180 @@
+package gomobile
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// MergeListener is informed about the course of an asynchronous merge
+// started with StartMerge. It can be implemented in Swift or Kotlin.
+// Note that the methods are not called on the UI thread.
+type MergeListener interface {
+	ProgressListener
+	// OnConflict is called when a conflict has been found that needs to be
+	// solved using MergeSession.SolveConflict. The merge waits until
+	// the conflict is solved or the merge is canceled.
+	OnConflict(conflict *MergeConflict)
+	// OnDone is called when the merge has finished. If it failed, err
+	// contains the error message, otherwise it is empty.
+	OnDone(err string)
+}
+
+// MergeSession represents an asynchronous merge started with StartMerge
+type MergeSession struct {
+	dbw       *DatabaseWrapper
+	mcw       *MergeConflictsWrapper
+	listener  MergeListener
+	solutions chan asyncSolution
+	done      chan struct{}
+
+	mu      sync.Mutex
+	pending string
+}
+
+// asyncSolution is a solution for the pending conflict of a MergeSession
+type asyncSolution struct {
+	key  string
+	side string
+}
+
+// StartMerge merges the imported databases in the background and returns
+// immediately. The progress, conflicts and the result are reported to the
+// listener. If conflictSolver is set, conflicts are solved automatically
+// (see merger.AutoResolveConflicts). After OnDone has been called without
+// an error, the merged database can be exported with ExportMerged.
+func (dbw *DatabaseWrapper) StartMerge(conflictSolver string, listener MergeListener) *MergeSession {
+	dbw.Init()
+	dbw.SetProgressListener(listener)
+
+	session := &MergeSession{
+		dbw:       dbw,
+		mcw:       &MergeConflictsWrapper{DBWrapper: dbw},
+		listener:  listener,
+		solutions: make(chan asyncSolution, 1),
+		done:      make(chan struct{}),
+	}
+	go session.run(conflictSolver)
+
+	return session
+}
+
+// SolveConflict solves the conflict that has been reported last with
+// OnConflict and chooses the given side. It may be called from within
+// OnConflict.
+func (ms *MergeSession) SolveConflict(key string, side string) error {
+	if side != "leftSide" && side != "rightSide" {
+		return fmt.Errorf("Side %s is not valid", side)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.pending == "" || ms.pending != key {
+		return errors.Errorf("Conflict with key %s is not pending", key)
+	}
+	ms.pending = ""
+	ms.solutions <- asyncSolution{key: key, side: side}
+
+	return nil
+}
+
+// Cancel aborts the merge. OnDone is called with the
+// error message of ErrMergeCanceled.
+func (ms *MergeSession) Cancel() {
+	ms.dbw.Cancel()
+}
+
+// Done indicates if the merge has finished.
+func (ms *MergeSession) Done() bool {
+	select {
+	case <-ms.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// run executes the merge and informs the listener about the result
+func (ms *MergeSession) run(conflictSolver string) {
+	err := ms.merge(conflictSolver)
+	close(ms.done)
+
+	if err != nil {
+		ms.listener.OnDone(err.Error())
+		return
+	}
+	ms.listener.OnDone("")
+}
+
+// merge executes all stages of the merge (see merger.MergeBackups).
+// Conflicts are solved automatically if conflictSolver is set and
+// reported to the listener otherwise.
+func (ms *MergeSession) merge(conflictSolver string) error {
+	dbw := ms.dbw
+	opts := dbw.mergeOptions()
+	opts.Solve = func(stage string, conflicts map[string]merger.MergeConflict, merged *model.Database) (map[string]merger.MergeSolution, error) {
+		if conflictSolver != "" {
+			solutions, err := merger.AutoResolveConflicts(conflicts, conflictSolver)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Could not automatically solve conflicts for %s", strings.ToLower(stage))
+			}
+			return solutions, nil
+		}
+		// Waiting for the user should not count towards the ETA
+		dbw.eta.Pause()
+		defer dbw.eta.Resume()
+		// Related entries of conflicts are looked up in the merged database
+		dbw.merged = merged
+		ms.mcw.addConflicts(conflicts)
+		if err := ms.solveConflicts(); err != nil {
+			return nil, err
+		}
+		return ms.mcw.solutions, nil
+	}
+	opts.Progress = func(stage string, finished bool) error {
+		if err := dbw.checkCanceled(); err != nil {
+			return err
+		}
+		dbw.reportProgress(stage, finished)
+		return nil
+	}
+	result, err := merger.MergeBackups(dbw.leftTmp, dbw.rightTmp, opts)
+	if err != nil {
+		return err
+	}
+
+	dbw.merged = result.Merged
+	dbw.merged.SetLogger(dbw.logger)
+	return nil
+}
+
+// solveConflicts reports the unsolved conflicts one after another
+// to the listener and waits for their solutions.
+func (ms *MergeSession) solveConflicts() error {
+	for len(ms.mcw.unsolvedConflicts) > 0 {
+		conflict, err := ms.mcw.NextConflict()
+		if err != nil {
+			return err
+		}
+
+		ms.mu.Lock()
+		ms.pending = conflict.Key
+		ms.mu.Unlock()
+		ms.listener.OnConflict(conflict)
+
+		select {
+		case solution := <-ms.solutions:
+			if err := ms.mcw.SolveConflict(solution.key, solution.side); err != nil {
+				return err
+			}
+		case <-ms.dbw.ctx.Done():
+			return ms.dbw.checkCanceled()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+//go:build !windows
+// +build !windows
+
+package gomobile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+type asyncListener struct {
+	recordingListener
+	onConflict func(conflict *MergeConflict)
+	conflicts  int
+	done       chan string
+}
+
+func (l *asyncListener) OnConflict(conflict *MergeConflict) {
+	l.conflicts++
+	l.onConflict(conflict)
+}
+
+func (l *asyncListener) OnDone(err string) {
+	l.done <- err
+}
+
+func waitForDone(t *testing.T, l *asyncListener) string {
+	select {
+	case err := <-l.done:
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatal("Merge did not finish in time")
+	}
+	return ""
+}
+
+func TestDatabaseWrapper_StartMerge(t *testing.T) {
+	dbw := &DatabaseWrapper{
+		left:  model.MakeDatabaseCopy(leftMultiCollision),
+		right: model.MakeDatabaseCopy(rightMultiCollision),
+	}
+
+	sessions := make(chan *MergeSession, 1)
+	listener := &asyncListener{done: make(chan string, 1)}
+	listener.onConflict = func(conflict *MergeConflict) {
+		session := <-sessions
+		defer func() { sessions <- session }()
+		assert.Error(t, session.SolveConflict("wrongKey", "rightSide"))
+		assert.Error(t, session.SolveConflict(conflict.Key, "wrongSide"))
+		assert.NoError(t, session.SolveConflict(conflict.Key, "rightSide"))
+		assert.Error(t, session.SolveConflict(conflict.Key, "rightSide"))
+	}
+	session := dbw.StartMerge("", listener)
+	sessions <- session
+
+	assert.Equal(t, "", waitForDone(t, listener))
+	assert.True(t, session.Done())
+	assert.Equal(t, 4, listener.conflicts)
+	assert.Equal(t, progressUpdate{StageTagMaps, 100}, listener.updates[len(listener.updates)-1])
+	assert.True(t, dbw.merged.Equals(rightMultiCollision))
+}
+
+func TestDatabaseWrapper_StartMerge_AutoResolution(t *testing.T) {
+	dbw := &DatabaseWrapper{
+		left:  model.MakeDatabaseCopy(leftMultiCollision),
+		right: model.MakeDatabaseCopy(rightMultiCollision),
+	}
+
+	listener := &asyncListener{done: make(chan string, 1)}
+	listener.onConflict = func(conflict *MergeConflict) {
+		t.Fatal("Conflicts should be solved automatically")
+	}
+	dbw.StartMerge("chooseRight", listener)
+
+	assert.Equal(t, "", waitForDone(t, listener))
+	assert.True(t, dbw.merged.Equals(rightMultiCollision))
+}
+
+func TestMergeSession_Cancel(t *testing.T) {
+	dbw := &DatabaseWrapper{
+		left:  model.MakeDatabaseCopy(leftMultiCollision),
+		right: model.MakeDatabaseCopy(rightMultiCollision),
+	}
+
+	sessions := make(chan *MergeSession, 1)
+	listener := &asyncListener{done: make(chan string, 1)}
+	listener.onConflict = func(conflict *MergeConflict) {
+		(<-sessions).Cancel()
+	}
+	sessions <- dbw.StartMerge("", listener)
+
+	assert.Equal(t, ErrMergeCanceled.Error(), waitForDone(t, listener))
+	assert.Equal(t, 1, listener.conflicts)
+	assert.Nil(t, dbw.merged)
+}
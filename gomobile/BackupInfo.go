@@ -0,0 +1,48 @@
+package gomobile
+
+import (
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// BackupInfo gives an overview of a backup, so it can be
+// shown in a picker before importing the backup
+type BackupInfo struct {
+	Name       string
+	DeviceName string
+	// LastModified is the time the backup has last been modified
+	// as Unix timestamp, or 0 if it is not known
+	LastModified  int64
+	SchemaVersion int
+	Stats         *DatabaseStats
+}
+
+// ReadBackupInfo reads the manifest of the given backup and counts
+// its entries, which is much faster than importing it.
+func ReadBackupInfo(filename string) (*BackupInfo, error) {
+	info, err := model.ReadBackupInfo(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BackupInfo{
+		Stats: &DatabaseStats{
+			BlockRange: info.BlockRange,
+			Bookmark:   info.Bookmark,
+			Location:   info.Location,
+			Note:       info.Note,
+			Tag:        info.Tag,
+			TagMap:     info.TagMap,
+			UserMark:   info.UserMark,
+		},
+	}
+	if mfst := info.Manifest; mfst != nil {
+		result.Name = mfst.Name
+		result.DeviceName = mfst.DeviceName
+		result.SchemaVersion = mfst.SchemaVersion
+		if !mfst.LastModified.IsZero() {
+			result.LastModified = mfst.LastModified.Unix()
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package gomobile
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBackupInfo(t *testing.T) {
+	info, err := ReadBackupInfo(filepath.Join("../model/testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	assert.Equal(t, &BackupInfo{
+		Name:          "UserDataBackup_2020-08-15_Andreas-iPhone-Xs",
+		DeviceName:    "Andreas iPhone Xs",
+		LastModified:  1586889735,
+		SchemaVersion: 8,
+		Stats: &DatabaseStats{
+			BlockRange: 4,
+			Bookmark:   2,
+			Location:   7,
+			Note:       2,
+			Tag:        2,
+			TagMap:     2,
+			UserMark:   4,
+		},
+	}, info)
+
+	_, err = ReadBackupInfo(filepath.Join("../model/testdata", "nonexistent.jwlibrary"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package gomobile
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMergeCanceled is returned by the functions of the DatabaseWrapper
+// after the merge has been canceled using Cancel.
+var ErrMergeCanceled = errors.New("Merge has been canceled")
+
+// Cancel aborts the current merge. Running and subsequent merge functions
+// return ErrMergeCanceled as soon as possible and the merged database is
+// discarded. Call Init to start a new merge.
+func (dbw *DatabaseWrapper) Cancel() {
+	if dbw.cancel == nil {
+		dbw.ctx, dbw.cancel = context.WithCancel(context.Background())
+	}
+	dbw.cancel()
+}
+
+// checkCanceled returns ErrMergeCanceled if the current merge has been
+// canceled. In that case, the temporary and merged databases are released.
+// If Init could not prepare the merge, its error is returned instead.
+func (dbw *DatabaseWrapper) checkCanceled() error {
+	if dbw.initErr != nil {
+		return dbw.initErr
+	}
+	if dbw.ctx == nil || dbw.ctx.Err() == nil {
+		return nil
+	}
+
+	dbw.leftTmp = nil
+	dbw.rightTmp = nil
+	dbw.merged = nil
+	return ErrMergeCanceled
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+// +build !windows
+
+package gomobile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDatabaseWrapper_Cancel(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	dbw := &DatabaseWrapper{}
+	assert.NoError(t, dbw.ImportJWLBackup(backupFile, "leftSide"))
+	assert.NoError(t, dbw.ImportJWLBackup(backupFile, "rightSide"))
+	dbw.Init()
+	mcw := &MergeConflictsWrapper{DBWrapper: dbw}
+
+	assert.NoError(t, dbw.MergeLocations())
+	dbw.Cancel()
+	assert.Equal(t, ErrMergeCanceled, dbw.MergeBookmarks("", mcw))
+	assert.Nil(t, dbw.merged)
+	assert.Nil(t, dbw.leftTmp)
+	assert.Nil(t, dbw.rightTmp)
+	assert.Equal(t, ErrMergeCanceled, dbw.MergeTags())
+	assert.Equal(t, ErrMergeCanceled, dbw.MergeUserMarkAndBlockRange("", mcw))
+	assert.Equal(t, ErrMergeCanceled, dbw.MergeNotes("", mcw))
+	assert.Equal(t, ErrMergeCanceled, dbw.MergeTagMaps())
+
+	newBackup := filepath.Join(tmp, "test.jwlibrary")
+	assert.Equal(t, ErrMergeCanceled, dbw.ExportMerged(newBackup))
+	assert.NoFileExists(t, newBackup)
+	assert.Equal(t, ErrMergeCanceled, dbw.ImportJWLBackup(backupFile, "leftSide"))
+
+	// Init starts a new merge
+	dbw.Init()
+	assert.NoError(t, dbw.MergeLocations())
+	assert.NoError(t, dbw.MergeBookmarks("chooseLeft", mcw))
+	assert.NoError(t, dbw.MergeTags())
+	assert.NoError(t, dbw.MergeUserMarkAndBlockRange("chooseLeft", mcw))
+	assert.NoError(t, dbw.MergeNotes("chooseLeft", mcw))
+	assert.NoError(t, dbw.MergeTagMaps())
+	assert.NoError(t, dbw.ExportMerged(newBackup))
+	assert.FileExists(t, newBackup)
+
+	// Cancel before Init
+	dbw = &DatabaseWrapper{}
+	dbw.Cancel()
+	assert.Equal(t, ErrMergeCanceled, dbw.ImportJWLBackup(backupFile, "leftSide"))
+}
@@ -95,3 +95,9 @@ func CatalogExists(path string) bool {
 func CatalogSize(path string) int64 {
 	return publication.CatalogSize(path)
 }
+
+// VerifyCatalog checks if the catalog.db at path is usable. If it returns
+// an error, the catalog should be downloaded again.
+func VerifyCatalog(path string) error {
+	return publication.VerifyCatalog(path)
+}
@@ -1,3 +1,4 @@
+//go:build !windows
 // +build !windows
 
 package gomobile
@@ -109,6 +110,12 @@ func TestCatalogSize(t *testing.T) {
 	assert.Equal(t, int64(0), CatalogSize("not-valid-path"))
 }
 
+func TestVerifyCatalog(t *testing.T) {
+	assert.NoError(t, VerifyCatalog(filepath.Join("../publication/testdata", "catalog.db")))
+	assert.Error(t, VerifyCatalog(filepath.Join("../publication/testdata", "catalog.db.gz")))
+	assert.Error(t, VerifyCatalog("not-valid-path"))
+}
+
 func hashFile(path string) string {
 	f, _ := os.Open(path)
 	hasher := sha256.New()
@@ -0,0 +1,244 @@
+package gomobile
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/AndreasSko/go-jwlm/publication"
+	"github.com/pkg/errors"
+)
+
+// conflictJSON represents a pending conflict as it is exchanged with
+// PendingConflictsJSON
+type conflictJSON struct {
+	Key   string       `json:"key"`
+	Left  conflictSide `json:"left"`
+	Right conflictSide `json:"right"`
+	Diff  []fieldDiff  `json:"diff"`
+}
+
+// conflictSide represents one side of a conflict together with its related
+// entries, the human-readable title of its publication, the name of
+// the device the backup has been created on, and - for notes - the
+// tags and location of the note
+type conflictSide struct {
+	Model            model.Model             `json:"model"`
+	Related          model.Related           `json:"related"`
+	PublicationTitle string                  `json:"publicationTitle"`
+	Device           string                  `json:"device,omitempty"`
+	Context          *merger.ConflictContext `json:"context,omitempty"`
+}
+
+// fieldDiff represents a field that differs between both sides of a conflict
+type fieldDiff struct {
+	Field string      `json:"field"`
+	Left  interface{} `json:"left"`
+	Right interface{} `json:"right"`
+}
+
+// conflictResolution represents the resolution of a conflict
+// as it is accepted by SolveConflictsJSON
+type conflictResolution struct {
+	Key  string `json:"key"`
+	Side string `json:"side"`
+}
+
+// PendingConflictsJSON returns all unsolved conflicts as a JSON array sorted by
+// their key. Next to both sides and their related entries, each conflict
+// contains the fields that differ and the title of the related publication,
+// which is looked up in the catalogDB at catalogPath. If catalogPath is empty
+// or the publication can't be found there, the offline catalog is used.
+func (mcw *MergeConflictsWrapper) PendingConflictsJSON(catalogPath string) (string, error) {
+	var mergedDB *model.Database
+	var leftDevice, rightDevice string
+	if mcw.DBWrapper != nil {
+		mergedDB = mcw.DBWrapper.merged
+		leftDevice = mcw.DBWrapper.left.DeviceName()
+		rightDevice = mcw.DBWrapper.right.DeviceName()
+	}
+
+	keys := make([]string, 0, len(mcw.unsolvedConflicts))
+	for key := range mcw.unsolvedConflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]conflictJSON, 0, len(keys))
+	for _, key := range keys {
+		conflict := mcw.conflicts[key]
+		diff, err := diffModels(conflict.Left, conflict.Right)
+		if err != nil {
+			return "", errors.Wrapf(err, "Error while comparing conflict %s", key)
+		}
+		item := conflictJSON{
+			Key:   key,
+			Left:  newConflictSide(conflict.Left, mergedDB, catalogPath, leftDevice),
+			Right: newConflictSide(conflict.Right, mergedDB, catalogPath, rightDevice),
+			Diff:  diff,
+		}
+		item.Left.Context = conflict.LeftContext
+		item.Right.Context = conflict.RightContext
+		result = append(result, item)
+	}
+
+	jsn, err := json.Marshal(result)
+	if err != nil {
+		return "", errors.Wrap(err, "Error while marshalling to JSON")
+	}
+
+	return string(jsn), nil
+}
+
+// SolveConflictsJSON solves conflicts given as a JSON array of resolutions
+// like `[{"key": "...", "side": "leftSide"}]`. If one of the resolutions is
+// invalid, none of them are applied.
+func (mcw *MergeConflictsWrapper) SolveConflictsJSON(resolutions string) error {
+	var res []conflictResolution
+	if err := json.Unmarshal([]byte(resolutions), &res); err != nil {
+		return errors.Wrap(err, "Could not unmarshal resolutions")
+	}
+
+	// Validate all resolutions first, so SolveConflict can't fail
+	// after some of them have already been applied
+	seen := make(map[string]bool, len(res))
+	for _, r := range res {
+		if _, exists := mcw.unsolvedConflicts[r.Key]; !exists {
+			return errors.Errorf("Unsolved conflict with key %s does not exist", r.Key)
+		}
+		if seen[r.Key] {
+			return errors.Errorf("Conflict with key %s is solved more than once", r.Key)
+		}
+		seen[r.Key] = true
+		if r.Side != "leftSide" && r.Side != "rightSide" {
+			return fmt.Errorf("Side %s is not valid", r.Side)
+		}
+	}
+
+	for _, r := range res {
+		if err := mcw.SolveConflict(r.Key, r.Side); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newConflictSide creates a conflictSide for the given Model
+func newConflictSide(m model.Model, mergedDB *model.Database, catalogPath string, device string) conflictSide {
+	related := m.RelatedEntries(mergedDB)
+	return conflictSide{
+		Model:            m,
+		Related:          related,
+		PublicationTitle: publicationTitle(related.Location, catalogPath),
+		Device:           device,
+	}
+}
+
+// publicationTitle tries to find a human-readable title of the publication
+// the given Location belongs to. It first looks into the catalogDB and falls
+// back to the offline catalog. If both fail, it returns an empty string.
+func publicationTitle(location *model.Location, catalogPath string) string {
+	if location == nil {
+		return ""
+	}
+
+	if catalogPath != "" {
+		lookup := publication.Lookup{
+			KeySymbol:         location.KeySymbol.String,
+			IssueTagNumber:    location.IssueTagNumber,
+			MepsLanguage:      location.MepsLanguage,
+			FallbackLanguages: []int{publication.EnglishMepsLanguage},
+		}
+		if !location.KeySymbol.Valid {
+			lookup.DocumentID = int(location.DocumentID.Int32)
+		}
+		if publ, err := publication.LookupPublication(catalogPath, lookup); err == nil {
+			return publ.Title
+		}
+	}
+
+	title, _ := publication.OfflinePublicationTitle(location.KeySymbol.String)
+	return title
+}
+
+// diffModels returns the fields that differ between left and right, sorted
+// by their name. Nested fields are separated by a dot.
+func diffModels(left model.Model, right model.Model) ([]fieldDiff, error) {
+	leftFields, err := flattenModel(left)
+	if err != nil {
+		return nil, err
+	}
+	rightFields, err := flattenModel(right)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for name := range leftFields {
+		names[name] = true
+	}
+	for name := range rightFields {
+		names[name] = true
+	}
+
+	result := []fieldDiff{}
+	for name := range names {
+		if name == "type" || reflect.DeepEqual(leftFields[name], rightFields[name]) {
+			continue
+		}
+		result = append(result, fieldDiff{Field: name, Left: leftFields[name], Right: rightFields[name]})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Field < result[j].Field
+	})
+
+	return result, nil
+}
+
+// flattenModel converts the JSON representation of a Model
+// to a flat map of field names and their values
+func flattenModel(m model.Model) (map[string]interface{}, error) {
+	jsn, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(jsn, &fields); err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	flattenFields("", fields, result)
+	return result, nil
+}
+
+func flattenFields(prefix string, fields map[string]interface{}, result map[string]interface{}) {
+	for name, value := range fields {
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		nested, ok := value.(map[string]interface{})
+		if !ok {
+			result[name] = value
+			continue
+		}
+		// Represent sql.NullString and sql.NullInt32 by their value
+		if valid, ok := nested["Valid"].(bool); ok && len(nested) == 2 {
+			result[name] = nil
+			if valid {
+				for key, v := range nested {
+					if key != "Valid" {
+						result[name] = v
+					}
+				}
+			}
+			continue
+		}
+		flattenFields(name, nested, result)
+	}
+}
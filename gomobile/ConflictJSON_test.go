@@ -0,0 +1,136 @@
+//go:build !windows
+// +build !windows
+
+package gomobile
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newJSONTestWrapper() *MergeConflictsWrapper {
+	db := &model.Database{
+		Location: []*model.Location{
+			nil,
+			{
+				LocationID:   1,
+				KeySymbol:    sql.NullString{String: "cl", Valid: true},
+				MepsLanguage: 1,
+				Title:        sql.NullString{String: "Location-Title", Valid: true},
+			},
+			{
+				LocationID:   2,
+				KeySymbol:    sql.NullString{String: "lff", Valid: true},
+				MepsLanguage: 0,
+			},
+		},
+	}
+
+	return &MergeConflictsWrapper{
+		DBWrapper: &DatabaseWrapper{merged: db},
+		conflicts: map[string]merger.MergeConflict{
+			"1": {
+				Left: &model.Note{
+					NoteID:     1,
+					GUID:       "GUID",
+					LocationID: sql.NullInt32{Int32: 1, Valid: true},
+					Title:      sql.NullString{String: "Left", Valid: true},
+					Content:    sql.NullString{String: "Content", Valid: true},
+				},
+				Right: &model.Note{
+					NoteID:     2,
+					GUID:       "GUID",
+					LocationID: sql.NullInt32{Int32: 1, Valid: true},
+					Title:      sql.NullString{String: "Right", Valid: true},
+					Content:    sql.NullString{String: "Content", Valid: true},
+				},
+			},
+			"2": {
+				Left: &model.Bookmark{
+					LocationID: 2,
+					Title:      "Bookmark",
+				},
+				Right: &model.Bookmark{
+					LocationID: 2,
+					Title:      "Bookmark",
+					Snippet:    sql.NullString{String: "Snippet", Valid: true},
+				},
+			},
+		},
+		unsolvedConflicts: map[string]bool{"1": true, "2": true},
+	}
+}
+
+func TestMergeConflictsWrapper_PendingConflictsJSON(t *testing.T) {
+	mcw := newJSONTestWrapper()
+
+	jsn, err := mcw.PendingConflictsJSON(filepath.Join("../publication/testdata", "catalog.db"))
+	assert.NoError(t, err)
+
+	var result []map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(jsn), &result))
+	assert.Len(t, result, 2)
+
+	assert.Equal(t, "1", result[0]["key"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "noteId", "left": 1.0, "right": 2.0},
+		map[string]interface{}{"field": "title", "left": "Left", "right": "Right"},
+	}, result[0]["diff"])
+	assert.Equal(t, "Acerquémonos a Jehová", result[0]["left"].(map[string]interface{})["publicationTitle"])
+	assert.Equal(t, "Right", result[0]["right"].(map[string]interface{})["model"].(map[string]interface{})["title"].(map[string]interface{})["String"])
+
+	assert.Equal(t, "2", result[1]["key"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "snippet", "left": nil, "right": "Snippet"},
+	}, result[1]["diff"])
+	// Not in catalog.db, so the offline catalog is used
+	assert.Equal(t, "Enjoy Life Forever!", result[1]["right"].(map[string]interface{})["publicationTitle"])
+
+	// Without catalog only the offline catalog is used
+	jsn, err = mcw.PendingConflictsJSON("")
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal([]byte(jsn), &result))
+	assert.Equal(t, "Draw Close to Jehovah", result[0]["left"].(map[string]interface{})["publicationTitle"])
+
+	mcw = &MergeConflictsWrapper{}
+	jsn, err = mcw.PendingConflictsJSON("")
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", jsn)
+}
+
+func TestMergeConflictsWrapper_SolveConflictsJSON(t *testing.T) {
+	mcw := newJSONTestWrapper()
+
+	assert.Error(t, mcw.SolveConflictsJSON("no json"))
+	assert.EqualError(t, mcw.SolveConflictsJSON(`[{"key": "1", "side": "leftSide"}, {"key": "3", "side": "leftSide"}]`),
+		"Unsolved conflict with key 3 does not exist")
+	assert.EqualError(t, mcw.SolveConflictsJSON(`[{"key": "1", "side": "leftSide"}, {"key": "2", "side": "middle"}]`),
+		"Side middle is not valid")
+	assert.EqualError(t, mcw.SolveConflictsJSON(`[{"key": "1", "side": "leftSide"}, {"key": "1", "side": "rightSide"}]`),
+		"Conflict with key 1 is solved more than once")
+	assert.Len(t, mcw.unsolvedConflicts, 2)
+	assert.Empty(t, mcw.solutions)
+
+	assert.NoError(t, mcw.SolveConflictsJSON(`[{"key": "1", "side": "leftSide"}, {"key": "2", "side": "rightSide"}]`))
+	assert.Empty(t, mcw.unsolvedConflicts)
+	assert.Equal(t, merger.MergeSolution{
+		Side:      merger.LeftSide,
+		Solution:  mcw.conflicts["1"].Left,
+		Discarded: mcw.conflicts["1"].Right,
+	}, mcw.solutions["1"])
+	assert.Equal(t, merger.MergeSolution{
+		Side:      merger.RightSide,
+		Solution:  mcw.conflicts["2"].Right,
+		Discarded: mcw.conflicts["2"].Left,
+	}, mcw.solutions["2"])
+
+	jsn, err := mcw.PendingConflictsJSON("")
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", jsn)
+}
@@ -1,8 +1,11 @@
 package gomobile
 
 import (
+	"context"
 	"errors"
+	"os"
 
+	"github.com/AndreasSko/go-jwlm/merger"
 	"github.com/AndreasSko/go-jwlm/model"
 )
 
@@ -17,22 +20,108 @@ type DatabaseWrapper struct {
 	// times without changing the content of the original databases.
 	leftTmp  *model.Database
 	rightTmp *model.Database
+
+	// Thumbnails of the imported backups, one of which
+	// is kept for the merged backup (see SetThumbnailSide)
+	leftThumbnail  []byte
+	rightThumbnail []byte
+	thumbnailSide  string
+
+	// Media files of the imported backups, which are
+	// merged when exporting (see merger.MergeMedia)
+	leftMedia  map[string][]byte
+	rightMedia map[string][]byte
+
+	// tagMapOrder is the order of the entries of a Tag after merging
+	tagMapOrder merger.TagMapOrder
+	// editions maps Bible editions to an equivalent one (see merger.LocationOptions)
+	editions map[string]string
+
+	progressListener ProgressListener
+	logger           model.Logger
+	// eta estimates the remaining time of the running merge
+	eta *merger.ETA
+
+	// memoryBudget is the memory a merge should use (see SetMemoryBudget).
+	// If it is exceeded, the imported databases are moved to spilled.
+	memoryBudget int64
+	spilled      map[string]*model.SpilledDatabase
+	// initErr is the error that prevented Init from preparing the merge
+	initErr error
+
+	// ctx is canceled when the user aborts the current merge
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // ImportJWLBackup imports a .jwlibrary backup file into the struct
 // on the given side.
 func (dbw *DatabaseWrapper) ImportJWLBackup(filename string, side string) error {
+	if err := dbw.checkCanceled(); err != nil {
+		return err
+	}
+
 	db := &model.Database{}
+	db.SetLogger(dbw.logger)
 
 	if err := db.ImportJWLBackup(filename); err != nil {
 		return err
 	}
+	thumbnail, err := model.ReadThumbnail(filename)
+	if err != nil {
+		return err
+	}
+	media, err := model.ReadMedia(filename)
+	if err != nil {
+		return err
+	}
+
+	return dbw.setBackup(side, db, thumbnail, media)
+}
+
+// ImportJWLBackupFromBytes imports the content of a .jwlibrary backup
+// into the struct on the given side, so backups handed over as data by
+// file pickers don't need to be stored in a temporary file first.
+func (dbw *DatabaseWrapper) ImportJWLBackupFromBytes(data []byte, side string) error {
+	if err := dbw.checkCanceled(); err != nil {
+		return err
+	}
+
+	db := &model.Database{}
+	db.SetLogger(dbw.logger)
+
+	if err := db.ImportJWLBackupFromBytes(data); err != nil {
+		return err
+	}
+	thumbnail, err := model.ReadThumbnailFromBytes(data)
+	if err != nil {
+		return err
+	}
+	media, err := model.ReadMediaFromBytes(data)
+	if err != nil {
+		return err
+	}
+
+	return dbw.setBackup(side, db, thumbnail, media)
+}
+
+// setBackup stores an imported backup on the given side
+func (dbw *DatabaseWrapper) setBackup(side string, db *model.Database, thumbnail []byte, media map[string][]byte) error {
+	if side == "leftSide" || side == "rightSide" {
+		if err := dbw.removeSpilled(side); err != nil {
+			return err
+		}
+	}
 
 	switch side {
 	case "leftSide":
 		dbw.left = db
+		dbw.leftThumbnail = thumbnail
+		dbw.leftMedia = media
 	case "rightSide":
 		dbw.right = db
+		dbw.rightThumbnail = thumbnail
+		dbw.rightMedia = media
 	default:
 		return errors.New("Only leftSide and rightSide are valid for importing backups")
 	}
@@ -40,21 +129,66 @@ func (dbw *DatabaseWrapper) ImportJWLBackup(filename string, side string) error
 	return nil
 }
 
+// DeviceName returns the name of the device the backup of the
+// given side (leftSide or rightSide) has been created on. It is
+// empty if the backup has not been imported yet.
+func (dbw *DatabaseWrapper) DeviceName(side string) string {
+	switch side {
+	case "leftSide", "rightSide":
+		if spilled, ok := dbw.spilled[side]; ok {
+			return spilled.DeviceName()
+		}
+		return (*dbw.imported(side)).DeviceName()
+	default:
+		return ""
+	}
+}
+
 // Init initializes the DatabaseWrapper to prepare for subsequent
 // function calls. Should be called after ImportJWLBackup.
+// A previous call of Cancel is reset, so a new merge can be started.
 func (dbw *DatabaseWrapper) Init() {
-	dbw.leftTmp = model.MakeDatabaseCopy(dbw.left)
-	dbw.rightTmp = model.MakeDatabaseCopy(dbw.right)
+	dbw.ctx, dbw.cancel = context.WithCancel(context.Background())
+	// Errors are returned by the following merge functions (see checkCanceled)
+	if dbw.initErr = dbw.prepareWorkingCopies(); dbw.initErr != nil {
+		dbw.leftTmp, dbw.rightTmp, dbw.merged, dbw.eta = nil, nil, nil, nil
+		return
+	}
+	dbw.eta = merger.NewETA(dbw.leftTmp, dbw.rightTmp)
 	dbw.merged = &model.Database{}
+	dbw.merged.SetLogger(dbw.logger)
+}
+
+// SetThumbnailSide sets whose thumbnail should be kept for the merged
+// backup. It can be leftSide (the default), rightSide, or generate. If
+// the backup on the chosen side has no thumbnail, a new one is generated.
+func (dbw *DatabaseWrapper) SetThumbnailSide(side string) error {
+	switch side {
+	case "leftSide", "rightSide", "generate":
+		dbw.thumbnailSide = side
+		return nil
+	}
+	return errors.New("Only leftSide, rightSide, and generate are valid for thumbnails")
+}
+
+// thumbnail returns the thumbnail for the merged backup. If it is nil,
+// a new one is generated while exporting.
+func (dbw *DatabaseWrapper) thumbnail() []byte {
+	switch dbw.thumbnailSide {
+	case "rightSide":
+		return dbw.rightThumbnail
+	case "generate":
+		return nil
+	}
+	return dbw.leftThumbnail
 }
 
 // DBIsLoaded indicates if a DB on the given side has been loaded.
 func (dbw *DatabaseWrapper) DBIsLoaded(side string) bool {
 	switch side {
-	case "leftSide":
-		return dbw.left != nil
-	case "rightSide":
-		return dbw.right != nil
+	case "leftSide", "rightSide":
+		_, spilled := dbw.spilled[side]
+		return spilled || *dbw.imported(side) != nil
 	case "mergeSide":
 		return dbw.merged != nil
 	}
@@ -62,7 +196,59 @@ func (dbw *DatabaseWrapper) DBIsLoaded(side string) bool {
 	return false
 }
 
-// ExportMerged exports the merged database to filename.
+// ExportMerged exports the merged database to filename. If the merge
+// is canceled while exporting, the unfinished file is removed again.
 func (dbw *DatabaseWrapper) ExportMerged(filename string) error {
-	return dbw.merged.ExportJWLBackup(filename)
+	return dbw.ExportMergedWithCompression(filename, model.DefaultCompression)
+}
+
+// ExportMergedWithCompression works like ExportMerged, but allows to set
+// the compression level of the backup. As compressing is slow on older
+// phones, -1 stores the files without compression. Otherwise, the level
+// ranges from 1 (fastest) to 9 (smallest), with 0 being the default.
+func (dbw *DatabaseWrapper) ExportMergedWithCompression(filename string, level int) error {
+	if err := dbw.checkCanceled(); err != nil {
+		return err
+	}
+
+	media, _ := merger.MergeMedia(dbw.leftMedia, dbw.rightMedia)
+	opts := model.ExportOptions{CompressionLevel: level, Thumbnail: dbw.thumbnail(), Media: media}
+	if err := dbw.merged.ExportJWLBackupWithOptions(filename, opts); err != nil {
+		return err
+	}
+
+	if err := dbw.checkCanceled(); err != nil {
+		os.Remove(filename)
+		return err
+	}
+
+	return nil
+}
+
+// ExportMergedToBytes exports the merged database like ExportMerged,
+// but returns the content of the backup instead of storing it, so it
+// can be handed over to the file providers of the platform.
+func (dbw *DatabaseWrapper) ExportMergedToBytes() ([]byte, error) {
+	return dbw.ExportMergedToBytesWithCompression(model.DefaultCompression)
+}
+
+// ExportMergedToBytesWithCompression works like ExportMergedToBytes,
+// but allows to set the compression level like ExportMergedWithCompression.
+func (dbw *DatabaseWrapper) ExportMergedToBytesWithCompression(level int) ([]byte, error) {
+	if err := dbw.checkCanceled(); err != nil {
+		return nil, err
+	}
+
+	media, _ := merger.MergeMedia(dbw.leftMedia, dbw.rightMedia)
+	opts := model.ExportOptions{CompressionLevel: level, Thumbnail: dbw.thumbnail(), Media: media}
+	data, err := dbw.merged.ExportJWLBackupToBytesWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := dbw.checkCanceled(); err != nil {
+		return nil, err
+	}
+
+	return data, nil
 }
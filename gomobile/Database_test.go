@@ -1,3 +1,4 @@
+//go:build !windows
 // +build !windows
 
 package gomobile
@@ -33,6 +34,102 @@ func TestDatabaseWrapper_ImportJWLBackup(t *testing.T) {
 	assert.True(t, dbw.left.Equals(dbw.right))
 }
 
+func TestDatabaseWrapper_ImportJWLBackupFromBytes(t *testing.T) {
+	data, err := ioutil.ReadFile(backupFile)
+	assert.NoError(t, err)
+
+	dbw := &DatabaseWrapper{}
+	assert.Error(t, dbw.ImportJWLBackupFromBytes([]byte("wrongFile"), "leftSide"))
+	assert.NoError(t, dbw.ImportJWLBackupFromBytes(data, "leftSide"))
+	assert.NoError(t, dbw.ImportJWLBackup(backupFile, "rightSide"))
+	assert.EqualError(t, dbw.ImportJWLBackupFromBytes(data, "wrongSide"), "Only leftSide and rightSide are valid for importing backups")
+
+	assert.True(t, dbw.left.Equals(dbw.right))
+	assert.Equal(t, "Andreas iPhone Xs", dbw.DeviceName("leftSide"))
+	assert.Equal(t, dbw.rightThumbnail, dbw.leftThumbnail)
+}
+
+func TestDatabaseWrapper_ExportMergedToBytes(t *testing.T) {
+	dbw := &DatabaseWrapper{}
+	assert.NoError(t, dbw.ImportJWLBackup(backupFile, "leftSide"))
+	assert.NoError(t, dbw.ImportJWLBackup(backupFile, "rightSide"))
+	dbw.Init()
+	dbw.merged = model.MakeDatabaseCopy(dbw.left)
+
+	data, err := dbw.ExportMergedToBytes()
+	assert.NoError(t, err)
+	exported := &model.Database{}
+	assert.NoError(t, exported.ImportJWLBackupFromBytes(data))
+	assert.True(t, dbw.left.Equals(exported))
+	// The backup has no thumbnail, so a new one is generated
+	thumbnail, err := model.ReadThumbnailFromBytes(data)
+	assert.NoError(t, err)
+	generated, err := model.GenerateThumbnail()
+	assert.NoError(t, err)
+	assert.Equal(t, generated, thumbnail)
+
+	_, err = dbw.ExportMergedToBytesWithCompression(model.StoreOnly)
+	assert.NoError(t, err)
+	_, err = dbw.ExportMergedToBytesWithCompression(10)
+	assert.Error(t, err)
+
+	dbw.Cancel()
+	_, err = dbw.ExportMergedToBytes()
+	assert.Equal(t, ErrMergeCanceled, err)
+}
+
+func TestDatabaseWrapper_DeviceName(t *testing.T) {
+	dbw := &DatabaseWrapper{}
+	assert.Equal(t, "", dbw.DeviceName("leftSide"))
+
+	assert.NoError(t, dbw.ImportJWLBackup(backupFile, "leftSide"))
+	assert.Equal(t, "Andreas iPhone Xs", dbw.DeviceName("leftSide"))
+	assert.Equal(t, "", dbw.DeviceName("rightSide"))
+	assert.Equal(t, "", dbw.DeviceName("wrongSide"))
+}
+
+func TestDatabaseWrapper_SetThumbnailSide(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := &model.Database{}
+	assert.NoError(t, db.ImportJWLBackup(backupFile))
+	leftBackup := filepath.Join(tmp, "left.jwlibrary")
+	rightBackup := filepath.Join(tmp, "right.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackupWithOptions(leftBackup, model.ExportOptions{Thumbnail: []byte("left")}))
+	assert.NoError(t, db.ExportJWLBackupWithOptions(rightBackup, model.ExportOptions{Thumbnail: []byte("right")}))
+
+	dbw := &DatabaseWrapper{}
+	assert.NoError(t, dbw.ImportJWLBackup(leftBackup, "leftSide"))
+	assert.NoError(t, dbw.ImportJWLBackup(rightBackup, "rightSide"))
+	dbw.Init()
+	merged := filepath.Join(tmp, "merged.jwlibrary")
+	generated, err := model.GenerateThumbnail()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		side     string
+		expected []byte
+	}{
+		{"", []byte("left")},
+		{"rightSide", []byte("right")},
+		{"generate", generated},
+		{"leftSide", []byte("left")},
+	}
+	for _, tt := range tests {
+		if tt.side != "" {
+			assert.NoError(t, dbw.SetThumbnailSide(tt.side))
+		}
+		assert.NoError(t, dbw.ExportMerged(merged))
+		thumbnail, err := model.ReadThumbnail(merged)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, thumbnail)
+	}
+
+	assert.EqualError(t, dbw.SetThumbnailSide("wrongSide"), "Only leftSide, rightSide, and generate are valid for thumbnails")
+}
+
 func TestDatabaseWrapper_Init(t *testing.T) {
 	dbw := &DatabaseWrapper{}
 
@@ -94,4 +191,12 @@ func TestDatabaseWrapper_ExportMerged(t *testing.T) {
 	newDB := &model.Database{}
 	assert.NoError(t, newDB.ImportJWLBackup(newBackup))
 	assert.True(t, dbw.merged.Equals(newDB))
+
+	storedBackup := filepath.Join(tmp, "stored.jwlibrary")
+	assert.NoError(t, dbw.ExportMergedWithCompression(storedBackup, model.StoreOnly))
+	newDB = &model.Database{}
+	assert.NoError(t, newDB.ImportJWLBackup(storedBackup))
+	assert.True(t, dbw.merged.Equals(newDB))
+
+	assert.Error(t, dbw.ExportMergedWithCompression(filepath.Join(tmp, "invalid.jwlibrary"), 42))
 }
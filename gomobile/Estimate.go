@@ -0,0 +1,46 @@
+package gomobile
+
+import (
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/pkg/errors"
+)
+
+// ConflictEstimate represents the number of conflicts per
+// stage that are likely to come up during the merge
+type ConflictEstimate struct {
+	Bookmarks int
+	Tags      int
+	Markings  int
+	Notes     int
+	Total     int
+}
+
+// EstimateConflicts counts the conflicts that are likely to come up when
+// merging the left and right Database, so apps can warn about a large
+// number of conflicts before starting the merge.
+func (dbw *DatabaseWrapper) EstimateConflicts() (*ConflictEstimate, error) {
+	if !dbw.DBIsLoaded("leftSide") || !dbw.DBIsLoaded("rightSide") {
+		return nil, errors.New("Both sides need to be imported before estimating conflicts")
+	}
+	left, err := dbw.original("leftSide")
+	if err != nil {
+		return nil, err
+	}
+	right, err := dbw.original("rightSide")
+	if err != nil {
+		return nil, err
+	}
+
+	estimate, err := merger.EstimateConflicts(left, right)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not estimate conflicts")
+	}
+
+	return &ConflictEstimate{
+		Bookmarks: estimate.Bookmarks,
+		Tags:      estimate.Tags,
+		Markings:  estimate.Markings,
+		Notes:     estimate.Notes,
+		Total:     estimate.Total(),
+	}, nil
+}
@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package gomobile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseWrapper_EstimateConflicts(t *testing.T) {
+	dbw := &DatabaseWrapper{
+		left:  leftDB,
+		right: rightDB,
+	}
+	estimate, err := dbw.EstimateConflicts()
+	assert.NoError(t, err)
+	assert.Equal(t, &ConflictEstimate{Bookmarks: 1, Markings: 1, Notes: 1, Total: 3}, estimate)
+
+	dbw = &DatabaseWrapper{
+		left:  leftDB,
+		right: emptyDB,
+	}
+	estimate, err = dbw.EstimateConflicts()
+	assert.NoError(t, err)
+	assert.Equal(t, &ConflictEstimate{}, estimate)
+
+	dbw = &DatabaseWrapper{left: leftDB}
+	_, err = dbw.EstimateConflicts()
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package gomobile
+
+import (
+	"fmt"
+
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// Levels of the messages passed to a Logger
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+)
+
+// Logger receives the log messages of go-jwlm. It can be implemented
+// in Swift or Kotlin to route them into the logging system of the app.
+type Logger interface {
+	// Log is called for every message, with level being
+	// one of LogLevelDebug, LogLevelInfo, or LogLevelWarn.
+	Log(level string, message string)
+}
+
+// loggerAdapter makes a Logger usable as a model.Logger
+type loggerAdapter struct {
+	logger Logger
+}
+
+func (a loggerAdapter) Debugf(format string, args ...interface{}) {
+	a.logger.Log(LogLevelDebug, fmt.Sprintf(format, args...))
+}
+
+func (a loggerAdapter) Infof(format string, args ...interface{}) {
+	a.logger.Log(LogLevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (a loggerAdapter) Warnf(format string, args ...interface{}) {
+	a.logger.Log(LogLevelWarn, fmt.Sprintf(format, args...))
+}
+
+// SetLogger sets the Logger that receives the messages of subsequent
+// imports, exports, and merges. Passing nil restores the default
+// logging.
+func (dbw *DatabaseWrapper) SetLogger(logger Logger) {
+	if logger == nil {
+		dbw.logger = nil
+	} else {
+		dbw.logger = loggerAdapter{logger}
+	}
+
+	for _, db := range []*model.Database{dbw.left, dbw.right, dbw.merged, dbw.leftTmp, dbw.rightTmp} {
+		if db != nil {
+			db.SetLogger(dbw.logger)
+		}
+	}
+}
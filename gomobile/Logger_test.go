@@ -0,0 +1,78 @@
+//go:build !windows
+// +build !windows
+
+package gomobile
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+type logMessage struct {
+	level   string
+	message string
+}
+
+type recordingLogger struct {
+	messages []logMessage
+}
+
+func (l *recordingLogger) Log(level string, message string) {
+	l.messages = append(l.messages, logMessage{level, message})
+}
+
+func TestDatabaseWrapper_SetLogger(t *testing.T) {
+	dbw := DatabaseWrapper{
+		left: &model.Database{Location: []*model.Location{
+			nil,
+			{
+				LocationID:    1,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				MepsLanguage:  2,
+			},
+		}},
+		right: &model.Database{Location: []*model.Location{
+			nil,
+			{
+				LocationID:    1,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwt", Valid: true},
+				MepsLanguage:  2,
+			},
+		}},
+	}
+	logger := &recordingLogger{}
+	dbw.SetLogger(logger)
+	defer dbw.SetLogger(nil)
+	dbw.Init()
+
+	assert.NoError(t, dbw.MergeLocations())
+	assert.Equal(t, []logMessage{
+		{LogLevelInfo, "Migrating locations of language 2 on rightSide from nwt to nwtsty"},
+	}, logger.messages)
+
+	logger.messages = nil
+	path := filepath.Join(t.TempDir(), "merged.jwlibrary")
+	assert.NoError(t, dbw.ExportMerged(path))
+	assert.Equal(t, []logMessage{
+		{LogLevelDebug, "Exported backup to " + path},
+	}, logger.messages)
+
+	logger.messages = nil
+	assert.NoError(t, dbw.ImportJWLBackup(path, "leftSide"))
+	assert.Len(t, logger.messages, 1)
+	assert.Equal(t, LogLevelDebug, logger.messages[0].level)
+
+	logger.messages = nil
+	dbw.SetLogger(nil)
+	dbw.Init()
+	assert.NoError(t, dbw.MergeLocations())
+	assert.Empty(t, logger.messages)
+}
@@ -0,0 +1,123 @@
+package gomobile
+
+import (
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// mergeCopies is the number of versions of the imported databases that
+// are kept in memory during a merge: the imported ones, their temporary
+// copies, and the merged one, which is at most as big as both together
+const mergeCopies = 3
+
+// SetMemoryBudget limits the memory (in bytes) a merge should use. If
+// the imported backups are estimated to need more, Init moves them to
+// temporary SQLite databases and merges them without copying them
+// first. Whenever the original versions are needed again, like for a
+// new merge or Stats, they are read from the disk. This is slower, but
+// prevents the app from being killed on phones with little memory.
+// A budget of 0 (the default) disables the limit.
+func (dbw *DatabaseWrapper) SetMemoryBudget(bytes int64) {
+	dbw.memoryBudget = bytes
+}
+
+// Close removes the temporary databases created because the memory
+// budget has been exceeded (see SetMemoryBudget).
+func (dbw *DatabaseWrapper) Close() error {
+	for side := range dbw.spilled {
+		if err := dbw.removeSpilled(side); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exceedsMemoryBudget indicates if merging the imported
+// databases is likely to need more memory than allowed.
+func (dbw *DatabaseWrapper) exceedsMemoryBudget() bool {
+	if dbw.memoryBudget <= 0 {
+		return false
+	}
+	size := dbw.left.EstimatedSize() + dbw.right.EstimatedSize()
+	return size*mergeCopies > dbw.memoryBudget
+}
+
+// imported returns a pointer to the field holding the imported
+// database of the given side.
+func (dbw *DatabaseWrapper) imported(side string) **model.Database {
+	if side == "leftSide" {
+		return &dbw.left
+	}
+	return &dbw.right
+}
+
+// original returns the imported database of the given side (leftSide or
+// rightSide), reading it from the disk if it has been spilled. It is nil
+// if no backup has been imported on this side.
+func (dbw *DatabaseWrapper) original(side string) (*model.Database, error) {
+	if spilled, ok := dbw.spilled[side]; ok {
+		db, err := spilled.Load()
+		if err != nil {
+			return nil, err
+		}
+		db.SetLogger(dbw.logger)
+		return db, nil
+	}
+	return *dbw.imported(side), nil
+}
+
+// prepareWorkingCopies sets the temporary databases the merge functions
+// work on. Once the memory budget has been exceeded, the imported
+// databases stay on the disk for all following merges.
+func (dbw *DatabaseWrapper) prepareWorkingCopies() error {
+	spill := dbw.exceedsMemoryBudget() || len(dbw.spilled) > 0
+	left, err := dbw.workingCopy("leftSide", spill)
+	if err != nil {
+		return err
+	}
+	right, err := dbw.workingCopy("rightSide", spill)
+	if err != nil {
+		return err
+	}
+	dbw.leftTmp, dbw.rightTmp = left, right
+	return nil
+}
+
+// workingCopy returns a copy of the imported database of the given side
+// that can be changed by the merge functions. If spill is set, the
+// imported database is moved to the disk and returned itself instead.
+func (dbw *DatabaseWrapper) workingCopy(side string, spill bool) (*model.Database, error) {
+	db := dbw.imported(side)
+	if _, ok := dbw.spilled[side]; ok {
+		return dbw.original(side)
+	}
+	if !spill || *db == nil {
+		return model.MakeDatabaseCopy(*db), nil
+	}
+
+	spilled, err := (*db).Spill()
+	if err != nil {
+		return nil, err
+	}
+	if dbw.spilled == nil {
+		dbw.spilled = map[string]*model.SpilledDatabase{}
+	}
+	dbw.spilled[side] = spilled
+	working := *db
+	*db = nil
+	return working, nil
+}
+
+// removeSpilled removes the temporary database of the given side
+// (if there is one), like when a new backup has been imported.
+func (dbw *DatabaseWrapper) removeSpilled(side string) error {
+	spilled, ok := dbw.spilled[side]
+	if !ok {
+		return nil
+	}
+	delete(dbw.spilled, side)
+	if err := spilled.Remove(); err != nil {
+		return errors.Wrapf(err, "Could not remove temporary database of %s", side)
+	}
+	return nil
+}
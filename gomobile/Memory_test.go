@@ -0,0 +1,88 @@
+//go:build !windows
+// +build !windows
+
+package gomobile
+
+import (
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+// mergeAll runs all merge functions of the DatabaseWrapper
+func mergeAll(t *testing.T, dbw *DatabaseWrapper) {
+	mcw := &MergeConflictsWrapper{}
+	assert.NoError(t, dbw.MergeLocations())
+	assert.NoError(t, dbw.MergeBookmarks("chooseLeft", mcw))
+	assert.NoError(t, dbw.MergeTags())
+	assert.NoError(t, dbw.MergeUserMarkAndBlockRange("chooseLeft", mcw))
+	assert.NoError(t, dbw.MergeNotes("chooseLeft", mcw))
+	assert.NoError(t, dbw.MergeTagMaps())
+}
+
+func TestDatabaseWrapper_SetMemoryBudget(t *testing.T) {
+	expected := &DatabaseWrapper{left: model.MakeDatabaseCopy(leftDB), right: model.MakeDatabaseCopy(rightDB)}
+	expected.Init()
+	mergeAll(t, expected)
+	assert.Empty(t, expected.spilled)
+
+	// The budget is big enough to keep everything in memory
+	dbw := &DatabaseWrapper{left: model.MakeDatabaseCopy(leftDB), right: model.MakeDatabaseCopy(rightDB)}
+	dbw.SetMemoryBudget(1 << 30)
+	dbw.Init()
+	assert.Empty(t, dbw.spilled)
+	assert.NotNil(t, dbw.left)
+
+	dbw.SetMemoryBudget(1)
+	dbw.Init()
+	defer dbw.Close()
+	assert.Len(t, dbw.spilled, 2)
+	assert.Nil(t, dbw.left)
+	assert.Nil(t, dbw.right)
+	assert.True(t, dbw.DBIsLoaded("leftSide"))
+	assert.True(t, dbw.DBIsLoaded("rightSide"))
+	assert.Equal(t, expected.Stats("leftSide"), dbw.Stats("leftSide"))
+	estimate, err := dbw.EstimateConflicts()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, estimate.Total)
+
+	mergeAll(t, dbw)
+	assert.True(t, expected.merged.Equals(dbw.merged))
+
+	// A new merge reads the imported databases from the disk again
+	dbw.Init()
+	mergeAll(t, dbw)
+	assert.True(t, expected.merged.Equals(dbw.merged))
+
+	// Importing a new backup replaces the spilled one
+	spilled := dbw.spilled["leftSide"]
+	assert.NoError(t, dbw.ImportJWLBackup(backupFile, "leftSide"))
+	assert.NotContains(t, dbw.spilled, "leftSide")
+	_, err = spilled.Load()
+	assert.Error(t, err)
+	assert.Equal(t, "Andreas iPhone Xs", dbw.DeviceName("leftSide"))
+	dbw.Init()
+	assert.Len(t, dbw.spilled, 2)
+	assert.Equal(t, "Andreas iPhone Xs", dbw.DeviceName("leftSide"))
+
+	spilled = dbw.spilled["rightSide"]
+	assert.NoError(t, dbw.Close())
+	assert.Empty(t, dbw.spilled)
+	_, err = spilled.Load()
+	assert.Error(t, err)
+}
+
+func TestDatabaseWrapper_Init_spillError(t *testing.T) {
+	dbw := &DatabaseWrapper{left: model.MakeDatabaseCopy(leftDB), right: model.MakeDatabaseCopy(rightDB)}
+	dbw.SetMemoryBudget(1)
+	dbw.Init()
+	defer dbw.Close()
+
+	// Errors while reading the spilled databases are returned by the merge functions
+	assert.NoError(t, dbw.spilled["leftSide"].Remove())
+	dbw.Init()
+	assert.Error(t, dbw.MergeLocations())
+	assert.Nil(t, dbw.leftTmp)
+	assert.Equal(t, &DatabaseStats{}, dbw.Stats("leftSide"))
+}
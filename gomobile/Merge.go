@@ -1,157 +1,116 @@
 package gomobile
 
 import (
+	"strings"
+
 	"github.com/AndreasSko/go-jwlm/merger"
 	"github.com/pkg/errors"
 )
 
 // MergeLocations merges locations
 func (dbw *DatabaseWrapper) MergeLocations() error {
-	mergedLocations, locationIDChanges, err := merger.MergeLocations(dbw.leftTmp.Location, dbw.rightTmp.Location)
-	if err != nil {
-		return errors.Wrap(err, "Could not merge locations")
-	}
-	dbw.merged.Location = mergedLocations
-	merger.UpdateLRIDs(dbw.leftTmp.Bookmark, dbw.rightTmp.Bookmark, "LocationID", locationIDChanges)
-	merger.UpdateLRIDs(dbw.leftTmp.Bookmark, dbw.rightTmp.Bookmark, "PublicationLocationID", locationIDChanges)
-	merger.UpdateLRIDs(dbw.leftTmp.Note, dbw.rightTmp.Note, "LocationID", locationIDChanges)
-	merger.UpdateLRIDs(dbw.leftTmp.TagMap, dbw.rightTmp.TagMap, "LocationID", locationIDChanges)
-	merger.UpdateLRIDs(dbw.leftTmp.UserMark, dbw.rightTmp.UserMark, "LocationID", locationIDChanges)
-
-	return nil
+	return dbw.mergeStage(StageLocations, "", nil)
 }
 
 // MergeBookmarks merges bookmarks
 func (dbw *DatabaseWrapper) MergeBookmarks(conflictSolver string, mcw *MergeConflictsWrapper) error {
-	var conflictSolution = mcw.solutions
-	if conflictSolution == nil {
-		conflictSolution = map[string]merger.MergeSolution{}
-	}
-	for {
-		merged, _, err := merger.MergeBookmarks(dbw.leftTmp.Bookmark, dbw.rightTmp.Bookmark, conflictSolution)
-		if err == nil {
-			dbw.merged.Bookmark = merged
-			break
-		}
-		switch err := err.(type) {
-		case merger.MergeConflictError:
-			if conflictSolver == "" {
-				mcw.addConflicts(err.Conflicts)
-				return MergeConflictError{}
-			}
-			var resErr error
-			newSolutions, resErr := merger.AutoResolveConflicts(err.Conflicts, conflictSolver)
-			if resErr != nil {
-				return errors.Wrap(err, "Could not automatically solve conflicts for bookmarks")
-			}
-			addToSolutions(conflictSolution, newSolutions)
-		default:
-			return errors.Wrap(err, "Could not merge bookmarks")
-		}
-	}
-
-	return nil
+	return dbw.mergeStage(StageBookmarks, conflictSolver, mcw)
 }
 
 // MergeTags merges tags
 func (dbw *DatabaseWrapper) MergeTags() error {
-	var conflictSolution map[string]merger.MergeSolution
-	for {
-		merged, idChanges, err := merger.MergeTags(dbw.leftTmp.Tag, dbw.rightTmp.Tag, conflictSolution)
-		if err == nil {
-			dbw.merged.Tag = merged
-			merger.UpdateLRIDs(dbw.leftTmp.TagMap, dbw.rightTmp.TagMap, "TagID", idChanges)
-			break
-		}
-		return errors.Wrap(err, "Could not merge tags")
-	}
-
-	return nil
+	return dbw.mergeStage(StageTags, "", nil)
 }
 
 // MergeUserMarkAndBlockRange merges UserMarks and BlockRanges
 func (dbw *DatabaseWrapper) MergeUserMarkAndBlockRange(conflictSolver string, mcw *MergeConflictsWrapper) error {
-	var conflictSolution = mcw.solutions
-	if conflictSolution == nil {
-		conflictSolution = map[string]merger.MergeSolution{}
-	}
-	for {
-		mergedUserMarks, mergedBlockRanges, idChanges, err := merger.MergeUserMarkAndBlockRange(dbw.leftTmp.UserMark, dbw.leftTmp.BlockRange, dbw.rightTmp.UserMark, dbw.rightTmp.BlockRange, conflictSolution)
-		if err == nil {
-			dbw.merged.UserMark = mergedUserMarks
-			dbw.merged.BlockRange = mergedBlockRanges
-			merger.UpdateLRIDs(dbw.leftTmp.Note, dbw.rightTmp.Note, "UserMarkID", idChanges)
-			break
-		}
-		switch err := err.(type) {
-		case merger.MergeConflictError:
-			if conflictSolver == "" {
-				mcw.addConflicts(err.Conflicts)
-				return MergeConflictError{}
-			}
-			var resErr error
-			newSolutions, resErr := merger.AutoResolveConflicts(err.Conflicts, conflictSolver)
-			if resErr != nil {
-				return errors.Wrap(err, "Could not automatically solve conflicts for markings")
-			}
-			addToSolutions(conflictSolution, newSolutions)
-		default:
-			return errors.Wrap(err, "Could not merge markings")
-		}
-	}
-
-	return nil
+	return dbw.mergeStage(StageMarkings, conflictSolver, mcw)
 }
 
 // MergeNotes merges notes
 func (dbw *DatabaseWrapper) MergeNotes(conflictSolver string, mcw *MergeConflictsWrapper) error {
-	var conflictSolution = mcw.solutions
-	if conflictSolution == nil {
-		conflictSolution = map[string]merger.MergeSolution{}
+	return dbw.mergeStage(StageNotes, conflictSolver, mcw)
+}
+
+// MergeTagMaps merges tagMaps
+func (dbw *DatabaseWrapper) MergeTagMaps() error {
+	return dbw.mergeStage(StageTagMaps, "", nil)
+}
+
+// mergeStage merges a single stage using merger.MergeStage, so the
+// merge functions above have to be called in the order of merger.Stages.
+// Conflicts are solved automatically if conflictSolver is set, and added
+// to mcw otherwise. Stages without mcw can't have conflicts.
+func (dbw *DatabaseWrapper) mergeStage(stage string, conflictSolver string, mcw *MergeConflictsWrapper) error {
+	if err := dbw.checkCanceled(); err != nil {
+		return err
+	}
+
+	dbw.reportProgress(stage, false)
+
+	conflictSolution := map[string]merger.MergeSolution{}
+	if mcw != nil && mcw.solutions != nil {
+		conflictSolution = mcw.solutions
 	}
 	for {
-		merged, idChanges, err := merger.MergeNotes(dbw.leftTmp.Note, dbw.rightTmp.Note, conflictSolution)
+		err := merger.MergeStage(stage, dbw.leftTmp, dbw.rightTmp, dbw.merged, conflictSolution, dbw.mergeOptions())
 		if err == nil {
-			dbw.merged.Note = merged
-			merger.UpdateLRIDs(dbw.leftTmp.TagMap, dbw.rightTmp.TagMap, "NoteID", idChanges)
 			break
 		}
-		switch err := err.(type) {
-		case merger.MergeConflictError:
-			if conflictSolver == "" {
-				mcw.addConflicts(err.Conflicts)
-				return MergeConflictError{}
-			}
-			var resErr error
-			newSolutions, resErr := merger.AutoResolveConflicts(err.Conflicts, conflictSolver)
-			if resErr != nil {
-				return errors.Wrap(err, "Could not automatically solve conflicts for notes")
-			}
-			addToSolutions(conflictSolution, newSolutions)
-		default:
-			return errors.Wrap(err, "Could not merge notes")
+		mcErr, ok := err.(merger.MergeConflictError)
+		if !ok || mcw == nil {
+			return errors.Wrapf(err, "Could not merge %s", strings.ToLower(stage))
 		}
+		if conflictSolver == "" {
+			mcw.addConflicts(mcErr.Conflicts)
+			return MergeConflictError{}
+		}
+		newSolutions, err := merger.AutoResolveConflicts(mcErr.Conflicts, conflictSolver)
+		if err != nil {
+			return errors.Wrapf(err, "Could not automatically solve conflicts for %s", strings.ToLower(stage))
+		}
+		addToSolutions(conflictSolution, newSolutions)
 	}
 
+	dbw.reportProgress(stage, true)
 	return nil
 }
 
-// MergeTagMaps merges tagMaps
-func (dbw *DatabaseWrapper) MergeTagMaps() error {
-	var conflictSolution map[string]merger.MergeSolution
-	for {
-		merged, _, err := merger.MergeTagMaps(dbw.leftTmp.TagMap, dbw.rightTmp.TagMap, conflictSolution)
-		if err == nil {
-			dbw.merged.TagMap = merged
-			break
-		}
-
-		return errors.Wrap(err, "Could not merge tagMaps")
+// mergeOptions returns the options of the merge functions
+// as they have been set on dbw
+func (dbw *DatabaseWrapper) mergeOptions() merger.MergeOptions {
+	return merger.MergeOptions{
+		Editions:    dbw.editions,
+		TagMapOrder: dbw.tagMapOrder,
+		Logger:      dbw.logger,
 	}
+}
 
+// SetTagMapOrder sets how the entries of a Tag are ordered by MergeTagMaps.
+// It can be 'interleave' (default), 'leftFirst', 'rightFirst', or 'noteTitle'.
+func (dbw *DatabaseWrapper) SetTagMapOrder(order string) error {
+	tagMapOrder, err := merger.ParseTagMapOrder(order)
+	if err != nil {
+		return err
+	}
+	dbw.tagMapOrder = tagMapOrder
 	return nil
 }
 
+// SetEquivalentEdition makes MergeLocations merge the chapters of the Bible
+// edition (like nwt) with those of the equivalent one (like nwtsty).
+// If equivalent is empty, the edition is not treated specially anymore.
+func (dbw *DatabaseWrapper) SetEquivalentEdition(edition string, equivalent string) {
+	if equivalent == "" {
+		delete(dbw.editions, edition)
+		return
+	}
+	if dbw.editions == nil {
+		dbw.editions = map[string]string{}
+	}
+	dbw.editions[edition] = equivalent
+}
+
 // addToSolutions adds new mergeSolutions to the existing map of mergeSolutions
 func addToSolutions(solutions map[string]merger.MergeSolution, new map[string]merger.MergeSolution) {
 	for key, value := range new {
@@ -21,6 +21,11 @@ func (e MergeConflictError) Error() string {
 	return fmt.Sprintf("There were conflicts while trying to merge %s", e.Err)
 }
 
+// Unwrap returns merger.ErrMergeConflict
+func (e MergeConflictError) Unwrap() error {
+	return merger.ErrMergeConflict
+}
+
 // MergeConflictsWrapper wraps mergeConflicts and their solutions
 type MergeConflictsWrapper struct {
 	DBWrapper         *DatabaseWrapper
@@ -31,11 +36,14 @@ type MergeConflictsWrapper struct {
 
 // MergeConflict represents two Models that collide. It is equvalent
 // to merger.MergeConflict, but represents the Models as strings
-// to make it compatible with Gomobile.
+// to make it compatible with Gomobile. LeftDevice and RightDevice are
+// the names of the devices the backups have been created on (if known).
 type MergeConflict struct {
-	Key   string
-	Left  string
-	Right string
+	Key         string
+	Left        string
+	Right       string
+	LeftDevice  string
+	RightDevice string
 }
 
 // modelRelatedTuple contains a model and its related entries
@@ -90,7 +98,9 @@ func (mcw *MergeConflictsWrapper) NextConflict() (*MergeConflict, error) {
 	conflict := mcw.conflicts[conflictKey]
 
 	result := &MergeConflict{
-		Key: conflictKey,
+		Key:         conflictKey,
+		LeftDevice:  mcw.DBWrapper.left.DeviceName(),
+		RightDevice: mcw.DBWrapper.right.DeviceName(),
 	}
 	jsn, err := json.Marshal(modelRelatedTuple{
 		Model:   conflict.Left,
@@ -1,3 +1,4 @@
+//go:build !windows
 // +build !windows
 
 package gomobile
@@ -5,6 +6,7 @@ package gomobile
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/AndreasSko/go-jwlm/merger"
@@ -150,6 +152,17 @@ func TestMergeConflictsWrapper_NextConflict(t *testing.T) {
 		conflict.Right)
 	delete(mcw.unsolvedConflicts, conflict.Key)
 
+	// Names of the devices are taken from the imported backups
+	dbw := &DatabaseWrapper{}
+	assert.NoError(t, dbw.ImportJWLBackup(backupFile, "leftSide"))
+	mcw.DBWrapper = dbw
+	mcw.unsolvedConflicts["2"] = true
+	conflict, err = mcw.NextConflict()
+	assert.NoError(t, err)
+	assert.Equal(t, "Andreas iPhone Xs", conflict.LeftDevice)
+	assert.Equal(t, "", conflict.RightDevice)
+	delete(mcw.unsolvedConflicts, conflict.Key)
+
 	_, err = mcw.NextConflict()
 	assert.EqualError(t, err, "There are no unsolved conflicts")
 }
@@ -210,3 +223,9 @@ func TestMergeConflictsWrapper_SolveConflict(t *testing.T) {
 	_, err := mcw.NextConflict()
 	assert.EqualError(t, err, "There are no unsolved conflicts")
 }
+
+func TestMergeConflictError_Unwrap(t *testing.T) {
+	var err error = MergeConflictError{Err: "Notes"}
+	assert.True(t, errors.Is(err, merger.ErrMergeConflict))
+	assert.EqualError(t, err, "There were conflicts while trying to merge Notes")
+}
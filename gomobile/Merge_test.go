@@ -1,11 +1,17 @@
+//go:build !windows
 // +build !windows
 
 package gomobile
 
 import (
 	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/AndreasSko/go-jwlm/merger"
 	"github.com/AndreasSko/go-jwlm/model"
 	"github.com/tj/assert"
 )
@@ -270,6 +276,76 @@ func Test_MergeWithEmpty(t *testing.T) {
 	assert.True(t, dbw.left.Equals(dbw.merged))
 }
 
+func TestDatabaseWrapper_SetTagMapOrder(t *testing.T) {
+	dbw := DatabaseWrapper{
+		leftTmp: &model.Database{TagMap: []*model.TagMap{
+			nil,
+			{TagMapID: 1, NoteID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 1, Position: 0},
+		}},
+		rightTmp: &model.Database{TagMap: []*model.TagMap{
+			nil,
+			{TagMapID: 1, NoteID: sql.NullInt32{Int32: 2, Valid: true}, TagID: 1, Position: 0},
+		}},
+		merged: &model.Database{Note: []*model.Note{
+			nil,
+			{NoteID: 1, Title: sql.NullString{String: "B", Valid: true}},
+			{NoteID: 2, Title: sql.NullString{String: "A", Valid: true}},
+		}},
+	}
+
+	assert.NoError(t, dbw.MergeTagMaps())
+	assert.Equal(t, int32(1), dbw.merged.TagMap[1].NoteID.Int32)
+
+	assert.NoError(t, dbw.SetTagMapOrder("noteTitle"))
+	assert.NoError(t, dbw.MergeTagMaps())
+	assert.Equal(t, int32(2), dbw.merged.TagMap[1].NoteID.Int32)
+
+	assert.NoError(t, dbw.SetTagMapOrder("rightFirst"))
+	assert.NoError(t, dbw.MergeTagMaps())
+	assert.Equal(t, int32(2), dbw.merged.TagMap[1].NoteID.Int32)
+
+	assert.Error(t, dbw.SetTagMapOrder("random"))
+	assert.Equal(t, merger.OrderRightFirst, dbw.tagMapOrder)
+}
+
+func TestDatabaseWrapper_SetEquivalentEdition(t *testing.T) {
+	newDBW := func() *DatabaseWrapper {
+		return &DatabaseWrapper{
+			leftTmp: &model.Database{Location: []*model.Location{
+				nil,
+				{
+					LocationID:    1,
+					BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+					ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+					KeySymbol:     sql.NullString{String: "bi12", Valid: true},
+				},
+			}},
+			rightTmp: &model.Database{Location: []*model.Location{
+				nil,
+				{
+					LocationID:    1,
+					BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+					ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+					KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				},
+			}},
+			merged: &model.Database{},
+		}
+	}
+
+	dbw := newDBW()
+	assert.NoError(t, dbw.MergeLocations())
+	assert.Len(t, dbw.merged.Location, 3)
+
+	dbw = newDBW()
+	dbw.SetEquivalentEdition("bi12", "nwtsty")
+	assert.NoError(t, dbw.MergeLocations())
+	assert.Len(t, dbw.merged.Location, 2)
+
+	dbw.SetEquivalentEdition("bi12", "")
+	assert.Empty(t, dbw.editions)
+}
+
 // Merge while selecting all right
 func Test_MergeAllRight(t *testing.T) {
 	dbw := DatabaseWrapper{
@@ -1006,3 +1082,39 @@ var mergedAllRightDB = &model.Database{
 		},
 	},
 }
+
+// Merging the same inputs with the same resolutions should
+// result in byte-identical backups
+func Test_MergeReproducible(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	os.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	var backups [][]byte
+	for i := 0; i < 3; i++ {
+		dbw := DatabaseWrapper{
+			left:  model.MakeDatabaseCopy(leftMultiCollision),
+			right: model.MakeDatabaseCopy(rightMultiCollision),
+		}
+		dbw.Init()
+
+		mcw := &MergeConflictsWrapper{}
+		assert.NoError(t, dbw.MergeLocations())
+		assert.NoError(t, dbw.MergeBookmarks("chooseRight", mcw))
+		assert.NoError(t, dbw.MergeTags())
+		assert.NoError(t, dbw.MergeUserMarkAndBlockRange("chooseRight", mcw))
+		assert.NoError(t, dbw.MergeNotes("chooseRight", mcw))
+		assert.NoError(t, dbw.MergeTagMaps())
+
+		filename := filepath.Join(tmp, fmt.Sprintf("merged_%d.jwlibrary", i))
+		assert.NoError(t, dbw.ExportMerged(filename))
+		backup, err := ioutil.ReadFile(filename)
+		assert.NoError(t, err)
+		backups = append(backups, backup)
+	}
+
+	assert.Equal(t, backups[0], backups[1])
+	assert.Equal(t, backups[0], backups[2])
+}
@@ -0,0 +1,66 @@
+package gomobile
+
+import (
+	"math"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+)
+
+// Names of the stages of a merge, as they are reported to a ProgressListener
+const (
+	StageLocations = merger.StageLocations
+	StageBookmarks = merger.StageBookmarks
+	StageTags      = merger.StageTags
+	StageMarkings  = merger.StageMarkings
+	StageNotes     = merger.StageNotes
+	StageTagMaps   = merger.StageTagMaps
+)
+
+// ProgressListener receives updates about the progress of a merge. It can
+// be implemented in Swift or Kotlin to show a progress indicator.
+type ProgressListener interface {
+	// OnProgress is called when a stage of the merge starts or has been
+	// finished. percent indicates the progress of the whole merge.
+	OnProgress(stage string, percent int)
+}
+
+// SetProgressListener sets the ProgressListener that should be informed
+// about the progress of subsequent merge functions. Passing nil disables
+// progress reporting.
+func (dbw *DatabaseWrapper) SetProgressListener(listener ProgressListener) {
+	dbw.progressListener = listener
+}
+
+// RemainingSeconds estimates the number of seconds until the running
+// merge is finished (see merger.ETA), so apps can show it together with
+// the progress, like within ProgressListener.OnProgress. It returns 0
+// if Init has not been called yet.
+func (dbw *DatabaseWrapper) RemainingSeconds() int {
+	if dbw.eta == nil {
+		return 0
+	}
+	return int(math.Ceil(dbw.eta.Remaining().Seconds()))
+}
+
+// reportProgress informs the ProgressListener (if set) about the start
+// or the end of the given stage.
+func (dbw *DatabaseWrapper) reportProgress(stage string, finished bool) {
+	if dbw.eta != nil {
+		dbw.eta.Progress(stage, finished)
+	}
+
+	if dbw.progressListener == nil {
+		return
+	}
+
+	for i, s := range merger.Stages {
+		if s != stage {
+			continue
+		}
+		if finished {
+			i++
+		}
+		dbw.progressListener.OnProgress(stage, i*100/len(merger.Stages))
+		return
+	}
+}
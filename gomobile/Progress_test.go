@@ -0,0 +1,91 @@
+//go:build !windows
+// +build !windows
+
+package gomobile
+
+import (
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/tj/assert"
+)
+
+type progressUpdate struct {
+	stage   string
+	percent int
+}
+
+type recordingListener struct {
+	updates []progressUpdate
+}
+
+func (l *recordingListener) OnProgress(stage string, percent int) {
+	l.updates = append(l.updates, progressUpdate{stage, percent})
+}
+
+func TestDatabaseWrapper_SetProgressListener(t *testing.T) {
+	dbw := DatabaseWrapper{
+		left:  model.MakeDatabaseCopy(leftDB),
+		right: model.MakeDatabaseCopy(emptyDB),
+	}
+	dbw.Init()
+
+	listener := &recordingListener{}
+	dbw.SetProgressListener(listener)
+
+	mcw := &MergeConflictsWrapper{}
+	assert.NoError(t, dbw.MergeLocations())
+	assert.NoError(t, dbw.MergeBookmarks("", mcw))
+	assert.NoError(t, dbw.MergeTags())
+	assert.NoError(t, dbw.MergeUserMarkAndBlockRange("", mcw))
+	assert.NoError(t, dbw.MergeNotes("", mcw))
+	assert.NoError(t, dbw.MergeTagMaps())
+
+	assert.Equal(t, []progressUpdate{
+		{StageLocations, 0}, {StageLocations, 16},
+		{StageBookmarks, 16}, {StageBookmarks, 33},
+		{StageTags, 33}, {StageTags, 50},
+		{StageMarkings, 50}, {StageMarkings, 66},
+		{StageNotes, 66}, {StageNotes, 83},
+		{StageTagMaps, 83}, {StageTagMaps, 100},
+	}, listener.updates)
+
+	// A conflict should not report the stage as finished
+	dbw = DatabaseWrapper{
+		left:  model.MakeDatabaseCopy(leftDB),
+		right: model.MakeDatabaseCopy(rightDB),
+	}
+	dbw.Init()
+	listener = &recordingListener{}
+	dbw.SetProgressListener(listener)
+	assert.NoError(t, dbw.MergeLocations())
+	assert.Error(t, dbw.MergeBookmarks("", &MergeConflictsWrapper{}))
+	assert.Equal(t, []progressUpdate{
+		{StageLocations, 0}, {StageLocations, 16},
+		{StageBookmarks, 16},
+	}, listener.updates)
+
+	dbw.SetProgressListener(nil)
+	assert.NoError(t, dbw.MergeLocations())
+	assert.Len(t, listener.updates, 3)
+}
+
+func TestDatabaseWrapper_RemainingSeconds(t *testing.T) {
+	dbw := DatabaseWrapper{
+		left:  model.MakeDatabaseCopy(leftDB),
+		right: model.MakeDatabaseCopy(emptyDB),
+	}
+	assert.Equal(t, 0, dbw.RemainingSeconds())
+
+	dbw.Init()
+	assert.Greater(t, dbw.RemainingSeconds(), 0)
+
+	mcw := &MergeConflictsWrapper{}
+	assert.NoError(t, dbw.MergeLocations())
+	assert.NoError(t, dbw.MergeBookmarks("", mcw))
+	assert.NoError(t, dbw.MergeTags())
+	assert.NoError(t, dbw.MergeUserMarkAndBlockRange("", mcw))
+	assert.NoError(t, dbw.MergeNotes("", mcw))
+	assert.NoError(t, dbw.MergeTagMaps())
+	assert.Equal(t, 0, dbw.RemainingSeconds())
+}
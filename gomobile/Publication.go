@@ -1,29 +1,135 @@
 package gomobile
 
 import (
+	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/AndreasSko/go-jwlm/publication"
 )
 
 // PublicationLookup represents a lookup for a publication.
-// It directly maps to publication.Lookup
+// It directly maps to publication.Lookup, but only allows
+// to fall back to English publications.
 type PublicationLookup struct {
-	DocumentID     int
-	KeySymbol      string
-	IssueTagNumber int
-	MepsLanguage   int
+	DocumentID        int
+	KeySymbol         string
+	IssueTagNumber    int
+	MepsLanguage      int
+	FallbackToEnglish bool
+}
+
+// LookupManager runs lookups on the catalogDB, which can be canceled
+// (e.g. if the user navigates away) or limited by a timeout.
+type LookupManager struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+// NewLookupManager creates a new LookupManager. If timeoutMillis is greater
+// than zero, each lookup is canceled after the given number of milliseconds.
+func NewLookupManager(timeoutMillis int) *LookupManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &LookupManager{
+		ctx:     ctx,
+		cancel:  cancel,
+		timeout: time.Duration(timeoutMillis) * time.Millisecond,
+	}
+}
+
+// Cancel cancels all running lookups of the LookupManager. Subsequent
+// lookups will fail immediately.
+func (lm *LookupManager) Cancel() {
+	lm.cancel()
+}
+
+// LookupPublication looks up a publication like the LookupPublication
+// function, but can be canceled using the LookupManager.
+func (lm *LookupManager) LookupPublication(dbPath string, query *PublicationLookup) string {
+	ctx, cancel := lm.newContext()
+	defer cancel()
+	return lookupPublication(ctx, dbPath, query)
+}
+
+// SearchPublications searches for publications like the SearchPublications
+// function, but can be canceled using the LookupManager.
+func (lm *LookupManager) SearchPublications(dbPath string, term string) string {
+	ctx, cancel := lm.newContext()
+	defer cancel()
+	return searchPublications(ctx, dbPath, term)
+}
+
+// LookupDocument looks up a document like the LookupDocument
+// function, but can be canceled using the LookupManager.
+func (lm *LookupManager) LookupDocument(dbPath string, query *PublicationLookup) string {
+	ctx, cancel := lm.newContext()
+	defer cancel()
+	return lookupDocument(ctx, dbPath, query)
+}
+
+// newContext creates a context for a single lookup that respects
+// the timeout of the LookupManager
+func (lm *LookupManager) newContext() (context.Context, context.CancelFunc) {
+	if lm.timeout > 0 {
+		return context.WithTimeout(lm.ctx, lm.timeout)
+	}
+	return context.WithCancel(lm.ctx)
 }
 
 // LookupPublication looks up a publication from catalogDB located at dbPath
 // and returns a JSON string representing the Publication
 func LookupPublication(dbPath string, query *PublicationLookup) string {
-	result, err := publication.LookupPublication(dbPath, publication.Lookup{
-		DocumentID:     query.DocumentID,
-		KeySymbol:      query.KeySymbol,
-		IssueTagNumber: query.IssueTagNumber,
-		MepsLanguage:   query.MepsLanguage,
-	})
+	return lookupPublication(context.Background(), dbPath, query)
+}
+
+// SearchPublications searches the catalogDB located at dbPath for publications
+// whose titles contain term and returns a JSON string representing the
+// slice of found Publications
+func SearchPublications(dbPath string, term string) string {
+	return searchPublications(context.Background(), dbPath, term)
+}
+
+// LookupDocument looks up a document from catalogDB located at dbPath
+// and returns a JSON string representing the Document
+func LookupDocument(dbPath string, query *PublicationLookup) string {
+	return lookupDocument(context.Background(), dbPath, query)
+}
+
+func lookupPublication(ctx context.Context, dbPath string, query *PublicationLookup) string {
+	result, err := publication.LookupPublicationContext(ctx, dbPath, query.toLookup())
+	if err != nil {
+		return ""
+	}
+
+	jsn, err := json.Marshal(result)
+	if err != nil {
+		return ""
+	}
+
+	return string(jsn)
+}
+
+func searchPublications(ctx context.Context, dbPath string, term string) string {
+	result, err := publication.SearchContext(ctx, dbPath, term)
+	if err != nil {
+		return ""
+	}
+
+	jsn, err := json.Marshal(result)
+	if err != nil {
+		return ""
+	}
+
+	return string(jsn)
+}
+
+func lookupDocument(ctx context.Context, dbPath string, query *PublicationLookup) string {
+	lookup := query.toLookup()
+	lookup.KeySymbol = ""
+	lookup.IssueTagNumber = 0
+
+	result, err := publication.LookupDocumentContext(ctx, dbPath, lookup)
 	if err != nil {
 		return ""
 	}
@@ -35,3 +141,34 @@ func LookupPublication(dbPath string, query *PublicationLookup) string {
 
 	return string(jsn)
 }
+
+// toLookup converts the PublicationLookup to a publication.Lookup
+func (query *PublicationLookup) toLookup() publication.Lookup {
+	lookup := publication.Lookup{
+		DocumentID:     query.DocumentID,
+		KeySymbol:      query.KeySymbol,
+		IssueTagNumber: query.IssueTagNumber,
+		MepsLanguage:   query.MepsLanguage,
+	}
+	if query.FallbackToEnglish {
+		lookup.FallbackLanguages = []int{publication.EnglishMepsLanguage}
+	}
+
+	return lookup
+}
+
+// BibleBookName returns the English name of the Bible book with the given
+// number using the offline catalog. If it can't be found, it returns an
+// empty string.
+func BibleBookName(bookNumber int) string {
+	name, _ := publication.BibleBookName(bookNumber)
+	return name
+}
+
+// OfflinePublicationTitle returns the English title of the publication with
+// the given KeySymbol using the offline catalog. If it can't be found, it
+// returns an empty string.
+func OfflinePublicationTitle(keySymbol string) string {
+	title, _ := publication.OfflinePublicationTitle(keySymbol)
+	return title
+}
@@ -1,3 +1,4 @@
+//go:build !windows
 // +build !windows
 
 package gomobile
@@ -50,6 +51,14 @@ func TestLookupPublication(t *testing.T) {
 			},
 			expected: `{"id":305097,"publicationRootKeyId":780,"mepsLanguageId":0,"publicationTypeId":14,"issueTagNumber":20210200,"title":"The Watchtower Announcing Jehovah’s Kingdom (Study)—2021","issueTitle":"The Watchtower, February 2021","shortTitle":"The Watchtower (Study) (2021)","coverTitle":"Study Articles for April 5 to May 2","undatedTitle":"The Watchtower—Study Edition","undatedReferenceTitle":"The Watchtower (Study)","year":2021,"symbol":"w21","keySymbol":"w","reserved":0}`,
 		},
+		{
+			input: &PublicationLookup{
+				DocumentID:        1102002020,
+				MepsLanguage:      1,
+				FallbackToEnglish: true,
+			},
+			expected: `{"id":67,"publicationRootKeyId":64,"mepsLanguageId":0,"publicationTypeId":2,"issueTagNumber":0,"title":"Draw Close to Jehovah","issueTitle":"","shortTitle":"Close to Jehovah","coverTitle":"","undatedTitle":"","undatedReferenceTitle":"Close to Jehovah","year":2014,"symbol":"cl","keySymbol":"cl","reserved":0}`,
+		},
 	}
 
 	path := filepath.Join("../publication/testdata", "catalog.db")
@@ -58,3 +67,45 @@ func TestLookupPublication(t *testing.T) {
 		assert.Equal(t, test.expected, res)
 	}
 }
+
+func TestSearchPublications(t *testing.T) {
+	path := filepath.Join("../publication/testdata", "catalog.db")
+
+	res := SearchPublications(path, "Draw Close")
+	assert.Equal(t, `[{"id":67,"publicationRootKeyId":64,"mepsLanguageId":0,"publicationTypeId":2,"issueTagNumber":0,"title":"Draw Close to Jehovah","issueTitle":"","shortTitle":"Close to Jehovah","coverTitle":"","undatedTitle":"","undatedReferenceTitle":"Close to Jehovah","year":2014,"symbol":"cl","keySymbol":"cl","reserved":0}]`, res)
+
+	assert.Equal(t, "[]", SearchPublications(path, "Pure Worship"))
+	assert.Equal(t, "", SearchPublications("nonexistent.db", "Pure Worship"))
+}
+
+func TestLookupDocument(t *testing.T) {
+	path := filepath.Join("../publication/testdata", "catalog.db")
+
+	// The test catalog does not contain any documents
+	assert.Equal(t, "", LookupDocument(path, &PublicationLookup{DocumentID: 1102002020}))
+}
+
+func TestLookupManager(t *testing.T) {
+	path := filepath.Join("../publication/testdata", "catalog.db")
+
+	lm := NewLookupManager(0)
+	res := lm.LookupPublication(path, &PublicationLookup{KeySymbol: "cl"})
+	assert.Contains(t, res, `"id":67`)
+	assert.Contains(t, lm.SearchPublications(path, "Jehovah"), `"id":305097`)
+	assert.Equal(t, "", lm.LookupDocument(path, &PublicationLookup{DocumentID: 1102002020}))
+
+	lm = NewLookupManager(5000)
+	res = lm.LookupPublication(path, &PublicationLookup{KeySymbol: "cl"})
+	assert.Contains(t, res, `"id":67`)
+
+	lm.Cancel()
+	assert.Equal(t, "", lm.LookupPublication(path, &PublicationLookup{KeySymbol: "cl"}))
+	assert.Equal(t, "", lm.SearchPublications(path, "Jehovah"))
+}
+
+func TestOfflineCatalog(t *testing.T) {
+	assert.Equal(t, "Genesis", BibleBookName(1))
+	assert.Equal(t, "", BibleBookName(100))
+	assert.Equal(t, "Awake!", OfflinePublicationTitle("g"))
+	assert.Equal(t, "", OfflinePublicationTitle("nonexistent"))
+}
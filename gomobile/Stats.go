@@ -24,10 +24,9 @@ func (dbw *DatabaseWrapper) Stats(side string) *DatabaseStats {
 	var db *model.Database
 
 	switch side {
-	case "leftSide":
-		db = dbw.left
-	case "rightSide":
-		db = dbw.right
+	case "leftSide", "rightSide":
+		// Spilled databases that can't be read count as empty
+		db, _ = dbw.original(side)
 	case "mergeSide":
 		db = dbw.merged
 	default:
@@ -0,0 +1,345 @@
+// Package grpcserver implements the MergeService of proto/jwlm/v1, so
+// frontends written in any language can merge backups using gRPC. Every
+// session wraps a gomobile.DatabaseWrapper and follows its merge lifecycle:
+//
+//	s := grpc.NewServer()
+//	jwlmv1.RegisterMergeServiceServer(s, grpcserver.NewServer())
+//	s.Serve(listener)
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"sync"
+
+	"github.com/AndreasSko/go-jwlm/gomobile"
+	jwlmv1 "github.com/AndreasSko/go-jwlm/proto/jwlm/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chunkSize is the size of the chunks the merged backup is downloaded in
+const chunkSize = 64 * 1024
+
+// Server implements jwlmv1.MergeServiceServer
+type Server struct {
+	jwlmv1.UnimplementedMergeServiceServer
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// session holds the backups and the merge of a single client
+type session struct {
+	mu  sync.Mutex
+	dbw *gomobile.DatabaseWrapper
+	// merge is the running or last merge of the session,
+	// and finished is closed as soon as it has finished
+	merge    *gomobile.MergeSession
+	finished chan struct{}
+	// merged indicates if the last merge succeeded, so it can be downloaded
+	merged bool
+}
+
+// NewServer returns a Server without any sessions
+func NewServer() *Server {
+	return &Server{sessions: map[string]*session{}}
+}
+
+// CreateSession starts a new merge session
+func (s *Server) CreateSession(ctx context.Context, req *jwlmv1.CreateSessionRequest) (*jwlmv1.Session, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, status.Errorf(codes.Internal, "Error while generating session id: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess := &jwlmv1.Session{Id: hex.EncodeToString(id)}
+	s.sessions[sess.Id] = &session{dbw: &gomobile.DatabaseWrapper{}}
+
+	return sess, nil
+}
+
+// UploadBackup imports the uploaded backup on the given side of the session
+func (s *Server) UploadBackup(stream jwlmv1.MergeService_UploadBackupServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	sess, err := s.session(first.SessionId)
+	if err != nil {
+		return err
+	}
+	side, err := mergeSide(first.Side)
+	if err != nil {
+		return err
+	}
+
+	backup := bytes.NewBuffer(first.Chunk)
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		backup.Write(req.Chunk)
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.running() {
+		return status.Error(codes.FailedPrecondition, "Backups can not be uploaded while merging")
+	}
+	if err := sess.dbw.ImportJWLBackupFromBytes(backup.Bytes(), side); err != nil {
+		return status.Errorf(codes.InvalidArgument, "Error while importing backup: %v", err)
+	}
+	sess.merged = false
+
+	return stream.SendAndClose(&jwlmv1.UploadBackupResponse{Stats: statsMap(sess.dbw.Stats(side))})
+}
+
+// Merge merges the uploaded backups and streams the progress and the
+// conflicts to the client. If the client goes away, the merge is canceled.
+func (s *Server) Merge(req *jwlmv1.MergeRequest, stream jwlmv1.MergeService_MergeServer) error {
+	sess, err := s.session(req.SessionId)
+	if err != nil {
+		return err
+	}
+	resolver, err := conflictSolver(req.Resolver)
+	if err != nil {
+		return err
+	}
+
+	listener := &streamListener{
+		events:   make(chan *jwlmv1.MergeEvent),
+		done:     make(chan string, 1),
+		finished: make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	sess.mu.Lock()
+	if !sess.dbw.DBIsLoaded("leftSide") || !sess.dbw.DBIsLoaded("rightSide") {
+		sess.mu.Unlock()
+		return status.Error(codes.FailedPrecondition, "Both backups need to be uploaded before merging")
+	}
+	if sess.running() {
+		sess.mu.Unlock()
+		return status.Error(codes.FailedPrecondition, "A merge is already running")
+	}
+	sess.merged = false
+	sess.merge = sess.dbw.StartMerge(resolver, listener)
+	sess.finished = listener.finished
+	merge := sess.merge
+	sess.mu.Unlock()
+
+	defer close(listener.stop)
+	for {
+		select {
+		case event := <-listener.events:
+			if err := stream.Send(event); err != nil {
+				merge.Cancel()
+				return err
+			}
+		case msg := <-listener.done:
+			if msg != "" {
+				return status.Errorf(codes.Aborted, "Error while merging: %s", msg)
+			}
+			sess.mu.Lock()
+			sess.merged = true
+			stats := statsMap(sess.dbw.Stats("mergeSide"))
+			sess.mu.Unlock()
+			return stream.Send(&jwlmv1.MergeEvent{
+				Event: &jwlmv1.MergeEvent_Finished{Finished: &jwlmv1.Finished{Stats: stats}},
+			})
+		case <-stream.Context().Done():
+			merge.Cancel()
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// Resolve resolves the conflict that has been streamed last
+func (s *Server) Resolve(ctx context.Context, req *jwlmv1.ResolveRequest) (*jwlmv1.ResolveResponse, error) {
+	sess, err := s.session(req.SessionId)
+	if err != nil {
+		return nil, err
+	}
+	side, err := mergeSide(req.Side)
+	if err != nil {
+		return nil, err
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if !sess.running() {
+		return nil, status.Error(codes.FailedPrecondition, "There is no running merge")
+	}
+	if err := sess.merge.SolveConflict(req.Key, side); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return &jwlmv1.ResolveResponse{}, nil
+}
+
+// DownloadBackup exports the merged backup and sends it in chunks
+func (s *Server) DownloadBackup(req *jwlmv1.DownloadBackupRequest, stream jwlmv1.MergeService_DownloadBackupServer) error {
+	sess, err := s.session(req.SessionId)
+	if err != nil {
+		return err
+	}
+
+	sess.mu.Lock()
+	if !sess.merged {
+		sess.mu.Unlock()
+		return status.Error(codes.FailedPrecondition, "The backups have not been merged yet")
+	}
+	backup, err := sess.dbw.ExportMergedToBytes()
+	sess.mu.Unlock()
+	if err != nil {
+		return status.Errorf(codes.Internal, "Error while exporting merged backup: %v", err)
+	}
+
+	for len(backup) > 0 {
+		n := chunkSize
+		if len(backup) < n {
+			n = len(backup)
+		}
+		if err := stream.Send(&jwlmv1.DownloadBackupResponse{Chunk: backup[:n]}); err != nil {
+			return err
+		}
+		backup = backup[n:]
+	}
+
+	return nil
+}
+
+// CloseSession cancels a running merge and removes the session
+func (s *Server) CloseSession(ctx context.Context, req *jwlmv1.CloseSessionRequest) (*jwlmv1.CloseSessionResponse, error) {
+	sess, err := s.session(req.SessionId)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	delete(s.sessions, req.SessionId)
+	s.mu.Unlock()
+
+	// The merge has to be finished before its databases are closed
+	sess.mu.Lock()
+	merge, finished := sess.merge, sess.finished
+	sess.mu.Unlock()
+	if merge != nil {
+		merge.Cancel()
+		<-finished
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if err := sess.dbw.Close(); err != nil {
+		return nil, status.Errorf(codes.Internal, "Error while closing session: %v", err)
+	}
+
+	return &jwlmv1.CloseSessionResponse{}, nil
+}
+
+// session returns the session with the given id
+func (s *Server) session(id string) (*session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Session %s does not exist", id)
+	}
+	return sess, nil
+}
+
+// running indicates if a merge of the session is running.
+// The caller has to hold sess.mu.
+func (sess *session) running() bool {
+	return sess.merge != nil && !sess.merge.Done()
+}
+
+// streamListener implements gomobile.MergeListener and hands the
+// progress and the conflicts of a merge over to the Merge stream
+type streamListener struct {
+	events chan *jwlmv1.MergeEvent
+	// done receives the error message of the merge, and
+	// finished is closed afterwards
+	done     chan string
+	finished chan struct{}
+	// stop is closed as soon as nobody receives events anymore
+	stop chan struct{}
+}
+
+func (l *streamListener) OnProgress(stage string, percent int) {
+	l.send(&jwlmv1.MergeEvent{
+		Event: &jwlmv1.MergeEvent_Progress{Progress: &jwlmv1.Progress{Stage: stage, Percent: int32(percent)}},
+	})
+}
+
+func (l *streamListener) OnConflict(conflict *gomobile.MergeConflict) {
+	l.send(&jwlmv1.MergeEvent{
+		Event: &jwlmv1.MergeEvent_Conflict{Conflict: &jwlmv1.Conflict{
+			Key:   conflict.Key,
+			Left:  &jwlmv1.ConflictSide{Json: conflict.Left, Device: conflict.LeftDevice},
+			Right: &jwlmv1.ConflictSide{Json: conflict.Right, Device: conflict.RightDevice},
+		}},
+	})
+}
+
+func (l *streamListener) OnDone(err string) {
+	l.done <- err
+	close(l.finished)
+}
+
+func (l *streamListener) send(event *jwlmv1.MergeEvent) {
+	select {
+	case l.events <- event:
+	case <-l.stop:
+	}
+}
+
+// mergeSide converts a jwlmv1.Side to the sides used by gomobile
+func mergeSide(side jwlmv1.Side) (string, error) {
+	switch side {
+	case jwlmv1.Side_SIDE_LEFT:
+		return "leftSide", nil
+	case jwlmv1.Side_SIDE_RIGHT:
+		return "rightSide", nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "Side %s is not valid", side)
+}
+
+// conflictSolver converts a jwlmv1.Resolver to the name of
+// the resolver used by merger.AutoResolveConflicts
+func conflictSolver(resolver jwlmv1.Resolver) (string, error) {
+	switch resolver {
+	case jwlmv1.Resolver_RESOLVER_UNSPECIFIED:
+		return "", nil
+	case jwlmv1.Resolver_RESOLVER_CHOOSE_LEFT:
+		return "chooseLeft", nil
+	case jwlmv1.Resolver_RESOLVER_CHOOSE_RIGHT:
+		return "chooseRight", nil
+	case jwlmv1.Resolver_RESOLVER_CHOOSE_NEWEST:
+		return "chooseNewest", nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "Resolver %s is not valid", resolver)
+}
+
+// statsMap converts gomobile.DatabaseStats to the stats of the messages
+func statsMap(stats *gomobile.DatabaseStats) map[string]int64 {
+	return map[string]int64{
+		"BlockRange": int64(stats.BlockRange),
+		"Bookmark":   int64(stats.Bookmark),
+		"Location":   int64(stats.Location),
+		"Note":       int64(stats.Note),
+		"Tag":        int64(stats.Tag),
+		"TagMap":     int64(stats.TagMap),
+		"UserMark":   int64(stats.UserMark),
+	}
+}
@@ -0,0 +1,277 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	jwlmv1 "github.com/AndreasSko/go-jwlm/proto/jwlm/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient starts a Server listening on an in-memory
+// connection and returns a client connected to it
+func newTestClient(t *testing.T) jwlmv1.MergeServiceClient {
+	l := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	jwlmv1.RegisterMergeServiceServer(s, NewServer())
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return jwlmv1.NewMergeServiceClient(conn)
+}
+
+// upload uploads the given database as backup in small chunks
+func upload(t *testing.T, client jwlmv1.MergeServiceClient, sessionID string, side jwlmv1.Side, db *model.Database) (*jwlmv1.UploadBackupResponse, error) {
+	backup, err := db.ExportJWLBackupToBytes()
+	require.NoError(t, err)
+
+	stream, err := client.UploadBackup(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&jwlmv1.UploadBackupRequest{SessionId: sessionID, Side: side}))
+	for len(backup) > 0 {
+		n := 1000
+		if len(backup) < n {
+			n = len(backup)
+		}
+		require.NoError(t, stream.Send(&jwlmv1.UploadBackupRequest{Chunk: backup[:n]}))
+		backup = backup[n:]
+	}
+	return stream.CloseAndRecv()
+}
+
+// download downloads the merged backup of the session
+func download(t *testing.T, client jwlmv1.MergeServiceClient, sessionID string) ([]byte, error) {
+	stream, err := client.DownloadBackup(context.Background(), &jwlmv1.DownloadBackupRequest{SessionId: sessionID})
+	require.NoError(t, err)
+
+	backup := &bytes.Buffer{}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return backup.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		backup.Write(resp.Chunk)
+	}
+}
+
+func TestServer(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	session, err := client.CreateSession(ctx, &jwlmv1.CreateSessionRequest{})
+	require.NoError(t, err)
+
+	// Merging and downloading need both backups
+	_, err = download(t, client, session.Id)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	resp, err := upload(t, client, session.Id, jwlmv1.Side_SIDE_LEFT, leftConflict)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Stats["UserMark"])
+	merge, err := client.Merge(ctx, &jwlmv1.MergeRequest{SessionId: session.Id})
+	require.NoError(t, err)
+	_, err = merge.Recv()
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = upload(t, client, session.Id, jwlmv1.Side_SIDE_RIGHT, rightConflict)
+	require.NoError(t, err)
+
+	merge, err = client.Merge(ctx, &jwlmv1.MergeRequest{SessionId: session.Id})
+	require.NoError(t, err)
+	progress := 0
+	conflicts := 0
+	var finished *jwlmv1.Finished
+	for finished == nil {
+		event, err := merge.Recv()
+		require.NoError(t, err)
+		switch e := event.Event.(type) {
+		case *jwlmv1.MergeEvent_Progress:
+			progress++
+		case *jwlmv1.MergeEvent_Conflict:
+			conflicts++
+			assert.Contains(t, e.Conflict.Left.Json, `"model"`)
+			assert.Contains(t, e.Conflict.Right.Json, `"related"`)
+			_, err = client.Resolve(ctx, &jwlmv1.ResolveRequest{SessionId: session.Id, Key: "wrong", Side: jwlmv1.Side_SIDE_RIGHT})
+			assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+			_, err = client.Resolve(ctx, &jwlmv1.ResolveRequest{SessionId: session.Id, Key: e.Conflict.Key})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			_, err = client.Resolve(ctx, &jwlmv1.ResolveRequest{SessionId: session.Id, Key: e.Conflict.Key, Side: jwlmv1.Side_SIDE_RIGHT})
+			assert.NoError(t, err)
+		case *jwlmv1.MergeEvent_Finished:
+			finished = e.Finished
+		}
+	}
+	assert.Equal(t, 1, conflicts)
+	assert.Equal(t, 12, progress)
+	assert.Equal(t, int64(1), finished.Stats["UserMark"])
+
+	backup, err := download(t, client, session.Id)
+	require.NoError(t, err)
+	merged := &model.Database{}
+	require.NoError(t, merged.ImportJWLBackupFromBytes(backup))
+	assert.True(t, merged.Equals(rightConflict))
+
+	_, err = client.CloseSession(ctx, &jwlmv1.CloseSessionRequest{SessionId: session.Id})
+	assert.NoError(t, err)
+	_, err = download(t, client, session.Id)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServer_Merge_resolver(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	session, err := client.CreateSession(ctx, &jwlmv1.CreateSessionRequest{})
+	require.NoError(t, err)
+	_, err = upload(t, client, session.Id, jwlmv1.Side_SIDE_LEFT, leftConflict)
+	require.NoError(t, err)
+	_, err = upload(t, client, session.Id, jwlmv1.Side_SIDE_RIGHT, rightConflict)
+	require.NoError(t, err)
+
+	merge, err := client.Merge(ctx, &jwlmv1.MergeRequest{SessionId: session.Id, Resolver: jwlmv1.Resolver(42)})
+	require.NoError(t, err)
+	_, err = merge.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	merge, err = client.Merge(ctx, &jwlmv1.MergeRequest{SessionId: session.Id, Resolver: jwlmv1.Resolver_RESOLVER_CHOOSE_LEFT})
+	require.NoError(t, err)
+	for {
+		event, err := merge.Recv()
+		require.NoError(t, err)
+		assert.Nil(t, event.GetConflict())
+		if event.GetFinished() != nil {
+			break
+		}
+	}
+
+	backup, err := download(t, client, session.Id)
+	require.NoError(t, err)
+	merged := &model.Database{}
+	require.NoError(t, merged.ImportJWLBackupFromBytes(backup))
+	assert.True(t, merged.Equals(leftConflict))
+}
+
+func TestServer_CloseSession(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	session, err := client.CreateSession(ctx, &jwlmv1.CreateSessionRequest{})
+	require.NoError(t, err)
+	_, err = upload(t, client, session.Id, jwlmv1.Side_SIDE_LEFT, leftConflict)
+	require.NoError(t, err)
+	_, err = upload(t, client, session.Id, jwlmv1.Side_SIDE_RIGHT, rightConflict)
+	require.NoError(t, err)
+
+	// Closing the session cancels the merge waiting for a resolution
+	merge, err := client.Merge(ctx, &jwlmv1.MergeRequest{SessionId: session.Id})
+	require.NoError(t, err)
+	for {
+		event, err := merge.Recv()
+		require.NoError(t, err)
+		if event.GetConflict() != nil {
+			break
+		}
+	}
+	_, err = upload(t, client, session.Id, jwlmv1.Side_SIDE_LEFT, leftConflict)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = client.CloseSession(ctx, &jwlmv1.CloseSessionRequest{SessionId: session.Id})
+	assert.NoError(t, err)
+	_, err = merge.Recv()
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = client.CloseSession(ctx, &jwlmv1.CloseSessionRequest{SessionId: session.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	merge, err = client.Merge(ctx, &jwlmv1.MergeRequest{SessionId: session.Id})
+	require.NoError(t, err)
+	_, err = merge.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// leftConflict and rightConflict contain overlapping markings of the same
+// verse, so merging them results in a conflict
+var leftConflict = &model.Database{
+	BlockRange: []*model.BlockRange{
+		nil,
+		{
+			BlockRangeID: 1,
+			BlockType:    1,
+			Identifier:   1,
+			StartToken:   sql.NullInt32{Int32: 0, Valid: true},
+			EndToken:     sql.NullInt32{Int32: 5, Valid: true},
+			UserMarkID:   1,
+		},
+	},
+	Bookmark: []*model.Bookmark{nil},
+	Location: []*model.Location{
+		nil,
+		{
+			LocationID:    1,
+			BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+			KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			MepsLanguage:  2,
+			Title:         sql.NullString{String: "1. Mose 1", Valid: true},
+		},
+	},
+	Note:   []*model.Note{nil},
+	Tag:    []*model.Tag{nil},
+	TagMap: []*model.TagMap{nil},
+	UserMark: []*model.UserMark{
+		nil,
+		{
+			UserMarkID:   1,
+			ColorIndex:   1,
+			LocationID:   1,
+			StyleIndex:   1,
+			UserMarkGUID: "1L",
+		},
+	},
+}
+
+var rightConflict = &model.Database{
+	BlockRange: []*model.BlockRange{
+		nil,
+		{
+			BlockRangeID: 1,
+			BlockType:    1,
+			Identifier:   1,
+			StartToken:   sql.NullInt32{Int32: 3, Valid: true},
+			EndToken:     sql.NullInt32{Int32: 20, Valid: true},
+			UserMarkID:   1,
+		},
+	},
+	Bookmark: []*model.Bookmark{nil},
+	Location: leftConflict.Location,
+	Note:     []*model.Note{nil},
+	Tag:      []*model.Tag{nil},
+	TagMap:   []*model.TagMap{nil},
+	UserMark: []*model.UserMark{
+		nil,
+		{
+			UserMarkID:   1,
+			ColorIndex:   2,
+			LocationID:   1,
+			StyleIndex:   1,
+			UserMarkGUID: "1R",
+		},
+	},
+}
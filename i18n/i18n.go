@@ -0,0 +1,144 @@
+// Package i18n translates the messages and prompts of go-jwlm. The
+// translations are stored as JSON files mapping the English message
+// to the translated one. German, Spanish, and French are included,
+// further languages can be loaded at runtime using LoadFile.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var (
+	mu      sync.RWMutex
+	builder = catalog.NewBuilder(catalog.Fallback(language.English))
+	current = language.English
+	printer = message.NewPrinter(current, message.Catalog(builder))
+)
+
+func init() {
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		content, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		if err := load(strings.TrimSuffix(file.Name(), ".json"), content); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// T returns the translation of the given message in the current
+// language, formatted with the given arguments like fmt.Sprintf.
+// If there is no translation, the English message is used.
+func T(msg string, args ...interface{}) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return printer.Sprintf(msg, args...)
+}
+
+// SetLanguage sets the language messages are translated to. It accepts
+// BCP 47 tags (like de or pt-BR) as well as POSIX locales (like
+// de_DE.UTF-8). If the language is not supported, the closest
+// supported one is chosen, falling back to English.
+func SetLanguage(lang string) error {
+	tag, err := parseLanguage(lang)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	supported := append([]language.Tag{language.English}, builder.Languages()...)
+	_, i, _ := language.NewMatcher(supported).Match(tag)
+	current = supported[i]
+	printer = message.NewPrinter(current, message.Catalog(builder))
+	return nil
+}
+
+// Language returns the language messages are currently translated to
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.String()
+}
+
+// DetectLanguage returns the language set in the environment, looking
+// at JWLM_LANG, LC_ALL, LC_MESSAGES, and LANG in this order. If none
+// of them is set, it returns an empty string.
+func DetectLanguage() string {
+	for _, env := range []string{"JWLM_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := os.Getenv(env); lang != "" {
+			return lang
+		}
+	}
+	return ""
+}
+
+// LoadFile loads additional translations from the given JSON file. The
+// name of the file determines the language (like pt.json or pt-BR.json).
+// Existing translations for this language are overwritten.
+func LoadFile(filename string) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return errors.Wrap(err, "Error while reading translations")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return load(strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)), content)
+}
+
+// load adds the given translations to the catalog
+func load(lang string, content []byte) error {
+	tag, err := parseLanguage(lang)
+	if err != nil {
+		return err
+	}
+
+	translations := map[string]string{}
+	if err := json.Unmarshal(content, &translations); err != nil {
+		return errors.Wrapf(err, "Error while parsing translations for %s", lang)
+	}
+	for msg, translation := range translations {
+		if err := builder.SetString(tag, msg, translation); err != nil {
+			return errors.Wrapf(err, "Error while adding translation for %s", lang)
+		}
+	}
+	return nil
+}
+
+// parseLanguage parses BCP 47 tags as well as POSIX locales
+func parseLanguage(lang string) (language.Tag, error) {
+	// Remove encoding and modifier of POSIX locales (de_DE.UTF-8@euro)
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "C" || lang == "POSIX" {
+		return language.English, nil
+	}
+
+	tag, err := language.Parse(strings.ReplaceAll(lang, "_", "-"))
+	if err != nil {
+		return language.Und, errors.Wrapf(err, "Language %s is not supported", lang)
+	}
+	return tag, nil
+}
@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestT(t *testing.T) {
+	defer SetLanguage("en")
+
+	assert.Equal(t, "Finished merging!", T("Finished merging!"))
+	assert.Equal(t, "Imported 3 notes!", T("Imported %d notes!", 3))
+
+	tests := []struct {
+		lang     string
+		expected string
+		language string
+	}{
+		{"de", "3 Notizen importiert!", "de"},
+		{"de_DE.UTF-8", "3 Notizen importiert!", "de"},
+		{"es-MX", "¡3 notas importadas!", "es"},
+		{"fr_CA", "3 notes importées !", "fr"},
+		{"C", "Imported 3 notes!", "en"},
+		{"ja", "Imported 3 notes!", "en"},
+	}
+	for _, tt := range tests {
+		assert.NoError(t, SetLanguage(tt.lang))
+		assert.Equal(t, tt.expected, T("Imported %d notes!", 3), tt.lang)
+		assert.Equal(t, tt.language, Language(), tt.lang)
+	}
+
+	// Untranslated messages stay in English
+	assert.NoError(t, SetLanguage("de"))
+	assert.Equal(t, "Something else", T("Something else"))
+
+	assert.Error(t, SetLanguage("not a language"))
+}
+
+func TestLoadFile(t *testing.T) {
+	defer SetLanguage("en")
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "pt.json")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`{"Done.": "Concluído."}`), 0644))
+	assert.NoError(t, LoadFile(filename))
+	assert.NoError(t, SetLanguage("pt_BR.UTF-8"))
+	assert.Equal(t, "pt", Language())
+	assert.Equal(t, "Concluído.", T("Done."))
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`not json`), 0644))
+	assert.Error(t, LoadFile(filename))
+	assert.Error(t, LoadFile(filepath.Join(tmp, "nonexistent.json")))
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for _, env := range []string{"JWLM_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+
+	assert.Equal(t, "", DetectLanguage())
+	os.Setenv("LANG", "fr_FR.UTF-8")
+	assert.Equal(t, "fr_FR.UTF-8", DetectLanguage())
+	os.Setenv("LC_ALL", "es_ES.UTF-8")
+	assert.Equal(t, "es_ES.UTF-8", DetectLanguage())
+	os.Setenv("JWLM_LANG", "de")
+	assert.Equal(t, "de", DetectLanguage())
+}
+
+func TestTranslationsComplete(t *testing.T) {
+	files, err := locales.ReadDir("locales")
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	// All languages should translate the same messages
+	var expected []string
+	for _, file := range files {
+		content, err := locales.ReadFile("locales/" + file.Name())
+		assert.NoError(t, err)
+		translations := map[string]string{}
+		assert.NoError(t, json.Unmarshal(content, &translations))
+		var msgs []string
+		for msg := range translations {
+			msgs = append(msgs, msg)
+		}
+		if expected == nil {
+			expected = msgs
+			continue
+		}
+		assert.ElementsMatch(t, expected, msgs, file.Name())
+	}
+}
@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// csvColumns are the columns ReadCSV understands
+var csvColumns = []string{
+	"title", "content", "tags", "publication", "book",
+	"chapter", "verse", "document", "paragraph", "modified",
+}
+
+// ReadCSV reads notes from a CSV file. The first row must contain the
+// names of the columns, which can be in any order and are matched
+// case-insensitively. The following columns are understood, all of them
+// being optional:
+//
+//	title       - title of the note
+//	content     - content of the note
+//	tags        - tags of the note, separated by semicolons
+//	publication - name of the publication as used in the Mapping
+//	book        - number of the Bible book (1 = Genesis)
+//	chapter     - number of the chapter within the Bible book
+//	verse       - number of the verse the note is attached to
+//	document    - MEPS document ID (overrides the one of the Mapping)
+//	paragraph   - number of the paragraph the note is attached to
+//	modified    - last modification, in RFC 3339 or YYYY-MM-DD format
+func ReadCSV(r io.Reader) ([]ExternalNote, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while reading CSV header")
+	}
+	mapping := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for _, col := range csvColumns {
+			if name == col {
+				mapping[col] = i
+			}
+		}
+	}
+	_, hasTitle := mapping["title"]
+	_, hasContent := mapping["content"]
+	if !hasTitle && !hasContent {
+		return nil, errors.New("CSV file needs at least a title or content column")
+	}
+
+	notes := []ExternalNote{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Error while reading CSV")
+		}
+
+		field := func(col string) string {
+			i, ok := mapping[col]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		var numErr error
+		number := func(col string) int {
+			value := field(col)
+			if value == "" || numErr != nil {
+				return 0
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				numErr = errors.Errorf("Column %s in line %d is not a number: %s", col, line, value)
+			}
+			return n
+		}
+
+		note := ExternalNote{
+			Title:       field("title"),
+			Content:     field("content"),
+			Publication: field("publication"),
+			Book:        number("book"),
+			Chapter:     number("chapter"),
+			Verse:       number("verse"),
+			DocumentID:  number("document"),
+			Paragraph:   number("paragraph"),
+		}
+		if numErr != nil {
+			return nil, numErr
+		}
+		if tags := field("tags"); tags != "" {
+			note.Tags = strings.Split(tags, ";")
+		}
+		if modified := field("modified"); modified != "" {
+			note.LastModified, err = parseCSVTime(modified)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Column modified in line %d is not a valid date", line)
+			}
+		}
+		if note.Title == "" && note.Content == "" {
+			continue
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+// parseCSVTime parses a time in RFC 3339 or YYYY-MM-DD format
+func parseCSVTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,59 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCSV = "\ufeffTitle,Content,Tags,Publication,Book,Chapter,Verse,Document,Paragraph,Modified\n" +
+	`Verse,"Multi-line
+content",Faith;Hope,Study Bible,1,3,15,,,2020-04-14T18:42:58Z
+Paragraph,,,Watchtower,,,,2020124,4,2020-04-14
+Empty,,,,,,,,,
+`
+
+func TestReadCSV(t *testing.T) {
+	notes, err := ReadCSV(strings.NewReader(testCSV))
+	assert.NoError(t, err)
+	assert.Equal(t, []ExternalNote{
+		{
+			Title:        "Verse",
+			Content:      "Multi-line\ncontent",
+			Tags:         []string{"Faith", "Hope"},
+			Publication:  "Study Bible",
+			Book:         1,
+			Chapter:      3,
+			Verse:        15,
+			LastModified: time.Date(2020, 4, 14, 18, 42, 58, 0, time.UTC),
+		},
+		{
+			Title:        "Paragraph",
+			Publication:  "Watchtower",
+			DocumentID:   2020124,
+			Paragraph:    4,
+			LastModified: time.Date(2020, 4, 14, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Title: "Empty",
+		},
+	}, notes)
+
+	notes, err = ReadCSV(strings.NewReader("content,unknown\nOnly content,x\n,\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []ExternalNote{{Content: "Only content"}}, notes)
+
+	_, err = ReadCSV(strings.NewReader("tags,book\n"))
+	assert.EqualError(t, err, "CSV file needs at least a title or content column")
+
+	_, err = ReadCSV(strings.NewReader("title,book\nA,Genesis\n"))
+	assert.EqualError(t, err, "Column book in line 2 is not a number: Genesis")
+
+	_, err = ReadCSV(strings.NewReader("title,modified\nA,yesterday\n"))
+	assert.Error(t, err)
+
+	_, err = ReadCSV(strings.NewReader(""))
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"encoding/xml"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// enexTimeFormat is the format Evernote uses for dates
+const enexTimeFormat = "20060102T150405Z"
+
+type enexExport struct {
+	Notes []enexNote `xml:"note"`
+}
+
+type enexNote struct {
+	Title   string   `xml:"title"`
+	Content string   `xml:"content"`
+	Created string   `xml:"created"`
+	Updated string   `xml:"updated"`
+	Tags    []string `xml:"tag"`
+}
+
+var (
+	enmlLineBreak = regexp.MustCompile(`(?i)<br\s*/?>|</(div|p|li|h[1-6]|tr)>`)
+	enmlTag       = regexp.MustCompile(`<[^>]*>`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+)
+
+// ReadENEX reads notes from an Evernote export (.enex). The content of the
+// notes is converted to plain text, as JW Library does not support any
+// formatting. Attachments are skipped.
+func ReadENEX(r io.Reader) ([]ExternalNote, error) {
+	export := enexExport{}
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	if err := decoder.Decode(&export); err != nil {
+		return nil, errors.Wrap(err, "Error while reading ENEX file")
+	}
+
+	notes := make([]ExternalNote, 0, len(export.Notes))
+	for _, n := range export.Notes {
+		note := ExternalNote{
+			Title:   strings.TrimSpace(n.Title),
+			Content: enmlToText(n.Content),
+			Tags:    n.Tags,
+		}
+		for _, date := range []string{n.Updated, n.Created} {
+			if t, err := time.Parse(enexTimeFormat, date); err == nil {
+				note.LastModified = t
+				break
+			}
+		}
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+// enmlToText converts the ENML content of an Evernote note into plain text
+func enmlToText(enml string) string {
+	text := enmlLineBreak.ReplaceAllString(enml, "\n")
+	text = enmlTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
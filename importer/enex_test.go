@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testENEX = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20200415T080000Z" application="Evernote" version="10.0">
+  <note>
+    <title>Study notes</title>
+    <created>20200413T100000Z</created>
+    <updated>20200414T184258Z</updated>
+    <tag>Faith</tag>
+    <tag>Watchtower</tag>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div>First &amp; foremost</div><div><br/></div><div>Second&nbsp;line</div><ul><li>Item</li></ul></en-note>]]></content>
+  </note>
+  <note>
+    <title>Only created</title>
+    <created>20200413T100000Z</created>
+    <content><![CDATA[<en-note></en-note>]]></content>
+  </note>
+</en-export>
+`
+
+func TestReadENEX(t *testing.T) {
+	notes, err := ReadENEX(strings.NewReader(testENEX))
+	assert.NoError(t, err)
+	assert.Equal(t, []ExternalNote{
+		{
+			Title:        "Study notes",
+			Content:      "First & foremost\n\nSecond line\nItem",
+			Tags:         []string{"Faith", "Watchtower"},
+			LastModified: time.Date(2020, 4, 14, 18, 42, 58, 0, time.UTC),
+		},
+		{
+			Title:        "Only created",
+			LastModified: time.Date(2020, 4, 13, 10, 0, 0, 0, time.UTC),
+		},
+	}, notes)
+
+	_, err = ReadENEX(strings.NewReader("<en-export><note>"))
+	assert.Error(t, err)
+}
+
+func Test_enmlToText(t *testing.T) {
+	assert.Equal(t, "a\nb", enmlToText("<en-note>a<br>b</en-note>"))
+	assert.Equal(t, "a\n\nb", enmlToText("<p>a</p>\n\n\n<p>b</p>"))
+	assert.Equal(t, "<tag>", enmlToText("&lt;tag&gt;"))
+}
@@ -0,0 +1,198 @@
+// Package importer converts notes from other tools (like Evernote or
+// spreadsheets) into a JW Library database, so they can be merged into
+// an existing backup.
+package importer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// lastModifiedFormat is the format JW Library uses for Note.LastModified
+const lastModifiedFormat = "2006-01-02T15:04:05-07:00"
+
+// userTagType is the type of tags created by the user
+// (in contrast to the Favorites tag)
+const userTagType = 1
+
+// ExternalNote represents a note imported from another tool
+type ExternalNote struct {
+	Title        string
+	Content      string
+	Tags         []string
+	Publication  string
+	Book         int
+	Chapter      int
+	Verse        int
+	DocumentID   int
+	Paragraph    int
+	LastModified time.Time
+}
+
+// PublicationMapping describes the JW Library publication a name
+// used by another tool refers to.
+type PublicationMapping struct {
+	KeySymbol      string `json:"keySymbol"`
+	MepsLanguage   int    `json:"mepsLanguage"`
+	IssueTagNumber int    `json:"issueTagNumber"`
+	DocumentID     int    `json:"documentId"`
+}
+
+// Mapping maps names of publications used by another tool
+// to the corresponding JW Library publication.
+type Mapping map[string]PublicationMapping
+
+// ReadMapping reads a Mapping from its JSON representation, like
+//
+//	{"Study Bible": {"keySymbol": "nwtsty", "mepsLanguage": 0}}
+func ReadMapping(r io.Reader) (Mapping, error) {
+	mapping := Mapping{}
+	if err := json.NewDecoder(r).Decode(&mapping); err != nil {
+		return nil, errors.Wrap(err, "Error while reading publication mapping")
+	}
+	for name, publ := range mapping {
+		if publ.KeySymbol == "" {
+			return nil, errors.Errorf("Publication %s in mapping has no keySymbol", name)
+		}
+	}
+	return mapping, nil
+}
+
+// Build creates a Database containing the given notes together with
+// their tags and locations. The publication of a note is looked up in the
+// mapping. If a note does not name a publication, the first of its tags
+// that is found in the mapping is used instead (and not imported as tag).
+// Notes without a publication are imported without a location.
+func Build(notes []ExternalNote, mapping Mapping) (*model.Database, error) {
+	db := &model.Database{
+		BlockRange: []*model.BlockRange{nil},
+		Bookmark:   []*model.Bookmark{nil},
+		Location:   []*model.Location{nil},
+		Note:       []*model.Note{nil},
+		Tag:        []*model.Tag{nil},
+		TagMap:     []*model.TagMap{nil},
+		UserMark:   []*model.UserMark{nil},
+	}
+	locations := map[string]*model.Location{}
+	tags := map[string]*model.Tag{}
+	tagPositions := map[int]int{}
+
+	for i, ext := range notes {
+		tagNames := ext.Tags
+		if ext.Publication == "" {
+			for j, tag := range ext.Tags {
+				if _, ok := mapping[tag]; ok {
+					ext.Publication = tag
+					tagNames = append(append([]string{}, ext.Tags[:j]...), ext.Tags[j+1:]...)
+					break
+				}
+			}
+		}
+
+		guid, err := model.NewGUID()
+		if err != nil {
+			return nil, err
+		}
+		lastModified := ext.LastModified
+		if lastModified.IsZero() {
+			lastModified = time.Now()
+		}
+		note := &model.Note{
+			NoteID:       len(db.Note),
+			GUID:         guid,
+			Title:        sql.NullString{String: ext.Title, Valid: true},
+			Content:      sql.NullString{String: ext.Content, Valid: true},
+			LastModified: lastModified.UTC().Format(lastModifiedFormat),
+		}
+
+		if ext.Publication != "" {
+			location, err := noteLocation(ext, mapping)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Error while importing note %d (%s)", i+1, ext.Title)
+			}
+			if existing, ok := locations[location.UniqueKey()]; ok {
+				location = existing
+			} else {
+				location.LocationID = len(db.Location)
+				locations[location.UniqueKey()] = location
+				db.Location = append(db.Location, location)
+			}
+			note.LocationID = sql.NullInt32{Int32: int32(location.LocationID), Valid: true}
+
+			switch {
+			case ext.Verse > 0 && location.BookNumber.Valid:
+				note.BlockType = 2
+				note.BlockIdentifier = sql.NullInt32{Int32: int32(ext.Verse), Valid: true}
+			case ext.Paragraph > 0 && location.DocumentID.Valid:
+				note.BlockType = 1
+				note.BlockIdentifier = sql.NullInt32{Int32: int32(ext.Paragraph), Valid: true}
+			}
+		}
+		db.Note = append(db.Note, note)
+
+		seen := map[string]bool{}
+		for _, name := range tagNames {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			tag, ok := tags[name]
+			if !ok {
+				tag = &model.Tag{
+					TagID:   len(db.Tag),
+					TagType: userTagType,
+					Name:    name,
+				}
+				tags[name] = tag
+				db.Tag = append(db.Tag, tag)
+			}
+			db.TagMap = append(db.TagMap, &model.TagMap{
+				TagMapID: len(db.TagMap),
+				NoteID:   sql.NullInt32{Int32: int32(note.NoteID), Valid: true},
+				TagID:    tag.TagID,
+				Position: tagPositions[tag.TagID],
+			})
+			tagPositions[tag.TagID]++
+		}
+	}
+
+	return db, nil
+}
+
+// noteLocation creates the Location the given note refers to
+func noteLocation(ext ExternalNote, mapping Mapping) (*model.Location, error) {
+	publ, ok := mapping[ext.Publication]
+	if !ok {
+		return nil, errors.Errorf("Publication %s is not part of the mapping", ext.Publication)
+	}
+
+	location := &model.Location{
+		KeySymbol:      sql.NullString{String: publ.KeySymbol, Valid: true},
+		MepsLanguage:   publ.MepsLanguage,
+		IssueTagNumber: publ.IssueTagNumber,
+	}
+	documentID := publ.DocumentID
+	if ext.DocumentID != 0 {
+		documentID = ext.DocumentID
+	}
+
+	switch {
+	case ext.Book > 0 && ext.Chapter > 0:
+		location.BookNumber = sql.NullInt32{Int32: int32(ext.Book), Valid: true}
+		location.ChapterNumber = sql.NullInt32{Int32: int32(ext.Chapter), Valid: true}
+	case documentID > 0:
+		location.DocumentID = sql.NullInt32{Int32: int32(documentID), Valid: true}
+	default:
+		return nil, errors.New("Location needs either a book and chapter or a document")
+	}
+
+	return location, nil
+}
@@ -0,0 +1,172 @@
+package importer
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMapping = Mapping{
+	"Study Bible": {KeySymbol: "nwtsty", MepsLanguage: 2},
+	"Watchtower":  {KeySymbol: "w", MepsLanguage: 2, IssueTagNumber: 20200100, DocumentID: 2020123},
+}
+
+func TestReadMapping(t *testing.T) {
+	mapping, err := ReadMapping(strings.NewReader(`{
+		"Study Bible": {"keySymbol": "nwtsty", "mepsLanguage": 2},
+		"Watchtower": {"keySymbol": "w", "mepsLanguage": 2, "issueTagNumber": 20200100, "documentId": 2020123}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, testMapping, mapping)
+
+	_, err = ReadMapping(strings.NewReader(`{"Study Bible": {"mepsLanguage": 2}}`))
+	assert.EqualError(t, err, "Publication Study Bible in mapping has no keySymbol")
+
+	_, err = ReadMapping(strings.NewReader(`[`))
+	assert.Error(t, err)
+}
+
+func TestBuild(t *testing.T) {
+	modified := time.Date(2020, 4, 14, 20, 42, 58, 0, time.FixedZone("CEST", 2*60*60))
+	notes := []ExternalNote{
+		{
+			Title:        "Verse",
+			Content:      "Content",
+			Tags:         []string{"Faith", " Hope ", "Faith"},
+			Publication:  "Study Bible",
+			Book:         1,
+			Chapter:      3,
+			Verse:        15,
+			LastModified: modified,
+		},
+		{
+			Title:        "Same chapter",
+			Tags:         []string{"Faith"},
+			Publication:  "Study Bible",
+			Book:         1,
+			Chapter:      3,
+			LastModified: modified,
+		},
+		{
+			Title:        "Paragraph",
+			Tags:         []string{"Watchtower", "Hope"},
+			Paragraph:    4,
+			LastModified: modified,
+		},
+		{
+			Title:        "Without location",
+			LastModified: modified,
+		},
+	}
+
+	db, err := Build(notes, testMapping)
+	assert.NoError(t, err)
+
+	guid := regexp.MustCompile(`^[0-9A-F]{8}-[0-9A-F]{4}-4[0-9A-F]{3}-[89AB][0-9A-F]{3}-[0-9A-F]{12}$`)
+	for _, note := range db.Note[1:] {
+		assert.Regexp(t, guid, note.GUID)
+		note.GUID = ""
+	}
+
+	expected := &model.Database{
+		BlockRange: []*model.BlockRange{nil},
+		Bookmark:   []*model.Bookmark{nil},
+		UserMark:   []*model.UserMark{nil},
+		Location: []*model.Location{
+			nil,
+			{
+				LocationID:    1,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 3, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				MepsLanguage:  2,
+			},
+			{
+				LocationID:     2,
+				DocumentID:     sql.NullInt32{Int32: 2020123, Valid: true},
+				IssueTagNumber: 20200100,
+				KeySymbol:      sql.NullString{String: "w", Valid: true},
+				MepsLanguage:   2,
+			},
+		},
+		Note: []*model.Note{
+			nil,
+			{
+				NoteID:          1,
+				LocationID:      sql.NullInt32{Int32: 1, Valid: true},
+				Title:           sql.NullString{String: "Verse", Valid: true},
+				Content:         sql.NullString{String: "Content", Valid: true},
+				LastModified:    "2020-04-14T18:42:58+00:00",
+				BlockType:       2,
+				BlockIdentifier: sql.NullInt32{Int32: 15, Valid: true},
+			},
+			{
+				NoteID:       2,
+				LocationID:   sql.NullInt32{Int32: 1, Valid: true},
+				Title:        sql.NullString{String: "Same chapter", Valid: true},
+				Content:      sql.NullString{String: "", Valid: true},
+				LastModified: "2020-04-14T18:42:58+00:00",
+			},
+			{
+				NoteID:          3,
+				LocationID:      sql.NullInt32{Int32: 2, Valid: true},
+				Title:           sql.NullString{String: "Paragraph", Valid: true},
+				Content:         sql.NullString{String: "", Valid: true},
+				LastModified:    "2020-04-14T18:42:58+00:00",
+				BlockType:       1,
+				BlockIdentifier: sql.NullInt32{Int32: 4, Valid: true},
+			},
+			{
+				NoteID:       4,
+				Title:        sql.NullString{String: "Without location", Valid: true},
+				Content:      sql.NullString{String: "", Valid: true},
+				LastModified: "2020-04-14T18:42:58+00:00",
+			},
+		},
+		Tag: []*model.Tag{
+			nil,
+			{TagID: 1, TagType: 1, Name: "Faith"},
+			{TagID: 2, TagType: 1, Name: "Hope"},
+		},
+		TagMap: []*model.TagMap{
+			nil,
+			{TagMapID: 1, NoteID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 1, Position: 0},
+			{TagMapID: 2, NoteID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 2, Position: 0},
+			{TagMapID: 3, NoteID: sql.NullInt32{Int32: 2, Valid: true}, TagID: 1, Position: 1},
+			{TagMapID: 4, NoteID: sql.NullInt32{Int32: 3, Valid: true}, TagID: 2, Position: 1},
+		},
+	}
+	assert.Equal(t, expected, db)
+
+	_, err = Build([]ExternalNote{{Title: "Unknown", Publication: "Unknown"}}, testMapping)
+	assert.EqualError(t, err, "Error while importing note 1 (Unknown): Publication Unknown is not part of the mapping")
+
+	_, err = Build([]ExternalNote{{Title: "Chapter missing", Publication: "Study Bible", Book: 1}}, testMapping)
+	assert.EqualError(t, err, "Error while importing note 1 (Chapter missing): Location needs either a book and chapter or a document")
+}
+
+func TestBuild_export(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	notes, err := ReadCSV(strings.NewReader(testCSV))
+	assert.NoError(t, err)
+	db, err := Build(notes, testMapping)
+	assert.NoError(t, err)
+
+	filename := filepath.Join(tmp, "imported.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(filename))
+
+	imported := &model.Database{}
+	assert.NoError(t, imported.ImportJWLBackup(filename))
+	assert.True(t, db.Equals(imported))
+}
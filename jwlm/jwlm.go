@@ -0,0 +1,280 @@
+// Package jwlm provides a small API to open, merge, and export JW Library
+// backups for apps that embed go-jwlm. Contrary to the model and merger
+// packages, whose signatures change whenever go-jwlm learns something new,
+// this API is kept stable: it only grows in a backwards compatible way
+// within the same major version.
+package jwlm
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+)
+
+// ErrUnresolvedConflict is returned by Merge if a conflict could neither
+// be solved by a Resolver nor by MergeOptions.Resolve
+var ErrUnresolvedConflict = errors.New("Merge conflict could not be resolved")
+
+// Backup is a JW Library backup that has been opened with OpenBackup
+// or created by Merge.
+type Backup struct {
+	db    *model.Database
+	media map[string][]byte
+}
+
+// OpenBackup opens the .jwlibrary backup or bare user_data.db
+// with the given filename.
+func OpenBackup(filename string) (*Backup, error) {
+	return OpenBackupWithOptions(filename, ImportOptions{})
+}
+
+// ImportOptions configure how OpenBackupWithOptions opens a backup
+type ImportOptions struct {
+	// Context cancels opening the backup. If it is nil,
+	// context.Background() is used.
+	Context context.Context
+}
+
+// OpenBackupWithOptions opens the .jwlibrary backup or bare
+// user_data.db with the given filename using the given options.
+func OpenBackupWithOptions(filename string, opts ImportOptions) (*Backup, error) {
+	isDB, err := model.IsUserDB(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &Backup{db: &model.Database{}}
+	importOpts := model.ImportOptions{Context: opts.Context}
+	if isDB {
+		err = backup.db.ImportUserDBWithOptions(filename, importOpts)
+	} else {
+		err = backup.db.ImportJWLBackupWithOptions(filename, importOpts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isDB {
+		if backup.media, err = model.ReadMedia(filename); err != nil {
+			return nil, err
+		}
+	}
+
+	return backup, nil
+}
+
+// OpenBackupFromFS opens the .jwlibrary backup or bare user_data.db with
+// the given name within fsys, like backups embedded using embed.FS or the
+// files of a virtual file system.
+func OpenBackupFromFS(fsys fs.FS, name string) (*Backup, error) {
+	isDB, err := model.IsUserDBFromFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &Backup{db: &model.Database{}}
+	if isDB {
+		return backup, backup.db.ImportUserDBFromFS(fsys, name)
+	}
+	if err := backup.db.ImportJWLBackupFromFS(fsys, name); err != nil {
+		return nil, err
+	}
+	if backup.media, err = model.ReadMediaFromFS(fsys, name); err != nil {
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+// Export stores the given Backup as .jwlibrary backup with the given
+// filename. If the filename ends with .db, only the bare user_data.db
+// is stored.
+func Export(backup *Backup, filename string) error {
+	return ExportWithOptions(backup, filename, ExportOptions{})
+}
+
+// ExportOptions configure how ExportWithOptions stores a backup
+type ExportOptions struct {
+	// CompressionLevel of the .jwlibrary backup, ranging from 1 (fastest)
+	// to 9 (smallest). 0 compresses like JW Library does, -1 disables
+	// compression. It is ignored for a bare user_data.db.
+	CompressionLevel int
+	// Verify opens the stored backup again and returns an
+	// error if it differs from the given one
+	Verify bool
+}
+
+// ExportWithOptions stores the given Backup like Export
+// does, using the given options.
+func ExportWithOptions(backup *Backup, filename string, opts ExportOptions) error {
+	if backup == nil || backup.db == nil {
+		return errors.New("Can't export an empty backup")
+	}
+	if filepath.Ext(filename) != ".db" {
+		return backup.db.ExportJWLBackupWithOptions(filename, model.ExportOptions{
+			CompressionLevel: opts.CompressionLevel,
+			Verify:           opts.Verify,
+			Media:            backup.media,
+		})
+	}
+
+	if err := backup.db.ExportUserDB(filename); err != nil {
+		return err
+	}
+	if !opts.Verify {
+		return nil
+	}
+	exported := &model.Database{}
+	if err := exported.ImportUserDB(filename); err != nil {
+		return errors.Wrap(err, "Error while opening exported database for verification")
+	}
+	if !backup.db.Equals(exported) {
+		return errors.Wrapf(model.ErrExportMismatch, "%s differs from the backup", filename)
+	}
+	return nil
+}
+
+// Side is one of the two backups of a merge
+type Side int
+
+const (
+	// Left is the first backup given to Merge
+	Left Side = iota
+	// Right is the second backup given to Merge
+	Right
+)
+
+// Resolver is the name of a strategy to solve conflicts automatically
+type Resolver string
+
+const (
+	// ChooseLeft always keeps the entry of the left backup
+	ChooseLeft Resolver = "chooseLeft"
+	// ChooseRight always keeps the entry of the right backup
+	ChooseRight Resolver = "chooseRight"
+	// ChooseNewest keeps the entry that has been modified last,
+	// which is only supported for notes
+	ChooseNewest Resolver = "chooseNewest"
+)
+
+// Kinds of entries that can be in conflict
+const (
+	KindBookmark = "bookmark"
+	KindMarking  = "marking"
+	KindNote     = "note"
+)
+
+// Conflict describes two entries that can't be merged automatically
+type Conflict struct {
+	// Kind is KindBookmark, KindMarking, or KindNote
+	Kind string
+	// Left and Right are human-readable descriptions of the entries
+	Left  string
+	Right string
+}
+
+// MergeOptions configure how Merge solves conflicts. For every kind of entry,
+// the given Resolver is used first. Remaining conflicts are passed to
+// Resolve. If it is nil, Merge fails with ErrUnresolvedConflict.
+type MergeOptions struct {
+	Bookmarks Resolver
+	Markings  Resolver
+	Notes     Resolver
+	// Resolve is called for every conflict that is not solved by a
+	// Resolver and returns the side whose entry should be kept
+	Resolve func(conflict Conflict) (Side, error)
+}
+
+// Result is the outcome of Merge
+type Result struct {
+	// Backup is the merged backup, which can be stored with Export
+	Backup *Backup
+	// Conflicts is the number of conflicts that have been solved
+	Conflicts int
+}
+
+// Merge merges the right backup into the left one, solving conflicts as
+// given by opts. The given backups are not changed.
+func Merge(left *Backup, right *Backup, opts MergeOptions) (Result, error) {
+	if left == nil || right == nil {
+		return Result{}, errors.New("Can't merge an empty backup")
+	}
+
+	// Merging changes the IDs of both sides
+	l, r := model.MakeDatabaseCopy(left.db), model.MakeDatabaseCopy(right.db)
+	kinds := map[string]string{
+		merger.StageBookmarks: KindBookmark,
+		merger.StageMarkings:  KindMarking,
+		merger.StageNotes:     KindNote,
+	}
+	resolvers := map[string]Resolver{
+		merger.StageBookmarks: opts.Bookmarks,
+		merger.StageMarkings:  opts.Markings,
+		merger.StageNotes:     opts.Notes,
+	}
+	conflicts := 0
+	result, err := merger.MergeBackups(l, r, merger.MergeOptions{
+		Solve: func(stage string, c map[string]merger.MergeConflict, merged *model.Database) (map[string]merger.MergeSolution, error) {
+			conflicts += len(c)
+			return resolve(kinds[stage], resolvers[stage], opts.Resolve, c, merged)
+		},
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	media, _ := merger.MergeMedia(left.media, right.media)
+	return Result{
+		Backup:    &Backup{db: result.Merged, media: media},
+		Conflicts: conflicts,
+	}, nil
+}
+
+// resolve solves the given conflicts with the resolver, passing
+// the remaining ones to the callback
+func resolve(kind string, resolver Resolver, callback func(Conflict) (Side, error),
+	conflicts map[string]merger.MergeConflict, merged *model.Database) (map[string]merger.MergeSolution, error) {
+	if resolver != "" {
+		solutions, err := merger.AutoResolveConflicts(conflicts, string(resolver))
+		if err != nil {
+			return nil, err
+		}
+		return solutions, nil
+	}
+	if callback == nil {
+		return nil, errors.Wrapf(ErrUnresolvedConflict, "%d conflicts of type %s", len(conflicts), kind)
+	}
+
+	// Ask in a stable order, so the callback can be tested
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	solutions := make(map[string]merger.MergeSolution, len(conflicts))
+	for _, key := range keys {
+		c := conflicts[key]
+		side, err := callback(Conflict{
+			Kind:  kind,
+			Left:  c.Left.PrettyPrint(merged),
+			Right: c.Right.PrettyPrint(merged),
+		})
+		if err != nil {
+			return nil, err
+		}
+		switch side {
+		case Left:
+			solutions[key] = merger.MergeSolution{Side: merger.LeftSide, Solution: c.Left, Discarded: c.Right}
+		case Right:
+			solutions[key] = merger.MergeSolution{Side: merger.RightSide, Solution: c.Right, Discarded: c.Left}
+		default:
+			return nil, errors.Errorf("%d is not a valid side", side)
+		}
+	}
+	return solutions, nil
+}
@@ -0,0 +1,180 @@
+package jwlm
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBackup(noteContent string, bookmarkTitle string, lastModified string) *Backup {
+	return &Backup{db: &model.Database{
+		BlockRange: []*model.BlockRange{nil},
+		UserMark:   []*model.UserMark{nil},
+		Location: []*model.Location{
+			nil,
+			{
+				LocationID:    1,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				MepsLanguage:  2,
+			},
+			{
+				LocationID:   2,
+				KeySymbol:    sql.NullString{String: "nwtsty", Valid: true},
+				MepsLanguage: 2,
+				LocationType: 1,
+			},
+		},
+		Bookmark: []*model.Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 2, Slot: 0, Title: bookmarkTitle},
+		},
+		Note: []*model.Note{
+			nil,
+			{
+				NoteID:       1,
+				GUID:         "2C5E7B4A-5D87-4A2B-8D6C-1A1F0E4B5C6D",
+				LocationID:   sql.NullInt32{Int32: 1, Valid: true},
+				Title:        sql.NullString{String: "Note", Valid: true},
+				Content:      sql.NullString{String: noteContent, Valid: true},
+				LastModified: lastModified,
+			},
+		},
+		Tag: []*model.Tag{
+			nil,
+			{TagID: 1, TagType: 1, Name: "Study"},
+		},
+		TagMap: []*model.TagMap{
+			nil,
+			{TagMapID: 1, NoteID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 1},
+		},
+	}}
+}
+
+func TestOpenBackupAndExport(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	backup := testBackup("Content", "Genesis 1", "2021-01-01T10:00:00+00:00")
+	for _, name := range []string{"backup.jwlibrary", "user_data.db"} {
+		filename := filepath.Join(tmp, name)
+		assert.NoError(t, Export(backup, filename))
+
+		opened, err := OpenBackup(filename)
+		assert.NoError(t, err)
+		assert.True(t, backup.db.Equals(opened.db))
+	}
+
+	_, err = OpenBackup(filepath.Join(tmp, "missing.jwlibrary"))
+	assert.Error(t, err)
+	assert.Error(t, Export(nil, filepath.Join(tmp, "empty.jwlibrary")))
+}
+
+func TestOpenBackupAndExportWithOptions(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	backup := testBackup("Content", "Genesis 1", "2021-01-01T10:00:00+00:00")
+	for _, name := range []string{"backup.jwlibrary", "user_data.db"} {
+		filename := filepath.Join(tmp, name)
+		assert.NoError(t, ExportWithOptions(backup, filename, ExportOptions{CompressionLevel: 9, Verify: true}))
+
+		opened, err := OpenBackupWithOptions(filename, ImportOptions{Context: context.Background()})
+		assert.NoError(t, err)
+		assert.True(t, backup.db.Equals(opened.db))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = OpenBackupWithOptions(filename, ImportOptions{Context: ctx})
+		assert.True(t, errors.Is(err, context.Canceled))
+	}
+
+	assert.Error(t, ExportWithOptions(nil, filepath.Join(tmp, "empty.jwlibrary"), ExportOptions{Verify: true}))
+}
+
+func TestOpenBackupFromFS(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	backup := testBackup("Content", "Genesis 1", "2021-01-01T10:00:00+00:00")
+	backup.media = map[string][]byte{"picture.jpg": []byte("picture")}
+	assert.NoError(t, Export(backup, filepath.Join(tmp, "backup.jwlibrary")))
+	assert.NoError(t, Export(backup, filepath.Join(tmp, "user_data.db")))
+
+	fsys := os.DirFS(tmp)
+	opened, err := OpenBackupFromFS(fsys, "backup.jwlibrary")
+	assert.NoError(t, err)
+	assert.True(t, backup.db.Equals(opened.db))
+	assert.Equal(t, backup.media, opened.media)
+
+	opened, err = OpenBackupFromFS(fsys, "user_data.db")
+	assert.NoError(t, err)
+	assert.True(t, backup.db.Equals(opened.db))
+
+	_, err = OpenBackupFromFS(fsys, "missing.jwlibrary")
+	assert.Error(t, err)
+}
+
+func TestMerge(t *testing.T) {
+	left := testBackup("Left content", "Left bookmark", "2021-01-01T10:00:00+00:00")
+	right := testBackup("Right content", "Right bookmark", "2021-02-01T10:00:00+00:00")
+	leftCopy := model.MakeDatabaseCopy(left.db)
+
+	result, err := Merge(left, right, MergeOptions{
+		Bookmarks: ChooseLeft,
+		Notes:     ChooseNewest,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Conflicts)
+	assert.Equal(t, "Left bookmark", result.Backup.db.Bookmark[1].Title)
+	assert.Equal(t, "Right content", result.Backup.db.Note[1].Content.String)
+	assert.Len(t, result.Backup.db.TagMap, 2)
+	// The given backups are left as they are
+	assert.True(t, leftCopy.Equals(left.db))
+
+	var asked []Conflict
+	result, err = Merge(left, right, MergeOptions{
+		Resolve: func(conflict Conflict) (Side, error) {
+			asked = append(asked, conflict)
+			return Right, nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Right bookmark", result.Backup.db.Bookmark[1].Title)
+	assert.Equal(t, "Right content", result.Backup.db.Note[1].Content.String)
+	if assert.Len(t, asked, 2) {
+		assert.Equal(t, KindBookmark, asked[0].Kind)
+		assert.Contains(t, asked[0].Left, "Left bookmark")
+		assert.Contains(t, asked[0].Right, "Right bookmark")
+		assert.Equal(t, KindNote, asked[1].Kind)
+	}
+
+	_, err = Merge(left, right, MergeOptions{Bookmarks: ChooseLeft})
+	assert.True(t, errors.Is(err, ErrUnresolvedConflict))
+
+	_, err = Merge(left, right, MergeOptions{Resolve: func(Conflict) (Side, error) { return Side(5), nil }})
+	assert.EqualError(t, err, "5 is not a valid side")
+
+	_, err = Merge(left, right, MergeOptions{Resolve: func(Conflict) (Side, error) { return Left, errors.New("Canceled") }})
+	assert.EqualError(t, err, "Canceled")
+
+	_, err = Merge(left, nil, MergeOptions{})
+	assert.Error(t, err)
+
+	// Merging a backup with itself does not cause any conflicts
+	result, err = Merge(left, left, MergeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Conflicts)
+	assert.True(t, left.db.Equals(result.Backup.db))
+}
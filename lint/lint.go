@@ -0,0 +1,422 @@
+// Package lint checks JW Library databases for anomalies, like references
+// to entries that don't exist or duplicate bookmarks, which might confuse
+// JW Library or lead to unexpected results when merging.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// Severity describes how serious a Finding is
+type Severity int
+
+const (
+	// Info is an anomaly that is most likely intended,
+	// but might be worth a look
+	Info Severity = iota
+	// Warning is an anomaly that JW Library handles,
+	// but that might lead to unexpected results
+	Warning
+	// Error is an anomaly that violates the assumptions
+	// of JW Library and should be fixed
+	Error
+)
+
+// String returns the name of the Severity
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Checks that are run by Lint
+const (
+	CheckNoteWithoutLocation     = "note-without-location"
+	CheckNoteMissingLocation     = "note-missing-location"
+	CheckNoteMissingUserMark     = "note-missing-usermark"
+	CheckUserMarkMissingLocation = "usermark-missing-location"
+	CheckUserMarkWithoutRange    = "usermark-without-range"
+	CheckBlockRangeMissingMark   = "blockrange-missing-usermark"
+	CheckBlockRangeInvalidBlock  = "blockrange-invalid-block"
+	CheckTagMapDangling          = "tagmap-dangling"
+	CheckDuplicateBookmark       = "duplicate-bookmark"
+	CheckBookmarkInvalidSlot     = "bookmark-invalid-slot"
+	CheckRedundantBookmark       = "redundant-bookmark"
+	CheckDuplicateLocation       = "duplicate-location"
+	CheckEmptyGUID               = "empty-guid"
+	CheckDuplicateGUID           = "duplicate-guid"
+)
+
+// maxVerse is the highest verse number of the Bible (Psalm 119:176)
+const maxVerse = 176
+
+// maxBookmarkSlot is the highest slot of the bookmarks of a publication
+const maxBookmarkSlot = 9
+
+// emptyGUID is a GUID that only consists of zeros
+const emptyGUID = "00000000-0000-0000-0000-000000000000"
+
+// Finding is an anomaly found in a Database
+type Finding struct {
+	// Check is the name of the check that found the anomaly
+	Check    string
+	Severity Severity
+	// Table and ID point to the affected entry
+	Table   string
+	ID      int
+	Message string
+	// Fix describes how the anomaly can be fixed
+	Fix string
+}
+
+// String returns a one-line description of the Finding
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s %d: %s (%s)", f.Severity, f.Table, f.ID, f.Message, f.Check)
+}
+
+// Lint checks the given Database for anomalies. The findings are
+// sorted by their severity (most severe first), table, and ID.
+func Lint(db *model.Database) []Finding {
+	var findings []Finding
+	findings = append(findings, lintNotes(db)...)
+	findings = append(findings, lintUserMarks(db)...)
+	findings = append(findings, lintBlockRanges(db)...)
+	findings = append(findings, lintTagMaps(db)...)
+	findings = append(findings, lintBookmarks(db)...)
+	findings = append(findings, lintRedundantBookmarks(db)...)
+	findings = append(findings, lintLocations(db)...)
+	findings = append(findings, lintGUIDs(db)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Check < b.Check
+	})
+
+	return findings
+}
+
+// lintNotes checks the references of Notes
+func lintNotes(db *model.Database) []Finding {
+	var findings []Finding
+	for _, note := range db.Note {
+		if note == nil {
+			continue
+		}
+
+		if note.LocationID.Valid && !exists(db, "Location", int(note.LocationID.Int32)) {
+			findings = append(findings, Finding{
+				Check:    CheckNoteMissingLocation,
+				Severity: Error,
+				Table:    "Note",
+				ID:       note.NoteID,
+				Message:  fmt.Sprintf("Refers to the Location %d, which does not exist", note.LocationID.Int32),
+				Fix:      "Remove the reference to the Location, so the note is kept without a location",
+			})
+		} else if !note.LocationID.Valid {
+			findings = append(findings, Finding{
+				Check:    CheckNoteWithoutLocation,
+				Severity: Info,
+				Table:    "Note",
+				ID:       note.NoteID,
+				Message:  "Is not attached to any publication",
+				Fix:      "Nothing to do if this is a personal note. Otherwise, recreate it within the publication",
+			})
+		}
+
+		if note.UserMarkID.Valid && !exists(db, "UserMark", int(note.UserMarkID.Int32)) {
+			findings = append(findings, Finding{
+				Check:    CheckNoteMissingUserMark,
+				Severity: Error,
+				Table:    "Note",
+				ID:       note.NoteID,
+				Message:  fmt.Sprintf("Refers to the highlight (UserMark) %d, which does not exist", note.UserMarkID.Int32),
+				Fix:      "Remove the reference to the highlight",
+			})
+		}
+	}
+	return findings
+}
+
+// lintUserMarks checks the Locations and BlockRanges of UserMarks
+func lintUserMarks(db *model.Database) []Finding {
+	withRange := map[int]bool{}
+	for _, br := range db.BlockRange {
+		if br != nil {
+			withRange[br.UserMarkID] = true
+		}
+	}
+
+	var findings []Finding
+	for _, um := range db.UserMark {
+		if um == nil {
+			continue
+		}
+
+		if !exists(db, "Location", um.LocationID) {
+			findings = append(findings, Finding{
+				Check:    CheckUserMarkMissingLocation,
+				Severity: Error,
+				Table:    "UserMark",
+				ID:       um.UserMarkID,
+				Message:  fmt.Sprintf("Highlight refers to the Location %d, which does not exist", um.LocationID),
+				Fix:      "Remove the highlight together with its ranges",
+			})
+		}
+		if !withRange[um.UserMarkID] {
+			findings = append(findings, Finding{
+				Check:    CheckUserMarkWithoutRange,
+				Severity: Warning,
+				Table:    "UserMark",
+				ID:       um.UserMarkID,
+				Message:  "Highlight does not cover any text",
+				Fix:      "Remove the highlight",
+			})
+		}
+	}
+	return findings
+}
+
+// lintBlockRanges checks the UserMarks and blocks of BlockRanges
+func lintBlockRanges(db *model.Database) []Finding {
+	var findings []Finding
+	for _, br := range db.BlockRange {
+		if br == nil {
+			continue
+		}
+
+		if !exists(db, "UserMark", br.UserMarkID) {
+			findings = append(findings, Finding{
+				Check:    CheckBlockRangeMissingMark,
+				Severity: Error,
+				Table:    "BlockRange",
+				ID:       br.BlockRangeID,
+				Message:  fmt.Sprintf("Belongs to the highlight (UserMark) %d, which does not exist", br.UserMarkID),
+				Fix:      "Remove the range",
+			})
+		}
+
+		var problem string
+		switch {
+		case br.BlockType == 2 && (br.Identifier < 1 || br.Identifier > maxVerse):
+			problem = fmt.Sprintf("Highlights the verse %d, which does not exist", br.Identifier)
+		case br.Identifier < 0:
+			problem = fmt.Sprintf("Highlights the block %d, which does not exist", br.Identifier)
+		case br.StartToken.Valid && br.EndToken.Valid && br.StartToken.Int32 > br.EndToken.Int32:
+			problem = fmt.Sprintf("Starts at token %d, but ends before at token %d", br.StartToken.Int32, br.EndToken.Int32)
+		}
+		if problem != "" {
+			findings = append(findings, Finding{
+				Check:    CheckBlockRangeInvalidBlock,
+				Severity: Error,
+				Table:    "BlockRange",
+				ID:       br.BlockRangeID,
+				Message:  problem,
+				Fix:      "Remove the range",
+			})
+		}
+	}
+	return findings
+}
+
+// lintTagMaps checks if TagMaps refer to existing entries
+func lintTagMaps(db *model.Database) []Finding {
+	var findings []Finding
+	for _, tm := range db.TagMap {
+		if tm == nil {
+			continue
+		}
+
+		var problem string
+		switch {
+		case !exists(db, "Tag", tm.TagID):
+			problem = fmt.Sprintf("Refers to the Tag %d, which does not exist", tm.TagID)
+		case tm.NoteID.Valid && !exists(db, "Note", int(tm.NoteID.Int32)):
+			problem = fmt.Sprintf("Tags the Note %d, which does not exist", tm.NoteID.Int32)
+		case tm.LocationID.Valid && !exists(db, "Location", int(tm.LocationID.Int32)):
+			problem = fmt.Sprintf("Tags the Location %d, which does not exist", tm.LocationID.Int32)
+		}
+		if problem != "" {
+			findings = append(findings, Finding{
+				Check:    CheckTagMapDangling,
+				Severity: Error,
+				Table:    "TagMap",
+				ID:       tm.TagMapID,
+				Message:  problem,
+				Fix:      "Remove the entry from the tag",
+			})
+		}
+	}
+	return findings
+}
+
+// lintBookmarks checks the slots of Bookmarks
+func lintBookmarks(db *model.Database) []Finding {
+	var findings []Finding
+	slots := map[string]int{}
+	for _, bm := range db.Bookmark {
+		if bm == nil {
+			continue
+		}
+
+		if bm.Slot < 0 || bm.Slot > maxBookmarkSlot {
+			findings = append(findings, Finding{
+				Check:    CheckBookmarkInvalidSlot,
+				Severity: Warning,
+				Table:    "Bookmark",
+				ID:       bm.BookmarkID,
+				Message:  fmt.Sprintf("Uses the slot %d, but only 0 to %d are available", bm.Slot, maxBookmarkSlot),
+				Fix:      "Move the bookmark to a free slot",
+			})
+		}
+
+		key := fmt.Sprintf("%d_%d", bm.PublicationLocationID, bm.Slot)
+		if first, ok := slots[key]; ok {
+			findings = append(findings, Finding{
+				Check:    CheckDuplicateBookmark,
+				Severity: Error,
+				Table:    "Bookmark",
+				ID:       bm.BookmarkID,
+				Message:  fmt.Sprintf("Uses the same slot %d as the bookmark %d", bm.Slot, first),
+				Fix:      "Move the bookmark to a free slot or remove it",
+			})
+			continue
+		}
+		slots[key] = bm.BookmarkID
+	}
+	return findings
+}
+
+// lintRedundantBookmarks checks for Bookmarks of a publication that point
+// at the same location as a Bookmark in another slot, which is a common
+// artifact of restoring the same bookmarks repeatedly. The Bookmark with the
+// lowest slot is kept, all others are reported.
+func lintRedundantBookmarks(db *model.Database) []Finding {
+	var bookmarks []*model.Bookmark
+	for _, bm := range db.Bookmark {
+		if bm != nil {
+			bookmarks = append(bookmarks, bm)
+		}
+	}
+	sort.SliceStable(bookmarks, func(i, j int) bool {
+		return bookmarks[i].Slot < bookmarks[j].Slot
+	})
+
+	var findings []Finding
+	kept := map[string]*model.Bookmark{}
+	for _, bm := range bookmarks {
+		key := fmt.Sprintf("%d_%d_%d_%d_%t", bm.PublicationLocationID, bm.LocationID,
+			bm.BlockType, bm.BlockIdentifier.Int32, bm.BlockIdentifier.Valid)
+		first, ok := kept[key]
+		if !ok {
+			kept[key] = bm
+			continue
+		}
+		// Bookmarks in the same slot are found by lintBookmarks
+		if first.Slot == bm.Slot {
+			continue
+		}
+		findings = append(findings, Finding{
+			Check:    CheckRedundantBookmark,
+			Severity: Warning,
+			Table:    "Bookmark",
+			ID:       bm.BookmarkID,
+			Message:  fmt.Sprintf("Points at the same location as the bookmark %d in slot %d", first.BookmarkID, first.Slot),
+			Fix:      "Remove the bookmark to free its slot",
+		})
+	}
+	return findings
+}
+
+// lintLocations checks for Locations that only differ in their Title
+func lintLocations(db *model.Database) []Finding {
+	var findings []Finding
+	seen := map[string]int{}
+	for _, location := range db.Location {
+		if location == nil {
+			continue
+		}
+
+		key := location.UniqueKey()
+		if first, ok := seen[key]; ok {
+			findings = append(findings, Finding{
+				Check:    CheckDuplicateLocation,
+				Severity: Warning,
+				Table:    "Location",
+				ID:       location.LocationID,
+				Message:  fmt.Sprintf("Is the same location as the Location %d", first),
+				Fix:      "Move its entries to the other Location and remove it",
+			})
+			continue
+		}
+		seen[key] = location.LocationID
+	}
+	return findings
+}
+
+// lintGUIDs checks the GUIDs of Notes and UserMarks
+func lintGUIDs(db *model.Database) []Finding {
+	var findings []Finding
+	check := func(table string, id int, guid string, seen map[string]int) {
+		if strings.TrimSpace(guid) == "" || guid == emptyGUID {
+			findings = append(findings, Finding{
+				Check:    CheckEmptyGUID,
+				Severity: Error,
+				Table:    table,
+				ID:       id,
+				Message:  fmt.Sprintf("Has the empty GUID %q", guid),
+				Fix:      "Generate a new GUID",
+			})
+			return
+		}
+		if first, ok := seen[strings.ToUpper(guid)]; ok {
+			findings = append(findings, Finding{
+				Check:    CheckDuplicateGUID,
+				Severity: Error,
+				Table:    table,
+				ID:       id,
+				Message:  fmt.Sprintf("Has the same GUID %s as the entry %d", guid, first),
+				Fix:      "Generate a new GUID",
+			})
+			return
+		}
+		seen[strings.ToUpper(guid)] = id
+	}
+
+	seen := map[string]int{}
+	for _, note := range db.Note {
+		if note != nil {
+			check("Note", note.NoteID, note.GUID, seen)
+		}
+	}
+	seen = map[string]int{}
+	for _, um := range db.UserMark {
+		if um != nil {
+			check("UserMark", um.UserMarkID, um.UserMarkGUID, seen)
+		}
+	}
+	return findings
+}
+
+// exists checks if the entry with the given ID exists in the table
+func exists(db *model.Database, table string, id int) bool {
+	return id > 0 && db.FetchFromTable(table, id) != nil
+}
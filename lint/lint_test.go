@@ -0,0 +1,97 @@
+package lint
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	db := &model.Database{
+		BlockRange: []*model.BlockRange{
+			nil,
+			{BlockRangeID: 1, BlockType: 2, Identifier: 1, UserMarkID: 1},
+			{BlockRangeID: 2, BlockType: 2, Identifier: 200, UserMarkID: 1},
+			{BlockRangeID: 3, BlockType: 1, Identifier: 3, UserMarkID: 9},
+			{
+				BlockRangeID: 4,
+				BlockType:    1,
+				Identifier:   3,
+				StartToken:   sql.NullInt32{Int32: 5, Valid: true},
+				EndToken:     sql.NullInt32{Int32: 2, Valid: true},
+				UserMarkID:   1,
+			},
+		},
+		Bookmark: []*model.Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 2, Slot: 0},
+			{BookmarkID: 2, LocationID: 1, PublicationLocationID: 2, Slot: 0, BlockType: 1, BlockIdentifier: sql.NullInt32{Int32: 2, Valid: true}},
+			{BookmarkID: 3, LocationID: 1, PublicationLocationID: 2, Slot: 12, BlockType: 1, BlockIdentifier: sql.NullInt32{Int32: 3, Valid: true}},
+			{BookmarkID: 4, LocationID: 1, PublicationLocationID: 2, Slot: 4},
+		},
+		Location: []*model.Location{
+			nil,
+			{LocationID: 1, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}},
+			{LocationID: 2, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}, LocationType: 1},
+			{LocationID: 3, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}, Title: sql.NullString{String: "Title", Valid: true}},
+		},
+		Note: []*model.Note{
+			nil,
+			{NoteID: 1, GUID: "A", LocationID: sql.NullInt32{Int32: 1, Valid: true}},
+			{NoteID: 2, GUID: "B"},
+			{NoteID: 3, GUID: "a", LocationID: sql.NullInt32{Int32: 7, Valid: true}, UserMarkID: sql.NullInt32{Int32: 8, Valid: true}},
+		},
+		TagMap: []*model.TagMap{
+			nil,
+			{TagMapID: 1, TagID: 3, NoteID: sql.NullInt32{Int32: 1, Valid: true}},
+		},
+		UserMark: []*model.UserMark{
+			nil,
+			{UserMarkID: 1, LocationID: 1, UserMarkGUID: "C"},
+			{UserMarkID: 2, LocationID: 5, UserMarkGUID: "00000000-0000-0000-0000-000000000000"},
+		},
+	}
+
+	var got []string
+	for _, f := range Lint(db) {
+		got = append(got, f.String())
+		assert.NotEmpty(t, f.Fix)
+	}
+	assert.Equal(t, []string{
+		"error: BlockRange 2: Highlights the verse 200, which does not exist (blockrange-invalid-block)",
+		"error: BlockRange 3: Belongs to the highlight (UserMark) 9, which does not exist (blockrange-missing-usermark)",
+		"error: BlockRange 4: Starts at token 5, but ends before at token 2 (blockrange-invalid-block)",
+		"error: Bookmark 2: Uses the same slot 0 as the bookmark 1 (duplicate-bookmark)",
+		"error: Note 3: Has the same GUID a as the entry 1 (duplicate-guid)",
+		"error: Note 3: Refers to the Location 7, which does not exist (note-missing-location)",
+		"error: Note 3: Refers to the highlight (UserMark) 8, which does not exist (note-missing-usermark)",
+		"error: TagMap 1: Refers to the Tag 3, which does not exist (tagmap-dangling)",
+		`error: UserMark 2: Has the empty GUID "00000000-0000-0000-0000-000000000000" (empty-guid)`,
+		"error: UserMark 2: Highlight refers to the Location 5, which does not exist (usermark-missing-location)",
+		"warning: Bookmark 3: Uses the slot 12, but only 0 to 9 are available (bookmark-invalid-slot)",
+		"warning: Bookmark 4: Points at the same location as the bookmark 1 in slot 0 (redundant-bookmark)",
+		"warning: Location 3: Is the same location as the Location 1 (duplicate-location)",
+		"warning: UserMark 2: Highlight does not cover any text (usermark-without-range)",
+		"info: Note 2: Is not attached to any publication (note-without-location)",
+	}, got)
+
+	assert.Empty(t, Lint(&model.Database{}))
+
+	// Generated databases only contain notes without a Location
+	generated, err := model.GenerateTestDatabase(model.TestDatabaseSpec{
+		Notes: 500, Highlights: 500, Bookmarks: 30, Publications: 5, Tags: 10,
+	})
+	assert.NoError(t, err)
+	for _, f := range Lint(generated) {
+		assert.Equal(t, CheckNoteWithoutLocation, f.Check, f.String())
+	}
+}
+
+func TestSeverity_String(t *testing.T) {
+	assert.Equal(t, "info", Info.String())
+	assert.Equal(t, "warning", Warning.String())
+	assert.Equal(t, "error", Error.String())
+	assert.Equal(t, "Severity(5)", Severity(5).String())
+}
@@ -0,0 +1,265 @@
+package lint
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// maxRepairRounds limits how often Repair lints the Database again, as a
+// fix might reveal new findings (like a highlight without any ranges
+// after removing its invalid ones).
+const maxRepairRounds = 10
+
+// Change describes a modification Repair made to a Database
+type Change struct {
+	// Check is the name of the check whose finding has been fixed
+	Check string
+	// Table and ID point to the changed entry
+	Table       string
+	ID          int
+	Description string
+}
+
+// String returns a one-line description of the Change
+func (c Change) String() string {
+	return fmt.Sprintf("%s %d: %s (%s)", c.Table, c.ID, c.Description, c.Check)
+}
+
+// fix fixes the anomaly of a Finding and returns a description of the
+// change. If there was nothing to change, the description is empty.
+type fix func(db *model.Database, f Finding) (string, error)
+
+// fixes are the fixes for the checks that can be repaired safely
+var fixes = map[string]fix{
+	CheckNoteMissingLocation:     fixNoteMissingLocation,
+	CheckNoteMissingUserMark:     fixNoteMissingUserMark,
+	CheckUserMarkMissingLocation: fixUserMarkMissingLocation,
+	CheckUserMarkWithoutRange:    fixUserMarkWithoutRange,
+	CheckBlockRangeMissingMark:   fixBlockRange,
+	CheckBlockRangeInvalidBlock:  fixBlockRange,
+	CheckTagMapDangling:          fixTagMapDangling,
+	CheckDuplicateBookmark:       fixBookmarkSlot,
+	CheckBookmarkInvalidSlot:     fixBookmarkSlot,
+	CheckRedundantBookmark:       fixRedundantBookmark,
+	CheckDuplicateLocation:       fixDuplicateLocation,
+	CheckEmptyGUID:               fixGUID,
+	CheckDuplicateGUID:           fixGUID,
+}
+
+// Repair applies safe fixes for the findings of Lint and returns every
+// change it made. Findings without a safe fix (like notes without any
+// location) are left as they are. Removed entries are set to nil.
+func Repair(db *model.Database) ([]Change, error) {
+	var changes []Change
+	for round := 0; round < maxRepairRounds; round++ {
+		applied := false
+		for _, finding := range Lint(db) {
+			fix, ok := fixes[finding.Check]
+			if !ok {
+				continue
+			}
+			description, err := fix(db, finding)
+			if err != nil {
+				return changes, err
+			}
+			if description == "" {
+				continue
+			}
+			changes = append(changes, Change{
+				Check:       finding.Check,
+				Table:       finding.Table,
+				ID:          finding.ID,
+				Description: description,
+			})
+			applied = true
+		}
+		if !applied {
+			break
+		}
+	}
+	return changes, nil
+}
+
+// fixNoteMissingLocation attaches the Note to the Location of its
+// highlight. If there is none, the reference to the Location is removed.
+func fixNoteMissingLocation(db *model.Database, f Finding) (string, error) {
+	note, ok := db.FetchFromTable("Note", f.ID).(*model.Note)
+	if !ok || !note.LocationID.Valid || exists(db, "Location", int(note.LocationID.Int32)) {
+		return "", nil
+	}
+
+	if note.UserMarkID.Valid {
+		if um, ok := db.FetchFromTable("UserMark", int(note.UserMarkID.Int32)).(*model.UserMark); ok && exists(db, "Location", um.LocationID) {
+			note.LocationID = sql.NullInt32{Int32: int32(um.LocationID), Valid: true}
+			return fmt.Sprintf("Attached the note to the Location %d of its highlight", um.LocationID), nil
+		}
+	}
+
+	missing := note.LocationID.Int32
+	note.LocationID = sql.NullInt32{}
+	note.BlockType = 0
+	note.BlockIdentifier = sql.NullInt32{}
+	return fmt.Sprintf("Removed the reference to the missing Location %d", missing), nil
+}
+
+// fixNoteMissingUserMark removes the reference to the missing highlight
+func fixNoteMissingUserMark(db *model.Database, f Finding) (string, error) {
+	note, ok := db.FetchFromTable("Note", f.ID).(*model.Note)
+	if !ok || !note.UserMarkID.Valid || exists(db, "UserMark", int(note.UserMarkID.Int32)) {
+		return "", nil
+	}
+
+	missing := note.UserMarkID.Int32
+	note.UserMarkID = sql.NullInt32{}
+	return fmt.Sprintf("Removed the reference to the missing highlight %d", missing), nil
+}
+
+// fixUserMarkMissingLocation attaches the highlight to the Location of a Note
+// that belongs to it. If there is none, the highlight is removed.
+func fixUserMarkMissingLocation(db *model.Database, f Finding) (string, error) {
+	um, ok := db.FetchFromTable("UserMark", f.ID).(*model.UserMark)
+	if !ok || exists(db, "Location", um.LocationID) {
+		return "", nil
+	}
+
+	for _, note := range db.Note {
+		if note == nil || !note.UserMarkID.Valid || int(note.UserMarkID.Int32) != um.UserMarkID {
+			continue
+		}
+		if note.LocationID.Valid && exists(db, "Location", int(note.LocationID.Int32)) {
+			um.LocationID = int(note.LocationID.Int32)
+			return fmt.Sprintf("Attached the highlight to the Location %d of its note", um.LocationID), nil
+		}
+	}
+
+	return removeUserMark(db, um), nil
+}
+
+// fixUserMarkWithoutRange removes the highlight
+func fixUserMarkWithoutRange(db *model.Database, f Finding) (string, error) {
+	um, ok := db.FetchFromTable("UserMark", f.ID).(*model.UserMark)
+	if !ok {
+		return "", nil
+	}
+	return removeUserMark(db, um), nil
+}
+
+// removeUserMark removes the highlight together with its BlockRanges
+// and the references of Notes to it
+func removeUserMark(db *model.Database, um *model.UserMark) string {
+	ranges := 0
+	for i, br := range db.BlockRange {
+		if br != nil && br.UserMarkID == um.UserMarkID {
+			db.BlockRange[i] = nil
+			ranges++
+		}
+	}
+	for _, note := range db.Note {
+		if note != nil && note.UserMarkID.Valid && int(note.UserMarkID.Int32) == um.UserMarkID {
+			note.UserMarkID = sql.NullInt32{}
+		}
+	}
+	db.UserMark[um.UserMarkID] = nil
+
+	return fmt.Sprintf("Removed the highlight together with %d range(s)", ranges)
+}
+
+// fixBlockRange removes the BlockRange
+func fixBlockRange(db *model.Database, f Finding) (string, error) {
+	if !exists(db, "BlockRange", f.ID) {
+		return "", nil
+	}
+	db.BlockRange[f.ID] = nil
+	return "Removed the range", nil
+}
+
+// fixTagMapDangling removes the TagMap
+func fixTagMapDangling(db *model.Database, f Finding) (string, error) {
+	if !exists(db, "TagMap", f.ID) {
+		return "", nil
+	}
+	db.TagMap[f.ID] = nil
+	return "Removed the entry from its tag", nil
+}
+
+// fixBookmarkSlot moves the Bookmark to a free slot of its
+// publication. If all slots are taken, the Bookmark is removed.
+func fixBookmarkSlot(db *model.Database, f Finding) (string, error) {
+	bm, ok := db.FetchFromTable("Bookmark", f.ID).(*model.Bookmark)
+	if !ok {
+		return "", nil
+	}
+
+	taken := map[int]bool{}
+	for _, other := range db.Bookmark {
+		if other != nil && other != bm && other.PublicationLocationID == bm.PublicationLocationID {
+			taken[other.Slot] = true
+		}
+	}
+	if bm.Slot >= 0 && bm.Slot <= maxBookmarkSlot && !taken[bm.Slot] {
+		return "", nil
+	}
+
+	for slot := 0; slot <= maxBookmarkSlot; slot++ {
+		if !taken[slot] {
+			bm.Slot = slot
+			return fmt.Sprintf("Moved the bookmark to the free slot %d", slot), nil
+		}
+	}
+
+	db.Bookmark[bm.BookmarkID] = nil
+	return "Removed the bookmark, as there is no free slot left", nil
+}
+
+// fixRedundantBookmark removes the Bookmark, freeing its slot
+func fixRedundantBookmark(db *model.Database, f Finding) (string, error) {
+	bm, ok := db.FetchFromTable("Bookmark", f.ID).(*model.Bookmark)
+	if !ok {
+		return "", nil
+	}
+	db.Bookmark[bm.BookmarkID] = nil
+	return fmt.Sprintf("Removed the bookmark, freeing the slot %d", bm.Slot), nil
+}
+
+// fixDuplicateLocation moves the entries of the Location to the
+// first Location that is equal to it and removes it
+func fixDuplicateLocation(db *model.Database, f Finding) (string, error) {
+	location, ok := db.FetchFromTable("Location", f.ID).(*model.Location)
+	if !ok {
+		return "", nil
+	}
+
+	for _, first := range db.Location {
+		if first == nil || first == location {
+			continue
+		}
+		if first.UniqueKey() == location.UniqueKey() {
+			if !first.Title.Valid || first.Title.String == "" {
+				first.Title = location.Title
+			}
+			db.MoveLocations(map[int]int{location.LocationID: first.LocationID})
+			return fmt.Sprintf("Merged the location into the Location %d", first.LocationID), nil
+		}
+	}
+	return "", nil
+}
+
+// fixGUID generates a new GUID for the Note or UserMark
+func fixGUID(db *model.Database, f Finding) (string, error) {
+	guid, err := model.NewGUID()
+	if err != nil {
+		return "", err
+	}
+
+	switch entry := db.FetchFromTable(f.Table, f.ID).(type) {
+	case *model.Note:
+		entry.GUID = guid
+	case *model.UserMark:
+		entry.UserMarkGUID = guid
+	default:
+		return "", nil
+	}
+	return fmt.Sprintf("Generated the new GUID %s", guid), nil
+}
@@ -0,0 +1,226 @@
+package lint
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepair(t *testing.T) {
+	db := &model.Database{
+		BlockRange: []*model.BlockRange{
+			nil,
+			{BlockRangeID: 1, BlockType: 2, Identifier: 1, UserMarkID: 1},
+			{BlockRangeID: 2, BlockType: 2, Identifier: 200, UserMarkID: 2},
+			{BlockRangeID: 3, BlockType: 1, Identifier: 3, UserMarkID: 9},
+			{BlockRangeID: 4, BlockType: 2, Identifier: 4, UserMarkID: 3},
+		},
+		Bookmark: []*model.Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 2, Slot: 0},
+			{BookmarkID: 2, LocationID: 1, PublicationLocationID: 2, Slot: 0, BlockType: 1, BlockIdentifier: sql.NullInt32{Int32: 2, Valid: true}},
+			{BookmarkID: 3, LocationID: 1, PublicationLocationID: 2, Slot: 12, BlockType: 1, BlockIdentifier: sql.NullInt32{Int32: 3, Valid: true}},
+			{BookmarkID: 4, LocationID: 1, PublicationLocationID: 2, Slot: 5},
+		},
+		Location: []*model.Location{
+			nil,
+			{LocationID: 1, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}},
+			{LocationID: 2, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}, LocationType: 1},
+		},
+		Note: []*model.Note{
+			nil,
+			{NoteID: 1, GUID: "A", LocationID: sql.NullInt32{Int32: 1, Valid: true}, UserMarkID: sql.NullInt32{Int32: 3, Valid: true}},
+			{NoteID: 2, GUID: "B"},
+			{
+				NoteID:          3,
+				GUID:            "a",
+				LocationID:      sql.NullInt32{Int32: 7, Valid: true},
+				UserMarkID:      sql.NullInt32{Int32: 1, Valid: true},
+				BlockType:       2,
+				BlockIdentifier: sql.NullInt32{Int32: 1, Valid: true},
+			},
+			{
+				NoteID:          4,
+				GUID:            "D",
+				LocationID:      sql.NullInt32{Int32: 8, Valid: true},
+				UserMarkID:      sql.NullInt32{Int32: 8, Valid: true},
+				BlockType:       2,
+				BlockIdentifier: sql.NullInt32{Int32: 1, Valid: true},
+			},
+		},
+		Tag: []*model.Tag{
+			nil,
+			{TagID: 1, TagType: 1, Name: "Tag"},
+		},
+		TagMap: []*model.TagMap{
+			nil,
+			{TagMapID: 1, TagID: 1, NoteID: sql.NullInt32{Int32: 1, Valid: true}},
+			{TagMapID: 2, TagID: 1, NoteID: sql.NullInt32{Int32: 9, Valid: true}, Position: 1},
+			{TagMapID: 3, TagID: 2, NoteID: sql.NullInt32{Int32: 1, Valid: true}},
+		},
+		UserMark: []*model.UserMark{
+			nil,
+			{UserMarkID: 1, LocationID: 1, UserMarkGUID: "C"},
+			{UserMarkID: 2, LocationID: 1, UserMarkGUID: ""},
+			{UserMarkID: 3, LocationID: 5, UserMarkGUID: "E"},
+		},
+	}
+
+	changes, err := Repair(db)
+	assert.NoError(t, err)
+
+	var got []string
+	for _, c := range changes {
+		if c.Check == CheckEmptyGUID || c.Check == CheckDuplicateGUID {
+			assert.Contains(t, c.Description, "Generated the new GUID")
+			c.Description = "Generated a new GUID"
+		}
+		got = append(got, c.String())
+	}
+	assert.Equal(t, []string{
+		"BlockRange 2: Removed the range (blockrange-invalid-block)",
+		"BlockRange 3: Removed the range (blockrange-missing-usermark)",
+		"Bookmark 2: Moved the bookmark to the free slot 1 (duplicate-bookmark)",
+		"Note 3: Generated a new GUID (duplicate-guid)",
+		"Note 3: Attached the note to the Location 1 of its highlight (note-missing-location)",
+		"Note 4: Removed the reference to the missing Location 8 (note-missing-location)",
+		"Note 4: Removed the reference to the missing highlight 8 (note-missing-usermark)",
+		"TagMap 2: Removed the entry from its tag (tagmap-dangling)",
+		"TagMap 3: Removed the entry from its tag (tagmap-dangling)",
+		"UserMark 2: Generated a new GUID (empty-guid)",
+		"UserMark 3: Attached the highlight to the Location 1 of its note (usermark-missing-location)",
+		"Bookmark 3: Moved the bookmark to the free slot 2 (bookmark-invalid-slot)",
+		"Bookmark 4: Removed the bookmark, freeing the slot 5 (redundant-bookmark)",
+		"UserMark 2: Removed the highlight together with 0 range(s) (usermark-without-range)",
+	}, got)
+
+	assert.Nil(t, db.Bookmark[4])
+	assert.Nil(t, db.BlockRange[2])
+	assert.Nil(t, db.BlockRange[3])
+	assert.Nil(t, db.UserMark[2])
+	assert.Nil(t, db.TagMap[2])
+	assert.Nil(t, db.TagMap[3])
+	assert.NotEqual(t, "a", db.Note[3].GUID)
+	assert.Equal(t, 0, db.Note[4].BlockType)
+	assert.False(t, db.Note[4].BlockIdentifier.Valid)
+
+	// Only findings without a safe fix are left
+	for _, f := range Lint(db) {
+		assert.Equal(t, CheckNoteWithoutLocation, f.Check)
+	}
+
+	changes, err = Repair(db)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestRepair_NoFreeBookmarkSlot(t *testing.T) {
+	db := &model.Database{Bookmark: []*model.Bookmark{nil}}
+	for i := 1; i <= 11; i++ {
+		slot := i - 1
+		if slot > maxBookmarkSlot {
+			slot = 0
+		}
+		db.Bookmark = append(db.Bookmark, &model.Bookmark{BookmarkID: i, LocationID: i, PublicationLocationID: 1, Slot: slot})
+	}
+
+	changes, err := Repair(db)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{
+			Check:       CheckDuplicateBookmark,
+			Table:       "Bookmark",
+			ID:          11,
+			Description: "Removed the bookmark, as there is no free slot left",
+		},
+	}, changes)
+	assert.Nil(t, db.Bookmark[11])
+}
+
+func TestRepair_DuplicateLocation(t *testing.T) {
+	db := &model.Database{
+		Bookmark: []*model.Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 3, PublicationLocationID: 2, Slot: 0},
+		},
+		Location: []*model.Location{
+			nil,
+			{LocationID: 1, DocumentID: sql.NullInt32{Int32: 1, Valid: true}, KeySymbol: sql.NullString{String: "w", Valid: true}},
+			{LocationID: 2, KeySymbol: sql.NullString{String: "w", Valid: true}, LocationType: 1},
+			{
+				LocationID: 3,
+				DocumentID: sql.NullInt32{Int32: 1, Valid: true},
+				KeySymbol:  sql.NullString{String: "w", Valid: true},
+				Title:      sql.NullString{String: "Article", Valid: true},
+			},
+		},
+		Note: []*model.Note{
+			nil,
+			{NoteID: 1, GUID: "A", LocationID: sql.NullInt32{Int32: 3, Valid: true}},
+		},
+		TagMap: []*model.TagMap{
+			nil,
+			{TagMapID: 1, TagID: 1, LocationID: sql.NullInt32{Int32: 1, Valid: true}},
+			{TagMapID: 2, TagID: 1, LocationID: sql.NullInt32{Int32: 3, Valid: true}, Position: 1},
+		},
+		Tag: []*model.Tag{
+			nil,
+			{TagID: 1, TagType: 1, Name: "Tag"},
+		},
+	}
+
+	changes, err := Repair(db)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{
+			Check:       CheckDuplicateLocation,
+			Table:       "Location",
+			ID:          3,
+			Description: "Merged the location into the Location 1",
+		},
+	}, changes)
+	assert.Nil(t, db.Location[3])
+	assert.Equal(t, "Article", db.Location[1].Title.String)
+	assert.Equal(t, 1, db.Bookmark[1].LocationID)
+	assert.Equal(t, int32(1), db.Note[1].LocationID.Int32)
+	assert.Nil(t, db.TagMap[2])
+}
+
+func TestRepair_RedundantBookmark(t *testing.T) {
+	paragraph := sql.NullInt32{Int32: 3, Valid: true}
+	db := &model.Database{
+		Bookmark: []*model.Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 2, Slot: 7, Title: "Restored", BlockType: 1, BlockIdentifier: paragraph},
+			{BookmarkID: 2, LocationID: 1, PublicationLocationID: 2, Slot: 2, Title: "Original", BlockType: 1, BlockIdentifier: paragraph},
+			{BookmarkID: 3, LocationID: 1, PublicationLocationID: 2, Slot: 4, Title: "Restored again", BlockType: 1, BlockIdentifier: paragraph},
+			// Other paragraphs and publications are kept
+			{BookmarkID: 4, LocationID: 1, PublicationLocationID: 2, Slot: 5, BlockType: 1, BlockIdentifier: sql.NullInt32{Int32: 4, Valid: true}},
+			{BookmarkID: 5, LocationID: 1, PublicationLocationID: 3, Slot: 0, BlockType: 1, BlockIdentifier: paragraph},
+		},
+	}
+
+	changes, err := Repair(db)
+	assert.NoError(t, err)
+	assert.Equal(t, []Change{
+		{
+			Check:       CheckRedundantBookmark,
+			Table:       "Bookmark",
+			ID:          1,
+			Description: "Removed the bookmark, freeing the slot 7",
+		},
+		{
+			Check:       CheckRedundantBookmark,
+			Table:       "Bookmark",
+			ID:          3,
+			Description: "Removed the bookmark, freeing the slot 4",
+		},
+	}, changes)
+	assert.Nil(t, db.Bookmark[1])
+	assert.Equal(t, "Original", db.Bookmark[2].Title)
+	assert.Nil(t, db.Bookmark[3])
+	assert.NotNil(t, db.Bookmark[4])
+	assert.NotNil(t, db.Bookmark[5])
+}
@@ -0,0 +1,196 @@
+package merger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// MergeOptions configure MergeBackups
+type MergeOptions struct {
+	// Editions are Bible editions that are merged into each other
+	// (see LocationOptions)
+	Editions map[string]string
+	// TagMapOrder determines how the entries of a Tag are ordered
+	// (see TagMapOptions)
+	TagMapOrder TagMapOrder
+	// MoveCollidingBookmarks moves bookmarks of the right side that use
+	// the slot of a different bookmark to a free slot before merging
+	// them (see MoveCollidingBookmarks)
+	MoveCollidingBookmarks bool
+	// AppendOnly makes sure that the automatic solving of conflicts
+	// never changes entries of the left side: Notes with the same GUID
+	// are solved in favor of the left side and the TagMaps are ordered
+	// by OrderLeftFirst, regardless of TagMapOrder.
+	AppendOnly bool
+	// Solve is called with the conflicts of a stage (like StageNotes)
+	// and the partially merged Database and returns their solutions.
+	// The stage is merged again afterwards, which might bring up new
+	// conflicts. If Solve is nil, MergeBackups returns the
+	// MergeConflictError of the first stage with conflicts.
+	Solve func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error)
+	// Logger receives the messages of the merge functions.
+	// If it is nil, they are discarded.
+	Logger model.Logger
+	// Metrics receives the duration and the number of conflicts every
+	// time a stage has been merged. If it is nil, nothing is measured.
+	Metrics Metrics
+	// Progress is called before (finished is false) and after (finished is
+	// true) each stage. If it returns an error, the merge is aborted.
+	Progress func(stage string, finished bool) error
+}
+
+// MergeResult is the outcome of MergeBackups
+type MergeResult struct {
+	Merged *model.Database
+	// Solutions contains the solutions of the conflicts of every stage,
+	// as they are needed by VerifyMerge
+	Solutions map[string]map[string]MergeSolution
+	// MovedBookmarks are the bookmarks of the right side that have been
+	// moved to a free slot (see MergeOptions.MoveCollidingBookmarks)
+	MovedBookmarks []*model.Bookmark
+}
+
+// MergeBackups merges the right Database into the left one. It runs the
+// merge functions of all tables in the order they depend on each other
+// and updates the IDs of the entries referring to merged ones in
+// between, so the IDs of left and right are changed. Use
+// model.MakeDatabaseCopy to keep the original ones.
+func MergeBackups(left *model.Database, right *model.Database, opts MergeOptions) (*MergeResult, error) {
+	result := &MergeResult{
+		Merged:    &model.Database{},
+		Solutions: map[string]map[string]MergeSolution{},
+	}
+	merged := result.Merged
+
+	progress := func(stage string, finished bool) error {
+		if opts.Progress == nil {
+			return nil
+		}
+		return opts.Progress(stage, finished)
+	}
+	// run merges a stage until there are no conflicts left
+	run := func(stage string, merge func(solutions map[string]MergeSolution) error) error {
+		if err := progress(stage, false); err != nil {
+			return err
+		}
+		solutions := map[string]MergeSolution{}
+		result.Solutions[stage] = solutions
+		for {
+			start := time.Now()
+			err := merge(solutions)
+			observeStage(opts.Metrics, stage, start, err)
+			if err == nil {
+				break
+			}
+			var conflictErr MergeConflictError
+			if !errors.As(err, &conflictErr) || opts.Solve == nil {
+				return fmt.Errorf("Error while merging %s: %w", stage, err)
+			}
+			solved, err := opts.Solve(stage, conflictErr.Conflicts, merged)
+			if err != nil {
+				return err
+			}
+			for key, solution := range solved {
+				solutions[key] = solution
+			}
+		}
+		return progress(stage, true)
+	}
+
+	for _, stage := range Stages {
+		// Bookmarks can only be moved once their Locations have been merged
+		if stage == StageBookmarks && opts.MoveCollidingBookmarks {
+			result.MovedBookmarks = MoveCollidingBookmarks(left.Bookmark, right.Bookmark)
+		}
+		err := run(stage, func(solutions map[string]MergeSolution) error {
+			return MergeStage(stage, left, right, merged, solutions, opts)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// Stages are the stages of MergeBackups in the order they are merged
+var Stages = []string{StageLocations, StageBookmarks, StageTags, StageMarkings, StageNotes, StageTagMaps}
+
+// MergeStage merges the tables of a single stage of MergeBackups into
+// merged, using the given solutions for conflicts, and updates the IDs of
+// the entries of left and right that refer to the merged ones. As the
+// stages depend on each other, they have to be merged in the order of
+// Stages. If there are conflicts, it returns a MergeConflictError and
+// the stage has to be merged again with their solutions. Only the options
+// concerning a single stage are used, so MoveCollidingBookmarks, Solve,
+// and Progress are ignored.
+func MergeStage(stage string, left *model.Database, right *model.Database, merged *model.Database, solutions map[string]MergeSolution, opts MergeOptions) error {
+	switch stage {
+	case StageLocations:
+		mergedLocations, idChanges, err := MergeLocationsWithOptions(left.Location, right.Location,
+			LocationOptions{Editions: opts.Editions, Logger: opts.Logger})
+		if err != nil {
+			return err
+		}
+		merged.Location = mergedLocations
+		UpdateLRIDs(left.Bookmark, right.Bookmark, "LocationID", idChanges)
+		UpdateLRIDs(left.Bookmark, right.Bookmark, "PublicationLocationID", idChanges)
+		UpdateLRIDs(left.Note, right.Note, "LocationID", idChanges)
+		UpdateLRIDs(left.TagMap, right.TagMap, "LocationID", idChanges)
+		UpdateLRIDs(left.UserMark, right.UserMark, "LocationID", idChanges)
+
+	case StageBookmarks:
+		mergedBookmarks, _, err := MergeBookmarks(left.Bookmark, right.Bookmark, solutions)
+		if err != nil {
+			return err
+		}
+		merged.Bookmark = mergedBookmarks
+
+	case StageTags:
+		mergedTags, idChanges, err := MergeTags(left.Tag, right.Tag, solutions)
+		if err != nil {
+			return err
+		}
+		merged.Tag = mergedTags
+		UpdateLRIDs(left.TagMap, right.TagMap, "TagID", idChanges)
+
+	case StageMarkings:
+		mergedUserMarks, mergedBlockRanges, idChanges, err := MergeUserMarkAndBlockRange(
+			left.UserMark, left.BlockRange, right.UserMark, right.BlockRange, solutions)
+		if err != nil {
+			return err
+		}
+		merged.UserMark = mergedUserMarks
+		merged.BlockRange = mergedBlockRanges
+		UpdateLRIDs(left.Note, right.Note, "UserMarkID", idChanges)
+
+	case StageNotes:
+		mergedNotes, idChanges, err := MergeNotesWithOptions(left.Note, right.Note, solutions,
+			NoteOptions{LeftTagMaps: left.TagMap, RightTagMaps: right.TagMap, Merged: merged, KeepLeft: opts.AppendOnly})
+		if err != nil {
+			return err
+		}
+		merged.Note = mergedNotes
+		UpdateLRIDs(left.TagMap, right.TagMap, "NoteID", idChanges)
+
+	case StageTagMaps:
+		tagMapOrder := opts.TagMapOrder
+		if opts.AppendOnly {
+			tagMapOrder = OrderLeftFirst
+		}
+		mergedTagMaps, _, err := MergeTagMapsWithOptions(left.TagMap, right.TagMap, solutions,
+			TagMapOptions{Order: tagMapOrder, Notes: merged.Note})
+		if err != nil {
+			return err
+		}
+		merged.TagMap = mergedTagMaps
+
+	default:
+		return fmt.Errorf("Stage %s does not exist", stage)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package merger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeBackups(t *testing.T) {
+	left, right := verifyTestDatabases()
+	var progress []string
+	var solved []string
+	result, err := MergeBackups(left, right, MergeOptions{
+		Solve: func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+			solved = append(solved, stage)
+			// Earlier stages are already merged
+			assert.NotEmpty(t, merged.Location)
+			return SolveConflictByChoosingRight(conflicts)
+		},
+		Progress: func(stage string, finished bool) error {
+			if finished {
+				progress = append(progress, stage+" done")
+			} else {
+				progress = append(progress, stage)
+			}
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"Locations", "Locations done",
+		"Bookmarks", "Bookmarks done",
+		"Tags", "Tags done",
+		"Markings", "Markings done",
+		"Notes", "Notes done",
+		"TagMaps", "TagMaps done",
+	}, progress)
+	assert.Equal(t, []string{StageBookmarks, StageMarkings, StageNotes}, solved)
+	assert.Len(t, result.Solutions[StageBookmarks], 1)
+	assert.Len(t, result.Solutions[StageMarkings], 1)
+	assert.Len(t, result.Solutions[StageNotes], 1)
+	assert.Empty(t, result.MovedBookmarks)
+
+	notes := map[string]string{}
+	for _, note := range result.Merged.Note {
+		if note != nil {
+			notes[note.GUID] = note.Content.String
+		}
+	}
+	assert.Equal(t, map[string]string{"A": "Right", "B": "Only left", "C": "Only right"}, notes)
+	assert.Len(t, result.Merged.TagMap, 4)
+}
+
+func TestMergeStage(t *testing.T) {
+	left, right := verifyTestDatabases()
+	expected, err := MergeBackups(left, right, MergeOptions{
+		Solve: func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+			return SolveConflictByChoosingRight(conflicts)
+		},
+	})
+	assert.NoError(t, err)
+
+	// Merging the stages one by one gives the same result
+	left, right = verifyTestDatabases()
+	merged := &model.Database{}
+	for _, stage := range Stages {
+		solutions := map[string]MergeSolution{}
+		err := MergeStage(stage, left, right, merged, solutions, MergeOptions{})
+		var conflictErr MergeConflictError
+		if errors.As(err, &conflictErr) {
+			solutions, err = SolveConflictByChoosingRight(conflictErr.Conflicts)
+			assert.NoError(t, err)
+			err = MergeStage(stage, left, right, merged, solutions, MergeOptions{})
+		}
+		assert.NoError(t, err, stage)
+	}
+	assert.True(t, expected.Merged.Equals(merged))
+
+	assert.EqualError(t, MergeStage("Playlists", left, right, merged, nil, MergeOptions{}), "Stage Playlists does not exist")
+}
+
+func TestMergeBackups_Failures(t *testing.T) {
+	// Without Solve, the conflicts are returned
+	left, right := verifyTestDatabases()
+	_, err := MergeBackups(left, right, MergeOptions{})
+	var conflictErr MergeConflictError
+	assert.True(t, errors.As(err, &conflictErr))
+	assert.Len(t, conflictErr.Conflicts, 1)
+	assert.Contains(t, err.Error(), "Error while merging Bookmarks")
+
+	left, right = verifyTestDatabases()
+	_, err = MergeBackups(left, right, MergeOptions{
+		Solve: func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+			return nil, errors.New("Canceled")
+		},
+	})
+	assert.EqualError(t, err, "Canceled")
+
+	left, right = verifyTestDatabases()
+	_, err = MergeBackups(left, right, MergeOptions{
+		Progress: func(stage string, finished bool) error {
+			if stage == StageBookmarks {
+				return errors.New("Canceled")
+			}
+			return nil
+		},
+	})
+	assert.EqualError(t, err, "Canceled")
+}
+
+func TestMergeBackups_MoveCollidingBookmarks(t *testing.T) {
+	// Both bookmarks use the first slot of the same publication
+	left, right := verifyTestDatabases()
+	_, err := MergeBackups(model.MakeDatabaseCopy(left), model.MakeDatabaseCopy(right), MergeOptions{
+		Solve: func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+			assert.NotEqual(t, StageBookmarks, stage)
+			return SolveConflictByChoosingLeft(conflicts)
+		},
+		MoveCollidingBookmarks: true,
+	})
+	assert.NoError(t, err)
+
+	result, err := MergeBackups(left, right, MergeOptions{
+		Solve: func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+			return SolveConflictByChoosingLeft(conflicts)
+		},
+		MoveCollidingBookmarks: true,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, result.MovedBookmarks, 1) {
+		assert.Equal(t, "Right", result.MovedBookmarks[0].Title)
+		assert.Equal(t, 1, result.MovedBookmarks[0].Slot)
+	}
+}
+
+func Benchmark_MergeBackups(b *testing.B) {
+	spec := model.TestDatabaseSpec{
+		Notes:        20000,
+		Highlights:   20000,
+		Bookmarks:    50,
+		Publications: 10,
+		Tags:         50,
+	}
+	left, err := model.GenerateTestDatabase(spec)
+	if err != nil {
+		b.Fatal(err)
+	}
+	spec.Seed = 1
+	right, err := model.GenerateTestDatabase(spec)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		l, r := model.MakeDatabaseCopy(left), model.MakeDatabaseCopy(right)
+		b.StartTimer()
+		_, err := MergeBackups(l, r, MergeOptions{
+			Solve: func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+				return SolveConflictByChoosingLeft(conflicts)
+			},
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -11,3 +11,52 @@ func MergeBookmarks(left []*model.Bookmark, right []*model.Bookmark, conflictSol
 
 	return model.Bookmark{}.MakeSlice(result), changes, err
 }
+
+// maxBookmarkSlot is the highest slot of the bookmarks of a publication
+const maxBookmarkSlot = 9
+
+// MoveCollidingBookmarks moves Bookmarks of the right side that would
+// collide with a different Bookmark of the left side (i.e. that use the
+// same slot of the same publication) to a slot that is free on both sides,
+// so both of them are kept when merging. Bookmarks for which no free slot
+// is left are not moved. It returns the Bookmarks that have been moved.
+func MoveCollidingBookmarks(left []*model.Bookmark, right []*model.Bookmark) []*model.Bookmark {
+	taken := map[int]map[int]bool{}
+	leftSlots := map[string]*model.Bookmark{}
+	for _, side := range [][]*model.Bookmark{left, right} {
+		for _, bm := range side {
+			if bm == nil {
+				continue
+			}
+			if taken[bm.PublicationLocationID] == nil {
+				taken[bm.PublicationLocationID] = map[int]bool{}
+			}
+			taken[bm.PublicationLocationID][bm.Slot] = true
+		}
+	}
+	for _, bm := range left {
+		if bm != nil {
+			leftSlots[bm.UniqueKey()] = bm
+		}
+	}
+
+	var moved []*model.Bookmark
+	for _, bm := range right {
+		if bm == nil {
+			continue
+		}
+		other, ok := leftSlots[bm.UniqueKey()]
+		if !ok || other.Equals(bm) {
+			continue
+		}
+		for slot := 0; slot <= maxBookmarkSlot; slot++ {
+			if !taken[bm.PublicationLocationID][slot] {
+				taken[bm.PublicationLocationID][slot] = true
+				bm.Slot = slot
+				moved = append(moved, bm)
+				break
+			}
+		}
+	}
+	return moved
+}
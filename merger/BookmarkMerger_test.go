@@ -393,3 +393,34 @@ func TestMergeBookmarks(t *testing.T) {
 	assert.Equal(t, expectedResult, result)
 	assert.Equal(t, expectedChanges, changes)
 }
+
+func TestMoveCollidingBookmarks(t *testing.T) {
+	left := []*model.Bookmark{
+		nil,
+		{BookmarkID: 1, LocationID: 1, PublicationLocationID: 1, Slot: 0, Title: "Left"},
+		{BookmarkID: 2, LocationID: 2, PublicationLocationID: 1, Slot: 1, Title: "Same"},
+		{BookmarkID: 3, LocationID: 3, PublicationLocationID: 2, Slot: 0, Title: "Full"},
+	}
+	right := []*model.Bookmark{
+		nil,
+		{BookmarkID: 1, LocationID: 4, PublicationLocationID: 1, Slot: 0, Title: "Right"},
+		{BookmarkID: 2, LocationID: 2, PublicationLocationID: 1, Slot: 1, Title: "Same"},
+		{BookmarkID: 3, LocationID: 5, PublicationLocationID: 1, Slot: 2, Title: "Other"},
+		{BookmarkID: 4, LocationID: 6, PublicationLocationID: 2, Slot: 0, Title: "NoSlotLeft"},
+	}
+	for slot := 1; slot <= maxBookmarkSlot; slot++ {
+		right = append(right, &model.Bookmark{BookmarkID: 4 + slot, LocationID: 6 + slot, PublicationLocationID: 2, Slot: slot})
+	}
+
+	moved := MoveCollidingBookmarks(left, right)
+	assert.Equal(t, []*model.Bookmark{right[1]}, moved)
+	assert.Equal(t, 3, right[1].Slot)
+	assert.Equal(t, 1, right[2].Slot)
+	assert.Equal(t, 2, right[3].Slot)
+	assert.Equal(t, 0, right[4].Slot)
+	assert.Equal(t, 0, left[1].Slot)
+
+	merged, _, err := MergeBookmarks(left, right[:4], nil)
+	assert.NoError(t, err)
+	assert.Len(t, merged, 6)
+}
@@ -0,0 +1,200 @@
+package merger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// mergeStages lists the stages of a merge in the order MergeBackups runs them
+var mergeStages = []string{StageLocations, StageBookmarks, StageTags, StageMarkings, StageNotes, StageTagMaps}
+
+// throughput is the number of entries per second each stage merges. It
+// starts with rough values and is refined every time an ETA sees a stage
+// finish, so later merges get more accurate estimates.
+var throughput = map[string]float64{
+	StageLocations: 50000,
+	StageBookmarks: 50000,
+	StageTags:      50000,
+	StageMarkings:  20000,
+	StageNotes:     20000,
+	StageTagMaps:   50000,
+}
+var throughputMutex sync.Mutex
+
+// minMeasuredEntries is the number of entries a stage needs to have
+// to update its throughput, as the duration of smaller stages is
+// dominated by the overhead of the merge functions
+const minMeasuredEntries = 1000
+
+// Throughput returns the number of entries per second each stage
+// merged so far, so it can be stored and restored using SetThroughput.
+func Throughput() map[string]float64 {
+	throughputMutex.Lock()
+	defer throughputMutex.Unlock()
+
+	result := make(map[string]float64, len(throughput))
+	for stage, t := range throughput {
+		result[stage] = t
+	}
+	return result
+}
+
+// SetThroughput sets the number of entries per second of the given
+// stages, like the ones measured by a previous run of go-jwlm. Unknown
+// stages and values that are not positive are ignored.
+func SetThroughput(t map[string]float64) {
+	throughputMutex.Lock()
+	defer throughputMutex.Unlock()
+
+	for stage, value := range t {
+		if _, ok := throughput[stage]; ok && value > 0 {
+			throughput[stage] = value
+		}
+	}
+}
+
+// ETA estimates the remaining time of a merge using the number of entries
+// of every stage and their throughput (see Throughput). It is informed
+// about the progress of the merge by Progress, so it can be used within
+// MergeOptions.Progress, or by wrapping the options using Wrap.
+type ETA struct {
+	mu       sync.Mutex
+	entries  map[string]int
+	finished map[string]bool
+	// stage is the currently running stage, which started at start.
+	// Time spent solving conflicts (see Pause) is stored in paused.
+	stage       string
+	start       time.Time
+	paused      time.Duration
+	pausedSince time.Time
+	now         func() time.Time
+}
+
+// NewETA creates an ETA for merging the right Database into the left one.
+func NewETA(left *model.Database, right *model.Database) *ETA {
+	return &ETA{
+		entries: map[string]int{
+			StageLocations: len(left.Location) + len(right.Location),
+			StageBookmarks: len(left.Bookmark) + len(right.Bookmark),
+			StageTags:      len(left.Tag) + len(right.Tag),
+			StageMarkings: len(left.UserMark) + len(right.UserMark) +
+				len(left.BlockRange) + len(right.BlockRange),
+			StageNotes:   len(left.Note) + len(right.Note),
+			StageTagMaps: len(left.TagMap) + len(right.TagMap),
+		},
+		finished: map[string]bool{},
+		now:      time.Now,
+	}
+}
+
+// Progress informs the ETA that the given stage has been started (finished
+// is false) or finished. Starting a stage again, like after solving its
+// conflicts, restarts its measurement. When a stage finishes, its measured
+// throughput is taken into account for the estimates of later merges.
+func (e *ETA) Progress(stage string, finished bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	if !finished {
+		e.stage, e.start, e.paused, e.pausedSince = stage, now, 0, time.Time{}
+		return
+	}
+
+	e.finished[stage] = true
+	if stage != e.stage {
+		return
+	}
+	elapsed := e.elapsed(now)
+	e.stage = ""
+	if elapsed <= 0 || e.entries[stage] < minMeasuredEntries {
+		return
+	}
+
+	measured := float64(e.entries[stage]) / elapsed.Seconds()
+	throughputMutex.Lock()
+	defer throughputMutex.Unlock()
+	if t, ok := throughput[stage]; ok {
+		// Smooth the measurements, as a single merge might be
+		// slowed down by other work on the device
+		throughput[stage] = (t + measured) / 2
+	}
+}
+
+// Pause stops measuring the current stage until Resume is called,
+// so the time spent waiting for conflict solutions is not counted.
+func (e *ETA) Pause() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.pausedSince.IsZero() {
+		e.pausedSince = e.now()
+	}
+}
+
+// Resume continues measuring the current stage after Pause.
+func (e *ETA) Resume() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.pausedSince.IsZero() {
+		e.paused += e.now().Sub(e.pausedSince)
+		e.pausedSince = time.Time{}
+	}
+}
+
+// Remaining returns the estimated time until all stages are finished.
+func (e *ETA) Remaining() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	t := Throughput()
+	var remaining time.Duration
+	for _, stage := range mergeStages {
+		if e.finished[stage] || t[stage] <= 0 {
+			continue
+		}
+		expected := time.Duration(float64(e.entries[stage]) / t[stage] * float64(time.Second))
+		if stage == e.stage {
+			expected -= e.elapsed(e.now())
+			if expected < 0 {
+				expected = 0
+			}
+		}
+		remaining += expected
+	}
+	return remaining
+}
+
+// Wrap returns a copy of opts that informs the ETA about the progress
+// of the merge and pauses it while conflicts are being solved.
+func (e *ETA) Wrap(opts MergeOptions) MergeOptions {
+	progress, solve := opts.Progress, opts.Solve
+	opts.Progress = func(stage string, finished bool) error {
+		e.Progress(stage, finished)
+		if progress == nil {
+			return nil
+		}
+		return progress(stage, finished)
+	}
+	if solve != nil {
+		opts.Solve = func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+			e.Pause()
+			defer e.Resume()
+			return solve(stage, conflicts, merged)
+		}
+	}
+	return opts
+}
+
+// elapsed returns the time the current stage has been running
+// without the time it has been paused.
+func (e *ETA) elapsed(now time.Time) time.Duration {
+	elapsed := now.Sub(e.start) - e.paused
+	if !e.pausedSince.IsZero() {
+		elapsed -= now.Sub(e.pausedSince)
+	}
+	return elapsed
+}
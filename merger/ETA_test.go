@@ -0,0 +1,112 @@
+package merger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetThroughput(t *testing.T) {
+	orig := Throughput()
+	defer SetThroughput(orig)
+
+	SetThroughput(map[string]float64{StageNotes: 100, StageTags: -1, "Unknown": 1})
+	result := Throughput()
+	assert.Equal(t, 100.0, result[StageNotes])
+	assert.Equal(t, orig[StageTags], result[StageTags])
+	assert.NotContains(t, result, "Unknown")
+
+	// Changing the result does not change the throughput
+	result[StageNotes] = 1
+	assert.Equal(t, 100.0, Throughput()[StageNotes])
+}
+
+func TestETA(t *testing.T) {
+	orig := Throughput()
+	defer SetThroughput(orig)
+	SetThroughput(map[string]float64{
+		StageLocations: 100,
+		StageBookmarks: 100,
+		StageTags:      100,
+		StageMarkings:  100,
+		StageNotes:     100,
+		StageTagMaps:   100,
+	})
+
+	left := &model.Database{
+		Location: make([]*model.Location, 1000),
+		Note:     make([]*model.Note, 500),
+	}
+	right := &model.Database{
+		Location: make([]*model.Location, 1000),
+		Note:     make([]*model.Note, 500),
+	}
+	eta := NewETA(left, right)
+	now := time.Now()
+	eta.now = func() time.Time { return now }
+
+	assert.Equal(t, 30*time.Second, eta.Remaining())
+
+	eta.Progress(StageLocations, false)
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, 25*time.Second, eta.Remaining())
+
+	// Locations took 10s instead of 20s, so the throughput is updated
+	now = now.Add(5 * time.Second)
+	eta.Progress(StageLocations, true)
+	assert.Equal(t, 10*time.Second, eta.Remaining())
+	assert.Equal(t, 150.0, Throughput()[StageLocations])
+
+	// Time spent solving conflicts is not counted
+	eta.Progress(StageNotes, false)
+	now = now.Add(2 * time.Second)
+	eta.Pause()
+	now = now.Add(time.Minute)
+	assert.Equal(t, 8*time.Second, eta.Remaining())
+	eta.Resume()
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, 6*time.Second, eta.Remaining())
+
+	// Taking longer than expected doesn't result in a negative ETA
+	now = now.Add(time.Minute)
+	assert.Equal(t, time.Duration(0), eta.Remaining())
+	eta.Progress(StageNotes, true)
+	assert.Equal(t, time.Duration(0), eta.Remaining())
+	assert.Less(t, Throughput()[StageNotes], 100.0)
+}
+
+func TestETA_Wrap(t *testing.T) {
+	orig := Throughput()
+	defer SetThroughput(orig)
+
+	left, right := verifyTestDatabases()
+	eta := NewETA(left, right)
+	assert.Greater(t, int64(eta.Remaining()), int64(0))
+
+	stages := []string{}
+	_, err := MergeBackups(left, right, eta.Wrap(MergeOptions{
+		Solve: func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+			assert.False(t, eta.pausedSince.IsZero())
+			return SolveConflictByChoosingRight(conflicts)
+		},
+		Progress: func(stage string, finished bool) error {
+			if finished {
+				stages = append(stages, stage)
+			}
+			return nil
+		},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, mergeStages, stages)
+	assert.Equal(t, time.Duration(0), eta.Remaining())
+	assert.True(t, eta.pausedSince.IsZero())
+	// Small stages don't change the throughput
+	assert.Equal(t, orig, Throughput())
+
+	// Options without callbacks can be wrapped as well
+	opts := NewETA(left, right).Wrap(MergeOptions{})
+	assert.NoError(t, opts.Progress(StageLocations, false))
+	assert.Nil(t, opts.Solve)
+}
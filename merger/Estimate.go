@@ -0,0 +1,113 @@
+package merger
+
+import (
+	"fmt"
+
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// ConflictEstimate is the number of conflicts per stage that
+// are likely to come up when merging two Databases
+type ConflictEstimate struct {
+	Bookmarks int
+	Tags      int
+	Markings  int
+	Notes     int
+}
+
+// Total returns the number of conflicts of all stages
+func (e ConflictEstimate) Total() int {
+	return e.Bookmarks + e.Tags + e.Markings + e.Notes
+}
+
+// EstimateConflicts counts the conflicts that are likely to come up when
+// merging left and right, without asking for solutions. It runs the same
+// stages as a merge on copies of the Databases, so left and right are
+// not changed. To continue with the following stages, conflicts are
+// temporarily solved by choosing the left side, so the numbers of later
+// stages might slightly differ from the ones of the actual merge.
+func EstimateConflicts(left *model.Database, right *model.Database) (ConflictEstimate, error) {
+	estimate := ConflictEstimate{}
+	l := model.MakeDatabaseCopy(left)
+	r := model.MakeDatabaseCopy(right)
+
+	moveToNwtsty(needsNwtstyMigration(l.Location, r.Location), l.Location, r.Location)
+	_, locationIDChanges, err := tryMergeWithConflictSolver(l.Location, r.Location, nil, solveLocationMergeConflict)
+	if err != nil {
+		return estimate, fmt.Errorf("Error while estimating conflicts of Locations: %w", err)
+	}
+	UpdateLRIDs(l.Bookmark, r.Bookmark, "LocationID", locationIDChanges)
+	UpdateLRIDs(l.Bookmark, r.Bookmark, "PublicationLocationID", locationIDChanges)
+	UpdateLRIDs(l.Note, r.Note, "LocationID", locationIDChanges)
+	UpdateLRIDs(l.TagMap, r.TagMap, "LocationID", locationIDChanges)
+	UpdateLRIDs(l.UserMark, r.UserMark, "LocationID", locationIDChanges)
+
+	estimate.Bookmarks, err = countConflicts(func(solutions map[string]MergeSolution) error {
+		_, _, err := tryMergeWithConflictSolver(l.Bookmark, r.Bookmark, solutions, solveEqualityMergeConflict)
+		return err
+	})
+	if err != nil {
+		return estimate, fmt.Errorf("Error while estimating conflicts of Bookmarks: %w", err)
+	}
+
+	estimate.Tags, err = countConflicts(func(solutions map[string]MergeSolution) error {
+		_, tagIDChanges, err := tryMergeWithConflictSolver(l.Tag, r.Tag, solutions, solveTagMergeConflict)
+		if err == nil {
+			UpdateLRIDs(l.TagMap, r.TagMap, "TagID", tagIDChanges)
+		}
+		return err
+	})
+	if err != nil {
+		return estimate, fmt.Errorf("Error while estimating conflicts of Tags: %w", err)
+	}
+
+	estimate.Markings, err = countConflicts(func(solutions map[string]MergeSolution) error {
+		_, _, userMarkIDChanges, err := MergeUserMarkAndBlockRange(l.UserMark, l.BlockRange, r.UserMark, r.BlockRange, solutions)
+		if err == nil {
+			UpdateLRIDs(l.Note, r.Note, "UserMarkID", userMarkIDChanges)
+		}
+		return err
+	})
+	if err != nil {
+		return estimate, fmt.Errorf("Error while estimating conflicts of Markings: %w", err)
+	}
+
+	estimate.Notes, err = countConflicts(func(solutions map[string]MergeSolution) error {
+		_, _, err := mergeNotes(l.Note, r.Note, solutions, solveNoteMergeConflict)
+		return err
+	})
+	if err != nil {
+		return estimate, fmt.Errorf("Error while estimating conflicts of Notes: %w", err)
+	}
+
+	return estimate, nil
+}
+
+// countConflicts calls mergeFn until it doesn't return a MergeConflictError
+// anymore, solving the conflicts by choosing the left side in between.
+// It returns the number of conflicts that have been solved this way.
+func countConflicts(mergeFn func(solutions map[string]MergeSolution) error) (int, error) {
+	solutions := map[string]MergeSolution{}
+	count := 0
+	for {
+		err := mergeFn(solutions)
+		if err == nil {
+			return count, nil
+		}
+		mergeConflict, ok := err.(MergeConflictError)
+		if !ok {
+			return count, err
+		}
+		newSolutions, err := solveConflictByChoosingSide(mergeConflict.Conflicts, LeftSide)
+		if err != nil {
+			return count, err
+		}
+		for key, sol := range newSolutions {
+			if _, ok := solutions[key]; ok {
+				return count, fmt.Errorf("Conflict %s came up again", key)
+			}
+			solutions[key] = sol
+		}
+		count += len(newSolutions)
+	}
+}
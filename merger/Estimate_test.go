@@ -0,0 +1,78 @@
+package merger
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateConflicts(t *testing.T) {
+	left := &model.Database{
+		Bookmark: []*model.Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 1, Slot: 0, Title: "Left"},
+		},
+		BlockRange: []*model.BlockRange{
+			nil,
+			{BlockRangeID: 1, UserMarkID: 1, Identifier: 1, StartToken: sql.NullInt32{Int32: 0, Valid: true}, EndToken: sql.NullInt32{Int32: 5, Valid: true}},
+		},
+		Location: []*model.Location{
+			nil,
+			{LocationID: 1, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}, MepsLanguage: 2, BookNumber: sql.NullInt32{Int32: 1, Valid: true}, ChapterNumber: sql.NullInt32{Int32: 1, Valid: true}},
+		},
+		Note: []*model.Note{
+			nil,
+			{NoteID: 1, GUID: "A", Content: sql.NullString{String: "Left", Valid: true}},
+			{NoteID: 2, GUID: "B", Content: sql.NullString{String: "Same", Valid: true}},
+		},
+		UserMark: []*model.UserMark{
+			nil,
+			{UserMarkID: 1, ColorIndex: 1, LocationID: 1, UserMarkGUID: "LEFT"},
+		},
+	}
+	right := &model.Database{
+		Bookmark: []*model.Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 1, Slot: 0, Title: "Right"},
+		},
+		BlockRange: []*model.BlockRange{
+			nil,
+			{BlockRangeID: 1, UserMarkID: 1, Identifier: 1, StartToken: sql.NullInt32{Int32: 3, Valid: true}, EndToken: sql.NullInt32{Int32: 8, Valid: true}},
+		},
+		Location: []*model.Location{
+			nil,
+			{LocationID: 1, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}, MepsLanguage: 2, BookNumber: sql.NullInt32{Int32: 1, Valid: true}, ChapterNumber: sql.NullInt32{Int32: 1, Valid: true}},
+		},
+		Note: []*model.Note{
+			nil,
+			{NoteID: 1, GUID: "A", Content: sql.NullString{String: "Right", Valid: true}},
+			{NoteID: 2, GUID: "B", Content: sql.NullString{String: "Same", Valid: true}},
+		},
+		UserMark: []*model.UserMark{
+			nil,
+			{UserMarkID: 1, ColorIndex: 2, LocationID: 1, UserMarkGUID: "RIGHT"},
+		},
+	}
+	leftCopy := model.MakeDatabaseCopy(left)
+	rightCopy := model.MakeDatabaseCopy(right)
+
+	estimate, err := EstimateConflicts(left, right)
+	assert.NoError(t, err)
+	assert.Equal(t, ConflictEstimate{Bookmarks: 1, Markings: 1, Notes: 1}, estimate)
+	assert.Equal(t, 3, estimate.Total())
+
+	// left and right should not have been changed
+	assert.True(t, leftCopy.Equals(left))
+	assert.True(t, rightCopy.Equals(right))
+
+	estimate, err = EstimateConflicts(left, left)
+	assert.NoError(t, err)
+	assert.Equal(t, ConflictEstimate{}, estimate)
+	assert.Equal(t, 0, estimate.Total())
+
+	estimate, err = EstimateConflicts(left, &model.Database{})
+	assert.NoError(t, err)
+	assert.Equal(t, ConflictEstimate{}, estimate)
+}
@@ -6,15 +6,47 @@ import (
 	"github.com/AndreasSko/go-jwlm/model"
 )
 
+// LocationOptions are options for merging Locations
+type LocationOptions struct {
+	// Editions maps the KeySymbol of a Bible edition to the KeySymbol of an
+	// equivalent edition, like {"nwt": "nwtsty"}. As chapters of equivalent
+	// editions refer to the same verses, they are merged into the Location of
+	// the equivalent edition. Documents (like study notes) are not affected.
+	Editions map[string]string
+	// Logger receives messages like the migration of Bible editions.
+	// If it is nil, they are discarded.
+	Logger model.Logger
+}
+
 // MergeLocations merges two slices of Location into one and returns
 // the merged locations together with a IDChanges struct indicating
 // if the ID of a location has changed.
 func MergeLocations(left []*model.Location, right []*model.Location) ([]*model.Location, IDChanges, error) {
+	return MergeLocationsWithOptions(left, right, LocationOptions{})
+}
+
+// MergeLocationsWithOptions merges two slices of Location like
+// MergeLocations, while treating equivalent Bible editions as given by the
+// options as the same. Like the migration from nwt to nwtsty, this changes
+// the KeySymbol of the given Locations.
+func MergeLocationsWithOptions(left []*model.Location, right []*model.Location, options LocationOptions) ([]*model.Location, IDChanges, error) {
 	// Check if one side needs to migrate the bible edition from standard to study
 	nwtstyMigrations := needsNwtstyMigration(left, right)
+	for lang, side := range nwtstyMigrations {
+		loggerOrNop(options.Logger).Infof("Migrating locations of language %d on %s from nwt to nwtsty", lang, side)
+	}
 	moveToNwtsty(nwtstyMigrations, left, right)
 
+	// Unifying editions might result in duplicates on the same side,
+	// which are merged into the first one
+	left, leftDuplicates := unifyEditions(options.Editions, left)
+	right, rightDuplicates := unifyEditions(options.Editions, right)
+
 	result, changes, err := tryMergeWithConflictSolver(left, right, nil, solveLocationMergeConflict)
+	if err == nil {
+		addDuplicateChanges(changes.Left, leftDuplicates)
+		addDuplicateChanges(changes.Right, rightDuplicates)
+	}
 
 	return model.Location{}.MakeSlice(result), changes, err
 }
@@ -168,6 +200,57 @@ func moveToNwtsty(langs map[int]MergeSide, left []*model.Location, right []*mode
 	}
 }
 
+// unifyEditions changes the KeySymbol of Bible chapters (and the Bibles
+// themselves) to the one of their equivalent edition. It returns the
+// Locations without those that became a duplicate of another one,
+// together with the IDs of the duplicates and the ID they are equal to.
+func unifyEditions(editions map[string]string, locations []*model.Location) ([]*model.Location, map[int]int) {
+	if len(editions) == 0 {
+		return locations, nil
+	}
+
+	for _, location := range locations {
+		if location == nil || !location.KeySymbol.Valid {
+			continue
+		}
+		if location.DocumentID.Valid && !location.BookNumber.Valid {
+			continue
+		}
+		if equivalent, ok := editions[location.KeySymbol.String]; ok {
+			location.KeySymbol.String = equivalent
+		}
+	}
+
+	unique := make([]*model.Location, len(locations))
+	duplicates := map[int]int{}
+	first := make(map[string]int, len(locations))
+	for i, location := range locations {
+		if location == nil {
+			continue
+		}
+		if id, ok := first[location.UniqueKey()]; ok {
+			duplicates[location.LocationID] = id
+			continue
+		}
+		first[location.UniqueKey()] = location.LocationID
+		unique[i] = location
+	}
+
+	return unique, duplicates
+}
+
+// addDuplicateChanges adds the IDs of duplicates to the changes of their
+// side, so they point to the same ID as the entry they are equal to
+func addDuplicateChanges(changes map[int]int, duplicates map[int]int) {
+	for duplicate, id := range duplicates {
+		if newID, ok := changes[id]; ok {
+			changes[duplicate] = newID
+		} else {
+			changes[duplicate] = id
+		}
+	}
+}
+
 // significantlyHigher checks if a is significantly (10x) higher than b
 func significantlyHigher(a, b int) bool {
 	return (float32(a) * 0.1) > float32(b)
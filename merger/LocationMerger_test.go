@@ -627,6 +627,68 @@ func Benchmark_MergeLocations(b *testing.B) {
 	MergeLocations(left, right)
 }
 
+func Test_MergeLocationsWithOptions(t *testing.T) {
+	left := []*model.Location{
+		nil,
+		{
+			LocationID:    1,
+			BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+			KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			MepsLanguage:  2,
+			Title:         sql.NullString{String: "1. Mose 1", Valid: true},
+		},
+		{
+			LocationID:    2,
+			BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+			KeySymbol:     sql.NullString{String: "nwt", Valid: true},
+			MepsLanguage:  2,
+		},
+		{
+			LocationID:   3,
+			KeySymbol:    sql.NullString{String: "nwt", Valid: true},
+			MepsLanguage: 2,
+			LocationType: 1,
+		},
+		{
+			LocationID:   4,
+			DocumentID:   sql.NullInt32{Int32: 1001070000, Valid: true},
+			KeySymbol:    sql.NullString{String: "bi12", Valid: true},
+			MepsLanguage: 2,
+		},
+	}
+	right := []*model.Location{
+		nil,
+		{
+			LocationID:    1,
+			BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+			KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			MepsLanguage:  2,
+		},
+		{
+			LocationID:    2,
+			BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: 2, Valid: true},
+			KeySymbol:     sql.NullString{String: "bi12", Valid: true},
+			MepsLanguage:  2,
+		},
+	}
+
+	result, changes, err := MergeLocationsWithOptions(left, right, LocationOptions{
+		Editions: map[string]string{"nwt": "nwtsty", "bi12": "nwtsty"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, result, 5)
+	assert.Equal(t, "1. Mose 1", result[1].Title.String)
+	assert.Equal(t, int32(2), result[2].ChapterNumber.Int32)
+	assert.Equal(t, "nwtsty", result[2].KeySymbol.String)
+	assert.Equal(t, "nwtsty", result[3].KeySymbol.String)
+	assert.Equal(t, "bi12", result[4].KeySymbol.String)
+	assert.Equal(t, IDChanges{Left: map[int]int{2: 1}, Right: map[int]int{}}, changes)
+}
+
 func Test_solveLocationMergeConflict(t *testing.T) {
 	conflicts := map[string]MergeConflict{
 		"ChooseLeftConflict": {
@@ -0,0 +1,82 @@
+package merger
+
+import (
+	"crypto/sha256"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FilenameChanges represents the changed names of media files after
+// they have been merged, so references to them can be updated. Like
+// with IDChanges, a renamed file of the left side is represented as
+// {"picture.jpg": "picture 2.jpg"}.
+type FilenameChanges struct {
+	Left  map[string]string
+	Right map[string]string
+}
+
+// MergeMedia merges the media files (see model.ReadMedia) of the left
+// and the right backup. Files with the same content are only kept once,
+// preferring the name of the left side. Files with the same name but
+// different content are kept both, renaming the one of the right side.
+// The returned FilenameChanges contain every file whose name changed.
+func MergeMedia(left map[string][]byte, right map[string][]byte) (map[string][]byte, FilenameChanges) {
+	result := map[string][]byte{}
+	changes := FilenameChanges{
+		Left:  map[string]string{},
+		Right: map[string]string{},
+	}
+	byHash := map[[sha256.Size]byte]string{}
+	taken := map[string]bool{}
+
+	merge := func(media map[string][]byte, changed map[string]string) {
+		for _, name := range sortedFilenames(media) {
+			content := media[name]
+			hash := sha256.Sum256(content)
+			if existing, ok := byHash[hash]; ok {
+				if existing != name {
+					changed[name] = existing
+				}
+				continue
+			}
+
+			newName := name
+			if taken[strings.ToLower(newName)] {
+				newName = uniqueFilename(name, taken)
+				changed[name] = newName
+			}
+			taken[strings.ToLower(newName)] = true
+			byHash[hash] = newName
+			result[newName] = content
+		}
+	}
+	merge(left, changes.Left)
+	merge(right, changes.Right)
+
+	return result, changes
+}
+
+// uniqueFilename appends a number to the base name of the file, so it does
+// not collide with any of the taken names. Names are compared
+// case-insensitively, as not every file system distinguishes them.
+func uniqueFilename(name string, taken map[string]bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		newName := base + " " + strconv.Itoa(i) + ext
+		if !taken[strings.ToLower(newName)] {
+			return newName
+		}
+	}
+}
+
+func sortedFilenames(media map[string][]byte) []string {
+	names := make([]string, 0, len(media))
+	for name := range media {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,45 @@
+package merger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeMedia(t *testing.T) {
+	left := map[string][]byte{
+		"picture.jpg": []byte("left picture"),
+		"audio.mp3":   []byte("audio"),
+		"copy.mp3":    []byte("audio"),
+	}
+	right := map[string][]byte{
+		"picture.jpg":   []byte("right picture"),
+		"Picture 2.jpg": []byte("another picture"),
+		"same.mp3":      []byte("audio"),
+		"audio.mp3":     []byte("audio"),
+		"new.png":       []byte("new"),
+	}
+
+	merged, changes := MergeMedia(left, right)
+	assert.Equal(t, map[string][]byte{
+		"picture.jpg":   []byte("left picture"),
+		"audio.mp3":     []byte("audio"),
+		"Picture 2.jpg": []byte("another picture"),
+		"picture 3.jpg": []byte("right picture"),
+		"new.png":       []byte("new"),
+	}, merged)
+	assert.Equal(t, FilenameChanges{
+		Left: map[string]string{
+			"copy.mp3": "audio.mp3",
+		},
+		Right: map[string]string{
+			"picture.jpg": "picture 3.jpg",
+			"same.mp3":    "audio.mp3",
+		},
+	}, changes)
+
+	merged, changes = MergeMedia(nil, nil)
+	assert.Empty(t, merged)
+	assert.Empty(t, changes.Left)
+	assert.Empty(t, changes.Right)
+}
@@ -1,6 +1,7 @@
 package merger
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -31,8 +32,26 @@ const (
 type MergeConflict struct {
 	Left  model.Model
 	Right model.Model
+	// LeftContext and RightContext describe entries related to each
+	// side, if the merge function has been given them (like
+	// MergeNotesWithOptions). Otherwise, they are nil.
+	LeftContext  *ConflictContext
+	RightContext *ConflictContext
 }
 
+// ConflictContext describes entries related to one side of a conflict,
+// which are not part of the conflicting Model itself, like the tags of
+// a Note or the Location it belongs to.
+type ConflictContext struct {
+	Tags     []string        `json:"tags"`
+	Location *model.Location `json:"location,omitempty"`
+}
+
+// ErrMergeConflict indicates that a conflict happened while merging. It is
+// wrapped by MergeConflictError, so errors.Is can be used to check for
+// conflicts, while errors.As gives access to the actual conflicts.
+var ErrMergeConflict = errors.New("There were conflicts while trying to merge")
+
 // MergeConflictError indicates that a conflict happened while trying to merge
 // two slices of Model. It contains the conflicts in order for the caller to solve them.
 type MergeConflictError struct {
@@ -41,7 +60,21 @@ type MergeConflictError struct {
 }
 
 func (e MergeConflictError) Error() string {
-	return fmt.Sprintf("There were conflicts while trying to merge: %s", e.Conflicts)
+	return fmt.Sprintf("%s: %v", ErrMergeConflict, e.Conflicts)
+}
+
+// Unwrap returns ErrMergeConflict
+func (e MergeConflictError) Unwrap() error {
+	return ErrMergeConflict
+}
+
+// loggerOrNop returns l, or a Logger discarding
+// all messages if l is nil
+func loggerOrNop(l model.Logger) model.Logger {
+	if l == nil {
+		return model.NopLogger{}
+	}
+	return l
 }
 
 // merge merges a left and a right slice of structs implementing the Model interface.
@@ -83,17 +116,18 @@ func merge(left interface{}, right interface{}, conflictSolution map[string]Merg
 			}
 
 			r := s.Index(i).Interface().(model.Model)
-			if conflict, exists := duplicateCheck[r.UniqueKey()]; exists {
-				if solution, ok := conflictSolution[r.UniqueKey()]; ok {
-					duplicateCheck[r.UniqueKey()] = solution
+			key := r.UniqueKey()
+			if conflict, exists := duplicateCheck[key]; exists {
+				if solution, ok := conflictSolution[key]; ok {
+					duplicateCheck[key] = solution
 				} else {
-					collisions[r.UniqueKey()] = MergeConflict{
+					collisions[key] = MergeConflict{
 						Left:  conflict.Solution,
 						Right: r,
 					}
 				}
 			} else {
-				duplicateCheck[r.UniqueKey()] = MergeSolution{Side: RightSide, Solution: r}
+				duplicateCheck[key] = MergeSolution{Side: RightSide, Solution: r}
 			}
 		}
 	}
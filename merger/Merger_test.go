@@ -2,6 +2,9 @@ package merger
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/AndreasSko/go-jwlm/model"
@@ -139,3 +142,63 @@ func Test_sortMergeSolution(t *testing.T) {
 
 	assert.Equal(t, expectedResult, solution)
 }
+
+func TestMergeConflictError(t *testing.T) {
+	left := []*model.Tag{nil, {TagID: 1, TagType: 1, Name: "Tag"}}
+	right := []*model.Tag{nil, {TagID: 1, TagType: 1, Name: "Tag", ImageFilename: sql.NullString{String: "img", Valid: true}}}
+	_, err := merge(left, right, nil)
+
+	// Wrapped errors can still be inspected
+	wrapped := fmt.Errorf("Error while merging tags: %w", err)
+	assert.True(t, errors.Is(wrapped, ErrMergeConflict))
+	var conflictErr MergeConflictError
+	assert.True(t, errors.As(wrapped, &conflictErr))
+	assert.Len(t, conflictErr.Conflicts, 1)
+	assert.Contains(t, err.Error(), "There were conflicts while trying to merge: ")
+}
+
+type testLogger struct {
+	messages []string
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) {}
+
+func (l *testLogger) Infof(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Warnf(format string, args ...interface{}) {}
+
+func TestMergeOptions_Logger(t *testing.T) {
+	newDatabase := func(keySymbol string) *model.Database {
+		return &model.Database{Location: []*model.Location{
+			nil,
+			{
+				LocationID:   1,
+				BookNumber:   sql.NullInt32{Int32: 1, Valid: true},
+				KeySymbol:    sql.NullString{String: keySymbol, Valid: true},
+				MepsLanguage: 2,
+			},
+		}}
+	}
+
+	// Every merge logs to its own Logger, even if they run concurrently
+	loggers := []*testLogger{{}, {}}
+	var wg sync.WaitGroup
+	for _, l := range loggers {
+		wg.Add(1)
+		go func(l *testLogger) {
+			defer wg.Done()
+			_, err := MergeBackups(newDatabase("nwtsty"), newDatabase("nwt"), MergeOptions{Logger: l})
+			assert.NoError(t, err)
+		}(l)
+	}
+	wg.Wait()
+	for _, l := range loggers {
+		assert.Equal(t, []string{"Migrating locations of language 2 on rightSide from nwt to nwtsty"}, l.messages)
+	}
+
+	// Without a Logger, messages are discarded
+	_, err := MergeBackups(newDatabase("nwtsty"), newDatabase("nwt"), MergeOptions{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,42 @@
+package merger
+
+import (
+	"errors"
+	"time"
+)
+
+// Names of the stages of a merge, as they are reported to Metrics
+const (
+	StageLocations = "Locations"
+	StageBookmarks = "Bookmarks"
+	StageTags      = "Tags"
+	StageMarkings  = "Markings"
+	StageNotes     = "Notes"
+	StageTagMaps   = "TagMaps"
+)
+
+// Metrics receives measurements of the stages of MergeBackups, so they can be
+// exposed to monitoring systems like Prometheus when running go-jwlm
+// as a service.
+type Metrics interface {
+	// ObserveStage is called every time a stage of a merge has been
+	// run. conflicts is the number of conflicts that could not be
+	// solved automatically and err is the error returned by the
+	// merge function (if any).
+	ObserveStage(stage string, duration time.Duration, conflicts int, err error)
+}
+
+// observeStage reports the duration since start and the
+// number of conflicts contained in err to metrics, if it is not nil.
+func observeStage(metrics Metrics, stage string, start time.Time, err error) {
+	if metrics == nil {
+		return
+	}
+
+	conflicts := 0
+	var mergeConflict MergeConflictError
+	if errors.As(err, &mergeConflict) {
+		conflicts = len(mergeConflict.Conflicts)
+	}
+	metrics.ObserveStage(stage, time.Since(start), conflicts, err)
+}
@@ -0,0 +1,65 @@
+package merger
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type observation struct {
+	stage     string
+	conflicts int
+	err       bool
+}
+
+type recordingMetrics struct {
+	observations []observation
+	durations    []time.Duration
+}
+
+func (m *recordingMetrics) ObserveStage(stage string, duration time.Duration, conflicts int, err error) {
+	m.observations = append(m.observations, observation{stage, conflicts, err != nil})
+	m.durations = append(m.durations, duration)
+}
+
+func TestMergeOptions_Metrics(t *testing.T) {
+	newDatabase := func(title string) *model.Database {
+		return &model.Database{Note: []*model.Note{nil, {
+			NoteID:       1,
+			GUID:         "FirstNote",
+			Title:        sql.NullString{String: title, Valid: true},
+			LastModified: "2017-06-01T19:36:28+0200",
+		}}}
+	}
+
+	m := &recordingMetrics{}
+	_, err := MergeBackups(newDatabase("Left"), newDatabase("Right"), MergeOptions{
+		Metrics: m,
+		Solve: func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+			return AutoResolveConflicts(conflicts, "chooseLeft")
+		},
+	})
+	assert.NoError(t, err)
+
+	// Every run of a stage is measured, including the
+	// conflicts that could not be solved automatically
+	assert.Equal(t, []observation{
+		{StageLocations, 0, false},
+		{StageBookmarks, 0, false},
+		{StageTags, 0, false},
+		{StageMarkings, 0, false},
+		{StageNotes, 1, true},
+		{StageNotes, 0, false},
+		{StageTagMaps, 0, false},
+	}, m.observations)
+	for _, duration := range m.durations {
+		assert.Greater(t, int64(duration), int64(0))
+	}
+
+	// Without Metrics, nothing is measured
+	_, err = MergeBackups(newDatabase("Left"), newDatabase("Left"), MergeOptions{})
+	assert.NoError(t, err)
+}
@@ -1,11 +1,282 @@
 package merger
 
-import "github.com/AndreasSko/go-jwlm/model"
+import (
+	"errors"
+	"sort"
+	"time"
 
-// MergeNotes tries to merge the left and right slice of Note. If there is a
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// MergeNotes tries to merge the left and right slice of Note. Notes are
+// matched by their GUID, so a note that exists on both sides is treated as
+// the same note, even if it has been moved to another location. Notes with
+// different GUIDs, but the same content at different locations are
+// considered as moved as well (see detectMovedNotes). If there is a
 // collision, it returns an error asking for specification how it should handle it.
 func MergeNotes(left []*model.Note, right []*model.Note, conflictSolution map[string]MergeSolution) ([]*model.Note, IDChanges, error) {
-	result, changes, err := tryMergeWithConflictSolver(left, right, conflictSolution, solveEqualityMergeConflict)
+	result, changes, err := mergeNotes(left, right, conflictSolution, solveNoteMergeConflict)
 
 	return model.Note{}.MakeSlice(result), changes, err
 }
+
+// NoteOptions are options for merging Notes
+type NoteOptions struct {
+	// LeftTagMaps and RightTagMaps are the TagMaps of the left and
+	// right Notes, with their TagIDs already pointing to merged Tags
+	LeftTagMaps  []*model.TagMap
+	RightTagMaps []*model.TagMap
+	// Merged is the partly merged Database, which contains the
+	// Tags, Locations, and UserMarks the Notes refer to.
+	Merged *model.Database
+	// KeepLeft solves conflicts of Notes with the same GUID in favor
+	// of the left side, even if the right one has been modified later.
+	KeepLeft bool
+}
+
+// MergeNotesWithOptions merges the left and right slice of Note like
+// MergeNotes. If there are conflicts, they contain the tags and the
+// Location of both sides as context (see ConflictContext).
+func MergeNotesWithOptions(left []*model.Note, right []*model.Note, conflictSolution map[string]MergeSolution, options NoteOptions) ([]*model.Note, IDChanges, error) {
+	solver := solveNoteMergeConflict
+	if options.KeepLeft {
+		solver = solveNoteMergeConflictKeepLeft
+	}
+	models, changes, err := mergeNotes(left, right, conflictSolution, solver)
+	result := model.Note{}.MakeSlice(models)
+
+	var mcErr MergeConflictError
+	if errors.As(err, &mcErr) {
+		leftTags := noteTagNames(options.LeftTagMaps, options.Merged)
+		rightTags := noteTagNames(options.RightTagMaps, options.Merged)
+		for key, conflict := range mcErr.Conflicts {
+			if note, ok := conflict.Left.(*model.Note); ok {
+				conflict.LeftContext = noteContext(note, leftTags, options.Merged)
+			}
+			if note, ok := conflict.Right.(*model.Note); ok {
+				conflict.RightContext = noteContext(note, rightTags, options.Merged)
+			}
+			mcErr.Conflicts[key] = conflict
+		}
+	}
+
+	return result, changes, err
+}
+
+// noteTagNames returns the sorted names of the Tags of each Note
+func noteTagNames(tagMaps []*model.TagMap, db *model.Database) map[int][]string {
+	tags := map[int][]string{}
+	for _, tm := range tagMaps {
+		if tm == nil || !tm.NoteID.Valid {
+			continue
+		}
+		if tag, ok := db.FetchFromTable("Tag", tm.TagID).(*model.Tag); ok {
+			tags[int(tm.NoteID.Int32)] = append(tags[int(tm.NoteID.Int32)], tag.Name)
+		}
+	}
+	for _, names := range tags {
+		sort.Strings(names)
+	}
+	return tags
+}
+
+// noteContext returns the tags and the Location of the Note. If
+// it has no Location itself, the one of its UserMark is used.
+func noteContext(note *model.Note, tags map[int][]string, db *model.Database) *ConflictContext {
+	ctx := &ConflictContext{Tags: tags[note.NoteID]}
+	if ctx.Tags == nil {
+		ctx.Tags = []string{}
+	}
+
+	if note.LocationID.Valid {
+		ctx.Location, _ = db.FetchFromTable("Location", int(note.LocationID.Int32)).(*model.Location)
+	} else if note.UserMarkID.Valid {
+		if um, ok := db.FetchFromTable("UserMark", int(note.UserMarkID.Int32)).(*model.UserMark); ok {
+			ctx.Location, _ = db.FetchFromTable("Location", um.LocationID).(*model.Location)
+		}
+	}
+	return ctx
+}
+
+// mergeNotes implements MergeNotes, solving conflicts of
+// notes with the same GUID automatically using solver
+func mergeNotes(left []*model.Note, right []*model.Note, conflictSolution map[string]MergeSolution, solver MergeConflictSolver) ([]model.Model, IDChanges, error) {
+	moved := detectMovedNotes(left, right)
+
+	// Remove the discarded note of already solved moved notes, so only
+	// the chosen one is merged. The discarded one is replaced by it later.
+	solvedMoved := map[string]MergeSolution{}
+	unsolvedMoved := map[string]MergeConflict{}
+	if len(moved) > 0 {
+		left = append([]*model.Note{}, left...)
+		right = append([]*model.Note{}, right...)
+	}
+	for key, conflict := range moved {
+		sol, ok := conflictSolution[key]
+		if !ok || sol.Discarded == nil {
+			unsolvedMoved[key] = conflict
+			continue
+		}
+		solvedMoved[key] = sol
+		if sol.Side == LeftSide {
+			right[sol.Discarded.ID()] = nil
+		} else {
+			left[sol.Discarded.ID()] = nil
+		}
+	}
+
+	result, changes, err := tryMergeWithConflictSolver(left, right, conflictSolution, solver)
+	if len(unsolvedMoved) > 0 {
+		if mcErr, ok := err.(MergeConflictError); ok {
+			for key, conflict := range mcErr.Conflicts {
+				unsolvedMoved[key] = conflict
+			}
+		} else if err != nil {
+			return result, changes, err
+		}
+		return []model.Model{}, IDChanges{}, MergeConflictError{
+			Err:       "There were conflicts while trying to merge",
+			Conflicts: unsolvedMoved,
+		}
+	}
+	if err != nil {
+		return result, changes, err
+	}
+
+	// The discarded note now points to the chosen one
+	for _, sol := range solvedMoved {
+		if sol.Side == LeftSide {
+			newID := sol.Solution.ID()
+			if id, ok := changes.Left[newID]; ok {
+				newID = id
+			}
+			changes.Right[sol.Discarded.ID()] = newID
+		} else {
+			newID := sol.Solution.ID()
+			if id, ok := changes.Right[newID]; ok {
+				newID = id
+			}
+			changes.Left[sol.Discarded.ID()] = newID
+		}
+	}
+
+	return result, changes, nil
+}
+
+// detectMovedNotes returns conflicts for notes that only exist on one side
+// (by their GUID), but have the same title and content as a note on the
+// other side that is attached to a different location. This happens if
+// the user reattached a note to a new location, so the caller can decide
+// where to keep it instead of ending up with two copies of the same text.
+// The keys of the conflicts are prefixed with "moved_".
+func detectMovedNotes(left []*model.Note, right []*model.Note) map[string]MergeConflict {
+	conflicts := map[string]MergeConflict{}
+
+	leftOnly := notesByContent(left, right)
+	rightOnly := notesByContent(right, left)
+	for content, l := range leftOnly {
+		r, ok := rightOnly[content]
+		if !ok || l == nil || r == nil {
+			continue
+		}
+		if l.LocationID == r.LocationID && l.BlockIdentifier == r.BlockIdentifier {
+			continue
+		}
+		conflicts["moved_"+l.GUID+"_"+r.GUID] = MergeConflict{Left: l, Right: r}
+	}
+
+	return conflicts
+}
+
+// noteTimeLayouts are the formats of Note.LastModified used by JW Library
+var noteTimeLayouts = []string{"2006-01-02T15:04:05-07:00", "2006-01-02T15:04:05-0700", time.RFC3339}
+
+// solveNoteMergeConflict solves conflicts of notes with the same GUID and the
+// same content. Unlike solveEqualityMergeConflict, it chooses the note that has
+// been modified last, so the location of a note the user moved on one side is
+// kept. Notes with different content are returned as a MergeConflictError.
+func solveNoteMergeConflict(conflicts map[string]MergeConflict) (map[string]MergeSolution, error) {
+	solution := make(map[string]MergeSolution, len(conflicts))
+	unsolvableConflicts := map[string]MergeConflict{}
+
+	for key, value := range conflicts {
+		if !value.Left.Equals(value.Right) {
+			unsolvableConflicts[key] = value
+			continue
+		}
+
+		if modifiedAfter(value.Right.(*model.Note).LastModified, value.Left.(*model.Note).LastModified) {
+			solution[key] = MergeSolution{Side: RightSide, Solution: value.Right, Discarded: value.Left}
+		} else {
+			solution[key] = MergeSolution{Side: LeftSide, Solution: value.Left, Discarded: value.Right}
+		}
+	}
+
+	if len(unsolvableConflicts) != 0 {
+		return solution, MergeConflictError{Err: "Could not solve all conflicts", Conflicts: unsolvableConflicts}
+	}
+
+	return solution, nil
+}
+
+// solveNoteMergeConflictKeepLeft solves conflicts like solveNoteMergeConflict,
+// but always chooses the left note, so it is never changed
+func solveNoteMergeConflictKeepLeft(conflicts map[string]MergeConflict) (map[string]MergeSolution, error) {
+	solution, err := solveNoteMergeConflict(conflicts)
+	for key, sol := range solution {
+		if sol.Side == RightSide {
+			solution[key] = MergeSolution{Side: LeftSide, Solution: sol.Discarded, Discarded: sol.Solution}
+		}
+	}
+	return solution, err
+}
+
+// modifiedAfter checks if the timestamp a is after b. If one
+// of them can't be parsed, they are compared as strings.
+func modifiedAfter(a, b string) bool {
+	aTime, aErr := parseNoteTime(a)
+	bTime, bErr := parseNoteTime(b)
+	if aErr != nil || bErr != nil {
+		return a > b
+	}
+	return aTime.After(bTime)
+}
+
+// parseNoteTime parses a timestamp using noteTimeLayouts
+func parseNoteTime(value string) (time.Time, error) {
+	var err error
+	for _, layout := range noteTimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// notesByContent indexes the notes of side whose GUID does not exist
+// in other by their title and content. Empty notes are skipped and if
+// the content is not unique within side, the note is set to nil.
+func notesByContent(side []*model.Note, other []*model.Note) map[string]*model.Note {
+	otherGUIDs := make(map[string]bool, len(other))
+	for _, note := range other {
+		if note != nil {
+			otherGUIDs[note.GUID] = true
+		}
+	}
+
+	result := map[string]*model.Note{}
+	for _, note := range side {
+		if note == nil || otherGUIDs[note.GUID] || (note.Title.String == "" && note.Content.String == "") {
+			continue
+		}
+		content := note.Title.String + "\x00" + note.Content.String
+		if _, exists := result[content]; exists {
+			result[content] = nil
+			continue
+		}
+		result[content] = note
+	}
+
+	return result
+}
@@ -405,3 +405,245 @@ func TestMergeNotes(t *testing.T) {
 	assert.Equal(t, expectedResult, result)
 	assert.Equal(t, expectedChanges, changes)
 }
+
+func TestMergeNotes_moved(t *testing.T) {
+	// The note has been moved to another location on the right side
+	left := []*model.Note{
+		nil,
+		{
+			NoteID:       1,
+			GUID:         "MovedNote",
+			UserMarkID:   sql.NullInt32{Int32: 1, Valid: true},
+			LocationID:   sql.NullInt32{Int32: 1, Valid: true},
+			Title:        sql.NullString{String: "A Title", Valid: true},
+			Content:      sql.NullString{String: "The content", Valid: true},
+			LastModified: "2017-06-01T20:36:28+02:00",
+		},
+	}
+	right := []*model.Note{
+		nil,
+		{
+			NoteID:       1,
+			GUID:         "MovedNote",
+			UserMarkID:   sql.NullInt32{Int32: 2, Valid: true},
+			LocationID:   sql.NullInt32{Int32: 3, Valid: true},
+			Title:        sql.NullString{String: "A Title", Valid: true},
+			Content:      sql.NullString{String: "The content", Valid: true},
+			LastModified: "2017-06-02T08:00:00+02:00",
+		},
+	}
+
+	merged, _, err := MergeNotes(left, right, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Note{nil, right[1]}, merged)
+
+	// The moved note on the left is newer
+	left[1].LastModified = "2017-06-03T08:00:00+02:00"
+	merged, _, err = MergeNotes(left, right, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Note{nil, left[1]}, merged)
+
+	// If the content has been edited as well, it's a conflict
+	right[1].Content = sql.NullString{String: "Edited content", Valid: true}
+	_, _, err = MergeNotes(left, right, nil)
+	assert.Equal(t, MergeConflictError{
+		Err: "There were conflicts while trying to merge",
+		Conflicts: map[string]MergeConflict{
+			"MovedNote": {Left: left[1], Right: right[1]},
+		},
+	}, err)
+}
+
+func TestMergeNotesWithOptions(t *testing.T) {
+	merged := &model.Database{
+		Location: []*model.Location{
+			nil,
+			{LocationID: 1, BookNumber: sql.NullInt32{Int32: 1, Valid: true}, ChapterNumber: sql.NullInt32{Int32: 1, Valid: true}},
+			{LocationID: 2, BookNumber: sql.NullInt32{Int32: 2, Valid: true}, ChapterNumber: sql.NullInt32{Int32: 3, Valid: true}},
+		},
+		Tag: []*model.Tag{
+			nil,
+			{TagID: 1, Name: "Faith"},
+			{TagID: 2, Name: "Love"},
+		},
+		UserMark: []*model.UserMark{
+			nil,
+			{UserMarkID: 1, LocationID: 2},
+		},
+	}
+	left := []*model.Note{
+		nil,
+		{
+			NoteID:       1,
+			GUID:         "Note",
+			LocationID:   sql.NullInt32{Int32: 1, Valid: true},
+			Content:      sql.NullString{String: "Left content", Valid: true},
+			LastModified: "2017-06-01T20:36:28+02:00",
+		},
+	}
+	right := []*model.Note{
+		nil,
+		{
+			NoteID:       1,
+			GUID:         "Note",
+			UserMarkID:   sql.NullInt32{Int32: 1, Valid: true},
+			Content:      sql.NullString{String: "Right content", Valid: true},
+			LastModified: "2017-06-02T08:00:00+02:00",
+		},
+	}
+	options := NoteOptions{
+		LeftTagMaps: []*model.TagMap{
+			nil,
+			{TagMapID: 1, NoteID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 2},
+			{TagMapID: 2, NoteID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 1},
+			{TagMapID: 3, LocationID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 1},
+		},
+		Merged: merged,
+	}
+
+	_, _, err := MergeNotesWithOptions(left, right, nil, options)
+	assert.Equal(t, MergeConflictError{
+		Err: "There were conflicts while trying to merge",
+		Conflicts: map[string]MergeConflict{
+			"Note": {
+				Left:         left[1],
+				Right:        right[1],
+				LeftContext:  &ConflictContext{Tags: []string{"Faith", "Love"}, Location: merged.Location[1]},
+				RightContext: &ConflictContext{Tags: []string{}, Location: merged.Location[2]},
+			},
+		},
+	}, err)
+
+	// Without conflicts, it behaves like MergeNotes
+	result, _, err := MergeNotesWithOptions(left, nil, nil, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Note{nil, left[1]}, result)
+
+	// A newer right note with the same content replaces the
+	// left one and its Location, unless KeepLeft is set
+	moved := []*model.Note{nil, model.MakeModelCopy(right[1]).(*model.Note)}
+	moved[1].Content = left[1].Content
+	result, _, err = MergeNotesWithOptions(left, moved, nil, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Note{nil, moved[1]}, result)
+	options.KeepLeft = true
+	result, _, err = MergeNotesWithOptions(left, moved, nil, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Note{nil, left[1]}, result)
+}
+
+func Test_modifiedAfter(t *testing.T) {
+	assert.True(t, modifiedAfter("2017-06-02T08:00:00+02:00", "2017-06-01T20:36:28+02:00"))
+	assert.False(t, modifiedAfter("2017-06-01T20:36:28+02:00", "2017-06-02T08:00:00+02:00"))
+	assert.False(t, modifiedAfter("2017-06-01T20:36:28+02:00", "2017-06-01T20:36:28+02:00"))
+	// Different time zones and formats
+	assert.True(t, modifiedAfter("2017-06-01T20:00:00+0000", "2017-06-01T21:00:00+02:00"))
+	assert.True(t, modifiedAfter("2017-06-01T20:00:00Z", "2017-06-01T21:00:00+0200"))
+	// Unknown formats are compared as strings
+	assert.True(t, modifiedAfter("b", "a"))
+	assert.False(t, modifiedAfter("", "2017-06-01T21:00:00+0200"))
+}
+
+func TestMergeNotes_movedWithNewGUID(t *testing.T) {
+	// The note has been reattached to another location on the
+	// right side, which JW Library stored as a new note.
+	left := []*model.Note{
+		nil,
+		{
+			NoteID:       1,
+			GUID:         "OldGUID",
+			LocationID:   sql.NullInt32{Int32: 1, Valid: true},
+			Title:        sql.NullString{String: "A Title", Valid: true},
+			Content:      sql.NullString{String: "The content", Valid: true},
+			LastModified: "2017-06-01T20:36:28+02:00",
+		},
+		{
+			NoteID:       2,
+			GUID:         "OnlyLeft",
+			LocationID:   sql.NullInt32{Int32: 1, Valid: true},
+			Title:        sql.NullString{String: "Another note", Valid: true},
+			LastModified: "2017-06-01T20:36:28+02:00",
+		},
+	}
+	right := []*model.Note{
+		nil,
+		{
+			NoteID:       1,
+			GUID:         "NewGUID",
+			LocationID:   sql.NullInt32{Int32: 2, Valid: true},
+			Title:        sql.NullString{String: "A Title", Valid: true},
+			Content:      sql.NullString{String: "The content", Valid: true},
+			LastModified: "2017-06-02T08:00:00+02:00",
+		},
+	}
+
+	_, _, err := MergeNotes(left, right, nil)
+	assert.Equal(t, MergeConflictError{
+		Err: "There were conflicts while trying to merge",
+		Conflicts: map[string]MergeConflict{
+			"moved_OldGUID_NewGUID": {Left: left[1], Right: right[1]},
+		},
+	}, err)
+
+	// Keep the note at the new location
+	solution := map[string]MergeSolution{
+		"moved_OldGUID_NewGUID": {Side: RightSide, Solution: right[1], Discarded: left[1]},
+	}
+	merged, changes, err := MergeNotes(left, right, solution)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Note{
+		nil,
+		{
+			NoteID:       1,
+			GUID:         "NewGUID",
+			LocationID:   sql.NullInt32{Int32: 2, Valid: true},
+			Title:        sql.NullString{String: "A Title", Valid: true},
+			Content:      sql.NullString{String: "The content", Valid: true},
+			LastModified: "2017-06-02T08:00:00+02:00",
+		},
+		left[2],
+	}, merged)
+	// TagMaps of the discarded note should point to the kept one
+	assert.Equal(t, IDChanges{Left: map[int]int{1: 1}, Right: map[int]int{}}, changes)
+
+	// Keep the note at the old location
+	solution = map[string]MergeSolution{
+		"moved_OldGUID_NewGUID": {Side: LeftSide, Solution: left[1], Discarded: right[1]},
+	}
+	merged, changes, err = MergeNotes(left, right, solution)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Note{nil, left[1], left[2]}, merged)
+	assert.Equal(t, IDChanges{Left: map[int]int{}, Right: map[int]int{1: 1}}, changes)
+}
+
+func Test_detectMovedNotes(t *testing.T) {
+	note := func(guid string, location int32, content string) *model.Note {
+		return &model.Note{
+			GUID:       guid,
+			LocationID: sql.NullInt32{Int32: location, Valid: true},
+			Content:    sql.NullString{String: content, Valid: content != ""},
+		}
+	}
+
+	left := []*model.Note{
+		nil,
+		note("Moved", 1, "Moved content"),
+		note("SameLocation", 1, "Same location"),
+		note("Duplicate1", 1, "Duplicate"),
+		note("Duplicate2", 2, "Duplicate"),
+		note("Empty", 1, ""),
+		note("SameGUID", 1, "Same GUID"),
+	}
+	right := []*model.Note{
+		nil,
+		note("MovedRight", 2, "Moved content"),
+		note("SameLocationRight", 1, "Same location"),
+		note("DuplicateRight", 3, "Duplicate"),
+		note("EmptyRight", 2, ""),
+		note("SameGUID", 2, "Same GUID"),
+	}
+
+	assert.Equal(t, map[string]MergeConflict{
+		"moved_Moved_MovedRight": {Left: left[1], Right: right[1]},
+	}, detectMovedNotes(left, right))
+}
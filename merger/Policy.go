@@ -0,0 +1,225 @@
+package merger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"gopkg.in/yaml.v2"
+)
+
+// Strategies of a Policy
+const (
+	// StrategyLeft always chooses the left side
+	StrategyLeft = "left"
+	// StrategyRight always chooses the right side
+	StrategyRight = "right"
+	// StrategyNewest chooses the newest note (see SolveConflictByChoosingNewest)
+	StrategyNewest = "newest"
+	// StrategyAsk leaves the conflict to be solved otherwise, like by asking
+	StrategyAsk = "ask"
+	// StrategyIgnoreColorDifferences keeps the left side of markings
+	// that only differ in their color
+	StrategyIgnoreColorDifferences = "ignore-color-differences"
+	// StrategyIgnoreWhitespaceDifferences keeps the left side of notes
+	// whose title and content only differ in whitespace
+	StrategyIgnoreWhitespaceDifferences = "ignore-whitespace-differences"
+)
+
+// Strategies is a list of strategies that are tried in order until
+// one of them solves a conflict. In YAML, it can be given as a single
+// strategy or as a list of them.
+type Strategies []string
+
+// UnmarshalYAML accepts a single strategy as well as a list of them
+func (s *Strategies) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*s = Strategies{single}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// Policy describes how conflicts of each table are solved, so the common
+// ways of solving them don't have to be chosen every time:
+//
+//	notes: newest
+//	bookmarks: left
+//	markings: [ignore-color-differences, right]
+//
+// Conflicts no strategy solves are left unresolved.
+type Policy struct {
+	Bookmarks Strategies `yaml:"bookmarks"`
+	Markings  Strategies `yaml:"markings"`
+	Notes     Strategies `yaml:"notes"`
+}
+
+// strategy decides a single conflict if it can. A decision
+// without a side leaves the conflict unresolved.
+type strategy func(conflict MergeConflict) (MergeSide, bool, error)
+
+// strategies are the strategies that can be used for each stage
+var strategies = map[string]map[string]strategy{
+	StageBookmarks: {
+		StrategyLeft:  chooseSide(LeftSide),
+		StrategyRight: chooseSide(RightSide),
+		StrategyAsk:   ask,
+	},
+	StageMarkings: {
+		StrategyLeft:                   chooseSide(LeftSide),
+		StrategyRight:                  chooseSide(RightSide),
+		StrategyAsk:                    ask,
+		StrategyIgnoreColorDifferences: ignoreColorDifferences,
+	},
+	StageNotes: {
+		StrategyLeft:                        chooseSide(LeftSide),
+		StrategyRight:                       chooseSide(RightSide),
+		StrategyNewest:                      chooseNewest,
+		StrategyAsk:                         ask,
+		StrategyIgnoreWhitespaceDifferences: ignoreWhitespaceDifferences,
+	},
+}
+
+// LoadPolicy reads a Policy from the given YAML file (see ParsePolicy)
+func LoadPolicy(filename string) (*Policy, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading policy: %w", err)
+	}
+	policy, err := ParsePolicy(content)
+	if err != nil {
+		return nil, fmt.Errorf("Error while parsing policy %s: %w", filename, err)
+	}
+	return policy, nil
+}
+
+// ParsePolicy parses a Policy from YAML and makes sure
+// every strategy can be used for its table
+func ParsePolicy(content []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(content, policy); err != nil {
+		return nil, err
+	}
+
+	for _, stage := range []string{StageBookmarks, StageMarkings, StageNotes} {
+		for _, name := range policy.strategies(stage) {
+			if _, ok := strategies[stage][name]; !ok {
+				supported := make([]string, 0, len(strategies[stage]))
+				for s := range strategies[stage] {
+					supported = append(supported, s)
+				}
+				sort.Strings(supported)
+				return nil, fmt.Errorf("%s is not a valid strategy for %s. Can be '%s'",
+					name, strings.ToLower(stage), strings.Join(supported, "', '"))
+			}
+		}
+	}
+	return policy, nil
+}
+
+// strategies returns the strategies of the given stage
+func (p *Policy) strategies(stage string) Strategies {
+	switch stage {
+	case StageBookmarks:
+		return p.Bookmarks
+	case StageMarkings:
+		return p.Markings
+	case StageNotes:
+		return p.Notes
+	}
+	return nil
+}
+
+// Resolve solves the conflicts of the given stage (like StageNotes) using
+// the strategies of the Policy. Conflicts that no strategy solves, as well
+// as conflicts of stages the Policy doesn't cover, are left unresolved.
+func (p *Policy) Resolve(stage string, conflicts map[string]MergeConflict) (map[string]MergeSolution, error) {
+	solutions := map[string]MergeSolution{}
+	names := p.strategies(stage)
+	if len(names) == 0 {
+		return solutions, nil
+	}
+
+	for key, conflict := range conflicts {
+		for _, name := range names {
+			solve, ok := strategies[stage][name]
+			if !ok {
+				return nil, fmt.Errorf("%s is not a valid strategy for %s", name, strings.ToLower(stage))
+			}
+			side, ok, err := solve(conflict)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if side == "" {
+				break
+			}
+			if side == LeftSide {
+				solutions[key] = MergeSolution{Side: LeftSide, Solution: conflict.Left, Discarded: conflict.Right}
+			} else {
+				solutions[key] = MergeSolution{Side: RightSide, Solution: conflict.Right, Discarded: conflict.Left}
+			}
+			break
+		}
+	}
+	return solutions, nil
+}
+
+func chooseSide(side MergeSide) strategy {
+	return func(conflict MergeConflict) (MergeSide, bool, error) {
+		return side, true, nil
+	}
+}
+
+// ask leaves the conflict unresolved, so the remaining strategies are skipped
+func ask(conflict MergeConflict) (MergeSide, bool, error) {
+	return "", true, nil
+}
+
+func chooseNewest(conflict MergeConflict) (MergeSide, bool, error) {
+	solutions, err := SolveConflictByChoosingNewest(map[string]MergeConflict{"": conflict})
+	if err != nil {
+		return "", false, err
+	}
+	return solutions[""].Side, true, nil
+}
+
+// ignoreColorDifferences keeps the left side of markings
+// that would be equal if they had the same color
+func ignoreColorDifferences(conflict MergeConflict) (MergeSide, bool, error) {
+	left, ok := conflict.Left.(*model.UserMarkBlockRange)
+	right, ok2 := conflict.Right.(*model.UserMarkBlockRange)
+	if !ok || !ok2 || left.UserMark == nil || right.UserMark == nil {
+		return "", false, nil
+	}
+
+	recolored := *left.UserMark
+	recolored.ColorIndex = right.UserMark.ColorIndex
+	withSameColor := &model.UserMarkBlockRange{UserMark: &recolored, BlockRanges: left.BlockRanges}
+	return LeftSide, withSameColor.Equals(right), nil
+}
+
+// ignoreWhitespaceDifferences keeps the left side of notes
+// whose title and content only differ in whitespace
+func ignoreWhitespaceDifferences(conflict MergeConflict) (MergeSide, bool, error) {
+	left, ok := conflict.Left.(*model.Note)
+	right, ok2 := conflict.Right.(*model.Note)
+	if !ok || !ok2 {
+		return "", false, nil
+	}
+
+	same := func(a, b string) bool {
+		return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+	}
+	return LeftSide, same(left.Title.String, right.Title.String) && same(left.Content.String, right.Content.String), nil
+}
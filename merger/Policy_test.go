@@ -0,0 +1,133 @@
+package merger
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`
+notes: newest
+bookmarks: left
+markings: [ignore-color-differences, right]
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, &Policy{
+		Notes:     Strategies{StrategyNewest},
+		Bookmarks: Strategies{StrategyLeft},
+		Markings:  Strategies{StrategyIgnoreColorDifferences, StrategyRight},
+	}, policy)
+
+	policy, err = ParsePolicy([]byte(""))
+	assert.NoError(t, err)
+	assert.Equal(t, &Policy{}, policy)
+
+	_, err = ParsePolicy([]byte("bookmarks: newest"))
+	assert.EqualError(t, err, "newest is not a valid strategy for bookmarks. Can be 'ask', 'left', 'right'")
+	_, err = ParsePolicy([]byte("notes: [left, ignore-color-differences]"))
+	assert.EqualError(t, err, "ignore-color-differences is not a valid strategy for notes. "+
+		"Can be 'ask', 'ignore-whitespace-differences', 'left', 'newest', 'right'")
+	_, err = ParsePolicy([]byte("tags: left"))
+	assert.Error(t, err)
+	_, err = ParsePolicy([]byte("notes: {left: true}"))
+	assert.Error(t, err)
+}
+
+func TestLoadPolicy(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "policy.yaml")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("notes: right\n"), 0644))
+	policy, err := LoadPolicy(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, Strategies{StrategyRight}, policy.Notes)
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("notes: up\n"), 0644))
+	_, err = LoadPolicy(filename)
+	assert.Contains(t, err.Error(), "Error while parsing policy "+filename+": up is not a valid strategy for notes")
+
+	_, err = LoadPolicy(filepath.Join(tmp, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestPolicy_Resolve(t *testing.T) {
+	policy := &Policy{
+		Bookmarks: Strategies{StrategyAsk, StrategyLeft},
+		Markings:  Strategies{StrategyIgnoreColorDifferences},
+		Notes:     Strategies{StrategyIgnoreWhitespaceDifferences, StrategyNewest},
+	}
+
+	// Bookmarks are never solved, as asking comes first
+	solutions, err := policy.Resolve(StageBookmarks, map[string]MergeConflict{
+		"bookmark": {Left: &model.Bookmark{BookmarkID: 1}, Right: &model.Bookmark{BookmarkID: 2}},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, solutions)
+
+	// Markings are only solved if they differ in color alone
+	blockRanges := func(start int32) []*model.BlockRange {
+		return []*model.BlockRange{{BlockType: 2, Identifier: 1, StartToken: sql.NullInt32{Int32: start, Valid: true}}}
+	}
+	yellow := &model.UserMarkBlockRange{UserMark: &model.UserMark{UserMarkID: 1, ColorIndex: 1, LocationID: 1}, BlockRanges: blockRanges(0)}
+	green := &model.UserMarkBlockRange{UserMark: &model.UserMark{UserMarkID: 2, ColorIndex: 2, LocationID: 1}, BlockRanges: blockRanges(0)}
+	shifted := &model.UserMarkBlockRange{UserMark: &model.UserMark{UserMarkID: 3, ColorIndex: 2, LocationID: 1}, BlockRanges: blockRanges(3)}
+	solutions, err = policy.Resolve(StageMarkings, map[string]MergeConflict{
+		"color": {Left: yellow, Right: green},
+		"range": {Left: yellow, Right: shifted},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]MergeSolution{
+		"color": {Side: LeftSide, Solution: yellow, Discarded: green},
+	}, solutions)
+
+	// Notes with whitespace changes keep the left side, others the newest
+	older := &model.Note{
+		NoteID:       1,
+		Title:        sql.NullString{String: "Faith", Valid: true},
+		Content:      sql.NullString{String: "Hebrews  11:1\n", Valid: true},
+		LastModified: "2021-04-01T10:00:00+00:00",
+	}
+	whitespace := &model.Note{
+		NoteID:       2,
+		Title:        sql.NullString{String: " Faith", Valid: true},
+		Content:      sql.NullString{String: "Hebrews 11:1", Valid: true},
+		LastModified: "2021-05-01T10:00:00+00:00",
+	}
+	changed := &model.Note{
+		NoteID:       3,
+		Title:        sql.NullString{String: "Faith", Valid: true},
+		Content:      sql.NullString{String: "Hebrews 11:6", Valid: true},
+		LastModified: "2021-05-01T10:00:00+00:00",
+	}
+	solutions, err = policy.Resolve(StageNotes, map[string]MergeConflict{
+		"whitespace": {Left: older, Right: whitespace},
+		"changed":    {Left: older, Right: changed},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]MergeSolution{
+		"whitespace": {Side: LeftSide, Solution: older, Discarded: whitespace},
+		"changed":    {Side: RightSide, Solution: changed, Discarded: older},
+	}, solutions)
+
+	// Stages that are not covered stay unresolved
+	solutions, err = policy.Resolve(StageTags, map[string]MergeConflict{
+		"tag": {Left: &model.Tag{TagID: 1}, Right: &model.Tag{TagID: 2}},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, solutions)
+
+	// Invalid dates are reported
+	invalid := &model.Note{NoteID: 4, LastModified: "yesterday"}
+	_, err = (&Policy{Notes: Strategies{StrategyNewest}}).Resolve(StageNotes, map[string]MergeConflict{
+		"invalid": {Left: older, Right: invalid},
+	})
+	assert.Error(t, err)
+}
@@ -1,35 +1,102 @@
 package merger
 
 import (
+	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/AndreasSko/go-jwlm/model"
 )
 
+// TagMapOrder defines how the TagMaps of a Tag are ordered after merging
+type TagMapOrder int
+
+const (
+	// OrderInterleave sorts the TagMaps of both sides by their
+	// original position, so they are interleaved. This is the default.
+	OrderInterleave TagMapOrder = iota
+	// OrderLeftFirst keeps the order of the left side and
+	// appends the TagMaps that only exist on the right side.
+	OrderLeftFirst
+	// OrderRightFirst keeps the order of the right side and
+	// appends the TagMaps that only exist on the left side.
+	OrderRightFirst
+	// OrderNoteTitle sorts tagged Notes alphabetically by their title.
+	// Other TagMaps (like Locations) are put after them.
+	OrderNoteTitle
+)
+
+// ParseTagMapOrder parses the name of a TagMapOrder, which can be
+// 'interleave', 'leftFirst', 'rightFirst', or 'noteTitle'.
+func ParseTagMapOrder(name string) (TagMapOrder, error) {
+	switch name {
+	case "", "interleave":
+		return OrderInterleave, nil
+	case "leftFirst":
+		return OrderLeftFirst, nil
+	case "rightFirst":
+		return OrderRightFirst, nil
+	case "noteTitle":
+		return OrderNoteTitle, nil
+	}
+
+	return OrderInterleave, fmt.Errorf("%s is not a valid order. Can be 'interleave', 'leftFirst', 'rightFirst', or 'noteTitle'", name)
+}
+
+// TagMapOptions are options for merging TagMaps
+type TagMapOptions struct {
+	// Order defines how the TagMaps of a Tag are ordered
+	Order TagMapOrder
+	// Notes are the merged Notes the TagMaps refer to,
+	// which are needed for sorting by OrderNoteTitle.
+	Notes []*model.Note
+}
+
+// tagMapEntry is a TagMap together with the sides it exists on
+type tagMapEntry struct {
+	left  *model.TagMap
+	right *model.TagMap
+}
+
 // MergeTagMaps merges a left and right slice of TagMap. It automatically
 // removes redundant entries and also makes sure that the position-order
 // stays similar.
 func MergeTagMaps(left []*model.TagMap, right []*model.TagMap, conflictSolution map[string]MergeSolution) ([]*model.TagMap, IDChanges, error) {
+	return MergeTagMapsWithOptions(left, right, conflictSolution, TagMapOptions{})
+}
+
+// MergeTagMapsWithOptions merges a left and right slice of TagMap like
+// MergeTagMaps, while ordering the TagMaps of a Tag as given by the options.
+func MergeTagMapsWithOptions(left []*model.TagMap, right []*model.TagMap, conflictSolution map[string]MergeSolution, options TagMapOptions) ([]*model.TagMap, IDChanges, error) {
 	if len(left)+len(right) == 0 {
 		return nil, IDChanges{}, nil
 	}
 
-	// map[TagID]map[UniqueKey]TagMap
-	tags := make(map[int]map[string]*model.TagMap, len(left)+len(right))
+	// map[TagID]map[UniqueKey]tagMapEntry
+	tags := make(map[int]map[string]*tagMapEntry, len(left)+len(right))
 
 	// Per TagID add TagMap entries to map with UniqueKey as the key,
 	// automatically filtering duplicate entries
-	for _, side := range [][]*model.TagMap{left, right} {
+	for sideIdx, side := range [][]*model.TagMap{left, right} {
 		for _, tm := range side {
 			if tm == nil {
 				continue
 			}
 
 			if _, ok := tags[tm.TagID]; !ok {
-				tags[tm.TagID] = map[string]*model.TagMap{}
+				tags[tm.TagID] = map[string]*tagMapEntry{}
 			}
 
-			tags[tm.TagID][tm.UniqueKey()] = tm
+			entry, ok := tags[tm.TagID][tm.UniqueKey()]
+			if !ok {
+				entry = &tagMapEntry{}
+				tags[tm.TagID][tm.UniqueKey()] = entry
+			}
+			if sideIdx == 0 {
+				entry.left = tm
+			} else {
+				entry.right = tm
+			}
 		}
 	}
 
@@ -47,32 +114,94 @@ func MergeTagMaps(left []*model.TagMap, right []*model.TagMap, conflictSolution
 	// For each TagID add all connected TagMaps to result
 	i = 1
 	for _, id := range sortedTagIDs {
-		tagMapSet := tags[id]
-		sortedTagMap := make([]*model.TagMap, len(tagMapSet))
-		j := 0
-		for _, tm := range tagMapSet {
-			sortedTagMap[j] = tm
-			j++
+		entrySet := tags[id]
+		sortedEntries := make([]*tagMapEntry, 0, len(entrySet))
+		for _, entry := range entrySet {
+			sortedEntries = append(sortedEntries, entry)
 		}
+		sortTagMapEntries(sortedEntries, options)
 
-		sort.SliceStable(sortedTagMap, func(i, j int) bool {
-			// If equal position, sort by TagID
-			if sortedTagMap[i].Position == sortedTagMap[j].Position {
-				return sortedTagMap[i].TagMapID < sortedTagMap[j].TagMapID
-			}
-			return sortedTagMap[i].Position < sortedTagMap[j].Position
-		})
-
-		j = 0
-		for j, tm := range sortedTagMap {
-			result[i] = model.MakeModelCopy(tm).(*model.TagMap)
+		for j, entry := range sortedEntries {
+			result[i] = model.MakeModelCopy(entry.tagMap(options.Order)).(*model.TagMap)
 			result[i].SetID(i)
 			// Position is defined per Tag(!), not PlaylistItemID/LocationID/NoteID
 			result[i].Position = j
-			j++
 			i++
 		}
 	}
 
 	return result[:i], IDChanges{}, nil
 }
+
+// tagMap returns the TagMap of the side whose position
+// should be used for the given order
+func (e *tagMapEntry) tagMap(order TagMapOrder) *model.TagMap {
+	if order == OrderLeftFirst && e.left != nil {
+		return e.left
+	}
+	if e.right != nil {
+		return e.right
+	}
+	return e.left
+}
+
+// rank returns 0 if the entry exists on the side that
+// comes first for the given order, and 1 otherwise
+func (e *tagMapEntry) rank(order TagMapOrder) int {
+	switch {
+	case order == OrderLeftFirst && e.left == nil:
+		return 1
+	case order == OrderRightFirst && e.right == nil:
+		return 1
+	}
+	return 0
+}
+
+// sortTagMapEntries sorts the entries of a Tag as given by the options
+func sortTagMapEntries(entries []*tagMapEntry, options TagMapOptions) {
+	var titles map[int]string
+	if options.Order == OrderNoteTitle {
+		titles = make(map[int]string, len(options.Notes))
+		for _, note := range options.Notes {
+			if note != nil {
+				titles[note.NoteID] = strings.ToLower(note.Title.String)
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a := entries[i].tagMap(options.Order)
+		b := entries[j].tagMap(options.Order)
+
+		if options.Order == OrderNoteTitle {
+			aTitle, aIsNote := titles[int(a.NoteID.Int32)]
+			bTitle, bIsNote := titles[int(b.NoteID.Int32)]
+			aIsNote = aIsNote && a.NoteID.Valid
+			bIsNote = bIsNote && b.NoteID.Valid
+			if aIsNote != bIsNote {
+				return aIsNote
+			}
+			if aTitle != bTitle {
+				return aTitle < bTitle
+			}
+		}
+
+		if aRank, bRank := entries[i].rank(options.Order), entries[j].rank(options.Order); aRank != bRank {
+			return aRank < bRank
+		}
+
+		// Sort by position and, if equal, by TagMapID
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		if a.TagMapID != b.TagMapID {
+			return a.TagMapID < b.TagMapID
+		}
+		// Entries come from a map, so keep ties deterministic
+		// by putting entries of the left side first
+		if aLeft, bLeft := entries[i].left != nil, entries[j].left != nil; aLeft != bLeft {
+			return aLeft
+		}
+		return a.UniqueKey() < b.UniqueKey()
+	})
+}
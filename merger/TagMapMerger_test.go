@@ -2,6 +2,7 @@ package merger
 
 import (
 	"database/sql"
+	"fmt"
 	"testing"
 
 	"github.com/AndreasSko/go-jwlm/model"
@@ -286,3 +287,75 @@ func TestMergeTagMaps(t *testing.T) {
 		MergeTagMaps([]*model.TagMap{}, []*model.TagMap{}, nil)
 	})
 }
+
+func TestMergeTagMapsWithOptions(t *testing.T) {
+	left := []*model.TagMap{
+		nil,
+		{TagMapID: 1, NoteID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 1, Position: 0},
+		{TagMapID: 2, NoteID: sql.NullInt32{Int32: 2, Valid: true}, TagID: 1, Position: 1},
+		{TagMapID: 3, LocationID: sql.NullInt32{Int32: 5, Valid: true}, TagID: 1, Position: 2},
+	}
+	right := []*model.TagMap{
+		nil,
+		{TagMapID: 4, NoteID: sql.NullInt32{Int32: 2, Valid: true}, TagID: 1, Position: 0},
+		{TagMapID: 5, NoteID: sql.NullInt32{Int32: 3, Valid: true}, TagID: 1, Position: 1},
+	}
+	notes := []*model.Note{
+		nil,
+		{NoteID: 1, Title: sql.NullString{String: "Zebra", Valid: true}},
+		{NoteID: 2, Title: sql.NullString{String: "apple", Valid: true}},
+		{NoteID: 3, Title: sql.NullString{String: "Mango", Valid: true}},
+	}
+
+	tests := []struct {
+		order    TagMapOrder
+		expected []string
+	}{
+		{OrderInterleave, []string{"note1", "note2", "note3", "location5"}},
+		{OrderLeftFirst, []string{"note1", "note2", "location5", "note3"}},
+		{OrderRightFirst, []string{"note2", "note3", "note1", "location5"}},
+		{OrderNoteTitle, []string{"note2", "note3", "note1", "location5"}},
+	}
+	for _, test := range tests {
+		merged, _, err := MergeTagMapsWithOptions(left, right, nil, TagMapOptions{Order: test.order, Notes: notes})
+		assert.NoError(t, err)
+
+		var order []string
+		for i, tm := range merged[1:] {
+			assert.Equal(t, i+1, tm.TagMapID)
+			assert.Equal(t, i, tm.Position)
+			if tm.NoteID.Valid {
+				order = append(order, fmt.Sprintf("note%d", tm.NoteID.Int32))
+			} else {
+				order = append(order, fmt.Sprintf("location%d", tm.LocationID.Int32))
+			}
+		}
+		assert.Equal(t, test.expected, order, "Order %d", test.order)
+	}
+
+	// The original TagMaps should not be changed
+	assert.Equal(t, 1, left[2].Position)
+	assert.Equal(t, 0, right[1].Position)
+
+	// Without the Notes, the original order is kept
+	merged, _, err := MergeTagMapsWithOptions(left, right, nil, TagMapOptions{Order: OrderNoteTitle})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), merged[1].NoteID.Int32)
+}
+
+func TestParseTagMapOrder(t *testing.T) {
+	for name, expected := range map[string]TagMapOrder{
+		"":           OrderInterleave,
+		"interleave": OrderInterleave,
+		"leftFirst":  OrderLeftFirst,
+		"rightFirst": OrderRightFirst,
+		"noteTitle":  OrderNoteTitle,
+	} {
+		order, err := ParseTagMapOrder(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, order)
+	}
+
+	_, err := ParseTagMapOrder("random")
+	assert.EqualError(t, err, "random is not a valid order. Can be 'interleave', 'leftFirst', 'rightFirst', or 'noteTitle'")
+}
@@ -2,10 +2,52 @@ package merger
 
 import "github.com/AndreasSko/go-jwlm/model"
 
-// MergeTags tries to merge the left and right slice of Tag. If there is a
+// MergeTags tries to merge the left and right slice of Tag. Hierarchical
+// names are compared in their normalized form (see model.NormalizeTagName),
+// so "Talks / 2024" and "Talks/2024" are merged into one Tag. If there is a
 // collision, it returns an error asking for specification how it should handle it.
 func MergeTags(left []*model.Tag, right []*model.Tag, conflictSolution map[string]MergeSolution) ([]*model.Tag, IDChanges, error) {
-	result, changes, err := tryMergeWithConflictSolver(left, right, conflictSolution, solveEqualityMergeConflict)
+	result, changes, err := tryMergeWithConflictSolver(left, right, conflictSolution, solveTagMergeConflict)
 
 	return model.Tag{}.MakeSlice(result), changes, err
 }
+
+// solveTagMergeConflict solves conflicts of Tags that are equal or only
+// differ in the notation of their hierarchical name. In the latter case,
+// the Tag with the normalized name is chosen. For other conflicts it
+// returns a mergeConflictError asking the caller to handle it.
+func solveTagMergeConflict(conflicts map[string]MergeConflict) (map[string]MergeSolution, error) {
+	solution := make(map[string]MergeSolution, len(conflicts))
+	unsolvableConflicts := map[string]MergeConflict{}
+
+	for key, value := range conflicts {
+		left, leftOk := value.Left.(*model.Tag)
+		right, rightOk := value.Right.(*model.Tag)
+		switch {
+		case value.Left.Equals(value.Right):
+			solution[key] = MergeSolution{Side: LeftSide, Solution: value.Left, Discarded: value.Right}
+		case leftOk && rightOk && sameNormalizedTag(left, right):
+			if left.Name != model.NormalizeTagName(left.Name) && right.Name == model.NormalizeTagName(right.Name) {
+				solution[key] = MergeSolution{Side: RightSide, Solution: value.Right, Discarded: value.Left}
+			} else {
+				solution[key] = MergeSolution{Side: LeftSide, Solution: value.Left, Discarded: value.Right}
+			}
+		default:
+			unsolvableConflicts[key] = value
+		}
+	}
+
+	if len(unsolvableConflicts) != 0 {
+		return solution, MergeConflictError{Err: "Could not solve all conflicts", Conflicts: unsolvableConflicts}
+	}
+
+	return solution, nil
+}
+
+// sameNormalizedTag checks if both Tags are equal
+// when comparing their normalized names
+func sameNormalizedTag(left *model.Tag, right *model.Tag) bool {
+	return left.TagType == right.TagType &&
+		model.NormalizeTagName(left.Name) == model.NormalizeTagName(right.Name) &&
+		left.ImageFilename == right.ImageFilename
+}
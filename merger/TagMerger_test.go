@@ -127,3 +127,30 @@ func TestMergeTags(t *testing.T) {
 	assert.Equal(t, 1, left[0].TagID)
 	assert.Equal(t, 1, right[0].TagID)
 }
+
+func TestMergeTags_Hierarchical(t *testing.T) {
+	left := []*model.Tag{
+		nil,
+		{TagID: 1, TagType: 1, Name: "Talks / 2024"},
+		{TagID: 2, TagType: 1, Name: "Talks/2023"},
+	}
+	right := []*model.Tag{
+		nil,
+		{TagID: 1, TagType: 1, Name: "Talks/2024"},
+		{TagID: 2, TagType: 1, Name: "Talks / 2023"},
+	}
+
+	result, changes, err := MergeTags(left, right, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Tag{
+		nil,
+		{TagID: 1, TagType: 1, Name: "Talks/2024"},
+		{TagID: 2, TagType: 1, Name: "Talks/2023"},
+	}, result)
+	assert.Equal(t, IDChanges{Left: map[int]int{}, Right: map[int]int{}}, changes)
+
+	// Different images can't be solved automatically
+	right[1].ImageFilename = sql.NullString{String: "image.jpg", Valid: true}
+	_, _, err = MergeTags(left, right, nil)
+	assert.IsType(t, MergeConflictError{}, err)
+}
@@ -84,10 +84,13 @@ func mergeUMBR(left []*model.UserMarkBlockRange, right []*model.UserMarkBlockRan
 	// and right side, so we don't detect them again.
 	changes, invertedChanges := replaceUMBRConflictsWithSolution(&left, &right, conflictSolution)
 
-	conflicts := map[string]MergeConflict{}
+	// Entries with the same UserMarkGUID on both sides are the same highlight,
+	// so if it has been changed on one side (e.g. recolored), it's a conflict.
+	// They are excluded from the following detection of overlapping BlockRanges.
+	conflicts, leftRemaining, rightRemaining := detectUserMarkGUIDConflicts(left, right)
 
 	// Ingest UserMarks & BlockRanges in a Map[LocationID]map[Identifier][]*model.BlockRange
-	blRanges := ingestUMBR(left, right)
+	blRanges := ingestUMBR(leftRemaining, rightRemaining)
 
 	// For each BlockRange slice per identifier, sort BlockRanges by StartToken
 	for _, locationBlock := range blRanges {
@@ -130,7 +133,7 @@ func mergeUMBR(left []*model.UserMarkBlockRange, right []*model.UserMarkBlockRan
 					conflictKey.WriteString(first.UniqueKey())
 					conflictKey.WriteString("_")
 					conflictKey.WriteString(second.UniqueKey())
-					conflicts[conflictKey.String()] = MergeConflict{first, second}
+					conflicts[conflictKey.String()] = MergeConflict{Left: first, Right: second}
 
 					// Skip further possible collisions of this interval
 					// by continuing at the next BlockRange that starts after the
@@ -193,6 +196,49 @@ func mergeUMBR(left []*model.UserMarkBlockRange, right []*model.UserMarkBlockRan
 	return result[:i], changes, nil
 }
 
+// detectUserMarkGUIDConflicts returns conflicts for entries that have the same
+// UserMarkGUID on both sides, but are not equal. As the UserMarkGUID must be
+// unique, only one of them can be kept. It also returns copies of left and
+// right without the conflicting entries.
+func detectUserMarkGUIDConflicts(left []*model.UserMarkBlockRange, right []*model.UserMarkBlockRange) (map[string]MergeConflict, []*model.UserMarkBlockRange, []*model.UserMarkBlockRange) {
+	conflicts := map[string]MergeConflict{}
+
+	leftGUIDs := make(map[string]int, len(left))
+	for i, umbr := range left {
+		if umbr == nil || umbr.UserMark.UserMarkGUID == "" {
+			continue
+		}
+		leftGUIDs[umbr.UserMark.UserMarkGUID] = i
+	}
+
+	leftRemaining := append([]*model.UserMarkBlockRange{}, left...)
+	rightRemaining := append([]*model.UserMarkBlockRange{}, right...)
+	for i, umbr := range right {
+		if umbr == nil {
+			continue
+		}
+		j, ok := leftGUIDs[umbr.UserMark.UserMarkGUID]
+		if !ok || left[j].Equals(umbr) {
+			continue
+		}
+
+		var conflictKey strings.Builder
+		// Use UnixNano as a monotonically increasing number, so we
+		// are able to apply conflict solutions in the right order later
+		conflictKey.WriteString(fmt.Sprint(time.Now().UnixNano()))
+		conflictKey.WriteString("_")
+		conflictKey.WriteString(left[j].UniqueKey())
+		conflictKey.WriteString("_")
+		conflictKey.WriteString(umbr.UniqueKey())
+		conflicts[conflictKey.String()] = MergeConflict{Left: left[j], Right: umbr}
+
+		leftRemaining[j] = nil
+		rightRemaining[i] = nil
+	}
+
+	return conflicts, leftRemaining, rightRemaining
+}
+
 // detectAndFilterDuplicateBRs removes block Range entries that exists on both
 // sides (duplicates) and only leaves the one on the left side.
 // It returns a slice of brFroms sorted by StartToken
@@ -250,7 +296,7 @@ func detectAndFilterDuplicateBRs(idBlock []brFrom, left []*model.UserMarkBlockRa
 				conflictKey.WriteString(first.UniqueKey())
 				conflictKey.WriteString("_")
 				conflictKey.WriteString(second.UniqueKey())
-				conflicts[conflictKey.String()] = MergeConflict{first, second}
+				conflicts[conflictKey.String()] = MergeConflict{Left: first, Right: second}
 				idBlock[j] = brFrom{}
 			}
 		}
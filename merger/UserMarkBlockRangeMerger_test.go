@@ -3694,3 +3694,93 @@ func Test_sortBRFroms(t *testing.T) {
 
 	assert.Equal(t, expectedResult, sortBRFroms(entries))
 }
+
+func TestMergeUserMarkAndBlockRange_sameGUID(t *testing.T) {
+	// The highlight has been synced to both sides and
+	// then recolored and extended on the right side.
+	leftUM := []*model.UserMark{
+		nil,
+		{UserMarkID: 1, LocationID: 1, ColorIndex: 1, UserMarkGUID: "SYNCED"},
+		{UserMarkID: 2, LocationID: 1, ColorIndex: 2, UserMarkGUID: "ONLY_LEFT"},
+	}
+	leftBR := []*model.BlockRange{
+		nil,
+		{BlockRangeID: 1, UserMarkID: 1, Identifier: 1, StartToken: sql.NullInt32{Int32: 0, Valid: true}, EndToken: sql.NullInt32{Int32: 5, Valid: true}},
+		{BlockRangeID: 2, UserMarkID: 2, Identifier: 3, StartToken: sql.NullInt32{Int32: 0, Valid: true}, EndToken: sql.NullInt32{Int32: 5, Valid: true}},
+	}
+	rightUM := []*model.UserMark{
+		nil,
+		{UserMarkID: 1, LocationID: 1, ColorIndex: 3, UserMarkGUID: "SYNCED"},
+	}
+	rightBR := []*model.BlockRange{
+		nil,
+		{BlockRangeID: 1, UserMarkID: 1, Identifier: 1, StartToken: sql.NullInt32{Int32: 0, Valid: true}, EndToken: sql.NullInt32{Int32: 5, Valid: true}},
+		{BlockRangeID: 2, UserMarkID: 1, Identifier: 2, StartToken: sql.NullInt32{Int32: 0, Valid: true}, EndToken: sql.NullInt32{Int32: 3, Valid: true}},
+	}
+
+	_, _, _, err := MergeUserMarkAndBlockRange(leftUM, leftBR, rightUM, rightBR, nil)
+	assert.IsType(t, MergeConflictError{}, err)
+	conflicts := mergeConflictMapToSliceHelper(err.(MergeConflictError).Conflicts)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, leftUM[1], conflicts[0].Left.(*model.UserMarkBlockRange).UserMark)
+	assert.Equal(t, rightUM[1], conflicts[0].Right.(*model.UserMarkBlockRange).UserMark)
+
+	// Choosing the right side keeps only one entry for the highlight
+	var key string
+	for k := range err.(MergeConflictError).Conflicts {
+		key = k
+	}
+	solution := map[string]MergeSolution{
+		key: {Side: RightSide, Solution: conflicts[0].Right, Discarded: conflicts[0].Left},
+	}
+	um, br, _, err := MergeUserMarkAndBlockRange(leftUM, leftBR, rightUM, rightBR, solution)
+	assert.NoError(t, err)
+	assert.Len(t, um, 3)
+	guids := map[string]int{}
+	for _, entry := range um[1:] {
+		guids[entry.UserMarkGUID] = entry.ColorIndex
+	}
+	assert.Equal(t, map[string]int{"SYNCED": 3, "ONLY_LEFT": 2}, guids)
+	assert.Len(t, br, 4)
+}
+
+func Test_detectUserMarkGUIDConflicts(t *testing.T) {
+	left := []*model.UserMarkBlockRange{
+		nil,
+		{
+			UserMark:    &model.UserMark{UserMarkID: 1, LocationID: 1, ColorIndex: 1, UserMarkGUID: "RECOLORED"},
+			BlockRanges: []*model.BlockRange{{BlockRangeID: 1, UserMarkID: 1, Identifier: 1}},
+		},
+		{
+			UserMark:    &model.UserMark{UserMarkID: 2, LocationID: 1, ColorIndex: 1, UserMarkGUID: "EQUAL"},
+			BlockRanges: []*model.BlockRange{{BlockRangeID: 2, UserMarkID: 2, Identifier: 2}},
+		},
+		{
+			UserMark:    &model.UserMark{UserMarkID: 3, LocationID: 1, ColorIndex: 1},
+			BlockRanges: []*model.BlockRange{{BlockRangeID: 3, UserMarkID: 3, Identifier: 3}},
+		},
+	}
+	right := []*model.UserMarkBlockRange{
+		nil,
+		{
+			UserMark:    &model.UserMark{UserMarkID: 1, LocationID: 1, ColorIndex: 1, UserMarkGUID: "EQUAL"},
+			BlockRanges: []*model.BlockRange{{BlockRangeID: 1, UserMarkID: 1, Identifier: 2}},
+		},
+		{
+			UserMark:    &model.UserMark{UserMarkID: 2, LocationID: 1, ColorIndex: 4, UserMarkGUID: "RECOLORED"},
+			BlockRanges: []*model.BlockRange{{BlockRangeID: 2, UserMarkID: 2, Identifier: 1}},
+		},
+		{
+			UserMark:    &model.UserMark{UserMarkID: 3, LocationID: 1, ColorIndex: 2},
+			BlockRanges: []*model.BlockRange{{BlockRangeID: 3, UserMarkID: 3, Identifier: 3}},
+		},
+	}
+
+	conflicts, leftRemaining, rightRemaining := detectUserMarkGUIDConflicts(left, right)
+	assert.Equal(t, []MergeConflict{{Left: left[1], Right: right[2]}}, mergeConflictMapToSliceHelper(conflicts))
+	assert.Equal(t, []*model.UserMarkBlockRange{nil, nil, left[2], left[3]}, leftRemaining)
+	assert.Equal(t, []*model.UserMarkBlockRange{nil, right[1], nil, right[3]}, rightRemaining)
+	// The original slices are not changed
+	assert.NotNil(t, left[1])
+	assert.NotNil(t, right[2])
+}
@@ -0,0 +1,281 @@
+package merger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// ErrVerificationFailed indicates that a merged Database violates an
+// invariant of merging. Use errors.As to get a VerificationError with details.
+var ErrVerificationFailed = errors.New("Merged database failed verification")
+
+// VerificationError lists the invariants a merged Database violates.
+// It matches ErrVerificationFailed when using errors.Is.
+type VerificationError struct {
+	Problems []string
+}
+
+func (e VerificationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrVerificationFailed, strings.Join(e.Problems, "; "))
+}
+
+// Unwrap returns ErrVerificationFailed
+func (e VerificationError) Unwrap() error {
+	return ErrVerificationFailed
+}
+
+// entryKey identifies an entry independent of its ID and the
+// IDs of the entries it refers to, so entries of different
+// Databases can be compared with each other.
+type entryKey struct {
+	table string
+	key   string
+}
+
+// keyedEntry is an entry of a Database together with its entryKey
+type keyedEntry struct {
+	key   entryKey
+	entry model.Model
+	// dependsOn is the key of an entry that, if discarded, might
+	// cause this entry to be discarded or replaced as well
+	dependsOn *entryKey
+}
+
+// VerifyMerge checks if the merged Database is a valid result of merging
+// the left and right Database with the given solutions (one map per merge
+// stage). left and right must be the Databases as they were before merging,
+// as merging updates their IDs. It checks that
+//   - every entry of left and right is part of the merged Database,
+//     unless it has been discarded by one of the solutions,
+//   - the entries of the merged Database don't refer to missing entries,
+//   - every conflict has been resolved as recorded in the solutions.
+//
+// If one of the checks fails, a VerificationError is returned.
+func VerifyMerge(left *model.Database, right *model.Database, merged *model.Database, solutions ...map[string]MergeSolution) error {
+	var problems []string
+
+	// Merging might migrate Locations to the study edition, which
+	// changes their keys, so do the same on copies of left and right
+	left = model.MakeDatabaseCopy(left)
+	right = model.MakeDatabaseCopy(right)
+	moveToNwtsty(needsNwtstyMigration(left.Location, right.Location), left.Location, right.Location)
+
+	mergedEntries, dangling := keyDatabase(merged)
+	problems = append(problems, dangling...)
+	mergedIndex := make(map[entryKey]model.Model, len(mergedEntries))
+	for _, e := range mergedEntries {
+		mergedIndex[e.key] = e.entry
+	}
+
+	// Solutions refer to the IDs of the merged Database
+	discarded := map[entryKey]bool{}
+	var solutionProblems []string
+	for _, stage := range solutions {
+		for _, sol := range stage {
+			if sol.Discarded != nil {
+				if key, err := modelKey(merged, sol.Discarded); err == nil {
+					discarded[key] = true
+				}
+			}
+			if sol.Solution == nil {
+				continue
+			}
+			key, err := modelKey(merged, sol.Solution)
+			if err != nil {
+				solutionProblems = append(solutionProblems, fmt.Sprintf("Solution %s: %s", describe(sol.Solution), err))
+				continue
+			}
+			entry, ok := mergedIndex[key]
+			if !ok {
+				solutionProblems = append(solutionProblems, fmt.Sprintf("Solution %s is missing", describe(sol.Solution)))
+			} else if !entry.Equals(sol.Solution) {
+				solutionProblems = append(solutionProblems, fmt.Sprintf("Solution %s differs from the merged entry", describe(sol.Solution)))
+			}
+		}
+	}
+	sort.Strings(solutionProblems)
+	problems = append(problems, solutionProblems...)
+
+	for _, side := range []struct {
+		name string
+		db   *model.Database
+	}{
+		{"left", left},
+		{"right", right},
+	} {
+		entries, _ := keyDatabase(side.db)
+		for _, e := range entries {
+			if _, ok := mergedIndex[e.key]; ok || discarded[e.key] {
+				continue
+			}
+			if e.dependsOn != nil && discarded[*e.dependsOn] {
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("%s of the %s side is missing", describe(e.entry), side.name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return VerificationError{Problems: problems}
+	}
+	return nil
+}
+
+// keyDatabase returns all entries of the Database with their keys.
+// Entries referring to missing entries are reported as problems.
+func keyDatabase(db *model.Database) ([]keyedEntry, []string) {
+	var entries []keyedEntry
+	var problems []string
+
+	add := func(m model.Model) {
+		key, err := modelKey(db, m)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", describe(m), err))
+			return
+		}
+		entry := keyedEntry{key: key, entry: m}
+		if tm, ok := m.(*model.TagMap); ok && tm.NoteID.Valid {
+			if note, ok := db.FetchFromTable("Note", int(tm.NoteID.Int32)).(*model.Note); ok {
+				entry.dependsOn = &entryKey{"Note", note.UniqueKey()}
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, m := range db.Location {
+		if m != nil {
+			add(m)
+		}
+	}
+	for _, m := range db.Bookmark {
+		if m != nil {
+			add(m)
+		}
+	}
+	for _, m := range db.Tag {
+		if m != nil {
+			add(m)
+		}
+	}
+	ranges := map[int][]*model.BlockRange{}
+	for _, br := range db.BlockRange {
+		if br == nil {
+			continue
+		}
+		if db.FetchFromTable("UserMark", br.UserMarkID) == nil {
+			problems = append(problems, fmt.Sprintf("%s: refers to missing UserMark %d", describe(br), br.UserMarkID))
+			continue
+		}
+		ranges[br.UserMarkID] = append(ranges[br.UserMarkID], br)
+	}
+	for _, m := range db.UserMark {
+		if m != nil {
+			add(&model.UserMarkBlockRange{UserMark: m, BlockRanges: ranges[m.UserMarkID]})
+		}
+	}
+	for _, m := range db.Note {
+		if m != nil {
+			add(m)
+		}
+	}
+	for _, m := range db.TagMap {
+		if m != nil {
+			add(m)
+		}
+	}
+
+	return entries, problems
+}
+
+// modelKey returns the entryKey of the given entry. The entries it
+// refers to are looked up in the given Database. If one of them
+// is missing, an error is returned.
+func modelKey(db *model.Database, m model.Model) (entryKey, error) {
+	switch m := m.(type) {
+	case *model.Location, *model.Tag:
+		return entryKey{tableName(m), m.UniqueKey()}, nil
+	case *model.Note:
+		if m.LocationID.Valid {
+			if _, err := referencedKey(db, "Location", int(m.LocationID.Int32)); err != nil {
+				return entryKey{}, err
+			}
+		}
+		if m.UserMarkID.Valid {
+			if _, err := referencedKey(db, "UserMark", int(m.UserMarkID.Int32)); err != nil {
+				return entryKey{}, err
+			}
+		}
+		return entryKey{"Note", m.UniqueKey()}, nil
+	case *model.Bookmark:
+		if _, err := referencedKey(db, "Location", m.LocationID); err != nil {
+			return entryKey{}, err
+		}
+		publication, err := referencedKey(db, "Location", m.PublicationLocationID)
+		if err != nil {
+			return entryKey{}, err
+		}
+		return entryKey{"Bookmark", publication + "|" + strconv.Itoa(m.Slot)}, nil
+	case *model.UserMarkBlockRange:
+		location, err := referencedKey(db, "Location", m.UserMark.LocationID)
+		if err != nil {
+			return entryKey{}, err
+		}
+		rangeKeys := make([]string, len(m.BlockRanges))
+		for i, br := range m.BlockRanges {
+			rangeKeys[i] = fmt.Sprintf("%d_%d_%d_%d", br.BlockType, br.Identifier, br.StartToken.Int32, br.EndToken.Int32)
+		}
+		sort.Strings(rangeKeys)
+		return entryKey{"UserMark", location + "|" + strings.Join(rangeKeys, "|")}, nil
+	case *model.TagMap:
+		tag, err := referencedKey(db, "Tag", m.TagID)
+		if err != nil {
+			return entryKey{}, err
+		}
+		var target string
+		switch {
+		case m.NoteID.Valid:
+			target, err = referencedKey(db, "Note", int(m.NoteID.Int32))
+		case m.LocationID.Valid:
+			target, err = referencedKey(db, "Location", int(m.LocationID.Int32))
+		default:
+			target = "playlist_" + strconv.Itoa(int(m.PlaylistItemID.Int32))
+		}
+		if err != nil {
+			return entryKey{}, err
+		}
+		return entryKey{"TagMap", tag + "|" + target}, nil
+	}
+
+	return entryKey{}, fmt.Errorf("Entry of type %T is not supported", m)
+}
+
+// referencedKey returns the UniqueKey of the referenced entry
+func referencedKey(db *model.Database, table string, id int) (string, error) {
+	if id <= 0 {
+		return "", fmt.Errorf("refers to missing %s %d", table, id)
+	}
+	entry := db.FetchFromTable(table, id)
+	if entry == nil {
+		return "", fmt.Errorf("refers to missing %s %d", table, id)
+	}
+	return entry.UniqueKey(), nil
+}
+
+// tableName returns the name of the table of the given entry
+func tableName(m model.Model) string {
+	switch m.(type) {
+	case *model.UserMarkBlockRange:
+		return "UserMark"
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", m), "*model.")
+}
+
+// describe returns a short description of the entry for error messages
+func describe(m model.Model) string {
+	return fmt.Sprintf("%s %d", tableName(m), m.ID())
+}
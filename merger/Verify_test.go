@@ -0,0 +1,146 @@
+package merger
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// mergeChoosingSide merges left and right using MergeBackups, solving
+// all conflicts by choosing the given side. It returns the merged
+// Database together with the solutions of each stage.
+func mergeChoosingSide(t *testing.T, left *model.Database, right *model.Database, side MergeSide) (*model.Database, []map[string]MergeSolution) {
+	result, err := MergeBackups(left, right, MergeOptions{
+		Solve: func(stage string, conflicts map[string]MergeConflict, merged *model.Database) (map[string]MergeSolution, error) {
+			return solveConflictByChoosingSide(conflicts, side)
+		},
+	})
+	assert.NoError(t, err)
+
+	return result.Merged, []map[string]MergeSolution{
+		result.Solutions[StageBookmarks],
+		result.Solutions[StageTags],
+		result.Solutions[StageMarkings],
+		result.Solutions[StageNotes],
+	}
+}
+
+func verifyTestDatabases() (*model.Database, *model.Database) {
+	location := func(id int, chapter int32) *model.Location {
+		return &model.Location{
+			LocationID:    id,
+			KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			MepsLanguage:  2,
+			BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: chapter, Valid: true},
+		}
+	}
+	left := &model.Database{
+		BlockRange: []*model.BlockRange{
+			nil,
+			{BlockRangeID: 1, UserMarkID: 1, Identifier: 1, StartToken: sql.NullInt32{Int32: 0, Valid: true}, EndToken: sql.NullInt32{Int32: 5, Valid: true}},
+		},
+		Bookmark: []*model.Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 1, Slot: 0, Title: "Left"},
+		},
+		Location: []*model.Location{nil, location(1, 1)},
+		Note: []*model.Note{
+			nil,
+			{NoteID: 1, GUID: "A", Content: sql.NullString{String: "Left", Valid: true}},
+			{NoteID: 2, GUID: "B", Content: sql.NullString{String: "Only left", Valid: true}, LocationID: sql.NullInt32{Int32: 1, Valid: true}},
+		},
+		Tag: []*model.Tag{nil, {TagID: 1, TagType: 1, Name: "Talks / 2024"}},
+		TagMap: []*model.TagMap{
+			nil,
+			{TagMapID: 1, TagID: 1, NoteID: sql.NullInt32{Int32: 2, Valid: true}},
+		},
+		UserMark: []*model.UserMark{
+			nil,
+			{UserMarkID: 1, ColorIndex: 1, LocationID: 1, UserMarkGUID: "LEFT"},
+		},
+	}
+	right := &model.Database{
+		BlockRange: []*model.BlockRange{
+			nil,
+			{BlockRangeID: 1, UserMarkID: 1, Identifier: 1, StartToken: sql.NullInt32{Int32: 3, Valid: true}, EndToken: sql.NullInt32{Int32: 8, Valid: true}},
+		},
+		Bookmark: []*model.Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 2, PublicationLocationID: 2, Slot: 0, Title: "Right"},
+		},
+		Location: []*model.Location{nil, location(1, 2), location(2, 1)},
+		Note: []*model.Note{
+			nil,
+			{NoteID: 1, GUID: "A", Content: sql.NullString{String: "Right", Valid: true}},
+			{NoteID: 2, GUID: "C", Content: sql.NullString{String: "Only right", Valid: true}, LocationID: sql.NullInt32{Int32: 1, Valid: true}},
+		},
+		Tag: []*model.Tag{nil, {TagID: 1, TagType: 1, Name: "Talks/2024"}},
+		TagMap: []*model.TagMap{
+			nil,
+			{TagMapID: 1, TagID: 1, NoteID: sql.NullInt32{Int32: 2, Valid: true}},
+			{TagMapID: 2, TagID: 1, LocationID: sql.NullInt32{Int32: 1, Valid: true}, Position: 1},
+		},
+		UserMark: []*model.UserMark{
+			nil,
+			{UserMarkID: 1, ColorIndex: 2, LocationID: 2, UserMarkGUID: "RIGHT"},
+		},
+	}
+	return left, right
+}
+
+func TestVerifyMerge(t *testing.T) {
+	for _, side := range []MergeSide{LeftSide, RightSide} {
+		left, right := verifyTestDatabases()
+		origLeft, origRight := model.MakeDatabaseCopy(left), model.MakeDatabaseCopy(right)
+		merged, solutions := mergeChoosingSide(t, left, right, side)
+		assert.NoError(t, VerifyMerge(origLeft, origRight, merged, solutions...), side)
+
+		// Without the solutions, discarded entries are missing
+		err := VerifyMerge(origLeft, origRight, merged)
+		assert.True(t, errors.Is(err, ErrVerificationFailed))
+		var vErr VerificationError
+		assert.True(t, errors.As(err, &vErr))
+		assert.Len(t, vErr.Problems, 1)
+		assert.Contains(t, vErr.Problems[0], "UserMark 1 of the")
+	}
+}
+
+func TestVerifyMerge_Failures(t *testing.T) {
+	left, right := verifyTestDatabases()
+	origLeft, origRight := model.MakeDatabaseCopy(left), model.MakeDatabaseCopy(right)
+	merged, solutions := mergeChoosingSide(t, left, right, LeftSide)
+
+	// Missing entry
+	broken := model.MakeDatabaseCopy(merged)
+	for i, note := range broken.Note {
+		if note != nil && note.GUID == "C" {
+			broken.Note[i] = nil
+		}
+	}
+	err := VerifyMerge(origLeft, origRight, broken, solutions...)
+	var vErr VerificationError
+	assert.True(t, errors.As(err, &vErr))
+	assert.Len(t, vErr.Problems, 3)
+	assert.Regexp(t, "^TagMap [0-9]: refers to missing Note 3$", vErr.Problems[0])
+	assert.Equal(t, "Note 2 of the right side is missing", vErr.Problems[1])
+	assert.Equal(t, "TagMap 1 of the right side is missing", vErr.Problems[2])
+
+	// Dangling reference
+	broken = model.MakeDatabaseCopy(merged)
+	broken.TagMap[2].TagID = 99
+	err = VerifyMerge(origLeft, origRight, broken, solutions...)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TagMap 2: refers to missing Tag 99")
+
+	// Solution not applied
+	broken = model.MakeDatabaseCopy(merged)
+	broken.Bookmark[1].Title = "Right"
+	err = VerifyMerge(origLeft, origRight, broken, solutions...)
+	assert.EqualError(t, err, "Merged database failed verification: Solution Bookmark 1 differs from the merged entry")
+
+	assert.NoError(t, VerifyMerge(&model.Database{}, &model.Database{}, &model.Database{}))
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"github.com/pkg/errors"
+)
+
+// MigrateBibleEdition moves notes, highlights, bookmarks, and tags from one
+// Bible edition to another one, like from the standard edition (nwt) to the
+// Study Edition (nwtsty). editions maps the KeySymbol of the old edition to
+// the one of the new edition. As the chapters and verses of both editions
+// align, only Bible chapters and the Bibles themselves are migrated, but not
+// documents (like the study notes). If a Location already exists in the new
+// edition, all entries are moved to it and the old Location is removed (set
+// to nil). It returns the number of migrated Locations.
+func (db *Database) MigrateBibleEdition(editions map[string]string) (int, error) {
+	if len(editions) == 0 {
+		return 0, errors.New("No editions to migrate")
+	}
+	for from, to := range editions {
+		if from == "" || to == "" {
+			return 0, errors.Errorf("Can't migrate from edition %q to %q", from, to)
+		}
+		if from == to {
+			return 0, errors.Errorf("Can't migrate %s to itself", from)
+		}
+	}
+
+	return db.remapLocations(func(location *Location) bool {
+		if _, ok := editions[location.KeySymbol.String]; !ok || !location.KeySymbol.Valid {
+			return false
+		}
+		return location.BookNumber.Valid || !location.DocumentID.Valid
+	}, func(location *Location) {
+		location.KeySymbol.String = editions[location.KeySymbol.String]
+	}), nil
+}
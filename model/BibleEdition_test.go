@@ -0,0 +1,83 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_MigrateBibleEdition(t *testing.T) {
+	db := &Database{
+		Bookmark: []*Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 3, Slot: 0},
+		},
+		Location: []*Location{
+			nil,
+			{
+				LocationID:    1,
+				BookNumber:    sql.NullInt32{Int32: 40, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 5, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwt", Valid: true},
+			},
+			{
+				LocationID:    2,
+				BookNumber:    sql.NullInt32{Int32: 40, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 6, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwt", Valid: true},
+				MepsLanguage:  2,
+			},
+			{
+				LocationID:   3,
+				KeySymbol:    sql.NullString{String: "nwt", Valid: true},
+				LocationType: 1,
+			},
+			{
+				LocationID:    4,
+				BookNumber:    sql.NullInt32{Int32: 40, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 5, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			},
+			{
+				LocationID: 5,
+				DocumentID: sql.NullInt32{Int32: 1001070000, Valid: true},
+				KeySymbol:  sql.NullString{String: "nwt", Valid: true},
+			},
+		},
+		Note: []*Note{
+			nil,
+			{NoteID: 1, LocationID: sql.NullInt32{Int32: 1, Valid: true}, BlockType: 2, BlockIdentifier: sql.NullInt32{Int32: 3, Valid: true}},
+		},
+		UserMark: []*UserMark{
+			nil,
+			{UserMarkID: 1, LocationID: 2},
+		},
+	}
+
+	count, err := db.MigrateBibleEdition(map[string]string{"nwt": "nwtsty"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Nil(t, db.Location[1])
+	assert.Equal(t, "nwtsty", db.Location[2].KeySymbol.String)
+	assert.Equal(t, 2, db.Location[2].MepsLanguage)
+	assert.Equal(t, "nwtsty", db.Location[3].KeySymbol.String)
+	assert.Equal(t, "nwt", db.Location[5].KeySymbol.String)
+	assert.Equal(t, 4, db.Bookmark[1].LocationID)
+	assert.Equal(t, 3, db.Bookmark[1].PublicationLocationID)
+	assert.Equal(t, int32(4), db.Note[1].LocationID.Int32)
+	assert.Equal(t, 2, db.UserMark[1].LocationID)
+
+	count, err = db.MigrateBibleEdition(map[string]string{"nwt": "nwtsty"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	_, err = db.MigrateBibleEdition(nil)
+	assert.EqualError(t, err, "No editions to migrate")
+
+	_, err = db.MigrateBibleEdition(map[string]string{"nwt": "nwt"})
+	assert.EqualError(t, err, "Can't migrate nwt to itself")
+
+	_, err = db.MigrateBibleEdition(map[string]string{"nwt": ""})
+	assert.EqualError(t, err, `Can't migrate from edition "nwt" to ""`)
+}
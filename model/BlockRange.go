@@ -65,8 +65,11 @@ func (m *BlockRange) RelatedEntries(db *Database) Related {
 // PrettyPrint prints BlockRange in a human readable format and
 // adds information about related entries if helpful.
 func (m *BlockRange) PrettyPrint(db *Database) string {
-	fields := []string{"Identifier", "StartToken", "EndToken"}
-	return prettyPrint(m, fields)
+	return newPrettyPrinter().
+		addInt("Identifier", m.Identifier).
+		addNullInt32("StartToken", m.StartToken).
+		addNullInt32("EndToken", m.EndToken).
+		String()
 }
 
 // MarshalJSON returns the JSON encoding of the entry
@@ -98,8 +101,12 @@ func (m *BlockRange) idName() string {
 	return "BlockRangeId"
 }
 
-func (m *BlockRange) scanRow(rows *sql.Rows) (Model, error) {
-	err := rows.Scan(&m.BlockRangeID, &m.BlockType, &m.Identifier, &m.StartToken, &m.EndToken, &m.UserMarkID)
+func (m *BlockRange) columns() []string {
+	return []string{"BlockRangeId", "BlockType", "Identifier", "StartToken", "EndToken", "UserMarkId"}
+}
+
+func (m *BlockRange) scanRow(rows *sql.Rows, mapping []int) (Model, error) {
+	err := scanColumns(rows, mapping, &m.BlockRangeID, &m.BlockType, &m.Identifier, &m.StartToken, &m.EndToken, &m.UserMarkID)
 	return m, err
 }
 
@@ -73,8 +73,12 @@ func (m *Bookmark) RelatedEntries(db *Database) Related {
 // PrettyPrint prints Bookmark in a human readable format and
 // adds information about related entries if helpful.
 func (m *Bookmark) PrettyPrint(db *Database) string {
-	fields := []string{"Title", "Snippet", "Slot", "PublicationLocationID"}
-	result := prettyPrint(m, fields)
+	result := newPrettyPrinter().
+		addString("Title", m.Title).
+		addNullString("Snippet", m.Snippet).
+		addInt("Slot", m.Slot).
+		addInt("PublicationLocationID", m.PublicationLocationID).
+		String()
 
 	if location := db.FetchFromTable("Location", m.LocationID); location != nil {
 		result += "\n\n\nRelated Location:\n"
@@ -117,8 +121,13 @@ func (m *Bookmark) idName() string {
 	return "BookmarkId"
 }
 
-func (m *Bookmark) scanRow(rows *sql.Rows) (Model, error) {
-	err := rows.Scan(&m.BookmarkID, &m.LocationID, &m.PublicationLocationID, &m.Slot, &m.Title,
+func (m *Bookmark) columns() []string {
+	return []string{"BookmarkId", "LocationId", "PublicationLocationId", "Slot", "Title",
+		"Snippet", "BlockType", "BlockIdentifier"}
+}
+
+func (m *Bookmark) scanRow(rows *sql.Rows, mapping []int) (Model, error) {
+	err := scanColumns(rows, mapping, &m.BookmarkID, &m.LocationID, &m.PublicationLocationID, &m.Slot, &m.Title,
 		&m.Snippet, &m.BlockType, &m.BlockIdentifier)
 	return m, err
 }
@@ -2,25 +2,31 @@ package model
 
 import (
 	"archive/zip"
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
-	"time"
+	"strings"
+	"sync"
 
+	"github.com/AndreasSko/go-jwlm/sqlite"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/pkg/errors"
 	"github.com/sergi/go-diff/diffmatchpatch"
-
-	// Register SQLite driver
-	_ "github.com/mattn/go-sqlite3"
 )
 
 const manifestFilename = "manifest.json"
 
+// maxSQLiteVariables is the maximum number of host parameters
+// in a single SQLite statement (SQLITE_MAX_VARIABLE_NUMBER)
+const maxSQLiteVariables = 999
+
 // Database represents the JW Library database as a struct
 type Database struct {
 	BlockRange []*BlockRange
@@ -30,6 +36,21 @@ type Database struct {
 	Tag        []*Tag
 	TagMap     []*TagMap
 	UserMark   []*UserMark
+
+	// logger and deviceName must stay unexported, so they are
+	// skipped when iterating over the tables of the Database
+	logger     Logger
+	deviceName string
+}
+
+// DeviceName returns the name of the device the imported backup
+// has been created on, as stated in its manifest. It is empty if
+// the Database has not been imported from a backup.
+func (db *Database) DeviceName() string {
+	if db == nil {
+		return ""
+	}
+	return db.deviceName
 }
 
 // FetchFromTable tries to fetch a entry with the given ID. If it can't find it
@@ -57,7 +78,7 @@ func (db *Database) FetchFromTable(tableName string, id int) Model {
 // MakeDatabaseCopy creates a deep copy of the given Database, so elements of
 // the copy can be safely updated without affecting the original one.
 func MakeDatabaseCopy(db *Database) *Database {
-	newDB := &Database{}
+	newDB := &Database{logger: db.logger, deviceName: db.deviceName}
 
 	dbFields := reflect.ValueOf(db).Elem()
 	for i := 0; i < dbFields.NumField(); i++ {
@@ -97,35 +118,22 @@ func MakeDatabaseCopy(db *Database) *Database {
 
 // Equals checks if all entries of a Database are equal.
 func (db *Database) Equals(other *Database) bool {
+	if diff := db.difference(other); diff != "" {
+		db.Logger().Infof("%s", diff)
+		return false
+	}
+	return true
+}
+
+// difference describes the first difference between the Databases
+// after normalizing them, or returns an empty string if they are equal.
+func (db *Database) difference(other *Database) string {
 	// Make copy of DBs so we can safely transform them if necessary
 	dbCp := MakeDatabaseCopy(db)
 	otherCp := MakeDatabaseCopy(other)
 
-	// Sort all tables by UniqueKey and update IDs in other tables
-	for _, db := range []*Database{dbCp, otherCp} {
-		locIDChanges := sortByUniqueKey(&db.Location)
-		UpdateIDs(db.Bookmark, "LocationID", locIDChanges)
-		UpdateIDs(db.Bookmark, "PublicationLocationID", locIDChanges)
-		UpdateIDs(db.Note, "LocationID", locIDChanges)
-		UpdateIDs(db.TagMap, "LocationID", locIDChanges)
-		UpdateIDs(db.UserMark, "LocationID", locIDChanges)
-
-		sortByUniqueKey(&db.Bookmark)
-
-		tagIDChanges := sortByUniqueKey(&db.Tag)
-		UpdateIDs(db.TagMap, "TagID", tagIDChanges)
-
-		umIDChanges := sortByUniqueKey(&db.UserMark)
-		UpdateIDs(db.BlockRange, "UserMarkID", umIDChanges)
-		UpdateIDs(db.Note, "UserMarkID", umIDChanges)
-
-		sortByUniqueKey(&db.BlockRange)
-
-		noteIDChanges := sortByUniqueKey(&db.Note)
-		UpdateIDs(db.TagMap, "NoteID", noteIDChanges)
-
-		sortByUniqueKey(&db.TagMap)
-	}
+	normalize(dbCp)
+	normalize(otherCp)
 
 	// Check if all entries are equal.
 	dbFields := reflect.ValueOf(dbCp).Elem()
@@ -138,8 +146,7 @@ func (db *Database) Equals(other *Database) bool {
 		}
 
 		if dbSlice.Len() != otherSlice.Len() {
-			fmt.Printf("Length of slices at index %d are not equal: %d vs %d\n", i, dbSlice.Len(), otherSlice.Len())
-			return false
+			return fmt.Sprintf("Length of slices at index %d are not equal: %d vs %d", i, dbSlice.Len(), otherSlice.Len())
 		}
 
 		for j := 0; j < dbSlice.Len(); j++ {
@@ -149,31 +156,115 @@ func (db *Database) Equals(other *Database) bool {
 			if dElem.IsNil() {
 				if oElem.IsNil() {
 					continue
-				} else {
-					return false
 				}
+				return fmt.Sprintf("Entry %d at index %d only exists on one side", j, i)
 			}
 
 			if !dElem.MethodByName("Equals").Call([]reflect.Value{oElem})[0].Bool() {
-				fmt.Println("Found different entries: ")
 				left := spew.Sdump(dElem.Interface())
 				right := spew.Sdump(oElem.Interface())
-				fmt.Printf("%s \nvs\n %s", left, right)
 				dmp := diffmatchpatch.New()
 				diffs := dmp.DiffMain(left, right, true)
-				fmt.Println("Diff:")
-				fmt.Println(dmp.DiffPrettyText(diffs))
-				return false
+				return fmt.Sprintf("Found different entries: \n%s \nvs\n %s\nDiff:\n%s", left, right, dmp.DiffPrettyText(diffs))
 			}
 		}
 	}
 
-	return true
+	return ""
+}
+
+// normalize sorts all tables of the Database by UniqueKey and updates
+// the IDs in other tables accordingly, so the IDs of two Databases with
+// the same content are equal.
+func normalize(db *Database) {
+	locIDChanges := sortByUniqueKey(&db.Location)
+	UpdateIDs(db.Bookmark, "LocationID", locIDChanges)
+	UpdateIDs(db.Bookmark, "PublicationLocationID", locIDChanges)
+	UpdateIDs(db.Note, "LocationID", locIDChanges)
+	UpdateIDs(db.TagMap, "LocationID", locIDChanges)
+	UpdateIDs(db.UserMark, "LocationID", locIDChanges)
+
+	sortByUniqueKey(&db.Bookmark)
+
+	tagIDChanges := sortByUniqueKey(&db.Tag)
+	UpdateIDs(db.TagMap, "TagID", tagIDChanges)
+
+	umIDChanges := sortByUniqueKey(&db.UserMark)
+	UpdateIDs(db.BlockRange, "UserMarkID", umIDChanges)
+	UpdateIDs(db.Note, "UserMarkID", umIDChanges)
+
+	sortByUniqueKey(&db.BlockRange)
+
+	noteIDChanges := sortByUniqueKey(&db.Note)
+	UpdateIDs(db.TagMap, "NoteID", noteIDChanges)
+
+	sortByUniqueKey(&db.TagMap)
+}
+
+// ContentHash returns a SHA256 hash over the content of all entries of the
+// Database. As the IDs are normalized before, Databases with the same
+// entries result in the same hash, even if their IDs or order differ.
+func (db *Database) ContentHash() string {
+	dbCp := MakeDatabaseCopy(db)
+	normalize(dbCp)
+
+	hasher := sha256.New()
+	dbFields := reflect.ValueOf(dbCp).Elem()
+	for i := 0; i < dbFields.NumField(); i++ {
+		table := dbFields.Field(i)
+		if !table.CanInterface() {
+			continue
+		}
+
+		fmt.Fprintf(hasher, "%s\n", dbFields.Type().Field(i).Name)
+		for j := 0; j < table.Len(); j++ {
+			if table.Index(j).IsNil() {
+				continue
+			}
+			// Marshalling our own structs can't fail
+			entry, _ := json.Marshal(table.Index(j).Interface())
+			hasher.Write(entry)
+			hasher.Write([]byte("\n"))
+		}
+	}
+
+	return fmt.Sprintf("%x", hasher.Sum(nil))
 }
 
 // ImportJWLBackup unzips a given JW Library Backup file and imports the
-// included SQLite DB to the Database struct
+// included SQLite DB to the Database struct. The filename can also be an
+// https:// URL, in which case the backup is downloaded first
+// (see DownloadBackup).
 func (db *Database) ImportJWLBackup(filename string) error {
+	return db.ImportJWLBackupWithOptions(filename, ImportOptions{})
+}
+
+// ImportOptions configures how a backup is imported
+type ImportOptions struct {
+	// Context cancels the import, e.g. while a remote backup is
+	// downloaded. If it is nil, context.Background() is used.
+	Context context.Context
+	// Logger is set as Logger of the Database before importing
+	// (see SetLogger). If it is nil, the Logger is left unchanged.
+	Logger Logger
+}
+
+// context returns the Context of the options or context.Background()
+func (opts ImportOptions) context() context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+// ImportJWLBackupWithOptions unzips a given JW Library Backup file
+// and imports the included SQLite DB using the given options.
+func (db *Database) ImportJWLBackupWithOptions(filename string, opts ImportOptions) error {
+	if opts.Logger != nil {
+		db.SetLogger(opts.Logger)
+	}
+	ctx := opts.context()
+
 	// Create tmp folder and place all files there
 	tmp, err := ioutil.TempDir("", "go-jwlm")
 	if err != nil {
@@ -181,62 +272,124 @@ func (db *Database) ImportJWLBackup(filename string) error {
 	}
 	defer os.RemoveAll(tmp)
 
-	r, err := zip.OpenReader(filename)
+	path, manifest, err := unpackBackup(ctx, filename, tmp)
 	if err != nil {
 		return err
 	}
-	defer r.Close()
 
-	for _, file := range r.File {
-		fileReader, err := file.Open()
-		if err != nil {
-			return err
-		}
-		defer fileReader.Close()
+	// Fill the Database with actual data
+	db.Logger().Debugf("Importing %s created on %s", filename, manifest.CreationDate)
+	db.deviceName = manifest.UserDataBackup.DeviceName
+	return db.importSQLite(ctx, path)
+}
 
-		path := filepath.Join(tmp, file.Name)
-		targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
+// unpackBackup extracts the given backup into tmp and validates its
+// manifest. It returns the path of the extracted SQLite DB together
+// with the manifest. Remote backups are downloaded first.
+func unpackBackup(ctx context.Context, filename string, tmp string) (string, *manifest, error) {
+	if IsRemoteBackup(filename) {
+		dir := filepath.Join(tmp, "download")
+		if err := os.Mkdir(dir, 0755); err != nil {
+			return "", nil, errors.Wrap(err, "Error while creating temporary directory")
+		}
+		var err error
+		filename, err = DownloadBackupContext(ctx, filename, dir)
 		if err != nil {
-			return err
+			return "", nil, err
 		}
-		defer targetFile.Close()
+	}
+
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return "", nil, damagedBackupError(err)
+	}
+	defer r.Close()
+
+	return unpackZip(&r.Reader, tmp)
+}
 
-		if _, err := io.Copy(targetFile, fileReader); err != nil {
-			return errors.Wrap(err, "Error while copying files from backup to temporary folder")
+// unpackZip extracts the files of the given backup into tmp like
+// unpackBackup does, returning the path of the extracted SQLite DB
+// together with the validated manifest.
+func unpackZip(r *zip.Reader, tmp string) (string, *manifest, error) {
+	extracted := map[string]bool{}
+	for _, file := range r.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		// Backups don't contain any folders, so crafted
+		// names can't be used to write outside of tmp.
+		if !isPlainFilename(file.Name) {
+			return "", nil, fmt.Errorf("%w: file %s has an invalid name", ErrBackupDamaged, file.Name)
+		}
+		if err := extractZipFile(file, filepath.Join(tmp, file.Name)); err != nil {
+			return "", nil, err
 		}
+		extracted[file.Name] = true
 	}
 
 	// Import manifest
+	if !extracted[manifestFilename] {
+		return "", nil, fmt.Errorf("%w: %s is missing", ErrBackupDamaged, manifestFilename)
+	}
 	path := filepath.Join(tmp, manifestFilename)
-	manifest := manifest{}
+	manifest := &manifest{}
 	if err := manifest.importManifest(path); err != nil {
-		return errors.Wrap(err, "Error while importing manifest")
+		return "", nil, errors.Wrap(err, "Error while importing manifest")
 	}
 
 	// Make sure that we support this backup version
 	if err := manifest.validateManifest(); err != nil {
-		return err
+		return "", nil, err
 	}
 
-	// Fill the Database with actual data
-	path = filepath.Join(tmp, manifest.UserDataBackup.DatabaseName)
-	return db.importSQLite(path)
+	dbName := manifest.UserDataBackup.DatabaseName
+	if !extracted[dbName] {
+		return "", nil, fmt.Errorf("%w: database %s is missing", ErrBackupDamaged, dbName)
+	}
+	return filepath.Join(tmp, dbName), manifest, nil
+}
+
+// extractZipFile writes the content of the given file of a backup to path
+func extractZipFile(file *zip.File, path string) error {
+	fileReader, err := file.Open()
+	if err != nil {
+		return damagedBackupError(err)
+	}
+	defer fileReader.Close()
+
+	targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(targetFile, fileReader); err != nil {
+		targetFile.Close()
+		return errors.Wrap(damagedBackupError(err), "Error while copying files from backup to temporary folder")
+	}
+	return targetFile.Close()
 }
 
 // importSQLite imports a given SQLite DB into the Database struct
-func (db *Database) importSQLite(filename string) error {
+func (db *Database) importSQLite(ctx context.Context, filename string) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "Error while importing SQLite database")
+	}
+
 	// Open SQLite file as immutable to avoid locks (and therefore speed up import)
-	sqlite, err := sql.Open("sqlite3", filename+"?immutable=1")
+	sqliteDB, err := sqlite.OpenImmutable(filename)
 	if err != nil {
 		return errors.Wrap(err, "Error while opening SQLite database")
 	}
-	defer sqlite.Close()
+	defer sqliteDB.Close()
+
+	stmts := newStmtCache(sqliteDB)
+	defer stmts.close()
 
 	// Make sure these tables are empty as we are not able to merge them yet.
 	// Better to fail, than to risk losing data..
 	emptyTables := []string{"InputField", "PlaylistItem", "PlaylistItemChild", "PlaylistMedia"}
 	for _, table := range emptyTables {
-		count, err := getTableEntryCount(sqlite, table)
+		count, err := getTableEntryCount(stmts, table)
 		if err != nil {
 			return err
 		}
@@ -245,73 +398,93 @@ func (db *Database) importSQLite(filename string) error {
 		}
 	}
 
-	// Fill each table struct separately (did not find a DRYer solution yet..)
-	mdl, err := fetchFromSQLite(sqlite, &BlockRange{})
-	if err != nil {
-		return err
-	}
-	db.BlockRange = BlockRange{}.MakeSlice(mdl)
-
-	mdl, err = fetchFromSQLite(sqlite, &Bookmark{})
-	if err != nil {
-		return err
+	// Fill each table struct concurrently. As the SQLite file is opened as
+	// immutable, every table can be read using its own connection.
+	var blockRange, bookmark, location, note, tag, tagMap, userMark []Model
+	tables := []struct {
+		modelType Model
+		result    *[]Model
+	}{
+		{&BlockRange{}, &blockRange},
+		{&Bookmark{}, &bookmark},
+		{&Location{}, &location},
+		{&Note{}, &note},
+		{&Tag{}, &tag},
+		{&TagMap{}, &tagMap},
+		{&UserMark{}, &userMark},
+	}
+	errs := make([]error, len(tables))
+	var wg sync.WaitGroup
+	for i := range tables {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			*tables[i].result, errs[i] = fetchFromSQLite(stmts, tables[i].modelType)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
 	}
-	db.Bookmark = Bookmark{}.MakeSlice(mdl)
 
-	mdl, err = fetchFromSQLite(sqlite, &Location{})
-	if err != nil {
-		return err
-	}
-	db.Location = Location{}.MakeSlice(mdl)
+	db.BlockRange = BlockRange{}.MakeSlice(blockRange)
+	db.Bookmark = Bookmark{}.MakeSlice(bookmark)
+	db.Location = Location{}.MakeSlice(location)
+	db.Note = Note{}.MakeSlice(note)
+	db.Tag = Tag{}.MakeSlice(tag)
+	db.TagMap = TagMap{}.MakeSlice(tagMap)
+	db.UserMark = UserMark{}.MakeSlice(userMark)
 
-	mdl, err = fetchFromSQLite(sqlite, &Note{})
-	if err != nil {
-		return err
-	}
-	db.Note = Note{}.MakeSlice(mdl)
+	return nil
+}
 
-	mdl, err = fetchFromSQLite(sqlite, &Tag{})
+// fetchFromSQLite fetches the entries for a given modelType and returns a slice
+// of entries, for which the index corresponds to the ID in the SQLite DB
+func fetchFromSQLite(stmts *stmtCache, modelType Model) ([]Model, error) {
+	// Create slice of correct size (number of entries)
+	capacity, err := getSliceCapacity(stmts, modelType)
 	if err != nil {
-		return err
+		return nil, errors.Wrap(err, "Could not determine number of entries in SQLite database")
 	}
-	db.Tag = Tag{}.MakeSlice(mdl)
+	result := make([]Model, capacity)
 
-	mdl, err = fetchFromSQLite(sqlite, &TagMap{})
+	// Put entries in slice with the index coresponding to the ID in the SQLite DB
+	err = forEachRow(stmts, modelType, func(m Model) error {
+		if m.ID() < 0 || m.ID() >= len(result) {
+			return fmt.Errorf("%w: invalid ID %d in table %s", ErrBackupDamaged, m.ID(), modelType.tableName())
+		}
+		result[m.ID()] = m
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	db.TagMap = TagMap{}.MakeSlice(mdl)
 
-	mdl, err = fetchFromSQLite(sqlite, &UserMark{})
-	if err != nil {
-		return err
-	}
+	return result, nil
+}
 
-	db.UserMark = UserMark{}.MakeSlice(mdl)
+// forEachRow scans the entries of the table of the given modelType one by
+// one, ordered by their ID, and calls fn for each of them. If fn returns an
+// error, the iteration is stopped and the error is returned.
+func forEachRow(stmts *stmtCache, modelType Model, fn func(Model) error) error {
+	rows, err := stmts.query(fmt.Sprintf("SELECT * FROM %s ORDER BY %s", modelType.tableName(), modelType.idName()))
 	if err != nil {
-		return err
+		return errors.Wrap(err, "Error while querying SQLite database")
 	}
+	defer rows.Close()
 
-	return nil
-}
-
-// fetchFromSQLite fetches the entries for a given modelType and returns a slice
-// of entries, for which the index corresponds to the ID in the SQLite DB
-func fetchFromSQLite(sqlite *sql.DB, modelType Model) ([]Model, error) {
-	// Create slice of correct size (number of entries)
-	capacity, err := getSliceCapacity(sqlite, modelType)
+	// Scan by the name of the columns, so we don't depend on their order
+	columns, err := rows.Columns()
 	if err != nil {
-		return nil, errors.Wrap(err, "Could not determine number of entries in SQLite database")
+		return errors.Wrap(err, "Error while fetching columns from SQLite database")
 	}
-	result := make([]Model, capacity)
-
-	rows, err := sqlite.Query(fmt.Sprintf("SELECT * FROM %s ORDER BY %s", modelType.tableName(), modelType.idName()))
+	mapping, err := columnMapping(columns, modelType.columns(), modelType.idName())
 	if err != nil {
-		return nil, errors.Wrap(err, "Error while querying SQLite database")
+		return errors.Wrapf(err, "Could not scan table %s", modelType.tableName())
 	}
 
-	// Put entries in slice with the index coresponding to the ID in the SQLite DB
-	defer rows.Close()
 	for rows.Next() {
 		var m Model
 		switch tp := modelType.(type) {
@@ -332,24 +505,68 @@ func fetchFromSQLite(sqlite *sql.DB, modelType Model) ([]Model, error) {
 		default:
 			panic(fmt.Sprintf("Fetching %T is not supported!", tp))
 		}
-		mn, err := m.scanRow(rows)
+		mn, err := m.scanRow(rows, mapping)
 		if err != nil {
-			return nil, errors.Wrap(err, "Error while scanning results from SQLite database")
+			return errors.Wrap(err, "Error while scanning results from SQLite database")
+		}
+		if err := fn(mn); err != nil {
+			return err
 		}
-		result[mn.ID()] = mn
 	}
 	err = rows.Err()
 	if err != nil {
-		return nil, errors.Wrap(err, "Error while scanning results from SQLite database")
+		return errors.Wrap(err, "Error while scanning results from SQLite database")
 	}
 
-	return result, nil
+	return nil
+}
+
+// columnMapping maps the index of each column of a result to the index of
+// the column in names (or -1 if the column is unknown). As SQLite, it
+// compares the names case-insensitively. It fails if the required
+// column is not part of the result.
+func columnMapping(columns []string, names []string, required string) ([]int, error) {
+	mapping := make([]int, len(columns))
+	found := false
+	for i, column := range columns {
+		mapping[i] = -1
+		for j, name := range names {
+			if strings.EqualFold(column, name) {
+				mapping[i] = j
+				break
+			}
+		}
+		if strings.EqualFold(column, required) {
+			found = true
+		}
+	}
+	if !found {
+		return nil, errors.Errorf("Column %s is missing", required)
+	}
+
+	return mapping, nil
+}
+
+// scanColumns scans the current row into fields using the
+// mapping created by columnMapping. Values of unknown
+// columns are discarded.
+func scanColumns(rows *sql.Rows, mapping []int, fields ...interface{}) error {
+	dest := make([]interface{}, len(mapping))
+	for i, field := range mapping {
+		if field < 0 {
+			dest[i] = new(interface{})
+			continue
+		}
+		dest[i] = fields[field]
+	}
+
+	return rows.Scan(dest...)
 }
 
 // getTableEntryCount returns the number of entries in a given table
-func getTableEntryCount(sqlite *sql.DB, tableName string) (int, error) {
+func getTableEntryCount(stmts *stmtCache, tableName string) (int, error) {
 	var count int
-	err := sqlite.QueryRow(fmt.Sprintf("SELECT Count(*) FROM %s", tableName)).Scan(&count)
+	err := stmts.queryRow(fmt.Sprintf("SELECT Count(*) FROM %s", tableName), &count)
 	if err != nil {
 		return 0, errors.Wrapf(err, "Error while determing entry count of table %s", tableName)
 	}
@@ -357,29 +574,128 @@ func getTableEntryCount(sqlite *sql.DB, tableName string) (int, error) {
 	return count, nil
 }
 
+// maxEntryID is the highest ID of an entry go-jwlm imports. Even the
+// biggest libraries stay far below, while it keeps the slices of
+// damaged databases from using up all memory.
+const maxEntryID = 1 << 24
+
 // getSliceCapacity determines the needed capacity for a slice from a table
 // by looking at the highest ID in the DB
-func getSliceCapacity(sqlite *sql.DB, modelType Model) (int, error) {
-	row, err := sqlite.Query(fmt.Sprintf("SELECT %s FROM %s ORDER BY %s DESC LIMIT 1",
-		modelType.idName(), modelType.tableName(), modelType.idName()))
-	if err != nil {
+func getSliceCapacity(stmts *stmtCache, modelType Model) (int, error) {
+	capacity := 0
+	err := stmts.queryRow(fmt.Sprintf("SELECT %s FROM %s ORDER BY %s DESC LIMIT 1",
+		modelType.idName(), modelType.tableName(), modelType.idName()), &capacity)
+	if err != nil && err != sql.ErrNoRows {
 		return 0, err
 	}
-	defer row.Close()
-
-	capacity := 0
-	for row.Next() {
-		if err := row.Scan(&capacity); err != nil {
-			return 0, err
-		}
+	// Don't try to allocate slices for IDs of damaged databases
+	if capacity < 0 || capacity > maxEntryID {
+		return 0, fmt.Errorf("%w: invalid ID %d in table %s", ErrBackupDamaged, capacity, modelType.tableName())
 	}
 
 	// Index in DB starts with 1, so 0 is always nil
 	return capacity + 1, nil
 }
 
+// stmtCache prepares each query only once and reuses the
+// prepared statement for subsequent executions. It is safe
+// for concurrent use.
+type stmtCache struct {
+	sqlite *sql.DB
+	mu     sync.Mutex
+	stmts  map[string]*sql.Stmt
+}
+
+// newStmtCache creates a stmtCache for the given database
+func newStmtCache(sqlite *sql.DB) *stmtCache {
+	return &stmtCache{
+		sqlite: sqlite,
+		stmts:  map[string]*sql.Stmt{},
+	}
+}
+
+// prepare returns the prepared statement for query
+func (c *stmtCache) prepare(query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.sqlite.Prepare(query)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error while preparing query %s", query)
+	}
+	c.stmts[query] = stmt
+
+	return stmt, nil
+}
+
+// query executes the given query using a prepared statement
+func (c *stmtCache) query(query string, args ...interface{}) (*sql.Rows, error) {
+	stmt, err := c.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Query(args...)
+}
+
+// queryRow executes a query that is expected to return at most one row
+// using a prepared statement and scans the result into dest.
+func (c *stmtCache) queryRow(query string, dest ...interface{}) error {
+	stmt, err := c.prepare(query)
+	if err != nil {
+		return err
+	}
+	return stmt.QueryRow().Scan(dest...)
+}
+
+// close closes all prepared statements
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for query, stmt := range c.stmts {
+		stmt.Close()
+		delete(c.stmts, query)
+	}
+}
+
 // ExportJWLBackup creates a .jwlibrary backup file out of a Database{} struct
 func (db *Database) ExportJWLBackup(filename string) error {
+	return db.ExportJWLBackupWithHistory(filename, nil)
+}
+
+// ExportJWLBackupWithHistory creates a .jwlibrary backup file out of a
+// Database{} struct and includes the given MergeHistory, so it can later
+// be determined from which backups it has been created. If history is
+// nil, it is omitted.
+func (db *Database) ExportJWLBackupWithHistory(filename string, history *MergeHistory) error {
+	return db.ExportJWLBackupWithOptions(filename, ExportOptions{History: history})
+}
+
+// ExportOptions configures how a backup is exported
+type ExportOptions struct {
+	// History is included in the backup if it is not nil
+	History *MergeHistory
+	// CompressionLevel of the zip archive, ranging from BestSpeed (1) to
+	// BestCompression (9). Use StoreOnly (-1) to disable compression
+	// or DefaultCompression (0) to compress like JW Library does.
+	CompressionLevel int
+	// Thumbnail is the PNG image JW Library shows for the backup. If it
+	// is nil, a simple one is generated (see GenerateThumbnail).
+	Thumbnail []byte
+	// Verify re-imports the exported backup and returns an error
+	// if it does not equal the Database (see VerifyExport)
+	Verify bool
+	// Media files, like pictures and audio files of playlists, that
+	// are stored in the backup next to the database (see ReadMedia)
+	Media map[string][]byte
+}
+
+// ExportJWLBackupWithOptions creates a .jwlibrary backup
+// file out of a Database{} struct using the given options.
+func (db *Database) ExportJWLBackupWithOptions(filename string, opts ExportOptions) error {
 	// Create tmp folder and place all files there
 	tmp, err := ioutil.TempDir("", "go-jwlm")
 	if err != nil {
@@ -387,27 +703,84 @@ func (db *Database) ExportJWLBackup(filename string) error {
 	}
 	defer os.RemoveAll(tmp)
 
+	files, err := db.writeBackupFiles(tmp, opts)
+	if err != nil {
+		return err
+	}
+	if err := zipFiles(filename, files, opts.CompressionLevel); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error while storing files in zip archive %s", filename))
+	}
+	db.Logger().Debugf("Exported backup to %s", filename)
+
+	if opts.Verify {
+		return db.VerifyExport(filename)
+	}
+
+	return nil
+}
+
+// writeBackupFiles creates the files of a .jwlibrary backup in tmp
+// and returns their paths in the order they are stored in the backup
+func (db *Database) writeBackupFiles(tmp string, opts ExportOptions) ([]string, error) {
 	// Create user_data.db
 	dbPath := filepath.Join(tmp, "user_data.db")
 	if err := db.saveToNewSQLite(dbPath); err != nil {
-		return errors.Wrap(err, "Could not create SQLite database for exporting")
+		return nil, errors.Wrap(err, "Could not create SQLite database for exporting")
 	}
 
 	// Create manifest.json
 	manifestPath := filepath.Join(tmp, manifestFilename)
 	mfst, err := generateManifest("go-jwlm", dbPath)
 	if err != nil {
-		return errors.Wrap(err, "Error while generating manifest")
+		return nil, errors.Wrap(err, "Error while generating manifest")
 	}
 	if err := mfst.exportManifest(manifestPath); err != nil {
-		return errors.Wrap(err, "Error while creating manifest.json")
+		return nil, errors.Wrap(err, "Error while creating manifest.json")
 	}
 
-	// Store files in .jwlibrary (zip)-file
-	files := []string{dbPath, manifestPath}
-	if err := zipFiles(filename, files); err != nil {
-		return errors.Wrap(err, fmt.Sprintf("Error while storing files in zip archive %s", filename))
+	// Create default_thumbnail.png
+	thumbnail := opts.Thumbnail
+	if thumbnail == nil {
+		thumbnail, err = GenerateThumbnail()
+		if err != nil {
+			return nil, err
+		}
+	}
+	thumbnailPath := filepath.Join(tmp, thumbnailFilename)
+	if err := ioutil.WriteFile(thumbnailPath, thumbnail, 0644); err != nil {
+		return nil, errors.Wrap(err, "Error while creating thumbnail")
+	}
+
+	// Collect the files of the .jwlibrary (zip)-file
+	files := []string{dbPath, manifestPath, thumbnailPath}
+	if opts.History != nil {
+		historyPath := filepath.Join(tmp, mergeHistoryFilename)
+		if err := opts.History.exportMergeHistory(historyPath); err != nil {
+			return nil, err
+		}
+		files = append(files, historyPath)
 	}
+	mediaPaths, err := writeMedia(tmp, opts.Media)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, mediaPaths...), nil
+}
+
+// VerifyExport imports the given backup and checks if it equals the
+// Database, so it can be used to make sure that exporting did not lose
+// or change any entry. If they differ, an error wrapping
+// ErrExportMismatch and describing the difference is returned.
+func (db *Database) VerifyExport(filename string) error {
+	exported := &Database{}
+	if err := exported.ImportJWLBackup(filename); err != nil {
+		return errors.Wrap(err, "Error while importing exported backup for verification")
+	}
+
+	if diff := db.difference(exported); diff != "" {
+		return fmt.Errorf("%w: %s differs from the database: %s", ErrExportMismatch, filename, diff)
+	}
+	db.Logger().Debugf("Verified exported backup %s", filename)
 
 	return nil
 }
@@ -419,35 +792,41 @@ func (db *Database) saveToNewSQLite(filename string) error {
 		return errors.Wrap(err, "Error while creating new empty SQLite database")
 	}
 
-	sqlite, err := sql.Open("sqlite3", filename)
+	// The database is only a temporary file until it is zipped, so
+	// we can trade durability for speed by not syncing to disk and
+	// keeping the rollback journal in memory.
+	sqliteDB, err := sqlite.OpenUnsynced(filename)
 	if err != nil {
 		return errors.Wrap(err, "Error while opening SQLite database")
 	}
-	defer sqlite.Close()
+	defer sqliteDB.Close()
 
 	// For every field of the Database{} struct, create a []model slice
 	// and use it to insert its entries to the new SQLite DB
 	dbFields := reflect.ValueOf(db).Elem()
 	for j := 0; j < dbFields.NumField(); j++ {
+		if !dbFields.Field(j).CanInterface() {
+			continue
+		}
 		slice := dbFields.Field(j).Interface()
 		mdl, err := MakeModelSlice(slice)
 		if err != nil {
 			return err
 		}
-		if err := insertEntries(sqlite, mdl); err != nil {
+		if err := insertEntries(sqliteDB, mdl); err != nil {
 			return errors.Wrapf(err, "Error while inserting entries of field %d", j)
 		}
 	}
 
 	// Update LastModified
-	lastModified := time.Now().Format("2006-01-02T15:04:05-07:00")
-	_, err = sqlite.Exec(fmt.Sprintf("UPDATE LastModified SET LastModified = \"%s\" WHERE LastModified = (SELECT * FROM LastModified)", lastModified))
+	lastModified := exportTime().Format("2006-01-02T15:04:05-07:00")
+	_, err = sqliteDB.Exec(fmt.Sprintf("UPDATE LastModified SET LastModified = \"%s\" WHERE LastModified = (SELECT * FROM LastModified)", lastModified))
 	if err != nil {
 		return errors.Wrap(err, "Error while updating LastModified")
 	}
 
 	// Vacuum to clean up SQLite DB
-	_, err = sqlite.Exec("VACUUM")
+	_, err = sqliteDB.Exec("VACUUM")
 	if err != nil {
 		return errors.Wrap(err, "Error while vacuuming SQLite DB")
 	}
@@ -486,26 +865,21 @@ func insertEntries(sqlite *sql.DB, m []Model) error {
 	if err != nil {
 		return err
 	}
+	// Rollback is a no-op if the transaction has already been committed
+	defer tx.Rollback()
 
-	// Dynamically add all column-names of the struct to the query
-	query := fmt.Sprintf("INSERT INTO %s VALUES (", tableName)
-	for i := 0; i < rowCount; i++ {
-		query += "?"
-		if i+1 < rowCount {
-			query += ", "
-		}
-	}
-	query += ")"
-
-	stmt, err := tx.Prepare(query)
+	// Insert multiple rows per statement to reduce the overhead
+	// of executing a statement for every single entry
+	batchSize := maxSQLiteVariables / rowCount
+	batchStmt, err := prepareInsert(tx, tableName, rowCount, batchSize)
 	if err != nil {
-		return errors.Wrapf(err, "Error while preparing query %s", query)
+		return err
 	}
-	defer stmt.Close()
+	defer batchStmt.Close()
 
+	values := make([]interface{}, 0, batchSize*rowCount)
+	rowsInBatch := 0
 	for _, entry := range m {
-		// Prepare struct for ingestion with stmt.Exec
-		values := make([]interface{}, rowCount)
 		reflValues := reflect.ValueOf(entry).Elem()
 
 		// Check if entry is actually a nil-pointer and shouldn't be considered
@@ -515,12 +889,29 @@ func insertEntries(sqlite *sql.DB, m []Model) error {
 
 		// Add all fields of the struct to the values slice, so we can ingest them later
 		for j := 0; j < reflValues.NumField(); j++ {
-			v := reflValues.Field(j).Interface()
-			values[j] = v
+			values = append(values, reflValues.Field(j).Interface())
 		}
+		rowsInBatch++
+
+		if rowsInBatch == batchSize {
+			if _, err := batchStmt.Exec(values...); err != nil {
+				return errors.Wrapf(err, "Could not insert entries into %s", tableName)
+			}
+			values = values[:0]
+			rowsInBatch = 0
+		}
+	}
+
+	// Insert remaining entries that didn't fill a whole batch
+	if rowsInBatch > 0 {
+		stmt, err := prepareInsert(tx, tableName, rowCount, rowsInBatch)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
 
 		if _, err := stmt.Exec(values...); err != nil {
-			return errors.Wrap(err, fmt.Sprintf("Could not insert entry %v", entry))
+			return errors.Wrapf(err, "Could not insert entries into %s", tableName)
 		}
 	}
 
@@ -531,6 +922,20 @@ func insertEntries(sqlite *sql.DB, m []Model) error {
 	return nil
 }
 
+// prepareInsert prepares a statement that INSERTs rows entries
+// with rowCount columns each into the given table.
+func prepareInsert(tx *sql.Tx, tableName string, rowCount int, rows int) (*sql.Stmt, error) {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", rowCount), ", ") + ")"
+	query := fmt.Sprintf("INSERT INTO %s VALUES %s", tableName, strings.TrimSuffix(strings.Repeat(row+", ", rows), ", "))
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error while preparing insert for table %s", tableName)
+	}
+
+	return stmt, nil
+}
+
 // createEmptySQLiteDB creates a new SQLite database at filename with the base user_data.db from JWLibrary
 func createEmptySQLiteDB(filename string) error {
 	userData, err := Asset("user_data.db")
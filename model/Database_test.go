@@ -1,6 +1,9 @@
 package model
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"fmt"
@@ -11,9 +14,10 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/AndreasSko/go-jwlm/sqlite"
 	"github.com/DATA-DOG/go-sqlmock"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -56,7 +60,7 @@ func TestMakeDatabaseCopy(t *testing.T) {
 	db := &Database{}
 
 	path := filepath.Join("testdata", "user_data.db")
-	assert.NoError(t, db.importSQLite(path))
+	assert.NoError(t, db.importSQLite(context.Background(), path))
 
 	dbCp := MakeDatabaseCopy(db)
 	assertEqualNotDeepSame(t, db.BlockRange, dbCp.BlockRange)
@@ -90,18 +94,19 @@ func Test_getTableEntryCount(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
+	stmts := newStmtCache(db)
 
 	rows := mock.NewRows([]string{"Count"}).AddRow(123)
-	mock.ExpectQuery("SELECT Count\\(\\*\\) FROM PlaylistItem").WillReturnRows(rows)
+	mock.ExpectPrepare("SELECT Count\\(\\*\\) FROM PlaylistItem").ExpectQuery().WillReturnRows(rows)
 
-	res, err := getTableEntryCount(db, "PlaylistItem")
+	res, err := getTableEntryCount(stmts, "PlaylistItem")
 	assert.NoError(t, err)
 	assert.Equal(t, 123, res)
 
 	rows = mock.NewRows([]string{"Count"}).AddRow(0)
-	mock.ExpectQuery("SELECT Count\\(\\*\\) FROM InputField").WillReturnRows(rows)
+	mock.ExpectPrepare("SELECT Count\\(\\*\\) FROM InputField").ExpectQuery().WillReturnRows(rows)
 
-	res, err = getTableEntryCount(db, "InputField")
+	res, err = getTableEntryCount(stmts, "InputField")
 	assert.NoError(t, err)
 	assert.Equal(t, 0, res)
 }
@@ -110,71 +115,143 @@ func Test_getSliceCapacity(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
+	stmts := newStmtCache(db)
 
 	rows := mock.NewRows([]string{"TagId"}).AddRow(3)
-	mock.ExpectQuery("SELECT TagId FROM Tag ORDER BY TagId DESC LIMIT 1").WillReturnRows(rows)
+	prep := mock.ExpectPrepare("SELECT TagId FROM Tag ORDER BY TagId DESC LIMIT 1")
+	prep.ExpectQuery().WillReturnRows(rows)
 
-	res, err := getSliceCapacity(db, &Tag{})
+	res, err := getSliceCapacity(stmts, &Tag{})
 	assert.NoError(t, err)
 	assert.Equal(t, 4, res)
 
-	// Test with empty DB
+	// Test with empty DB (reusing the prepared statement)
 	rows = mock.NewRows([]string{"TagId"})
-	mock.ExpectQuery("SELECT TagId FROM Tag ORDER BY TagId DESC LIMIT 1").WillReturnRows(rows)
-	res, err = getSliceCapacity(db, &Tag{})
+	prep.ExpectQuery().WillReturnRows(rows)
+	res, err = getSliceCapacity(stmts, &Tag{})
 	assert.NoError(t, err)
 	assert.Equal(t, 1, res)
+
+	// IDs of damaged databases
+	for _, id := range []int{-1, maxEntryID + 1} {
+		rows = mock.NewRows([]string{"TagId"}).AddRow(id)
+		prep.ExpectQuery().WillReturnRows(rows)
+		_, err = getSliceCapacity(stmts, &Tag{})
+		assert.True(t, errors.Is(err, ErrBackupDamaged))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func Test_fetchFromSQLite(t *testing.T) {
 	path := filepath.Join("testdata", "user_data.db")
-	sqlite, err := sql.Open("sqlite3", path+"?immutable=1")
+	sqliteDB, err := sqlite.OpenImmutable(path)
 	if err != nil {
 		t.Fatal(errors.Wrap(err, "Error while opening SQLite database"))
 	}
-	defer sqlite.Close()
+	defer sqliteDB.Close()
+	stmts := newStmtCache(sqliteDB)
+	defer stmts.close()
 
-	blockRange, err := fetchFromSQLite(sqlite, &BlockRange{})
+	blockRange, err := fetchFromSQLite(stmts, &BlockRange{})
 	assert.NoError(t, err)
 	assert.Len(t, blockRange, 5)
 	assert.Equal(t, &BlockRange{3, 2, 13, sql.NullInt32{Int32: 0, Valid: true}, sql.NullInt32{Int32: 14, Valid: true}, 3}, blockRange[3])
 
-	bookmark, err := fetchFromSQLite(sqlite, &Bookmark{})
+	bookmark, err := fetchFromSQLite(stmts, &Bookmark{})
 	assert.NoError(t, err)
 	assert.Len(t, bookmark, 3)
 	assert.Equal(t, &Bookmark{2, 3, 7, 4, "Philippians 4", sql.NullString{String: "12 I know how to be low on provisions and how to have an abundance. In everything and in all circumstances I have learned the secret of both how to be full and how to hunger, both how to have an abundance and how to do without. ", Valid: true}, 0, sql.NullInt32{}}, bookmark[2])
 
-	location, err := fetchFromSQLite(sqlite, &Location{})
+	location, err := fetchFromSQLite(stmts, &Location{})
 	assert.NoError(t, err)
 	assert.Len(t, location, 8)
 	assert.Equal(t, &Location{4, sql.NullInt32{Int32: 66, Valid: true}, sql.NullInt32{Int32: 21, Valid: true}, sql.NullInt32{}, sql.NullInt32{}, 0, sql.NullString{String: "nwtsty", Valid: true}, 2, 0, sql.NullString{String: "Offenbarung 21", Valid: true}}, location[4])
 
-	note, err := fetchFromSQLite(sqlite, &Note{})
+	note, err := fetchFromSQLite(stmts, &Note{})
 	assert.NoError(t, err)
 	assert.Len(t, note, 3)
 	assert.Equal(t, &Note{2, "F75A18EE-FC17-4E0B-ABB6-CC16DABE9610", sql.NullInt32{Int32: 3, Valid: true}, sql.NullInt32{Int32: 3, Valid: true}, sql.NullString{String: "For all things I have the strength through the one who gives me power.", Valid: true}, sql.NullString{String: "", Valid: true}, "2020-04-14T18:42:14+00:00", 2, sql.NullInt32{Int32: 13, Valid: true}}, note[2])
 
-	tag, err := fetchFromSQLite(sqlite, &Tag{})
+	tag, err := fetchFromSQLite(stmts, &Tag{})
 	assert.NoError(t, err)
 	assert.Len(t, tag, 3)
 	assert.Equal(t, &Tag{2, 1, "Strengthening", sql.NullString{}}, tag[2])
 
-	tagMap, err := fetchFromSQLite(sqlite, &TagMap{})
+	tagMap, err := fetchFromSQLite(stmts, &TagMap{})
 	assert.NoError(t, err)
 	assert.Len(t, tagMap, 3)
 	assert.Equal(t, &TagMap{2, sql.NullInt32{Int32: 0, Valid: false}, sql.NullInt32{Int32: 0, Valid: false}, sql.NullInt32{Int32: 2, Valid: true}, 2, 1}, tagMap[2])
 
-	userMark, err := fetchFromSQLite(sqlite, &UserMark{})
+	userMark, err := fetchFromSQLite(stmts, &UserMark{})
 	assert.NoError(t, err)
 	assert.Len(t, userMark, 5)
 	assert.Equal(t, &UserMark{2, 1, 2, 0, "2C5E7B4A-4997-4EDA-9CFF-38A7599C487B", 1}, userMark[2])
 }
 
+func Test_fetchFromSQLite_columnOrder(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	sqliteDB, err := sqlite.Open(filepath.Join(tmp, "reordered.db"))
+	assert.NoError(t, err)
+	defer sqliteDB.Close()
+	stmts := newStmtCache(sqliteDB)
+	defer stmts.close()
+
+	// Columns in a different order, an unknown column and a missing one
+	_, err = sqliteDB.Exec(`CREATE TABLE Tag (Name TEXT, NewColumn INTEGER, TagId INTEGER, Type INTEGER);
+		INSERT INTO Tag VALUES ("Favorite", 42, 1, 1);
+		CREATE TABLE Note (Guid TEXT, Title TEXT)`)
+	assert.NoError(t, err)
+
+	tag, err := fetchFromSQLite(stmts, &Tag{})
+	assert.NoError(t, err)
+	assert.Equal(t, []Model{nil, &Tag{TagID: 1, TagType: 1, Name: "Favorite"}}, tag)
+
+	_, err = fetchFromSQLite(stmts, &Note{})
+	assert.Error(t, err)
+}
+
+func Test_fetchFromSQLite_invalidIDs(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	sqliteDB, err := sqlite.Open(filepath.Join(tmp, "invalid.db"))
+	assert.NoError(t, err)
+	defer sqliteDB.Close()
+	stmts := newStmtCache(sqliteDB)
+	defer stmts.close()
+
+	_, err = sqliteDB.Exec(`CREATE TABLE Tag (TagId INTEGER, Type INTEGER, Name TEXT);
+		INSERT INTO Tag VALUES (-1, 1, "Negative"), (2, 1, "Favorite");
+		CREATE TABLE Note (NoteId INTEGER, Guid TEXT);
+		INSERT INTO Note VALUES (-1, "Negative");
+		CREATE TABLE Location (LocationId INTEGER, Title TEXT);
+		INSERT INTO Location VALUES (4294967296, "Huge")`)
+	assert.NoError(t, err)
+
+	for _, modelType := range []Model{&Tag{}, &Note{}, &Location{}} {
+		_, err = fetchFromSQLite(stmts, modelType)
+		assert.True(t, errors.Is(err, ErrBackupDamaged), "%T: %v", modelType, err)
+	}
+}
+
+func Test_columnMapping(t *testing.T) {
+	mapping, err := columnMapping([]string{"name", "Extra", "TagId"}, []string{"TagId", "Type", "Name"}, "TagId")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, -1, 0}, mapping)
+
+	_, err = columnMapping([]string{"Name"}, []string{"TagId", "Type", "Name"}, "TagId")
+	assert.EqualError(t, err, "Column TagId is missing")
+}
+
 func TestDatabase_importSQLite(t *testing.T) {
 	db := Database{}
 
 	path := filepath.Join("testdata", "user_data.db")
-	assert.NoError(t, db.importSQLite(path))
+	assert.NoError(t, db.importSQLite(context.Background(), path))
 
 	// As we already test the correctness in Test_fetchFromSQLite,
 	// it should be sufficient to just double-check the size of the slices.
@@ -187,7 +264,7 @@ func TestDatabase_importSQLite(t *testing.T) {
 	assert.Len(t, db.UserMark, 5)
 
 	path = filepath.Join("testdata", "error_playlistMedia.db")
-	assert.EqualError(t, db.importSQLite(path), "Table PlaylistMedia is not empty. Merging of these entries are not supported yet")
+	assert.EqualError(t, db.importSQLite(context.Background(), path), "Table PlaylistMedia is not empty. Merging of these entries are not supported yet")
 }
 
 func TestDatabase_ImportJWLBackup(t *testing.T) {
@@ -207,6 +284,87 @@ func TestDatabase_ImportJWLBackup(t *testing.T) {
 	assert.Len(t, db.UserMark, 5)
 }
 
+func Test_unpackZip(t *testing.T) {
+	mfst, err := ioutil.ReadFile(filepath.Join("testdata", "manifest_correct.json"))
+	assert.NoError(t, err)
+	userDB, err := ioutil.ReadFile(filepath.Join("testdata", "user_data.db"))
+	assert.NoError(t, err)
+	escapingMfst := bytes.Replace(mfst, []byte(`"user_data.db"`), []byte(`"../user_data.db"`), 1)
+
+	tests := []struct {
+		name  string
+		files map[string][]byte
+		err   error
+	}{
+		{"valid", map[string][]byte{"manifest.json": mfst, "user_data.db": userDB}, nil},
+		{"relative path", map[string][]byte{"manifest.json": mfst, "user_data.db": userDB, "../evil": {}}, ErrBackupDamaged},
+		{"absolute path", map[string][]byte{"manifest.json": mfst, "user_data.db": userDB, "/evil": {}}, ErrBackupDamaged},
+		{"missing manifest", map[string][]byte{"user_data.db": userDB}, ErrBackupDamaged},
+		{"missing database", map[string][]byte{"manifest.json": mfst}, ErrBackupDamaged},
+		{"escaping database", map[string][]byte{"manifest.json": escapingMfst, "user_data.db": userDB}, ErrBackupDamaged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp, err := ioutil.TempDir("", "go-jwlm")
+			assert.NoError(t, err)
+			defer os.RemoveAll(tmp)
+			dir := filepath.Join(tmp, "backup")
+			assert.NoError(t, os.Mkdir(dir, 0755))
+
+			var buf bytes.Buffer
+			w := zip.NewWriter(&buf)
+			for name, content := range tt.files {
+				f, err := w.Create(name)
+				assert.NoError(t, err)
+				_, err = f.Write(content)
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, w.Close())
+			r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			assert.NoError(t, err)
+
+			path, _, err := unpackZip(r, dir)
+			assert.NoFileExists(t, filepath.Join(tmp, "evil"))
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.Join(dir, "user_data.db"), path)
+		})
+	}
+}
+
+func TestDatabase_ImportJWLBackupWithOptions(t *testing.T) {
+	path := filepath.Join("testdata", "backup.jwlibrary")
+	expected := &Database{}
+	assert.NoError(t, expected.ImportJWLBackup(path))
+
+	logger := &testLogger{}
+	db := &Database{}
+	assert.NoError(t, db.ImportJWLBackupWithOptions(path, ImportOptions{Logger: logger}))
+	assert.True(t, expected.Equals(db))
+	assert.Equal(t, logger, db.Logger())
+	assert.Contains(t, logger.messages[0], "debug: Importing "+path)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := (&Database{}).ImportJWLBackupWithOptions(path, ImportOptions{Context: ctx})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestDatabase_DeviceName(t *testing.T) {
+	db := &Database{}
+	assert.Equal(t, "", db.DeviceName())
+
+	assert.NoError(t, db.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+	assert.Equal(t, "Andreas iPhone Xs", db.DeviceName())
+	assert.Equal(t, "Andreas iPhone Xs", MakeDatabaseCopy(db).DeviceName())
+
+	var nilDB *Database
+	assert.Equal(t, "", nilDB.DeviceName())
+}
+
 func TestDatabase_ExportJWLBackup(t *testing.T) {
 	// Create tmp folder and place all files there
 	testFolder := ".jwlm-tmp_test"
@@ -273,6 +431,96 @@ func Test_createEmptySQLiteDB(t *testing.T) {
 	assert.Equal(t, "3fae739471144fd8815344bf479fc5d8468df9e9d5a27aeae88a144e51c97bdd", hash)
 }
 
+func TestDatabase_ExportJWLBackup_reproducible(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	os.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	db := Database{}
+	assert.NoError(t, db.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+
+	first := filepath.Join(tmp, "first.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(first))
+	// Make sure timestamps of the temporary files differ
+	time.Sleep(1100 * time.Millisecond)
+	second := filepath.Join(tmp, "second.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(second))
+
+	firstBytes, err := ioutil.ReadFile(first)
+	assert.NoError(t, err)
+	secondBytes, err := ioutil.ReadFile(second)
+	assert.NoError(t, err)
+	assert.Equal(t, firstBytes, secondBytes)
+}
+
+func TestDatabase_ExportJWLBackupWithOptions(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := Database{}
+	assert.NoError(t, db.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+
+	sizes := map[int]int64{}
+	for _, level := range []int{StoreOnly, DefaultCompression, BestSpeed, BestCompression} {
+		filename := filepath.Join(tmp, fmt.Sprintf("backup%d.jwlibrary", level))
+		assert.NoError(t, db.ExportJWLBackupWithOptions(filename, ExportOptions{CompressionLevel: level}))
+
+		imported := Database{}
+		assert.NoError(t, imported.ImportJWLBackup(filename))
+		assert.True(t, db.Equals(&imported))
+
+		r, err := zip.OpenReader(filename)
+		assert.NoError(t, err)
+		for _, f := range r.File {
+			if level == StoreOnly {
+				assert.Equal(t, zip.Store, f.Method)
+			} else {
+				assert.Equal(t, zip.Deflate, f.Method)
+			}
+		}
+		r.Close()
+
+		info, err := os.Stat(filename)
+		assert.NoError(t, err)
+		sizes[level] = info.Size()
+	}
+	assert.Greater(t, sizes[StoreOnly], sizes[BestSpeed])
+	assert.GreaterOrEqual(t, sizes[BestSpeed], sizes[BestCompression])
+
+	err = db.ExportJWLBackupWithOptions(filepath.Join(tmp, "invalid.jwlibrary"), ExportOptions{CompressionLevel: 10})
+	assert.EqualError(t, err, "Error while storing files in zip archive "+filepath.Join(tmp, "invalid.jwlibrary")+": Invalid compression level 10")
+}
+
+func TestDatabase_VerifyExport(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := Database{}
+	assert.NoError(t, db.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+
+	filename := filepath.Join(tmp, "backup.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackupWithOptions(filename, ExportOptions{Verify: true}))
+	assert.NoError(t, db.VerifyExport(filename))
+
+	db.Note[2].Content = sql.NullString{String: "Changed", Valid: true}
+	err = db.VerifyExport(filename)
+	assert.True(t, errors.Is(err, ErrExportMismatch))
+	assert.Contains(t, err.Error(), "Exported backup does not match the database: "+filename+" differs from the database: Found different entries")
+
+	db.Note = db.Note[:2]
+	err = db.VerifyExport(filename)
+	assert.True(t, errors.Is(err, ErrExportMismatch))
+	assert.Contains(t, err.Error(), "Length of slices at index 3 are not equal: 2 vs 3")
+
+	err = db.VerifyExport(filepath.Join(tmp, "missing.jwlibrary"))
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrExportMismatch))
+}
+
 func TestDatabase_saveToNewSQLite(t *testing.T) {
 	// Create tmp folder and place all files there
 	tmp, err := ioutil.TempDir("", "go-jwlm")
@@ -292,7 +540,7 @@ func TestDatabase_saveToNewSQLite(t *testing.T) {
 	assert.NoError(t, db.saveToNewSQLite(path))
 
 	db2 := Database{}
-	assert.NoError(t, db2.importSQLite(path))
+	assert.NoError(t, db2.importSQLite(context.Background(), path))
 
 	assert.Equal(t, db.BlockRange[0], db2.BlockRange[3])
 	assert.Equal(t, db.Bookmark[0], db2.Bookmark[2])
@@ -307,6 +555,44 @@ func TestDatabase_saveToNewSQLite(t *testing.T) {
 		Bookmark:   []*Bookmark{nil},
 	}
 	assert.NoError(t, db.saveToNewSQLite(path))
+
+	// Entries exceeding a single batch of inserts
+	db = Database{Location: make([]*Location, 251)}
+	for i := 1; i < len(db.Location); i++ {
+		db.Location[i] = &Location{
+			LocationID:    i,
+			BookNumber:    sql.NullInt32{Int32: int32(i), Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+			KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			MepsLanguage:  2,
+			Title:         sql.NullString{String: fmt.Sprint(i), Valid: true},
+		}
+	}
+	path = filepath.Join(tmp, "user_data_batch.db")
+	assert.NoError(t, db.saveToNewSQLite(path))
+	db2 = Database{}
+	assert.NoError(t, db2.importSQLite(context.Background(), path))
+	assert.Equal(t, db.Location, db2.Location)
+}
+
+func TestDatabase_ContentHash(t *testing.T) {
+	db1 := &Database{}
+	db2 := &Database{}
+	assert.NoError(t, db1.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+	assert.NoError(t, db2.ImportJWLBackup(filepath.Join("testdata", "backup_shuffled.jwlibrary")))
+
+	// Different IDs and order, but same content
+	assert.Equal(t, db1.ContentHash(), db2.ContentHash())
+	// Calculating the hash must not change the Database
+	before := MakeDatabaseCopy(db1)
+	assert.Equal(t, db1.ContentHash(), db1.ContentHash())
+	assert.Equal(t, before, db1)
+
+	db2.Note[len(db2.Note)-1].Content.String = "Changed"
+	assert.NotEqual(t, db1.ContentHash(), db2.ContentHash())
+
+	assert.Equal(t, (&Database{}).ContentHash(), (&Database{Note: []*Note{nil}}).ContentHash())
+	assert.NotEqual(t, db1.ContentHash(), (&Database{}).ContentHash())
 }
 
 func TestDatabase_Equals(t *testing.T) {
@@ -330,3 +616,27 @@ func TestDatabase_Equals(t *testing.T) {
 	assert.NoError(t, db3.ImportJWLBackup(path))
 	assert.True(t, db2.Equals(db3))
 }
+
+func Benchmark_saveToNewSQLite(b *testing.B) {
+	const locationCount = 100000
+	db := Database{Location: make([]*Location, locationCount+1)}
+	for i := 1; i < locationCount+1; i++ {
+		db.Location[i] = &Location{
+			LocationID:    i,
+			BookNumber:    sql.NullInt32{Int32: int32(i), Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: int32(i), Valid: true},
+			KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			MepsLanguage:  2,
+			Title:         sql.NullString{String: fmt.Sprint(i), Valid: true},
+		}
+	}
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(b, err)
+	defer os.RemoveAll(tmp)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		assert.NoError(b, db.saveToNewSQLite(filepath.Join(tmp, fmt.Sprintf("user_data_%d.db", i))))
+	}
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"bufio"
+	"database/sql"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// blockTypeParagraph is the BlockType of paragraphs of documents
+const blockTypeParagraph = 1
+
+// DocumentMapping describes how the documents of a superseded publication
+// (like an old edition of a songbook) map to the ones of its replacement.
+type DocumentMapping struct {
+	// Documents maps the DocumentID of an old document to its new one
+	Documents map[int]DocumentTarget
+	// KeySymbols maps the KeySymbol of the old publication to the one
+	// of the new publication, if it differs
+	KeySymbols map[string]string
+}
+
+// DocumentTarget is the document an old document is migrated to
+type DocumentTarget struct {
+	DocumentID int
+	// ParagraphOffset is added to the paragraphs of the old document,
+	// like -1 if the new document lost its first paragraph
+	ParagraphOffset int
+}
+
+// ParseDocumentMapping parses a DocumentMapping with one document per line
+// in the form `<old DocumentID> <new DocumentID> [paragraph offset]`.
+// Empty lines and lines starting with # are ignored.
+func ParseDocumentMapping(r io.Reader) (DocumentMapping, error) {
+	mapping := DocumentMapping{Documents: map[int]DocumentTarget{}}
+	scanner := bufio.NewScanner(r)
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return DocumentMapping{}, errors.Errorf("Line %d should contain the old and the new DocumentID, optionally followed by a paragraph offset", i)
+		}
+		numbers := make([]int, len(fields))
+		for j, field := range fields {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return DocumentMapping{}, errors.Errorf("Line %d: %s is not a number", i, field)
+			}
+			numbers[j] = n
+		}
+		if _, ok := mapping.Documents[numbers[0]]; ok {
+			return DocumentMapping{}, errors.Errorf("Line %d: document %d is mapped twice", i, numbers[0])
+		}
+
+		target := DocumentTarget{DocumentID: numbers[1]}
+		if len(numbers) == 3 {
+			target.ParagraphOffset = numbers[2]
+		}
+		mapping.Documents[numbers[0]] = target
+	}
+	if err := scanner.Err(); err != nil {
+		return DocumentMapping{}, errors.Wrap(err, "Error while reading document mapping")
+	}
+
+	return mapping, nil
+}
+
+// MigrateDocuments moves notes, highlights, bookmarks, and tags of the
+// documents of a superseded publication to the documents of its replacement
+// as given by mapping. Paragraphs are moved by the ParagraphOffset of their
+// document. If a Location already exists in the new publication, all entries
+// are moved to it and the old Location is removed (set to nil). It returns
+// the number of migrated Locations.
+func (db *Database) MigrateDocuments(mapping DocumentMapping) (int, error) {
+	if len(mapping.Documents) == 0 {
+		return 0, errors.New("No documents to migrate")
+	}
+	for from, to := range mapping.Documents {
+		if from <= 0 || to.DocumentID <= 0 {
+			return 0, errors.Errorf("Can't migrate document %d to %d", from, to.DocumentID)
+		}
+	}
+
+	selected := func(location *Location) bool {
+		if !location.DocumentID.Valid {
+			return false
+		}
+		_, ok := mapping.Documents[int(location.DocumentID.Int32)]
+		return ok
+	}
+
+	// Paragraphs are moved before their Locations, as
+	// Locations might be merged with existing ones
+	offsets := map[int]int{}
+	for _, location := range db.Location {
+		if location != nil && selected(location) {
+			if offset := mapping.Documents[int(location.DocumentID.Int32)].ParagraphOffset; offset != 0 {
+				offsets[location.LocationID] = offset
+			}
+		}
+	}
+	if err := db.moveParagraphs(offsets); err != nil {
+		return 0, err
+	}
+
+	return db.remapLocations(selected, func(location *Location) {
+		location.DocumentID.Int32 = int32(mapping.Documents[int(location.DocumentID.Int32)].DocumentID)
+		if keySymbol, ok := mapping.KeySymbols[location.KeySymbol.String]; ok && location.KeySymbol.Valid {
+			location.KeySymbol = sql.NullString{String: keySymbol, Valid: true}
+		}
+	}), nil
+}
+
+// moveParagraphs adds the offset of their Location to the paragraphs of
+// highlights, notes, and bookmarks. If a paragraph would end up before
+// the first one, nothing is changed and an error is returned.
+func (db *Database) moveParagraphs(offsets map[int]int) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	userMarkOffsets := map[int]int{}
+	for _, um := range db.UserMark {
+		if um != nil && offsets[um.LocationID] != 0 {
+			userMarkOffsets[um.UserMarkID] = offsets[um.LocationID]
+		}
+	}
+
+	// Check all paragraphs first, so the Database is not changed halfway
+	var moves []func()
+	move := func(blockType int, block int, offset int, set func(int)) error {
+		if blockType != blockTypeParagraph || offset == 0 {
+			return nil
+		}
+		if block+offset < 1 {
+			return errors.Errorf("Paragraph %d can't be moved by %d", block, offset)
+		}
+		moves = append(moves, func() { set(block + offset) })
+		return nil
+	}
+
+	for _, br := range db.BlockRange {
+		if br == nil {
+			continue
+		}
+		br := br
+		if err := move(br.BlockType, br.Identifier, userMarkOffsets[br.UserMarkID], func(p int) { br.Identifier = p }); err != nil {
+			return err
+		}
+	}
+	for _, note := range db.Note {
+		if note == nil || !note.LocationID.Valid || !note.BlockIdentifier.Valid {
+			continue
+		}
+		note := note
+		if err := move(note.BlockType, int(note.BlockIdentifier.Int32), offsets[int(note.LocationID.Int32)],
+			func(p int) { note.BlockIdentifier.Int32 = int32(p) }); err != nil {
+			return err
+		}
+	}
+	for _, bm := range db.Bookmark {
+		if bm == nil || !bm.BlockIdentifier.Valid {
+			continue
+		}
+		bm := bm
+		if err := move(bm.BlockType, int(bm.BlockIdentifier.Int32), offsets[bm.LocationID],
+			func(p int) { bm.BlockIdentifier.Int32 = int32(p) }); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range moves {
+		m()
+	}
+	return nil
+}
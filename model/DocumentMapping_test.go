@@ -0,0 +1,105 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDocumentMapping(t *testing.T) {
+	mapping, err := ParseDocumentMapping(strings.NewReader(`
+# Old songbook -> new songbook
+1102016801 1102022801
+1102016802   1102022802  -1
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]DocumentTarget{
+		1102016801: {DocumentID: 1102022801},
+		1102016802: {DocumentID: 1102022802, ParagraphOffset: -1},
+	}, mapping.Documents)
+
+	_, err = ParseDocumentMapping(strings.NewReader("1102016801"))
+	assert.EqualError(t, err, "Line 1 should contain the old and the new DocumentID, optionally followed by a paragraph offset")
+	_, err = ParseDocumentMapping(strings.NewReader("\n1102016801 new"))
+	assert.EqualError(t, err, "Line 2: new is not a number")
+	_, err = ParseDocumentMapping(strings.NewReader("1 2\n1 3"))
+	assert.EqualError(t, err, "Line 2: document 1 is mapped twice")
+}
+
+func TestDatabase_MigrateDocuments(t *testing.T) {
+	document := func(id int, docID int32, keySymbol string) *Location {
+		return &Location{
+			LocationID:   id,
+			DocumentID:   sql.NullInt32{Int32: docID, Valid: true},
+			KeySymbol:    sql.NullString{String: keySymbol, Valid: true},
+			MepsLanguage: 0,
+			LocationType: 0,
+		}
+	}
+	paragraph := func(p int32) sql.NullInt32 { return sql.NullInt32{Int32: p, Valid: true} }
+
+	db := &Database{
+		Location: []*Location{
+			nil,
+			document(1, 100, "sjj"),
+			document(2, 200, "sjj"),
+			document(3, 201, "sjjm"),
+			document(4, 300, "other"),
+		},
+		UserMark: []*UserMark{
+			nil,
+			{UserMarkID: 1, LocationID: 1, UserMarkGUID: "A"},
+			{UserMarkID: 2, LocationID: 2, UserMarkGUID: "B"},
+		},
+		BlockRange: []*BlockRange{
+			nil,
+			{BlockRangeID: 1, BlockType: 1, Identifier: 3, UserMarkID: 1},
+			{BlockRangeID: 2, BlockType: 1, Identifier: 3, UserMarkID: 2},
+		},
+		Note: []*Note{
+			nil,
+			{NoteID: 1, GUID: "N1", LocationID: paragraph(1), BlockType: 1, BlockIdentifier: paragraph(2)},
+			{NoteID: 2, GUID: "N2", LocationID: paragraph(4), BlockType: 1, BlockIdentifier: paragraph(2)},
+		},
+		Bookmark: []*Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 2, PublicationLocationID: 4, BlockType: 1, BlockIdentifier: paragraph(5)},
+		},
+	}
+
+	count, err := db.MigrateDocuments(DocumentMapping{
+		Documents: map[int]DocumentTarget{
+			100: {DocumentID: 101, ParagraphOffset: 1},
+			200: {DocumentID: 201, ParagraphOffset: -2},
+		},
+		KeySymbols: map[string]string{"sjj": "sjjm"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Equal(t, document(1, 101, "sjjm"), db.Location[1])
+	// Document 201 already exists, so the entries are moved to it
+	assert.Nil(t, db.Location[2])
+	assert.Equal(t, 3, db.UserMark[2].LocationID)
+	assert.Equal(t, 3, db.Bookmark[1].LocationID)
+	assert.Equal(t, document(4, 300, "other"), db.Location[4])
+
+	assert.Equal(t, 4, db.BlockRange[1].Identifier)
+	assert.Equal(t, 1, db.BlockRange[2].Identifier)
+	assert.Equal(t, paragraph(3), db.Note[1].BlockIdentifier)
+	assert.Equal(t, paragraph(2), db.Note[2].BlockIdentifier)
+	assert.Equal(t, paragraph(3), db.Bookmark[1].BlockIdentifier)
+
+	// Paragraphs before the first one are refused without changing anything
+	_, err = db.MigrateDocuments(DocumentMapping{Documents: map[int]DocumentTarget{101: {DocumentID: 102, ParagraphOffset: -3}}})
+	assert.EqualError(t, err, "Paragraph 3 can't be moved by -3")
+	assert.Equal(t, 4, db.BlockRange[1].Identifier)
+	assert.Equal(t, document(1, 101, "sjjm"), db.Location[1])
+
+	_, err = db.MigrateDocuments(DocumentMapping{})
+	assert.Error(t, err)
+	_, err = db.MigrateDocuments(DocumentMapping{Documents: map[int]DocumentTarget{1: {}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+// LanguageRemap describes how Locations are moved from one
+// language edition of a publication to another.
+type LanguageRemap struct {
+	// From and To are the MepsLanguage of the source and target edition
+	From int
+	To   int
+	// KeySymbols maps the KeySymbol of a publication in the source language
+	// to its KeySymbol in the target language, for publications whose
+	// symbol differs between languages. Other publications keep theirs.
+	KeySymbols map[string]string
+	// Documents also remaps Locations of documents (like articles of
+	// the Watchtower), not only those of Bibles. Paragraphs are kept as
+	// they are, so this only makes sense if both editions share the
+	// same structure.
+	Documents bool
+}
+
+// RemapLanguage moves the Locations of the given source language to the
+// target language, so highlights, notes, bookmarks, and tags show up
+// in the other language edition. As verses are the same in every
+// language, only Bible chapters (and the Bibles themselves) are remapped,
+// unless remap.Documents is set. If a Location already exists in the
+// target language, all entries are moved to it and the source Location
+// is removed (set to nil). It returns the number of remapped Locations.
+func (db *Database) RemapLanguage(remap LanguageRemap) (int, error) {
+	if remap.From < 0 || remap.To < 0 {
+		return 0, errors.Errorf("Can't remap from language %d to %d", remap.From, remap.To)
+	}
+	if remap.From == remap.To && len(remap.KeySymbols) == 0 {
+		return 0, errors.New("Source and target language must differ")
+	}
+
+	bibles := map[string]bool{}
+	for _, location := range db.Location {
+		if location != nil && location.MepsLanguage == remap.From && location.BookNumber.Valid {
+			bibles[location.KeySymbol.String] = true
+		}
+	}
+	remapped := func(location *Location) bool {
+		if location.MepsLanguage != remap.From || !location.KeySymbol.Valid {
+			return false
+		}
+		if remap.Documents {
+			return true
+		}
+		return location.BookNumber.Valid || (bibles[location.KeySymbol.String] && !location.DocumentID.Valid)
+	}
+
+	return db.remapLocations(remapped, func(location *Location) {
+		location.MepsLanguage = remap.To
+		if keySymbol, ok := remap.KeySymbols[location.KeySymbol.String]; ok {
+			location.KeySymbol = sql.NullString{String: keySymbol, Valid: true}
+		}
+	}), nil
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_RemapLanguage(t *testing.T) {
+	db := &Database{
+		Bookmark: []*Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 3, Slot: 0},
+		},
+		Location: []*Location{
+			nil,
+			{
+				LocationID:    1,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				MepsLanguage:  2,
+			},
+			{
+				LocationID:    2,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 2, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				MepsLanguage:  2,
+			},
+			{
+				LocationID:   3,
+				KeySymbol:    sql.NullString{String: "nwtsty", Valid: true},
+				MepsLanguage: 2,
+				LocationType: 1,
+			},
+			{
+				LocationID:     4,
+				DocumentID:     sql.NullInt32{Int32: 2023601, Valid: true},
+				IssueTagNumber: 20230600,
+				KeySymbol:      sql.NullString{String: "w", Valid: true},
+				MepsLanguage:   2,
+			},
+			{
+				LocationID:    5,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 2, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				MepsLanguage:  0,
+			},
+		},
+		Note: []*Note{
+			nil,
+			{NoteID: 1, LocationID: sql.NullInt32{Int32: 2, Valid: true}},
+			{NoteID: 2, LocationID: sql.NullInt32{Int32: 4, Valid: true}},
+		},
+		TagMap: []*TagMap{
+			nil,
+			{TagMapID: 1, TagID: 1, LocationID: sql.NullInt32{Int32: 2, Valid: true}},
+			{TagMapID: 2, TagID: 1, LocationID: sql.NullInt32{Int32: 5, Valid: true}, Position: 1},
+		},
+		UserMark: []*UserMark{
+			nil,
+			{UserMarkID: 1, LocationID: 2},
+		},
+	}
+
+	count, err := db.RemapLanguage(LanguageRemap{From: 2, To: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	assert.Equal(t, 0, db.Location[1].MepsLanguage)
+	assert.Nil(t, db.Location[2])
+	assert.Equal(t, 0, db.Location[3].MepsLanguage)
+	assert.Equal(t, 2, db.Location[4].MepsLanguage)
+	assert.Equal(t, 1, db.Bookmark[1].LocationID)
+	assert.Equal(t, 3, db.Bookmark[1].PublicationLocationID)
+	assert.Equal(t, int32(5), db.Note[1].LocationID.Int32)
+	assert.Equal(t, int32(4), db.Note[2].LocationID.Int32)
+	assert.Equal(t, 5, db.UserMark[1].LocationID)
+	assert.Equal(t, int32(5), db.TagMap[1].LocationID.Int32)
+	assert.Nil(t, db.TagMap[2])
+
+	count, err = db.RemapLanguage(LanguageRemap{
+		From:       2,
+		To:         1,
+		KeySymbols: map[string]string{"w": "ws"},
+		Documents:  true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, db.Location[4].MepsLanguage)
+	assert.Equal(t, "ws", db.Location[4].KeySymbol.String)
+
+	_, err = db.RemapLanguage(LanguageRemap{From: 1, To: 1})
+	assert.EqualError(t, err, "Source and target language must differ")
+
+	_, err = db.RemapLanguage(LanguageRemap{From: -1, To: 1})
+	assert.EqualError(t, err, "Can't remap from language -1 to 1")
+}
@@ -78,9 +78,16 @@ func (m *Location) RelatedEntries(db *Database) Related {
 // PrettyPrint prints Location in a human readable format and
 // adds information about related entries if helpful.
 func (m *Location) PrettyPrint(db *Database) string {
-	fields := []string{"Title", "BookNumber", "ChapterNumber", "DocumentID", "Track",
-		"IssueTagNumber", "KeySymbol", "MepsLanguage"}
-	return prettyPrint(m, fields)
+	return newPrettyPrinter().
+		addNullString("Title", m.Title).
+		addNullInt32("BookNumber", m.BookNumber).
+		addNullInt32("ChapterNumber", m.ChapterNumber).
+		addNullInt32("DocumentID", m.DocumentID).
+		addNullInt32("Track", m.Track).
+		addInt("IssueTagNumber", m.IssueTagNumber).
+		addNullString("KeySymbol", m.KeySymbol).
+		addInt("MepsLanguage", m.MepsLanguage).
+		String()
 }
 
 // MarshalJSON returns the JSON encoding of the entry
@@ -120,8 +127,13 @@ func (m *Location) idName() string {
 	return "LocationId"
 }
 
-func (m *Location) scanRow(rows *sql.Rows) (Model, error) {
-	err := rows.Scan(&m.LocationID, &m.BookNumber, &m.ChapterNumber, &m.DocumentID, &m.Track,
+func (m *Location) columns() []string {
+	return []string{"LocationId", "BookNumber", "ChapterNumber", "DocumentId", "Track",
+		"IssueTagNumber", "KeySymbol", "MepsLanguage", "Type", "Title"}
+}
+
+func (m *Location) scanRow(rows *sql.Rows, mapping []int) (Model, error) {
+	err := scanColumns(rows, mapping, &m.LocationID, &m.BookNumber, &m.ChapterNumber, &m.DocumentID, &m.Track,
 		&m.IssueTagNumber, &m.KeySymbol, &m.MepsLanguage, &m.LocationType, &m.Title)
 	return m, err
 }
@@ -23,7 +23,8 @@ type Model interface {
 	PrettyPrint(db *Database) string
 	tableName() string
 	idName() string
-	scanRow(row *sql.Rows) (Model, error)
+	columns() []string
+	scanRow(rows *sql.Rows, mapping []int) (Model, error)
 }
 
 // Related combines entries that are related to a given model
@@ -157,39 +158,54 @@ func MakeModelCopy(mdl Model) Model {
 	return mdlCopy
 }
 
-// prettyPrint prints the given fields of a Model as a table. If the field
-// is empty, its omitted.
-func prettyPrint(m Model, fields []string) string {
+// prettyPrinter prints fields of a Model as a table. Fields
+// with an empty (NULL) value are omitted.
+type prettyPrinter struct {
+	buf *bytes.Buffer
+	w   *tabwriter.Writer
+}
+
+// newPrettyPrinter creates a new prettyPrinter
+func newPrettyPrinter() *prettyPrinter {
 	buf := new(bytes.Buffer)
-	w := tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0)
+	return &prettyPrinter{
+		buf: buf,
+		w:   tabwriter.NewWriter(buf, 0, 0, 1, ' ', 0),
+	}
+}
 
-Loop:
-	for _, fieldName := range fields {
-		field := reflect.ValueOf(m).Elem().FieldByName(fieldName)
-		if !field.IsValid() {
-			panic(fmt.Sprintf("Given struct does not contain field %s", fieldName))
-		}
-		switch field.Interface().(type) {
-		case string:
-			fmt.Fprintf(w, "\n%s:\t%s", fieldName, strings.ReplaceAll(wordwrap.WrapString(field.String(), 70), "\n", "\n\t"))
-		case sql.NullString:
-			if field.Field(1).Bool() == false {
-				continue Loop
-			}
-			fmt.Fprintf(w, "\n%s:\t%s", fieldName, strings.ReplaceAll(wordwrap.WrapString(field.Field(0).String(), 70), "\n", "\n\t"))
-		case int:
-			fmt.Fprintf(w, "\n%s:\t%d", fieldName, field.Int())
-		case sql.NullInt32:
-			if field.Field(1).Bool() == false {
-				continue Loop
-			}
-			fmt.Fprintf(w, "\n%s:\t%d", fieldName, field.Field(0).Int())
-		default:
-			panic(fmt.Sprintf("Unsupported type for field %s", fieldName))
-		}
+// addString adds a string field, wrapping long values
+func (p *prettyPrinter) addString(name string, value string) *prettyPrinter {
+	fmt.Fprintf(p.w, "\n%s:\t%s", name, strings.ReplaceAll(wordwrap.WrapString(value, 70), "\n", "\n\t"))
+	return p
+}
+
+// addNullString adds a sql.NullString field if it is valid
+func (p *prettyPrinter) addNullString(name string, value sql.NullString) *prettyPrinter {
+	if !value.Valid {
+		return p
 	}
-	w.Flush()
-	return buf.String()
+	return p.addString(name, value.String)
+}
+
+// addInt adds an int field
+func (p *prettyPrinter) addInt(name string, value int) *prettyPrinter {
+	fmt.Fprintf(p.w, "\n%s:\t%d", name, value)
+	return p
+}
+
+// addNullInt32 adds a sql.NullInt32 field if it is valid
+func (p *prettyPrinter) addNullInt32(name string, value sql.NullInt32) *prettyPrinter {
+	if !value.Valid {
+		return p
+	}
+	return p.addInt(name, int(value.Int32))
+}
+
+// String returns the table of all added fields
+func (p *prettyPrinter) String() string {
+	p.w.Flush()
+	return p.buf.String()
 }
 
 // sortByUniqueKey sorts the given pointer to a slice of Model by UniqueKey,
@@ -206,22 +222,9 @@ func sortByUniqueKey(slice interface{}) map[int]int {
 
 	s := reflect.ValueOf(slice).Elem()
 
-	// Sort by UniqueKey
-	sort.Slice(s.Interface(), func(i, j int) bool {
-		// Nil is always smaller than every other value
-		jVal := s.Index(j)
-		if jVal.IsNil() {
-			return false
-		}
-		iVal := s.Index(i)
-		if iVal.IsNil() {
-			return true
-		}
-
-		iUQ := s.Index(i).MethodByName("UniqueKey").Call(nil)
-		jUQ := s.Index(j).MethodByName("UniqueKey").Call(nil)
-		return iUQ[0].String() < jUQ[0].String()
-	})
+	// Sort by UniqueKey. Generating the keys is expensive,
+	// so they are generated only once per entry.
+	sort.Sort(newKeyedSlice(s))
 
 	// If there are more than one nil values, remove all except one
 	// (all nil values are located at the beginning)
@@ -242,16 +245,62 @@ func sortByUniqueKey(slice interface{}) map[int]int {
 		if elem.IsNil() {
 			continue
 		}
-		oldID := int(elem.MethodByName("ID").Call(nil)[0].Int())
-		if oldID != i {
+		mdl := elem.Interface().(Model)
+		if oldID := mdl.ID(); oldID != i {
 			changes[oldID] = i
-			elem.MethodByName("SetID").Call([]reflect.Value{reflect.ValueOf(i)})
+			mdl.SetID(i)
 		}
 	}
 
 	return changes
 }
 
+// keyedSlice sorts a slice of Model by their precomputed UniqueKey,
+// for which nil-entries are always smaller than every other entry.
+type keyedSlice struct {
+	keys  []string
+	isNil []bool
+	swap  func(i, j int)
+}
+
+// newKeyedSlice generates the UniqueKeys for all entries of s
+func newKeyedSlice(s reflect.Value) *keyedSlice {
+	ks := &keyedSlice{
+		keys:  make([]string, s.Len()),
+		isNil: make([]bool, s.Len()),
+		swap:  reflect.Swapper(s.Interface()),
+	}
+	for i := 0; i < s.Len(); i++ {
+		if s.Index(i).IsNil() {
+			ks.isNil[i] = true
+			continue
+		}
+		ks.keys[i] = s.Index(i).Interface().(Model).UniqueKey()
+	}
+
+	return ks
+}
+
+func (ks *keyedSlice) Len() int {
+	return len(ks.keys)
+}
+
+func (ks *keyedSlice) Less(i, j int) bool {
+	if ks.isNil[j] {
+		return false
+	}
+	if ks.isNil[i] {
+		return true
+	}
+	return ks.keys[i] < ks.keys[j]
+}
+
+func (ks *keyedSlice) Swap(i, j int) {
+	ks.keys[i], ks.keys[j] = ks.keys[j], ks.keys[i]
+	ks.isNil[i], ks.isNil[j] = ks.isNil[j], ks.isNil[i]
+	ks.swap(i, j)
+}
+
 // UpdateIDs updates a given ID (named by IDName) on the slice of *model.Model
 // according to the given map, for which the key represents the old ID,
 // and value represents the new ID.
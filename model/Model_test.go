@@ -2,6 +2,7 @@ package model
 
 import (
 	"database/sql"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -143,21 +144,22 @@ func TestMakeModelCopy(t *testing.T) {
 	assert.Equal(t, 1, umbrCP.(*UserMarkBlockRange).BlockRanges[1].BlockRangeID)
 }
 
-func TestPrettyPrint(t *testing.T) {
-	location := &Location{
-		LocationID: 1,
-	}
-	assert.PanicsWithValue(t, "Given struct does not contain field notexistent", func() {
-		prettyPrint(location, []string{"notexistent"})
-	})
+func Test_prettyPrinter(t *testing.T) {
+	result := newPrettyPrinter().
+		addString("Name", "Value").
+		addNullString("Empty", sql.NullString{}).
+		addNullString("NullString", sql.NullString{String: "Valid", Valid: true}).
+		addInt("Int", 1).
+		addNullInt32("EmptyInt", sql.NullInt32{}).
+		addNullInt32("NullInt32", sql.NullInt32{Int32: 2, Valid: true}).
+		String()
+	assert.Equal(t, "\nName:       Value\nNullString: Valid\nInt:        1\nNullInt32:  2", result)
 
-	umbr := &UserMarkBlockRange{
-		UserMark: &UserMark{},
-	}
+	long := strings.Repeat("word ", 20)
+	result = newPrettyPrinter().addString("Long", long).String()
+	assert.Equal(t, "\nLong: "+strings.TrimSpace(strings.Repeat("word ", 14))+"\n      "+strings.Repeat("word ", 6), result)
 
-	assert.PanicsWithValue(t, "Unsupported type for field UserMark", func() {
-		prettyPrint(umbr, []string{"UserMark"})
-	})
+	assert.Equal(t, "", newPrettyPrinter().String())
 }
 
 func Test_sortByUniqueKey(t *testing.T) {
@@ -250,3 +252,22 @@ func Test_sortByUniqueKey(t *testing.T) {
 	assert.Equal(t, expectedNotes, notes)
 	assert.Equal(t, expectedNoteIDChanges, noteIDChanges)
 }
+
+func Benchmark_sortByUniqueKey(b *testing.B) {
+	const locationCount = 60000
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		locations := make([]*Location, locationCount+1)
+		for j := 1; j < locationCount+1; j++ {
+			locations[j] = &Location{
+				LocationID:    j,
+				BookNumber:    sql.NullInt32{Int32: int32(locationCount - j), Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: int32(j % 150), Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				MepsLanguage:  2,
+			}
+		}
+		b.StartTimer()
+		sortByUniqueKey(&locations)
+	}
+}
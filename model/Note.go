@@ -63,8 +63,11 @@ func (m *Note) RelatedEntries(db *Database) Related {
 // PrettyPrint prints Note in a human readable format and
 // adds information about related entries if helpful.
 func (m *Note) PrettyPrint(db *Database) string {
-	fields := []string{"Title", "Content", "LastModified"}
-	result := prettyPrint(m, fields)
+	result := newPrettyPrinter().
+		addNullString("Title", m.Title).
+		addNullString("Content", m.Content).
+		addString("LastModified", m.LastModified).
+		String()
 
 	// TODO: Use RelatedEntries
 	if location := db.FetchFromTable("Location", int(m.LocationID.Int32)); location != nil {
@@ -115,8 +118,13 @@ func (m *Note) idName() string {
 	return "NoteId"
 }
 
-func (m *Note) scanRow(rows *sql.Rows) (Model, error) {
-	err := rows.Scan(&m.NoteID, &m.GUID, &m.UserMarkID, &m.LocationID, &m.Title, &m.Content,
+func (m *Note) columns() []string {
+	return []string{"NoteId", "Guid", "UserMarkId", "LocationId", "Title", "Content",
+		"LastModified", "BlockType", "BlockIdentifier"}
+}
+
+func (m *Note) scanRow(rows *sql.Rows, mapping []int) (Model, error) {
+	err := scanColumns(rows, mapping, &m.NoteID, &m.GUID, &m.UserMarkID, &m.LocationID, &m.Title, &m.Content,
 		&m.LastModified, &m.BlockType, &m.BlockIdentifier)
 	return m, err
 }
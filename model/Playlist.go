@@ -0,0 +1,186 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/sqlite"
+	"github.com/pkg/errors"
+)
+
+// playlistTagType is the type of tags that represent playlists
+const playlistTagType = 2
+
+// tick is the unit JW Library uses for durations of playlist items
+const tick = 100 * time.Nanosecond
+
+// Playlist is a playlist of JW Library together with its items. Playlists
+// are not part of a Database yet, so they can only be read from a backup
+// using ReadPlaylists.
+type Playlist struct {
+	Name  string
+	Items []PlaylistItem
+}
+
+// PlaylistItem is an entry of a Playlist, like a picture or a video
+type PlaylistItem struct {
+	Label string
+	// MediaType is the type of the media as stored by JW Library
+	// (like image, audio, or video)
+	MediaType int
+	// Filename is the name of the media file inside the backup,
+	// if it has been added by the user
+	Filename string
+	// Location is the publication the media belongs to,
+	// if it has not been added by the user
+	Location *Location
+	// Duration is the duration of the media, while StartTime and
+	// EndTime trim it (0 if it is not trimmed)
+	Duration          time.Duration
+	StartTime         time.Duration
+	EndTime           time.Duration
+	EndAction         int
+	ThumbnailFilename string
+	Markers           []PlaylistMarker
+}
+
+// PlaylistMarker is a marker of a PlaylistItem, like a chapter of a video
+type PlaylistMarker struct {
+	Label      string
+	StartTime  time.Duration
+	Transition time.Duration
+}
+
+// ReadPlaylists reads the playlists of the given JW Library backup,
+// ordered by their ID. The items of a playlist are sorted by their
+// position.
+func ReadPlaylists(filename string) ([]Playlist, error) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while creating temporary directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	path, _, err := unpackBackup(context.Background(), filename, tmp)
+	if err != nil {
+		return nil, err
+	}
+	return readPlaylists(path)
+}
+
+// readPlaylists reads the playlists of the given SQLite DB
+func readPlaylists(filename string) ([]Playlist, error) {
+	sqliteDB, err := sqlite.OpenImmutable(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while opening SQLite database")
+	}
+	defer sqliteDB.Close()
+	stmts := newStmtCache(sqliteDB)
+	defer stmts.close()
+
+	locations, err := fetchFromSQLite(stmts, &Location{})
+	if err != nil {
+		return nil, err
+	}
+	markers, err := readPlaylistMarkers(stmts)
+	if err != nil {
+		return nil, err
+	}
+
+	var playlists []Playlist
+	byTag := map[int]int{}
+	rows, err := stmts.query("SELECT TagId, Name FROM Tag WHERE Type = ? ORDER BY TagId", playlistTagType)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while reading playlists")
+	}
+	for rows.Next() {
+		var tagID int
+		var playlist Playlist
+		if err := rows.Scan(&tagID, &playlist.Name); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, "Error while reading playlists")
+		}
+		byTag[tagID] = len(playlists)
+		playlists = append(playlists, playlist)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error while reading playlists")
+	}
+
+	rows, err = stmts.query(`SELECT TagMap.TagId, PlaylistItem.PlaylistItemId, PlaylistItem.Label,
+		PlaylistItem.StartTimeOffsetTicks, PlaylistItem.EndTimeOffsetTicks, PlaylistItem.EndAction,
+		PlaylistItem.ThumbnailFilename, PlaylistMedia.MediaType, PlaylistMedia.Filename, PlaylistMedia.LocationId,
+		(SELECT MAX(BaseDurationTicks) FROM PlaylistItemChild
+			WHERE PlaylistItemChild.PlaylistItemId = PlaylistItem.PlaylistItemId)
+		FROM TagMap
+		JOIN PlaylistItem ON PlaylistItem.PlaylistItemId = TagMap.PlaylistItemId
+		JOIN PlaylistMedia ON PlaylistMedia.PlaylistMediaId = PlaylistItem.PlaylistMediaId
+		ORDER BY TagMap.TagId, TagMap.Position`)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while reading playlist items")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tagID, itemID int
+		var item PlaylistItem
+		var start, end, duration sql.NullInt64
+		var thumbnail, filename sql.NullString
+		var locationID sql.NullInt32
+		err := rows.Scan(&tagID, &itemID, &item.Label, &start, &end, &item.EndAction,
+			&thumbnail, &item.MediaType, &filename, &locationID, &duration)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error while reading playlist items")
+		}
+
+		item.StartTime = time.Duration(start.Int64) * tick
+		item.EndTime = time.Duration(end.Int64) * tick
+		item.Duration = time.Duration(duration.Int64) * tick
+		item.ThumbnailFilename = thumbnail.String
+		item.Filename = filename.String
+		if locationID.Valid && int(locationID.Int32) < len(locations) && locations[locationID.Int32] != nil {
+			item.Location = locations[locationID.Int32].(*Location)
+		}
+		item.Markers = markers[itemID]
+
+		i, ok := byTag[tagID]
+		if !ok {
+			return nil, errors.Errorf("Playlist item %d belongs to tag %d, which is not a playlist", itemID, tagID)
+		}
+		playlists[i].Items = append(playlists[i].Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error while reading playlist items")
+	}
+
+	return playlists, nil
+}
+
+// readPlaylistMarkers reads the markers of all playlist
+// items, sorted by their start time
+func readPlaylistMarkers(stmts *stmtCache) (map[int][]PlaylistMarker, error) {
+	rows, err := stmts.query(`SELECT PlaylistItemId, MarkerLabel, MarkerStartTimeTicks, MarkerEndTransitionDurationTicks
+		FROM PlaylistItemChild WHERE MarkerId IS NOT NULL
+		ORDER BY PlaylistItemId, MarkerStartTimeTicks`)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while reading playlist markers")
+	}
+	defer rows.Close()
+
+	markers := map[int][]PlaylistMarker{}
+	for rows.Next() {
+		var itemID int
+		var marker PlaylistMarker
+		var start, transition sql.NullInt64
+		if err := rows.Scan(&itemID, &marker.Label, &start, &transition); err != nil {
+			return nil, errors.Wrap(err, "Error while reading playlist markers")
+		}
+		marker.StartTime = time.Duration(start.Int64) * tick
+		marker.Transition = time.Duration(transition.Int64) * tick
+		markers[itemID] = append(markers[itemID], marker)
+	}
+	return markers, errors.Wrap(rows.Err(), "Error while reading playlist markers")
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AndreasSko/go-jwlm/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_readPlaylists(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "user_data.db"))
+	assert.NoError(t, err)
+	path := filepath.Join(tmp, "user_data.db")
+	assert.NoError(t, ioutil.WriteFile(path, data, 0644))
+
+	sqliteDB, err := sqlite.Open(path)
+	assert.NoError(t, err)
+	_, err = sqliteDB.Exec(`INSERT INTO Tag VALUES (10, 2, 'Meeting', NULL), (11, 2, 'Empty', NULL);
+		INSERT INTO Location (LocationId, DocumentId, KeySymbol, MepsLanguage, Type)
+			VALUES (20, 502013365, 'w', 2, 0);
+		INSERT INTO PlaylistMedia VALUES (1, 1, 'Picture', 'picture.jpg', NULL), (2, 3, NULL, NULL, 20);
+		INSERT INTO PlaylistItem VALUES
+			(1, 'Introduction', 0, NULL, NULL, 0, 'thumb.jpg', 1),
+			(2, 'Video', 0, 10000000, 50000000, 1, NULL, 2);
+		INSERT INTO PlaylistItemChild VALUES
+			(1, 600000000, NULL, NULL, NULL, NULL, 2),
+			(2, 600000000, 1, 'Chapter 2', 300000000, 10000000, 2),
+			(3, 600000000, 2, 'Chapter 1', 100000000, NULL, 2);
+		INSERT INTO TagMap VALUES (10, 2, NULL, NULL, 10, 0), (11, 1, NULL, NULL, 10, 1);`)
+	assert.NoError(t, err)
+	assert.NoError(t, sqliteDB.Close())
+
+	playlists, err := readPlaylists(path)
+	assert.NoError(t, err)
+	assert.Len(t, playlists, 2)
+
+	assert.Equal(t, "Meeting", playlists[0].Name)
+	assert.Len(t, playlists[0].Items, 2)
+	video := playlists[0].Items[0]
+	assert.Equal(t, "Video", video.Label)
+	assert.Equal(t, 3, video.MediaType)
+	assert.Equal(t, "w", video.Location.KeySymbol.String)
+	assert.Equal(t, time.Second, video.StartTime)
+	assert.Equal(t, 5*time.Second, video.EndTime)
+	assert.Equal(t, time.Minute, video.Duration)
+	assert.Equal(t, 1, video.EndAction)
+	assert.Equal(t, []PlaylistMarker{
+		{Label: "Chapter 1", StartTime: 10 * time.Second},
+		{Label: "Chapter 2", StartTime: 30 * time.Second, Transition: time.Second},
+	}, video.Markers)
+	assert.Equal(t, PlaylistItem{
+		Label:             "Introduction",
+		MediaType:         1,
+		Filename:          "picture.jpg",
+		ThumbnailFilename: "thumb.jpg",
+	}, playlists[0].Items[1])
+
+	assert.Equal(t, Playlist{Name: "Empty"}, playlists[1])
+
+	playlists, err = readPlaylists(filepath.Join("testdata", "user_data.db"))
+	assert.NoError(t, err)
+	assert.Empty(t, playlists)
+}
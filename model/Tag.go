@@ -26,13 +26,15 @@ func (m *Tag) SetID(id int) {
 }
 
 // UniqueKey returns the key that makes this Tag unique,
-// so it can be used as a key in a map.
+// so it can be used as a key in a map. The name is
+// normalized, so hierarchical names that only differ
+// in their notation result in the same key.
 func (m *Tag) UniqueKey() string {
 	var sb strings.Builder
 	sb.Grow(15)
 	sb.WriteString(strconv.FormatInt(int64(m.TagType), 10))
 	sb.WriteString("_")
-	sb.WriteString(m.Name)
+	sb.WriteString(NormalizeTagName(m.Name))
 	return sb.String()
 }
 
@@ -56,8 +58,9 @@ func (m *Tag) RelatedEntries(db *Database) Related {
 // PrettyPrint prints Tag in a human readable format and
 // adds information about related entries if helpful.
 func (m *Tag) PrettyPrint(db *Database) string {
-	fields := []string{"Name"}
-	return prettyPrint(m, fields)
+	return newPrettyPrinter().
+		addString("Name", m.Name).
+		String()
 }
 
 // MarshalJSON returns the JSON encoding of the entry
@@ -85,8 +88,12 @@ func (m *Tag) idName() string {
 	return "TagId"
 }
 
-func (m *Tag) scanRow(rows *sql.Rows) (Model, error) {
-	err := rows.Scan(&m.TagID, &m.TagType, &m.Name, &m.ImageFilename)
+func (m *Tag) columns() []string {
+	return []string{"TagId", "Type", "Name", "ImageFilename"}
+}
+
+func (m *Tag) scanRow(rows *sql.Rows, mapping []int) (Model, error) {
+	err := scanColumns(rows, mapping, &m.TagID, &m.TagType, &m.Name, &m.ImageFilename)
 	return m, err
 }
 
@@ -96,8 +96,12 @@ func (m *TagMap) idName() string {
 	return "TagMapId"
 }
 
-func (m *TagMap) scanRow(rows *sql.Rows) (Model, error) {
-	err := rows.Scan(&m.TagMapID, &m.PlaylistItemID, &m.LocationID, &m.NoteID, &m.TagID, &m.Position)
+func (m *TagMap) columns() []string {
+	return []string{"TagMapId", "PlaylistItemId", "LocationId", "NoteId", "TagId", "Position"}
+}
+
+func (m *TagMap) scanRow(rows *sql.Rows, mapping []int) (Model, error) {
+	err := scanColumns(rows, mapping, &m.TagMapID, &m.PlaylistItemID, &m.LocationID, &m.NoteID, &m.TagID, &m.Position)
 	return m, err
 }
 
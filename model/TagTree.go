@@ -0,0 +1,201 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TagSeparator separates the levels of hierarchical tag names
+// like "Talks/2024/Dedication"
+const TagSeparator = "/"
+
+// TagPath splits the name of a Tag into the levels of its hierarchy.
+// Surrounding spaces and empty levels are removed, so "Talks / 2024"
+// and "Talks/2024/" result in the same path.
+func TagPath(name string) []string {
+	parts := strings.Split(name, TagSeparator)
+	path := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			path = append(path, part)
+		}
+	}
+	return path
+}
+
+// NormalizeTagName returns the name of a Tag in its normalized
+// form, like "Talks/2024" for "Talks / 2024" (see TagPath).
+func NormalizeTagName(name string) string {
+	return strings.Join(TagPath(name), TagSeparator)
+}
+
+// TagNode is a level in the hierarchy of Tags
+type TagNode struct {
+	// Name is the name of the level, like "2024"
+	Name string
+	// Path is the full name of the level, like "Talks/2024"
+	Path string
+	// Tag is the Tag of this level. It is nil if there are only
+	// Tags below this level, like "Talks" for "Talks/2024".
+	Tag      *Tag
+	Children []*TagNode
+	// Notes is the number of Notes tagged with the Tag of this level
+	Notes int
+	// TotalNotes is the number of different Notes tagged with
+	// the Tag of this level or one of the levels below it
+	TotalNotes int
+}
+
+// child returns the child with the given name and creates it if necessary
+func (n *TagNode) child(name string) *TagNode {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+
+	path := name
+	if n.Path != "" {
+		path = n.Path + TagSeparator + name
+	}
+	child := &TagNode{Name: name, Path: path}
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// countNotes sets the number of Notes of the node and its children
+// and returns the IDs of all Notes of the branch
+func (n *TagNode) countNotes(notesPerTag map[int]map[int]bool) map[int]bool {
+	total := map[int]bool{}
+	if n.Tag != nil {
+		n.Notes = len(notesPerTag[n.Tag.TagID])
+		for noteID := range notesPerTag[n.Tag.TagID] {
+			total[noteID] = true
+		}
+	}
+	for _, child := range n.Children {
+		for noteID := range child.countNotes(notesPerTag) {
+			total[noteID] = true
+		}
+	}
+	n.TotalNotes = len(total)
+	return total
+}
+
+// sort sorts the children of the node and their children by name
+func (n *TagNode) sort() {
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		return strings.ToLower(n.Children[i].Name) < strings.ToLower(n.Children[j].Name)
+	})
+	for _, child := range n.Children {
+		child.sort()
+	}
+}
+
+// Walk calls fn for every node below n in depth-first order,
+// together with its depth (starting with 0 for the children of n).
+func (n *TagNode) Walk(fn func(node *TagNode, depth int)) {
+	n.walk(fn, 0)
+}
+
+func (n *TagNode) walk(fn func(node *TagNode, depth int), depth int) {
+	for _, child := range n.Children {
+		fn(child, depth)
+		child.walk(fn, depth+1)
+	}
+}
+
+// TagTree returns the hierarchy of the Tags of the Database, which is
+// built from their names (see TagSeparator). Tags without a separator
+// are at the top level. The returned root node has no name and no Tag.
+// Each node also contains the number of tagged Notes of its branch.
+func (db *Database) TagTree() *TagNode {
+	root := &TagNode{}
+	for _, tag := range db.Tag {
+		if tag == nil {
+			continue
+		}
+
+		node := root
+		for _, name := range TagPath(tag.Name) {
+			node = node.child(name)
+		}
+		if node != root && node.Tag == nil {
+			node.Tag = tag
+		}
+	}
+
+	notesPerTag := map[int]map[int]bool{}
+	for _, tm := range db.TagMap {
+		if tm == nil || !tm.NoteID.Valid {
+			continue
+		}
+		if _, ok := notesPerTag[tm.TagID]; !ok {
+			notesPerTag[tm.TagID] = map[int]bool{}
+		}
+		notesPerTag[tm.TagID][int(tm.NoteID.Int32)] = true
+	}
+	root.countNotes(notesPerTag)
+
+	root.sort()
+	return root
+}
+
+// RenameTagBranch renames the Tag at the path from and all Tags below it
+// to the path to, so "Talks/2024" -> "Archive/2024" also renames
+// "Talks/2024/Dedication" to "Archive/2024/Dedication". This allows to
+// rename a branch or move it to another one. It returns the number of
+// renamed Tags and fails if one of the new names already exists.
+func (db *Database) RenameTagBranch(from string, to string) (int, error) {
+	fromPath := TagPath(from)
+	toPath := TagPath(to)
+	if len(fromPath) == 0 || len(toPath) == 0 {
+		return 0, errors.New("Tag names must not be empty")
+	}
+	if hasTagPathPrefix(toPath, fromPath) {
+		return 0, errors.Errorf("Can't move %s into itself", NormalizeTagName(from))
+	}
+
+	renames := map[*Tag]string{}
+	existing := map[string]bool{}
+	for _, tag := range db.Tag {
+		if tag == nil {
+			continue
+		}
+		path := TagPath(tag.Name)
+		if !hasTagPathPrefix(path, fromPath) {
+			existing[fmt.Sprintf("%d_%s", tag.TagType, NormalizeTagName(tag.Name))] = true
+			continue
+		}
+		newPath := append(append([]string{}, toPath...), path[len(fromPath):]...)
+		renames[tag] = strings.Join(newPath, TagSeparator)
+	}
+
+	for tag, name := range renames {
+		if existing[fmt.Sprintf("%d_%s", tag.TagType, name)] {
+			return 0, errors.Errorf("Tag %s already exists", name)
+		}
+	}
+	for tag, name := range renames {
+		tag.Name = name
+	}
+
+	return len(renames), nil
+}
+
+// hasTagPathPrefix checks if path starts with all levels of prefix
+func hasTagPathPrefix(path []string, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagPath(t *testing.T) {
+	assert.Equal(t, []string{"Talks", "2024", "Dedication"}, TagPath("Talks/2024/Dedication"))
+	assert.Equal(t, []string{"Talks", "2024"}, TagPath(" Talks / 2024/"))
+	assert.Equal(t, []string{"Favorite"}, TagPath("Favorite"))
+	assert.Equal(t, []string{}, TagPath(" / "))
+}
+
+func TestNormalizeTagName(t *testing.T) {
+	assert.Equal(t, "Talks/2024", NormalizeTagName("Talks / 2024/"))
+	assert.Equal(t, "Favorite", NormalizeTagName(" Favorite "))
+}
+
+func TestDatabase_TagTree(t *testing.T) {
+	db := &Database{
+		Tag: []*Tag{
+			nil,
+			{TagID: 1, TagType: 1, Name: "Talks/2024/Dedication"},
+			{TagID: 2, TagType: 1, Name: "Favorite"},
+			{TagID: 3, TagType: 1, Name: "Talks/2023"},
+			{TagID: 4, TagType: 1, Name: "Talks"},
+		},
+		TagMap: []*TagMap{
+			nil,
+			{TagMapID: 1, TagID: 1, NoteID: sql.NullInt32{Int32: 1, Valid: true}},
+			{TagMapID: 2, TagID: 1, NoteID: sql.NullInt32{Int32: 2, Valid: true}},
+			{TagMapID: 3, TagID: 3, NoteID: sql.NullInt32{Int32: 2, Valid: true}},
+			{TagMapID: 4, TagID: 4, NoteID: sql.NullInt32{Int32: 3, Valid: true}},
+			{TagMapID: 5, TagID: 2, LocationID: sql.NullInt32{Int32: 1, Valid: true}},
+		},
+	}
+
+	tree := db.TagTree()
+	assert.Equal(t, "", tree.Name)
+	assert.Nil(t, tree.Tag)
+
+	var paths []string
+	var depths []int
+	tree.Walk(func(node *TagNode, depth int) {
+		paths = append(paths, node.Path)
+		depths = append(depths, depth)
+	})
+	assert.Equal(t, []string{"Favorite", "Talks", "Talks/2023", "Talks/2024", "Talks/2024/Dedication"}, paths)
+	assert.Equal(t, []int{0, 0, 1, 1, 2}, depths)
+
+	talks := tree.Children[1]
+	assert.Equal(t, db.Tag[4], talks.Tag)
+	assert.Nil(t, talks.Children[1].Tag)
+	assert.Equal(t, db.Tag[1], talks.Children[1].Children[0].Tag)
+
+	var notes, totals []int
+	tree.Walk(func(node *TagNode, depth int) {
+		notes = append(notes, node.Notes)
+		totals = append(totals, node.TotalNotes)
+	})
+	assert.Equal(t, []int{0, 1, 1, 0, 2}, notes)
+	assert.Equal(t, []int{0, 3, 1, 2, 2}, totals)
+	assert.Equal(t, 3, tree.TotalNotes)
+
+	assert.Empty(t, (&Database{}).TagTree().Children)
+}
+
+func TestDatabase_RenameTagBranch(t *testing.T) {
+	db := &Database{
+		Tag: []*Tag{
+			nil,
+			{TagID: 1, TagType: 1, Name: "Talks/2024/Dedication"},
+			{TagID: 2, TagType: 1, Name: "Talks/2024"},
+			{TagID: 3, TagType: 1, Name: "Talks/2023"},
+			{TagID: 4, TagType: 1, Name: "Talks/20245"},
+			{TagID: 5, TagType: 1, Name: "Archive/2023"},
+		},
+	}
+
+	count, err := db.RenameTagBranch("Talks/2024", "Archive / 2024")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "Archive/2024/Dedication", db.Tag[1].Name)
+	assert.Equal(t, "Archive/2024", db.Tag[2].Name)
+	assert.Equal(t, "Talks/2023", db.Tag[3].Name)
+	assert.Equal(t, "Talks/20245", db.Tag[4].Name)
+
+	count, err = db.RenameTagBranch("Talks", "Archive")
+	assert.EqualError(t, err, "Tag Archive/2023 already exists")
+	assert.Equal(t, 0, count)
+	assert.Equal(t, "Talks/2023", db.Tag[3].Name)
+
+	_, err = db.RenameTagBranch("Archive", "Archive/Old")
+	assert.EqualError(t, err, "Can't move Archive into itself")
+
+	_, err = db.RenameTagBranch("", "Archive")
+	assert.EqualError(t, err, "Tag names must not be empty")
+
+	count, err = db.RenameTagBranch("Unknown", "Archive")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
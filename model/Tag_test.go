@@ -37,6 +37,13 @@ func TestTag_UniqueKey(t *testing.T) {
 		ImageFilename: sql.NullString{},
 	}
 	assert.Equal(t, "2000000000_Another Tag with spaces", m2.UniqueKey())
+
+	m3 := &Tag{
+		TagID:   1,
+		TagType: 1,
+		Name:    " Talks / 2024/",
+	}
+	assert.Equal(t, "1_Talks/2024", m3.UniqueKey())
 }
 
 func TestTag_Equals(t *testing.T) {
@@ -51,10 +51,9 @@ func (m *UserMark) RelatedEntries(db *Database) Related {
 // PrettyPrint prints UserMark in a human readable format and
 // adds information about related entries if helpful.
 func (m *UserMark) PrettyPrint(db *Database) string {
-	fields := []string{"ColorIndex"}
-	result := prettyPrint(m, fields)
-
-	return result
+	return newPrettyPrinter().
+		addInt("ColorIndex", m.ColorIndex).
+		String()
 }
 
 // MarshalJSON returns the JSON encoding of the entry
@@ -86,8 +85,12 @@ func (m *UserMark) idName() string {
 	return "UserMarkId"
 }
 
-func (m *UserMark) scanRow(rows *sql.Rows) (Model, error) {
-	err := rows.Scan(&m.UserMarkID, &m.ColorIndex, &m.LocationID, &m.StyleIndex, &m.UserMarkGUID, &m.Version)
+func (m *UserMark) columns() []string {
+	return []string{"UserMarkId", "ColorIndex", "LocationId", "StyleIndex", "UserMarkGuid", "Version"}
+}
+
+func (m *UserMark) scanRow(rows *sql.Rows, mapping []int) (Model, error) {
+	err := scanColumns(rows, mapping, &m.UserMarkID, &m.ColorIndex, &m.LocationID, &m.StyleIndex, &m.UserMarkGUID, &m.Version)
 	return m, err
 }
 
@@ -42,22 +42,22 @@ func (m *UserMarkBlockRange) UniqueKey() string {
 	return sb.String()
 }
 
+// blockRangeKeyRegex matches the UniqueKey of a BlockRange, so the UserMarkID
+// can be removed from it, as BlockRanges have already been joined with UserMark
+var blockRangeKeyRegex = regexp.MustCompile(`^(\d*_\d*_\d*_\d*)(_\d*)`)
+
 // Equals checks if the UserMarkBlockRange is equal to the given one.
 // It will both check its UserMark and all BlockRanges.
 func (m *UserMarkBlockRange) Equals(m2 Model) bool {
-	// Remove UserMarkID from UniqueKey, as BlockRanges have already
-	// been joined with UserMark
-	re := regexp.MustCompile(`^(\d*_\d*_\d*_\d*)(_\d*)`)
-
 	// Compare UniqueKeys of both BlockRanges to check if they are the same
 	mBRKeys := make(map[string]bool, len(m.BlockRanges))
 	m2BRKeys := make(map[string]bool, len(m2.(*UserMarkBlockRange).BlockRanges))
 	for _, br := range m.BlockRanges {
-		uq := re.ReplaceAllString(br.UniqueKey(), "$1")
+		uq := blockRangeKeyRegex.ReplaceAllString(br.UniqueKey(), "$1")
 		mBRKeys[uq] = true
 	}
 	for _, br := range m2.(*UserMarkBlockRange).BlockRanges {
-		uq := re.ReplaceAllString(br.UniqueKey(), "$1")
+		uq := blockRangeKeyRegex.ReplaceAllString(br.UniqueKey(), "$1")
 		m2BRKeys[uq] = true
 	}
 
@@ -79,19 +79,16 @@ func (m *UserMarkBlockRange) RelatedEntries(db *Database) Related {
 // PrettyPrint prints UserMarkBlockRange in a human readable format and
 // adds information about related entries if helpful.
 func (m *UserMarkBlockRange) PrettyPrint(db *Database) string {
-	umFields := []string{"ColorIndex"}
-	brFields := []string{"Identifier", "StartToken", "EndToken"}
-
 	var result string
 
 	if location := db.FetchFromTable("Location", m.UserMark.LocationID); location != nil {
 		result += location.PrettyPrint(db)
 	}
 
-	result += "\n" + prettyPrint(m.UserMark, umFields) + "\n"
+	result += "\n" + m.UserMark.PrettyPrint(db) + "\n"
 
 	for _, br := range m.BlockRanges {
-		result += prettyPrint(br, brFields) + "\n"
+		result += br.PrettyPrint(db) + "\n"
 	}
 
 	return result
@@ -118,7 +115,11 @@ func (m *UserMarkBlockRange) idName() string {
 	panic("Not supported!")
 }
 
-func (m *UserMarkBlockRange) scanRow(rows *sql.Rows) (Model, error) {
+func (m *UserMarkBlockRange) columns() []string {
+	panic("Not supported!")
+}
+
+func (m *UserMarkBlockRange) scanRow(rows *sql.Rows, mapping []int) (Model, error) {
 	panic("Not supported!")
 }
 
@@ -0,0 +1,34 @@
+package model
+
+import "sort"
+
+// CompactBookmarkSlots renumbers the slots of the Bookmarks of every
+// publication to be contiguous starting at 0, keeping their order, so
+// slots freed by deleting or cleaning up Bookmarks can be used again in
+// JW Library. Bookmarks sharing a slot are ordered by their ID. It
+// returns the IDs of the moved Bookmarks together with their new slot.
+func (db *Database) CompactBookmarkSlots() map[int]int {
+	publications := map[int][]*Bookmark{}
+	for _, bm := range db.Bookmark {
+		if bm != nil {
+			publications[bm.PublicationLocationID] = append(publications[bm.PublicationLocationID], bm)
+		}
+	}
+
+	moved := map[int]int{}
+	for _, bookmarks := range publications {
+		sort.SliceStable(bookmarks, func(i, j int) bool {
+			if bookmarks[i].Slot != bookmarks[j].Slot {
+				return bookmarks[i].Slot < bookmarks[j].Slot
+			}
+			return bookmarks[i].BookmarkID < bookmarks[j].BookmarkID
+		})
+		for slot, bm := range bookmarks {
+			if bm.Slot != slot {
+				bm.Slot = slot
+				moved[bm.BookmarkID] = slot
+			}
+		}
+	}
+	return moved
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_CompactBookmarkSlots(t *testing.T) {
+	db := &Database{
+		Bookmark: []*Bookmark{
+			nil,
+			{BookmarkID: 1, PublicationLocationID: 1, Slot: 7},
+			{BookmarkID: 2, PublicationLocationID: 1, Slot: 2},
+			{BookmarkID: 3, PublicationLocationID: 2, Slot: 0},
+			{BookmarkID: 4, PublicationLocationID: 2, Slot: 1},
+			nil,
+			{BookmarkID: 6, PublicationLocationID: 1, Slot: 2},
+			{BookmarkID: 7, PublicationLocationID: 2, Slot: 12},
+		},
+	}
+
+	assert.Equal(t, map[int]int{1: 2, 2: 0, 6: 1, 7: 2}, db.CompactBookmarkSlots())
+	assert.Equal(t, 2, db.Bookmark[1].Slot)
+	assert.Equal(t, 0, db.Bookmark[2].Slot)
+	assert.Equal(t, 0, db.Bookmark[3].Slot)
+	assert.Equal(t, 1, db.Bookmark[4].Slot)
+	assert.Equal(t, 1, db.Bookmark[6].Slot)
+	assert.Equal(t, 2, db.Bookmark[7].Slot)
+
+	assert.Empty(t, db.CompactBookmarkSlots())
+	assert.Empty(t, (&Database{}).CompactBookmarkSlots())
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// userTagType is the type of tags created by the user
+// (in contrast to the Favorites tag)
+const userTagType = 1
+
+// NewNote creates a Note with the given title and content in the Database.
+// If a keySymbol is given, the Note is attached to the Bible chapter (or
+// verse, if it is greater than 0) of that publication, reusing an existing
+// Location if possible. Without a keySymbol, the Note is created without a
+// Location. The Note is tagged with the given tags, which are created if
+// they don't exist yet.
+func (db *Database) NewNote(keySymbol string, issue int, lang int, book int, chapter int, verse int,
+	title string, content string, tags ...string) (*Note, error) {
+	if keySymbol != "" && (book <= 0 || chapter <= 0) {
+		return nil, errors.Errorf("Note for %s needs a book and chapter", keySymbol)
+	}
+	guid, err := NewGUID()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(db.Note) == 0 {
+		db.Note = []*Note{nil}
+	}
+	note := &Note{
+		NoteID:       len(db.Note),
+		GUID:         guid,
+		Title:        sql.NullString{String: title, Valid: true},
+		Content:      sql.NullString{String: content, Valid: true},
+		LastModified: exportTime().Format("2006-01-02T15:04:05-07:00"),
+	}
+
+	if keySymbol != "" {
+		location := db.findOrCreateLocation(&Location{
+			BookNumber:     sql.NullInt32{Int32: int32(book), Valid: true},
+			ChapterNumber:  sql.NullInt32{Int32: int32(chapter), Valid: true},
+			IssueTagNumber: issue,
+			KeySymbol:      sql.NullString{String: keySymbol, Valid: true},
+			MepsLanguage:   lang,
+		})
+		note.LocationID = sql.NullInt32{Int32: int32(location.LocationID), Valid: true}
+		if verse > 0 {
+			note.BlockType = 2
+			note.BlockIdentifier = sql.NullInt32{Int32: int32(verse), Valid: true}
+		}
+	}
+	db.Note = append(db.Note, note)
+
+	seen := map[string]bool{}
+	for _, name := range tags {
+		name = NormalizeTagName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		db.tagNote(db.findOrCreateTag(name), note)
+	}
+
+	return note, nil
+}
+
+// findOrCreateLocation returns the Location of the Database that
+// equals the given one, or adds the given one if there is none.
+func (db *Database) findOrCreateLocation(location *Location) *Location {
+	for _, existing := range db.Location {
+		if existing != nil && existing.UniqueKey() == location.UniqueKey() {
+			return existing
+		}
+	}
+
+	if len(db.Location) == 0 {
+		db.Location = []*Location{nil}
+	}
+	location.LocationID = len(db.Location)
+	db.Location = append(db.Location, location)
+	return location
+}
+
+// findOrCreateTag returns the user Tag with the given
+// name, or creates it if it doesn't exist yet.
+func (db *Database) findOrCreateTag(name string) *Tag {
+	for _, existing := range db.Tag {
+		if existing != nil && existing.TagType == userTagType && NormalizeTagName(existing.Name) == name {
+			return existing
+		}
+	}
+
+	if len(db.Tag) == 0 {
+		db.Tag = []*Tag{nil}
+	}
+	tag := &Tag{
+		TagID:   len(db.Tag),
+		TagType: userTagType,
+		Name:    name,
+	}
+	db.Tag = append(db.Tag, tag)
+	return tag
+}
+
+// tagNote adds a TagMap for the Note at the end of the Tag
+func (db *Database) tagNote(tag *Tag, note *Note) {
+	position := 0
+	for _, tm := range db.TagMap {
+		if tm != nil && tm.TagID == tag.TagID && tm.Position >= position {
+			position = tm.Position + 1
+		}
+	}
+
+	if len(db.TagMap) == 0 {
+		db.TagMap = []*TagMap{nil}
+	}
+	db.TagMap = append(db.TagMap, &TagMap{
+		TagMapID: len(db.TagMap),
+		NoteID:   sql.NullInt32{Int32: int32(note.NoteID), Valid: true},
+		TagID:    tag.TagID,
+		Position: position,
+	})
+}
+
+// NewGUID generates a random (version 4) UUID in
+// the uppercase format JW Library uses for GUIDs.
+func NewGUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "Error while generating GUID")
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
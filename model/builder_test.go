@@ -0,0 +1,81 @@
+package model
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_NewNote(t *testing.T) {
+	db := &Database{}
+
+	first, err := db.NewNote("nwtsty", 0, 2, 1, 1, 3, "Light", "Let there be light", "Genesis", " Talks / 2024 ")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, first.NoteID)
+	assert.Len(t, first.GUID, 36)
+	assert.Equal(t, sql.NullString{String: "Light", Valid: true}, first.Title)
+	assert.Equal(t, sql.NullString{String: "Let there be light", Valid: true}, first.Content)
+	assert.Equal(t, sql.NullInt32{Int32: 1, Valid: true}, first.LocationID)
+	assert.Equal(t, 2, first.BlockType)
+	assert.Equal(t, sql.NullInt32{Int32: 3, Valid: true}, first.BlockIdentifier)
+
+	second, err := db.NewNote("nwtsty", 0, 2, 1, 1, 0, "Creation", "", "Genesis")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, second.NoteID)
+	assert.Equal(t, first.LocationID, second.LocationID)
+	assert.Equal(t, 0, second.BlockType)
+	assert.False(t, second.BlockIdentifier.Valid)
+
+	third, err := db.NewNote("", 0, 0, 0, 0, 0, "Shopping list", "Bread")
+	assert.NoError(t, err)
+	assert.False(t, third.LocationID.Valid)
+
+	assert.Equal(t, []*Location{
+		nil,
+		{
+			LocationID:    1,
+			BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+			KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			MepsLanguage:  2,
+		},
+	}, db.Location)
+	assert.Equal(t, []*Tag{
+		nil,
+		{TagID: 1, TagType: 1, Name: "Genesis"},
+		{TagID: 2, TagType: 1, Name: "Talks/2024"},
+	}, db.Tag)
+	assert.Equal(t, []*TagMap{
+		nil,
+		{TagMapID: 1, NoteID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 1, Position: 0},
+		{TagMapID: 2, NoteID: sql.NullInt32{Int32: 1, Valid: true}, TagID: 2, Position: 0},
+		{TagMapID: 3, NoteID: sql.NullInt32{Int32: 2, Valid: true}, TagID: 1, Position: 1},
+	}, db.TagMap)
+
+	_, err = db.NewNote("nwtsty", 0, 2, 1, 0, 0, "Invalid", "")
+	assert.EqualError(t, err, "Note for nwtsty needs a book and chapter")
+	assert.Len(t, db.Note, 4)
+
+	// The created entries can be exported
+	db.Bookmark = []*Bookmark{nil}
+	db.BlockRange = []*BlockRange{nil}
+	db.UserMark = []*UserMark{nil}
+	filename := filepath.Join(t.TempDir(), "notes.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(filename))
+	imported := &Database{}
+	assert.NoError(t, imported.ImportJWLBackup(filename))
+	assert.True(t, db.Equals(imported))
+}
+
+func TestNewGUID(t *testing.T) {
+	first, err := NewGUID()
+	assert.NoError(t, err)
+	second, err := NewGUID()
+	assert.NoError(t, err)
+	assert.Len(t, first, 36)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, strings.ToUpper(first), first)
+}
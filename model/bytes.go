@@ -0,0 +1,46 @@
+package model
+
+import "bytes"
+
+// ImportJWLBackupFromBytes imports the content of a .jwlibrary backup,
+// like the data file pickers of mobile platforms hand over, so it does
+// not have to be stored as file first.
+func (db *Database) ImportJWLBackupFromBytes(data []byte) error {
+	return db.ImportJWLBackupFromReaderAt(bytes.NewReader(data), int64(len(data)))
+}
+
+// ExportJWLBackupToBytes creates a .jwlibrary backup out of
+// the Database and returns its content instead of storing it.
+func (db *Database) ExportJWLBackupToBytes() ([]byte, error) {
+	return db.ExportJWLBackupToBytesWithOptions(ExportOptions{})
+}
+
+// ExportJWLBackupToBytesWithOptions works like ExportJWLBackupToBytes,
+// but uses the given options like ExportJWLBackupWithOptions does.
+func (db *Database) ExportJWLBackupToBytesWithOptions(opts ExportOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	// The backup is verified using buf instead of a copy of it
+	verify := opts.Verify
+	opts.Verify = false
+	if err := db.ExportJWLBackupToWriter(&buf, opts); err != nil {
+		return nil, err
+	}
+	if verify {
+		if err := db.verifyExportedBytes(buf.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadThumbnailFromBytes reads the PNG thumbnail of the given backup
+// content like ReadThumbnail does.
+func ReadThumbnailFromBytes(data []byte) ([]byte, error) {
+	return ReadThumbnailFromReaderAt(bytes.NewReader(data), int64(len(data)))
+}
+
+// ReadMediaFromBytes reads the media files of the given backup
+// content like ReadMedia does.
+func ReadMediaFromBytes(data []byte) (map[string][]byte, error) {
+	return ReadMediaFromReaderAt(bytes.NewReader(data), int64(len(data)))
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_ImportExportJWLBackupBytes(t *testing.T) {
+	// Exports within different seconds would have different timestamps
+	os.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	path := filepath.Join("testdata", "backup.jwlibrary")
+	expected := &Database{}
+	assert.NoError(t, expected.ImportJWLBackup(path))
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	db := &Database{}
+	assert.NoError(t, db.ImportJWLBackupFromBytes(data))
+	assert.True(t, expected.Equals(db))
+	assert.Equal(t, expected.DeviceName(), db.DeviceName())
+
+	exported, err := db.ExportJWLBackupToBytes()
+	assert.NoError(t, err)
+	reimported := &Database{}
+	assert.NoError(t, reimported.ImportJWLBackupFromBytes(exported))
+	assert.True(t, db.Equals(reimported))
+
+	// Exporting the same content results in the same backup
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "backup.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(filename))
+	fromFile, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, fromFile, exported)
+
+	thumbnail := []byte("thumbnail")
+	media := map[string][]byte{"picture.jpg": []byte("picture")}
+	exported, err = db.ExportJWLBackupToBytesWithOptions(ExportOptions{
+		CompressionLevel: StoreOnly,
+		Thumbnail:        thumbnail,
+		Media:            media,
+		Verify:           true,
+	})
+	assert.NoError(t, err)
+	readThumbnail, err := ReadThumbnailFromBytes(exported)
+	assert.NoError(t, err)
+	assert.Equal(t, thumbnail, readThumbnail)
+	readMedia, err := ReadMediaFromBytes(exported)
+	assert.NoError(t, err)
+	assert.Equal(t, media, readMedia)
+
+	_, err = db.ExportJWLBackupToBytesWithOptions(ExportOptions{CompressionLevel: 10})
+	assert.Error(t, err)
+
+	assert.Error(t, (&Database{}).ImportJWLBackupFromBytes([]byte("no backup")))
+	_, err = ReadThumbnailFromBytes(nil)
+	assert.Error(t, err)
+	_, err = ReadMediaFromBytes(nil)
+	assert.Error(t, err)
+}
+
+func TestDatabase_ImportJWLBackupFromBytes_damaged(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	corrupted := append([]byte{}, data...)
+	for i := 1000; i < 1100; i++ {
+		corrupted[i] = 0
+	}
+
+	tests := map[string][]byte{
+		"empty":            {},
+		"truncated":        data[:len(data)/2],
+		"missing one byte": data[:len(data)-1],
+		"corrupted":        corrupted,
+	}
+	for name, damaged := range tests {
+		t.Run(name, func(t *testing.T) {
+			db := &Database{}
+			err := db.ImportJWLBackupFromBytes(damaged)
+			assert.True(t, errors.Is(err, ErrBackupDamaged), err)
+		})
+	}
+
+	_, err = ReadMediaFromBytes(tests["truncated"])
+	assert.True(t, errors.Is(err, ErrBackupDamaged), err)
+	_, err = ReadThumbnailFromBytes(tests["truncated"])
+	assert.True(t, errors.Is(err, ErrBackupDamaged), err)
+}
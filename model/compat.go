@@ -0,0 +1,186 @@
+package model
+
+import (
+	"archive/zip"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CompatibilityIssue describes a difference between two backups that
+// might cause problems when merging them or using the merged backup
+type CompatibilityIssue struct {
+	// Fatal is true if the backups can't be merged at all
+	Fatal   bool
+	Message string
+}
+
+// String returns the message of the issue
+func (i CompatibilityIssue) String() string {
+	return i.Message
+}
+
+// CheckCompatibility compares the manifest and schema versions, the type
+// of backup, and the languages of the left and right backup with each
+// other and with the merged backup go-jwlm would create. It is meant to
+// be called before merging, so users can be warned early on.
+func CheckCompatibility(leftFilename string, rightFilename string) ([]CompatibilityIssue, error) {
+	var issues []CompatibilityIssue
+	sides := []struct {
+		name     string
+		filename string
+	}{
+		{"left", leftFilename},
+		{"right", rightFilename},
+	}
+
+	manifests := make([]*manifest, len(sides))
+	for i, side := range sides {
+		mfst, err := readBackupManifest(side.filename)
+		if err != nil {
+			return nil, err
+		}
+		manifests[i] = mfst
+		issues = append(issues, checkManifest(side.name, mfst)...)
+	}
+
+	left := manifests[0].UserDataBackup.SchemaVersion
+	right := manifests[1].UserDataBackup.SchemaVersion
+	if left != right {
+		newer, older := "right", "left"
+		newerVersion, olderVersion := right, left
+		if left > right {
+			newer, older = "left", "right"
+			newerVersion, olderVersion = left, right
+		}
+		issues = append(issues, CompatibilityIssue{
+			Message: fmt.Sprintf("The %s backup uses a newer schema than the %s one (%d and %d), "+
+				"so the device of the %s backup might not be able to read the merged backup. "+
+				"Update JW Library on all devices before merging",
+				newer, older, newerVersion, olderVersion, older),
+		})
+	}
+
+	for _, issue := range issues {
+		if issue.Fatal {
+			// Without a supported schema, we can't look into the backups
+			return issues, nil
+		}
+	}
+
+	languages := make([]map[int]bool, len(sides))
+	for i, side := range sides {
+		langs, err := backupLanguages(side.filename)
+		if err != nil {
+			return nil, err
+		}
+		languages[i] = langs
+	}
+	for i, side := range sides {
+		var only []int
+		for lang := range languages[i] {
+			if !languages[1-i][lang] {
+				only = append(only, lang)
+			}
+		}
+		if len(only) == 0 {
+			continue
+		}
+		sort.Ints(only)
+		langs := make([]string, len(only))
+		for j, lang := range only {
+			langs[j] = strconv.Itoa(lang)
+		}
+		issues = append(issues, CompatibilityIssue{
+			Message: fmt.Sprintf("Only the %s backup contains entries of the MepsLanguage(s) %s, "+
+				"so they are taken over as they are", side.name, strings.Join(langs, ", ")),
+		})
+	}
+
+	return issues, nil
+}
+
+// checkManifest checks if the manifest of a side is compatible
+// with the merged backup go-jwlm creates
+func checkManifest(side string, mfst *manifest) []CompatibilityIssue {
+	var issues []CompatibilityIssue
+
+	if mfst.Version != supportedManifestVersion {
+		issues = append(issues, CompatibilityIssue{
+			Fatal: true,
+			Message: fmt.Sprintf("The %s backup has the manifest version %d, but only %d is supported",
+				side, mfst.Version, supportedManifestVersion),
+		})
+	}
+
+	schema := mfst.UserDataBackup.SchemaVersion
+	if schema > supportedSchemaVersion {
+		issues = append(issues, CompatibilityIssue{
+			Fatal: true,
+			Message: fmt.Sprintf("The %s backup uses a newer schema (%d) than go-jwlm supports (%d). "+
+				"Please check for a new version of go-jwlm", side, schema, supportedSchemaVersion),
+		})
+	} else if schema < supportedSchemaVersion {
+		issues = append(issues, CompatibilityIssue{
+			Fatal: true,
+			Message: fmt.Sprintf("The %s backup uses an older schema (%d) than go-jwlm supports (%d). "+
+				"Please update JW Library on the device the backup has been created with", side, schema, supportedSchemaVersion),
+		})
+	}
+
+	if mfst.Type != 0 {
+		issues = append(issues, CompatibilityIssue{
+			Message: fmt.Sprintf("The %s backup has the unknown type %d and might have been created "+
+				"by a newer version of JW Library", side, mfst.Type),
+		})
+	}
+
+	return issues
+}
+
+// readBackupManifest reads the manifest of the given backup file
+// without extracting the backup
+func readBackupManifest(filename string) (*manifest, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, errors.Wrapf(damagedBackupError(err), "Error while opening backup %s", filename)
+	}
+	defer r.Close()
+
+	for _, file := range r.File {
+		if file.Name != manifestFilename {
+			continue
+		}
+
+		blob, err := readZipFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error while reading manifest")
+		}
+		return parseManifest(blob)
+	}
+
+	return nil, errors.Errorf("Backup %s does not contain a manifest", filename)
+}
+
+// backupLanguages returns the MepsLanguages of the
+// Locations of the given backup file
+func backupLanguages(filename string) (map[int]bool, error) {
+	stream, err := OpenBackupStream(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	languages := map[int]bool{}
+	err = stream.ForEachLocation(func(l *Location) error {
+		languages[l.MepsLanguage] = true
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while reading locations")
+	}
+	return languages, nil
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	left := filepath.Join(tmp, "left.jwlibrary")
+	assert.NoError(t, (&Database{
+		Location: []*Location{nil, {LocationID: 1, MepsLanguage: 2, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}, BookNumber: sql.NullInt32{Int32: 1, Valid: true}, ChapterNumber: sql.NullInt32{Int32: 1, Valid: true}}},
+	}).ExportJWLBackup(left))
+	right := filepath.Join(tmp, "right.jwlibrary")
+	assert.NoError(t, (&Database{
+		Location: []*Location{
+			nil,
+			{LocationID: 1, MepsLanguage: 2, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}, BookNumber: sql.NullInt32{Int32: 1, Valid: true}, ChapterNumber: sql.NullInt32{Int32: 1, Valid: true}},
+			{LocationID: 2, MepsLanguage: 4, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}, BookNumber: sql.NullInt32{Int32: 1, Valid: true}, ChapterNumber: sql.NullInt32{Int32: 2, Valid: true}},
+			{LocationID: 3, MepsLanguage: 1, KeySymbol: sql.NullString{String: "nwtsty", Valid: true}, BookNumber: sql.NullInt32{Int32: 1, Valid: true}, ChapterNumber: sql.NullInt32{Int32: 3, Valid: true}},
+		},
+	}).ExportJWLBackup(right))
+
+	issues, err := CheckCompatibility(left, left)
+	assert.NoError(t, err)
+	assert.Empty(t, issues)
+
+	issues, err = CheckCompatibility(left, right)
+	assert.NoError(t, err)
+	assert.Equal(t, []CompatibilityIssue{
+		{Message: "Only the right backup contains entries of the MepsLanguage(s) 1, 4, so they are taken over as they are"},
+	}, issues)
+
+	newer := filepath.Join(tmp, "newer.jwlibrary")
+	writeManifestOnlyBackup(t, newer, manifest{Version: 1, UserDataBackup: userDataBackup{SchemaVersion: 9}})
+	issues, err = CheckCompatibility(left, newer)
+	assert.NoError(t, err)
+	assert.Equal(t, []CompatibilityIssue{
+		{
+			Fatal:   true,
+			Message: "The right backup uses a newer schema (9) than go-jwlm supports (8). Please check for a new version of go-jwlm",
+		},
+		{
+			Message: "The right backup uses a newer schema than the left one (9 and 8), so the device of the left backup " +
+				"might not be able to read the merged backup. Update JW Library on all devices before merging",
+		},
+	}, issues)
+
+	older := filepath.Join(tmp, "older.jwlibrary")
+	writeManifestOnlyBackup(t, older, manifest{Version: 2, Type: 1, UserDataBackup: userDataBackup{SchemaVersion: 7}})
+	issues, err = CheckCompatibility(older, left)
+	assert.NoError(t, err)
+	assert.Len(t, issues, 4)
+	assert.Equal(t, "The left backup has the manifest version 2, but only 1 is supported", issues[0].String())
+	assert.True(t, issues[0].Fatal)
+	assert.Contains(t, issues[1].Message, "The left backup uses an older schema (7)")
+	assert.True(t, issues[1].Fatal)
+	assert.Equal(t, "The left backup has the unknown type 1 and might have been created by a newer version of JW Library", issues[2].Message)
+	assert.False(t, issues[2].Fatal)
+	assert.Contains(t, issues[3].Message, "The right backup uses a newer schema than the left one (8 and 7)")
+
+	_, err = CheckCompatibility(left, filepath.Join(tmp, "nonexistent.jwlibrary"))
+	assert.Error(t, err)
+
+	noManifest := filepath.Join(tmp, "noManifest.jwlibrary")
+	f, err := os.Create(noManifest)
+	assert.NoError(t, err)
+	assert.NoError(t, zip.NewWriter(f).Close())
+	assert.NoError(t, f.Close())
+	_, err = CheckCompatibility(noManifest, left)
+	assert.EqualError(t, err, "Backup "+noManifest+" does not contain a manifest")
+}
+
+// writeManifestOnlyBackup writes a backup at path that
+// only contains the given manifest
+func writeManifestOnlyBackup(t *testing.T, path string, mfst manifest) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	entry, err := w.Create(manifestFilename)
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewEncoder(entry).Encode(mfst))
+	assert.NoError(t, w.Close())
+}
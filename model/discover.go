@@ -0,0 +1,115 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// mergedDeviceName is the device name of backups created by go-jwlm
+const mergedDeviceName = "go-jwlm"
+
+// DiscoveredBackup is a backup found by DiscoverBackups
+type DiscoveredBackup struct {
+	Filename string
+	Manifest *Manifest
+	// LastModified is the modification date of the manifest or,
+	// if it doesn't contain one, of the file
+	LastModified time.Time
+}
+
+// BackupLocations returns the folders JW Library backups are usually
+// exported or downloaded to on the current OS, like the Downloads
+// folder or the iCloud Drive. Only folders that exist are returned.
+func BackupLocations() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	var existing []string
+	for _, dir := range backupLocations(runtime.GOOS, home, os.Getenv) {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			existing = append(existing, dir)
+		}
+	}
+	return existing
+}
+
+// backupLocations returns the common backup locations for the given OS
+func backupLocations(goos string, home string, getenv func(string) string) []string {
+	dirs := []string{
+		filepath.Join(home, "Downloads"),
+		filepath.Join(home, "Desktop"),
+		filepath.Join(home, "Documents"),
+	}
+
+	switch goos {
+	case "darwin":
+		dirs = append(dirs, filepath.Join(home, "Library", "Mobile Documents", "com~apple~CloudDocs"))
+	case "windows":
+		if oneDrive := getenv("OneDrive"); oneDrive != "" {
+			dirs = append(dirs, oneDrive, filepath.Join(oneDrive, "Documents"))
+		} else {
+			dirs = append(dirs, filepath.Join(home, "OneDrive"), filepath.Join(home, "OneDrive", "Documents"))
+		}
+	default:
+		if xdg := getenv("XDG_DOWNLOAD_DIR"); xdg != "" {
+			dirs = append([]string{xdg}, dirs...)
+		}
+	}
+
+	return dirs
+}
+
+// DiscoverBackups looks for .jwlibrary backups within the given folders
+// and returns the newest backup of every device, sorted by their
+// modification date with the newest first. Backups created by go-jwlm and
+// files that are not valid backups are skipped, as are subfolders.
+func DiscoverBackups(dirs ...string) ([]DiscoveredBackup, error) {
+	newest := map[string]DiscoveredBackup{}
+	for _, dir := range dirs {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error while reading %s", dir)
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !strings.EqualFold(filepath.Ext(file.Name()), ".jwlibrary") {
+				continue
+			}
+			filename := filepath.Join(dir, file.Name())
+			mfst, err := ReadManifest(filename)
+			if err != nil || mfst.DeviceName == mergedDeviceName {
+				continue
+			}
+
+			backup := DiscoveredBackup{Filename: filename, Manifest: mfst, LastModified: mfst.LastModified}
+			if backup.LastModified.IsZero() {
+				backup.LastModified = file.ModTime()
+			}
+			if existing, ok := newest[mfst.DeviceName]; !ok || backup.LastModified.After(existing.LastModified) {
+				newest[mfst.DeviceName] = backup
+			}
+		}
+	}
+
+	result := make([]DiscoveredBackup, 0, len(newest))
+	for _, backup := range newest {
+		result = append(result, backup)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].LastModified.Equal(result[j].LastModified) {
+			return result[i].LastModified.After(result[j].LastModified)
+		}
+		return result[i].Filename < result[j].Filename
+	})
+
+	return result, nil
+}
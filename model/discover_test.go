@@ -0,0 +1,83 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// deviceManifest returns a manifest of a backup created on the given device
+func deviceManifest(deviceName string, lastModified string) manifest {
+	return manifest{
+		UserDataBackup: userDataBackup{
+			DeviceName:       deviceName,
+			LastModifiedDate: lastModified,
+			DatabaseName:     "user_data.db",
+			SchemaVersion:    supportedSchemaVersion,
+		},
+		Version: supportedManifestVersion,
+	}
+}
+
+func TestDiscoverBackups(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	phone := filepath.Join(tmp, "phone")
+	tablet := filepath.Join(tmp, "tablet")
+	assert.NoError(t, os.Mkdir(phone, 0755))
+	assert.NoError(t, os.Mkdir(tablet, 0755))
+
+	writeManifestOnlyBackup(t, filepath.Join(phone, "old.jwlibrary"), deviceManifest("iPhone", "2021-04-01T10:00:00+02:00"))
+	writeManifestOnlyBackup(t, filepath.Join(phone, "new.JWLIBRARY"), deviceManifest("iPhone", "2021-05-01T10:00:00+02:00"))
+	writeManifestOnlyBackup(t, filepath.Join(tablet, "tablet.jwlibrary"), deviceManifest("iPad", "2021-05-03T10:00:00+02:00"))
+	writeManifestOnlyBackup(t, filepath.Join(tablet, "merged.jwlibrary"), deviceManifest(mergedDeviceName, "2021-05-04T10:00:00+02:00"))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tablet, "broken.jwlibrary"), []byte("broken"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(tablet, "notes.txt"), []byte("notes"), 0644))
+
+	backups, err := DiscoverBackups(phone, tablet)
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+	assert.Equal(t, filepath.Join(tablet, "tablet.jwlibrary"), backups[0].Filename)
+	assert.Equal(t, "iPad", backups[0].Manifest.DeviceName)
+	assert.Equal(t, filepath.Join(phone, "new.JWLIBRARY"), backups[1].Filename)
+	assert.True(t, time.Date(2021, 5, 1, 8, 0, 0, 0, time.UTC).Equal(backups[1].LastModified))
+
+	backups, err = DiscoverBackups()
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+
+	_, err = DiscoverBackups(filepath.Join(tmp, "nonexistent"))
+	assert.Error(t, err)
+}
+
+func Test_backupLocations(t *testing.T) {
+	getenv := func(env map[string]string) func(string) string {
+		return func(key string) string { return env[key] }
+	}
+	home := filepath.Join("home", "user")
+	common := []string{
+		filepath.Join(home, "Downloads"),
+		filepath.Join(home, "Desktop"),
+		filepath.Join(home, "Documents"),
+	}
+
+	assert.Equal(t, common, backupLocations("linux", home, getenv(nil)))
+	assert.Equal(t, append([]string{"xdg"}, common...),
+		backupLocations("linux", home, getenv(map[string]string{"XDG_DOWNLOAD_DIR": "xdg"})))
+	assert.Equal(t, append(common, filepath.Join(home, "Library", "Mobile Documents", "com~apple~CloudDocs")),
+		backupLocations("darwin", home, getenv(nil)))
+	assert.Equal(t, append(common, "od", filepath.Join("od", "Documents")),
+		backupLocations("windows", home, getenv(map[string]string{"OneDrive": "od"})))
+}
+
+func TestBackupLocations(t *testing.T) {
+	for _, dir := range BackupLocations() {
+		assert.DirExists(t, dir)
+	}
+}
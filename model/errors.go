@@ -0,0 +1,45 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrManifestOutdated indicates that the manifest of a backup has a
+// version go-jwlm does not support, which usually means that the backup
+// has been created with an older or newer version of JW Library.
+var ErrManifestOutdated = errors.New("Manifest version is incompatible")
+
+// ErrSchemaUnsupported indicates that the database of a
+// backup has a schema version go-jwlm does not support.
+var ErrSchemaUnsupported = errors.New("Schema version is incompatible")
+
+// ErrBackupDamaged indicates that a backup is incomplete or damaged,
+// like a backup that has not been downloaded completely, or contains
+// entries that can't be read.
+var ErrBackupDamaged = errors.New("Backup is damaged or incomplete")
+
+// ErrExportMismatch indicates that an exported backup does
+// not contain the same entries as the exported Database
+var ErrExportMismatch = errors.New("Exported backup does not match the database")
+
+// ErrHashMismatch indicates that the SHA256 of a file does not match
+// the expected one. Use errors.As to get a HashMismatchError with details.
+var ErrHashMismatch = errors.New("Checksum does not match")
+
+// HashMismatchError indicates that the SHA256 of a file does not match
+// the expected one. It matches ErrHashMismatch when using errors.Is.
+type HashMismatchError struct {
+	Name     string
+	Expected string
+	Actual   string
+}
+
+func (e HashMismatchError) Error() string {
+	return fmt.Sprintf("Checksum of %s does not match: expected %s, got %s", e.Name, e.Expected, e.Actual)
+}
+
+// Is reports whether target is ErrHashMismatch
+func (e HashMismatchError) Is(target error) bool {
+	return target == ErrHashMismatch
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// IsUserDBFromFS checks if the file with the given name within fsys
+// is a bare SQLite database like IsUserDB does.
+func IsUserDBFromFS(fsys fs.FS, name string) (bool, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return false, errors.Wrapf(err, "Error while opening %s", name)
+	}
+	defer f.Close()
+
+	isDB, err := hasSQLiteHeader(f)
+	if err != nil {
+		return false, errors.Wrapf(err, "Error while reading %s", name)
+	}
+	return isDB, nil
+}
+
+// ImportJWLBackupFromFS imports the .jwlibrary backup with the given name
+// within fsys, like files embedded using embed.FS, so backups don't have to
+// be stored on the disk. Files that don't support random access are read
+// into memory (see BufferReader).
+func (db *Database) ImportJWLBackupFromFS(fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return errors.Wrapf(err, "Error while opening %s", name)
+	}
+	defer f.Close()
+
+	db.Logger().Debugf("Importing %s", name)
+	return db.ImportJWLBackupFromReader(f)
+}
+
+// ImportUserDBFromFS imports the bare SQLite database with the given name
+// within fsys like ImportUserDB does. As SQLite can only open files on
+// the disk, the database is copied to a temporary file first.
+func (db *Database) ImportUserDBFromFS(fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return errors.Wrapf(err, "Error while opening %s", name)
+	}
+	defer f.Close()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return errors.Wrap(err, "Error while creating temporary directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	path := filepath.Join(tmp, "user_data.db")
+	target, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "Error while creating temporary database")
+	}
+	_, err = io.Copy(target, f)
+	target.Close()
+	if err != nil {
+		return errors.Wrapf(err, "Error while copying %s to temporary database", name)
+	}
+
+	return db.ImportUserDB(path)
+}
+
+// ReadThumbnailFromFS reads the PNG thumbnail of the backup with
+// the given name within fsys like ReadThumbnail does.
+func ReadThumbnailFromFS(fsys fs.FS, name string) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error while opening backup %s", name)
+	}
+	defer f.Close()
+
+	r, size, err := BufferReader(f)
+	if err != nil {
+		return nil, err
+	}
+	return ReadThumbnailFromReaderAt(r, size)
+}
+
+// ReadMediaFromFS reads the media files of the backup with
+// the given name within fsys like ReadMedia does.
+func ReadMediaFromFS(fsys fs.FS, name string) (map[string][]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error while opening backup %s", name)
+	}
+	defer f.Close()
+
+	r, size, err := BufferReader(f)
+	if err != nil {
+		return nil, err
+	}
+	return ReadMediaFromReaderAt(r, size)
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_ImportFromFS(t *testing.T) {
+	expected := &Database{}
+	assert.NoError(t, expected.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+
+	fsys := os.DirFS("testdata")
+	isDB, err := IsUserDBFromFS(fsys, "user_data.db")
+	assert.NoError(t, err)
+	assert.True(t, isDB)
+	isDB, err = IsUserDBFromFS(fsys, "backup.jwlibrary")
+	assert.NoError(t, err)
+	assert.False(t, isDB)
+
+	db := &Database{}
+	assert.NoError(t, db.ImportJWLBackupFromFS(fsys, "backup.jwlibrary"))
+	assert.True(t, expected.Equals(db))
+	assert.Equal(t, expected.DeviceName(), db.DeviceName())
+
+	db = &Database{}
+	assert.NoError(t, db.ImportUserDBFromFS(fsys, "user_data.db"))
+	assert.True(t, expected.Equals(db))
+
+	// Files of other file systems, like those held in memory, work as well
+	media := map[string][]byte{"picture.jpg": []byte("picture")}
+	exported, err := expected.ExportJWLBackupToBytesWithOptions(ExportOptions{Thumbnail: []byte("thumbnail"), Media: media})
+	assert.NoError(t, err)
+	mapFS := fstest.MapFS{"backup.jwlibrary": &fstest.MapFile{Data: exported}}
+	db = &Database{}
+	assert.NoError(t, db.ImportJWLBackupFromFS(mapFS, "backup.jwlibrary"))
+	assert.True(t, expected.Equals(db))
+	thumbnail, err := ReadThumbnailFromFS(mapFS, "backup.jwlibrary")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("thumbnail"), thumbnail)
+	readMedia, err := ReadMediaFromFS(mapFS, "backup.jwlibrary")
+	assert.NoError(t, err)
+	assert.Equal(t, media, readMedia)
+
+	for _, name := range []string{"missing.jwlibrary", "missing.db"} {
+		_, err = IsUserDBFromFS(fsys, name)
+		assert.Error(t, err)
+		assert.Error(t, db.ImportJWLBackupFromFS(fsys, name))
+		assert.Error(t, db.ImportUserDBFromFS(fsys, name))
+		_, err = ReadThumbnailFromFS(fsys, name)
+		assert.Error(t, err)
+		_, err = ReadMediaFromFS(fsys, name)
+		assert.Error(t, err)
+	}
+}
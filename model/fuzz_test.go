@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+package model
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// The fuzz targets make sure that crafted or damaged backups result in
+// errors instead of panics. Their seeds run with every `go test`, while
+// fuzzing is started for one target at a time, e.g. using
+// go test ./model -run '^$' -fuzz FuzzImportJWLBackupFromBytes
+
+// readFuzzSeed reads the given file of testdata
+func readFuzzSeed(f *testing.F, name string) []byte {
+	f.Helper()
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		f.Fatal(err)
+	}
+	return data
+}
+
+func FuzzParseManifest(f *testing.F) {
+	f.Add(readFuzzSeed(f, "manifest_correct.json"))
+	f.Add(readFuzzSeed(f, "manifest_outdated.json"))
+	f.Add([]byte(`{"userDataBackup": null}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		mfst, err := parseManifest(data)
+		if err != nil {
+			return
+		}
+		mfst.validateManifest()
+	})
+}
+
+func FuzzImportJWLBackupFromBytes(f *testing.F) {
+	backup := readFuzzSeed(f, "backup.jwlibrary")
+	f.Add(backup)
+	f.Add(backup[:len(backup)/2])
+	f.Add(backup[:len(backup)-1])
+	f.Add(readFuzzSeed(f, "backup_shuffled.jwlibrary"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		db := &Database{}
+		db.ImportJWLBackupFromBytes(data)
+		ReadMediaFromBytes(data)
+		ReadThumbnailFromBytes(data)
+	})
+}
+
+func FuzzImportUserDB(f *testing.F) {
+	userDB := readFuzzSeed(f, "user_data.db")
+	f.Add(userDB)
+	f.Add(userDB[:len(userDB)/2])
+	f.Add(readFuzzSeed(f, "error_playlistMedia.db"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "user_data.db")
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		db := &Database{}
+		db.ImportUserDB(path)
+	})
+}
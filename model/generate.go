@@ -0,0 +1,329 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TestDatabaseSpec describes the Database GenerateTestDatabase creates
+type TestDatabaseSpec struct {
+	// Notes, Highlights, and Bookmarks are the numbers of entries to create
+	Notes      int
+	Highlights int
+	Bookmarks  int
+	// Publications is the number of publications the entries are spread
+	// across. The first one is always the Bible. Defaults to 1.
+	Publications int
+	// Tags is the number of user tags the notes are tagged with
+	Tags int
+	// Seed determines the content, so the same spec always
+	// results in the same Database
+	Seed int64
+}
+
+// chaptersPerBook is the number of chapters of the books of the Bible
+var chaptersPerBook = []int{50, 40, 27, 36, 34, 24, 21, 4, 31, 24, 22, 25, 29, 36, 10, 13, 10, 42,
+	150, 31, 12, 8, 66, 52, 5, 48, 12, 14, 3, 9, 1, 4, 7, 3, 3, 3, 2, 14, 4, 28, 16, 24, 21, 28,
+	16, 16, 13, 6, 6, 4, 4, 5, 3, 6, 4, 3, 1, 13, 5, 5, 3, 5, 1, 1, 1, 22}
+
+// Number of blocks of generated publications that can be highlighted
+const (
+	bibleVerses        = 25
+	bibleBlocks        = 1189 * bibleVerses
+	documents          = 500
+	documentParagraphs = 60
+	documentBlocks     = documents * documentParagraphs
+)
+
+// generatedKeySymbols are the publications the entries are spread across
+// after the Bible. Publications with an issue are periodicals.
+var generatedKeySymbols = []struct {
+	keySymbol string
+	issue     int
+}{
+	{"w", 20210100}, {"g", 20210100}, {"lff", 0}, {"bh", 0}, {"rr", 0}, {"jy", 0},
+	{"lfb", 0}, {"ia", 0}, {"cf", 0}, {"w", 20210200}, {"w", 20210300}, {"g", 20210200},
+}
+
+// generatedWords are used for the titles and contents of generated notes
+var generatedWords = strings.Fields(`love faith hope kingdom peace joy patience kindness
+	goodness mildness self-control prayer study ministry family friend promise future
+	paradise wisdom truth light life spirit strength comfort courage humility mercy`)
+
+// generatedPublication is a publication entries are created in
+type generatedPublication struct {
+	keySymbol string
+	issue     int
+	// location is the Location of the publication
+	// itself, as it is used for bookmarks
+	location *Location
+}
+
+// GenerateTestDatabase fabricates a Database with the given number of
+// entries, so performance problems and edge cases of merging can be
+// reproduced without sharing personal backups. Entries are spread
+// randomly (based on spec.Seed) across the Bible and other publications,
+// and notes are attached to highlights or tagged like they would be in
+// JW Library.
+func GenerateTestDatabase(spec TestDatabaseSpec) (*Database, error) {
+	if spec.Notes < 0 || spec.Highlights < 0 || spec.Bookmarks < 0 || spec.Tags < 0 || spec.Publications < 0 {
+		return nil, errors.New("Numbers of generated entries must not be negative")
+	}
+	if spec.Publications == 0 {
+		spec.Publications = 1
+	}
+	if spec.Publications > len(generatedKeySymbols)+1 {
+		return nil, errors.Errorf("At most %d publications can be generated", len(generatedKeySymbols)+1)
+	}
+	if maxBookmarks := spec.Publications * 10; spec.Bookmarks > maxBookmarks {
+		return nil, errors.Errorf("Only %d bookmarks fit into the slots of the generated publications", maxBookmarks)
+	}
+	// Highlights must not overlap, so only a part of the blocks is used
+	if maxHighlights := (bibleBlocks + (spec.Publications-1)*documentBlocks) / 2; spec.Highlights > maxHighlights {
+		return nil, errors.Errorf("Only %d highlights fit into the generated publications", maxHighlights)
+	}
+
+	g := &generator{
+		db: &Database{
+			BlockRange: []*BlockRange{nil},
+			Bookmark:   []*Bookmark{nil},
+			Location:   []*Location{nil},
+			Note:       []*Note{nil},
+			Tag:        []*Tag{nil},
+			TagMap:     []*TagMap{nil},
+			UserMark:   []*UserMark{nil},
+		},
+		rng:          rand.New(rand.NewSource(spec.Seed)),
+		locations:    map[string]*Location{},
+		blocks:       map[string]bool{},
+		notedMarks:   map[int]bool{},
+		bookmarked:   map[string]bool{},
+		tagPositions: map[int]int{},
+	}
+	g.addPublications(spec.Publications)
+	g.addTags(spec.Tags)
+	for i := 0; i < spec.Highlights; i++ {
+		g.addHighlight()
+	}
+	for i := 0; i < spec.Notes; i++ {
+		g.addNote()
+	}
+	for i := 0; i < spec.Bookmarks; i++ {
+		g.addBookmark(i)
+	}
+
+	return g.db, nil
+}
+
+// generator holds the state of GenerateTestDatabase
+type generator struct {
+	db           *Database
+	rng          *rand.Rand
+	publications []generatedPublication
+	// locations contains the Locations by their UniqueKey, and
+	// blocks the highlighted blocks, so they don't overlap
+	locations map[string]*Location
+	blocks    map[string]bool
+	// notedMarks are the UserMarks that already have a Note, and
+	// bookmarked the blocks of the Bookmarks of every publication
+	notedMarks map[int]bool
+	bookmarked map[string]bool
+	// tagPositions are the next free positions of the Tags
+	tagPositions map[int]int
+}
+
+// addPublications adds the Bible and count-1 other publications
+func (g *generator) addPublications(count int) {
+	g.publications = append(g.publications, generatedPublication{keySymbol: "nwtsty"})
+	for _, p := range generatedKeySymbols[:count-1] {
+		g.publications = append(g.publications, generatedPublication{keySymbol: p.keySymbol, issue: p.issue})
+	}
+	for i := range g.publications {
+		p := &g.publications[i]
+		p.location = g.location(&Location{
+			IssueTagNumber: p.issue,
+			KeySymbol:      sql.NullString{String: p.keySymbol, Valid: true},
+			LocationType:   1,
+		})
+	}
+}
+
+// addTags adds count user tags
+func (g *generator) addTags(count int) {
+	for i := 1; i <= count; i++ {
+		g.db.Tag = append(g.db.Tag, &Tag{
+			TagID:   len(g.db.Tag),
+			TagType: userTagType,
+			Name:    fmt.Sprintf("%s %d", strings.Title(g.word()), i),
+		})
+	}
+}
+
+// addHighlight adds a UserMark with a BlockRange on a block that
+// has not been highlighted yet
+func (g *generator) addHighlight() {
+	location, blockType, identifier := g.block()
+	for g.blocks[fmt.Sprintf("%d_%d", location.LocationID, identifier)] {
+		location, blockType, identifier = g.block()
+	}
+	g.blocks[fmt.Sprintf("%d_%d", location.LocationID, identifier)] = true
+
+	userMark := &UserMark{
+		UserMarkID:   len(g.db.UserMark),
+		ColorIndex:   1 + g.rng.Intn(6),
+		LocationID:   location.LocationID,
+		UserMarkGUID: g.guid(),
+		Version:      1,
+	}
+	g.db.UserMark = append(g.db.UserMark, userMark)
+
+	start := g.rng.Intn(20)
+	g.db.BlockRange = append(g.db.BlockRange, &BlockRange{
+		BlockRangeID: len(g.db.BlockRange),
+		BlockType:    blockType,
+		Identifier:   identifier,
+		StartToken:   sql.NullInt32{Int32: int32(start), Valid: true},
+		EndToken:     sql.NullInt32{Int32: int32(start + g.rng.Intn(30)), Valid: true},
+		UserMarkID:   userMark.UserMarkID,
+	})
+}
+
+// addNote adds a Note, which is attached to a highlight, a block, or
+// to nothing at all. Some notes are tagged.
+func (g *generator) addNote() {
+	note := &Note{
+		NoteID:       len(g.db.Note),
+		GUID:         g.guid(),
+		Title:        sql.NullString{String: strings.Title(g.words(1 + g.rng.Intn(4))), Valid: true},
+		Content:      sql.NullString{String: g.words(5 + g.rng.Intn(60)), Valid: true},
+		LastModified: g.time().Format("2006-01-02T15:04:05-07:00"),
+	}
+
+	r := g.rng.Intn(10)
+	var userMark *UserMark
+	if r < 5 && len(g.db.UserMark) > 1 {
+		userMark = g.db.UserMark[1+g.rng.Intn(len(g.db.UserMark)-1)]
+	}
+	switch {
+	case userMark != nil && !g.notedMarks[userMark.UserMarkID]:
+		g.notedMarks[userMark.UserMarkID] = true
+		blockRange := g.db.BlockRange[userMark.UserMarkID]
+		note.UserMarkID = sql.NullInt32{Int32: int32(userMark.UserMarkID), Valid: true}
+		note.LocationID = sql.NullInt32{Int32: int32(userMark.LocationID), Valid: true}
+		note.BlockType = blockRange.BlockType
+		note.BlockIdentifier = sql.NullInt32{Int32: int32(blockRange.Identifier), Valid: true}
+	case r < 9:
+		location, blockType, identifier := g.block()
+		note.LocationID = sql.NullInt32{Int32: int32(location.LocationID), Valid: true}
+		note.BlockType = blockType
+		note.BlockIdentifier = sql.NullInt32{Int32: int32(identifier), Valid: true}
+	}
+	g.db.Note = append(g.db.Note, note)
+
+	if len(g.db.Tag) > 1 && g.rng.Intn(3) == 0 {
+		// Database.tagNote would have to look at all TagMaps
+		tagID := 1 + g.rng.Intn(len(g.db.Tag)-1)
+		g.db.TagMap = append(g.db.TagMap, &TagMap{
+			TagMapID: len(g.db.TagMap),
+			NoteID:   sql.NullInt32{Int32: int32(note.NoteID), Valid: true},
+			TagID:    tagID,
+			Position: g.tagPositions[tagID],
+		})
+		g.tagPositions[tagID]++
+	}
+}
+
+// addBookmark adds the bookmark with the given index. Bookmarks fill
+// the slots of one publication after another.
+func (g *generator) addBookmark(index int) {
+	p := g.publications[index/10]
+	location, blockType, identifier := g.blockOf(p)
+	for g.bookmarked[fmt.Sprintf("%d_%d_%d", p.location.LocationID, location.LocationID, identifier)] {
+		location, blockType, identifier = g.blockOf(p)
+	}
+	g.bookmarked[fmt.Sprintf("%d_%d_%d", p.location.LocationID, location.LocationID, identifier)] = true
+	g.db.Bookmark = append(g.db.Bookmark, &Bookmark{
+		BookmarkID:            len(g.db.Bookmark),
+		LocationID:            location.LocationID,
+		PublicationLocationID: p.location.LocationID,
+		Slot:                  index % 10,
+		Title:                 strings.Title(g.words(2)),
+		Snippet:               sql.NullString{String: g.words(20), Valid: true},
+		BlockType:             blockType,
+		BlockIdentifier:       sql.NullInt32{Int32: int32(identifier), Valid: true},
+	})
+}
+
+// block returns a random block of a random publication
+func (g *generator) block() (*Location, int, int) {
+	return g.blockOf(g.publications[g.rng.Intn(len(g.publications))])
+}
+
+// blockOf returns a random block of the given publication together
+// with the Location it is part of. Blocks of the Bible are verses
+// (BlockType 2), blocks of other publications are paragraphs.
+func (g *generator) blockOf(p generatedPublication) (*Location, int, int) {
+	if p.keySymbol == "nwtsty" {
+		book := 1 + g.rng.Intn(len(chaptersPerBook))
+		chapter := 1 + g.rng.Intn(chaptersPerBook[book-1])
+		location := g.location(&Location{
+			BookNumber:    sql.NullInt32{Int32: int32(book), Valid: true},
+			ChapterNumber: sql.NullInt32{Int32: int32(chapter), Valid: true},
+			KeySymbol:     sql.NullString{String: p.keySymbol, Valid: true},
+		})
+		return location, 2, 1 + g.rng.Intn(bibleVerses)
+	}
+
+	location := g.location(&Location{
+		DocumentID:     sql.NullInt32{Int32: int32(1102021000 + g.rng.Intn(documents)), Valid: true},
+		IssueTagNumber: p.issue,
+		KeySymbol:      sql.NullString{String: p.keySymbol, Valid: true},
+	})
+	return location, 1, 1 + g.rng.Intn(documentParagraphs)
+}
+
+// location returns the existing Location that equals the
+// given one, or adds the given one if there is none.
+func (g *generator) location(location *Location) *Location {
+	if existing, ok := g.locations[location.UniqueKey()]; ok {
+		return existing
+	}
+	location.LocationID = len(g.db.Location)
+	g.db.Location = append(g.db.Location, location)
+	g.locations[location.UniqueKey()] = location
+	return location
+}
+
+// guid returns a random GUID like NewGUID, but based on the seed
+func (g *generator) guid() string {
+	b := make([]byte, 16)
+	g.rng.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%X-%X-%X-%X-%X", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// time returns a random time in 2020 or 2021
+func (g *generator) time() time.Time {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	return start.Add(time.Duration(g.rng.Int63n(int64(2 * 365 * 24 * time.Hour))))
+}
+
+// word returns a random word
+func (g *generator) word() string {
+	return generatedWords[g.rng.Intn(len(generatedWords))]
+}
+
+// words returns count random words separated by spaces
+func (g *generator) words(count int) string {
+	words := make([]string, count)
+	for i := range words {
+		words[i] = g.word()
+	}
+	return strings.Join(words, " ")
+}
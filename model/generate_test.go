@@ -0,0 +1,84 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateTestDatabase(t *testing.T) {
+	spec := TestDatabaseSpec{
+		Notes:        200,
+		Highlights:   300,
+		Bookmarks:    25,
+		Publications: 4,
+		Tags:         5,
+		Seed:         1,
+	}
+	db, err := GenerateTestDatabase(spec)
+	assert.NoError(t, err)
+	assert.Len(t, db.Note, 201)
+	assert.Len(t, db.UserMark, 301)
+	assert.Len(t, db.BlockRange, 301)
+	assert.Len(t, db.Bookmark, 26)
+	assert.Len(t, db.Tag, 6)
+	assert.NotEmpty(t, db.TagMap)
+
+	keySymbols := map[string]bool{}
+	for _, location := range db.Location[1:] {
+		keySymbols[location.KeySymbol.String] = true
+	}
+	assert.Len(t, keySymbols, 4)
+	assert.True(t, keySymbols["nwtsty"])
+
+	guids := map[string]bool{}
+	for _, note := range db.Note[1:] {
+		assert.False(t, guids[note.GUID])
+		guids[note.GUID] = true
+		if note.UserMarkID.Valid {
+			assert.Equal(t, int32(db.UserMark[note.UserMarkID.Int32].LocationID), note.LocationID.Int32)
+		}
+	}
+	slots := map[int]int{}
+	for _, bm := range db.Bookmark[1:] {
+		slots[bm.PublicationLocationID]++
+		assert.Less(t, bm.Slot, 10)
+	}
+	assert.Len(t, slots, 3)
+
+	// The same seed results in the same Database
+	same, err := GenerateTestDatabase(spec)
+	assert.NoError(t, err)
+	assert.True(t, db.Equals(same))
+	spec.Seed = 2
+	other, err := GenerateTestDatabase(spec)
+	assert.NoError(t, err)
+	assert.False(t, db.Equals(other))
+
+	// Generated databases can be exported like imported ones
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "generated.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(filename))
+	imported := &Database{}
+	assert.NoError(t, imported.ImportJWLBackup(filename))
+	assert.True(t, db.Equals(imported))
+
+	empty, err := GenerateTestDatabase(TestDatabaseSpec{})
+	assert.NoError(t, err)
+	assert.Len(t, empty.Location, 2)
+	assert.Len(t, empty.Note, 1)
+
+	_, err = GenerateTestDatabase(TestDatabaseSpec{Notes: -1})
+	assert.Error(t, err)
+	_, err = GenerateTestDatabase(TestDatabaseSpec{Publications: 100})
+	assert.Error(t, err)
+	_, err = GenerateTestDatabase(TestDatabaseSpec{Bookmarks: 11})
+	assert.EqualError(t, err, "Only 10 bookmarks fit into the slots of the generated publications")
+	_, err = GenerateTestDatabase(TestDatabaseSpec{Highlights: 1000000})
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const mergeHistoryFilename = "merge_history.json"
+
+// MergeHistory records from which backups a merged backup has been
+// created. It is stored next to the manifest within the backup.
+type MergeHistory struct {
+	ToolVersion string        `json:"toolVersion"`
+	Date        string        `json:"date"`
+	Sources     []MergeSource `json:"sources"`
+}
+
+// MergeSource describes a backup that has been merged. If the backup
+// itself is the result of a merge, its history is included.
+type MergeSource struct {
+	Name    string        `json:"name"`
+	Hash    string        `json:"hash"`
+	History *MergeHistory `json:"history,omitempty"`
+}
+
+// NewMergeHistory creates a MergeHistory for a merge of the given
+// backup files with the given version of go-jwlm.
+func NewMergeHistory(toolVersion string, backups ...string) (*MergeHistory, error) {
+	history := &MergeHistory{
+		ToolVersion: toolVersion,
+		Date:        exportTime().Format("2006-01-02T15:04:05-07:00"),
+		Sources:     make([]MergeSource, 0, len(backups)),
+	}
+
+	for _, backup := range backups {
+		hash, err := fileHash(backup)
+		if err != nil {
+			return nil, err
+		}
+		sourceHistory, err := ReadMergeHistory(backup)
+		if err != nil {
+			return nil, err
+		}
+		history.Sources = append(history.Sources, MergeSource{
+			Name:    filepath.Base(backup),
+			Hash:    hash,
+			History: sourceHistory,
+		})
+	}
+
+	return history, nil
+}
+
+// ReadMergeHistory reads the MergeHistory of the given backup file. If
+// the backup does not contain a history, it returns nil.
+func ReadMergeHistory(filename string) (*MergeHistory, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, errors.Wrapf(damagedBackupError(err), "Error while opening backup %s", filename)
+	}
+	defer r.Close()
+
+	for _, file := range r.File {
+		if file.Name != mergeHistoryFilename {
+			continue
+		}
+
+		fileReader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer fileReader.Close()
+
+		history := &MergeHistory{}
+		if err := json.NewDecoder(fileReader).Decode(history); err != nil {
+			return nil, errors.Wrap(err, "Error while decoding merge history")
+		}
+		return history, nil
+	}
+
+	return nil, nil
+}
+
+// exportMergeHistory exports the MergeHistory at path
+func (history *MergeHistory) exportMergeHistory(path string) error {
+	bytes, err := json.Marshal(history)
+	if err != nil {
+		return errors.Wrap(err, "Error while marshalling merge history")
+	}
+
+	if err := ioutil.WriteFile(path, bytes, 0644); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Error while saving merge history at %v", path))
+	}
+
+	return nil
+}
+
+// fileHash returns the SHA256 of the given file
+func fileHash(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", errors.Wrapf(err, "Error while opening %s to calculate hash", filename)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", errors.Wrapf(err, "Error while calculating hash of %s", filename)
+	}
+
+	return fmt.Sprintf("%x", hasher.Sum(nil)), nil
+}
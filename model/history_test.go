@@ -0,0 +1,61 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeHistory(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	os.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	backup := filepath.Join("testdata", "backup.jwlibrary")
+	history, err := ReadMergeHistory(backup)
+	assert.NoError(t, err)
+	assert.Nil(t, history)
+
+	_, err = ReadMergeHistory("nonexistent.jwlibrary")
+	assert.Error(t, err)
+	_, err = NewMergeHistory("1.0.0", backup, "nonexistent.jwlibrary")
+	assert.Error(t, err)
+
+	db := &Database{}
+	assert.NoError(t, db.ImportJWLBackup(backup))
+
+	history, err = NewMergeHistory("1.0.0", backup, backup)
+	assert.NoError(t, err)
+	hash, err := fileHash(backup)
+	assert.NoError(t, err)
+	assert.Equal(t, &MergeHistory{
+		ToolVersion: "1.0.0",
+		Date:        "2020-09-13T12:26:40+00:00",
+		Sources: []MergeSource{
+			{Name: "backup.jwlibrary", Hash: hash},
+			{Name: "backup.jwlibrary", Hash: hash},
+		},
+	}, history)
+
+	merged := filepath.Join(tmp, "merged.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackupWithHistory(merged, history))
+	readHistory, err := ReadMergeHistory(merged)
+	assert.NoError(t, err)
+	assert.Equal(t, history, readHistory)
+
+	// History should not interfere with importing
+	mergedDB := &Database{}
+	assert.NoError(t, mergedDB.ImportJWLBackup(merged))
+	assert.True(t, db.Equals(mergedDB))
+
+	// Histories of merged backups are included
+	nested, err := NewMergeHistory("1.0.1", merged, backup)
+	assert.NoError(t, err)
+	assert.Equal(t, history, nested.Sources[0].History)
+	assert.Nil(t, nested.Sources[1].History)
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/AndreasSko/go-jwlm/sqlite"
+	"github.com/pkg/errors"
+)
+
+// BackupInfo gives an overview of a backup, so it can be shown
+// to users before actually importing the backup
+type BackupInfo struct {
+	// Manifest of the backup, which is nil for bare databases
+	Manifest *Manifest
+
+	BlockRange int
+	Bookmark   int
+	Location   int
+	Note       int
+	Tag        int
+	TagMap     int
+	UserMark   int
+}
+
+// ReadBackupInfo reads the manifest of the given backup and counts the
+// entries of its tables. Only the database is extracted and the entries
+// are counted using SQLite directly, which is much faster than importing
+// the backup. Bare databases (see IsUserDB) are supported as well.
+func ReadBackupInfo(filename string) (*BackupInfo, error) {
+	info := &BackupInfo{}
+
+	dbPath := filename
+	isDB, err := IsUserDB(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !isDB {
+		info.Manifest, err = ReadManifest(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		tmp, err := ioutil.TempDir("", "go-jwlm")
+		if err != nil {
+			return nil, errors.Wrap(err, "Error while creating temporary directory")
+		}
+		defer os.RemoveAll(tmp)
+
+		dbPath, err = extractFile(filename, info.Manifest.DatabaseName, tmp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sqliteDB, err := sqlite.OpenImmutable(dbPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while opening SQLite database")
+	}
+	defer sqliteDB.Close()
+
+	stmts := newStmtCache(sqliteDB)
+	defer stmts.close()
+
+	counts := []struct {
+		table string
+		count *int
+	}{
+		{"BlockRange", &info.BlockRange},
+		{"Bookmark", &info.Bookmark},
+		{"Location", &info.Location},
+		{"Note", &info.Note},
+		{"Tag", &info.Tag},
+		{"TagMap", &info.TagMap},
+		{"UserMark", &info.UserMark},
+	}
+	for _, c := range counts {
+		if *c.count, err = getTableEntryCount(stmts, c.table); err != nil {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+// extractFile extracts the file with the given name from the backup
+// into dir and returns its path
+func extractFile(filename string, name string, dir string) (string, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return "", errors.Wrapf(damagedBackupError(err), "Error while opening backup %s", filename)
+	}
+	defer r.Close()
+
+	for _, file := range r.File {
+		if file.Name != name {
+			continue
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			return "", errors.Wrapf(err, "Error while reading %s", name)
+		}
+
+		path := filepath.Join(dir, filepath.Base(name))
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			return "", errors.Wrapf(err, "Error while extracting %s", name)
+		}
+		return path, nil
+	}
+
+	return "", errors.Errorf("Backup %s does not contain %s", filename, name)
+}
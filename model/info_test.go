@@ -0,0 +1,37 @@
+package model
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBackupInfo(t *testing.T) {
+	expected := BackupInfo{
+		BlockRange: 4,
+		Bookmark:   2,
+		Location:   7,
+		Note:       2,
+		Tag:        2,
+		TagMap:     2,
+		UserMark:   4,
+	}
+
+	info, err := ReadBackupInfo(filepath.Join("testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	assert.NotNil(t, info.Manifest)
+	assert.Equal(t, "Andreas iPhone Xs", info.Manifest.DeviceName)
+	info.Manifest = nil
+	assert.Equal(t, &expected, info)
+
+	// Bare databases don't have a manifest
+	info, err = ReadBackupInfo(filepath.Join("testdata", "user_data.db"))
+	assert.NoError(t, err)
+	assert.Equal(t, &expected, info)
+
+	_, err = ReadBackupInfo(filepath.Join("testdata", "nonexistent.jwlibrary"))
+	assert.Error(t, err)
+	_, err = ReadBackupInfo(filepath.Join("testdata", "manifest_correct.json"))
+	assert.Error(t, err)
+}
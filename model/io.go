@@ -0,0 +1,132 @@
+package model
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// BufferReader makes the content of r available as io.ReaderAt together
+// with its size, as needed to read zip archives like backups. Readers that
+// already support random access, like files, are used as they are. The
+// content of all other readers, like stdin or the body of an HTTP
+// response, is read into memory.
+func BufferReader(r io.Reader) (io.ReaderAt, int64, error) {
+	if ra, ok := r.(io.ReaderAt); ok {
+		if seeker, ok := r.(io.Seeker); ok {
+			size, err := seeker.Seek(0, io.SeekEnd)
+			if err == nil {
+				return ra, size, nil
+			}
+		}
+	}
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "Error while reading backup")
+	}
+	return bytes.NewReader(content), int64(len(content)), nil
+}
+
+// ImportJWLBackupFromReader imports a .jwlibrary backup read from r,
+// which is buffered using BufferReader.
+func (db *Database) ImportJWLBackupFromReader(r io.Reader) error {
+	ra, size, err := BufferReader(r)
+	if err != nil {
+		return err
+	}
+	return db.ImportJWLBackupFromReaderAt(ra, size)
+}
+
+// ImportJWLBackupFromReaderAt imports a .jwlibrary backup
+// of the given size that is read from r.
+func (db *Database) ImportJWLBackupFromReaderAt(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return damagedBackupError(err)
+	}
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return errors.Wrap(err, "Error while creating temporary directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	path, manifest, err := unpackZip(zr, tmp)
+	if err != nil {
+		return err
+	}
+
+	db.Logger().Debugf("Importing backup created on %s", manifest.CreationDate)
+	db.deviceName = manifest.UserDataBackup.DeviceName
+	return db.importSQLite(context.Background(), path)
+}
+
+// ExportJWLBackupToWriter creates a .jwlibrary backup out of the Database
+// using the given options and writes it to w, like stdout or the body of
+// an HTTP response.
+func (db *Database) ExportJWLBackupToWriter(w io.Writer, opts ExportOptions) error {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return errors.Wrap(err, "Error while creating temporary directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	files, err := db.writeBackupFiles(tmp, opts)
+	if err != nil {
+		return err
+	}
+	// Keep a copy of the backup, as w can't be read again
+	var exported bytes.Buffer
+	if opts.Verify {
+		w = io.MultiWriter(w, &exported)
+	}
+	if err := writeZip(w, files, opts.CompressionLevel); err != nil {
+		return errors.Wrap(err, "Error while storing files in zip archive")
+	}
+	db.Logger().Debugf("Exported backup")
+
+	if opts.Verify {
+		return db.verifyExportedBytes(exported.Bytes())
+	}
+	return nil
+}
+
+// verifyExportedBytes checks if the given exported backup
+// equals the Database like VerifyExport does.
+func (db *Database) verifyExportedBytes(data []byte) error {
+	exported := &Database{}
+	if err := exported.ImportJWLBackupFromBytes(data); err != nil {
+		return errors.Wrap(err, "Error while importing exported backup for verification")
+	}
+	if diff := db.difference(exported); diff != "" {
+		return fmt.Errorf("%w: exported backup differs from the database: %s", ErrExportMismatch, diff)
+	}
+	return nil
+}
+
+// ReadThumbnailFromReaderAt reads the PNG thumbnail of the
+// backup of the given size like ReadThumbnail does.
+func ReadThumbnailFromReaderAt(r io.ReaderAt, size int64) ([]byte, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.Wrap(damagedBackupError(err), "Error while opening backup")
+	}
+	return readThumbnail(zr)
+}
+
+// ReadMediaFromReaderAt reads the media files of the
+// backup of the given size like ReadMedia does.
+func ReadMediaFromReaderAt(r io.ReaderAt, size int64) (map[string][]byte, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.Wrap(damagedBackupError(err), "Error while opening backup")
+	}
+	return readMedia(zr)
+}
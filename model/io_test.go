@@ -0,0 +1,68 @@
+package model
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferReader(t *testing.T) {
+	path := filepath.Join("testdata", "backup.jwlibrary")
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	// Files are used as they are
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	ra, size, err := BufferReader(f)
+	assert.NoError(t, err)
+	assert.Equal(t, f, ra)
+	assert.Equal(t, int64(len(content)), size)
+
+	// Other readers are read into memory
+	ra, size, err = BufferReader(io.MultiReader(bytes.NewReader(content)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	buffered := make([]byte, size)
+	_, err = ra.ReadAt(buffered, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, content, buffered)
+}
+
+func TestDatabase_ImportExportJWLBackupReaderWriter(t *testing.T) {
+	path := filepath.Join("testdata", "backup.jwlibrary")
+	expected := &Database{}
+	assert.NoError(t, expected.ImportJWLBackup(path))
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	db := &Database{}
+	assert.NoError(t, db.ImportJWLBackupFromReader(f))
+	assert.True(t, expected.Equals(db))
+	assert.Equal(t, expected.DeviceName(), db.DeviceName())
+
+	var exported bytes.Buffer
+	media := map[string][]byte{"picture.jpg": []byte("picture")}
+	assert.NoError(t, db.ExportJWLBackupToWriter(&exported, ExportOptions{Media: media, Verify: true}))
+	reimported := &Database{}
+	assert.NoError(t, reimported.ImportJWLBackupFromReader(io.MultiReader(bytes.NewReader(exported.Bytes()))))
+	assert.True(t, db.Equals(reimported))
+
+	r := bytes.NewReader(exported.Bytes())
+	readMedia, err := ReadMediaFromReaderAt(r, r.Size())
+	assert.NoError(t, err)
+	assert.Equal(t, media, readMedia)
+	thumbnail, err := ReadThumbnailFromReaderAt(r, r.Size())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, thumbnail)
+
+	assert.Error(t, db.ExportJWLBackupToWriter(&exported, ExportOptions{CompressionLevel: 10}))
+	assert.Error(t, (&Database{}).ImportJWLBackupFromReader(bytes.NewReader([]byte("no backup"))))
+}
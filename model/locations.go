@@ -0,0 +1,126 @@
+package model
+
+import (
+	"database/sql"
+)
+
+// ConsolidateLocations merges Locations that are equal besides their Title
+// (like a chapter that has been stored once with and once without its
+// title) into the one with the lowest ID, keeping the first Title found.
+// All entries referring to the duplicates are moved to the kept Location,
+// and the duplicates are removed (set to nil). It returns the IDs of the
+// removed Locations together with the ID they have been merged into.
+func (db *Database) ConsolidateLocations() map[int]int {
+	kept := map[string]*Location{}
+	moved := map[int]int{}
+	for _, location := range db.Location {
+		if location == nil {
+			continue
+		}
+		key := location.UniqueKey()
+		first, ok := kept[key]
+		if !ok {
+			kept[key] = location
+			continue
+		}
+		if !first.Title.Valid || first.Title.String == "" {
+			first.Title = location.Title
+		}
+		moved[location.LocationID] = first.LocationID
+	}
+
+	db.MoveLocations(moved)
+	return moved
+}
+
+// remapLocations changes the selected Locations with the given function.
+// If a changed Location equals an existing one, all entries are moved
+// to it and the changed Location is removed. It returns the number
+// of selected Locations.
+func (db *Database) remapLocations(selected func(location *Location) bool, change func(location *Location)) int {
+	existing := map[string]*Location{}
+	for _, location := range db.Location {
+		if location != nil && !selected(location) {
+			existing[location.UniqueKey()] = location
+		}
+	}
+
+	count := 0
+	moved := map[int]int{}
+	for _, location := range db.Location {
+		if location == nil || !selected(location) {
+			continue
+		}
+		count++
+
+		target := *location
+		change(&target)
+		if other, ok := existing[target.UniqueKey()]; ok {
+			moved[location.LocationID] = other.LocationID
+			continue
+		}
+		*location = target
+		existing[location.UniqueKey()] = location
+	}
+
+	db.MoveLocations(moved)
+	return count
+}
+
+// MoveLocations moves all entries referring to the Locations that are keys
+// of moved to the Location with the corresponding value, and removes the
+// moved Locations (set to nil). TagMaps that would tag the same Location
+// twice are removed.
+func (db *Database) MoveLocations(moved map[int]int) {
+	if len(moved) == 0 {
+		return
+	}
+	move := func(id int) int {
+		if newID, ok := moved[id]; ok {
+			return newID
+		}
+		return id
+	}
+	moveNull := func(id sql.NullInt32) sql.NullInt32 {
+		if !id.Valid {
+			return id
+		}
+		return sql.NullInt32{Int32: int32(move(int(id.Int32))), Valid: true}
+	}
+
+	for _, note := range db.Note {
+		if note != nil {
+			note.LocationID = moveNull(note.LocationID)
+		}
+	}
+	for _, um := range db.UserMark {
+		if um != nil {
+			um.LocationID = move(um.LocationID)
+		}
+	}
+	for _, bm := range db.Bookmark {
+		if bm != nil {
+			bm.LocationID = move(bm.LocationID)
+			bm.PublicationLocationID = move(bm.PublicationLocationID)
+		}
+	}
+	tagged := map[[2]int]bool{}
+	for i, tm := range db.TagMap {
+		if tm == nil || !tm.LocationID.Valid {
+			continue
+		}
+		tm.LocationID = moveNull(tm.LocationID)
+		key := [2]int{tm.TagID, int(tm.LocationID.Int32)}
+		if tagged[key] {
+			db.TagMap[i] = nil
+			continue
+		}
+		tagged[key] = true
+	}
+
+	for id := range moved {
+		if id > 0 && id < len(db.Location) {
+			db.Location[id] = nil
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_ConsolidateLocations(t *testing.T) {
+	db := &Database{
+		Bookmark: []*Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 3, PublicationLocationID: 4, Slot: 0},
+		},
+		Location: []*Location{
+			nil,
+			{
+				LocationID:    1,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			},
+			{
+				LocationID:    2,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 2, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+			},
+			{
+				LocationID:    3,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				Title:         sql.NullString{String: "Genesis 1", Valid: true},
+			},
+			{
+				LocationID:   4,
+				KeySymbol:    sql.NullString{String: "nwtsty", Valid: true},
+				LocationType: 1,
+			},
+			{
+				LocationID:    5,
+				BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+				ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+				KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+				Title:         sql.NullString{String: "1. Mose 1", Valid: true},
+			},
+		},
+		Note: []*Note{
+			nil,
+			{NoteID: 1, LocationID: sql.NullInt32{Int32: 5, Valid: true}},
+			{NoteID: 2},
+		},
+		TagMap: []*TagMap{
+			nil,
+			{TagMapID: 1, TagID: 1, LocationID: sql.NullInt32{Int32: 3, Valid: true}},
+			{TagMapID: 2, TagID: 1, LocationID: sql.NullInt32{Int32: 5, Valid: true}, Position: 1},
+			{TagMapID: 3, TagID: 1, NoteID: sql.NullInt32{Int32: 1, Valid: true}, Position: 2},
+		},
+		UserMark: []*UserMark{
+			nil,
+			{UserMarkID: 1, LocationID: 3},
+		},
+	}
+
+	moved := db.ConsolidateLocations()
+	assert.Equal(t, map[int]int{3: 1, 5: 1}, moved)
+	assert.Nil(t, db.Location[3])
+	assert.Nil(t, db.Location[5])
+	assert.Equal(t, "Genesis 1", db.Location[1].Title.String)
+	assert.Equal(t, 1, db.Bookmark[1].LocationID)
+	assert.Equal(t, 4, db.Bookmark[1].PublicationLocationID)
+	assert.Equal(t, int32(1), db.Note[1].LocationID.Int32)
+	assert.False(t, db.Note[2].LocationID.Valid)
+	assert.Equal(t, 1, db.UserMark[1].LocationID)
+	assert.Equal(t, int32(1), db.TagMap[1].LocationID.Int32)
+	assert.Nil(t, db.TagMap[2])
+	assert.NotNil(t, db.TagMap[3])
+
+	assert.Empty(t, db.ConsolidateLocations())
+}
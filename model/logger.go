@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Logger is used by the library packages for logging, so applications
+// can route the messages into their own logging systems. It is satisfied
+// by *logrus.Logger and logrus.FieldLogger.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+}
+
+// NopLogger is a Logger that discards all messages
+type NopLogger struct{}
+
+// Debugf discards the message
+func (NopLogger) Debugf(format string, args ...interface{}) {}
+
+// Infof discards the message
+func (NopLogger) Infof(format string, args ...interface{}) {}
+
+// Warnf discards the message
+func (NopLogger) Warnf(format string, args ...interface{}) {}
+
+// writerLogger writes info and warning messages to a writer, one message
+// per line. Debug messages are discarded.
+type writerLogger struct {
+	w io.Writer
+}
+
+// NewWriterLogger returns a Logger that writes info and warning messages
+// to w, discarding debug messages. It is the default Logger of a Database
+// with w being os.Stdout.
+func NewWriterLogger(w io.Writer) Logger {
+	return &writerLogger{w: w}
+}
+
+func (l *writerLogger) Debugf(format string, args ...interface{}) {}
+
+func (l *writerLogger) Infof(format string, args ...interface{}) {
+	l.printf(format, args...)
+}
+
+func (l *writerLogger) Warnf(format string, args ...interface{}) {
+	l.printf(format, args...)
+}
+
+func (l *writerLogger) printf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	fmt.Fprint(l.w, msg)
+}
+
+// SetLogger sets the Logger used for messages concerning this Database,
+// like the differences found by Equals. If l is nil, the default Logger
+// writing to stdout is used again.
+func (db *Database) SetLogger(l Logger) {
+	db.logger = l
+}
+
+// Logger returns the Logger of the Database (see SetLogger)
+func (db *Database) Logger() Logger {
+	if db == nil || db.logger == nil {
+		return NewWriterLogger(os.Stdout)
+	}
+	return db.logger
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLogger struct {
+	messages []string
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) {
+	l.messages = append(l.messages, "debug: "+fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Infof(format string, args ...interface{}) {
+	l.messages = append(l.messages, "info: "+fmt.Sprintf(format, args...))
+}
+
+func (l *testLogger) Warnf(format string, args ...interface{}) {
+	l.messages = append(l.messages, "warn: "+fmt.Sprintf(format, args...))
+}
+
+func TestNewWriterLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewWriterLogger(buf)
+
+	logger.Debugf("Not %s", "shown")
+	logger.Infof("Some %s", "info")
+	logger.Warnf("A warning\n")
+
+	assert.Equal(t, "Some info\nA warning\n", buf.String())
+}
+
+func TestDatabase_SetLogger(t *testing.T) {
+	db := &Database{}
+	assert.IsType(t, &writerLogger{}, db.Logger())
+
+	logger := &testLogger{}
+	db.SetLogger(logger)
+	assert.Equal(t, logger, db.Logger())
+	assert.Equal(t, logger, MakeDatabaseCopy(db).Logger())
+
+	db.SetLogger(nil)
+	assert.IsType(t, &writerLogger{}, db.Logger())
+
+	var nilDB *Database
+	assert.IsType(t, &writerLogger{}, nilDB.Logger())
+}
+
+func TestDatabase_SetLogger_Equals(t *testing.T) {
+	logger := &testLogger{}
+	db := &Database{Tag: []*Tag{nil, {TagID: 1, Name: "A"}}}
+	db.SetLogger(logger)
+
+	assert.True(t, db.Equals(&Database{Tag: []*Tag{nil, {TagID: 1, Name: "A"}}}))
+	assert.Empty(t, logger.messages)
+
+	assert.False(t, db.Equals(&Database{Tag: []*Tag{nil, {TagID: 1, Name: "A"}, {TagID: 2, Name: "B"}}}))
+	assert.Len(t, logger.messages, 1)
+	assert.Contains(t, logger.messages[0], "info: Length of slices")
+
+	logger.messages = nil
+	assert.False(t, db.Equals(&Database{Tag: []*Tag{nil, {TagID: 1, Name: "B"}}}))
+	assert.Len(t, logger.messages, 1)
+	assert.Contains(t, logger.messages[0], "info: Found different entries")
+}
+
+func TestDatabase_SetLogger_ImportExport(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "backup.jwlibrary")
+
+	logger := &testLogger{}
+	db := &Database{}
+	db.SetLogger(logger)
+	assert.NoError(t, db.ExportJWLBackup(path))
+	assert.Equal(t, []string{"debug: Exported backup to " + path}, logger.messages)
+
+	logger.messages = nil
+	imported := &Database{}
+	imported.SetLogger(logger)
+	assert.NoError(t, imported.ImportJWLBackup(path))
+	assert.Len(t, logger.messages, 1)
+	assert.Contains(t, logger.messages[0], "debug: Importing "+path+" created on ")
+}
+
+func TestNopLogger(t *testing.T) {
+	var logger Logger = NopLogger{}
+	logger.Debugf("Nothing")
+	logger.Infof("Nothing")
+	logger.Warnf("Nothing")
+}
@@ -1,19 +1,24 @@
 package model
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
+// Versions of the manifest and the database schema go-jwlm
+// supports. Merged backups are always created with these.
+const (
+	supportedManifestVersion = 1
+	supportedSchemaVersion   = 8
+)
+
 type manifest struct {
 	CreationDate   string         `json:"creationDate"`
 	UserDataBackup userDataBackup `json:"userDataBackup"`
@@ -29,6 +34,47 @@ type userDataBackup struct {
 	DeviceName       string `json:"deviceName"`
 }
 
+// manifestDateFormat is the format of the modification date in the manifest
+const manifestDateFormat = "2006-01-02T15:04:05-07:00"
+
+// Manifest contains the information the manifest.json of a backup
+// stores about the backup and its database
+type Manifest struct {
+	// Name of the backup, like UserDataBackup_2020-04-11_iPhone
+	Name         string
+	CreationDate string
+	// LastModified is the time the database has last been modified.
+	// It is zero if the manifest doesn't contain a valid date.
+	LastModified  time.Time
+	DeviceName    string
+	Hash          string
+	DatabaseName  string
+	SchemaVersion int
+	Version       int
+}
+
+// ReadManifest reads the manifest of the given backup file. In contrast to
+// importing the backup, only the manifest.json is read from the archive,
+// so inspecting many backups is fast.
+func ReadManifest(filename string) (*Manifest, error) {
+	mfst, err := readBackupManifest(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	lastModified, _ := time.Parse(manifestDateFormat, mfst.UserDataBackup.LastModifiedDate)
+	return &Manifest{
+		Name:          mfst.Name,
+		CreationDate:  mfst.CreationDate,
+		LastModified:  lastModified,
+		DeviceName:    mfst.UserDataBackup.DeviceName,
+		Hash:          mfst.UserDataBackup.Hash,
+		DatabaseName:  mfst.UserDataBackup.DatabaseName,
+		SchemaVersion: mfst.UserDataBackup.SchemaVersion,
+		Version:       mfst.Version,
+	}, nil
+}
+
 // importManifest imports a manifest.json at path
 func (mfst *manifest) importManifest(path string) error {
 	file, err := os.Open(path)
@@ -37,29 +83,38 @@ func (mfst *manifest) importManifest(path string) error {
 	}
 	defer file.Close()
 
-	blob, _ := ioutil.ReadAll(file)
-
-	err = json.Unmarshal([]byte(blob), &mfst)
+	blob, err := ioutil.ReadAll(file)
 	if err != nil {
-		return errors.Wrap(err, "Could not unmarshall backup manifest file")
+		return errors.Wrap(err, "Error while reading manifest")
 	}
 
+	parsed, err := parseManifest(blob)
+	if err != nil {
+		return err
+	}
+	*mfst = *parsed
 	return nil
 }
 
+// parseManifest parses the content of a manifest.json
+func parseManifest(blob []byte) (*manifest, error) {
+	mfst := &manifest{}
+	if err := json.Unmarshal(blob, mfst); err != nil {
+		return nil, errors.Wrap(err, "Could not unmarshall backup manifest file")
+	}
+	return mfst, nil
+}
+
 // validateManifest checks if the backup file is compatible by validating the manifest
 func (mfst *manifest) validateManifest() error {
-	const version = 1
-	const schemaVersion = 8
-
-	if mfst.Version != version {
-		return fmt.Errorf("Manifest version is incompatible. Should be %d is %d. "+
-			"You might need to upgrade to a newer version of JW Library first", version, mfst.Version)
+	if mfst.Version != supportedManifestVersion {
+		return fmt.Errorf("%w. Should be %d is %d. "+
+			"You might need to upgrade to a newer version of JW Library first", ErrManifestOutdated, supportedManifestVersion, mfst.Version)
 	}
 
-	if mfst.UserDataBackup.SchemaVersion != schemaVersion {
-		return fmt.Errorf("Schema version is incompatible. Should be %d is %d. "+
-			"You might need to upgrade to a newer version of JW Library first", schemaVersion, mfst.UserDataBackup.SchemaVersion)
+	if mfst.UserDataBackup.SchemaVersion != supportedSchemaVersion {
+		return fmt.Errorf("%w. Should be %d is %d. "+
+			"You might need to upgrade to a newer version of JW Library first", ErrSchemaUnsupported, supportedSchemaVersion, mfst.UserDataBackup.SchemaVersion)
 	}
 
 	return nil
@@ -69,34 +124,63 @@ func (mfst *manifest) validateManifest() error {
 // later be exported
 func generateManifest(backupName string, dbFile string) (*manifest, error) {
 	// Get SHA256 of SQLite file
-	f, err := os.Open(dbFile)
+	hash, err := fileHash(dbFile)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Error while opening SQLite file %s to calculate hash", dbFile)
+		return nil, err
 	}
-	defer f.Close()
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, f); err != nil {
-		log.Fatal(err)
-	}
-	hash := fmt.Sprintf("%x", hasher.Sum(nil))
 
 	mfst := &manifest{
-		CreationDate: time.Now().Format("2006-01-02"),
+		CreationDate: exportTime().Format("2006-01-02"),
 		UserDataBackup: userDataBackup{
-			LastModifiedDate: time.Now().Format("2006-01-02T15:04:05-07:00"),
+			LastModifiedDate: exportTime().Format(manifestDateFormat),
 			Hash:             hash,
 			DatabaseName:     filepath.Base(dbFile),
-			SchemaVersion:    8,
-			DeviceName:       "go-jwlm",
+			SchemaVersion:    supportedSchemaVersion,
+			DeviceName:       mergedDeviceName,
 		},
 		Name:    backupName,
 		Type:    0,
-		Version: 1,
+		Version: supportedManifestVersion,
 	}
 
 	return mfst, nil
 }
 
+// ComputeBackupHash returns the SHA256 hash of the given SQLite database
+// as it is stored in the manifest of a backup. JW Library only restores
+// backups whose database matches this hash.
+func ComputeBackupHash(dbFile string) (string, error) {
+	return fileHash(dbFile)
+}
+
+// RefreshManifest updates the manifest.json at manifestPath for the
+// database at dbFile, e.g. after the database has been fixed by hand.
+// The hash, the modification date, and the name of the database are
+// updated, while everything else is kept as is.
+func RefreshManifest(manifestPath string, dbFile string) error {
+	mfst := &manifest{}
+	if err := mfst.importManifest(manifestPath); err != nil {
+		return errors.Wrap(err, "Error while importing manifest")
+	}
+	if err := mfst.refresh(dbFile); err != nil {
+		return err
+	}
+	return mfst.exportManifest(manifestPath)
+}
+
+// refresh updates the information about the database in the manifest
+func (mfst *manifest) refresh(dbFile string) error {
+	hash, err := ComputeBackupHash(dbFile)
+	if err != nil {
+		return err
+	}
+
+	mfst.UserDataBackup.Hash = hash
+	mfst.UserDataBackup.LastModifiedDate = exportTime().Format(manifestDateFormat)
+	mfst.UserDataBackup.DatabaseName = filepath.Base(dbFile)
+	return nil
+}
+
 // exportManifest exports a manifest at path
 func (mfst *manifest) exportManifest(path string) error {
 	bytes, err := json.Marshal(mfst)
@@ -110,3 +194,14 @@ func (mfst *manifest) exportManifest(path string) error {
 
 	return nil
 }
+
+// exportTime returns the time that is stored as creation and modification
+// date when exporting a backup. To create reproducible backups, it can be
+// fixed by setting the SOURCE_DATE_EPOCH environment variable to a
+// Unix timestamp (see https://reproducible-builds.org/specs/source-date-epoch/).
+func exportTime() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now()
+}
@@ -1,8 +1,10 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -58,7 +60,20 @@ func Test_validateManifest(t *testing.T) {
 	path = filepath.Join("testdata", "manifest_outdated.json")
 	mfst = manifest{}
 	assert.NoError(t, mfst.importManifest(path))
-	assert.Error(t, mfst.validateManifest())
+	err := mfst.validateManifest()
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrSchemaUnsupported))
+
+	mfst = manifest{Version: 2, UserDataBackup: userDataBackup{SchemaVersion: 8}}
+	err = mfst.validateManifest()
+	assert.True(t, errors.Is(err, ErrManifestOutdated))
+	assert.EqualError(t, err, "Manifest version is incompatible. Should be 1 is 2. "+
+		"You might need to upgrade to a newer version of JW Library first")
+
+	mfst = manifest{Version: 1, UserDataBackup: userDataBackup{SchemaVersion: 9}}
+	err = mfst.validateManifest()
+	assert.True(t, errors.Is(err, ErrSchemaUnsupported))
+	assert.False(t, errors.Is(err, ErrManifestOutdated))
 }
 
 func Test_generateManifest(t *testing.T) {
@@ -90,3 +105,93 @@ func Test_exportManifest(t *testing.T) {
 	assert.Equal(t, exampleManifest, otherMfst)
 
 }
+
+func Test_exportTime(t *testing.T) {
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	assert.Equal(t, time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC), exportTime())
+
+	os.Setenv("SOURCE_DATE_EPOCH", "invalid")
+	assert.WithinDuration(t, time.Now(), exportTime(), time.Minute)
+
+	os.Unsetenv("SOURCE_DATE_EPOCH")
+	assert.WithinDuration(t, time.Now(), exportTime(), time.Minute)
+}
+
+func TestComputeBackupHash(t *testing.T) {
+	hash, err := ComputeBackupHash(filepath.Join("testdata", "user_data.db"))
+	assert.NoError(t, err)
+	assert.Equal(t, "f57aabf8f375aa5469e3aea2292f89d2f624b8b2d70e0e0688f9ffbd44f0cf2b", hash)
+
+	_, err = ComputeBackupHash("nonexistentpath")
+	assert.Error(t, err)
+}
+
+func TestRefreshManifest(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "manifest_correct.json"))
+	assert.NoError(t, err)
+	path := filepath.Join(tmp, "manifest.json")
+	assert.NoError(t, ioutil.WriteFile(path, content, 0644))
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+	assert.NoError(t, RefreshManifest(path, filepath.Join("testdata", "user_data.db")))
+
+	mfst := &manifest{}
+	assert.NoError(t, mfst.importManifest(path))
+	assert.Equal(t, &manifest{
+		CreationDate: "2020-04-11",
+		UserDataBackup: userDataBackup{
+			LastModifiedDate: "2020-09-13T12:26:40+00:00",
+			Hash:             "f57aabf8f375aa5469e3aea2292f89d2f624b8b2d70e0e0688f9ffbd44f0cf2b",
+			DatabaseName:     "user_data.db",
+			SchemaVersion:    8,
+			DeviceName:       "iPhone",
+		},
+		Name:    "UserDataBackup_2020-04-11_iPhone",
+		Type:    0,
+		Version: 1,
+	}, mfst)
+
+	assert.Error(t, RefreshManifest(path, "nonexistentpath"))
+	assert.Error(t, RefreshManifest("nonexistentpath", filepath.Join("testdata", "user_data.db")))
+}
+
+func TestReadManifest(t *testing.T) {
+	mfst, err := ReadManifest(filepath.Join("testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	assert.Equal(t, "UserDataBackup_2020-08-15_Andreas-iPhone-Xs", mfst.Name)
+	assert.Equal(t, "Andreas iPhone Xs", mfst.DeviceName)
+	assert.Equal(t, "f57aabf8f375aa5469e3aea2292f89d2f624b8b2d70e0e0688f9ffbd44f0cf2b", mfst.Hash)
+	assert.True(t, time.Date(2020, 4, 14, 18, 42, 15, 0, time.UTC).Equal(mfst.LastModified))
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+	filename := filepath.Join(tmp, "backup.jwlibrary")
+	db := Database{}
+	assert.NoError(t, db.ExportJWLBackup(filename))
+	mfst, err = ReadManifest(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, &Manifest{
+		Name:          "go-jwlm",
+		CreationDate:  "2020-09-13",
+		LastModified:  time.Unix(1600000000, 0).In(mfst.LastModified.Location()),
+		DeviceName:    "go-jwlm",
+		Hash:          mfst.Hash,
+		DatabaseName:  "user_data.db",
+		SchemaVersion: 8,
+		Version:       1,
+	}, mfst)
+
+	_, err = ReadManifest(filepath.Join("testdata", "user_data.db"))
+	assert.Error(t, err)
+}
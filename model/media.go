@@ -0,0 +1,111 @@
+package model
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ReadMedia reads the media files of the given backup file, like the
+// pictures and audio files added to playlists, and returns their content
+// by filename. Every file besides the database, the manifest, the thumbnail
+// and the merge history is considered a media file.
+func ReadMedia(filename string) (map[string][]byte, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, errors.Wrapf(damagedBackupError(err), "Error while opening backup %s", filename)
+	}
+	defer r.Close()
+
+	return readMedia(&r.Reader)
+}
+
+// readMedia reads the media files of the given backup like ReadMedia
+func readMedia(r *zip.Reader) (map[string][]byte, error) {
+	reserved := map[string]bool{
+		"user_data.db":       true,
+		manifestFilename:     true,
+		thumbnailFilename:    true,
+		mergeHistoryFilename: true,
+	}
+	for _, file := range r.File {
+		if file.Name != manifestFilename {
+			continue
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error while reading manifest")
+		}
+		mfst, err := parseManifest(content)
+		if err != nil {
+			return nil, err
+		}
+		reserved[mfst.UserDataBackup.DatabaseName] = true
+	}
+
+	media := map[string][]byte{}
+	for _, file := range r.File {
+		if reserved[file.Name] || file.FileInfo().IsDir() {
+			continue
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error while reading media file %s", file.Name)
+		}
+		media[file.Name] = content
+	}
+
+	return media, nil
+}
+
+// readZipFile returns the uncompressed content of the file
+func readZipFile(file *zip.File) ([]byte, error) {
+	fileReader, err := file.Open()
+	if err != nil {
+		return nil, damagedBackupError(err)
+	}
+	defer fileReader.Close()
+
+	content, err := ioutil.ReadAll(fileReader)
+	if err != nil {
+		return nil, damagedBackupError(err)
+	}
+	return content, nil
+}
+
+// writeMedia writes the given media files into dir and returns their paths.
+// Filenames must not contain a path and must not collide with the other
+// files of a backup.
+func writeMedia(dir string, media map[string][]byte) ([]string, error) {
+	var paths []string
+	for _, name := range sortedMediaNames(media) {
+		if !isPlainFilename(name) {
+			return nil, errors.Errorf("Media file %s has an invalid name", name)
+		}
+		switch name {
+		case "user_data.db", manifestFilename, thumbnailFilename, mergeHistoryFilename:
+			return nil, errors.Errorf("Media file %s collides with a file of the backup", name)
+		}
+
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, media[name], 0644); err != nil {
+			return nil, errors.Wrapf(err, "Error while creating media file %s", name)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// sortedMediaNames returns the filenames of media sorted, so backups
+// are exported reproducibly
+func sortedMediaNames(media map[string][]byte) []string {
+	names := make([]string, 0, len(media))
+	for name := range media {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMedia(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	// testdata/backup.jwlibrary doesn't contain any media
+	media, err := ReadMedia(filepath.Join("testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	assert.Empty(t, media)
+
+	db := Database{}
+	assert.NoError(t, db.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+
+	filename := filepath.Join(tmp, "media.jwlibrary")
+	expected := map[string][]byte{
+		"picture.jpg": []byte("picture"),
+		"audio.mp3":   []byte("audio"),
+	}
+	opts := ExportOptions{Media: expected, History: &MergeHistory{}}
+	assert.NoError(t, db.ExportJWLBackupWithOptions(filename, opts))
+	media, err = ReadMedia(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, media)
+
+	// The backup can still be imported
+	imported := Database{}
+	assert.NoError(t, imported.ImportJWLBackup(filename))
+	assert.True(t, db.Equals(&imported))
+
+	_, err = ReadMedia(filepath.Join(tmp, "nonexistent.jwlibrary"))
+	assert.Error(t, err)
+}
+
+func TestExportJWLBackupWithOptions_invalidMedia(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	db := Database{}
+	filename := filepath.Join(tmp, "media.jwlibrary")
+	for _, name := range []string{"../picture.jpg", "dir/picture.jpg", "..", manifestFilename, "user_data.db"} {
+		opts := ExportOptions{Media: map[string][]byte{name: []byte("content")}}
+		assert.Error(t, db.ExportJWLBackupWithOptions(filename, opts), name)
+	}
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// EstimatedSize estimates the number of bytes the entries of the Database
+// occupy in memory, so callers can decide if merging it fits into the
+// memory of the device (see Spill).
+func (db *Database) EstimatedSize() int64 {
+	if db == nil {
+		return 0
+	}
+
+	var size int64
+	dbFields := reflect.ValueOf(db).Elem()
+	for i := 0; i < dbFields.NumField(); i++ {
+		field := dbFields.Field(i)
+		if !field.CanInterface() || field.Kind() != reflect.Slice {
+			continue
+		}
+		// Every slot of a table is a pointer, even if it is empty
+		size += int64(field.Len()) * int64(field.Type().Elem().Size())
+		for j := 0; j < field.Len(); j++ {
+			if entry := field.Index(j); !entry.IsNil() {
+				size += estimatedSize(entry.Elem())
+			}
+		}
+	}
+	return size
+}
+
+// estimatedSize returns the size of v including the content of its strings
+func estimatedSize(v reflect.Value) int64 {
+	size := int64(v.Type().Size())
+	switch v.Kind() {
+	case reflect.String:
+		size += int64(v.Len())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			// The size of the fields is already part of the struct
+			size += estimatedSize(v.Field(i)) - int64(v.Field(i).Type().Size())
+		}
+	}
+	return size
+}
+
+// SpilledDatabase is a Database that has been moved to a temporary SQLite
+// database (see Database.Spill), so it doesn't occupy any memory until it
+// is loaded again.
+type SpilledDatabase struct {
+	dir        string
+	deviceName string
+}
+
+// Spill stores the Database in a temporary SQLite database, so the
+// Database itself can be released and loaded again when it is needed.
+// This is slower than keeping it in memory, but prevents running out
+// of memory when merging big backups on phones. The temporary database
+// must be removed using Remove when it is not needed anymore.
+func (db *Database) Spill() (*SpilledDatabase, error) {
+	dir, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while creating temporary directory")
+	}
+
+	if err := db.saveToNewSQLite(filepath.Join(dir, "user_data.db")); err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrap(err, "Error while spilling database to disk")
+	}
+	db.Logger().Debugf("Spilled database to %s", dir)
+
+	return &SpilledDatabase{dir: dir, deviceName: db.deviceName}, nil
+}
+
+// Load reads the spilled Database from its temporary SQLite database.
+// Every call returns a new Database, so it can be changed without
+// affecting later calls.
+func (s *SpilledDatabase) Load() (*Database, error) {
+	db := &Database{deviceName: s.deviceName}
+	if err := db.importSQLite(context.Background(), filepath.Join(s.dir, "user_data.db")); err != nil {
+		return nil, errors.Wrap(err, "Error while loading spilled database")
+	}
+	return db, nil
+}
+
+// DeviceName returns the name of the device the spilled
+// Database has been created on (see Database.DeviceName).
+func (s *SpilledDatabase) DeviceName() string {
+	if s == nil {
+		return ""
+	}
+	return s.deviceName
+}
+
+// Remove deletes the temporary SQLite database of the SpilledDatabase.
+func (s *SpilledDatabase) Remove() error {
+	if err := os.RemoveAll(s.dir); err != nil {
+		return errors.Wrap(err, "Error while removing spilled database")
+	}
+	return nil
+}
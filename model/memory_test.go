@@ -0,0 +1,54 @@
+package model
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_EstimatedSize(t *testing.T) {
+	assert.Equal(t, int64(0), (*Database)(nil).EstimatedSize())
+	assert.Equal(t, int64(0), (&Database{}).EstimatedSize())
+
+	// Empty slots only need their pointer
+	empty := &Database{Note: []*Note{nil}}
+	assert.Greater(t, empty.EstimatedSize(), int64(0))
+
+	short := &Database{Note: []*Note{nil, {NoteID: 1, Title: sql.NullString{String: "a", Valid: true}}}}
+	long := &Database{Note: []*Note{nil, {NoteID: 1, Title: sql.NullString{String: "abcdefghij", Valid: true}}}}
+	assert.Equal(t, short.EstimatedSize()+9, long.EstimatedSize())
+	assert.Greater(t, short.EstimatedSize(), empty.EstimatedSize())
+
+	db := &Database{}
+	assert.NoError(t, db.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+	assert.Greater(t, db.EstimatedSize(), int64(0))
+}
+
+func TestDatabase_Spill(t *testing.T) {
+	db := &Database{}
+	assert.NoError(t, db.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+
+	spilled, err := db.Spill()
+	assert.NoError(t, err)
+	assert.Equal(t, db.DeviceName(), spilled.DeviceName())
+	assert.DirExists(t, spilled.dir)
+
+	loaded, err := spilled.Load()
+	assert.NoError(t, err)
+	assert.True(t, db.Equals(loaded))
+	assert.Equal(t, db.DeviceName(), loaded.DeviceName())
+
+	// Every Load returns a new Database
+	loaded.Note = nil
+	loaded, err = spilled.Load()
+	assert.NoError(t, err)
+	assert.True(t, db.Equals(loaded))
+
+	assert.NoError(t, spilled.Remove())
+	assert.NoDirExists(t, spilled.dir)
+	_, err = spilled.Load()
+	assert.Error(t, err)
+	assert.Equal(t, "", (*SpilledDatabase)(nil).DeviceName())
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// checksumPrefix is the prefix of the URL fragment
+// containing the expected SHA256 of a remote backup
+const checksumPrefix = "sha256="
+
+// httpClient is the client used for downloading remote backups
+var httpClient = http.DefaultClient
+
+// IsRemoteBackup checks if the given path points to
+// a backup that needs to be downloaded first.
+func IsRemoteBackup(path string) bool {
+	return strings.HasPrefix(strings.ToLower(path), "https://")
+}
+
+// DownloadBackup downloads the backup at the given https:// URL into dir
+// and returns the path of the downloaded file. The file keeps the name it
+// has within the URL. To verify the download, the expected SHA256 of the
+// backup can be given as fragment of the URL, like
+// https://example.com/backup.jwlibrary#sha256=<hex>.
+func DownloadBackup(backupURL string, dir string) (string, error) {
+	return DownloadBackupContext(context.Background(), backupURL, dir)
+}
+
+// DownloadBackupContext is like DownloadBackup, but
+// the download is canceled once ctx is done.
+func DownloadBackupContext(ctx context.Context, backupURL string, dir string) (string, error) {
+	u, err := url.Parse(backupURL)
+	if err != nil {
+		return "", errors.Wrap(err, "Error while parsing URL of backup")
+	}
+	if u.Scheme != "https" {
+		return "", errors.Errorf("Remote backups are only supported via https, got %s", u.Scheme)
+	}
+
+	expectedHash := ""
+	if strings.HasPrefix(u.Fragment, checksumPrefix) {
+		expectedHash = strings.ToLower(strings.TrimPrefix(u.Fragment, checksumPrefix))
+	}
+	u.Fragment = ""
+
+	name := path.Base(u.Path)
+	if name == "/" || name == "." || name == "" {
+		name = "backup.jwlibrary"
+	}
+	filename := filepath.Join(dir, name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", errors.Wrap(err, "Error while creating request for backup")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "Error while downloading backup")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("Error while downloading backup: %s", resp.Status)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return "", errors.Wrap(err, "Error while creating file for downloaded backup")
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	written, err := io.Copy(io.MultiWriter(f, hasher), resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "Error while downloading backup")
+	}
+	if resp.ContentLength >= 0 && written != resp.ContentLength {
+		return "", errors.Errorf("Downloaded backup is incomplete: got %d of %d bytes", written, resp.ContentLength)
+	}
+	if hash := hex.EncodeToString(hasher.Sum(nil)); expectedHash != "" && hash != expectedHash {
+		return "", HashMismatchError{Name: "downloaded backup", Expected: expectedHash, Actual: hash}
+	}
+
+	return filename, f.Close()
+}
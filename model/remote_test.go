@@ -0,0 +1,90 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRemoteBackup(t *testing.T) {
+	assert.True(t, IsRemoteBackup("https://example.com/backup.jwlibrary"))
+	assert.True(t, IsRemoteBackup("HTTPS://example.com/backup.jwlibrary"))
+	assert.False(t, IsRemoteBackup("http://example.com/backup.jwlibrary"))
+	assert.False(t, IsRemoteBackup("backup.jwlibrary"))
+	assert.False(t, IsRemoteBackup(filepath.Join("https:", "backup.jwlibrary")))
+}
+
+func TestDownloadBackup(t *testing.T) {
+	backup, err := ioutil.ReadFile(filepath.Join("testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	hash := fmt.Sprintf("%x", sha256.Sum256(backup))
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/share/backup.jwlibrary", "/":
+			w.Write(backup)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(c *http.Client) { httpClient = c }(httpClient)
+	httpClient = server.Client()
+
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename, err := DownloadBackup(server.URL+"/share/backup.jwlibrary", tmp)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmp, "backup.jwlibrary"), filename)
+	downloaded, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, backup, downloaded)
+
+	filename, err = DownloadBackup(server.URL+"/share/backup.jwlibrary#sha256="+hash, tmp)
+	assert.NoError(t, err)
+	assert.FileExists(t, filename)
+
+	filename, err = DownloadBackup(server.URL, tmp)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(tmp, "backup.jwlibrary"), filename)
+
+	_, err = DownloadBackup(server.URL+"/share/backup.jwlibrary#sha256=abc", tmp)
+	assert.EqualError(t, err, "Checksum of downloaded backup does not match: expected abc, got "+hash)
+	assert.True(t, errors.Is(err, ErrHashMismatch))
+	var mismatch HashMismatchError
+	assert.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "abc", mismatch.Expected)
+	assert.Equal(t, hash, mismatch.Actual)
+
+	_, err = DownloadBackup(server.URL+"/notfound.jwlibrary", tmp)
+	assert.EqualError(t, err, "Error while downloading backup: 404 Not Found")
+
+	_, err = DownloadBackup("http://example.com/backup.jwlibrary", tmp)
+	assert.EqualError(t, err, "Remote backups are only supported via https, got http")
+
+	db := &Database{}
+	assert.NoError(t, db.ImportJWLBackup(server.URL+"/share/backup.jwlibrary#sha256="+hash))
+	local := &Database{}
+	assert.NoError(t, local.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+	assert.True(t, local.Equals(db))
+
+	assert.Error(t, db.ImportJWLBackup(server.URL+"/share/backup.jwlibrary#sha256=abc"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = DownloadBackupContext(ctx, server.URL+"/share/backup.jwlibrary", tmp)
+	assert.True(t, errors.Is(err, context.Canceled))
+	err = db.ImportJWLBackupWithOptions(server.URL+"/share/backup.jwlibrary", ImportOptions{Context: ctx})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
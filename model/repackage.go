@@ -0,0 +1,115 @@
+package model
+
+import (
+	"archive/zip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// RepackageBackup creates a new backup at dest out of the backup filename,
+// replacing its database with the SQLite database at dbFile. The manifest
+// is refreshed to match the new database (see RefreshManifest), while all
+// other files, like the thumbnail and media files, are kept. This allows
+// to restore a database that has been modified by other tools.
+func RepackageBackup(filename string, dbFile string, dest string) error {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return errors.Wrap(err, "Error while creating temporary directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	files, err := extractBackup(filename, tmp)
+	if err != nil {
+		return err
+	}
+
+	manifestPath := filepath.Join(tmp, manifestFilename)
+	mfst := &manifest{}
+	if err := mfst.importManifest(manifestPath); err != nil {
+		return errors.Wrap(err, "Error while importing manifest")
+	}
+
+	dbName := mfst.UserDataBackup.DatabaseName
+	if dbName == "" || dbName != filepath.Base(dbName) {
+		dbName = "user_data.db"
+	}
+	dbPath := filepath.Join(tmp, dbName)
+	if err := copyFile(dbFile, dbPath); err != nil {
+		return errors.Wrapf(err, "Error while copying %s", dbFile)
+	}
+	if !contains(files, dbPath) {
+		files = append(files, dbPath)
+	}
+
+	if err := mfst.refresh(dbPath); err != nil {
+		return err
+	}
+	if err := mfst.exportManifest(manifestPath); err != nil {
+		return err
+	}
+
+	if err := zipFiles(dest, files, DefaultCompression); err != nil {
+		return errors.Wrapf(err, "Error while storing files in zip archive %s", dest)
+	}
+	return nil
+}
+
+// extractBackup extracts all files of the given backup into dir and
+// returns their paths in the order they are stored in the backup.
+func extractBackup(filename string, dir string) ([]string, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, errors.Wrapf(damagedBackupError(err), "Error while opening backup %s", filename)
+	}
+	defer r.Close()
+
+	var files []string
+	for _, file := range r.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error while reading %s", file.Name)
+		}
+
+		path := filepath.Join(dir, filepath.Base(file.Name))
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			return nil, errors.Wrapf(err, "Error while extracting %s", file.Name)
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// copyFile copies the file at src to dst
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepackageBackup(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	// Export an empty backup with a thumbnail and media
+	filename := filepath.Join(tmp, "empty.jwlibrary")
+	empty := Database{}
+	opts := ExportOptions{Thumbnail: []byte("thumbnail"), Media: map[string][]byte{"picture.jpg": []byte("picture")}}
+	assert.NoError(t, empty.ExportJWLBackupWithOptions(filename, opts))
+
+	// Repackage it with the database of another backup
+	dbFile := filepath.Join("testdata", "user_data.db")
+	dest := filepath.Join(tmp, "repackaged.jwlibrary")
+	assert.NoError(t, RepackageBackup(filename, dbFile, dest))
+
+	expected := Database{}
+	assert.NoError(t, expected.importSQLite(context.Background(), dbFile))
+	repackaged := Database{}
+	assert.NoError(t, repackaged.ImportJWLBackup(dest))
+	assert.True(t, expected.Equals(&repackaged))
+
+	tmpManifest, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpManifest)
+	_, mfst, err := unpackBackup(context.Background(), dest, tmpManifest)
+	assert.NoError(t, err)
+	assert.Equal(t, "f57aabf8f375aa5469e3aea2292f89d2f624b8b2d70e0e0688f9ffbd44f0cf2b", mfst.UserDataBackup.Hash)
+	assert.Equal(t, "user_data.db", mfst.UserDataBackup.DatabaseName)
+
+	thumbnail, err := ReadThumbnail(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("thumbnail"), thumbnail)
+	media, err := ReadMedia(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, opts.Media, media)
+
+	assert.Error(t, RepackageBackup(filename, filepath.Join(tmp, "nonexistent.db"), dest))
+	assert.Error(t, RepackageBackup(filepath.Join(tmp, "nonexistent.jwlibrary"), dbFile, dest))
+}
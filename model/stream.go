@@ -0,0 +1,124 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+
+	"github.com/AndreasSko/go-jwlm/sqlite"
+	"github.com/pkg/errors"
+)
+
+// ErrStopIteration can be returned by the function passed to the ForEach
+// methods of BackupStream to stop iterating without causing an error.
+var ErrStopIteration = errors.New("Stop iteration")
+
+// BackupStream gives access to the entries of a backup without loading
+// all of them into a Database. The entries are read one by one from the
+// SQLite DB, so even huge backups can be processed with constant memory.
+// A BackupStream must be closed after use.
+type BackupStream struct {
+	tmp      string
+	sqliteDB *sql.DB
+	stmts    *stmtCache
+}
+
+// OpenBackupStream extracts the given backup to a temporary directory and
+// opens its SQLite DB for reading. Like ImportJWLBackup, the filename can
+// also be an https:// URL.
+func OpenBackupStream(filename string) (*BackupStream, error) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while creating temporary directory")
+	}
+
+	path, _, err := unpackBackup(context.Background(), filename, tmp)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+
+	sqliteDB, err := sqlite.OpenImmutable(path)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, errors.Wrap(err, "Error while opening SQLite database")
+	}
+
+	return &BackupStream{
+		tmp:      tmp,
+		sqliteDB: sqliteDB,
+		stmts:    newStmtCache(sqliteDB),
+	}, nil
+}
+
+// Close closes the SQLite DB and removes the extracted backup
+func (s *BackupStream) Close() error {
+	s.stmts.close()
+	err := s.sqliteDB.Close()
+	if rmErr := os.RemoveAll(s.tmp); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// ForEach calls fn for every entry of the table belonging to modelType
+// (like &Note{}), ordered by their ID. If fn returns an error, the
+// iteration is stopped and the error is returned, except for
+// ErrStopIteration, which stops the iteration without an error.
+func (s *BackupStream) ForEach(modelType Model, fn func(Model) error) error {
+	err := forEachRow(s.stmts, modelType, fn)
+	if err == ErrStopIteration {
+		return nil
+	}
+	return err
+}
+
+// ForEachBlockRange calls fn for every BlockRange (see ForEach)
+func (s *BackupStream) ForEachBlockRange(fn func(*BlockRange) error) error {
+	return s.ForEach(&BlockRange{}, func(m Model) error {
+		return fn(m.(*BlockRange))
+	})
+}
+
+// ForEachBookmark calls fn for every Bookmark (see ForEach)
+func (s *BackupStream) ForEachBookmark(fn func(*Bookmark) error) error {
+	return s.ForEach(&Bookmark{}, func(m Model) error {
+		return fn(m.(*Bookmark))
+	})
+}
+
+// ForEachLocation calls fn for every Location (see ForEach)
+func (s *BackupStream) ForEachLocation(fn func(*Location) error) error {
+	return s.ForEach(&Location{}, func(m Model) error {
+		return fn(m.(*Location))
+	})
+}
+
+// ForEachNote calls fn for every Note (see ForEach)
+func (s *BackupStream) ForEachNote(fn func(*Note) error) error {
+	return s.ForEach(&Note{}, func(m Model) error {
+		return fn(m.(*Note))
+	})
+}
+
+// ForEachTag calls fn for every Tag (see ForEach)
+func (s *BackupStream) ForEachTag(fn func(*Tag) error) error {
+	return s.ForEach(&Tag{}, func(m Model) error {
+		return fn(m.(*Tag))
+	})
+}
+
+// ForEachTagMap calls fn for every TagMap (see ForEach)
+func (s *BackupStream) ForEachTagMap(fn func(*TagMap) error) error {
+	return s.ForEach(&TagMap{}, func(m Model) error {
+		return fn(m.(*TagMap))
+	})
+}
+
+// ForEachUserMark calls fn for every UserMark (see ForEach)
+func (s *BackupStream) ForEachUserMark(fn func(*UserMark) error) error {
+	return s.ForEach(&UserMark{}, func(m Model) error {
+		return fn(m.(*UserMark))
+	})
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupStream(t *testing.T) {
+	path := filepath.Join("testdata", "backup.jwlibrary")
+
+	db := &Database{}
+	assert.NoError(t, db.ImportJWLBackup(path))
+
+	stream, err := OpenBackupStream(path)
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	streamed := &Database{}
+	assert.NoError(t, stream.ForEachBlockRange(func(br *BlockRange) error {
+		streamed.BlockRange = append(streamed.BlockRange, br)
+		return nil
+	}))
+	assert.NoError(t, stream.ForEachBookmark(func(b *Bookmark) error {
+		streamed.Bookmark = append(streamed.Bookmark, b)
+		return nil
+	}))
+	assert.NoError(t, stream.ForEachLocation(func(l *Location) error {
+		streamed.Location = append(streamed.Location, l)
+		return nil
+	}))
+	assert.NoError(t, stream.ForEachNote(func(n *Note) error {
+		streamed.Note = append(streamed.Note, n)
+		return nil
+	}))
+	assert.NoError(t, stream.ForEachTag(func(tag *Tag) error {
+		streamed.Tag = append(streamed.Tag, tag)
+		return nil
+	}))
+	assert.NoError(t, stream.ForEachTagMap(func(tm *TagMap) error {
+		streamed.TagMap = append(streamed.TagMap, tm)
+		return nil
+	}))
+	assert.NoError(t, stream.ForEachUserMark(func(um *UserMark) error {
+		streamed.UserMark = append(streamed.UserMark, um)
+		return nil
+	}))
+
+	assert.Equal(t, withoutNil(db.BlockRange), streamed.BlockRange)
+	assert.Equal(t, withoutNil(db.Bookmark), streamed.Bookmark)
+	assert.Equal(t, withoutNil(db.Location), streamed.Location)
+	assert.Equal(t, withoutNil(db.Note), streamed.Note)
+	assert.Equal(t, withoutNil(db.Tag), streamed.Tag)
+	assert.Equal(t, withoutNil(db.TagMap), streamed.TagMap)
+	assert.Equal(t, withoutNil(db.UserMark), streamed.UserMark)
+}
+
+func TestBackupStream_stop(t *testing.T) {
+	stream, err := OpenBackupStream(filepath.Join("testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	count := 0
+	assert.NoError(t, stream.ForEachLocation(func(l *Location) error {
+		count++
+		if count == 2 {
+			return ErrStopIteration
+		}
+		return nil
+	}))
+	assert.Equal(t, 2, count)
+
+	someErr := errors.New("Some error")
+	count = 0
+	err = stream.ForEachLocation(func(l *Location) error {
+		count++
+		return someErr
+	})
+	assert.Equal(t, someErr, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestOpenBackupStream_error(t *testing.T) {
+	_, err := OpenBackupStream(filepath.Join("testdata", "doesnotexist.jwlibrary"))
+	assert.Error(t, err)
+}
+
+func TestBackupStream_Close(t *testing.T) {
+	stream, err := OpenBackupStream(filepath.Join("testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	assert.DirExists(t, stream.tmp)
+	assert.NoError(t, stream.Close())
+	assert.NoDirExists(t, stream.tmp)
+}
+
+// withoutNil returns the non-nil entries of the given slice of Models
+func withoutNil(slice interface{}) interface{} {
+	s := reflect.ValueOf(slice)
+	result := reflect.Zero(s.Type())
+	for i := 0; i < s.Len(); i++ {
+		if !s.Index(i).IsNil() {
+			result = reflect.Append(result, s.Index(i))
+		}
+	}
+	return result.Interface()
+}
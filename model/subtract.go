@@ -0,0 +1,211 @@
+package model
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Subtract removes every Bookmark, highlight (UserMark with its
+// BlockRanges), Note, and TagMap of the Database that is also present in
+// the reference Database, like to extract the notes that have been added
+// since the last merge or to remove entries that have been imported twice.
+// Entries are compared by their content, so their IDs and GUIDs don't
+// matter. Highlights that still have a Note attached are kept, as are
+// Tags that still contain entries and all Locations. Removed entries are
+// set to nil. It returns the number of removed entries per table.
+func (db *Database) Subtract(reference *Database) map[string]int {
+	removed := map[string]int{}
+
+	refNotes := map[string]bool{}
+	for _, note := range reference.Note {
+		if note != nil {
+			refNotes[noteContentKey(reference, note)] = true
+		}
+	}
+	refTagMaps := map[string]bool{}
+	for _, tm := range reference.TagMap {
+		if tm != nil {
+			if key, ok := tagMapContentKey(reference, tm); ok {
+				refTagMaps[key] = true
+			}
+		}
+	}
+	refUserMarks := map[string]bool{}
+	for um, ranges := range userMarkRanges(reference) {
+		refUserMarks[userMarkContentKey(reference, um, ranges)] = true
+	}
+	refBookmarks := map[string]bool{}
+	for _, bm := range reference.Bookmark {
+		if bm != nil {
+			refBookmarks[bookmarkContentKey(reference, bm)] = true
+		}
+	}
+	refTags := map[string]bool{}
+	for _, tag := range reference.Tag {
+		if tag != nil {
+			refTags[tag.UniqueKey()] = true
+		}
+	}
+
+	// TagMaps are checked first, as they refer to Notes by their content
+	removedNotes := map[int]bool{}
+	for _, note := range db.Note {
+		if note != nil && refNotes[noteContentKey(db, note)] {
+			removedNotes[note.NoteID] = true
+		}
+	}
+	for i, tm := range db.TagMap {
+		if tm == nil {
+			continue
+		}
+		key, ok := tagMapContentKey(db, tm)
+		if (tm.NoteID.Valid && removedNotes[int(tm.NoteID.Int32)]) || (ok && refTagMaps[key]) {
+			db.TagMap[i] = nil
+			removed["TagMap"]++
+		}
+	}
+	for i, note := range db.Note {
+		if note != nil && removedNotes[note.NoteID] {
+			db.Note[i] = nil
+			removed["Note"]++
+		}
+	}
+
+	attached := map[int]bool{}
+	for _, note := range db.Note {
+		if note != nil && note.UserMarkID.Valid {
+			attached[int(note.UserMarkID.Int32)] = true
+		}
+	}
+	removedUserMarks := map[int]bool{}
+	for um, ranges := range userMarkRanges(db) {
+		if !attached[um.UserMarkID] && refUserMarks[userMarkContentKey(db, um, ranges)] {
+			removedUserMarks[um.UserMarkID] = true
+		}
+	}
+	for i, um := range db.UserMark {
+		if um != nil && removedUserMarks[um.UserMarkID] {
+			db.UserMark[i] = nil
+			removed["UserMark"]++
+		}
+	}
+	for i, br := range db.BlockRange {
+		if br != nil && removedUserMarks[br.UserMarkID] {
+			db.BlockRange[i] = nil
+		}
+	}
+
+	for i, bm := range db.Bookmark {
+		if bm != nil && refBookmarks[bookmarkContentKey(db, bm)] {
+			db.Bookmark[i] = nil
+			removed["Bookmark"]++
+		}
+	}
+
+	used := map[int]bool{}
+	for _, tm := range db.TagMap {
+		if tm != nil {
+			used[tm.TagID] = true
+		}
+	}
+	for i, tag := range db.Tag {
+		if tag != nil && !used[tag.TagID] && refTags[tag.UniqueKey()] {
+			db.Tag[i] = nil
+			removed["Tag"]++
+		}
+	}
+
+	return removed
+}
+
+// contentKey joins the given fields, so they can be used as a key in a map
+func contentKey(fields ...string) string {
+	return strings.Join(fields, "\x00")
+}
+
+// locationContentKey returns the UniqueKey of the Location with the
+// given ID, or an empty string if there is none
+func locationContentKey(db *Database, id int) string {
+	if location := db.FetchFromTable("Location", id); location != nil {
+		return location.UniqueKey()
+	}
+	return ""
+}
+
+func noteContentKey(db *Database, note *Note) string {
+	return contentKey(
+		locationContentKey(db, int(note.LocationID.Int32)),
+		strconv.Itoa(note.BlockType),
+		strconv.Itoa(int(note.BlockIdentifier.Int32)),
+		note.Title.String,
+		note.Content.String,
+	)
+}
+
+func bookmarkContentKey(db *Database, bm *Bookmark) string {
+	return contentKey(
+		locationContentKey(db, bm.PublicationLocationID),
+		locationContentKey(db, bm.LocationID),
+		bm.Title,
+		bm.Snippet.String,
+		strconv.Itoa(bm.BlockType),
+		strconv.Itoa(int(bm.BlockIdentifier.Int32)),
+	)
+}
+
+func userMarkContentKey(db *Database, um *UserMark, ranges []*BlockRange) string {
+	fields := []string{
+		locationContentKey(db, um.LocationID),
+		strconv.Itoa(um.ColorIndex),
+		strconv.Itoa(um.StyleIndex),
+	}
+	rangeKeys := make([]string, len(ranges))
+	for i, br := range ranges {
+		rangeKeys[i] = strings.Join([]string{
+			strconv.Itoa(br.BlockType),
+			strconv.Itoa(br.Identifier),
+			strconv.Itoa(int(br.StartToken.Int32)),
+			strconv.Itoa(int(br.EndToken.Int32)),
+		}, "_")
+	}
+	sort.Strings(rangeKeys)
+	return contentKey(append(fields, rangeKeys...)...)
+}
+
+// tagMapContentKey returns the content key of a TagMap of a Note or a
+// Location. TagMaps of playlist items can't be compared by their content.
+func tagMapContentKey(db *Database, tm *TagMap) (string, bool) {
+	tag := db.FetchFromTable("Tag", tm.TagID)
+	if tag == nil {
+		return "", false
+	}
+	switch {
+	case tm.NoteID.Valid:
+		note, ok := db.FetchFromTable("Note", int(tm.NoteID.Int32)).(*Note)
+		if !ok {
+			return "", false
+		}
+		return contentKey(tag.UniqueKey(), "note", noteContentKey(db, note)), true
+	case tm.LocationID.Valid:
+		return contentKey(tag.UniqueKey(), "location", locationContentKey(db, int(tm.LocationID.Int32))), true
+	}
+	return "", false
+}
+
+// userMarkRanges returns the UserMarks of the Database with their BlockRanges
+func userMarkRanges(db *Database) map[*UserMark][]*BlockRange {
+	ranges := map[int][]*BlockRange{}
+	for _, br := range db.BlockRange {
+		if br != nil {
+			ranges[br.UserMarkID] = append(ranges[br.UserMarkID], br)
+		}
+	}
+	result := map[*UserMark][]*BlockRange{}
+	for _, um := range db.UserMark {
+		if um != nil {
+			result[um] = ranges[um.UserMarkID]
+		}
+	}
+	return result
+}
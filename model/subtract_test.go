@@ -0,0 +1,116 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_Subtract(t *testing.T) {
+	genesis := &Location{
+		LocationID:    1,
+		BookNumber:    sql.NullInt32{Int32: 1, Valid: true},
+		ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+		KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+		MepsLanguage:  2,
+	}
+	exodus := &Location{
+		LocationID:    2,
+		BookNumber:    sql.NullInt32{Int32: 2, Valid: true},
+		ChapterNumber: sql.NullInt32{Int32: 1, Valid: true},
+		KeySymbol:     sql.NullString{String: "nwtsty", Valid: true},
+		MepsLanguage:  2,
+	}
+	text := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	id := func(i int32) sql.NullInt32 { return sql.NullInt32{Int32: i, Valid: true} }
+
+	db := &Database{
+		Location: []*Location{nil, genesis, exodus},
+		Bookmark: []*Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 1, PublicationLocationID: 1, Slot: 0, Title: "Shared"},
+			{BookmarkID: 2, LocationID: 2, PublicationLocationID: 1, Slot: 1, Title: "New"},
+		},
+		UserMark: []*UserMark{
+			nil,
+			{UserMarkID: 1, ColorIndex: 1, LocationID: 1, UserMarkGUID: "A"},
+			{UserMarkID: 2, ColorIndex: 2, LocationID: 1, UserMarkGUID: "B"},
+			{UserMarkID: 3, ColorIndex: 1, LocationID: 2, UserMarkGUID: "C"},
+		},
+		BlockRange: []*BlockRange{
+			nil,
+			{BlockRangeID: 1, BlockType: 2, Identifier: 1, StartToken: id(0), EndToken: id(5), UserMarkID: 1},
+			{BlockRangeID: 2, BlockType: 2, Identifier: 2, StartToken: id(0), EndToken: id(5), UserMarkID: 2},
+			{BlockRangeID: 3, BlockType: 2, Identifier: 1, StartToken: id(0), EndToken: id(5), UserMarkID: 3},
+		},
+		Note: []*Note{
+			nil,
+			{NoteID: 1, GUID: "N1", LocationID: id(1), Title: text("Shared"), Content: text("Content")},
+			{NoteID: 2, GUID: "N2", LocationID: id(1), Title: text("New"), Content: text("Content")},
+			{NoteID: 3, GUID: "N3", UserMarkID: id(3), LocationID: id(2), Title: text("Highlighted"), Content: text("Content")},
+		},
+		Tag: []*Tag{
+			nil,
+			{TagID: 1, TagType: 1, Name: "Shared"},
+			{TagID: 2, TagType: 1, Name: "Still used"},
+		},
+		TagMap: []*TagMap{
+			nil,
+			{TagMapID: 1, NoteID: id(1), TagID: 1, Position: 0},
+			{TagMapID: 2, NoteID: id(2), TagID: 2, Position: 0},
+		},
+	}
+
+	// The reference uses other IDs and GUIDs for the same content
+	reference := &Database{
+		Location: []*Location{nil, nil, nil, exodus, genesis},
+		Bookmark: []*Bookmark{
+			nil,
+			{BookmarkID: 1, LocationID: 4, PublicationLocationID: 4, Slot: 5, Title: "Shared"},
+		},
+		UserMark: []*UserMark{
+			nil,
+			{UserMarkID: 1, ColorIndex: 1, LocationID: 4, UserMarkGUID: "X"},
+			{UserMarkID: 2, ColorIndex: 3, LocationID: 4, UserMarkGUID: "Y"},
+			{UserMarkID: 3, ColorIndex: 1, LocationID: 3, UserMarkGUID: "Z"},
+		},
+		BlockRange: []*BlockRange{
+			nil,
+			{BlockRangeID: 1, BlockType: 2, Identifier: 1, StartToken: id(0), EndToken: id(5), UserMarkID: 1},
+			{BlockRangeID: 2, BlockType: 2, Identifier: 2, StartToken: id(0), EndToken: id(5), UserMarkID: 2},
+			{BlockRangeID: 3, BlockType: 2, Identifier: 1, StartToken: id(0), EndToken: id(5), UserMarkID: 3},
+		},
+		Note: []*Note{
+			nil,
+			{NoteID: 1, GUID: "Other", LocationID: id(4), Title: text("Shared"), Content: text("Content")},
+		},
+		Tag: []*Tag{
+			nil,
+			{TagID: 1, TagType: 1, Name: "Shared"},
+			{TagID: 2, TagType: 1, Name: "Still used"},
+		},
+	}
+
+	removed := db.Subtract(reference)
+	assert.Equal(t, map[string]int{"Bookmark": 1, "Note": 1, "TagMap": 1, "UserMark": 1, "Tag": 1}, removed)
+
+	assert.Nil(t, db.Bookmark[1])
+	assert.NotNil(t, db.Bookmark[2])
+	assert.Nil(t, db.Note[1])
+	assert.NotNil(t, db.Note[2])
+	assert.NotNil(t, db.Note[3])
+	// Highlight 2 has another color, highlight 3 still has a note
+	assert.Nil(t, db.UserMark[1])
+	assert.Nil(t, db.BlockRange[1])
+	assert.NotNil(t, db.UserMark[2])
+	assert.NotNil(t, db.UserMark[3])
+	assert.NotNil(t, db.BlockRange[3])
+	assert.Nil(t, db.TagMap[1])
+	assert.NotNil(t, db.TagMap[2])
+	assert.Nil(t, db.Tag[1])
+	assert.NotNil(t, db.Tag[2])
+	assert.Equal(t, []*Location{nil, genesis, exodus}, db.Location)
+
+	assert.Empty(t, db.Subtract(&Database{}))
+}
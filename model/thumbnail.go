@@ -0,0 +1,73 @@
+package model
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/pkg/errors"
+)
+
+// thumbnailFilename is the name of the thumbnail JW Library
+// shows for a backup in its restore picker
+const thumbnailFilename = "default_thumbnail.png"
+
+const thumbnailSize = 256
+
+var (
+	thumbnailBackground = color.RGBA{0x4a, 0x6d, 0xa7, 0xff}
+	thumbnailPage       = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	thumbnailLine       = color.RGBA{0xbd, 0xc3, 0xcc, 0xff}
+)
+
+// ReadThumbnail reads the PNG thumbnail of the given backup file. If
+// the backup does not contain a thumbnail, it returns nil.
+func ReadThumbnail(filename string) ([]byte, error) {
+	r, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, errors.Wrapf(damagedBackupError(err), "Error while opening backup %s", filename)
+	}
+	defer r.Close()
+
+	return readThumbnail(&r.Reader)
+}
+
+// readThumbnail reads the thumbnail of the given backup like ReadThumbnail
+func readThumbnail(r *zip.Reader) ([]byte, error) {
+	for _, file := range r.File {
+		if file.Name != thumbnailFilename {
+			continue
+		}
+
+		thumbnail, err := readZipFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error while reading thumbnail")
+		}
+		return thumbnail, nil
+	}
+
+	return nil, nil
+}
+
+// GenerateThumbnail creates a simple PNG thumbnail showing a page
+// of notes, which is used for backups that don't have one.
+func GenerateThumbnail() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, thumbnailSize, thumbnailSize))
+	draw.Draw(img, img.Bounds(), &image.Uniform{thumbnailBackground}, image.Point{}, draw.Src)
+
+	page := image.Rect(64, 40, 192, 216)
+	draw.Draw(img, page, &image.Uniform{thumbnailPage}, image.Point{}, draw.Src)
+	for y := page.Min.Y + 24; y < page.Max.Y-16; y += 20 {
+		line := image.Rect(page.Min.X+16, y, page.Max.X-16, y+6)
+		draw.Draw(img, line, &image.Uniform{thumbnailLine}, image.Point{}, draw.Src)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, errors.Wrap(err, "Error while encoding thumbnail")
+	}
+	return buf.Bytes(), nil
+}
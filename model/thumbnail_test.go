@@ -0,0 +1,60 @@
+package model
+
+import (
+	"bytes"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadThumbnail(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	// testdata/backup.jwlibrary doesn't contain a thumbnail
+	thumbnail, err := ReadThumbnail(filepath.Join("testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	assert.Nil(t, thumbnail)
+
+	db := Database{}
+	assert.NoError(t, db.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+
+	// A thumbnail is generated by default
+	generated := filepath.Join(tmp, "generated.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackup(generated))
+	thumbnail, err = ReadThumbnail(generated)
+	assert.NoError(t, err)
+	expected, err := GenerateThumbnail()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, thumbnail)
+
+	// Otherwise the given one is kept
+	custom := filepath.Join(tmp, "custom.jwlibrary")
+	assert.NoError(t, db.ExportJWLBackupWithOptions(custom, ExportOptions{Thumbnail: []byte("thumbnail")}))
+	thumbnail, err = ReadThumbnail(custom)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("thumbnail"), thumbnail)
+
+	_, err = ReadThumbnail(filepath.Join(tmp, "nonexistent.jwlibrary"))
+	assert.Error(t, err)
+}
+
+func TestGenerateThumbnail(t *testing.T) {
+	thumbnail, err := GenerateThumbnail()
+	assert.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(thumbnail))
+	assert.NoError(t, err)
+	assert.Equal(t, thumbnailSize, img.Bounds().Dx())
+	assert.Equal(t, thumbnailSize, img.Bounds().Dy())
+
+	r, g, b, _ := img.At(0, 0).RGBA()
+	assert.Equal(t, []uint32{0x4a, 0x6d, 0xa7}, []uint32{r >> 8, g >> 8, b >> 8})
+	r, g, b, _ = img.At(thumbnailSize/2, 50).RGBA()
+	assert.Equal(t, []uint32{0xff, 0xff, 0xff}, []uint32{r >> 8, g >> 8, b >> 8})
+}
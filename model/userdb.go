@@ -0,0 +1,69 @@
+package model
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// sqliteHeader is the magic string every SQLite database starts with
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// IsUserDB checks if the given file is a bare SQLite database, like the
+// user_data.db of a backup, instead of a .jwlibrary backup.
+func IsUserDB(filename string) (bool, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return false, errors.Wrapf(err, "Error while opening %s", filename)
+	}
+	defer f.Close()
+
+	isDB, err := hasSQLiteHeader(f)
+	if err != nil {
+		return false, errors.Wrapf(err, "Error while reading %s", filename)
+	}
+	return isDB, nil
+}
+
+// hasSQLiteHeader checks if r starts with the header of a SQLite database
+func hasSQLiteHeader(r io.Reader) (bool, error) {
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return false, err
+	}
+	return bytes.Equal(header, sqliteHeader), nil
+}
+
+// ImportUserDB imports a bare SQLite database with the scheme of JW Library
+// into the Database{} struct. In contrast to ImportJWLBackup, it expects the
+// user_data.db itself, e.g. one extracted from a backup or recovered by
+// other tools, so there is no manifest to be validated.
+func (db *Database) ImportUserDB(filename string) error {
+	return db.ImportUserDBWithOptions(filename, ImportOptions{})
+}
+
+// ImportUserDBWithOptions imports a bare SQLite database with the
+// scheme of JW Library using the given options (see ImportUserDB).
+func (db *Database) ImportUserDBWithOptions(filename string, opts ImportOptions) error {
+	if opts.Logger != nil {
+		db.SetLogger(opts.Logger)
+	}
+	db.Logger().Debugf("Importing database %s", filename)
+	return db.importSQLite(opts.context(), filename)
+}
+
+// ExportUserDB saves the Database{} struct as bare SQLite database with the
+// scheme of JW Library at filename, skipping the manifest and zip archive
+// of a backup. It can be turned into a backup using RepackageBackup.
+func (db *Database) ExportUserDB(filename string) error {
+	if err := db.saveToNewSQLite(filename); err != nil {
+		return errors.Wrap(err, "Could not create SQLite database for exporting")
+	}
+	db.Logger().Debugf("Exported database to %s", filename)
+	return nil
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUserDB(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	isDB, err := IsUserDB(filepath.Join("testdata", "user_data.db"))
+	assert.NoError(t, err)
+	assert.True(t, isDB)
+
+	isDB, err = IsUserDB(filepath.Join("testdata", "backup.jwlibrary"))
+	assert.NoError(t, err)
+	assert.False(t, isDB)
+
+	empty := filepath.Join(tmp, "empty")
+	assert.NoError(t, ioutil.WriteFile(empty, nil, 0644))
+	isDB, err = IsUserDB(empty)
+	assert.NoError(t, err)
+	assert.False(t, isDB)
+
+	_, err = IsUserDB(filepath.Join(tmp, "nonexistent"))
+	assert.Error(t, err)
+}
+
+func TestDatabase_ImportExportUserDB(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	backup := Database{}
+	assert.NoError(t, backup.ImportJWLBackup(filepath.Join("testdata", "backup.jwlibrary")))
+
+	db := Database{}
+	assert.NoError(t, db.ImportUserDB(filepath.Join("testdata", "user_data.db")))
+	assert.True(t, backup.Equals(&db))
+
+	filename := filepath.Join(tmp, "user_data.db")
+	assert.NoError(t, db.ExportUserDB(filename))
+	isDB, err := IsUserDB(filename)
+	assert.NoError(t, err)
+	assert.True(t, isDB)
+
+	exported := Database{}
+	assert.NoError(t, exported.ImportUserDB(filename))
+	assert.True(t, db.Equals(&exported))
+
+	assert.Error(t, db.ImportUserDB(filepath.Join(tmp, "nonexistent.db")))
+	assert.Error(t, db.ExportUserDB(filepath.Join(tmp, "nonexistent", "user_data.db")))
+}
+
+func TestDatabase_ImportUserDBWithOptions(t *testing.T) {
+	path := filepath.Join("testdata", "user_data.db")
+	expected := &Database{}
+	assert.NoError(t, expected.ImportUserDB(path))
+
+	logger := &testLogger{}
+	db := &Database{}
+	assert.NoError(t, db.ImportUserDBWithOptions(path, ImportOptions{Logger: logger}))
+	assert.True(t, expected.Equals(db))
+	assert.Equal(t, []string{"debug: Importing database " + path}, logger.messages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := (&Database{}).ImportUserDBWithOptions(path, ImportOptions{Context: ctx})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
@@ -2,13 +2,34 @@ package model
 
 import (
 	"archive/zip"
+	"compress/flate"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Compression levels for exported backups (see ExportOptions)
+const (
+	// DefaultCompression compresses backups like JW Library does
+	DefaultCompression = 0
+	// StoreOnly stores the files without any compression,
+	// which is the fastest option on slow devices.
+	StoreOnly = -1
+	// BestSpeed is the fastest level that still compresses
+	BestSpeed = flate.BestSpeed
+	// BestCompression results in the smallest backups
+	BestCompression = flate.BestCompression
 )
 
 // https://golangcode.com/create-zip-files-in-go/
-func zipFiles(filename string, files []string) error {
+func zipFiles(filename string, files []string, level int) error {
+	if level < StoreOnly || level > BestCompression {
+		return errors.Errorf("Invalid compression level %d", level)
+	}
 
 	newZipFile, err := os.Create(filename)
 	if err != nil {
@@ -16,19 +37,41 @@ func zipFiles(filename string, files []string) error {
 	}
 	defer newZipFile.Close()
 
-	zipWriter := zip.NewWriter(newZipFile)
+	return writeZip(newZipFile, files, level)
+}
+
+// writeZip writes a zip archive containing the given files to w
+func writeZip(w io.Writer, files []string, level int) error {
+	if level < StoreOnly || level > BestCompression {
+		return errors.Errorf("Invalid compression level %d", level)
+	}
+
+	zipWriter := zip.NewWriter(w)
 	defer zipWriter.Close()
 
+	// Keep the compressor of archive/zip for the default level,
+	// so existing backups are still reproduced byte by byte.
+	method := zip.Deflate
+	switch level {
+	case DefaultCompression:
+	case StoreOnly:
+		method = zip.Store
+	default:
+		zipWriter.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		})
+	}
+
 	// Add files to zip
 	for _, file := range files {
-		if err = addFileToZip(zipWriter, file); err != nil {
+		if err := addFileToZip(zipWriter, file, method); err != nil {
 			return err
 		}
 	}
-	return nil
+	return zipWriter.Close()
 }
 
-func addFileToZip(zipWriter *zip.Writer, filename string) error {
+func addFileToZip(zipWriter *zip.Writer, filename string, method uint16) error {
 
 	fileToZip, err := os.Open(filename)
 	if err != nil {
@@ -47,8 +90,12 @@ func addFileToZip(zipWriter *zip.Writer, filename string) error {
 		return err
 	}
 
+	// Don't depend on the temporary files, so the same
+	// content always results in the same archive
 	header.Name = filepath.Base(filename)
-	header.Method = zip.Deflate
+	header.Method = method
+	header.Modified = exportTime()
+	header.SetMode(0644)
 
 	writer, err := zipWriter.CreateHeader(header)
 	if err != nil {
@@ -57,3 +104,25 @@ func addFileToZip(zipWriter *zip.Writer, filename string) error {
 	_, err = io.Copy(writer, fileToZip)
 	return err
 }
+
+// isPlainFilename checks if name is a filename without any path, so
+// files of a backup can't be written outside of the given directory.
+func isPlainFilename(name string) bool {
+	return name == filepath.Base(name) && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// damagedBackupError marks errors of archive/zip that are caused by an
+// incomplete or damaged archive as ErrBackupDamaged, so they can be told
+// apart from errors while accessing the file itself.
+func damagedBackupError(err error) error {
+	var corruptErr flate.CorruptInputError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, zip.ErrFormat), errors.Is(err, zip.ErrAlgorithm),
+		errors.Is(err, zip.ErrChecksum), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.As(err, &corruptErr):
+		return fmt.Errorf("%w: %v", ErrBackupDamaged, err)
+	}
+	return err
+}
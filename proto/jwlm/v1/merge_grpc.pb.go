@@ -0,0 +1,390 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: jwlm/v1/merge.proto
+
+package jwlmv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// MergeServiceClient is the client API for MergeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MergeServiceClient interface {
+	// CreateSession starts a new merge session
+	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*Session, error)
+	// UploadBackup uploads the backup for one side of a session in chunks
+	UploadBackup(ctx context.Context, opts ...grpc.CallOption) (MergeService_UploadBackupClient, error)
+	// Merge merges both backups and streams its progress and the conflicts
+	// as they occur. The merge waits until the streamed conflict has been
+	// resolved using Resolve.
+	Merge(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (MergeService_MergeClient, error)
+	// Resolve resolves the conflict that has been streamed last
+	Resolve(ctx context.Context, in *ResolveRequest, opts ...grpc.CallOption) (*ResolveResponse, error)
+	// DownloadBackup downloads the merged backup in chunks
+	DownloadBackup(ctx context.Context, in *DownloadBackupRequest, opts ...grpc.CallOption) (MergeService_DownloadBackupClient, error)
+	// CloseSession cancels a running merge and removes all its data
+	CloseSession(ctx context.Context, in *CloseSessionRequest, opts ...grpc.CallOption) (*CloseSessionResponse, error)
+}
+
+type mergeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMergeServiceClient(cc grpc.ClientConnInterface) MergeServiceClient {
+	return &mergeServiceClient{cc}
+}
+
+func (c *mergeServiceClient) CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*Session, error) {
+	out := new(Session)
+	err := c.cc.Invoke(ctx, "/jwlm.v1.MergeService/CreateSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mergeServiceClient) UploadBackup(ctx context.Context, opts ...grpc.CallOption) (MergeService_UploadBackupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MergeService_ServiceDesc.Streams[0], "/jwlm.v1.MergeService/UploadBackup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mergeServiceUploadBackupClient{stream}
+	return x, nil
+}
+
+type MergeService_UploadBackupClient interface {
+	Send(*UploadBackupRequest) error
+	CloseAndRecv() (*UploadBackupResponse, error)
+	grpc.ClientStream
+}
+
+type mergeServiceUploadBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *mergeServiceUploadBackupClient) Send(m *UploadBackupRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *mergeServiceUploadBackupClient) CloseAndRecv() (*UploadBackupResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(UploadBackupResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *mergeServiceClient) Merge(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (MergeService_MergeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MergeService_ServiceDesc.Streams[1], "/jwlm.v1.MergeService/Merge", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mergeServiceMergeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MergeService_MergeClient interface {
+	Recv() (*MergeEvent, error)
+	grpc.ClientStream
+}
+
+type mergeServiceMergeClient struct {
+	grpc.ClientStream
+}
+
+func (x *mergeServiceMergeClient) Recv() (*MergeEvent, error) {
+	m := new(MergeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *mergeServiceClient) Resolve(ctx context.Context, in *ResolveRequest, opts ...grpc.CallOption) (*ResolveResponse, error) {
+	out := new(ResolveResponse)
+	err := c.cc.Invoke(ctx, "/jwlm.v1.MergeService/Resolve", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mergeServiceClient) DownloadBackup(ctx context.Context, in *DownloadBackupRequest, opts ...grpc.CallOption) (MergeService_DownloadBackupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MergeService_ServiceDesc.Streams[2], "/jwlm.v1.MergeService/DownloadBackup", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mergeServiceDownloadBackupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MergeService_DownloadBackupClient interface {
+	Recv() (*DownloadBackupResponse, error)
+	grpc.ClientStream
+}
+
+type mergeServiceDownloadBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *mergeServiceDownloadBackupClient) Recv() (*DownloadBackupResponse, error) {
+	m := new(DownloadBackupResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *mergeServiceClient) CloseSession(ctx context.Context, in *CloseSessionRequest, opts ...grpc.CallOption) (*CloseSessionResponse, error) {
+	out := new(CloseSessionResponse)
+	err := c.cc.Invoke(ctx, "/jwlm.v1.MergeService/CloseSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MergeServiceServer is the server API for MergeService service.
+// All implementations must embed UnimplementedMergeServiceServer
+// for forward compatibility
+type MergeServiceServer interface {
+	// CreateSession starts a new merge session
+	CreateSession(context.Context, *CreateSessionRequest) (*Session, error)
+	// UploadBackup uploads the backup for one side of a session in chunks
+	UploadBackup(MergeService_UploadBackupServer) error
+	// Merge merges both backups and streams its progress and the conflicts
+	// as they occur. The merge waits until the streamed conflict has been
+	// resolved using Resolve.
+	Merge(*MergeRequest, MergeService_MergeServer) error
+	// Resolve resolves the conflict that has been streamed last
+	Resolve(context.Context, *ResolveRequest) (*ResolveResponse, error)
+	// DownloadBackup downloads the merged backup in chunks
+	DownloadBackup(*DownloadBackupRequest, MergeService_DownloadBackupServer) error
+	// CloseSession cancels a running merge and removes all its data
+	CloseSession(context.Context, *CloseSessionRequest) (*CloseSessionResponse, error)
+	mustEmbedUnimplementedMergeServiceServer()
+}
+
+// UnimplementedMergeServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMergeServiceServer struct {
+}
+
+func (UnimplementedMergeServiceServer) CreateSession(context.Context, *CreateSessionRequest) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
+}
+func (UnimplementedMergeServiceServer) UploadBackup(MergeService_UploadBackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadBackup not implemented")
+}
+func (UnimplementedMergeServiceServer) Merge(*MergeRequest, MergeService_MergeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Merge not implemented")
+}
+func (UnimplementedMergeServiceServer) Resolve(context.Context, *ResolveRequest) (*ResolveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resolve not implemented")
+}
+func (UnimplementedMergeServiceServer) DownloadBackup(*DownloadBackupRequest, MergeService_DownloadBackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadBackup not implemented")
+}
+func (UnimplementedMergeServiceServer) CloseSession(context.Context, *CloseSessionRequest) (*CloseSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseSession not implemented")
+}
+func (UnimplementedMergeServiceServer) mustEmbedUnimplementedMergeServiceServer() {}
+
+// UnsafeMergeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MergeServiceServer will
+// result in compilation errors.
+type UnsafeMergeServiceServer interface {
+	mustEmbedUnimplementedMergeServiceServer()
+}
+
+func RegisterMergeServiceServer(s grpc.ServiceRegistrar, srv MergeServiceServer) {
+	s.RegisterService(&MergeService_ServiceDesc, srv)
+}
+
+func _MergeService_CreateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MergeServiceServer).CreateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jwlm.v1.MergeService/CreateSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MergeServiceServer).CreateSession(ctx, req.(*CreateSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MergeService_UploadBackup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MergeServiceServer).UploadBackup(&mergeServiceUploadBackupServer{stream})
+}
+
+type MergeService_UploadBackupServer interface {
+	SendAndClose(*UploadBackupResponse) error
+	Recv() (*UploadBackupRequest, error)
+	grpc.ServerStream
+}
+
+type mergeServiceUploadBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *mergeServiceUploadBackupServer) SendAndClose(m *UploadBackupResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *mergeServiceUploadBackupServer) Recv() (*UploadBackupRequest, error) {
+	m := new(UploadBackupRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _MergeService_Merge_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(MergeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MergeServiceServer).Merge(m, &mergeServiceMergeServer{stream})
+}
+
+type MergeService_MergeServer interface {
+	Send(*MergeEvent) error
+	grpc.ServerStream
+}
+
+type mergeServiceMergeServer struct {
+	grpc.ServerStream
+}
+
+func (x *mergeServiceMergeServer) Send(m *MergeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MergeService_Resolve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MergeServiceServer).Resolve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jwlm.v1.MergeService/Resolve",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MergeServiceServer).Resolve(ctx, req.(*ResolveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MergeService_DownloadBackup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadBackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MergeServiceServer).DownloadBackup(m, &mergeServiceDownloadBackupServer{stream})
+}
+
+type MergeService_DownloadBackupServer interface {
+	Send(*DownloadBackupResponse) error
+	grpc.ServerStream
+}
+
+type mergeServiceDownloadBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *mergeServiceDownloadBackupServer) Send(m *DownloadBackupResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MergeService_CloseSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MergeServiceServer).CloseSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jwlm.v1.MergeService/CloseSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MergeServiceServer).CloseSession(ctx, req.(*CloseSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MergeService_ServiceDesc is the grpc.ServiceDesc for MergeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MergeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jwlm.v1.MergeService",
+	HandlerType: (*MergeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateSession",
+			Handler:    _MergeService_CreateSession_Handler,
+		},
+		{
+			MethodName: "Resolve",
+			Handler:    _MergeService_Resolve_Handler,
+		},
+		{
+			MethodName: "CloseSession",
+			Handler:    _MergeService_CloseSession_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadBackup",
+			Handler:       _MergeService_UploadBackup_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Merge",
+			Handler:       _MergeService_Merge_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "DownloadBackup",
+			Handler:       _MergeService_DownloadBackup_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "jwlm/v1/merge.proto",
+}
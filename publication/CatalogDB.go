@@ -3,13 +3,16 @@ package publication
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
 	"time"
 
+	"github.com/AndreasSko/go-jwlm/sqlite"
 	"github.com/cavaliercoder/grab"
 	"github.com/codeclysm/extract/v3"
 	"github.com/pkg/errors"
@@ -32,6 +35,38 @@ type Progress struct {
 	Done           bool
 }
 
+// ErrCatalogNotFound indicates that there is no catalogDB at the given path
+var ErrCatalogNotFound = errors.New("CatalogDB does not exist")
+
+// CatalogCorruptError indicates that the catalogDB can't be used, either because
+// it is not a valid SQLite database or because its schema does not match the
+// expected one. The catalogDB should be downloaded again.
+type CatalogCorruptError struct {
+	Reason string
+	Err    error
+}
+
+func (e CatalogCorruptError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("CatalogDB is corrupt: %s: %s", e.Reason, e.Err)
+	}
+	return fmt.Sprintf("CatalogDB is corrupt: %s", e.Reason)
+}
+
+// Unwrap returns the underlying error
+func (e CatalogCorruptError) Unwrap() error {
+	return e.Err
+}
+
+// catalogSchema lists the tables and columns of the catalogDB
+// that are needed for looking up publications.
+var catalogSchema = map[string][]string{
+	"Publication": {"PublicationRootKeyId", "MepsLanguageId", "PublicationTypeId",
+		"IssueTagNumber", "Title", "IssueTitle", "ShortTitle", "CoverTitle", "UndatedTitle",
+		"UndatedReferenceTitle", "Year", "Symbol", "KeySymbol", "Reserved", "Id"},
+	"PublicationDocument": {"DocumentId", "PublicationId"},
+}
+
 type catalogManifest struct {
 	Version int    `json:"version"`
 	Current string `json:"current"`
@@ -67,6 +102,67 @@ func CatalogSize(path string) int64 {
 	return info.Size()
 }
 
+// VerifyCatalog checks if the catalogDB at path can be opened and contains all
+// tables and columns needed for looking up publications. If not, it returns
+// ErrCatalogNotFound or a CatalogCorruptError.
+func VerifyCatalog(path string) error {
+	if !CatalogExists(path) {
+		return ErrCatalogNotFound
+	}
+
+	db, err := sqlite.OpenImmutable(path)
+	if err != nil {
+		return CatalogCorruptError{Reason: "could not open SQLite database", Err: err}
+	}
+	defer db.Close()
+
+	tables := make([]string, 0, len(catalogSchema))
+	for table := range catalogSchema {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		columns, err := tableColumns(db, table)
+		if err != nil {
+			return CatalogCorruptError{Reason: "could not read schema", Err: err}
+		}
+		if len(columns) == 0 {
+			return CatalogCorruptError{Reason: fmt.Sprintf("table %s is missing", table)}
+		}
+		for _, column := range catalogSchema[table] {
+			if !columns[column] {
+				return CatalogCorruptError{Reason: fmt.Sprintf("column %s of table %s is missing", column, table)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// tableColumns returns the set of columns of the given table. If the table
+// does not exist, the set is empty.
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, tp string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &tp, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
 // DownloadCatalog downloads the newest catalog.db and saves it at dst.
 // The prgrs channel informs about the progress of the download.
 func DownloadCatalog(ctx context.Context, prgrs chan Progress, dst string) error {
@@ -14,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/AndreasSko/go-jwlm/sqlite"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -121,6 +122,48 @@ func Test_DownloadCatalog(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestVerifyCatalog(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	assert.NoError(t, VerifyCatalog(filepath.Join("testdata", "catalog.db")))
+
+	assert.Equal(t, ErrCatalogNotFound, VerifyCatalog(filepath.Join(tmp, "nonexistent.db")))
+
+	// Not a SQLite database at all
+	err = VerifyCatalog(filepath.Join("testdata", "catalog.db.gz"))
+	assert.IsType(t, CatalogCorruptError{}, err)
+	assert.Equal(t, "could not read schema", err.(CatalogCorruptError).Reason)
+	assert.Error(t, err.(CatalogCorruptError).Unwrap())
+
+	// Missing table
+	path := filepath.Join(tmp, "missingTable.db")
+	createSQLite(t, path, "CREATE TABLE PublicationDocument (DocumentId INTEGER, PublicationId INTEGER)")
+	err = VerifyCatalog(path)
+	assert.Equal(t, CatalogCorruptError{Reason: "table Publication is missing"}, err)
+
+	// Missing column
+	path = filepath.Join(tmp, "missingColumn.db")
+	createSQLite(t, path, "CREATE TABLE Publication (Id INTEGER)",
+		"CREATE TABLE PublicationDocument (DocumentId INTEGER, PublicationId INTEGER)")
+	err = VerifyCatalog(path)
+	assert.Equal(t, CatalogCorruptError{Reason: "column PublicationRootKeyId of table Publication is missing"}, err)
+	assert.Equal(t, "CatalogDB is corrupt: column PublicationRootKeyId of table Publication is missing", err.Error())
+}
+
+// createSQLite creates a SQLite database at path and runs the given statements
+func createSQLite(t *testing.T, path string, stmts ...string) {
+	db, err := sqlite.Open(path)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	for _, stmt := range stmts {
+		_, err := db.Exec(stmt)
+		assert.NoError(t, err)
+	}
+}
+
 func Test_fetchManifest(t *testing.T) {
 	var tests = []struct {
 		input       string
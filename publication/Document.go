@@ -0,0 +1,103 @@
+package publication
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoDocumentTable indicates that the catalogDB does not contain
+// a Document table, so titles of documents can't be looked up.
+var ErrNoDocumentTable = errors.New("CatalogDB does not contain a Document table")
+
+// Document represents a single document of a publication
+// (like an article or a chapter) from the catalogDB
+type Document struct {
+	DocumentID    int
+	PublicationID int
+	MepsLanguage  int
+	Title         string
+}
+
+// LookupDocument looks up the document with the DocumentID and MepsLanguage
+// of the query from the catalogDB located at dbPath. If it can't be found
+// for the given MepsLanguage, the FallbackLanguages of the query are tried.
+func LookupDocument(dbPath string, query Lookup) (Document, error) {
+	return LookupDocumentContext(context.Background(), dbPath, query)
+}
+
+// LookupDocumentContext is like LookupDocument, but allows to cancel
+// the lookup using the given context.
+func LookupDocumentContext(ctx context.Context, dbPath string, query Lookup) (Document, error) {
+	db, err := openCatalog(dbPath)
+	if err != nil {
+		return Document{}, err
+	}
+	defer db.Close()
+
+	exists, err := tableExists(ctx, db, "Document")
+	if err != nil {
+		return Document{}, err
+	}
+	if !exists {
+		return Document{}, ErrNoDocumentTable
+	}
+
+	doc, err := lookupDocument(ctx, db, query)
+	for _, lang := range query.FallbackLanguages {
+		if errors.Cause(err) != sql.ErrNoRows {
+			break
+		}
+		query.MepsLanguage = lang
+		doc, err = lookupDocument(ctx, db, query)
+	}
+
+	return doc, err
+}
+
+func lookupDocument(ctx context.Context, db *sql.DB, query Lookup) (Document, error) {
+	stmt, err := db.PrepareContext(ctx, "SELECT D.MepsDocumentId, D.PublicationId, P.MepsLanguageId, D.Title "+
+		"FROM Document AS D, Publication AS P "+
+		"WHERE D.PublicationId = P.Id AND D.MepsDocumentId = ? AND P.MepsLanguageId = ?")
+	if err != nil {
+		return Document{}, errors.Wrap(err, "Error while preparing query")
+	}
+	defer stmt.Close()
+
+	doc := Document{}
+	err = stmt.QueryRowContext(ctx, query.DocumentID, query.MepsLanguage).
+		Scan(&doc.DocumentID, &doc.PublicationID, &doc.MepsLanguage, &doc.Title)
+	if err != nil {
+		return Document{}, errors.Wrap(err, "Error while scanning row for document")
+	}
+
+	return doc, nil
+}
+
+// tableExists checks if a table with the given name exists in db
+func tableExists(ctx context.Context, db *sql.DB, name string) (bool, error) {
+	var count int
+	err := db.QueryRowContext(ctx, "SELECT Count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	if err != nil {
+		return false, errors.Wrapf(err, "Error while checking if table %s exists", name)
+	}
+
+	return count > 0, nil
+}
+
+// MarshalJSON returns the JSON encoding of the entry
+func (m Document) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		DocumentID    int    `json:"documentId"`
+		PublicationID int    `json:"publicationId"`
+		MepsLanguage  int    `json:"mepsLanguage"`
+		Title         string `json:"title"`
+	}{
+		DocumentID:    m.DocumentID,
+		PublicationID: m.PublicationID,
+		MepsLanguage:  m.MepsLanguage,
+		Title:         m.Title,
+	})
+}
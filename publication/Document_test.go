@@ -0,0 +1,95 @@
+package publication
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/sqlite"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// createDocumentCatalog creates a copy of the test catalog.db in tmp that
+// additionally contains a Document table.
+func createDocumentCatalog(t *testing.T, tmp string) string {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "catalog.db"))
+	assert.NoError(t, err)
+	path := filepath.Join(tmp, "catalog.db")
+	assert.NoError(t, ioutil.WriteFile(path, data, 0644))
+
+	db, err := sqlite.Open(path)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE Document (
+		DocumentId INTEGER NOT NULL PRIMARY KEY,
+		PublicationId INTEGER NOT NULL,
+		MepsDocumentId INTEGER NOT NULL,
+		Title VARCHAR NOT NULL)`)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO Document VALUES
+		(1, 67, 1102002020, 'Chapter 2: “This Is Our God”'),
+		(2, 129, 1102002021, 'Capítulo 3: “Santo, santo, santo es Jehová”')`)
+	assert.NoError(t, err)
+
+	return path
+}
+
+func TestLookupDocument(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	path := createDocumentCatalog(t, tmp)
+
+	doc, err := LookupDocument(path, Lookup{DocumentID: 1102002020, MepsLanguage: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, Document{
+		DocumentID:    1102002020,
+		PublicationID: 67,
+		MepsLanguage:  0,
+		Title:         "Chapter 2: “This Is Our God”",
+	}, doc)
+
+	_, err = LookupDocument(path, Lookup{DocumentID: 1102002020, MepsLanguage: 1})
+	assert.Error(t, err)
+
+	doc, err = LookupDocument(path, Lookup{
+		DocumentID:        1102002020,
+		MepsLanguage:      1,
+		FallbackLanguages: []int{EnglishMepsLanguage},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 67, doc.PublicationID)
+
+	doc, err = LookupDocument(path, Lookup{DocumentID: 1102002021, MepsLanguage: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "Capítulo 3: “Santo, santo, santo es Jehová”", doc.Title)
+
+	_, err = LookupDocument(filepath.Join("testdata", "catalog.db"), Lookup{DocumentID: 1102002020})
+	assert.Equal(t, ErrNoDocumentTable, err)
+
+	_, err = LookupDocument("nonexistent.db", Lookup{DocumentID: 1102002020})
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = LookupDocumentContext(ctx, path, Lookup{DocumentID: 1102002020})
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+}
+
+func TestDocument_MarshalJSON(t *testing.T) {
+	doc := Document{
+		DocumentID:    1,
+		PublicationID: 2,
+		MepsLanguage:  3,
+		Title:         "4",
+	}
+
+	jsn, err := json.Marshal(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"documentId":1,"publicationId":2,"mepsLanguage":3,"title":"4"}`, string(jsn))
+}
@@ -0,0 +1,54 @@
+package publication
+
+import (
+	_ "embed" // Needed for embedding the offline catalog
+	"encoding/json"
+	"sync"
+)
+
+// offlineCatalogJSON contains a minimal catalog with the names of Bible
+// books and the titles of the most common publications, so entries can be
+// rendered human-readable even if no catalogDB has been downloaded yet.
+//
+//go:embed offline_catalog.json
+var offlineCatalogJSON []byte
+
+type offlineCatalog struct {
+	BibleBooks   []string          `json:"bibleBooks"`
+	Publications map[string]string `json:"publications"`
+}
+
+var (
+	offline     offlineCatalog
+	offlineOnce sync.Once
+)
+
+// loadOfflineCatalog unmarshals the embedded offline catalog once
+func loadOfflineCatalog() offlineCatalog {
+	offlineOnce.Do(func() {
+		if err := json.Unmarshal(offlineCatalogJSON, &offline); err != nil {
+			panic(err)
+		}
+	})
+	return offline
+}
+
+// BibleBookName returns the English name of the Bible book with the given
+// number (1 = Genesis, 66 = Revelation). If the number is out of range, it
+// returns false.
+func BibleBookName(bookNumber int) (string, bool) {
+	books := loadOfflineCatalog().BibleBooks
+	if bookNumber < 1 || bookNumber > len(books) {
+		return "", false
+	}
+
+	return books[bookNumber-1], true
+}
+
+// OfflinePublicationTitle returns the English title of the publication with
+// the given KeySymbol from the embedded offline catalog. Only the most common
+// publications are included, so if it can't be found, it returns false.
+func OfflinePublicationTitle(keySymbol string) (string, bool) {
+	title, ok := loadOfflineCatalog().Publications[keySymbol]
+	return title, ok
+}
@@ -0,0 +1,43 @@
+package publication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBibleBookName(t *testing.T) {
+	var tests = []struct {
+		input    int
+		expected string
+		ok       bool
+	}{
+		{1, "Genesis", true},
+		{19, "Psalms", true},
+		{40, "Matthew", true},
+		{66, "Revelation", true},
+		{0, "", false},
+		{67, "", false},
+		{-1, "", false},
+	}
+
+	for _, test := range tests {
+		name, ok := BibleBookName(test.input)
+		assert.Equal(t, test.expected, name)
+		assert.Equal(t, test.ok, ok)
+	}
+}
+
+func TestOfflinePublicationTitle(t *testing.T) {
+	title, ok := OfflinePublicationTitle("nwtsty")
+	assert.True(t, ok)
+	assert.Equal(t, "New World Translation of the Holy Scriptures (Study Edition)", title)
+
+	title, ok = OfflinePublicationTitle("cl")
+	assert.True(t, ok)
+	assert.Equal(t, "Draw Close to Jehovah", title)
+
+	title, ok = OfflinePublicationTitle("nonexistent")
+	assert.False(t, ok)
+	assert.Equal(t, "", title)
+}
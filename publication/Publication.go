@@ -1,15 +1,15 @@
 package publication
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/AndreasSko/go-jwlm/sqlite"
 	"github.com/pkg/errors"
-
-	// Register SQLite driver
-	_ "github.com/mattn/go-sqlite3"
 )
 
 // Publication represents a publication with all
@@ -32,6 +32,10 @@ type Publication struct {
 	Reserved              int
 }
 
+// EnglishMepsLanguage is the MepsLanguage of English publications, which
+// are the most likely ones to exist in the catalogDB.
+const EnglishMepsLanguage = 0
+
 // Lookup represents a lookup for a publication.
 // This query can contain various fields.
 type Lookup struct {
@@ -39,42 +43,147 @@ type Lookup struct {
 	KeySymbol      string
 	IssueTagNumber int
 	MepsLanguage   int
+	// FallbackLanguages are tried in the given order if no publication
+	// could be found for MepsLanguage.
+	FallbackLanguages []int
 }
 
-// LookupPublication looks up a publication from catalogDB located at dbPath
+// LookupPublication looks up a publication from catalogDB located at dbPath.
+// If it can't be found for the given MepsLanguage, the FallbackLanguages
+// of the query are tried.
 func LookupPublication(dbPath string, query Lookup) (Publication, error) {
+	return LookupPublicationContext(context.Background(), dbPath, query)
+}
+
+// LookupPublicationContext is like LookupPublication, but allows to cancel
+// the lookup using the given context.
+func LookupPublicationContext(ctx context.Context, dbPath string, query Lookup) (Publication, error) {
+	db, err := openCatalog(dbPath)
+	if err != nil {
+		return Publication{}, err
+	}
+	defer db.Close()
+
+	return lookupPublicationWithFallback(ctx, db, query)
+}
+
+// Search searches the catalogDB located at dbPath for publications whose
+// titles contain the given term. The results are sorted by their title.
+func Search(dbPath string, term string) ([]Publication, error) {
+	return SearchContext(context.Background(), dbPath, term)
+}
+
+// SearchContext is like Search, but allows to cancel the search
+// using the given context.
+func SearchContext(ctx context.Context, dbPath string, term string) ([]Publication, error) {
+	db, err := openCatalog(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return search(ctx, db, term)
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns,
+// so search terms like "100%" are matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func search(ctx context.Context, db *sql.DB, term string) ([]Publication, error) {
+	stmt, err := db.PrepareContext(ctx, "SELECT * FROM Publication "+
+		`WHERE Title LIKE ? ESCAPE '\' OR ShortTitle LIKE ? ESCAPE '\' `+
+		`OR IssueTitle LIKE ? ESCAPE '\' OR UndatedTitle LIKE ? ESCAPE '\' `+
+		"ORDER BY Title, IssueTagNumber, MepsLanguageId")
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while preparing query")
+	}
+	defer stmt.Close()
+
+	pattern := "%" + likeEscaper.Replace(term) + "%"
+	rows, err := stmt.QueryContext(ctx, pattern, pattern, pattern, pattern)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while searching for publications")
+	}
+	defer rows.Close()
+
+	result := []Publication{}
+	for rows.Next() {
+		publ, err := scanPublication(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error while scanning row for publication")
+		}
+		result = append(result, publ)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "Error while scanning rows for publications")
+	}
+
+	return result, nil
+}
+
+// openCatalog opens the catalogDB located at dbPath as immutable
+func openCatalog(dbPath string) (*sql.DB, error) {
 	// Check if file exists
 	if _, err := os.Stat(dbPath); err != nil {
-		return Publication{}, fmt.Errorf("CatalogDB does not exist at %s", dbPath)
+		return nil, fmt.Errorf("CatalogDB does not exist at %s", dbPath)
 	}
 
-	db, err := sql.Open("sqlite3", dbPath+"?immutable=1")
+	db, err := sqlite.OpenImmutable(dbPath)
 	if err != nil {
-		return Publication{}, errors.Wrap(err, "Error while opening SQLite database")
+		return nil, errors.Wrap(err, "Error while opening SQLite database")
+	}
+
+	return db, nil
+}
+
+// lookupPublicationWithFallback looks up a publication and retries the
+// lookup with the FallbackLanguages of the query if it could not be found.
+func lookupPublicationWithFallback(ctx context.Context, db *sql.DB, query Lookup) (Publication, error) {
+	publ, err := lookupPublication(ctx, db, query)
+	for _, lang := range query.FallbackLanguages {
+		if errors.Cause(err) != sql.ErrNoRows {
+			break
+		}
+		query.MepsLanguage = lang
+		publ, err = lookupPublication(ctx, db, query)
 	}
-	defer db.Close()
 
-	return lookupPublication(db, query)
+	return publ, err
 }
 
-func lookupPublication(db *sql.DB, query Lookup) (Publication, error) {
+func lookupPublication(ctx context.Context, db *sql.DB, query Lookup) (Publication, error) {
 	var row *sql.Row
 	if query.DocumentID != 0 {
-		stmt, err := db.Prepare("SELECT P.* " +
-			"FROM Publication AS P, PublicationDocument AS PD " +
+		stmt, err := db.PrepareContext(ctx, "SELECT P.* "+
+			"FROM Publication AS P, PublicationDocument AS PD "+
 			"WHERE P.Id = PD.PublicationId AND PD.DocumentId = ? AND P.MepsLanguageId = ?")
 		if err != nil {
 			return Publication{}, errors.Wrap(err, "Error while preparing query")
 		}
-		row = stmt.QueryRow(query.DocumentID, query.MepsLanguage)
+		row = stmt.QueryRowContext(ctx, query.DocumentID, query.MepsLanguage)
 	} else {
-		stmt, err := db.Prepare("SELECT * FROM Publication WHERE KeySymbol = ? AND MepsLanguageId = ? AND IssueTagNumber = ?")
+		stmt, err := db.PrepareContext(ctx, "SELECT * FROM Publication WHERE KeySymbol = ? AND MepsLanguageId = ? AND IssueTagNumber = ?")
 		if err != nil {
 			return Publication{}, errors.Wrap(err, "Error while preparing query")
 		}
-		row = stmt.QueryRow(query.KeySymbol, query.MepsLanguage, query.IssueTagNumber)
+		row = stmt.QueryRowContext(ctx, query.KeySymbol, query.MepsLanguage, query.IssueTagNumber)
+	}
+
+	publ, err := scanPublication(row)
+	if err != nil {
+		return Publication{}, errors.Wrap(err, "Error while scanning row for publication")
 	}
 
+	return publ, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPublication scans a row of the Publication table
+func scanPublication(row rowScanner) (Publication, error) {
 	publ := Publication{}
 	err := row.Scan(&publ.PublicationRootKeyID,
 		&publ.MepsLanguageID,
@@ -92,7 +201,7 @@ func lookupPublication(db *sql.DB, query Lookup) (Publication, error) {
 		&publ.Reserved,
 		&publ.ID)
 	if err != nil {
-		return Publication{}, errors.Wrap(err, "Error while scanning row for publication")
+		return Publication{}, err
 	}
 
 	return publ, nil
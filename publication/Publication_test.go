@@ -1,13 +1,17 @@
 package publication
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"io/ioutil"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/AndreasSko/go-jwlm/sqlite"
 	"github.com/DATA-DOG/go-sqlmock"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -127,6 +131,57 @@ func TestLookupPublication(t *testing.T) {
 				Reserved:              0,
 			},
 		},
+		{
+			input: Lookup{
+				DocumentID:        1102002020,
+				MepsLanguage:      1,
+				FallbackLanguages: []int{EnglishMepsLanguage},
+			},
+			expected: Publication{
+				ID:                    67,
+				PublicationRootKeyID:  64,
+				MepsLanguageID:        0,
+				PublicationTypeID:     2,
+				IssueTagNumber:        0,
+				Title:                 "Draw Close to Jehovah",
+				ShortTitle:            "Close to Jehovah",
+				UndatedReferenceTitle: sql.NullString{"Close to Jehovah", true},
+				Year:                  2014,
+				Symbol:                "cl",
+				KeySymbol:             sql.NullString{"cl", true},
+				Reserved:              0,
+			},
+		},
+		{
+			input: Lookup{
+				KeySymbol:         "cl",
+				MepsLanguage:      2,
+				FallbackLanguages: []int{1, EnglishMepsLanguage},
+			},
+			expected: Publication{
+				ID:                    129,
+				PublicationRootKeyID:  64,
+				MepsLanguageID:        1,
+				PublicationTypeID:     2,
+				IssueTagNumber:        0,
+				Title:                 "Acerquémonos a Jehová",
+				ShortTitle:            "Acerquémonos a Jehová",
+				UndatedReferenceTitle: sql.NullString{"Acerquémonos a Jehová", true},
+				Year:                  2014,
+				Symbol:                "cl",
+				KeySymbol:             sql.NullString{"cl", true},
+				Reserved:              0,
+			},
+		},
+		{
+			input: Lookup{
+				KeySymbol:         "nonexistent",
+				MepsLanguage:      1,
+				FallbackLanguages: []int{EnglishMepsLanguage},
+			},
+			expected:    Publication{},
+			expectError: true,
+		},
 	}
 
 	path := filepath.Join("testdata", "catalog.db")
@@ -182,7 +237,7 @@ func Test_lookupPublication(t *testing.T) {
 			publication.Reserved,
 			publication.ID))
 
-	res, err := lookupPublication(db, Lookup{DocumentID: 1})
+	res, err := lookupPublication(context.Background(), db, Lookup{DocumentID: 1})
 	assert.NoError(t, err)
 	assert.Equal(t, publication, res)
 
@@ -202,7 +257,7 @@ func Test_lookupPublication(t *testing.T) {
 			publication.KeySymbol,
 			publication.Reserved,
 			publication.ID))
-	res, err = lookupPublication(db, Lookup{KeySymbol: "KeySymbol"})
+	res, err = lookupPublication(context.Background(), db, Lookup{KeySymbol: "KeySymbol"})
 	assert.NoError(t, err)
 	assert.Equal(t, publication, res)
 }
@@ -232,3 +287,77 @@ func TestPublication_MarshalJSON(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expected, string(jsn))
 }
+
+func TestSearch(t *testing.T) {
+	path := filepath.Join("testdata", "catalog.db")
+
+	res, err := Search(path, "Jehovah")
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, 67, res[0].ID)
+	assert.Equal(t, 305097, res[1].ID)
+
+	res, err = Search(path, "acerquémonos")
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, 129, res[0].ID)
+
+	res, err = Search(path, "February 2021")
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, 305097, res[0].ID)
+
+	res, err = Search(path, "Pure Worship")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	_, err = Search("nonexistent.db", "Jehovah")
+	assert.Error(t, err)
+}
+
+func TestSearch_wildcards(t *testing.T) {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", "catalog.db"))
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	assert.NoError(t, ioutil.WriteFile(path, content, 0644))
+	db, err := sqlite.Open(path)
+	assert.NoError(t, err)
+	_, err = db.Exec(`UPDATE Publication SET Title = "Draw 100% Close to Jehovah", ShortTitle = "Close_to_Jehovah" WHERE Id = 67`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	// Wildcards of LIKE are matched literally
+	for _, term := range []string{"100%", "%", "_", "Close_to", `\`} {
+		res, err := Search(path, term)
+		assert.NoError(t, err)
+		if term == `\` {
+			assert.Empty(t, res)
+			continue
+		}
+		assert.Len(t, res, 1, term)
+		assert.Equal(t, 67, res[0].ID, term)
+	}
+
+	res, err := Search(path, "10_%")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func TestLookupPublicationContext(t *testing.T) {
+	path := filepath.Join("testdata", "catalog.db")
+
+	res, err := LookupPublicationContext(context.Background(), path, Lookup{KeySymbol: "cl"})
+	assert.NoError(t, err)
+	assert.Equal(t, 67, res.ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = LookupPublicationContext(ctx, path, Lookup{KeySymbol: "cl"})
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+	_, err = SearchContext(ctx, path, "Jehovah")
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+}
@@ -0,0 +1,518 @@
+package rules
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// token is a token of an expression
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenLiteral
+	tokenOperator
+)
+
+// operators are sorted so longer ones are matched first
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", ".", ","}
+
+// tokenize splits an expression into its tokens
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(expr) && (expr[i] == '_' || unicode.IsLetter(rune(expr[i])) || unicode.IsDigit(rune(expr[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[start:i]})
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(expr) && unicode.IsDigit(rune(expr[i+1]))):
+			start := i
+			i++
+			for i < len(expr) && (unicode.IsDigit(rune(expr[i])) || expr[i] == '.') {
+				i++
+			}
+			n, err := strconv.ParseFloat(expr[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %s", expr[start:i])
+			}
+			tokens = append(tokens, token{kind: tokenLiteral, text: expr[start:i], value: n})
+		case c == '\'' || c == '"':
+			var s strings.Builder
+			start := i
+			for i++; ; i++ {
+				if i >= len(expr) {
+					return nil, fmt.Errorf("unterminated string %s", expr[start:])
+				}
+				if expr[i] == '\\' && i+1 < len(expr) {
+					i++
+					s.WriteByte(expr[i])
+					continue
+				}
+				if rune(expr[i]) == c {
+					i++
+					break
+				}
+				s.WriteByte(expr[i])
+			}
+			tokens = append(tokens, token{kind: tokenLiteral, text: expr[start:i], value: s.String()})
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+// env is what an expression is evaluated against
+type env struct {
+	table string
+	left  interface{}
+	right interface{}
+}
+
+// node is a node of a parsed expression
+type node interface {
+	eval(e env) (interface{}, error)
+}
+
+// parser is a recursive descent parser for expressions
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the given operator
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		return fmt.Errorf("expected %s, got %s", op, describe(p.peek()))
+	}
+	return nil
+}
+
+// describe returns a token in a form suitable for error messages
+func describe(t token) string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// parseExpr parses expr := and ("||" and)*
+func (p *parser) parseExpr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses and := not ("&&" not)*
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = logicalNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseNot parses not := "!" not | comparison
+func (p *parser) parseNot() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses comparison := primary [op primary]
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return left, nil
+	}
+	switch t.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{op: t.text, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+// parsePrimary parses literals, paths like left.Title,
+// function calls, and expressions in parentheses
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLiteral:
+		return literalNode{value: t.value}, nil
+	case tokenOperator:
+		if t.text == "(" {
+			n, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		}
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literalNode{value: true}, nil
+		case "false":
+			return literalNode{value: false}, nil
+		case "null":
+			return literalNode{value: nil}, nil
+		case "table":
+			return tableNode{}, nil
+		case "left", "right":
+			path := pathNode{side: t.text}
+			for p.accept(".") {
+				field := p.next()
+				if field.kind != tokenIdent {
+					return nil, fmt.Errorf("expected field name, got %s", describe(field))
+				}
+				path.fields = append(path.fields, field.text)
+			}
+			return path, nil
+		}
+		if fn, ok := functions[t.text]; ok {
+			return p.parseCall(t.text, fn)
+		}
+		return nil, fmt.Errorf("unknown identifier %s", t.text)
+	}
+	return nil, fmt.Errorf("unexpected %s", describe(t))
+}
+
+// parseCall parses the arguments of a function call
+func (p *parser) parseCall(name string, fn function) (node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	call := callNode{name: name, fn: fn}
+	if !p.accept(")") {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.accept(")") {
+				break
+			}
+			if !p.accept(",") {
+				return nil, fmt.Errorf("expected , or ), got %s", describe(p.peek()))
+			}
+		}
+	}
+	if len(call.args) != fn.args {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", name, fn.args, len(call.args))
+	}
+	return call, nil
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(e env) (interface{}, error) {
+	return n.value, nil
+}
+
+// tableNode is the name of the table of the conflicting entries
+type tableNode struct{}
+
+func (n tableNode) eval(e env) (interface{}, error) {
+	return e.table, nil
+}
+
+// pathNode is a field of the left or the right side,
+// which can be nested like left.UserMark.ColorIndex
+type pathNode struct {
+	side   string
+	fields []string
+}
+
+func (n pathNode) eval(e env) (interface{}, error) {
+	v := reflect.ValueOf(e.left)
+	if n.side == "right" {
+		v = reflect.ValueOf(e.right)
+	}
+	path := n.side
+	for _, field := range n.fields {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, nil
+			}
+			v = v.Elem()
+		}
+		if !v.IsValid() {
+			return nil, nil
+		}
+		if v.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s has no field %s", path, field)
+		}
+		f := v.FieldByName(field)
+		if !f.IsValid() || !f.CanInterface() {
+			return nil, fmt.Errorf("%s %s has no field %s", e.table, path, field)
+		}
+		v = f
+		path += "." + field
+	}
+	return toValue(v, path)
+}
+
+// toValue converts a field into a value of the expression language,
+// which is either nil, a bool, a float64, or a string
+func toValue(v reflect.Value, path string) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		// Null types of database/sql, like sql.NullString
+		value, err := valuer.Value()
+		if err != nil || value == nil {
+			return nil, err
+		}
+		return toValue(reflect.ValueOf(value), path)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice:
+		// Only the length of slices (like BlockRanges) can be used
+		return float64(v.Len()), nil
+	}
+	return nil, fmt.Errorf("%s can't be used in expressions", path)
+}
+
+type logicalNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n logicalNode) eval(e env) (interface{}, error) {
+	left, err := evalBool(n.left, e)
+	if err != nil {
+		return nil, err
+	}
+	if (n.op == "&&" && !left) || (n.op == "||" && left) {
+		return left, nil
+	}
+	return evalBool(n.right, e)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(e env) (interface{}, error) {
+	b, err := evalBool(n.operand, e)
+	return !b, err
+}
+
+// evalBool evaluates the node and makes sure it results in a bool
+func evalBool(n node, e env) (bool, error) {
+	v, err := n.eval(e)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a boolean, got %v", v)
+	}
+	return b, nil
+}
+
+type compareNode struct {
+	op    string
+	left  node
+	right node
+}
+
+// eval compares both operands. Only numbers and strings can be ordered,
+// and ordering something with null is always false, like in SQL.
+func (n compareNode) eval(e env) (interface{}, error) {
+	left, err := n.left.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	}
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return nil, fmt.Errorf("can't compare %v with %v", left, right)
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("can't compare %q with %v", left, right)
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return nil, fmt.Errorf("can't order %v", left)
+	}
+
+	switch n.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	}
+	return cmp >= 0, nil
+}
+
+// function is a function that can be called within expressions
+type function struct {
+	args int
+	call func(args []interface{}) (interface{}, error)
+}
+
+var functions = map[string]function{
+	// len returns the length of a string, or 0 for null
+	"len": {args: 1, call: func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case nil:
+			return float64(0), nil
+		case string:
+			return float64(len([]rune(v))), nil
+		}
+		return nil, fmt.Errorf("len expects a string, got %v", args[0])
+	}},
+	// contains checks if the first string contains the second one
+	"contains": {args: 2, call: func(args []interface{}) (interface{}, error) {
+		s, _ := args[0].(string)
+		substr, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("contains expects a string, got %v", args[1])
+		}
+		return strings.Contains(s, substr), nil
+	}},
+}
+
+type callNode struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (n callNode) eval(e env) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		var err error
+		if args[i], err = arg.eval(e); err != nil {
+			return nil, err
+		}
+	}
+	return n.fn.call(args)
+}
@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func evalExpr(t *testing.T, expr string, e env) (interface{}, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		t.Fatalf("unexpected token %s in %s", tok.text, expr)
+	}
+	return n.eval(e)
+}
+
+func Test_eval(t *testing.T) {
+	e := env{
+		table: "Note",
+		left: &model.Note{
+			NoteID:       1,
+			Title:        sql.NullString{String: "Faith", Valid: true},
+			Content:      sql.NullString{String: "Hebrews 11:1", Valid: true},
+			LastModified: "2021-05-01T10:00:00+00:00",
+			LocationID:   sql.NullInt32{Int32: 3, Valid: true},
+		},
+		right: &model.Note{
+			NoteID:       2,
+			LastModified: "2021-04-01T10:00:00+00:00",
+		},
+	}
+
+	tests := map[string]interface{}{
+		`table == 'Note'`:                               true,
+		`table == "Bookmark"`:                           false,
+		`left.LastModified > right.LastModified`:        true,
+		`left.LastModified <= right.LastModified`:       false,
+		`left.NoteID < right.NoteID && left.NoteID > 0`: true,
+		`left.NoteID >= -1`:                             true,
+		`left.LocationID == 3`:                          true,
+		`right.LocationID == null`:                      true,
+		`right.LocationID < 3 || right.LocationID >= 3`: false,
+		`!(right.Title != null)`:                        true,
+		`left.Title == 'It\'s'`:                         false,
+		`len(left.Content) > len(right.Content)`:        true,
+		`contains(left.Content, "Hebrews")`:             true,
+		`contains(right.Content, "Hebrews")`:            false,
+		`true && !false`:                                true,
+		`left.NoteID`:                                   float64(1),
+	}
+	for expr, expected := range tests {
+		result, err := evalExpr(t, expr, e)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, expected, result, expr)
+	}
+
+	// Nested fields and slices
+	umbr := env{
+		table: "UserMarkBlockRange",
+		left: &model.UserMarkBlockRange{
+			UserMark:    &model.UserMark{ColorIndex: 2},
+			BlockRanges: []*model.BlockRange{{}, {}},
+		},
+		right: &model.UserMarkBlockRange{},
+	}
+	result, err := evalExpr(t, `left.UserMark.ColorIndex == 2 && left.BlockRanges == 2`, umbr)
+	assert.NoError(t, err)
+	assert.Equal(t, true, result)
+	result, err = evalExpr(t, `right.UserMark.ColorIndex`, umbr)
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+
+	errs := []string{
+		`left.Unknown == 1`,
+		`left.Title.Length == 1`,
+		`left.NoteID < 'a'`,
+		`'a' < 1`,
+		`true < false`,
+		`left.NoteID && true`,
+		`!left.Title`,
+		`len(left.NoteID)`,
+		`contains(left.Title, 1)`,
+		`unknown == 1`,
+		`(table == 'Note'`,
+		`table ==`,
+		`len(1, 2)`,
+		`left.`,
+		`'unterminated`,
+		`table = 'Note'`,
+	}
+	for _, expr := range errs {
+		_, err := evalExpr(t, expr, e)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,175 @@
+// Package rules solves merge conflicts using rules written in a small
+// expression language, so users can codify their resolution policy once:
+//
+//	# Keep the newer note
+//	if table == 'Note' && left.LastModified > right.LastModified then left
+//	table == 'Note' then right
+//
+// Every rule consists of a condition and the side to choose if it holds.
+// The first rule whose condition holds for a conflict decides it.
+package rules
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+)
+
+// Rule chooses a side for the conflicts its condition holds for
+type Rule struct {
+	// Line is the line of the rule within its rules file
+	Line      int
+	Condition string
+	Side      merger.MergeSide
+	condition node
+}
+
+// RuleSet is a list of rules, which are evaluated in order
+type RuleSet struct {
+	Rules []Rule
+}
+
+// ParseFile parses the rules of the given file (see Parse)
+func ParseFile(filename string) (*RuleSet, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading rules: %w", err)
+	}
+	rs, err := Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return rs, nil
+}
+
+// Parse parses rules, one per line, in the form
+// `[if] <condition> then left|right`. Empty lines
+// and lines starting with # are ignored.
+//
+// Conditions can compare the fields of both sides (like left.Title or
+// left.UserMark.ColorIndex) and table, which is the name of the model
+// of the conflicting entries (like Note, Bookmark, or UserMarkBlockRange).
+// They support ==, !=, <, <=, >, >=, &&, ||, !, and parentheses, as well as
+// strings in single or double quotes, numbers, true, false, and null.
+// Fields that are NULL in the database are null. The functions len(s) and
+// contains(s, substr) can be used for strings.
+func Parse(src string) (*RuleSet, error) {
+	rs := &RuleSet{}
+	for i, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := parseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		rule.Line = i + 1
+		rs.Rules = append(rs.Rules, rule)
+	}
+	return rs, nil
+}
+
+// parseRule parses a single rule
+func parseRule(line string) (Rule, error) {
+	tokens, err := tokenize(line)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	// A rule ends with "then left" or "then right"
+	n := len(tokens) - 1 // Without EOF
+	if n < 3 || tokens[n-2].kind != tokenIdent || tokens[n-2].text != "then" || tokens[n-1].kind != tokenIdent {
+		return Rule{}, fmt.Errorf("rule should end with 'then left' or 'then right'")
+	}
+	var side merger.MergeSide
+	switch tokens[n-1].text {
+	case "left":
+		side = merger.LeftSide
+	case "right":
+		side = merger.RightSide
+	default:
+		return Rule{}, fmt.Errorf("rule should end with 'then left' or 'then right', not 'then %s'", tokens[n-1].text)
+	}
+
+	condTokens := append(tokens[:n-2:n-2], token{kind: tokenEOF})
+	if condTokens[0].kind == tokenIdent && condTokens[0].text == "if" {
+		condTokens = condTokens[1:]
+	}
+	p := &parser{tokens: condTokens}
+	cond, err := p.parseExpr()
+	if err != nil {
+		return Rule{}, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return Rule{}, fmt.Errorf("unexpected %s", describe(t))
+	}
+
+	text := strings.TrimSpace(line[:strings.LastIndex(line, "then")])
+	text = strings.TrimSpace(strings.TrimPrefix(text, "if "))
+	return Rule{Condition: text, Side: side, condition: cond}, nil
+}
+
+// Match returns the first rule whose condition holds for
+// the conflict, or nil if there is none
+func (rs *RuleSet) Match(conflict merger.MergeConflict) (*Rule, error) {
+	e := env{table: tableName(conflict.Left), left: conflict.Left, right: conflict.Right}
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		holds, err := evalBool(rule.condition, e)
+		if err != nil {
+			return nil, fmt.Errorf("Error while evaluating rule in line %d: %w", rule.Line, err)
+		}
+		if holds {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// Resolve solves the conflicts using the rules. Conflicts no rule
+// matches are left unresolved, so they can be solved otherwise.
+func (rs *RuleSet) Resolve(conflicts map[string]merger.MergeConflict) (map[string]merger.MergeSolution, error) {
+	keys := make([]string, 0, len(conflicts))
+	for key := range conflicts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	solutions := map[string]merger.MergeSolution{}
+	for _, key := range keys {
+		conflict := conflicts[key]
+		rule, err := rs.Match(conflict)
+		if err != nil {
+			return nil, fmt.Errorf("Error while resolving conflict %s: %w", key, err)
+		}
+		if rule == nil {
+			continue
+		}
+
+		if rule.Side == merger.LeftSide {
+			solutions[key] = merger.MergeSolution{Side: merger.LeftSide, Solution: conflict.Left, Discarded: conflict.Right}
+		} else {
+			solutions[key] = merger.MergeSolution{Side: merger.RightSide, Solution: conflict.Right, Discarded: conflict.Left}
+		}
+	}
+	return solutions, nil
+}
+
+// tableName returns the name of the model, like Note
+func tableName(m model.Model) string {
+	if m == nil {
+		return ""
+	}
+	t := reflect.TypeOf(m)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
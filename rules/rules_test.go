@@ -0,0 +1,112 @@
+package rules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AndreasSko/go-jwlm/merger"
+	"github.com/AndreasSko/go-jwlm/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRules = `
+# Prefer the newer note
+if table == 'Note' && left.LastModified > right.LastModified then left
+table == 'Note' && left.LastModified < right.LastModified then right
+
+if table == "Bookmark" then right
+`
+
+func TestParse(t *testing.T) {
+	rs, err := Parse(testRules)
+	assert.NoError(t, err)
+	assert.Len(t, rs.Rules, 3)
+	assert.Equal(t, 3, rs.Rules[0].Line)
+	assert.Equal(t, "table == 'Note' && left.LastModified > right.LastModified", rs.Rules[0].Condition)
+	assert.Equal(t, merger.LeftSide, rs.Rules[0].Side)
+	assert.Equal(t, 4, rs.Rules[1].Line)
+	assert.Equal(t, merger.RightSide, rs.Rules[1].Side)
+	assert.Equal(t, `table == "Bookmark"`, rs.Rules[2].Condition)
+
+	rs, err = Parse("")
+	assert.NoError(t, err)
+	assert.Empty(t, rs.Rules)
+
+	for src, msg := range map[string]string{
+		"table == 'Note'":                    "line 1: rule should end with 'then left' or 'then right'",
+		"\ntable == 'Note' then middle":      "line 2: rule should end with 'then left' or 'then right', not 'then middle'",
+		"then left":                          "line 1: rule should end with 'then left' or 'then right'",
+		"if then left":                       "line 1: unexpected end of expression",
+		"table == 'Note' table then left":    "line 1: unexpected \"table\"",
+		"table == 'Note' && then left":       "line 1: unexpected end of expression",
+		"left.Title == 'a then left":         "line 1: unterminated string 'a then left",
+		"left.Title ~ 'a' then left":         "line 1: unexpected character '~'",
+		"contains(left.Title) then left":     "line 1: contains expects 2 arguments, got 1",
+		"contains(left.Title, 'a' then left": "line 1: expected , or ), got end of expression",
+	} {
+		_, err := Parse(src)
+		assert.EqualError(t, err, msg, src)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "go-jwlm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	filename := filepath.Join(tmp, "rules.txt")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(testRules), 0644))
+	rs, err := ParseFile(filename)
+	assert.NoError(t, err)
+	assert.Len(t, rs.Rules, 3)
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("table then"), 0644))
+	_, err = ParseFile(filename)
+	assert.EqualError(t, err, filename+": line 1: rule should end with 'then left' or 'then right'")
+
+	_, err = ParseFile(filepath.Join(tmp, "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestRuleSet_Resolve(t *testing.T) {
+	rs, err := Parse(testRules)
+	assert.NoError(t, err)
+
+	newer := &model.Note{NoteID: 1, GUID: "a", LastModified: "2021-05-01T10:00:00+00:00"}
+	older := &model.Note{NoteID: 2, GUID: "a", LastModified: "2021-04-01T10:00:00+00:00"}
+	same := &model.Note{NoteID: 3, GUID: "b", LastModified: "2021-04-01T10:00:00+00:00"}
+	leftBookmark := &model.Bookmark{BookmarkID: 1, Slot: 1}
+	rightBookmark := &model.Bookmark{BookmarkID: 2, Slot: 1}
+	leftMarking := &model.UserMarkBlockRange{UserMark: &model.UserMark{UserMarkID: 1}}
+	rightMarking := &model.UserMarkBlockRange{UserMark: &model.UserMark{UserMarkID: 2}}
+
+	conflicts := map[string]merger.MergeConflict{
+		"newerLeft":  {Left: newer, Right: older},
+		"newerRight": {Left: older, Right: newer},
+		"same":       {Left: same, Right: older},
+		"bookmark":   {Left: leftBookmark, Right: rightBookmark},
+		"marking":    {Left: leftMarking, Right: rightMarking},
+	}
+	solutions, err := rs.Resolve(conflicts)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]merger.MergeSolution{
+		"newerLeft":  {Side: merger.LeftSide, Solution: newer, Discarded: older},
+		"newerRight": {Side: merger.RightSide, Solution: newer, Discarded: older},
+		"bookmark":   {Side: merger.RightSide, Solution: rightBookmark, Discarded: leftBookmark},
+	}, solutions)
+
+	rule, err := rs.Match(conflicts["newerRight"])
+	assert.NoError(t, err)
+	assert.Equal(t, 4, rule.Line)
+	rule, err = rs.Match(conflicts["marking"])
+	assert.NoError(t, err)
+	assert.Nil(t, rule)
+
+	// Errors while evaluating are reported with the rule and the conflict
+	rs, err = Parse("left.LastModified > right.LastModified then left")
+	assert.NoError(t, err)
+	_, err = rs.Resolve(map[string]merger.MergeConflict{"bookmark": conflicts["bookmark"]})
+	assert.EqualError(t, err, "Error while resolving conflict bookmark: Error while evaluating rule in line 1: Bookmark left has no field LastModified")
+}
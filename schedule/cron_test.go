@@ -0,0 +1,60 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// A Friday
+	now := time.Date(2021, 5, 7, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2021, 5, 7, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 5, 7, 10, 45, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2021, 5, 8, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 5, 8, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2021, 5, 7, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2021, 5, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 5, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * mon-fri", time.Date(2021, 5, 7, 13, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2021, 5, 8, 10, 30, 0, 0, time.UTC)},
+		{"0 0 1,15 feb *", time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// Either the day of month or the day of week has to match
+		{"0 0 13 * mon", time.Date(2021, 5, 10, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.expr)
+		assert.NoError(t, err, test.expr)
+		assert.Equal(t, test.expected, schedule.Next(now), test.expr)
+	}
+}
+
+func TestParse_invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@every",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !modernc && !nocgo
+// +build !modernc,!nocgo
+
+package sqlite
+
+import (
+	// Register SQLite driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const driverName = "sqlite3"
+
+func unsyncedDSN(path string) string {
+	return path + "?_sync=OFF&_journal_mode=MEMORY"
+}
@@ -0,0 +1,15 @@
+//go:build modernc || nocgo
+// +build modernc nocgo
+
+package sqlite
+
+import (
+	// Register SQLite driver
+	_ "modernc.org/sqlite"
+)
+
+const driverName = "sqlite"
+
+func unsyncedDSN(path string) string {
+	return path + "?_pragma=synchronous(OFF)&_pragma=journal_mode(MEMORY)"
+}
@@ -0,0 +1,41 @@
+// Package sqlite opens SQLite databases using the database/sql driver
+// selected at build time. By default, mattn/go-sqlite3 is used, which
+// requires cgo. Building with the modernc or nocgo tag uses
+// modernc.org/sqlite instead, which is written in pure Go and therefore
+// allows to cross-compile go-jwlm without a C toolchain.
+package sqlite
+
+import (
+	"database/sql"
+	"net/url"
+)
+
+// DriverName is the name of the database/sql driver that is used
+const DriverName = driverName
+
+// Open opens the SQLite database at path
+func Open(path string) (*sql.DB, error) {
+	return sql.Open(driverName, path)
+}
+
+// OpenImmutable opens the SQLite database at path as immutable, which
+// avoids locks and therefore speeds up reading. The database must not
+// be changed while it is open.
+func OpenImmutable(path string) (*sql.DB, error) {
+	return sql.Open(driverName, immutableDSN(path))
+}
+
+// immutableDSN returns the DSN for opening path as read-only and
+// immutable. Both drivers only pass these parameters on to SQLite
+// if the DSN is a file: URI.
+func immutableDSN(path string) string {
+	return "file:" + url.PathEscape(path) + "?mode=ro&immutable=1"
+}
+
+// OpenUnsynced opens the SQLite database at path without syncing to
+// disk and with the journal kept in memory. This speeds up writing, but
+// the database might get corrupted on a crash, so it should only be used
+// for new databases that can be recreated.
+func OpenUnsynced(path string) (*sql.DB, error) {
+	return sql.Open(driverName, unsyncedDSN(path))
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := OpenUnsynced(path)
+	assert.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE Tag (TagId INTEGER, Name TEXT);
+		INSERT INTO Tag VALUES (1, "Favorite");`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	db, err = Open(path)
+	assert.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO Tag VALUES (2, "Study")`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	db, err = OpenImmutable(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM Tag").Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestOpenImmutable_specialCharacters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a backup #1.db")
+
+	db, err := Open(path)
+	assert.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE Tag (TagId INTEGER)`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	db, err = OpenImmutable(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM Tag").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestOpenImmutable_readOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := Open(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE Tag (TagId INTEGER)`)
+	assert.NoError(t, err)
+
+	// Immutable databases are read without taking any lock
+	conn, err := db.Conn(context.Background())
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(context.Background(), `BEGIN EXCLUSIVE; INSERT INTO Tag VALUES (1)`)
+	assert.NoError(t, err)
+	defer conn.ExecContext(context.Background(), `ROLLBACK`)
+
+	immutable, err := OpenImmutable(path)
+	assert.NoError(t, err)
+	defer immutable.Close()
+	var count int
+	assert.NoError(t, immutable.QueryRow("SELECT COUNT(*) FROM Tag").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	_, err = immutable.Exec(`INSERT INTO Tag VALUES (2)`)
+	assert.Error(t, err)
+}
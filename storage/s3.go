@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	s3DefaultRegion  = "us-east-1"
+	s3EmptyHash      = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3UnsignedHash   = "UNSIGNED-PAYLOAD"
+	s3DateFormat     = "20060102T150405Z"
+	s3SigningService = "s3"
+)
+
+// now returns the current time and is used to sign S3 requests
+var now = time.Now
+
+// s3 is a Backend for S3-compatible object storages. URLs have the form
+// s3://bucket/key. The credentials are read from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, and (optional) AWS_SESSION_TOKEN environment
+// variables. To use other S3-compatible storages than AWS (like MinIO),
+// set AWS_ENDPOINT_URL. AWS_REGION defaults to us-east-1.
+type s3 struct{}
+
+// s3Config contains the settings needed for accessing an S3 storage
+type s3Config struct {
+	endpoint     *url.URL
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+// Download writes the content of the object at the given URL to w
+func (s *s3) Download(u *url.URL, w io.Writer) error {
+	req, err := s.request(http.MethodGet, u, nil, s3EmptyHash)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// Upload stores size bytes read from r as object at the given URL
+func (s *s3) Upload(u *url.URL, r io.Reader, size int64) error {
+	req, err := s.request(http.MethodPut, u, r, s3UnsignedHash)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// request creates a signed request for the object at the given s3:// URL,
+// using path-style addressing (endpoint/bucket/key).
+func (s *s3) request(method string, u *url.URL, body io.Reader, payloadHash string) (*http.Request, error) {
+	cfg, err := s3ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	target := *cfg.endpoint
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + u.Host + u.Path
+	req, err := http.NewRequest(method, target.String(), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while creating request")
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if cfg.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", cfg.sessionToken)
+	}
+	signS3Request(req, cfg, payloadHash, now())
+	return req, nil
+}
+
+// s3ConfigFromEnv reads the S3 settings from the environment
+func s3ConfigFromEnv() (*s3Config, error) {
+	cfg := &s3Config{
+		region:       os.Getenv("AWS_REGION"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if cfg.accessKey == "" || cfg.secretKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY need to be set for accessing S3")
+	}
+	if cfg.region == "" {
+		cfg.region = s3DefaultRegion
+	}
+
+	endpoint := os.Getenv("AWS_ENDPOINT_URL")
+	if endpoint == "" {
+		endpoint = "https://s3." + cfg.region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error while parsing AWS_ENDPOINT_URL")
+	}
+	cfg.endpoint = u
+	return cfg, nil
+}
+
+// signS3Request adds an AWS Signature Version 4 to the given request,
+// signing the host and all headers that have been set so far.
+func signS3Request(req *http.Request, cfg *s3Config, payloadHash string, t time.Time) {
+	amzDate := t.UTC().Format(s3DateFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + cfg.region + "/" + s3SigningService + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+cfg.secretKey), date)
+	key = hmacSHA256(key, cfg.region)
+	key = hmacSHA256(key, s3SigningService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+cfg.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// s3EscapePath URI-encodes every segment of the given path as
+// required for the canonical request of AWS Signature Version 4.
+func s3EscapePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	var sb strings.Builder
+	for _, b := range []byte(path) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/':
+			sb.WriteByte(b)
+		default:
+			sb.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{b})))
+		}
+	}
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,123 @@
+// Package storage allows to download backups from and upload them to
+// remote storages, like WebDAV servers (e.g. Nextcloud) or S3-compatible
+// object storages.
+package storage
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Backend is a remote storage that backups can be downloaded
+// from and uploaded to.
+type Backend interface {
+	// Download writes the content of the file at the given URL to w
+	Download(u *url.URL, w io.Writer) error
+	// Upload stores size bytes read from r at the given URL
+	Upload(u *url.URL, r io.Reader, size int64) error
+}
+
+// backends contains the supported backends, indexed by their URL scheme
+var backends = map[string]Backend{
+	"dav":  &webDAV{secure: false},
+	"davs": &webDAV{secure: true},
+	"s3":   &s3{},
+}
+
+// httpClient is the client used for all requests to remote storages
+var httpClient = http.DefaultClient
+
+// IsRemote checks if the given path is a URL pointing to a supported
+// remote storage, like dav://, davs://, or s3://.
+func IsRemote(path string) bool {
+	_, _, err := parse(path)
+	return err == nil
+}
+
+// Download downloads the file at the given URL into dir and returns the
+// path of the downloaded file. The file keeps the name it has within
+// the URL.
+func Download(remote string, dir string) (string, error) {
+	u, backend, err := parse(remote)
+	if err != nil {
+		return "", err
+	}
+
+	filename := filepath.Join(dir, path.Base(u.Path))
+	f, err := os.Create(filename)
+	if err != nil {
+		return "", errors.Wrap(err, "Error while creating file for download")
+	}
+	defer f.Close()
+
+	if err := backend.Download(u, f); err != nil {
+		return "", errors.Wrapf(err, "Error while downloading %s", redact(u))
+	}
+	return filename, f.Close()
+}
+
+// Upload uploads the given file to the given URL
+func Upload(filename string, remote string) error {
+	u, backend, err := parse(remote)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return errors.Wrap(err, "Error while opening file for upload")
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return errors.Wrap(err, "Error while opening file for upload")
+	}
+
+	if err := backend.Upload(u, f, stat.Size()); err != nil {
+		return errors.Wrapf(err, "Error while uploading to %s", redact(u))
+	}
+	return nil
+}
+
+// parse parses the given URL and returns the Backend responsible for it
+func parse(remote string) (*url.URL, Backend, error) {
+	u, err := url.Parse(remote)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Error while parsing URL")
+	}
+	backend, ok := backends[u.Scheme]
+	if !ok {
+		return nil, nil, errors.Errorf("Storage %s is not supported", u.Scheme)
+	}
+	if u.Host == "" || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return nil, nil, errors.Errorf("URL %s needs to contain a host and a path", redact(u))
+	}
+	return u, backend, nil
+}
+
+// redact returns the given URL without its password, so it can
+// be included in error messages.
+func redact(u *url.URL) string {
+	if _, ok := u.User.Password(); !ok {
+		return u.String()
+	}
+	redacted := *u
+	redacted.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return redacted.String()
+}
+
+// checkResponse returns an error if the given response does not
+// indicate success, closing its body in that case.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	resp.Body.Close()
+	return errors.Errorf("Server responded with %s", resp.Status)
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newWebDAVServer(t *testing.T, files map[string][]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			content, ok := files[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(content)
+		case http.MethodPut:
+			content, _ := ioutil.ReadAll(r.Body)
+			files[r.URL.Path] = content
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+}
+
+func Test_webDAV(t *testing.T) {
+	files := map[string][]byte{"/dav/backup.jwlibrary": []byte("backup")}
+	server := newWebDAVServer(t, files)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	backend := &webDAV{}
+	u, _ := url.Parse("dav://user:secret@" + host + "/dav/backup.jwlibrary")
+	var buf bytes.Buffer
+	assert.NoError(t, backend.Download(u, &buf))
+	assert.Equal(t, "backup", buf.String())
+
+	u, _ = url.Parse("dav://user:secret@" + host + "/dav/merged.jwlibrary")
+	assert.NoError(t, backend.Upload(u, strings.NewReader("merged"), 6))
+	assert.Equal(t, []byte("merged"), files["/dav/merged.jwlibrary"])
+
+	u, _ = url.Parse("dav://" + host + "/dav/backup.jwlibrary")
+	assert.EqualError(t, backend.Download(u, &buf), "Server responded with 401 Unauthorized")
+
+	os.Setenv("WEBDAV_USERNAME", "user")
+	defer os.Unsetenv("WEBDAV_USERNAME")
+	os.Setenv("WEBDAV_PASSWORD", "secret")
+	defer os.Unsetenv("WEBDAV_PASSWORD")
+	buf.Reset()
+	assert.NoError(t, backend.Download(u, &buf))
+	assert.Equal(t, "backup", buf.String())
+
+	req, err := (&webDAV{secure: true}).request(http.MethodGet, u, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "https", req.URL.Scheme)
+}